@@ -1,49 +1,225 @@
 package app
 
 import (
+	"bytes"
+	"expvar"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"sync"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/authenticator"
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/creator"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/emitter"
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/finder"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/healthchecker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/notifier"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/ratelimiter"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/replayer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/settlementreconciler"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/settler"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/middleware"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentstorer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/walletreconciler"
+	"github.com/nahuelsoma/event-driven-challenge-payments/config"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/circuitbreaker"
 	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/jwt"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/lock"
 	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
 )
 
-// StartAPI initializes and starts the HTTP API server
-func StartAPI(database *database.DB, walletClient *http.Client, messageBroker *messagebroker.Connection, exchange, queueName string) error {
+// breakerMetrics holds the registry published under the circuit_breakers expvar
+// expvar.Publish panics on a duplicate name, so the variable is published once and reads the last registry built.
+var breakerMetrics struct {
+	once     sync.Once
+	registry atomic.Pointer[circuitbreaker.Registry]
+}
+
+// publishBreakerMetrics exposes the circuit breaker snapshots with the runtime metrics
+func publishBreakerMetrics(registry *circuitbreaker.Registry) {
+	breakerMetrics.registry.Store(registry)
+	breakerMetrics.once.Do(func() {
+		expvar.Publish("circuit_breakers", expvar.Func(func() any {
+			return breakerMetrics.registry.Load().Snapshots()
+		}))
+	})
+}
+
+// NewAPIServer initializes the HTTP API server listening on the configured port
+// The caller starts serving and shuts the server down, see Run. It also returns the function that closes
+// the connection listening for payment events, to call once the server is shut down.
+func NewAPIServer(database *database.DB, walletClient *http.Client, breakers *Breakers, messageBroker *messagebroker.Connection, cfg *config.Config) (server *http.Server, closeListener func() error, err error) {
 	r := gin.New()
 
-	apiV1 := r.Group("/api/v1")
+	// Every request gets an ID first, so all of its logs, calls and messages can be correlated
+	r.Use(middleware.RequestID)
+	if cfg.Logging.AccessLog {
+		r.Use(middleware.AccessLog)
+	}
+
+	if err := healthchecker.Start(&r.RouterGroup, breakers.Registry); err != nil {
+		return nil, nil, fmt.Errorf("api: failed to start health checker vertical: %w", err)
+	}
+
+	publishBreakerMetrics(breakers.Registry)
+
+	verifier, err := jwt.NewVerifier(jwt.Config{
+		HMACKeyFile:      cfg.Auth.JWTHMACKeyFile,
+		RSAPublicKeyFile: cfg.Auth.JWTRSAPublicKeyFile,
+		JWKSFile:         cfg.Auth.JWTJWKSFile,
+		Issuer:           cfg.Auth.JWTIssuer,
+		Audience:         cfg.Auth.JWTAudience,
+		Leeway:           cfg.Auth.JWTLeeway,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("api: failed to create token verifier: %w", err)
+	}
+
+	auth, err := authenticator.Build(database, verifier)
+	if err != nil {
+		return nil, nil, fmt.Errorf("api: failed to build authenticator: %w", err)
+	}
+
+	limiter, err := rateLimiter(database, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("api: failed to build rate limiter: %w", err)
+	}
+
+	// Payment status writers share the per-payment advisory locks of the processor and the jobs
+	locker, err := lock.NewAdvisoryLocker(database)
+	if err != nil {
+		return nil, nil, fmt.Errorf("api: failed to create locker: %w", err)
+	}
+
+	// Every API route requires an API key or a bearer token, health checks stay public
+	// Requests are rate limited once authenticated, so limits can be keyed by the caller
+	apiV1 := r.Group("/api/v1", auth.Authenticate, limiter.Limit)
+	writeV1 := apiV1.Group("", auth.RequireScope(domain.ScopePaymentsWrite))
+	adminV1 := apiV1.Group("", auth.RequireScope(domain.ScopeAdmin))
+
+	// Runtime metrics expose the command line and memory stats, so only admins can read them
+	adminV1.GET("/admin/debug/vars", gin.WrapH(expvar.Handler()))
 
 	// Each vertical owns its internal wiring
-	if err := creator.Start(apiV1, database, walletClient, messageBroker, exchange, queueName); err != nil {
-		return fmt.Errorf("api: failed to start creator vertical: %w", err)
+	if err := creator.Start(writeV1, database, walletClient, breakers.Wallet, locker, messageBroker, cfg.Exchange, cfg.QueueName, eventFormat(cfg), creator.IdempotencyPolicy{TTL: cfg.Idempotency.TTL, LockTimeout: cfg.Idempotency.LockTimeout}); err != nil {
+		return nil, nil, fmt.Errorf("api: failed to start creator vertical: %w", err)
+	}
+
+	// A single connection listens for the events appended to every streamed payment
+	eventListener, err := paymentEventListener(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("api: failed to create payment event listener: %w", err)
 	}
+	// The caller closes the listener on shutdown, it is only closed here if the server cannot be built
+	defer func() {
+		if err != nil {
+			eventListener.Close()
+		}
+	}()
 
-	if err := finder.Start(apiV1, database); err != nil {
-		return fmt.Errorf("api: failed to start finder vertical: %w", err)
+	if err := finder.Start(apiV1, database, eventListener, eventStreamPolicy(cfg)); err != nil {
+		return nil, nil, fmt.Errorf("api: failed to start finder vertical: %w", err)
+	}
+
+	if err := emitter.Start(apiV1, database, messageBroker, cfg.Exchange, eventPublishPolicy(cfg)); err != nil {
+		return nil, nil, fmt.Errorf("api: failed to start emitter vertical: %w", err)
+	}
+
+	if err := replayer.Start(adminV1, database, messageBroker, cfg.Exchange, cfg.DeadLetter.BatchLimit); err != nil {
+		return nil, nil, fmt.Errorf("api: failed to start replayer vertical: %w", err)
+	}
+
+	if err := walletreconciler.Start(adminV1, database, walletClient, breakers.Wallet, locker, walletReconciliationPolicy(cfg)); err != nil {
+		return nil, nil, fmt.Errorf("api: failed to start wallet reconciler vertical: %w", err)
+	}
+
+	if err := settlementreconciler.Start(adminV1, database, cfg.SettlementReconciliation.Dir, settlementReconciliationPolicy(cfg)); err != nil {
+		return nil, nil, fmt.Errorf("api: failed to start settlement reconciler vertical: %w", err)
+	}
+
+	if err := notifier.Start(adminV1, database, webhookClient(cfg), webhookDeliveryPolicy(cfg)); err != nil {
+		return nil, nil, fmt.Errorf("api: failed to start notifier vertical: %w", err)
+	}
+
+	// The gateway authenticates its callbacks with a signature instead of an API key
+	callbackPolicy, err := gatewayCallbackPolicy(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("api: failed to build gateway callback policy: %w", err)
+	}
+	if callbackPolicy != nil {
+		if err := settler.Start(r.Group("/api/v1"), database, walletClient, breakers.Wallet, locker, *callbackPolicy); err != nil {
+			return nil, nil, fmt.Errorf("api: failed to start settler vertical: %w", err)
+		}
+	} else {
+		slog.Info("Gateway callbacks disabled, GATEWAY_CALLBACK_SECRET_FILE is not set")
 	}
 
 	r.NoRoute(func(c *gin.Context) {
-		c.JSON(http.StatusNotFound, gin.H{
-			"message": "the requested resource was not found",
-		})
+		problem.Respond(c, problem.New(problem.CodeNotFound, "the requested resource was not found"))
 	})
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
+	return &http.Server{
+		Addr:    "0.0.0.0:" + cfg.Run.Port,
+		Handler: r,
+	}, eventListener.Close, nil
+}
+
+// rateLimiter builds the rate limiter with the configured backend and rules
+func rateLimiter(db *database.DB, cfg *config.Config) (*ratelimiter.Handler, error) {
+	rules, err := ratelimiter.ParseRules(cfg.RateLimit.Rules)
+	if err != nil {
+		return nil, err
 	}
 
-	slog.Info("Starting API server", "port", port)
+	return ratelimiter.Build(db, ratelimiter.Backend(cfg.RateLimit.Backend), rules)
+}
+
+// eventFormat builds the CloudEvents format the payments are published with, shared by the API and the recovery job
+func eventFormat(cfg *config.Config) messagebroker.EventFormat {
+	format := messagebroker.EventFormat{
+		Mode:   messagebroker.EventMode(cfg.CloudEvents.Mode),
+		Source: cfg.CloudEvents.Source,
+		Codec:  messagebroker.JSONCodec{},
+	}
+	if cfg.CloudEvents.DataEncoding == "protobuf" {
+		format.Codec = messagebroker.ProtobufCodec{}
+	}
+	return format
+}
+
+// paymentEventListener listens for the payment events appended by any instance
+func paymentEventListener(cfg *config.Config) (*database.Listener, error) {
+	return database.NewListener(cfg.Database.URL(), paymentstorer.EventsChannel)
+}
+
+// eventStreamPolicy builds the payment event stream policy from the configuration
+func eventStreamPolicy(cfg *config.Config) finder.StreamPolicy {
+	return finder.StreamPolicy{
+		KeepAlive:   cfg.EventStream.KeepAlive,
+		MaxDuration: cfg.EventStream.MaxDuration,
+	}
+}
+
+// gatewayCallbackPolicy builds the gateway callback policy from the configuration
+// It returns nil when no callback secret is configured
+func gatewayCallbackPolicy(cfg *config.Config) (*settler.CallbackPolicy, error) {
+	if cfg.GatewayCallback.SecretFile == "" {
+		return nil, nil
+	}
 
-	if err := r.Run("0.0.0.0:" + port); err != nil {
-		return fmt.Errorf("api: failed to start server: %w", err)
+	secret, err := os.ReadFile(cfg.GatewayCallback.SecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("read gateway callback secret: %w", err)
 	}
 
-	return nil
+	return &settler.CallbackPolicy{
+		Secret:    bytes.TrimSpace(secret),
+		Tolerance: cfg.GatewayCallback.Tolerance,
+	}, nil
 }
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/walletclient"
+	"github.com/nahuelsoma/event-driven-challenge-payments/config"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/circuitbreaker"
+)
+
+// Breakers holds the circuit breakers of the external dependencies
+// They are shared by the API, the consumer and the jobs so every caller sees the same state
+type Breakers struct {
+	Wallet   *circuitbreaker.Breaker
+	Gateway  *circuitbreaker.Breaker
+	Registry *circuitbreaker.Registry
+}
+
+// NewBreakers creates the circuit breakers from the configuration
+func NewBreakers(cfg *config.Config) (*Breakers, error) {
+	// A wallet rejecting a payment, like for insufficient funds, is healthy and does not count as a failure
+	walletConfig := breakerConfig("wallet", cfg.CircuitBreaker.Wallet)
+	walletConfig.IsFailure = walletclient.IsFailure
+
+	wallet, err := circuitbreaker.New(walletConfig)
+	if err != nil {
+		return nil, fmt.Errorf("breakers: wallet: %w", err)
+	}
+
+	gateway, err := circuitbreaker.New(breakerConfig("gateway", cfg.CircuitBreaker.Gateway))
+	if err != nil {
+		return nil, fmt.Errorf("breakers: gateway: %w", err)
+	}
+
+	registry := circuitbreaker.NewRegistry()
+	registry.Register(wallet)
+	registry.Register(gateway)
+
+	return &Breakers{
+		Wallet:   wallet,
+		Gateway:  gateway,
+		Registry: registry,
+	}, nil
+}
+
+// breakerConfig builds the configuration of a named breaker
+func breakerConfig(name string, cfg config.BreakerConfig) circuitbreaker.Config {
+	return circuitbreaker.Config{
+		Name:        name,
+		Window:      cfg.Window,
+		MinRequests: cfg.MinRequests,
+		FailureRate: float64(cfg.FailureRate) / 100,
+		Cooldown:    cfg.Cooldown,
+		Probes:      cfg.Probes,
+	}
+}
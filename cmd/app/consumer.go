@@ -1,54 +1,115 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/archiver"
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/processor"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/messagestorer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/config"
 	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/lock"
 	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
 )
 
-const (
-	workers = 3
-)
-
-// StartConsumer initializes and starts the message consumer
-// Returns after setup is complete. Message consumption runs in background goroutines.
-func StartConsumer(db *database.DB, walletClient *http.Client, conn *messagebroker.Connection, exchange, queueName string) error {
+// StartConsumer initializes and starts the message consumers
+// Returns after setup is complete. Message consumption runs in background goroutines until shutdown,
+// which waits for the messages being handled.
+func StartConsumer(db *database.DB, walletClient *http.Client, breakers *Breakers, conn *messagebroker.Connection, cfg *config.Config) (Shutdown, error) {
 	// Create channel for consumer
 	channel, err := conn.NewChannel()
 	if err != nil {
-		return fmt.Errorf("consumer: failed to create channel: %w", err)
+		return nil, fmt.Errorf("consumer: failed to create channel: %w", err)
+	}
+
+	// Redelivered messages the processor already handled are skipped before parsing them
+	messages, err := messagestorer.NewStorer(db)
+	if err != nil {
+		return nil, fmt.Errorf("consumer: failed to create message store: %w", err)
 	}
 
 	// Consumer configuration with topic exchange for flexible routing
-	config := messagebroker.ConsumerConfig{
-		Exchange:   exchange,
-		QueueName:  queueName,
-		RoutingKey: queueName, // Use queue name as routing key
-		Workers:    workers,
+	consumerConfig := messagebroker.ConsumerConfig{
+		Exchange:             cfg.Exchange,
+		QueueName:            cfg.QueueName,
+		RoutingKey:           cfg.QueueName, // Use queue name as routing key
+		Workers:              cfg.Run.ConsumerWorkers,
+		MaxAttempts:          cfg.DeadLetter.MaxAttempts,
+		DeadLetterRoutingKey: cfg.DeadLetter.QueueName,
+		DeferDelay:           cfg.CircuitBreaker.DeferDelay, // Pause workers while the gateway breaker is open
+		Handler:              processor.HandlerName,
+		Deduplicator:         messages,
 	}
 
 	// Create infrastructure consumer
-	consumer, err := messagebroker.NewConsumer(channel, config)
+	consumer, err := messagebroker.NewConsumer(channel, consumerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("consumer: failed to create consumer: %w", err)
+	}
+
+	// Messages for the same payment are serialized with advisory locks shared by every consumer replica
+	locker, err := lock.NewAdvisoryLocker(db)
 	if err != nil {
-		return fmt.Errorf("consumer: failed to create consumer: %w", err)
+		return nil, fmt.Errorf("consumer: failed to create locker: %w", err)
 	}
 
 	// Create payment processor handler using the vertical pattern
-	handler, err := processor.Build(db, walletClient)
+	handler, err := processor.Build(db, walletClient, breakers.Wallet, breakers.Gateway, locker)
+	if err != nil {
+		return nil, fmt.Errorf("consumer: failed to create processor: %w", err)
+	}
+
+	// Start consuming, deliveries carry the request ID header the payment was published with
+	if err := consumer.StartDeliveries(handler); err != nil {
+		return nil, fmt.Errorf("consumer: failed to start consumer: %w", err)
+	}
+
+	slog.Info("Consumer started", "queue", cfg.QueueName, "workers", cfg.Run.ConsumerWorkers, "max_attempts", cfg.DeadLetter.MaxAttempts)
+
+	archiverConsumer, err := startArchiver(db, conn, cfg)
+	if err != nil {
+		return nil, errors.Join(err, consumer.Stop(context.Background()))
+	}
+
+	return func(ctx context.Context) error {
+		return errors.Join(consumer.Stop(ctx), archiverConsumer.Stop(ctx))
+	}, nil
+}
+
+// startArchiver starts the consumer that archives dead letters for inspection and replay
+func startArchiver(db *database.DB, conn *messagebroker.Connection, cfg *config.Config) (*messagebroker.Consumer, error) {
+	channel, err := conn.NewChannel()
+	if err != nil {
+		return nil, fmt.Errorf("archiver: failed to create channel: %w", err)
+	}
+
+	// Dead letters are requeued until archived, they are never dead-lettered again
+	consumerConfig := messagebroker.ConsumerConfig{
+		Exchange:   cfg.Exchange,
+		QueueName:  cfg.DeadLetter.QueueName,
+		RoutingKey: cfg.DeadLetter.QueueName,
+		Workers:    1,
+	}
+
+	consumer, err := messagebroker.NewConsumer(channel, consumerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("archiver: failed to create consumer: %w", err)
+	}
+
+	handler, err := archiver.Build(db)
 	if err != nil {
-		return fmt.Errorf("consumer: failed to create processor: %w", err)
+		return nil, fmt.Errorf("archiver: failed to create archiver: %w", err)
 	}
 
-	// Start consuming
-	if err := consumer.Start(handler); err != nil {
-		return fmt.Errorf("consumer: failed to start consumer: %w", err)
+	if err := consumer.StartDeliveries(handler); err != nil {
+		return nil, fmt.Errorf("archiver: failed to start consumer: %w", err)
 	}
 
-	slog.Info("Consumer started", "queue", queueName, "workers", workers)
+	slog.Info("Archiver started", "queue", cfg.DeadLetter.QueueName)
 
-	return nil
+	return consumer, nil
 }
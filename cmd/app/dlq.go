@@ -0,0 +1,158 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/replayer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/config"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+)
+
+const dlqUsage = `usage:
+  dlq list    [-status pending|replayed|discarded] [-queue name] [-failure-reason text] [-limit n] [-offset n]
+  dlq show    <id>
+  dlq audit   <id>
+  dlq replay  [-actor name] [-reason text] <id>
+  dlq replay  [-actor name] [-reason text] -all [-queue name] [-failure-reason text] [-limit n]
+  dlq discard [-actor name] [-reason text] <id>
+  dlq discard [-actor name] [-reason text] -all [-queue name] [-failure-reason text] [-limit n]`
+
+// RunDLQ runs a dead letter queue admin command and writes its result as JSON
+// It shares the replayer service with the admin API, so every replay and discard is audited the same way
+func RunDLQ(ctx context.Context, db *database.DB, conn *messagebroker.Connection, cfg *config.Config, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(dlqUsage)
+	}
+
+	service, err := replayer.BuildService(db, conn, cfg.Exchange, cfg.DeadLetter.BatchLimit)
+	if err != nil {
+		return fmt.Errorf("dlq: failed to create replayer: %w", err)
+	}
+
+	command, args := args[0], args[1:]
+	fs := flag.NewFlagSet("dlq "+command, flag.ContinueOnError)
+	fs.SetOutput(out)
+
+	switch command {
+	case "list":
+		status := fs.String("status", "", "status to match")
+		queue := fs.String("queue", "", "queue to match")
+		failureReason := fs.String("failure-reason", "", "text the failure reason must contain")
+		limit := fs.Int("limit", 50, "maximum number of dead letters")
+		offset := fs.Int("offset", 0, "number of dead letters to skip")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+
+		filter := &domain.DeadLetterFilter{
+			Status:        domain.DeadLetterStatus(*status),
+			Queue:         *queue,
+			FailureReason: *failureReason,
+			Limit:         *limit,
+			Offset:        *offset,
+		}
+		if err := filter.Validate(); err != nil {
+			return fmt.Errorf("dlq: %w", err)
+		}
+
+		deadLetters, err := service.List(ctx, filter)
+		if err != nil {
+			return err
+		}
+		return writeJSON(out, deadLetters)
+
+	case "show", "audit":
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return errors.New(dlqUsage)
+		}
+
+		if command == "show" {
+			deadLetter, err := service.Get(ctx, fs.Arg(0))
+			if err != nil {
+				return err
+			}
+			return writeJSON(out, deadLetter)
+		}
+
+		entries, err := service.Audit(ctx, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		return writeJSON(out, entries)
+
+	case "replay", "discard":
+		actor := fs.String("actor", os.Getenv("USER"), "who applies the action, recorded in the audit trail")
+		reason := fs.String("reason", "", "why the action is applied, recorded in the audit trail")
+		all := fs.Bool("all", false, "act on every pending dead letter matching the filter")
+		queue := fs.String("queue", "", "queue to match, with -all")
+		failureReason := fs.String("failure-reason", "", "text the failure reason must contain, with -all")
+		limit := fs.Int("limit", 0, "maximum number of dead letters, with -all")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+
+		if *all {
+			br := &replayer.BatchRequest{
+				Actor:         *actor,
+				Reason:        *reason,
+				Queue:         *queue,
+				FailureReason: *failureReason,
+				Limit:         *limit,
+			}
+			if err := br.Validate(); err != nil {
+				return fmt.Errorf("dlq: %w", err)
+			}
+
+			batch := service.ReplayBatch
+			if command == "discard" {
+				batch = service.DiscardBatch
+			}
+
+			result, err := batch(ctx, br)
+			if err != nil {
+				return err
+			}
+			return writeJSON(out, result)
+		}
+
+		if fs.NArg() != 1 {
+			return errors.New(dlqUsage)
+		}
+
+		ar := &replayer.ActionRequest{Actor: *actor, Reason: *reason}
+		if err := ar.Validate(); err != nil {
+			return fmt.Errorf("dlq: %w", err)
+		}
+
+		action, status := service.Replay, domain.DeadLetterStatusReplayed
+		if command == "discard" {
+			action, status = service.Discard, domain.DeadLetterStatusDiscarded
+		}
+
+		if err := action(ctx, fs.Arg(0), ar); err != nil {
+			return err
+		}
+		return writeJSON(out, map[string]interface{}{"id": fs.Arg(0), "status": status})
+
+	default:
+		return errors.New(dlqUsage)
+	}
+}
+
+// writeJSON writes a value as indented JSON
+func writeJSON(out io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,307 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/confirmer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/emitter"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/notifier"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/ratelimiter"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/recoverer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/settlementreconciler"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/messagestorer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/walletreconciler"
+	"github.com/nahuelsoma/event-driven-challenge-payments/config"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/lock"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/scheduler"
+)
+
+const (
+	schedulerLease                 = "scheduler" // Lease name for the scheduler leader election
+	rateLimitCleanupTimeout        = time.Minute // Maximum duration of a rate limit bucket cleanup run
+	processedMessageCleanupTimeout = time.Minute // Maximum duration of a processed message cleanup run
+)
+
+// StartJobs initializes and starts the scheduled jobs
+// Returns after setup is complete. Leader election and jobs run in background goroutines,
+// jobs only run on the replica holding the scheduler lease. Shutdown cancels the runs in flight
+// and releases the lease, so another replica takes over without waiting for it to expire.
+func StartJobs(db *database.DB, walletClient *http.Client, breakers *Breakers, conn *messagebroker.Connection, cfg *config.Config) (Shutdown, error) {
+	leases, err := lock.NewLeaseStore(db)
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to create lease store: %w", err)
+	}
+
+	elector, err := lock.NewElector(leases, schedulerLease, cfg.Scheduler.HolderID, cfg.Scheduler.LeaseTTL)
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to create elector: %w", err)
+	}
+
+	locker, err := lock.NewAdvisoryLocker(db)
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to create locker: %w", err)
+	}
+
+	history, err := scheduler.NewPostgresHistory(db)
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to create job history: %w", err)
+	}
+
+	s, err := scheduler.New(elector, &jobLocker{locker: locker}, history, cfg.Scheduler.HolderID)
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to create scheduler: %w", err)
+	}
+
+	policy := recoverer.RecoveryPolicy{
+		Threshold:   cfg.Recovery.Threshold,
+		MaxAttempts: cfg.Recovery.MaxAttempts,
+		BatchSize:   cfg.Recovery.BatchSize,
+	}
+
+	// Orphaned payments are republished with the same routing key the processor consumes
+	recovery, err := recoverer.Build(db, walletClient, breakers.Wallet, conn, cfg.Exchange, cfg.QueueName, eventFormat(cfg), locker, policy)
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to create recoverer: %w", err)
+	}
+
+	err = s.Register(scheduler.Job{
+		Name:     "recovery",
+		Interval: cfg.Recovery.Interval,
+		Jitter:   cfg.Scheduler.Jitter,
+		Timeout:  cfg.Recovery.Timeout,
+		Run:      recovery.Run,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to register recovery job: %w", err)
+	}
+
+	confirmPolicy := confirmer.ConfirmPolicy{
+		Threshold: cfg.ConfirmRetry.Threshold,
+		BatchSize: cfg.ConfirmRetry.BatchSize,
+	}
+
+	// Payments already charged by the gateway only retry the wallet confirmation
+	confirmRetry, err := confirmer.Build(db, walletClient, breakers.Wallet, locker, confirmPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to create confirmer: %w", err)
+	}
+
+	err = s.Register(scheduler.Job{
+		Name:     "confirm_retry",
+		Interval: cfg.ConfirmRetry.Interval,
+		Jitter:   cfg.Scheduler.Jitter,
+		Timeout:  cfg.ConfirmRetry.Timeout,
+		Run:      confirmRetry.Run,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to register confirm retry job: %w", err)
+	}
+
+	walletReconciliation, err := walletreconciler.Build(db, walletClient, breakers.Wallet, locker, walletReconciliationPolicy(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to create wallet reconciler: %w", err)
+	}
+
+	err = s.Register(scheduler.Job{
+		Name:     "wallet_reconciliation",
+		Interval: cfg.WalletReconciliation.Interval,
+		Jitter:   cfg.Scheduler.Jitter,
+		Timeout:  cfg.WalletReconciliation.Timeout,
+		Run:      walletReconciliation.Run,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to register wallet reconciliation job: %w", err)
+	}
+
+	settlementReconciliation, err := settlementreconciler.Build(db, cfg.SettlementReconciliation.Dir, settlementReconciliationPolicy(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to create settlement reconciler: %w", err)
+	}
+
+	err = s.Register(scheduler.Job{
+		Name:     "settlement_reconciliation",
+		Interval: cfg.SettlementReconciliation.Interval,
+		Jitter:   cfg.Scheduler.Jitter,
+		Timeout:  cfg.SettlementReconciliation.Timeout,
+		Run:      settlementReconciliation.Run,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to register settlement reconciliation job: %w", err)
+	}
+
+	webhooks, err := notifier.Build(db, webhookClient(cfg), webhookDeliveryPolicy(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to create notifier: %w", err)
+	}
+
+	err = s.Register(scheduler.Job{
+		Name:     "webhook_delivery",
+		Interval: cfg.Webhook.Interval,
+		Jitter:   cfg.Scheduler.Jitter,
+		Timeout:  cfg.Webhook.Timeout,
+		Run:      webhooks.Run,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to register webhook delivery job: %w", err)
+	}
+
+	// Every appended payment event is published to the exchange for other teams to consume
+	events, err := emitter.Build(db, conn, cfg.Exchange, eventPublishPolicy(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to create emitter: %w", err)
+	}
+
+	err = s.Register(scheduler.Job{
+		Name:     "event_publisher",
+		Interval: cfg.EventPublisher.Interval,
+		Jitter:   cfg.Scheduler.Jitter,
+		Timeout:  cfg.EventPublisher.Timeout,
+		Run:      events.Run,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to register event publisher job: %w", err)
+	}
+
+	// Buckets kept in memory are cleaned up by each API replica, only shared buckets need the job
+	if ratelimiter.Backend(cfg.RateLimit.Backend) == ratelimiter.BackendPostgres {
+		limiter, err := rateLimiter(db, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("jobs: failed to create rate limiter: %w", err)
+		}
+
+		err = s.Register(scheduler.Job{
+			Name:     "rate_limit_cleanup",
+			Interval: cfg.RateLimit.CleanupInterval,
+			Jitter:   cfg.Scheduler.Jitter,
+			Timeout:  rateLimitCleanupTimeout,
+			Run:      limiter.Run,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("jobs: failed to register rate limit cleanup job: %w", err)
+		}
+	}
+
+	messages, err := messagestorer.NewStorer(db)
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to create message store: %w", err)
+	}
+
+	err = s.Register(scheduler.Job{
+		Name:     "processed_message_cleanup",
+		Interval: cfg.ProcessedMessage.CleanupInterval,
+		Jitter:   cfg.Scheduler.Jitter,
+		Timeout:  processedMessageCleanupTimeout,
+		Run:      processedMessageCleanup(messages, cfg.ProcessedMessage.TTL),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("jobs: failed to register processed message cleanup job: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	elected := make(chan struct{})
+	go func() {
+		defer close(elected)
+		elector.Run(ctx)
+	}()
+	s.Start(ctx)
+
+	slog.Info("Jobs started", "holder", cfg.Scheduler.HolderID)
+
+	return func(shutdownCtx context.Context) error {
+		cancel()
+
+		done := make(chan struct{})
+		go func() {
+			s.Wait()
+			<-elected
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-shutdownCtx.Done():
+			return fmt.Errorf("jobs: failed to wait for job runs: %w", shutdownCtx.Err())
+		}
+	}, nil
+}
+
+// walletReconciliationPolicy builds the wallet reconciliation policy from the configuration
+func walletReconciliationPolicy(cfg *config.Config) walletreconciler.ReconciliationPolicy {
+	return walletreconciler.ReconciliationPolicy{
+		MinAge:            cfg.WalletReconciliation.MinAge,
+		Lookback:          cfg.WalletReconciliation.Lookback,
+		ProcessingTimeout: cfg.WalletReconciliation.ProcessingTimeout,
+		BatchSize:         cfg.WalletReconciliation.BatchSize,
+	}
+}
+
+// settlementReconciliationPolicy builds the settlement reconciliation policy from the configuration
+func settlementReconciliationPolicy(cfg *config.Config) settlementreconciler.ReconciliationPolicy {
+	return settlementreconciler.ReconciliationPolicy{
+		Grace:    cfg.SettlementReconciliation.Grace,
+		Lookback: cfg.SettlementReconciliation.Lookback,
+	}
+}
+
+// webhookDeliveryPolicy builds the webhook delivery policy from the configuration
+func webhookDeliveryPolicy(cfg *config.Config) notifier.DeliveryPolicy {
+	return notifier.DeliveryPolicy{
+		BatchSize:      cfg.Webhook.BatchSize,
+		MaxAttempts:    cfg.Webhook.MaxAttempts,
+		RetryBaseDelay: cfg.Webhook.RetryBaseDelay,
+		RetryMaxDelay:  cfg.Webhook.RetryMaxDelay,
+	}
+}
+
+// eventPublishPolicy builds the payment event publish policy from the configuration
+func eventPublishPolicy(cfg *config.Config) emitter.PublishPolicy {
+	return emitter.PublishPolicy{
+		BatchSize: cfg.EventPublisher.BatchSize,
+	}
+}
+
+// webhookClient creates the client webhooks are sent with
+// Redirects are not followed, so a subscriber cannot bounce the signed payloads to another host
+func webhookClient(cfg *config.Config) *http.Client {
+	return &http.Client{
+		Timeout: cfg.Webhook.RequestTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// processedMessageCleanup deletes the processed messages older than the TTL, their redeliveries are no longer expected
+func processedMessageCleanup(messages *messagestorer.MessageRepository, ttl time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		deleted, err := messages.Prune(ctx, time.Now().Add(-ttl))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to prune processed messages", "error", err)
+			return err
+		}
+
+		slog.DebugContext(ctx, "Processed messages pruned", "deleted", deleted)
+		return nil
+	}
+}
+
+// jobLocker adapts the advisory locker to the scheduler locker
+type jobLocker struct {
+	locker *lock.AdvisoryLocker
+}
+
+// TryLock tries to acquire the advisory lock of a job
+func (l *jobLocker) TryLock(ctx context.Context, name string) (scheduler.Unlocker, error) {
+	al, err := l.locker.TryLock(ctx, "job:"+name)
+	if err != nil {
+		return nil, err
+	}
+	return al, nil
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/config"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+)
+
+// Mode selects the components a process runs
+type Mode string
+
+const (
+	ModeServeAPI Mode = "serve-api" // HTTP API
+	ModeConsume  Mode = "consume"   // Payment processor and dead letter archiver consumers
+	ModeJobs     Mode = "jobs"      // Scheduled jobs
+	ModeAll      Mode = "all"       // Every component in one process, the default
+)
+
+const runUsage = `usage:
+  serve-api [-port n] [-shutdown-timeout d]
+  consume   [-workers n] [-shutdown-timeout d]
+  jobs      [-shutdown-timeout d]
+  all       [-port n] [-workers n] [-shutdown-timeout d]
+  dlq       <command> [flags]`
+
+// Shutdown stops a started component, waiting for its in-flight work until the context is done
+type Shutdown func(ctx context.Context) error
+
+// ParseMode parses the run mode and its flags from the command line arguments
+// Without arguments it returns ModeAll. Flags override the run configuration loaded from the environment.
+func ParseMode(args []string, cfg *config.RunConfig, out io.Writer) (Mode, error) {
+	if len(args) == 0 {
+		return ModeAll, nil
+	}
+
+	mode := Mode(args[0])
+	fs := flag.NewFlagSet(string(mode), flag.ContinueOnError)
+	fs.SetOutput(out)
+
+	switch mode {
+	case ModeServeAPI:
+		fs.StringVar(&cfg.Port, "port", cfg.Port, "port the API listens on")
+	case ModeConsume:
+		fs.IntVar(&cfg.ConsumerWorkers, "workers", cfg.ConsumerWorkers, "workers of the payment processor consumer")
+	case ModeJobs:
+	case ModeAll:
+		fs.StringVar(&cfg.Port, "port", cfg.Port, "port the API listens on")
+		fs.IntVar(&cfg.ConsumerWorkers, "workers", cfg.ConsumerWorkers, "workers of the payment processor consumer")
+	default:
+		return "", fmt.Errorf("run: unknown mode %q\n%s", args[0], runUsage)
+	}
+	fs.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", cfg.ShutdownTimeout, "time in-flight work gets to finish on shutdown")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return "", err
+	}
+	if fs.NArg() > 0 {
+		return "", errors.New(runUsage)
+	}
+
+	if !config.ValidPort(cfg.Port) {
+		return "", fmt.Errorf("run: invalid port %q", cfg.Port)
+	}
+	if cfg.ConsumerWorkers < 1 {
+		return "", fmt.Errorf("run: workers must be positive, got %d", cfg.ConsumerWorkers)
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		return "", fmt.Errorf("run: shutdown timeout must be positive, got %s", cfg.ShutdownTimeout)
+	}
+
+	return mode, nil
+}
+
+// Run starts the components of the mode and blocks until the context is done or the API server fails
+// Components are then shut down in reverse start order, so the API stops taking payments before the consumers drain,
+// and the whole shutdown is bounded by the configured timeout.
+func Run(ctx context.Context, mode Mode, db *database.DB, walletClient *http.Client, breakers *Breakers, conn *messagebroker.Connection, cfg *config.Config) error {
+	// The in-memory broker only delivers to consumers of the same process
+	if cfg.MessageBroker.Driver == config.BrokerDriverMemory && mode != ModeAll {
+		return fmt.Errorf("run: the %s broker driver requires the %s mode", config.BrokerDriverMemory, ModeAll)
+	}
+
+	var shutdowns []Shutdown
+	serveErrs := make(chan error, 1)
+
+	if mode == ModeConsume || mode == ModeAll {
+		shutdown, err := StartConsumer(db, walletClient, breakers, conn, cfg)
+		if err != nil {
+			return errors.Join(err, shutdownAll(shutdowns, cfg.Run.ShutdownTimeout))
+		}
+		shutdowns = append(shutdowns, shutdown)
+	}
+
+	if mode == ModeJobs || mode == ModeAll {
+		shutdown, err := StartJobs(db, walletClient, breakers, conn, cfg)
+		if err != nil {
+			return errors.Join(err, shutdownAll(shutdowns, cfg.Run.ShutdownTimeout))
+		}
+		shutdowns = append(shutdowns, shutdown)
+	}
+
+	if mode == ModeServeAPI || mode == ModeAll {
+		shutdown, err := serveAPI(db, walletClient, breakers, conn, cfg, serveErrs)
+		if err != nil {
+			return errors.Join(err, shutdownAll(shutdowns, cfg.Run.ShutdownTimeout))
+		}
+		shutdowns = append(shutdowns, shutdown)
+	}
+
+	slog.Info("Running", "mode", mode)
+
+	var err error
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutting down", "mode", mode, "timeout", cfg.Run.ShutdownTimeout)
+	case err = <-serveErrs:
+		slog.Error("API server stopped, shutting down", "mode", mode, "error", err)
+		err = fmt.Errorf("api: server stopped: %w", err)
+	}
+
+	if shutdownErr := shutdownAll(shutdowns, cfg.Run.ShutdownTimeout); shutdownErr != nil {
+		return errors.Join(err, shutdownErr)
+	}
+
+	slog.Info("Shutdown complete", "mode", mode)
+
+	return err
+}
+
+// serveAPI listens on the API port and serves in a background goroutine, sending unexpected server errors to errs
+// Listening before returning reports a port already in use as a startup error.
+// Shutdown stops accepting requests and waits for the active ones, connections still open when the context
+// is done, like event streams, are closed. The payment event listener is closed once no stream uses it.
+func serveAPI(db *database.DB, walletClient *http.Client, breakers *Breakers, conn *messagebroker.Connection, cfg *config.Config, errs chan<- error) (Shutdown, error) {
+	server, closeListener, err := NewAPIServer(db, walletClient, breakers, conn, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("api: failed to listen: %w", err), closeListener())
+	}
+
+	slog.Info("Starting API server", "port", cfg.Run.Port)
+
+	go func() {
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			errs <- err
+		}
+	}()
+
+	return func(ctx context.Context) error {
+		if err := server.Shutdown(ctx); err != nil {
+			return errors.Join(fmt.Errorf("api: failed to shut down server: %w", err), server.Close(), closeListener())
+		}
+		if err := closeListener(); err != nil {
+			return fmt.Errorf("api: failed to close payment event listener: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// shutdownAll shuts down the started components in reverse order within the timeout
+func shutdownAll(shutdowns []Shutdown, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(shutdowns) - 1; i >= 0; i-- {
+		errs = append(errs, shutdowns[i](ctx))
+	}
+	return errors.Join(errs...)
+}
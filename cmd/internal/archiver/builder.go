@@ -0,0 +1,26 @@
+package archiver
+
+import (
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/deadletterstorer"
+)
+
+// Build creates a new Handler with all dependencies wired up
+// It builds a new handler and returns an error if the builder fails
+func Build(db deadletterstorer.DeadLetterDB) (*Handler, error) {
+	dls, err := deadletterstorer.NewStorer(db)
+	if err != nil {
+		return nil, err
+	}
+
+	das, err := NewDeadLetterArchiverService(dls)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := NewHandler(das)
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
@@ -0,0 +1,75 @@
+package archiver
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+)
+
+// DeadLetterMessage represents a message received from a dead letter queue
+type DeadLetterMessage struct {
+	ID          string                 // Message ID the original message was published with
+	ContentType string                 // Content type the original message was published with
+	Body        []byte                 // Original message body
+	Headers     map[string]interface{} // Original headers plus the dead letter headers
+	RoutingKey  string                 // Routing key the dead letter was delivered with
+}
+
+// ToDeadLetter builds the dead letter to archive from the message and its headers
+// Messages dead-lettered without an ID get a new one, so they are archived once per delivery
+func (m *DeadLetterMessage) ToDeadLetter(now time.Time) *domain.DeadLetter {
+	deadLetter := &domain.DeadLetter{
+		ID:             stringHeader(m.Headers, messagebroker.DeadLetterIDHeader),
+		Queue:          stringHeader(m.Headers, messagebroker.OriginalQueueHeader),
+		RoutingKey:     stringHeader(m.Headers, messagebroker.OriginalRoutingKeyHeader),
+		Body:           m.Body,
+		ContentType:    m.ContentType,
+		MessageID:      m.ID,
+		Headers:        m.Headers,
+		FailureReason:  stringHeader(m.Headers, messagebroker.FailureReasonHeader),
+		Attempts:       intHeader(m.Headers, messagebroker.AttemptsHeader),
+		Status:         domain.DeadLetterStatusPending,
+		DeadLetteredAt: now,
+		UpdatedAt:      now,
+	}
+
+	if deadLetter.ID == "" {
+		deadLetter.ID = uuid.New().String()
+	}
+	if deadLetter.RoutingKey == "" {
+		deadLetter.RoutingKey = m.RoutingKey
+	}
+	if deadLetter.Queue == "" {
+		deadLetter.Queue = deadLetter.RoutingKey
+	}
+	if deadLetter.Headers == nil {
+		deadLetter.Headers = map[string]interface{}{}
+	}
+	if at, err := time.Parse(time.RFC3339, stringHeader(m.Headers, messagebroker.DeadLetteredAtHeader)); err == nil {
+		deadLetter.DeadLetteredAt = at
+	}
+
+	return deadLetter
+}
+
+// stringHeader returns a string header, or an empty string if it is missing
+func stringHeader(headers map[string]interface{}, key string) string {
+	value, _ := headers[key].(string)
+	return value
+}
+
+// intHeader returns an integer header, or zero if it is missing
+func intHeader(headers map[string]interface{}, key string) int {
+	switch v := headers[key].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,99 @@
+package archiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadLetterMessage_ToDeadLetter(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	deadLetteredAt := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		message            *DeadLetterMessage
+		expectedDeadLetter *domain.DeadLetter
+		expectGeneratedID  bool
+	}{
+		{
+			name: "when every dead letter header is set it should map them and the raw body to the dead letter and no error",
+			message: &DeadLetterMessage{
+				ID:          "msg_123",
+				ContentType: messagebroker.ProtobufContentType,
+				Body:        []byte{0x0a, 0x07, 'p', 'a', 'y', '_', '1', '2', '3', 0x00, 0xff},
+				Headers: map[string]interface{}{
+					"x-dead-letter-id":       "dl_123",
+					"x-original-queue":       "payments.created",
+					"x-original-routing-key": "payments.created",
+					"x-failure-reason":       "gateway timeout",
+					"x-attempts":             int32(5),
+					"x-dead-lettered-at":     "2024-01-15T10:00:00Z",
+				},
+				RoutingKey: "payments.created.dlq",
+			},
+			expectedDeadLetter: &domain.DeadLetter{
+				ID:          "dl_123",
+				Queue:       "payments.created",
+				RoutingKey:  "payments.created",
+				Body:        []byte{0x0a, 0x07, 'p', 'a', 'y', '_', '1', '2', '3', 0x00, 0xff},
+				ContentType: messagebroker.ProtobufContentType,
+				MessageID:   "msg_123",
+				Headers: map[string]interface{}{
+					"x-dead-letter-id":       "dl_123",
+					"x-original-queue":       "payments.created",
+					"x-original-routing-key": "payments.created",
+					"x-failure-reason":       "gateway timeout",
+					"x-attempts":             int32(5),
+					"x-dead-lettered-at":     "2024-01-15T10:00:00Z",
+				},
+				FailureReason:  "gateway timeout",
+				Attempts:       5,
+				Status:         domain.DeadLetterStatusPending,
+				DeadLetteredAt: deadLetteredAt,
+				UpdatedAt:      fixedTime,
+			},
+			expectGeneratedID: false,
+		},
+		{
+			name: "when dead letter headers are missing it should fall back to the delivery routing key and a new ID",
+			message: &DeadLetterMessage{
+				Body:       []byte(`{"id":"pay_123"}`),
+				Headers:    nil,
+				RoutingKey: "payments.created.dlq",
+			},
+			expectedDeadLetter: &domain.DeadLetter{
+				Queue:          "payments.created.dlq",
+				RoutingKey:     "payments.created.dlq",
+				Body:           []byte(`{"id":"pay_123"}`),
+				Headers:        map[string]interface{}{},
+				FailureReason:  "",
+				Attempts:       0,
+				Status:         domain.DeadLetterStatusPending,
+				DeadLetteredAt: fixedTime,
+				UpdatedAt:      fixedTime,
+			},
+			expectGeneratedID: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Message already prepared in test struct)
+
+			// Act
+			result := tt.message.ToDeadLetter(fixedTime)
+
+			// Assert
+			if tt.expectGeneratedID {
+				assert.NotEmpty(t, result.ID)
+				tt.expectedDeadLetter.ID = result.ID
+			}
+			assert.Equal(t, tt.expectedDeadLetter, result)
+		})
+	}
+}
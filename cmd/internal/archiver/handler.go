@@ -0,0 +1,61 @@
+package archiver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+)
+
+// DeadLetterArchiver defines the interface for dead letter archiving business logic
+type DeadLetterArchiver interface {
+	Archive(ctx context.Context, deadLetter *domain.DeadLetter) error
+}
+
+// Handler handles incoming messages from the dead letter queue
+type Handler struct {
+	deadLetterArchiver DeadLetterArchiver
+}
+
+// NewHandler creates a new archiver handler
+// It returns a new archiver handler and an error if the dead letter archiver is nil
+func NewHandler(dla DeadLetterArchiver) (*Handler, error) {
+	if dla == nil {
+		return nil, errors.New("archiver handler: dead letter archiver cannot be nil")
+	}
+
+	return &Handler{
+		deadLetterArchiver: dla,
+	}, nil
+}
+
+// HandleDelivery handles incoming messages from the dead letter queue
+// It returns an error if the dead letter cannot be archived, so the message is requeued
+func (h *Handler) HandleDelivery(msg *messagebroker.Message) error {
+	ctx := messagebroker.ContextFromHeaders(context.Background(), msg.Headers)
+
+	message := &DeadLetterMessage{
+		ID:          msg.ID,
+		ContentType: msg.ContentType,
+		Body:        msg.Body,
+		Headers:     msg.Headers,
+		RoutingKey:  msg.RoutingKey,
+	}
+	deadLetter := message.ToDeadLetter(time.Now())
+
+	if err := h.deadLetterArchiver.Archive(ctx, deadLetter); err != nil {
+		slog.ErrorContext(ctx, "Failed to archive dead letter", "error", err, "dead_letter_id", deadLetter.ID)
+		return err
+	}
+
+	slog.WarnContext(ctx, "Dead letter archived",
+		"dead_letter_id", deadLetter.ID,
+		"queue", deadLetter.Queue,
+		"attempts", deadLetter.Attempts,
+		"reason", deadLetter.FailureReason,
+	)
+	return nil
+}
@@ -0,0 +1,17 @@
+package archiver
+
+import (
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHandler is a mock implementation of Handler for testing
+type MockHandler struct {
+	mock.Mock
+}
+
+// HandleDelivery mocks the HandleDelivery method
+func (m *MockHandler) HandleDelivery(msg *messagebroker.Message) error {
+	args := m.Called(msg)
+	return args.Error(0)
+}
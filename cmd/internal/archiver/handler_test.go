@@ -0,0 +1,114 @@
+package archiver
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		deadLetterArchiver DeadLetterArchiver
+		expectedError      string
+	}{
+		{
+			name:               "when dead letter archiver is provided it should create handler successfully and no error",
+			deadLetterArchiver: new(MockDeadLetterArchiverService),
+			expectedError:      "",
+		},
+		{
+			name:               "when dead letter archiver is nil it should return error",
+			deadLetterArchiver: nil,
+			expectedError:      "archiver handler: dead letter archiver cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dead letter archiver already prepared in test struct)
+
+			// Act
+			result, err := NewHandler(tt.deadLetterArchiver)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestHandler_HandleDelivery(t *testing.T) {
+	tests := []struct {
+		name             string
+		message          *messagebroker.Message
+		mockArchiveError error
+		expectedError    error
+	}{
+		{
+			name: "when dead letter is archived it should keep its raw body, content type and message ID and return no error",
+			message: &messagebroker.Message{
+				ID:          "msg_123",
+				ContentType: messagebroker.ProtobufContentType,
+				Body:        []byte{0x0a, 0x07, 'p', 'a', 'y', '_', '1', '2', '3', 0x00, 0xff},
+				Headers: map[string]interface{}{
+					messagebroker.DeadLetterIDHeader:  "dl_123",
+					messagebroker.FailureReasonHeader: "gateway timeout",
+				},
+				RoutingKey: "payments.created.dlq",
+			},
+			mockArchiveError: nil,
+			expectedError:    nil,
+		},
+		{
+			name: "when archiving fails it should return the error so the message is requeued",
+			message: &messagebroker.Message{
+				Body:       []byte(`{"id":"pay_123"}`),
+				Headers:    map[string]interface{}{messagebroker.DeadLetterIDHeader: "dl_123"},
+				RoutingKey: "payments.created.dlq",
+			},
+			mockArchiveError: errors.New("dead letter archiver: save: connection refused"),
+			expectedError:    errors.New("dead letter archiver: save: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockArchiver := new(MockDeadLetterArchiverService)
+			mockArchiver.On("Archive", mock.Anything, mock.MatchedBy(func(dl *domain.DeadLetter) bool {
+				return dl.ID == "dl_123" &&
+					bytes.Equal(dl.Body, tt.message.Body) &&
+					dl.ContentType == tt.message.ContentType &&
+					dl.MessageID == tt.message.ID
+			})).Return(tt.mockArchiveError)
+
+			handler := &Handler{deadLetterArchiver: mockArchiver}
+
+			// Act
+			err := handler.HandleDelivery(tt.message)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockArchiver.AssertExpectations(t)
+		})
+	}
+}
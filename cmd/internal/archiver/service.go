@@ -0,0 +1,41 @@
+package archiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+)
+
+// DeadLetterSaver interface for storing dead letters
+type DeadLetterSaver interface {
+	Save(ctx context.Context, deadLetter *domain.DeadLetter) error
+}
+
+// DeadLetterArchiverService is a service for archiving dead letters
+type DeadLetterArchiverService struct {
+	deadLetterSaver DeadLetterSaver
+}
+
+// NewDeadLetterArchiverService creates a new DeadLetterArchiverService
+// It returns a new DeadLetterArchiverService and an error if the saver is nil
+func NewDeadLetterArchiverService(dls DeadLetterSaver) (*DeadLetterArchiverService, error) {
+	if dls == nil {
+		return nil, errors.New("dead letter archiver: saver cannot be nil")
+	}
+
+	return &DeadLetterArchiverService{
+		deadLetterSaver: dls,
+	}, nil
+}
+
+// Archive stores a dead letter so it can be inspected, replayed or discarded
+// It returns an error if the dead letter cannot be saved
+func (s *DeadLetterArchiverService) Archive(ctx context.Context, deadLetter *domain.DeadLetter) error {
+	if err := s.deadLetterSaver.Save(ctx, deadLetter); err != nil {
+		return fmt.Errorf("dead letter archiver: save: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package archiver
+
+import (
+	"context"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockDeadLetterArchiverService is a mock implementation of DeadLetterArchiver for testing
+type MockDeadLetterArchiverService struct {
+	mock.Mock
+}
+
+// Archive mocks the Archive method
+func (m *MockDeadLetterArchiverService) Archive(ctx context.Context, deadLetter *domain.DeadLetter) error {
+	args := m.Called(ctx, deadLetter)
+	return args.Error(0)
+}
@@ -0,0 +1,96 @@
+package archiver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/deadletterstorer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewDeadLetterArchiverService(t *testing.T) {
+	tests := []struct {
+		name          string
+		saver         DeadLetterSaver
+		expectedError string
+	}{
+		{
+			name:          "when saver is provided it should create service successfully and no error",
+			saver:         new(deadletterstorer.MockDeadLetterRepository),
+			expectedError: "",
+		},
+		{
+			name:          "when saver is nil it should return error",
+			saver:         nil,
+			expectedError: "dead letter archiver: saver cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Saver already prepared in test struct)
+
+			// Act
+			result, err := NewDeadLetterArchiverService(tt.saver)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestDeadLetterArchiverService_Archive(t *testing.T) {
+	tests := []struct {
+		name          string
+		deadLetter    *domain.DeadLetter
+		mockSaveError error
+		expectedError error
+	}{
+		{
+			name:          "when dead letter is saved it should return no error",
+			deadLetter:    &domain.DeadLetter{ID: "dl_123", Status: domain.DeadLetterStatusPending},
+			mockSaveError: nil,
+			expectedError: nil,
+		},
+		{
+			name:          "when save fails it should return wrapped error",
+			deadLetter:    &domain.DeadLetter{ID: "dl_123", Status: domain.DeadLetterStatusPending},
+			mockSaveError: errors.New("connection refused"),
+			expectedError: errors.New("dead letter archiver: save: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockSaver := new(deadletterstorer.MockDeadLetterRepository)
+			mockSaver.On("Save", mock.Anything, tt.deadLetter).Return(tt.mockSaveError)
+
+			service := &DeadLetterArchiverService{deadLetterSaver: mockSaver}
+
+			// Act
+			err := service.Archive(context.Background(), tt.deadLetter)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockSaver.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package authenticator
+
+// Build creates a new Handler with all dependencies wired up
+// It builds a new handler and returns an error if the builder fails
+func Build(db APIKeyDB, tv TokenVerifier) (*Handler, error) {
+	ar, err := NewAPIKeyRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	as, err := NewAuthenticatorService(ar, tv)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := NewHandler(as)
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
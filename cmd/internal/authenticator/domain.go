@@ -0,0 +1,37 @@
+package authenticator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+)
+
+// ErrUnauthenticated is returned when the request carries no valid credentials
+var ErrUnauthenticated = errors.New("missing or invalid credentials")
+
+// HashAPIKey returns the SHA-256 hex digest of an API key, the only form of the key that is stored
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// ParseScopes converts granted scope names into scopes
+func ParseScopes(names []string) []domain.Scope {
+	scopes := make([]domain.Scope, 0, len(names))
+	for _, name := range names {
+		scopes = append(scopes, domain.Scope(name))
+	}
+	return scopes
+}
+
+// APIKeyCredential returns the credential identifier of an API key
+func APIKeyCredential(keyID string) string {
+	return "api_key:" + keyID
+}
+
+// TokenCredential returns the credential identifier of the tokens issued to a subject
+func TokenCredential(subject string) string {
+	return "token:" + subject
+}
@@ -0,0 +1,72 @@
+package authenticator
+
+import (
+	"testing"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashAPIKey(t *testing.T) {
+	tests := []struct {
+		name         string
+		key          string
+		expectedHash string
+	}{
+		{
+			name:         "when key is provided it should return its sha256 hex digest",
+			key:          "secret",
+			expectedHash: "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b",
+		},
+		{
+			name:         "when key is empty it should return the digest of the empty string",
+			key:          "",
+			expectedHash: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Key already prepared in test struct)
+
+			// Act
+			result := HashAPIKey(tt.key)
+
+			// Assert
+			assert.Equal(t, tt.expectedHash, result)
+		})
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		name           string
+		names          []string
+		expectedScopes []domain.Scope
+	}{
+		{
+			name:           "when names are provided it should return the scopes in order",
+			names:          []string{"payments:read", "payments:write"},
+			expectedScopes: []domain.Scope{domain.ScopePaymentsRead, domain.ScopePaymentsWrite},
+		},
+		{
+			name:           "when names are nil it should return an empty list",
+			names:          nil,
+			expectedScopes: []domain.Scope{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Names already prepared in test struct)
+
+			// Act
+			result := ParseScopes(tt.names)
+
+			// Assert
+			assert.Equal(t, tt.expectedScopes, result)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package authenticator
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticator defines the interface for authentication business logic
+type Authenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*domain.Principal, error)
+	AuthenticateToken(ctx context.Context, token string) (*domain.Principal, error)
+}
+
+// Handler provides the authentication middlewares
+type Handler struct {
+	authenticator Authenticator
+}
+
+// NewHandler creates a new authentication handler
+// It returns a new handler and an error if the authenticator is nil
+func NewHandler(a Authenticator) (*Handler, error) {
+	if a == nil {
+		return nil, errors.New("authenticator handler: authenticator cannot be nil")
+	}
+
+	return &Handler{
+		authenticator: a,
+	}, nil
+}
+
+// Authenticate authenticates the request with the X-API-Key header or an Authorization bearer token
+// It puts the principal in the request context and aborts with 401 if the credentials are missing or invalid
+func (h *Handler) Authenticate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var principal *domain.Principal
+	var err error
+
+	apiKey := c.GetHeader("X-API-Key")
+	token, isBearer := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+	switch {
+	case apiKey != "":
+		principal, err = h.authenticator.AuthenticateAPIKey(ctx, apiKey)
+	case isBearer && strings.TrimSpace(token) != "":
+		principal, err = h.authenticator.AuthenticateToken(ctx, strings.TrimSpace(token))
+	default:
+		err = ErrUnauthenticated
+	}
+
+	if errors.Is(err, ErrUnauthenticated) {
+		slog.DebugContext(ctx, "Rejecting unauthenticated request", "error", err)
+		c.Header("WWW-Authenticate", "Bearer")
+		problem.Respond(c, problem.New(problem.CodeUnauthorized, "authentication required"))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to authenticate request", "error", err)
+		problem.Respond(c, problem.New(problem.CodeInternal, "failed to authenticate request"))
+		return
+	}
+
+	c.Request = c.Request.WithContext(domain.WithPrincipal(ctx, principal))
+	c.Next()
+}
+
+// RequireScope returns a middleware that aborts with 403 if the principal was not granted the scope
+func (h *Handler) RequireScope(scope domain.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := domain.PrincipalFromContext(c.Request.Context())
+		if !ok {
+			problem.Respond(c, problem.New(problem.CodeUnauthorized, "authentication required"))
+			return
+		}
+
+		if !principal.HasScope(scope) {
+			problem.Respond(c, problem.New(problem.CodeForbidden, "insufficient scope, "+string(scope)+" is required"))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,23 @@
+package authenticator
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHandler is a mock implementation of Handler
+type MockHandler struct {
+	mock.Mock
+}
+
+// Authenticate authenticates the request
+func (m *MockHandler) Authenticate(c *gin.Context) {
+	m.Called(c)
+}
+
+// RequireScope returns a middleware that requires the scope
+func (m *MockHandler) RequireScope(scope domain.Scope) gin.HandlerFunc {
+	args := m.Called(scope)
+	return args.Get(0).(gin.HandlerFunc)
+}
@@ -0,0 +1,230 @@
+package authenticator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		authenticator Authenticator
+		expectedError string
+	}{
+		{
+			name:          "when authenticator is provided it should create handler successfully and no error",
+			authenticator: new(MockAuthenticatorService),
+			expectedError: "",
+		},
+		{
+			name:          "when authenticator is nil it should return error",
+			authenticator: nil,
+			expectedError: "authenticator handler: authenticator cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Authenticator already prepared in test struct)
+
+			// Act
+			result, err := NewHandler(tt.authenticator)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestHandler_Authenticate(t *testing.T) {
+	principal := &domain.Principal{Subject: "user_123", Scopes: []domain.Scope{domain.ScopePaymentsWrite}}
+
+	tests := []struct {
+		name               string
+		apiKey             string
+		authorization      string
+		mockMethod         string
+		mockCredential     string
+		mockPrincipal      *domain.Principal
+		mockError          error
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when api key is valid it should put the principal in context and continue",
+			apiKey:             "secret",
+			mockMethod:         "AuthenticateAPIKey",
+			mockCredential:     "secret",
+			mockPrincipal:      principal,
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "user_123",
+		},
+		{
+			name:               "when bearer token is valid it should put the principal in context and continue",
+			authorization:      "Bearer token_123",
+			mockMethod:         "AuthenticateToken",
+			mockCredential:     "token_123",
+			mockPrincipal:      principal,
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "user_123",
+		},
+		{
+			name:               "when no credentials are sent it should return 401",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedMessage:    "authentication required",
+		},
+		{
+			name:               "when authorization is not a bearer token it should return 401",
+			authorization:      "Basic dXNlcjpwYXNz",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedMessage:    "authentication required",
+		},
+		{
+			name:               "when api key is unknown it should return 401",
+			apiKey:             "unknown",
+			mockMethod:         "AuthenticateAPIKey",
+			mockCredential:     "unknown",
+			mockError:          fmt.Errorf("%w: unknown api key", ErrUnauthenticated),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedMessage:    "authentication required",
+		},
+		{
+			name:               "when authentication fails with internal error it should return 500",
+			apiKey:             "secret",
+			mockMethod:         "AuthenticateAPIKey",
+			mockCredential:     "secret",
+			mockError:          errors.New("connection refused"),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "failed to authenticate request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockAuthenticator := new(MockAuthenticatorService)
+			if tt.mockMethod != "" {
+				mockAuthenticator.On(tt.mockMethod, mock.Anything, tt.mockCredential).Return(tt.mockPrincipal, tt.mockError)
+			}
+
+			handler := &Handler{authenticator: mockAuthenticator}
+
+			router := gin.New()
+			router.GET("/payments", handler.Authenticate, func(c *gin.Context) {
+				p, _ := domain.PrincipalFromContext(c.Request.Context())
+				c.JSON(http.StatusOK, gin.H{"message": p.Subject})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/payments", nil)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			router.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if w.Code >= http.StatusBadRequest {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedMessage, response["detail"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, response["message"])
+			}
+
+			mockAuthenticator.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_RequireScope(t *testing.T) {
+	tests := []struct {
+		name               string
+		principal          *domain.Principal
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when principal has the scope it should continue",
+			principal:          &domain.Principal{Subject: "user_123", Scopes: []domain.Scope{domain.ScopePaymentsWrite}},
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "ok",
+		},
+		{
+			name:               "when principal lacks the scope it should return 403",
+			principal:          &domain.Principal{Subject: "user_123", Scopes: []domain.Scope{domain.ScopePaymentsRead}},
+			expectedStatusCode: http.StatusForbidden,
+			expectedMessage:    "insufficient scope, payments:write is required",
+		},
+		{
+			name:               "when there is no principal it should return 401",
+			principal:          nil,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedMessage:    "authentication required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := &Handler{authenticator: new(MockAuthenticatorService)}
+
+			router := gin.New()
+			router.GET("/payments", handler.RequireScope(domain.ScopePaymentsWrite), func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "ok"})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/payments", nil)
+			if tt.principal != nil {
+				req = req.WithContext(domain.WithPrincipal(req.Context(), tt.principal))
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			router.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if w.Code >= http.StatusBadRequest {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedMessage, response["detail"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, response["message"])
+			}
+		})
+	}
+}
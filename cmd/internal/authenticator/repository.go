@@ -0,0 +1,56 @@
+package authenticator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+)
+
+// APIKeyDB defines the database operations required by APIKeyRepository
+type APIKeyDB interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) database.RowScanner
+}
+
+// APIKeyRepository finds the principal of hashed API keys
+type APIKeyRepository struct {
+	db APIKeyDB
+}
+
+// NewAPIKeyRepository creates a new APIKeyRepository
+// It returns a new APIKeyRepository and an error if the database is nil
+func NewAPIKeyRepository(db APIKeyDB) (*APIKeyRepository, error) {
+	if db == nil {
+		return nil, errors.New("api key repository: database cannot be nil")
+	}
+
+	return &APIKeyRepository{db: db}, nil
+}
+
+// FindByHash finds the principal of an active API key by its hash
+// It returns nil and no error if no active key has the hash
+func (r *APIKeyRepository) FindByHash(ctx context.Context, keyHash string) (*domain.Principal, error) {
+	query := `
+		SELECT id, subject, scopes
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`
+
+	var id, subject string
+	var scopes []string
+	err := r.db.QueryRowContext(ctx, query, keyHash).Scan(&id, &subject, pq.Array(&scopes))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("api key repository: find by hash: %w", err)
+	}
+
+	return &domain.Principal{Subject: subject, Credential: APIKeyCredential(id), Scopes: ParseScopes(scopes)}, nil
+}
@@ -0,0 +1,22 @@
+package authenticator
+
+import (
+	"context"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAPIKeyRepository is a mock implementation of APIKeyRepository
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+// FindByHash finds the principal of an API key by its hash
+func (m *MockAPIKeyRepository) FindByHash(ctx context.Context, keyHash string) (*domain.Principal, error) {
+	args := m.Called(ctx, keyHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Principal), args.Error(1)
+}
@@ -0,0 +1,127 @@
+package authenticator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewAPIKeyRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            APIKeyDB
+		expectedError string
+	}{
+		{
+			name:          "when database is provided it should create repository successfully and no error",
+			db:            new(database.MockDB),
+			expectedError: "",
+		},
+		{
+			name:          "when database is nil it should return error",
+			db:            nil,
+			expectedError: "api key repository: database cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Database already prepared in test struct)
+
+			// Act
+			result, err := NewAPIKeyRepository(tt.db)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestAPIKeyRepository_FindByHash(t *testing.T) {
+	tests := []struct {
+		name              string
+		mockID            string
+		mockSubject       string
+		mockScopes        []string
+		mockScanError     error
+		expectedPrincipal *domain.Principal
+		expectedError     error
+	}{
+		{
+			name:        "when active key exists it should return its principal and no error",
+			mockID:      "key_001",
+			mockSubject: "user_123",
+			mockScopes:  []string{"payments:read", "payments:write"},
+			expectedPrincipal: &domain.Principal{
+				Subject:    "user_123",
+				Credential: "api_key:key_001",
+				Scopes:     []domain.Scope{domain.ScopePaymentsRead, domain.ScopePaymentsWrite},
+			},
+			expectedError: nil,
+		},
+		{
+			name:              "when no active key has the hash it should return nil and no error",
+			mockScanError:     sql.ErrNoRows,
+			expectedPrincipal: nil,
+			expectedError:     nil,
+		},
+		{
+			name:          "when query fails it should return wrapped error",
+			mockScanError: errors.New("connection refused"),
+			expectedError: errors.New("api key repository: find by hash: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockScanner := new(database.MockRowScanner)
+
+			if tt.mockScanError == nil {
+				mockScanner.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+					dest := args.Get(0).([]any)
+					*dest[0].(*string) = tt.mockID
+					*dest[1].(*string) = tt.mockSubject
+					*dest[2].(*pq.StringArray) = tt.mockScopes
+				}).Return(nil)
+			} else {
+				mockScanner.On("Scan", mock.Anything).Return(tt.mockScanError)
+			}
+
+			mockDB.On("QueryRowContext", mock.Anything, mock.Anything, mock.Anything).Return(mockScanner)
+
+			repo := &APIKeyRepository{db: mockDB}
+
+			// Act
+			result, err := repo.FindByHash(context.Background(), "hash_123")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedPrincipal, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
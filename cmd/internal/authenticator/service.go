@@ -0,0 +1,74 @@
+package authenticator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/jwt"
+)
+
+// APIKeyFinder interface for finding the principal of an API key
+type APIKeyFinder interface {
+	FindByHash(ctx context.Context, keyHash string) (*domain.Principal, error)
+}
+
+// TokenVerifier interface for verifying bearer tokens
+type TokenVerifier interface {
+	Verify(token string) (*jwt.Claims, error)
+}
+
+// AuthenticatorService resolves the principal of API keys and bearer tokens
+type AuthenticatorService struct {
+	apiKeyFinder  APIKeyFinder  // APIKeyFinder implements the APIKeyFinder interface
+	tokenVerifier TokenVerifier // TokenVerifier implements the TokenVerifier interface
+}
+
+// NewAuthenticatorService creates a new AuthenticatorService
+// It returns a new AuthenticatorService and an error if any dependency is nil
+func NewAuthenticatorService(af APIKeyFinder, tv TokenVerifier) (*AuthenticatorService, error) {
+	if af == nil {
+		return nil, errors.New("authenticator: api key finder cannot be nil")
+	}
+	if tv == nil {
+		return nil, errors.New("authenticator: token verifier cannot be nil")
+	}
+
+	return &AuthenticatorService{
+		apiKeyFinder:  af,
+		tokenVerifier: tv,
+	}, nil
+}
+
+// AuthenticateAPIKey resolves the principal of an API key
+// It returns ErrUnauthenticated if the key is unknown or revoked
+func (as *AuthenticatorService) AuthenticateAPIKey(ctx context.Context, key string) (*domain.Principal, error) {
+	principal, err := as.apiKeyFinder.FindByHash(ctx, HashAPIKey(key))
+	if err != nil {
+		return nil, fmt.Errorf("authenticator: find api key: %w", err)
+	}
+	if principal == nil {
+		return nil, fmt.Errorf("%w: unknown api key", ErrUnauthenticated)
+	}
+
+	return principal, nil
+}
+
+// AuthenticateToken resolves the principal of a bearer token
+// It returns ErrUnauthenticated if the token cannot be trusted or has no subject
+func (as *AuthenticatorService) AuthenticateToken(ctx context.Context, token string) (*domain.Principal, error) {
+	claims, err := as.tokenVerifier.Verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrUnauthenticated)
+	}
+
+	return &domain.Principal{
+		Subject:    claims.Subject,
+		Credential: TokenCredential(claims.Subject),
+		Scopes:     ParseScopes(claims.Scopes()),
+	}, nil
+}
@@ -0,0 +1,31 @@
+package authenticator
+
+import (
+	"context"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAuthenticatorService is a mock implementation of AuthenticatorService
+type MockAuthenticatorService struct {
+	mock.Mock
+}
+
+// AuthenticateAPIKey resolves the principal of an API key
+func (m *MockAuthenticatorService) AuthenticateAPIKey(ctx context.Context, key string) (*domain.Principal, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Principal), args.Error(1)
+}
+
+// AuthenticateToken resolves the principal of a bearer token
+func (m *MockAuthenticatorService) AuthenticateToken(ctx context.Context, token string) (*domain.Principal, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Principal), args.Error(1)
+}
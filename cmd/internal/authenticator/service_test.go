@@ -0,0 +1,172 @@
+package authenticator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewAuthenticatorService(t *testing.T) {
+	tests := []struct {
+		name          string
+		apiKeyFinder  APIKeyFinder
+		tokenVerifier TokenVerifier
+		expectedError string
+	}{
+		{
+			name:          "when all dependencies are provided it should create service successfully and no error",
+			apiKeyFinder:  new(MockAPIKeyRepository),
+			tokenVerifier: new(jwt.MockVerifier),
+			expectedError: "",
+		},
+		{
+			name:          "when api key finder is nil it should return error",
+			apiKeyFinder:  nil,
+			tokenVerifier: new(jwt.MockVerifier),
+			expectedError: "authenticator: api key finder cannot be nil",
+		},
+		{
+			name:          "when token verifier is nil it should return error",
+			apiKeyFinder:  new(MockAPIKeyRepository),
+			tokenVerifier: nil,
+			expectedError: "authenticator: token verifier cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewAuthenticatorService(tt.apiKeyFinder, tt.tokenVerifier)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestAuthenticatorService_AuthenticateAPIKey(t *testing.T) {
+	tests := []struct {
+		name              string
+		mockPrincipal     *domain.Principal
+		mockError         error
+		expectedPrincipal *domain.Principal
+		expectedError     error
+	}{
+		{
+			name:              "when key is active it should return its principal and no error",
+			mockPrincipal:     &domain.Principal{Subject: "user_123", Scopes: []domain.Scope{domain.ScopePaymentsWrite}},
+			expectedPrincipal: &domain.Principal{Subject: "user_123", Scopes: []domain.Scope{domain.ScopePaymentsWrite}},
+			expectedError:     nil,
+		},
+		{
+			name:          "when key is unknown it should return unauthenticated error",
+			mockPrincipal: nil,
+			expectedError: errors.New("missing or invalid credentials: unknown api key"),
+		},
+		{
+			name:          "when lookup fails it should return wrapped error",
+			mockError:     errors.New("connection refused"),
+			expectedError: errors.New("authenticator: find api key: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockFinder := new(MockAPIKeyRepository)
+			mockFinder.On("FindByHash", mock.Anything, HashAPIKey("secret")).Return(tt.mockPrincipal, tt.mockError)
+
+			service := &AuthenticatorService{apiKeyFinder: mockFinder, tokenVerifier: new(jwt.MockVerifier)}
+
+			// Act
+			result, err := service.AuthenticateAPIKey(context.Background(), "secret")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedPrincipal, result)
+			}
+
+			mockFinder.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthenticatorService_AuthenticateToken(t *testing.T) {
+	tests := []struct {
+		name                    string
+		mockClaims              *jwt.Claims
+		mockError               error
+		expectedPrincipal       *domain.Principal
+		expectedError           error
+		expectedUnauthenticated bool
+	}{
+		{
+			name:       "when token is valid it should return principal from its claims and no error",
+			mockClaims: &jwt.Claims{Subject: "user_123", Scope: "payments:read payments:write"},
+			expectedPrincipal: &domain.Principal{
+				Subject:    "user_123",
+				Credential: "token:user_123",
+				Scopes:     []domain.Scope{domain.ScopePaymentsRead, domain.ScopePaymentsWrite},
+			},
+			expectedError: nil,
+		},
+		{
+			name:                    "when token is invalid it should return unauthenticated error",
+			mockError:               jwt.ErrInvalidToken,
+			expectedError:           errors.New("missing or invalid credentials: invalid token"),
+			expectedUnauthenticated: true,
+		},
+		{
+			name:                    "when token has no subject it should return unauthenticated error",
+			mockClaims:              &jwt.Claims{Scope: "admin"},
+			expectedError:           errors.New("missing or invalid credentials: token has no subject"),
+			expectedUnauthenticated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockVerifier := new(jwt.MockVerifier)
+			mockVerifier.On("Verify", "token_123").Return(tt.mockClaims, tt.mockError)
+
+			service := &AuthenticatorService{apiKeyFinder: new(MockAPIKeyRepository), tokenVerifier: mockVerifier}
+
+			// Act
+			result, err := service.AuthenticateToken(context.Background(), "token_123")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Equal(t, tt.expectedUnauthenticated, errors.Is(err, ErrUnauthenticated))
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedPrincipal, result)
+			}
+
+			mockVerifier.AssertExpectations(t)
+		})
+	}
+}
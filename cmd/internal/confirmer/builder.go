@@ -0,0 +1,50 @@
+package confirmer
+
+import (
+	"net/http"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentstorer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/walletclient"
+)
+
+// Build creates a new Handler with all dependencies wired up
+// It builds a new handler and returns an error if the builder fails
+func Build(db paymentstorer.PaymentDB, rc *http.Client, wb walletclient.CircuitBreaker, locker paymentlocker.AdvisoryLocker, policy ConfirmPolicy) (*Handler, error) {
+	ps, err := paymentstorer.NewStorer(db)
+	if err != nil {
+		return nil, err
+	}
+
+	pcf, err := NewPendingConfirmFinderRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	wc, err := walletclient.NewWalletClient(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	bwc, err := walletclient.NewBreakerWalletClient(wc, wb)
+	if err != nil {
+		return nil, err
+	}
+
+	pl, err := paymentlocker.NewLocker(locker)
+	if err != nil {
+		return nil, err
+	}
+
+	pcs, err := NewPaymentConfirmerService(pcf, bwc, ps, pl, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := NewHandler(pcs)
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
@@ -0,0 +1,33 @@
+package confirmer
+
+import (
+	"errors"
+	"time"
+)
+
+// ConfirmPolicy defines when a payment pending confirmation is retried
+// Payments pending confirmation were already charged by the gateway, so they are retried until the wallet confirms them
+type ConfirmPolicy struct {
+	Threshold time.Duration // Time without progress after which a payment pending confirmation is retried
+	BatchSize int           // Maximum number of payments retried per run
+}
+
+// Validate validates the confirm policy
+// It returns an error if the policy is invalid
+func (p *ConfirmPolicy) Validate() error {
+	if p.Threshold <= 0 {
+		return errors.New("threshold must be greater than 0")
+	}
+	if p.BatchSize <= 0 {
+		return errors.New("batch size must be greater than 0")
+	}
+	return nil
+}
+
+// ConfirmResult summarizes the outcome of a confirm retry run
+type ConfirmResult struct {
+	Found     int `json:"found"`     // Payments pending confirmation found
+	Confirmed int `json:"confirmed"` // Payments confirmed in the wallet and completed
+	Skipped   int `json:"skipped"`   // Payments that left the pending_confirm status before being confirmed
+	Errors    int `json:"errors"`    // Payments that could not be confirmed in this run
+}
@@ -0,0 +1,50 @@
+package confirmer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirmPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        *ConfirmPolicy
+		expectedError string
+	}{
+		{
+			name:          "when policy has all fields it should pass validation and no error",
+			policy:        &ConfirmPolicy{Threshold: time.Minute, BatchSize: 100},
+			expectedError: "",
+		},
+		{
+			name:          "when threshold is zero it should return error with message 'threshold must be greater than 0'",
+			policy:        &ConfirmPolicy{Threshold: 0, BatchSize: 100},
+			expectedError: "threshold must be greater than 0",
+		},
+		{
+			name:          "when batch size is negative it should return error with message 'batch size must be greater than 0'",
+			policy:        &ConfirmPolicy{Threshold: time.Minute, BatchSize: -1},
+			expectedError: "batch size must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Policy already prepared in test struct)
+
+			// Act
+			err := tt.policy.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
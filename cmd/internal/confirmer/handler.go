@@ -0,0 +1,52 @@
+package confirmer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// PaymentConfirmer defines the interface for confirm retry business logic
+type PaymentConfirmer interface {
+	Confirm(ctx context.Context) (*ConfirmResult, error)
+}
+
+// Handler handles scheduled confirm retry runs
+type Handler struct {
+	paymentConfirmer PaymentConfirmer
+}
+
+// NewHandler creates a new confirm retry handler
+// It returns a new confirm retry handler and an error if the payment confirmer is nil
+func NewHandler(pc PaymentConfirmer) (*Handler, error) {
+	if pc == nil {
+		return nil, errors.New("confirmer handler: payment confirmer cannot be nil")
+	}
+
+	return &Handler{
+		paymentConfirmer: pc,
+	}, nil
+}
+
+// Run runs a confirm retry pass over payments pending confirmation
+// It returns an error if the payments pending confirmation cannot be looked up
+func (h *Handler) Run(ctx context.Context) error {
+	result, err := h.paymentConfirmer.Confirm(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retry payment confirmations", "error", err)
+		return err
+	}
+
+	if result.Found == 0 {
+		slog.DebugContext(ctx, "No payments pending confirmation found")
+		return nil
+	}
+
+	slog.InfoContext(ctx, "Payment confirmations retried",
+		"found", result.Found,
+		"confirmed", result.Confirmed,
+		"skipped", result.Skipped,
+		"errors", result.Errors,
+	)
+	return nil
+}
@@ -0,0 +1,18 @@
+package confirmer
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHandler is a mock implementation of Handler for testing
+type MockHandler struct {
+	mock.Mock
+}
+
+// Run mocks the Run method
+func (m *MockHandler) Run(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
@@ -0,0 +1,100 @@
+package confirmer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		name             string
+		paymentConfirmer PaymentConfirmer
+		expectedError    string
+	}{
+		{
+			name:             "when payment confirmer is provided it should create handler successfully and no error",
+			paymentConfirmer: new(MockPaymentConfirmerService),
+			expectedError:    "",
+		},
+		{
+			name:             "when payment confirmer is nil it should return error",
+			paymentConfirmer: nil,
+			expectedError:    "confirmer handler: payment confirmer cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Payment confirmer already prepared in test struct)
+
+			// Act
+			result, err := NewHandler(tt.paymentConfirmer)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestHandler_Run(t *testing.T) {
+	tests := []struct {
+		name             string
+		mockResult       *ConfirmResult
+		mockConfirmError error
+		expectedError    error
+	}{
+		{
+			name:             "when payments are confirmed it should return no error",
+			mockResult:       &ConfirmResult{Found: 2, Confirmed: 1, Errors: 1},
+			mockConfirmError: nil,
+			expectedError:    nil,
+		},
+		{
+			name:             "when no payments are pending confirmation it should return no error",
+			mockResult:       &ConfirmResult{},
+			mockConfirmError: nil,
+			expectedError:    nil,
+		},
+		{
+			name:             "when confirm retry fails it should return confirm error",
+			mockResult:       nil,
+			mockConfirmError: errors.New("payment confirmer: find pending confirm: database error"),
+			expectedError:    errors.New("payment confirmer: find pending confirm: database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockConfirmer := new(MockPaymentConfirmerService)
+			mockConfirmer.On("Confirm", mock.Anything).Return(tt.mockResult, tt.mockConfirmError)
+
+			handler := &Handler{paymentConfirmer: mockConfirmer}
+
+			// Act
+			err := handler.Run(context.Background())
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockConfirmer.AssertExpectations(t)
+		})
+	}
+}
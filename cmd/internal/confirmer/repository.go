@@ -0,0 +1,76 @@
+package confirmer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+)
+
+// PendingConfirmDB defines the database operations required by PendingConfirmFinderRepository
+type PendingConfirmDB interface {
+	QueryContext(ctx context.Context, query string, args ...any) (database.Rows, error)
+}
+
+// PendingConfirmFinderRepository finds payments charged by the gateway whose wallet confirmation is pending
+type PendingConfirmFinderRepository struct {
+	db PendingConfirmDB
+}
+
+// NewPendingConfirmFinderRepository creates a new PendingConfirmFinderRepository
+// It returns a new PendingConfirmFinderRepository and an error if the database is nil
+func NewPendingConfirmFinderRepository(db PendingConfirmDB) (*PendingConfirmFinderRepository, error) {
+	if db == nil {
+		return nil, errors.New("pending confirm finder: database cannot be nil")
+	}
+
+	return &PendingConfirmFinderRepository{db: db}, nil
+}
+
+// FindPendingConfirm finds payments pending confirmation last updated before the given time
+// It returns the payments with their gateway reference, oldest first
+func (r *PendingConfirmFinderRepository) FindPendingConfirm(ctx context.Context, olderThan time.Time, limit int) ([]*domain.Payment, error) {
+	query := `
+		SELECT id, COALESCE(idempotency_key, ''), user_id, amount, currency, status, created_at, updated_at, COALESCE(gateway_ref, '')
+		FROM payments
+		WHERE status = $1
+			AND updated_at < $2
+		ORDER BY updated_at ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, domain.StatusPendingConfirm, olderThan, limit)
+	if err != nil {
+		return nil, fmt.Errorf("pending confirm finder: find pending confirm: %w", err)
+	}
+	defer rows.Close()
+
+	payments := []*domain.Payment{}
+	for rows.Next() {
+		var payment domain.Payment
+		err := rows.Scan(
+			&payment.ID,
+			&payment.IdempotencyKey,
+			&payment.UserID,
+			&payment.Amount,
+			&payment.Currency,
+			&payment.Status,
+			&payment.CreatedAt,
+			&payment.UpdatedAt,
+			&payment.GatewayRef,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("pending confirm finder: scan payment: %w", err)
+		}
+		payments = append(payments, &payment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pending confirm finder: iterate payments: %w", err)
+	}
+
+	return payments, nil
+}
@@ -0,0 +1,23 @@
+package confirmer
+
+import (
+	"context"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPendingConfirmFinder is a mock implementation of PendingConfirmFinder for testing
+type MockPendingConfirmFinder struct {
+	mock.Mock
+}
+
+// FindPendingConfirm mocks the FindPendingConfirm method
+func (m *MockPendingConfirmFinder) FindPendingConfirm(ctx context.Context, olderThan time.Time, limit int) ([]*domain.Payment, error) {
+	args := m.Called(ctx, olderThan, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Payment), args.Error(1)
+}
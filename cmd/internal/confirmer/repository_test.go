@@ -0,0 +1,178 @@
+package confirmer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewPendingConfirmFinderRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            PendingConfirmDB
+		expectedError string
+	}{
+		{
+			name:          "when database is provided it should create repository successfully and no error",
+			db:            new(database.MockDB),
+			expectedError: "",
+		},
+		{
+			name:          "when database is nil it should return error with message 'pending confirm finder: database cannot be nil'",
+			db:            nil,
+			expectedError: "pending confirm finder: database cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Database already prepared in test struct)
+
+			// Act
+			result, err := NewPendingConfirmFinderRepository(tt.db)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+				assert.NotNil(t, result.db)
+			}
+		})
+	}
+}
+
+func TestPendingConfirmFinderRepository_FindPendingConfirm(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		mockPayments     []*domain.Payment
+		mockQueryError   error
+		mockScanError    error
+		mockRowsError    error
+		expectedPayments []*domain.Payment
+		expectedError    error
+	}{
+		{
+			name: "when payments are pending confirmation it should return them with their gateway reference and no error",
+			mockPayments: []*domain.Payment{
+				{
+					ID:             "pay_123",
+					IdempotencyKey: "key_123",
+					UserID:         "user_123",
+					Amount:         100.50,
+					Currency:       domain.CurrencyUSD,
+					Status:         domain.StatusPendingConfirm,
+					GatewayRef:     "gw_123",
+					CreatedAt:      fixedTime,
+					UpdatedAt:      fixedTime,
+				},
+			},
+			expectedPayments: []*domain.Payment{
+				{
+					ID:             "pay_123",
+					IdempotencyKey: "key_123",
+					UserID:         "user_123",
+					Amount:         100.50,
+					Currency:       domain.CurrencyUSD,
+					Status:         domain.StatusPendingConfirm,
+					GatewayRef:     "gw_123",
+					CreatedAt:      fixedTime,
+					UpdatedAt:      fixedTime,
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name:             "when no payments are pending confirmation it should return empty slice and no error",
+			mockPayments:     []*domain.Payment{},
+			expectedPayments: []*domain.Payment{},
+			expectedError:    nil,
+		},
+		{
+			name:           "when query fails it should return wrapped error",
+			mockQueryError: errors.New("connection refused"),
+			expectedError:  errors.New("pending confirm finder: find pending confirm: connection refused"),
+		},
+		{
+			name:          "when scan fails it should return wrapped error",
+			mockScanError: errors.New("scan error"),
+			expectedError: errors.New("pending confirm finder: scan payment: scan error"),
+		},
+		{
+			name:          "when rows iteration fails it should return wrapped error",
+			mockPayments:  []*domain.Payment{},
+			mockRowsError: errors.New("iteration error"),
+			expectedError: errors.New("pending confirm finder: iterate payments: iteration error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockRows := new(database.MockRows)
+
+			if tt.mockQueryError != nil {
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, tt.mockQueryError)
+			} else {
+				if tt.mockScanError != nil {
+					mockRows.On("Next").Return(true).Once()
+					mockRows.On("Scan", mock.Anything).Return(tt.mockScanError)
+				} else {
+					paymentCount := len(tt.mockPayments)
+					if paymentCount > 0 {
+						mockRows.On("Next").Return(true).Times(paymentCount)
+						scanCallCount := 0
+						mockRows.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+							dest := args.Get(0).([]any)
+							payment := tt.mockPayments[scanCallCount]
+							*dest[0].(*string) = payment.ID
+							*dest[1].(*string) = payment.IdempotencyKey
+							*dest[2].(*string) = payment.UserID
+							*dest[3].(*float64) = payment.Amount
+							*dest[4].(*domain.Currency) = payment.Currency
+							*dest[5].(*domain.Status) = payment.Status
+							*dest[6].(*time.Time) = payment.CreatedAt
+							*dest[7].(*time.Time) = payment.UpdatedAt
+							*dest[8].(*string) = payment.GatewayRef
+							scanCallCount++
+						}).Return(nil).Times(paymentCount)
+					}
+					mockRows.On("Next").Return(false).Once()
+					mockRows.On("Err").Return(tt.mockRowsError)
+				}
+				mockRows.On("Close").Return(nil)
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(mockRows, nil)
+			}
+
+			repo := &PendingConfirmFinderRepository{db: mockDB}
+
+			// Act
+			result, err := repo.FindPendingConfirm(context.Background(), fixedTime, 100)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedPayments, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package confirmer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
+)
+
+// PendingConfirmFinder interface for finding payments pending confirmation
+type PendingConfirmFinder interface {
+	FindPendingConfirm(ctx context.Context, olderThan time.Time, limit int) ([]*domain.Payment, error)
+}
+
+// WalletConfirmer interface for confirming reserved funds
+type WalletConfirmer interface {
+	Confirm(ctx context.Context, userID string, amount float64, paymentID string) error
+}
+
+// PaymentRecorder interface for reading payments and recording their status changes
+type PaymentRecorder interface {
+	GetByID(ctx context.Context, paymentID string) (*domain.Payment, error)
+	UpdateStatus(ctx context.Context, paymentID string, status domain.Status, gatewayRef string) error
+}
+
+// PaymentLocker interface for serializing the status changes of each payment
+type PaymentLocker interface {
+	Lock(ctx context.Context, paymentID string) (paymentlocker.Unlocker, error)
+}
+
+// PaymentConfirmerService is a service for retrying the wallet confirmation of charged payments
+type PaymentConfirmerService struct {
+	pendingConfirmFinder PendingConfirmFinder
+	walletConfirmer      WalletConfirmer
+	paymentRecorder      PaymentRecorder
+	paymentLocker        PaymentLocker
+	policy               ConfirmPolicy
+}
+
+// NewPaymentConfirmerService creates a new PaymentConfirmerService
+// It returns a new PaymentConfirmerService and an error if any dependency is nil or the policy is invalid
+func NewPaymentConfirmerService(
+	pcf PendingConfirmFinder,
+	wc WalletConfirmer,
+	rec PaymentRecorder,
+	pl PaymentLocker,
+	policy ConfirmPolicy,
+) (*PaymentConfirmerService, error) {
+	if pcf == nil {
+		return nil, errors.New("payment confirmer: pending confirm finder cannot be nil")
+	}
+	if wc == nil {
+		return nil, errors.New("payment confirmer: wallet confirmer cannot be nil")
+	}
+	if rec == nil {
+		return nil, errors.New("payment confirmer: recorder cannot be nil")
+	}
+	if pl == nil {
+		return nil, errors.New("payment confirmer: payment locker cannot be nil")
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("payment confirmer: invalid policy: %w", err)
+	}
+
+	return &PaymentConfirmerService{
+		pendingConfirmFinder: pcf,
+		walletConfirmer:      wc,
+		paymentRecorder:      rec,
+		paymentLocker:        pl,
+		policy:               policy,
+	}, nil
+}
+
+// Confirm retries the wallet confirmation of the payments pending confirmation
+// It only confirms funds and completes the payment, the gateway is never called again
+// Payments that left the pending_confirm status before being confirmed are skipped
+// It returns the run summary and an error only if the payments cannot be found
+func (pcs *PaymentConfirmerService) Confirm(ctx context.Context) (*ConfirmResult, error) {
+	olderThan := time.Now().Add(-pcs.policy.Threshold)
+
+	payments, err := pcs.pendingConfirmFinder.FindPendingConfirm(ctx, olderThan, pcs.policy.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("payment confirmer: find pending confirm: %w", err)
+	}
+
+	result := &ConfirmResult{Found: len(payments)}
+
+	for _, payment := range payments {
+		confirmed, err := pcs.confirm(ctx, payment)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to confirm payment", "error", err, "payment_id", payment.ID)
+			result.Errors++
+			continue
+		}
+		if !confirmed {
+			result.Skipped++
+			continue
+		}
+		result.Confirmed++
+	}
+
+	return result, nil
+}
+
+// confirm confirms the funds of a payment and marks it as completed keeping its gateway reference
+// The payment is locked and read again first, so a processor redelivery or gateway callback confirming it
+// at the same time finishes before, and a payment they already completed is not confirmed twice
+// It returns false if the payment is no longer pending confirmation
+func (pcs *PaymentConfirmerService) confirm(ctx context.Context, pending *domain.Payment) (bool, error) {
+	lock, err := pcs.paymentLocker.Lock(ctx, pending.ID)
+	if err != nil {
+		return false, fmt.Errorf("payment confirmer: lock payment: %w", err)
+	}
+	defer func() {
+		if err := lock.Unlock(ctx); err != nil {
+			slog.WarnContext(ctx, "Failed to unlock payment", "error", err, "payment_id", pending.ID)
+		}
+	}()
+
+	payment, err := pcs.paymentRecorder.GetByID(ctx, pending.ID)
+	if err != nil {
+		return false, fmt.Errorf("payment confirmer: get payment: %w", err)
+	}
+	if payment.Status != domain.StatusPendingConfirm {
+		return false, nil
+	}
+
+	if err := pcs.walletConfirmer.Confirm(ctx, payment.UserID, payment.Amount, payment.ID); err != nil {
+		return false, fmt.Errorf("payment confirmer: confirm funds: %w", err)
+	}
+
+	if err := pcs.paymentRecorder.UpdateStatus(ctx, payment.ID, domain.StatusCompleted, payment.GatewayRef); err != nil {
+		return false, fmt.Errorf("payment confirmer: update status to completed: %w", err)
+	}
+
+	return true, nil
+}
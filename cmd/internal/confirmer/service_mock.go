@@ -0,0 +1,21 @@
+package confirmer
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPaymentConfirmerService is a mock implementation of PaymentConfirmer for testing
+type MockPaymentConfirmerService struct {
+	mock.Mock
+}
+
+// Confirm mocks the Confirm method
+func (m *MockPaymentConfirmerService) Confirm(ctx context.Context) (*ConfirmResult, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ConfirmResult), args.Error(1)
+}
@@ -0,0 +1,273 @@
+package confirmer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentstorer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/walletclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewPaymentConfirmerService(t *testing.T) {
+	validPolicy := ConfirmPolicy{Threshold: time.Minute, BatchSize: 100}
+
+	tests := []struct {
+		name                 string
+		pendingConfirmFinder PendingConfirmFinder
+		walletConfirmer      WalletConfirmer
+		paymentRecorder      PaymentRecorder
+		paymentLocker        PaymentLocker
+		policy               ConfirmPolicy
+		expectedError        string
+	}{
+		{
+			name:                 "when all dependencies are provided it should create service successfully and no error",
+			pendingConfirmFinder: new(MockPendingConfirmFinder),
+			walletConfirmer:      new(walletclient.MockWalletClient),
+			paymentRecorder:      new(paymentstorer.MockPaymentRepository),
+			paymentLocker:        new(paymentlocker.MockPaymentLockerRepository),
+			policy:               validPolicy,
+			expectedError:        "",
+		},
+		{
+			name:                 "when pending confirm finder is nil it should return error",
+			pendingConfirmFinder: nil,
+			walletConfirmer:      new(walletclient.MockWalletClient),
+			paymentRecorder:      new(paymentstorer.MockPaymentRepository),
+			paymentLocker:        new(paymentlocker.MockPaymentLockerRepository),
+			policy:               validPolicy,
+			expectedError:        "payment confirmer: pending confirm finder cannot be nil",
+		},
+		{
+			name:                 "when wallet confirmer is nil it should return error",
+			pendingConfirmFinder: new(MockPendingConfirmFinder),
+			walletConfirmer:      nil,
+			paymentRecorder:      new(paymentstorer.MockPaymentRepository),
+			paymentLocker:        new(paymentlocker.MockPaymentLockerRepository),
+			policy:               validPolicy,
+			expectedError:        "payment confirmer: wallet confirmer cannot be nil",
+		},
+		{
+			name:                 "when recorder is nil it should return error",
+			pendingConfirmFinder: new(MockPendingConfirmFinder),
+			walletConfirmer:      new(walletclient.MockWalletClient),
+			paymentRecorder:      nil,
+			paymentLocker:        new(paymentlocker.MockPaymentLockerRepository),
+			policy:               validPolicy,
+			expectedError:        "payment confirmer: recorder cannot be nil",
+		},
+		{
+			name:                 "when payment locker is nil it should return error",
+			pendingConfirmFinder: new(MockPendingConfirmFinder),
+			walletConfirmer:      new(walletclient.MockWalletClient),
+			paymentRecorder:      new(paymentstorer.MockPaymentRepository),
+			paymentLocker:        nil,
+			policy:               validPolicy,
+			expectedError:        "payment confirmer: payment locker cannot be nil",
+		},
+		{
+			name:                 "when policy is invalid it should return error",
+			pendingConfirmFinder: new(MockPendingConfirmFinder),
+			walletConfirmer:      new(walletclient.MockWalletClient),
+			paymentRecorder:      new(paymentstorer.MockPaymentRepository),
+			paymentLocker:        new(paymentlocker.MockPaymentLockerRepository),
+			policy:               ConfirmPolicy{Threshold: time.Minute, BatchSize: 0},
+			expectedError:        "payment confirmer: invalid policy: batch size must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewPaymentConfirmerService(tt.pendingConfirmFinder, tt.walletConfirmer, tt.paymentRecorder, tt.paymentLocker, tt.policy)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestPaymentConfirmerService_Confirm(t *testing.T) {
+	payment := &domain.Payment{
+		ID:         "pay_123",
+		UserID:     "user_123",
+		Amount:     100.50,
+		Currency:   domain.CurrencyUSD,
+		Status:     domain.StatusPendingConfirm,
+		GatewayRef: "gw_123",
+	}
+
+	tests := []struct {
+		name                   string
+		mockPayments           []*domain.Payment
+		mockFindError          error
+		mockLockError          error
+		mockCurrentStatus      domain.Status
+		mockGetError           error
+		mockConfirmError       error
+		mockUpdateStatusError  error
+		shouldCallLock         bool
+		shouldCallGet          bool
+		shouldCallConfirm      bool
+		shouldCallUpdateStatus bool
+		expectedResult         *ConfirmResult
+		expectedError          error
+	}{
+		{
+			name:           "when no payments are pending confirmation it should return empty result and no error",
+			mockPayments:   []*domain.Payment{},
+			expectedResult: &ConfirmResult{},
+			expectedError:  nil,
+		},
+		{
+			name:                   "when confirm succeeds it should lock the payment and complete it keeping its gateway reference and no error",
+			mockPayments:           []*domain.Payment{payment},
+			mockCurrentStatus:      domain.StatusPendingConfirm,
+			shouldCallLock:         true,
+			shouldCallGet:          true,
+			shouldCallConfirm:      true,
+			shouldCallUpdateStatus: true,
+			expectedResult:         &ConfirmResult{Found: 1, Confirmed: 1},
+			expectedError:          nil,
+		},
+		{
+			name:              "when the payment left pending confirmation once locked it should skip it without confirming funds",
+			mockPayments:      []*domain.Payment{payment},
+			mockCurrentStatus: domain.StatusCompleted,
+			shouldCallLock:    true,
+			shouldCallGet:     true,
+			expectedResult:    &ConfirmResult{Found: 1, Skipped: 1},
+			expectedError:     nil,
+		},
+		{
+			name:           "when the payment lock cannot be acquired it should count the error and not confirm funds",
+			mockPayments:   []*domain.Payment{payment},
+			mockLockError:  context.DeadlineExceeded,
+			shouldCallLock: true,
+			expectedResult: &ConfirmResult{Found: 1, Errors: 1},
+			expectedError:  nil,
+		},
+		{
+			name:           "when reading the locked payment fails it should count the error and not confirm funds",
+			mockPayments:   []*domain.Payment{payment},
+			mockGetError:   errors.New("database error"),
+			shouldCallLock: true,
+			shouldCallGet:  true,
+			expectedResult: &ConfirmResult{Found: 1, Errors: 1},
+			expectedError:  nil,
+		},
+		{
+			name:              "when confirm fails it should count the error and keep the payment pending confirmation",
+			mockPayments:      []*domain.Payment{payment},
+			mockConfirmError:  domain.ErrWalletUnavailable,
+			mockCurrentStatus: domain.StatusPendingConfirm,
+			shouldCallLock:    true,
+			shouldCallGet:     true,
+			shouldCallConfirm: true,
+			expectedResult:    &ConfirmResult{Found: 1, Errors: 1},
+			expectedError:     nil,
+		},
+		{
+			name:                   "when update status to completed fails it should count the error",
+			mockPayments:           []*domain.Payment{payment},
+			mockUpdateStatusError:  errors.New("database error"),
+			mockCurrentStatus:      domain.StatusPendingConfirm,
+			shouldCallLock:         true,
+			shouldCallGet:          true,
+			shouldCallConfirm:      true,
+			shouldCallUpdateStatus: true,
+			expectedResult:         &ConfirmResult{Found: 1, Errors: 1},
+			expectedError:          nil,
+		},
+		{
+			name:           "when finding payments fails it should return wrapped error",
+			mockPayments:   nil,
+			mockFindError:  errors.New("database error"),
+			expectedResult: nil,
+			expectedError:  errors.New("payment confirmer: find pending confirm: database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockFinder := new(MockPendingConfirmFinder)
+			mockConfirmer := new(walletclient.MockWalletClient)
+			mockRecorder := new(paymentstorer.MockPaymentRepository)
+			mockLocker := new(paymentlocker.MockPaymentLockerRepository)
+			mockUnlocker := new(paymentlocker.MockUnlocker)
+
+			mockFinder.On("FindPendingConfirm", mock.Anything, mock.AnythingOfType("time.Time"), 100).Return(tt.mockPayments, tt.mockFindError)
+
+			if tt.shouldCallLock {
+				if tt.mockLockError != nil {
+					mockLocker.On("Lock", mock.Anything, payment.ID).Return(nil, tt.mockLockError)
+				} else {
+					mockLocker.On("Lock", mock.Anything, payment.ID).Return(mockUnlocker, nil)
+					mockUnlocker.On("Unlock", mock.Anything).Return(nil)
+				}
+			}
+
+			if tt.shouldCallGet {
+				if tt.mockGetError != nil {
+					mockRecorder.On("GetByID", mock.Anything, payment.ID).Return(nil, tt.mockGetError)
+				} else {
+					current := *payment
+					current.Status = tt.mockCurrentStatus
+					mockRecorder.On("GetByID", mock.Anything, payment.ID).Return(&current, nil)
+				}
+			}
+
+			if tt.shouldCallConfirm {
+				mockConfirmer.On("Confirm", mock.Anything, payment.UserID, payment.Amount, payment.ID).Return(tt.mockConfirmError)
+			}
+
+			if tt.shouldCallUpdateStatus {
+				mockRecorder.On("UpdateStatus", mock.Anything, payment.ID, domain.StatusCompleted, payment.GatewayRef).Return(tt.mockUpdateStatusError)
+			}
+
+			service := &PaymentConfirmerService{
+				pendingConfirmFinder: mockFinder,
+				walletConfirmer:      mockConfirmer,
+				paymentRecorder:      mockRecorder,
+				paymentLocker:        mockLocker,
+				policy:               ConfirmPolicy{Threshold: time.Minute, BatchSize: 100},
+			}
+
+			// Act
+			result, err := service.Confirm(context.Background())
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, result)
+			}
+
+			mockFinder.AssertExpectations(t)
+			mockConfirmer.AssertExpectations(t)
+			mockRecorder.AssertExpectations(t)
+			mockLocker.AssertExpectations(t)
+			mockUnlocker.AssertExpectations(t)
+		})
+	}
+}
@@ -3,45 +3,73 @@ package creator
 import (
 	"net/http"
 
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentstorer"
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/walletclient"
 	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
 )
 
+// CreatorDB defines the database operations required by the creator
+type CreatorDB interface {
+	paymentstorer.PaymentDB
+	IdempotencyDB
+}
+
 // Build creates a new Handler with all dependencies wired up
-func Build(db paymentstorer.PaymentDB, rc *http.Client, mbc *messagebroker.Connection, exchange, queueName string) (*Handler, error) {
+func Build(db CreatorDB, rc *http.Client, wb walletclient.CircuitBreaker, locker paymentlocker.AdvisoryLocker, mbc *messagebroker.Connection, exchange, queueName string, format messagebroker.EventFormat, policy IdempotencyPolicy) (*Handler, error) {
 	ps, err := paymentstorer.NewStorer(db)
 	if err != nil {
 		return nil, err
 	}
 
+	ir, err := NewIdempotencyKeyRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
 	wc, err := walletclient.NewWalletClient(rc)
 	if err != nil {
 		return nil, err
 	}
 
+	bwc, err := walletclient.NewBreakerWalletClient(wc, wb)
+	if err != nil {
+		return nil, err
+	}
+
 	p, err := messagebroker.NewPublisher(
 		mbc,
 		messagebroker.PublisherConfig{
-			Exchange:   exchange,  // Topic exchange for routing
-			RoutingKey: queueName, // Queue name as routing key (e.g., payments.created)
+			Exchange:    exchange,  // Topic exchange for routing
+			RoutingKey:  queueName, // Queue name as routing key (e.g., payments.created)
+			EventFormat: format,    // CloudEvents mode and source of the published payments
 		},
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	pp, err := NewPaymentPublisherRepository(p)
+	pp, err := NewPaymentPublisherRepository(p, format.DataCodec())
+	if err != nil {
+		return nil, err
+	}
+
+	pl, err := paymentlocker.NewLocker(locker)
+	if err != nil {
+		return nil, err
+	}
+
+	pc, err := NewPaymentCreatorService(ps, bwc, pp, pl)
 	if err != nil {
 		return nil, err
 	}
 
-	pc, err := NewPaymentCreatorService(ps, wc, pp)
+	ig, err := NewIdempotencyGuardService(ir, policy)
 	if err != nil {
 		return nil, err
 	}
 
-	h, err := NewHandler(pc)
+	h, err := NewHandler(pc, ig)
 	if err != nil {
 		return nil, err
 	}
@@ -1,7 +1,11 @@
 package creator
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"math"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,24 +14,42 @@ import (
 
 // PaymentRequest represents a request to create a payment
 type PaymentRequest struct {
-	UserID   string          `json:"user_id"`  // UserID is the user ID of the payment (in a real application, this would be the user ID from the authenticated user)
+	UserID   string          `json:"user_id"`  // UserID is the user ID of the payment, set from the authenticated principal
 	Amount   float64         `json:"amount"`   // Amount is the amount of the payment
 	Currency domain.Currency `json:"currency"` // Currency is the currency of the payment
 }
 
 // Validate validates the payment request
-// It returns an error if the request is invalid
+// It returns a validation error with every invalid field if the request is invalid
 func (p *PaymentRequest) Validate() error {
+	var validation domain.ValidationError
 	if p.UserID == "" {
-		return errors.New("user ID is required")
+		validation.Add("user_id", "user ID is required")
 	}
 	if p.Amount <= 0 {
-		return errors.New("amount must be greater than 0")
+		validation.Add("amount", "amount must be greater than 0")
 	}
 	if err := p.Currency.Validate(); err != nil {
-		return err
+		validation.Add("currency", err.Error())
 	}
-	return nil
+	return validation.Err()
+}
+
+// Fingerprint returns a hash of the request fields, used to detect an idempotency key reused with a different request
+func (p *PaymentRequest) Fingerprint() string {
+	hash := sha256.Sum256([]byte(p.UserID + "|" + strconv.FormatFloat(p.Amount, 'f', -1, 64) + "|" + string(p.Currency)))
+	return hex.EncodeToString(hash[:])
+}
+
+// Matches reports whether the payment was created with the same fields as the request
+// Amounts are compared in cents, the precision payments are stored with
+func (p *PaymentRequest) Matches(payment *domain.Payment) bool {
+	return payment.UserID == p.UserID && cents(payment.Amount) == cents(p.Amount) && payment.Currency == p.Currency
+}
+
+// cents converts an amount to cents, so amounts are compared without floating point noise
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
 }
 
 // NewPayment creates a new payment
@@ -44,3 +66,60 @@ func NewPayment(idempotencyKey string, userID string, amount float64, currency d
 		UpdatedAt:      time.Now(),
 	}
 }
+
+// IdempotencyPolicy defines how long an idempotency key is remembered
+type IdempotencyPolicy struct {
+	TTL         time.Duration // Time after the first request during which the key replays its response
+	LockTimeout time.Duration // Time a request holds the key before a retry can reclaim it, in case the request crashed
+}
+
+// Validate validates the idempotency policy
+// It returns an error if the policy is invalid
+func (p *IdempotencyPolicy) Validate() error {
+	if p.TTL <= 0 {
+		return errors.New("ttl must be greater than 0")
+	}
+	if p.LockTimeout <= 0 {
+		return errors.New("lock timeout must be greater than 0")
+	}
+	return nil
+}
+
+// IdempotencyRecord represents the first request made with an idempotency key and its response
+type IdempotencyRecord struct {
+	Key          string    // Idempotency key sent by the client
+	RequestHash  string    // Fingerprint of the first request
+	ResponseCode int       // Status code of the first response, zero while the request is in progress
+	ResponseBody []byte    // Body of the first response
+	CreatedAt    time.Time // Timestamp when the first request started
+	ExpiresAt    time.Time // Timestamp after which the key can be reused
+	LockedUntil  time.Time // Timestamp after which an in progress key can be reclaimed
+}
+
+// NewIdempotencyRecord creates a new in progress idempotency record
+// It returns a record for the given key and request hash that expires after the given TTL and is locked for the lock timeout
+func NewIdempotencyRecord(key string, requestHash string, now time.Time, ttl time.Duration, lockTimeout time.Duration) *IdempotencyRecord {
+	return &IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+		LockedUntil: now.Add(lockTimeout),
+	}
+}
+
+// InProgress reports whether the first request has not stored its response yet
+func (r *IdempotencyRecord) InProgress() bool {
+	return r.ResponseCode == 0
+}
+
+// Locked reports whether the first request still holds the key at the given time
+// A request that has not stored its response past its lock is assumed to have crashed
+func (r *IdempotencyRecord) Locked(now time.Time) bool {
+	return r.InProgress() && now.Before(r.LockedUntil)
+}
+
+// Expired reports whether the key can be reused at the given time
+func (r *IdempotencyRecord) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
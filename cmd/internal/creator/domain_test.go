@@ -157,3 +157,203 @@ func TestNewPayment(t *testing.T) {
 	}
 }
 
+func TestPaymentRequest_Fingerprint(t *testing.T) {
+	base := &PaymentRequest{UserID: "user_123", Amount: 100.50, Currency: domain.CurrencyUSD}
+
+	tests := []struct {
+		name          string
+		request       *PaymentRequest
+		expectedEqual bool
+	}{
+		{
+			name:          "when request has the same fields it should return the same fingerprint",
+			request:       &PaymentRequest{UserID: "user_123", Amount: 100.50, Currency: domain.CurrencyUSD},
+			expectedEqual: true,
+		},
+		{
+			name:          "when user ID differs it should return a different fingerprint",
+			request:       &PaymentRequest{UserID: "user_456", Amount: 100.50, Currency: domain.CurrencyUSD},
+			expectedEqual: false,
+		},
+		{
+			name:          "when amount differs it should return a different fingerprint",
+			request:       &PaymentRequest{UserID: "user_123", Amount: 100.51, Currency: domain.CurrencyUSD},
+			expectedEqual: false,
+		},
+		{
+			name:          "when currency differs it should return a different fingerprint",
+			request:       &PaymentRequest{UserID: "user_123", Amount: 100.50, Currency: domain.CurrencyEUR},
+			expectedEqual: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Requests already prepared in test struct)
+
+			// Act
+			result := tt.request.Fingerprint()
+
+			// Assert
+			assert.Len(t, result, 64)
+			assert.Equal(t, tt.expectedEqual, result == base.Fingerprint())
+		})
+	}
+}
+
+func TestPaymentRequest_Matches(t *testing.T) {
+	payment := &domain.Payment{ID: "pay_123", UserID: "user_123", Amount: 100.50, Currency: domain.CurrencyUSD}
+
+	tests := []struct {
+		name     string
+		request  *PaymentRequest
+		expected bool
+	}{
+		{
+			name:     "when request has the same fields as the payment it should match",
+			request:  &PaymentRequest{UserID: "user_123", Amount: 100.50, Currency: domain.CurrencyUSD},
+			expected: true,
+		},
+		{
+			name:     "when request amount rounds to the stored cents it should match",
+			request:  &PaymentRequest{UserID: "user_123", Amount: 100.501, Currency: domain.CurrencyUSD},
+			expected: true,
+		},
+		{
+			name:     "when user ID differs it should not match",
+			request:  &PaymentRequest{UserID: "user_456", Amount: 100.50, Currency: domain.CurrencyUSD},
+			expected: false,
+		},
+		{
+			name:     "when amount differs it should not match",
+			request:  &PaymentRequest{UserID: "user_123", Amount: 100.51, Currency: domain.CurrencyUSD},
+			expected: false,
+		},
+		{
+			name:     "when currency differs it should not match",
+			request:  &PaymentRequest{UserID: "user_123", Amount: 100.50, Currency: domain.CurrencyEUR},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			result := tt.request.Matches(payment)
+
+			// Assert
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestIdempotencyPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        IdempotencyPolicy
+		expectedError string
+	}{
+		{
+			name:          "when ttl is positive it should pass validation and no error",
+			policy:        IdempotencyPolicy{TTL: 24 * time.Hour, LockTimeout: time.Minute},
+			expectedError: "",
+		},
+		{
+			name:          "when ttl is zero it should return error",
+			policy:        IdempotencyPolicy{TTL: 0, LockTimeout: time.Minute},
+			expectedError: "ttl must be greater than 0",
+		},
+		{
+			name:          "when lock timeout is zero it should return error",
+			policy:        IdempotencyPolicy{TTL: 24 * time.Hour, LockTimeout: 0},
+			expectedError: "lock timeout must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Policy already prepared in test struct)
+
+			// Act
+			err := tt.policy.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIdempotencyRecord(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		responseCode       int
+		now                time.Time
+		expectedInProgress bool
+		expectedLocked     bool
+		expectedExpired    bool
+	}{
+		{
+			name:               "when response is not stored within the lock timeout it should be in progress and locked",
+			responseCode:       0,
+			now:                fixedTime.Add(30 * time.Second),
+			expectedInProgress: true,
+			expectedLocked:     true,
+			expectedExpired:    false,
+		},
+		{
+			name:               "when response is not stored past the lock timeout it should be in progress and not locked",
+			responseCode:       0,
+			now:                fixedTime.Add(time.Hour),
+			expectedInProgress: true,
+			expectedLocked:     false,
+			expectedExpired:    false,
+		},
+		{
+			name:               "when response is stored it should not be in progress nor locked",
+			responseCode:       201,
+			now:                fixedTime.Add(30 * time.Second),
+			expectedInProgress: false,
+			expectedLocked:     false,
+			expectedExpired:    false,
+		},
+		{
+			name:               "when ttl has elapsed it should be expired",
+			responseCode:       201,
+			now:                fixedTime.Add(24 * time.Hour),
+			expectedInProgress: false,
+			expectedLocked:     false,
+			expectedExpired:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			record := NewIdempotencyRecord("key_123", "hash_123", fixedTime, 24*time.Hour, time.Minute)
+			record.ResponseCode = tt.responseCode
+
+			// Act
+			inProgress := record.InProgress()
+			locked := record.Locked(tt.now)
+			expired := record.Expired(tt.now)
+
+			// Assert
+			assert.Equal(t, "key_123", record.Key)
+			assert.Equal(t, "hash_123", record.RequestHash)
+			assert.Equal(t, fixedTime.Add(24*time.Hour), record.ExpiresAt)
+			assert.Equal(t, fixedTime.Add(time.Minute), record.LockedUntil)
+			assert.Equal(t, tt.expectedInProgress, inProgress)
+			assert.Equal(t, tt.expectedLocked, locked)
+			assert.Equal(t, tt.expectedExpired, expired)
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,19 +18,32 @@ type PaymentCreator interface {
 	Create(ctx context.Context, idempotencyKey string, pr *PaymentRequest) (*domain.Payment, error)
 }
 
+// IdempotencyGuard defines the interface for idempotency key business logic
+type IdempotencyGuard interface {
+	Claim(ctx context.Context, key string, fingerprint string) (*IdempotencyRecord, error)
+	Complete(ctx context.Context, key string, responseCode int, responseBody []byte) error
+	Release(ctx context.Context, key string) error
+	Abandon(ctx context.Context, key string) error
+}
+
 // Handler handles HTTP requests for payment operations
 type Handler struct {
-	paymentCreator PaymentCreator
+	paymentCreator   PaymentCreator
+	idempotencyGuard IdempotencyGuard
 }
 
 // NewHandler creates a new Payment controller
-func NewHandler(pc PaymentCreator) (*Handler, error) {
+func NewHandler(pc PaymentCreator, ig IdempotencyGuard) (*Handler, error) {
 	if pc == nil {
 		return nil, errors.New("payment handler: payment resolver cannot be nil")
 	}
+	if ig == nil {
+		return nil, errors.New("payment handler: idempotency guard cannot be nil")
+	}
 
 	return &Handler{
-		paymentCreator: pc,
+		paymentCreator:   pc,
+		idempotencyGuard: ig,
 	}, nil
 }
 
@@ -39,42 +53,117 @@ func (h *Handler) Create(c *gin.Context) {
 
 	idempotencyKey := c.GetHeader("Idempotency-Key")
 	if idempotencyKey == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "Idempotency-Key header is required",
-			"error":   "bad request",
-		})
+		problem.Respond(c, problem.New(problem.CodeBadRequest, "Idempotency-Key header is required"))
 		return
 	}
 
 	var pr PaymentRequest
 	if err := json.NewDecoder(c.Request.Body).Decode(&pr); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid request body",
-			"error":   "bad request",
-		})
+		problem.Respond(c, problem.New(problem.CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok {
+		problem.Respond(c, problem.New(problem.CodeUnauthorized, "authentication required"))
 		return
 	}
 
+	// The payer is always the authenticated user, paying from another user's wallet is rejected
+	if pr.UserID != "" && pr.UserID != principal.Subject {
+		problem.Respond(c, problem.New(problem.CodeForbidden, "user_id does not match the authenticated user"))
+		return
+	}
+	pr.UserID = principal.Subject
+
 	if err := pr.Validate(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": err.Error(),
-			"error":   "bad request",
-		})
+		problem.Respond(c, problem.Invalid(err))
+		return
+	}
+
+	record, err := h.idempotencyGuard.Claim(ctx, idempotencyKey, pr.Fingerprint())
+	if err != nil {
+		p := problem.FromError(err, "failed to create payment")
+		if p.Internal() {
+			slog.ErrorContext(ctx, "Failed to claim idempotency key", "error", err)
+		}
+		problem.Respond(c, p)
+		return
+	}
+
+	// The key already has a response, replay it as it was sent the first time
+	if record != nil {
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(record.ResponseCode, contentType(record.ResponseCode), record.ResponseBody)
 		return
 	}
 
 	payment, err := h.paymentCreator.Create(ctx, idempotencyKey, &pr)
+	if errors.Is(err, domain.ErrWalletUnavailable) {
+		slog.WarnContext(ctx, "Payment rejected, wallet unavailable", "error", err)
+
+		// The wallet may accept the payment later, the key is given up by the failed payment so a retry creates a new one
+		if err := h.idempotencyGuard.Abandon(ctx, idempotencyKey); err != nil {
+			slog.ErrorContext(ctx, "Failed to abandon idempotency key", "error", err)
+		}
+
+		problem.Respond(c, problem.FromError(err, "failed to create payment"))
+		return
+	}
+	if errors.Is(err, domain.ErrIdempotencyKeyMismatch) {
+		// The key belongs to a payment created with a different request, retries with the same key replay the rejection
+		slog.WarnContext(ctx, "Payment rejected, idempotency key reused", "error", err)
+		p := problem.FromError(err, "failed to create payment").ForRequest(c)
+		h.respond(c, idempotencyKey, p.Status, p)
+		return
+	}
+	if errors.Is(err, domain.ErrInsufficientFunds) {
+		// The rejection is final, retries with the same key replay it
+		slog.WarnContext(ctx, "Payment rejected", "error", err)
+		p := problem.FromError(err, "failed to create payment").ForRequest(c)
+		h.respond(c, idempotencyKey, p.Status, p)
+		return
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to create payment", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "failed to create payment",
-			"error":   "internal server error",
-		})
+
+		// The outcome is unknown, the key is released so the client can retry with it
+		if err := h.idempotencyGuard.Release(ctx, idempotencyKey); err != nil {
+			slog.ErrorContext(ctx, "Failed to release idempotency key", "error", err)
+		}
+
+		problem.Respond(c, problem.FromError(err, "failed to create payment"))
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	h.respond(c, idempotencyKey, http.StatusCreated, gin.H{
 		"message": "payment created successfully",
 		"data":    payment,
 	})
 }
+
+// respond writes the response and stores it for the idempotency key, so retries replay it
+func (h *Handler) respond(c *gin.Context, idempotencyKey string, code int, obj any) {
+	ctx := c.Request.Context()
+
+	body, err := json.Marshal(obj)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", "error", err)
+		c.JSON(code, obj)
+		return
+	}
+
+	if err := h.idempotencyGuard.Complete(ctx, idempotencyKey, code, body); err != nil {
+		slog.ErrorContext(ctx, "Failed to store idempotent response", "error", err)
+	}
+
+	c.Data(code, contentType(code), body)
+}
+
+// contentType returns the media type of a stored response, error responses are problem details
+func contentType(code int) string {
+	if code >= http.StatusBadRequest {
+		return problem.ContentType
+	}
+	return "application/json; charset=utf-8"
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -21,19 +23,28 @@ func init() {
 
 func TestNewHandler(t *testing.T) {
 	tests := []struct {
-		name           string
-		paymentCreator PaymentCreator
-		expectedError  string
+		name             string
+		paymentCreator   PaymentCreator
+		idempotencyGuard IdempotencyGuard
+		expectedError    string
 	}{
 		{
-			name:           "when payment creator is provided it should create handler successfully and no error",
-			paymentCreator: new(MockPaymentCreatorService),
-			expectedError:  "",
+			name:             "when payment creator is provided it should create handler successfully and no error",
+			paymentCreator:   new(MockPaymentCreatorService),
+			idempotencyGuard: new(MockIdempotencyGuardService),
+			expectedError:    "",
 		},
 		{
-			name:           "when payment creator is nil it should return error",
-			paymentCreator: nil,
-			expectedError:  "payment handler: payment resolver cannot be nil",
+			name:             "when payment creator is nil it should return error",
+			paymentCreator:   nil,
+			idempotencyGuard: new(MockIdempotencyGuardService),
+			expectedError:    "payment handler: payment resolver cannot be nil",
+		},
+		{
+			name:             "when idempotency guard is nil it should return error",
+			paymentCreator:   new(MockPaymentCreatorService),
+			idempotencyGuard: nil,
+			expectedError:    "payment handler: idempotency guard cannot be nil",
 		},
 	}
 
@@ -43,7 +54,7 @@ func TestNewHandler(t *testing.T) {
 			// (Payment creator already prepared in test struct)
 
 			// Act
-			result, err := NewHandler(tt.paymentCreator)
+			result, err := NewHandler(tt.paymentCreator, tt.idempotencyGuard)
 
 			// Assert
 			if tt.expectedError != "" {
@@ -68,8 +79,17 @@ func TestHandler_Create(t *testing.T) {
 		mockPayment        *domain.Payment
 		mockCreateError    error
 		shouldCallCreate   bool
+		mockClaimRecord    *IdempotencyRecord
+		mockClaimError     error
+		shouldCallClaim    bool
+		shouldCallComplete bool
+		shouldCallRelease  bool
+		shouldCallAbandon  bool
 		expectedStatusCode int
 		expectedMessage    string
+		expectedCode       string
+		expectedReplayed   string
+		unauthenticated    bool
 	}{
 		{
 			name:           "when request is valid it should create payment and return 201",
@@ -91,6 +111,8 @@ func TestHandler_Create(t *testing.T) {
 			},
 			mockCreateError:    nil,
 			shouldCallCreate:   true,
+			shouldCallClaim:    true,
+			shouldCallComplete: true,
 			expectedStatusCode: http.StatusCreated,
 			expectedMessage:    "payment created successfully",
 		},
@@ -101,6 +123,7 @@ func TestHandler_Create(t *testing.T) {
 			shouldCallCreate:   false,
 			expectedStatusCode: http.StatusBadRequest,
 			expectedMessage:    "Idempotency-Key header is required",
+			expectedCode:       "bad_request",
 		},
 		{
 			name:               "when request body is invalid JSON it should return 400",
@@ -109,14 +132,46 @@ func TestHandler_Create(t *testing.T) {
 			shouldCallCreate:   false,
 			expectedStatusCode: http.StatusBadRequest,
 			expectedMessage:    "invalid request body",
+			expectedCode:       "bad_request",
+		},
+		{
+			name:           "when user ID is omitted it should create payment for the authenticated user and return 201",
+			idempotencyKey: "key_123",
+			requestBody:    PaymentRequest{Amount: 100.50, Currency: domain.CurrencyUSD},
+			mockPayment: &domain.Payment{
+				ID:             "pay_123",
+				IdempotencyKey: "key_123",
+				UserID:         "user_123",
+				Amount:         100.50,
+				Currency:       domain.CurrencyUSD,
+				Status:         domain.StatusReserved,
+				CreatedAt:      fixedTime,
+				UpdatedAt:      fixedTime,
+			},
+			shouldCallCreate:   true,
+			shouldCallClaim:    true,
+			shouldCallComplete: true,
+			expectedStatusCode: http.StatusCreated,
+			expectedMessage:    "payment created successfully",
 		},
 		{
-			name:               "when user ID is empty it should return 400",
+			name:               "when user ID is not the authenticated user it should return 403",
 			idempotencyKey:     "key_123",
-			requestBody:        PaymentRequest{UserID: "", Amount: 100.50, Currency: domain.CurrencyUSD},
+			requestBody:        PaymentRequest{UserID: "user_456", Amount: 100.50, Currency: domain.CurrencyUSD},
 			shouldCallCreate:   false,
-			expectedStatusCode: http.StatusBadRequest,
-			expectedMessage:    "user ID is required",
+			expectedStatusCode: http.StatusForbidden,
+			expectedMessage:    "user_id does not match the authenticated user",
+			expectedCode:       "forbidden",
+		},
+		{
+			name:               "when request is not authenticated it should return 401",
+			idempotencyKey:     "key_123",
+			requestBody:        PaymentRequest{UserID: "user_123", Amount: 100.50, Currency: domain.CurrencyUSD},
+			shouldCallCreate:   false,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedMessage:    "authentication required",
+			expectedCode:       "unauthorized",
+			unauthenticated:    true,
 		},
 		{
 			name:               "when amount is zero it should return 400",
@@ -125,6 +180,7 @@ func TestHandler_Create(t *testing.T) {
 			shouldCallCreate:   false,
 			expectedStatusCode: http.StatusBadRequest,
 			expectedMessage:    "amount must be greater than 0",
+			expectedCode:       "validation_failed",
 		},
 		{
 			name:               "when currency is invalid it should return 400",
@@ -133,6 +189,7 @@ func TestHandler_Create(t *testing.T) {
 			shouldCallCreate:   false,
 			expectedStatusCode: http.StatusBadRequest,
 			expectedMessage:    "invalid currency",
+			expectedCode:       "validation_failed",
 		},
 		{
 			name:               "when payment creator fails it should return 500",
@@ -141,8 +198,97 @@ func TestHandler_Create(t *testing.T) {
 			mockPayment:        nil,
 			mockCreateError:    errors.New("database error"),
 			shouldCallCreate:   true,
+			shouldCallClaim:    true,
+			shouldCallRelease:  true,
 			expectedStatusCode: http.StatusInternalServerError,
 			expectedMessage:    "failed to create payment",
+			expectedCode:       "internal_error",
+		},
+		{
+			name:               "when the key belongs to a payment created with a different request it should return 422 and store the response",
+			idempotencyKey:     "key_123",
+			requestBody:        PaymentRequest{UserID: "user_123", Amount: 100.50, Currency: domain.CurrencyUSD},
+			mockPayment:        nil,
+			mockCreateError:    fmt.Errorf("payment creator: payment pay_123: %w", domain.ErrIdempotencyKeyMismatch),
+			shouldCallCreate:   true,
+			shouldCallClaim:    true,
+			shouldCallComplete: true,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedMessage:    "idempotency key was used with a different request",
+			expectedCode:       "idempotency_key_mismatch",
+		},
+		{
+			name:               "when wallet is unavailable it should return 503 and abandon the key without storing the response",
+			idempotencyKey:     "key_123",
+			requestBody:        PaymentRequest{UserID: "user_123", Amount: 100.50, Currency: domain.CurrencyUSD},
+			mockPayment:        nil,
+			mockCreateError:    fmt.Errorf("payment creator: reserve funds: %w", domain.ErrWalletUnavailable),
+			shouldCallCreate:   true,
+			shouldCallClaim:    true,
+			shouldCallAbandon:  true,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedMessage:    "wallet service unavailable",
+			expectedCode:       "wallet_unavailable",
+		},
+		{
+			name:               "when wallet has insufficient funds it should return 422 and store the response",
+			idempotencyKey:     "key_123",
+			requestBody:        PaymentRequest{UserID: "user_123", Amount: 100.50, Currency: domain.CurrencyUSD},
+			mockCreateError:    fmt.Errorf("payment creator: reserve funds: %w", domain.ErrInsufficientFunds),
+			shouldCallCreate:   true,
+			shouldCallClaim:    true,
+			shouldCallComplete: true,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedMessage:    "insufficient funds",
+			expectedCode:       "insufficient_funds",
+		},
+		{
+			name:           "when idempotency key has a stored response it should replay it without creating",
+			idempotencyKey: "key_123",
+			requestBody:    PaymentRequest{UserID: "user_123", Amount: 100.50, Currency: domain.CurrencyUSD},
+			mockClaimRecord: &IdempotencyRecord{
+				Key:          "key_123",
+				ResponseCode: http.StatusCreated,
+				ResponseBody: []byte(`{"message":"payment created successfully","data":{"id":"pay_123"}}`),
+			},
+			shouldCallCreate:   false,
+			shouldCallClaim:    true,
+			expectedStatusCode: http.StatusCreated,
+			expectedMessage:    "payment created successfully",
+			expectedReplayed:   "true",
+		},
+		{
+			name:               "when idempotency key was used with a different request it should return 422",
+			idempotencyKey:     "key_123",
+			requestBody:        PaymentRequest{UserID: "user_123", Amount: 100.50, Currency: domain.CurrencyUSD},
+			mockClaimError:     domain.ErrIdempotencyKeyMismatch,
+			shouldCallCreate:   false,
+			shouldCallClaim:    true,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedMessage:    "idempotency key was used with a different request",
+			expectedCode:       "idempotency_key_mismatch",
+		},
+		{
+			name:               "when first request with idempotency key is in progress it should return 409",
+			idempotencyKey:     "key_123",
+			requestBody:        PaymentRequest{UserID: "user_123", Amount: 100.50, Currency: domain.CurrencyUSD},
+			mockClaimError:     domain.ErrIdempotencyKeyInProgress,
+			shouldCallCreate:   false,
+			shouldCallClaim:    true,
+			expectedStatusCode: http.StatusConflict,
+			expectedMessage:    "idempotency key request is still in progress",
+			expectedCode:       "idempotency_key_in_progress",
+		},
+		{
+			name:               "when idempotency key cannot be claimed it should return 500",
+			idempotencyKey:     "key_123",
+			requestBody:        PaymentRequest{UserID: "user_123", Amount: 100.50, Currency: domain.CurrencyUSD},
+			mockClaimError:     errors.New("database error"),
+			shouldCallCreate:   false,
+			shouldCallClaim:    true,
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "failed to create payment",
+			expectedCode:       "internal_error",
 		},
 	}
 
@@ -151,10 +297,26 @@ func TestHandler_Create(t *testing.T) {
 			// Arrange
 			mockCreator := new(MockPaymentCreatorService)
 			if tt.shouldCallCreate {
-				mockCreator.On("Create", mock.Anything, tt.idempotencyKey, mock.Anything).Return(tt.mockPayment, tt.mockCreateError)
+				mockCreator.On("Create", mock.Anything, tt.idempotencyKey, mock.MatchedBy(func(pr *PaymentRequest) bool {
+					return pr.UserID == "user_123"
+				})).Return(tt.mockPayment, tt.mockCreateError)
 			}
 
-			handler := &Handler{paymentCreator: mockCreator}
+			mockGuard := new(MockIdempotencyGuardService)
+			if tt.shouldCallClaim {
+				mockGuard.On("Claim", mock.Anything, tt.idempotencyKey, mock.AnythingOfType("string")).Return(tt.mockClaimRecord, tt.mockClaimError)
+			}
+			if tt.shouldCallComplete {
+				mockGuard.On("Complete", mock.Anything, tt.idempotencyKey, tt.expectedStatusCode, mock.Anything).Return(nil)
+			}
+			if tt.shouldCallRelease {
+				mockGuard.On("Release", mock.Anything, tt.idempotencyKey).Return(nil)
+			}
+			if tt.shouldCallAbandon {
+				mockGuard.On("Abandon", mock.Anything, tt.idempotencyKey).Return(nil)
+			}
+
+			handler := &Handler{paymentCreator: mockCreator, idempotencyGuard: mockGuard}
 
 			var body []byte
 			switch v := tt.requestBody.(type) {
@@ -169,6 +331,10 @@ func TestHandler_Create(t *testing.T) {
 			if tt.idempotencyKey != "" {
 				req.Header.Set("Idempotency-Key", tt.idempotencyKey)
 			}
+			if !tt.unauthenticated {
+				principal := &domain.Principal{Subject: "user_123", Scopes: []domain.Scope{domain.ScopePaymentsWrite}}
+				req = req.WithContext(domain.WithPrincipal(req.Context(), principal))
+			}
 
 			w := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(w)
@@ -183,9 +349,92 @@ func TestHandler_Create(t *testing.T) {
 			var response map[string]interface{}
 			err := json.Unmarshal(w.Body.Bytes(), &response)
 			assert.NoError(t, err)
-			assert.Equal(t, tt.expectedMessage, response["message"])
+			if tt.expectedCode != "" {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedMessage, response["detail"])
+				assert.Equal(t, tt.expectedCode, response["code"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, response["message"])
+			}
+			assert.Equal(t, tt.expectedReplayed, w.Header().Get("Idempotent-Replayed"))
 
 			mockCreator.AssertExpectations(t)
+			mockGuard.AssertExpectations(t)
 		})
 	}
 }
+
+func TestHandler_Create_RetryAfterRelease(t *testing.T) {
+	// Arrange
+	payment := &domain.Payment{
+		ID:             "pay_123",
+		IdempotencyKey: "key_123",
+		UserID:         "user_123",
+		Amount:         100.50,
+		Currency:       domain.CurrencyUSD,
+		Status:         domain.StatusReserved,
+	}
+	original := PaymentRequest{Amount: 100.50, Currency: domain.CurrencyUSD}
+	changed := PaymentRequest{Amount: 250.00, Currency: domain.CurrencyUSD}
+
+	var stored *IdempotencyRecord
+	mockStorer := new(MockIdempotencyKeyRepository)
+	mockStorer.On("Get", mock.Anything, "key_123").Return(nil, nil).Once()
+	mockStorer.On("Start", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*IdempotencyRecord)
+	})
+	mockStorer.On("Release", mock.Anything, "key_123").Return(nil).Run(func(args mock.Arguments) {
+		stored.LockedUntil = stored.CreatedAt
+	})
+	mockStorer.On("Complete", mock.Anything, "key_123", http.StatusCreated, mock.Anything).Return(nil)
+
+	mockCreator := new(MockPaymentCreatorService)
+	mockCreator.On("Create", mock.Anything, "key_123", mock.MatchedBy(func(pr *PaymentRequest) bool {
+		return pr.Amount == original.Amount
+	})).Return(nil, errors.New("publish failed")).Once()
+	mockCreator.On("Create", mock.Anything, "key_123", mock.MatchedBy(func(pr *PaymentRequest) bool {
+		return pr.Amount == original.Amount
+	})).Return(payment, nil).Once()
+
+	guard := &IdempotencyGuardService{idempotencyStorer: mockStorer, policy: IdempotencyPolicy{TTL: 24 * time.Hour, LockTimeout: time.Minute}}
+	handler := &Handler{paymentCreator: mockCreator, idempotencyGuard: guard}
+
+	post := func(pr PaymentRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(pr)
+		req := httptest.NewRequest(http.MethodPost, "/payments", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "key_123")
+		principal := &domain.Principal{Subject: "user_123", Scopes: []domain.Scope{domain.ScopePaymentsWrite}}
+		req = req.WithContext(domain.WithPrincipal(req.Context(), principal))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		handler.Create(c)
+		return w
+	}
+
+	// Act
+	failed := post(original)
+	mockStorer.On("Get", mock.Anything, "key_123").Return(stored, nil)
+	mismatched := post(changed)
+	retried := post(original)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, failed.Code)
+
+	var problemBody map[string]interface{}
+	assert.NoError(t, json.Unmarshal(mismatched.Body.Bytes(), &problemBody))
+	assert.Equal(t, http.StatusUnprocessableEntity, mismatched.Code)
+	assert.Equal(t, "idempotency_key_mismatch", problemBody["code"])
+
+	var createdBody map[string]interface{}
+	assert.NoError(t, json.Unmarshal(retried.Body.Bytes(), &createdBody))
+	assert.Equal(t, http.StatusCreated, retried.Code)
+	assert.Equal(t, "pay_123", createdBody["data"].(map[string]interface{})["id"])
+
+	mockCreator.AssertExpectations(t)
+	mockCreator.AssertNumberOfCalls(t, "Create", 2)
+	mockStorer.AssertExpectations(t)
+	mockStorer.AssertNumberOfCalls(t, "Start", 2)
+}
@@ -2,46 +2,237 @@ package creator
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
 )
 
 // MessageBroker interface for the infrastructure publisher
 type MessageBroker interface {
-	Publish(body []byte) error
+	PublishEvent(event *messagebroker.CloudEvent, headers map[string]interface{}) error
 }
 
 // PaymentPublisherRepository is a repository for publishing payments
 type PaymentPublisherRepository struct {
 	messageBroker MessageBroker
+	codec         messagebroker.Codec
 }
 
 // NewPaymentPublisherRepository creates a new PaymentPublisherRepository
-// It returns a new PaymentPublisherRepository and an error if the message broker is nil
-func NewPaymentPublisherRepository(mb MessageBroker) (*PaymentPublisherRepository, error) {
+// It returns a new PaymentPublisherRepository and an error if the message broker or codec is nil
+func NewPaymentPublisherRepository(mb MessageBroker, codec messagebroker.Codec) (*PaymentPublisherRepository, error) {
 	if mb == nil {
 		return nil, errors.New("payment publisher: message broker cannot be nil")
 	}
+	if codec == nil {
+		return nil, errors.New("payment publisher: codec cannot be nil")
+	}
 
 	return &PaymentPublisherRepository{
 		messageBroker: mb,
+		codec:         codec,
 	}, nil
 }
 
 // Publish publishes a payment
-// It publishes a payment to the message broker
+// It publishes a payment to the message broker as a CloudEvent about the payment
 // It returns an error if the payment cannot be marshaled or published
 func (r *PaymentPublisherRepository) Publish(ctx context.Context, payment *domain.Payment) error {
-	data, err := payment.Marshal()
+	data, err := r.codec.Marshal(payment)
 	if err != nil {
 		return fmt.Errorf("publisher: failed to marshal payment: %w", err)
 	}
 
-	if err := r.messageBroker.Publish(data); err != nil {
+	event := messagebroker.NewCloudEvent(domain.CloudEventTypePaymentCreated, payment.ID, domain.PaymentDataSchema, r.codec.ContentType(), data)
+
+	// The request ID travels with the message, so the consumer logs with it
+	if err := r.messageBroker.PublishEvent(event, messagebroker.WithRequestID(ctx, nil)); err != nil {
 		return fmt.Errorf("publisher: failed to publish payment: %w", err)
 	}
 
 	return nil
 }
+
+// IdempotencyDB defines the database operations required by IdempotencyKeyRepository
+type IdempotencyDB interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) database.RowScanner
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error
+}
+
+// IdempotencyKeyRepository stores the first request made with each idempotency key and its response
+type IdempotencyKeyRepository struct {
+	db IdempotencyDB
+}
+
+// NewIdempotencyKeyRepository creates a new IdempotencyKeyRepository
+// It returns a new IdempotencyKeyRepository and an error if the database is nil
+func NewIdempotencyKeyRepository(db IdempotencyDB) (*IdempotencyKeyRepository, error) {
+	if db == nil {
+		return nil, errors.New("idempotency key repository: database cannot be nil")
+	}
+
+	return &IdempotencyKeyRepository{db: db}, nil
+}
+
+// Get retrieves the record of an idempotency key
+// It returns nil and no error if the key was never used
+func (r *IdempotencyKeyRepository) Get(ctx context.Context, key string) (*IdempotencyRecord, error) {
+	query := `
+		SELECT key, request_hash, COALESCE(response_code, 0), response_body, created_at, expires_at, locked_until
+		FROM idempotency_keys
+		WHERE key = $1
+	`
+
+	var record IdempotencyRecord
+	err := r.db.QueryRowContext(ctx, query, key).Scan(
+		&record.Key,
+		&record.RequestHash,
+		&record.ResponseCode,
+		&record.ResponseBody,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+		&record.LockedUntil,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("idempotency key repository: get: %w", err)
+	}
+
+	return &record, nil
+}
+
+// Start stores a new in progress record for an idempotency key
+// An expired record is replaced and its payment gives up the key, so a new payment can use it
+// An in progress record past its lock for the same request is reclaimed, its payment keeps the key so the retry returns it
+// It returns domain.ErrIdempotencyKeyInProgress if another request holds the key
+func (r *IdempotencyKeyRepository) Start(ctx context.Context, record *IdempotencyRecord) error {
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		deleteQuery := `
+			DELETE FROM idempotency_keys
+			WHERE key = $1 AND expires_at <= $2
+		`
+		result, err := tx.ExecContext(ctx, deleteQuery, record.Key, record.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("delete expired key: %w", err)
+		}
+
+		expired, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected: %w", err)
+		}
+
+		if expired > 0 {
+			releaseQuery := `
+				UPDATE payments
+				SET idempotency_key = NULL
+				WHERE idempotency_key = $1
+			`
+			if _, err := tx.ExecContext(ctx, releaseQuery, record.Key); err != nil {
+				return fmt.Errorf("release payment key: %w", err)
+			}
+		}
+
+		insertQuery := `
+			INSERT INTO idempotency_keys (key, request_hash, created_at, expires_at, locked_until)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (key) DO UPDATE
+			SET locked_until = EXCLUDED.locked_until
+			WHERE idempotency_keys.response_code IS NULL
+				AND idempotency_keys.locked_until <= EXCLUDED.created_at
+				AND idempotency_keys.request_hash = EXCLUDED.request_hash
+		`
+		result, err = tx.ExecContext(ctx, insertQuery, record.Key, record.RequestHash, record.CreatedAt, record.ExpiresAt, record.LockedUntil)
+		if err != nil {
+			return fmt.Errorf("insert key: %w", err)
+		}
+
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected: %w", err)
+		}
+
+		if inserted == 0 {
+			return domain.ErrIdempotencyKeyInProgress
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("idempotency key repository: start: %w", err)
+	}
+
+	return nil
+}
+
+// Complete stores the response of the first request made with an idempotency key
+func (r *IdempotencyKeyRepository) Complete(ctx context.Context, key string, responseCode int, responseBody []byte) error {
+	query := `
+		UPDATE idempotency_keys
+		SET response_code = $1, response_body = $2
+		WHERE key = $3
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, responseCode, responseBody, key); err != nil {
+		return fmt.Errorf("idempotency key repository: complete: %w", err)
+	}
+
+	return nil
+}
+
+// Release unlocks an in progress record, so the client can retry with the same key right away
+// The record keeps the fingerprint of the request, so while the payment created with the key keeps it
+// a retry with a different request is rejected as a mismatch instead of getting that payment
+func (r *IdempotencyKeyRepository) Release(ctx context.Context, key string) error {
+	query := `
+		UPDATE idempotency_keys
+		SET locked_until = created_at
+		WHERE key = $1 AND response_code IS NULL
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, key); err != nil {
+		return fmt.Errorf("idempotency key repository: release: %w", err)
+	}
+
+	return nil
+}
+
+// Abandon deletes an in progress record and clears the key from the failed payment created with it
+// The payment stays as failed, so a retry with the same key creates a new payment
+func (r *IdempotencyKeyRepository) Abandon(ctx context.Context, key string) error {
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		deleteQuery := `
+			DELETE FROM idempotency_keys
+			WHERE key = $1 AND response_code IS NULL
+		`
+		if _, err := tx.ExecContext(ctx, deleteQuery, key); err != nil {
+			return fmt.Errorf("delete key: %w", err)
+		}
+
+		releaseQuery := `
+			UPDATE payments
+			SET idempotency_key = NULL
+			WHERE idempotency_key = $1 AND status = $2
+		`
+		if _, err := tx.ExecContext(ctx, releaseQuery, key, domain.StatusFailed); err != nil {
+			return fmt.Errorf("release payment key: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("idempotency key repository: abandon: %w", err)
+	}
+
+	return nil
+}
@@ -17,3 +17,41 @@ func (m *MockPaymentPublisherRepository) Publish(ctx context.Context, payment *d
 	args := m.Called(ctx, payment)
 	return args.Error(0)
 }
+
+// MockIdempotencyKeyRepository is a mock implementation of IdempotencyKeyRepository
+type MockIdempotencyKeyRepository struct {
+	mock.Mock
+}
+
+// Get retrieves the record of an idempotency key
+func (m *MockIdempotencyKeyRepository) Get(ctx context.Context, key string) (*IdempotencyRecord, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*IdempotencyRecord), args.Error(1)
+}
+
+// Start stores a new in progress record
+func (m *MockIdempotencyKeyRepository) Start(ctx context.Context, record *IdempotencyRecord) error {
+	args := m.Called(ctx, record)
+	return args.Error(0)
+}
+
+// Complete stores the response of an idempotency key
+func (m *MockIdempotencyKeyRepository) Complete(ctx context.Context, key string, responseCode int, responseBody []byte) error {
+	args := m.Called(ctx, key, responseCode, responseBody)
+	return args.Error(0)
+}
+
+// Release deletes an in progress record
+func (m *MockIdempotencyKeyRepository) Release(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+// Abandon gives up an idempotency key and its failed payment
+func (m *MockIdempotencyKeyRepository) Abandon(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
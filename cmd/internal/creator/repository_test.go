@@ -2,12 +2,17 @@ package creator
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
 	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/requestid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -16,21 +21,31 @@ func TestNewPaymentPublisherRepository(t *testing.T) {
 	tests := []struct {
 		name           string
 		messageBroker  MessageBroker
+		codec          messagebroker.Codec
 		expectedError  string
 		expectedResult bool
 	}{
 		{
 			name:           "when message broker is provided it should create repository successfully and no error",
 			messageBroker:  new(messagebroker.MockPublisher),
+			codec:          messagebroker.JSONCodec{},
 			expectedError:  "",
 			expectedResult: true,
 		},
 		{
 			name:           "when message broker is nil it should return error with message 'payment publisher: message broker cannot be nil'",
 			messageBroker:  nil,
+			codec:          messagebroker.JSONCodec{},
 			expectedError:  "payment publisher: message broker cannot be nil",
 			expectedResult: false,
 		},
+		{
+			name:           "when codec is nil it should return error with message 'payment publisher: codec cannot be nil'",
+			messageBroker:  new(messagebroker.MockPublisher),
+			codec:          nil,
+			expectedError:  "payment publisher: codec cannot be nil",
+			expectedResult: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -39,7 +54,7 @@ func TestNewPaymentPublisherRepository(t *testing.T) {
 			// (Message broker already prepared in test struct)
 
 			// Act
-			result, err := NewPaymentPublisherRepository(tt.messageBroker)
+			result, err := NewPaymentPublisherRepository(tt.messageBroker, tt.codec)
 
 			// Assert
 			if tt.expectedError != "" {
@@ -50,6 +65,7 @@ func TestNewPaymentPublisherRepository(t *testing.T) {
 				assert.NoError(t, err)
 				assert.NotNil(t, result)
 				assert.NotNil(t, result.messageBroker)
+				assert.NotNil(t, result.codec)
 			}
 		})
 	}
@@ -59,10 +75,13 @@ func TestPaymentPublisherRepository_Publish(t *testing.T) {
 	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
 
 	tests := []struct {
-		name          string
-		payment       *domain.Payment
+		name             string
+		payment          *domain.Payment
+		codec            messagebroker.Codec
+		requestID        string
 		mockPublishError error
-		expectedError error
+		expectedHeaders  map[string]interface{}
+		expectedError    error
 	}{
 		{
 			name: "when payment is valid and broker publishes successfully it should return no error",
@@ -76,6 +95,41 @@ func TestPaymentPublisherRepository_Publish(t *testing.T) {
 				CreatedAt:      fixedTime,
 				UpdatedAt:      fixedTime,
 			},
+			codec:            messagebroker.JSONCodec{},
+			mockPublishError: nil,
+			expectedError:    nil,
+		},
+		{
+			name: "when context has a request ID it should publish it in the headers",
+			payment: &domain.Payment{
+				ID:             "pay_123",
+				IdempotencyKey: "key_456",
+				UserID:         "user_789",
+				Amount:         100.50,
+				Currency:       domain.CurrencyUSD,
+				Status:         domain.StatusPending,
+				CreatedAt:      fixedTime,
+				UpdatedAt:      fixedTime,
+			},
+			codec:            messagebroker.JSONCodec{},
+			requestID:        "req_123",
+			mockPublishError: nil,
+			expectedHeaders:  map[string]interface{}{messagebroker.RequestIDHeader: "req_123"},
+			expectedError:    nil,
+		},
+		{
+			name: "when codec is protobuf it should publish the payment as protobuf data",
+			payment: &domain.Payment{
+				ID:             "pay_123",
+				IdempotencyKey: "key_456",
+				UserID:         "user_789",
+				Amount:         100.50,
+				Currency:       domain.CurrencyUSD,
+				Status:         domain.StatusPending,
+				CreatedAt:      fixedTime,
+				UpdatedAt:      fixedTime,
+			},
+			codec:            messagebroker.ProtobufCodec{},
 			mockPublishError: nil,
 			expectedError:    nil,
 		},
@@ -91,6 +145,7 @@ func TestPaymentPublisherRepository_Publish(t *testing.T) {
 				CreatedAt:      fixedTime,
 				UpdatedAt:      fixedTime,
 			},
+			codec:            messagebroker.JSONCodec{},
 			mockPublishError: errors.New("connection refused"),
 			expectedError:    errors.New("publisher: failed to publish payment: connection refused"),
 		},
@@ -100,12 +155,25 @@ func TestPaymentPublisherRepository_Publish(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Arrange
 			mockBroker := new(messagebroker.MockPublisher)
-			mockBroker.On("Publish", mock.Anything).Return(tt.mockPublishError)
+			data, _ := tt.codec.Marshal(tt.payment)
+			mockBroker.On("PublishEvent", mock.MatchedBy(func(event *messagebroker.CloudEvent) bool {
+				return event.Type == domain.CloudEventTypePaymentCreated &&
+					event.Subject == tt.payment.ID &&
+					event.DataSchema == domain.PaymentDataSchema &&
+					event.DataContentType == tt.codec.ContentType() &&
+					event.ID != "" &&
+					string(event.Data) == string(data)
+			}), tt.expectedHeaders).Return(tt.mockPublishError)
+
+			repo := &PaymentPublisherRepository{messageBroker: mockBroker, codec: tt.codec}
 
-			repo := &PaymentPublisherRepository{messageBroker: mockBroker}
+			ctx := context.Background()
+			if tt.requestID != "" {
+				ctx = requestid.WithRequestID(ctx, tt.requestID)
+			}
 
 			// Act
-			err := repo.Publish(context.Background(), tt.payment)
+			err := repo.Publish(ctx, tt.payment)
 
 			// Assert
 			if tt.expectedError != nil {
@@ -119,3 +187,317 @@ func TestPaymentPublisherRepository_Publish(t *testing.T) {
 		})
 	}
 }
+
+func TestNewIdempotencyKeyRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            IdempotencyDB
+		expectedError string
+	}{
+		{
+			name:          "when database is provided it should create repository successfully and no error",
+			db:            new(database.MockDB),
+			expectedError: "",
+		},
+		{
+			name:          "when database is nil it should return error",
+			db:            nil,
+			expectedError: "idempotency key repository: database cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Database already prepared in test struct)
+
+			// Act
+			result, err := NewIdempotencyKeyRepository(tt.db)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeyRepository_Get(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		mockRecord     *IdempotencyRecord
+		mockScanError  error
+		expectedRecord *IdempotencyRecord
+		expectedError  error
+	}{
+		{
+			name: "when key exists it should return the record and no error",
+			mockRecord: &IdempotencyRecord{
+				Key:          "key_123",
+				RequestHash:  "hash_123",
+				ResponseCode: 201,
+				ResponseBody: []byte(`{"message":"payment created successfully"}`),
+				CreatedAt:    fixedTime,
+				ExpiresAt:    fixedTime.Add(24 * time.Hour),
+				LockedUntil:  fixedTime.Add(time.Minute),
+			},
+			expectedRecord: &IdempotencyRecord{
+				Key:          "key_123",
+				RequestHash:  "hash_123",
+				ResponseCode: 201,
+				ResponseBody: []byte(`{"message":"payment created successfully"}`),
+				CreatedAt:    fixedTime,
+				ExpiresAt:    fixedTime.Add(24 * time.Hour),
+				LockedUntil:  fixedTime.Add(time.Minute),
+			},
+			expectedError: nil,
+		},
+		{
+			name:           "when key does not exist it should return nil and no error",
+			mockScanError:  sql.ErrNoRows,
+			expectedRecord: nil,
+			expectedError:  nil,
+		},
+		{
+			name:          "when query fails it should return wrapped error",
+			mockScanError: errors.New("connection refused"),
+			expectedError: errors.New("idempotency key repository: get: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockScanner := new(database.MockRowScanner)
+
+			if tt.mockRecord != nil {
+				mockScanner.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+					dest := args.Get(0).([]any)
+					*dest[0].(*string) = tt.mockRecord.Key
+					*dest[1].(*string) = tt.mockRecord.RequestHash
+					*dest[2].(*int) = tt.mockRecord.ResponseCode
+					*dest[3].(*[]byte) = tt.mockRecord.ResponseBody
+					*dest[4].(*time.Time) = tt.mockRecord.CreatedAt
+					*dest[5].(*time.Time) = tt.mockRecord.ExpiresAt
+					*dest[6].(*time.Time) = tt.mockRecord.LockedUntil
+				}).Return(nil)
+			} else {
+				mockScanner.On("Scan", mock.Anything).Return(tt.mockScanError)
+			}
+
+			mockDB.On("QueryRowContext", mock.Anything, mock.Anything, mock.Anything).Return(mockScanner)
+
+			repo := &IdempotencyKeyRepository{db: mockDB}
+
+			// Act
+			result, err := repo.Get(context.Background(), "key_123")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedRecord, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIdempotencyKeyRepository_Start(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                 string
+		mockTransactionError error
+		expectedError        error
+	}{
+		{
+			name:                 "when transaction succeeds it should return no error",
+			mockTransactionError: nil,
+			expectedError:        nil,
+		},
+		{
+			name:                 "when key is held by another request it should return wrapped in progress error",
+			mockTransactionError: domain.ErrIdempotencyKeyInProgress,
+			expectedError:        errors.New("idempotency key repository: start: idempotency key request is still in progress"),
+		},
+		{
+			name:                 "when transaction fails it should return wrapped error",
+			mockTransactionError: errors.New("insert key: connection refused"),
+			expectedError:        errors.New("idempotency key repository: start: insert key: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockDB.On("WithTransaction", mock.Anything, mock.Anything).Return(tt.mockTransactionError)
+
+			repo := &IdempotencyKeyRepository{db: mockDB}
+
+			// Act
+			err := repo.Start(context.Background(), NewIdempotencyRecord("key_123", "hash_123", fixedTime, 24*time.Hour, time.Minute))
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIdempotencyKeyRepository_Complete(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockExecError error
+		expectedError error
+	}{
+		{
+			name:          "when response is stored it should return no error",
+			mockExecError: nil,
+			expectedError: nil,
+		},
+		{
+			name:          "when exec fails it should return wrapped error",
+			mockExecError: errors.New("connection refused"),
+			expectedError: errors.New("idempotency key repository: complete: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			if tt.mockExecError != nil {
+				mockDB.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, tt.mockExecError)
+			} else {
+				mockDB.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(driver.RowsAffected(1), nil)
+			}
+
+			repo := &IdempotencyKeyRepository{db: mockDB}
+
+			// Act
+			err := repo.Complete(context.Background(), "key_123", 201, []byte(`{"message":"payment created successfully"}`))
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIdempotencyKeyRepository_Release(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockExecError error
+		expectedError error
+	}{
+		{
+			name:          "when key is released it should unlock the record and return no error",
+			mockExecError: nil,
+			expectedError: nil,
+		},
+		{
+			name:          "when exec fails it should return wrapped error",
+			mockExecError: errors.New("connection refused"),
+			expectedError: errors.New("idempotency key repository: release: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// The record is unlocked instead of deleted, so it keeps the fingerprint of the request
+			unlocks := mock.MatchedBy(func(query string) bool {
+				return strings.Contains(query, "UPDATE idempotency_keys") && strings.Contains(query, "SET locked_until = created_at")
+			})
+			mockDB := new(database.MockDB)
+			if tt.mockExecError != nil {
+				mockDB.On("ExecContext", mock.Anything, unlocks, mock.Anything).Return(nil, tt.mockExecError)
+			} else {
+				mockDB.On("ExecContext", mock.Anything, unlocks, mock.Anything).Return(driver.RowsAffected(1), nil)
+			}
+
+			repo := &IdempotencyKeyRepository{db: mockDB}
+
+			// Act
+			err := repo.Release(context.Background(), "key_123")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIdempotencyKeyRepository_Abandon(t *testing.T) {
+	tests := []struct {
+		name                 string
+		mockTransactionError error
+		expectedError        error
+	}{
+		{
+			name:                 "when transaction succeeds it should return no error",
+			mockTransactionError: nil,
+			expectedError:        nil,
+		},
+		{
+			name:                 "when transaction fails it should return wrapped error",
+			mockTransactionError: errors.New("release payment key: connection refused"),
+			expectedError:        errors.New("idempotency key repository: abandon: release payment key: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockDB.On("WithTransaction", mock.Anything, mock.Anything).Return(tt.mockTransactionError)
+
+			repo := &IdempotencyKeyRepository{db: mockDB}
+
+			// Act
+			err := repo.Abandon(context.Background(), "key_123")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
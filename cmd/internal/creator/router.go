@@ -4,14 +4,15 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentstorer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/walletclient"
 	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
 )
 
 // Start starts the creator router
 // It starts the creator router and returns an error if the builder fails
-func Start(rg *gin.RouterGroup, db paymentstorer.PaymentDB, c *http.Client, mbc *messagebroker.Connection, exchange, queueName string) error {
-	h, err := Build(db, c, mbc, exchange, queueName)
+func Start(rg *gin.RouterGroup, db CreatorDB, c *http.Client, wb walletclient.CircuitBreaker, locker paymentlocker.AdvisoryLocker, mbc *messagebroker.Connection, exchange, queueName string, format messagebroker.EventFormat, policy IdempotencyPolicy) error {
+	h, err := Build(db, c, wb, locker, mbc, exchange, queueName, format, policy)
 	if err != nil {
 		return err
 	}
@@ -3,8 +3,11 @@ package creator
 import (
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -49,7 +52,7 @@ func TestStart(t *testing.T) {
 			rg := router.Group("/api/v1")
 
 			// Act
-			err := Start(rg, nil, tt.httpClient, nil, tt.exchange, tt.queueName)
+			err := Start(rg, nil, tt.httpClient, nil, new(paymentlocker.MockAdvisoryLocker), nil, tt.exchange, tt.queueName, messagebroker.EventFormat{Mode: messagebroker.EventModeStructured}, IdempotencyPolicy{TTL: time.Hour, LockTimeout: time.Minute})
 
 			// Assert
 			if tt.expectedError {
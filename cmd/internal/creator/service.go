@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
 )
 
 // WalletReserver interface for reserving funds
@@ -25,15 +28,21 @@ type PaymentPublisher interface {
 	Publish(ctx context.Context, payment *domain.Payment) error
 }
 
+// PaymentLocker interface for serializing the status changes of each payment
+type PaymentLocker interface {
+	Lock(ctx context.Context, paymentID string) (paymentlocker.Unlocker, error)
+}
+
 // PaymentCreator handles payment creation business logic
 type PaymentCreatorService struct {
 	paymentStorer    PaymentStorer    // PaymentStorer implements the PaymentStorer interface
 	walletReserver   WalletReserver   // WalletReserver implements the WalletReserver interface
 	paymentPublisher PaymentPublisher // PaymentPublisher implements the PaymentPublisher interface
+	paymentLocker    PaymentLocker    // PaymentLocker implements the PaymentLocker interface
 }
 
 // NewPaymentCreator creates a new PaymentCreator
-func NewPaymentCreatorService(ps PaymentStorer, wr WalletReserver, pp PaymentPublisher) (*PaymentCreatorService, error) {
+func NewPaymentCreatorService(ps PaymentStorer, wr WalletReserver, pp PaymentPublisher, pl PaymentLocker) (*PaymentCreatorService, error) {
 	if ps == nil {
 		return nil, errors.New("payment creator: storer cannot be nil")
 	}
@@ -43,16 +52,22 @@ func NewPaymentCreatorService(ps PaymentStorer, wr WalletReserver, pp PaymentPub
 	if pp == nil {
 		return nil, errors.New("payment creator: publisher cannot be nil")
 	}
+	if pl == nil {
+		return nil, errors.New("payment creator: payment locker cannot be nil")
+	}
 
 	return &PaymentCreatorService{
 		paymentStorer:    ps,
 		walletReserver:   wr,
 		paymentPublisher: pp,
+		paymentLocker:    pl,
 	}, nil
 }
 
 // Create creates a new payment and publishes it
 // It creates a new payment, reserves funds, updates the status to "reserved" and publishes the payment
+// A payment already created with the key is returned only if it was created with the same request,
+// otherwise it returns domain.ErrIdempotencyKeyMismatch
 // It returns a new payment and an error if the payment cannot be created
 func (pcs *PaymentCreatorService) Create(ctx context.Context, idempotencyKey string, pr *PaymentRequest) (*domain.Payment, error) {
 	// Step 1: Check if payment already exists
@@ -61,6 +76,9 @@ func (pcs *PaymentCreatorService) Create(ctx context.Context, idempotencyKey str
 		return nil, fmt.Errorf("payment creator: get by idempotency key: %w", err)
 	}
 	if existingPayment != nil {
+		if !pr.Matches(existingPayment) {
+			return nil, fmt.Errorf("payment creator: payment %s: %w", existingPayment.ID, domain.ErrIdempotencyKeyMismatch)
+		}
 		return existingPayment, nil
 	}
 
@@ -68,28 +86,165 @@ func (pcs *PaymentCreatorService) Create(ctx context.Context, idempotencyKey str
 	payment := NewPayment(idempotencyKey, pr.UserID, pr.Amount, pr.Currency)
 
 	if err := pcs.paymentStorer.Save(ctx, payment); err != nil {
+		// A concurrent request with the same key saved its payment first, that payment is the result
+		if errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+			return pcs.winningPayment(ctx, idempotencyKey, pr)
+		}
 		return nil, fmt.Errorf("payment creator: save payment: %w", err)
 	}
 
-	// Step 3: Reserve funds in wallet
-	if err := pcs.walletReserver.Reserve(ctx, pr.UserID, pr.Amount, payment.ID); err != nil {
+	// Steps 3 and 4: Reserve funds in wallet and update status to "reserved"
+	if err := pcs.reserve(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	// Step 6: Publish payment event
+	if err := pcs.paymentPublisher.Publish(ctx, payment); err != nil {
+		return nil, fmt.Errorf("payment creator: publish payment: %w", err)
+	}
+
+	return payment, nil
+}
+
+// reserve reserves the funds of a new payment and records the outcome while holding the payment lock,
+// so a job repairing the payment in the meantime waits for the status written here and is checked against it
+// A payment whose lock cannot be acquired stays pending for the recoverer
+func (pcs *PaymentCreatorService) reserve(ctx context.Context, payment *domain.Payment) error {
+	lock, err := pcs.paymentLocker.Lock(ctx, payment.ID)
+	if err != nil {
+		return fmt.Errorf("payment creator: lock payment: %w", err)
+	}
+	defer func() {
+		if err := lock.Unlock(ctx); err != nil {
+			slog.WarnContext(ctx, "Failed to unlock payment", "error", err, "payment_id", payment.ID)
+		}
+	}()
+
+	if err := pcs.walletReserver.Reserve(ctx, payment.UserID, payment.Amount, payment.ID); err != nil {
 		if err := pcs.paymentStorer.UpdateStatus(ctx, payment.ID, domain.StatusFailed, ""); err != nil {
-			return nil, fmt.Errorf("payment creator: update status to failed: %w", err)
+			return fmt.Errorf("payment creator: update status to failed: %w", err)
 		}
-		return nil, fmt.Errorf("payment creator: reserve funds: %w", err)
+		return fmt.Errorf("payment creator: reserve funds: %w", err)
 	}
 
-	// Step 4: Update status to "reserved"
 	if err := pcs.paymentStorer.UpdateStatus(ctx, payment.ID, domain.StatusReserved, ""); err != nil {
-		return nil, fmt.Errorf("payment creator: update status to reserved: %w", err)
+		return fmt.Errorf("payment creator: update status to reserved: %w", err)
 	}
 
 	payment.UpdateStatus(domain.StatusReserved)
+	return nil
+}
 
-	// Step 6: Publish payment event
-	if err := pcs.paymentPublisher.Publish(ctx, payment); err != nil {
-		return nil, fmt.Errorf("payment creator: publish payment: %w", err)
+// winningPayment loads the payment saved by the concurrent request that won the idempotency key
+// It returns domain.ErrIdempotencyKeyMismatch if the winning payment was created with a different request
+func (pcs *PaymentCreatorService) winningPayment(ctx context.Context, idempotencyKey string, pr *PaymentRequest) (*domain.Payment, error) {
+	payment, err := pcs.paymentStorer.GetByIDempotencyKey(ctx, idempotencyKey)
+	if err != nil {
+		return nil, fmt.Errorf("payment creator: get winning payment: %w", err)
+	}
+	if payment == nil {
+		return nil, fmt.Errorf("payment creator: get winning payment: %w", domain.ErrPaymentNotFound)
+	}
+	if !pr.Matches(payment) {
+		return nil, fmt.Errorf("payment creator: payment %s: %w", payment.ID, domain.ErrIdempotencyKeyMismatch)
 	}
 
 	return payment, nil
 }
+
+// IdempotencyStorer interface for storing idempotency records
+type IdempotencyStorer interface {
+	Get(ctx context.Context, key string) (*IdempotencyRecord, error)
+	Start(ctx context.Context, record *IdempotencyRecord) error
+	Complete(ctx context.Context, key string, responseCode int, responseBody []byte) error
+	Release(ctx context.Context, key string) error
+	Abandon(ctx context.Context, key string) error
+}
+
+// IdempotencyGuardService guards payment creation so each idempotency key runs a single request
+type IdempotencyGuardService struct {
+	idempotencyStorer IdempotencyStorer // IdempotencyStorer implements the IdempotencyStorer interface
+	policy            IdempotencyPolicy // Policy defines how long a key is remembered
+}
+
+// NewIdempotencyGuardService creates a new IdempotencyGuardService
+// It returns a new IdempotencyGuardService and an error if the storer is nil or the policy is invalid
+func NewIdempotencyGuardService(is IdempotencyStorer, policy IdempotencyPolicy) (*IdempotencyGuardService, error) {
+	if is == nil {
+		return nil, errors.New("idempotency guard: storer cannot be nil")
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("idempotency guard: invalid policy: %w", err)
+	}
+
+	return &IdempotencyGuardService{
+		idempotencyStorer: is,
+		policy:            policy,
+	}, nil
+}
+
+// Claim claims an idempotency key for the request with the given fingerprint
+// It returns the stored record when the key already has a response to replay, or nil when the caller must run the request
+// It returns domain.ErrIdempotencyKeyMismatch if the key was used with a different request and domain.ErrIdempotencyKeyInProgress if the first request is still running
+// A first request that holds the key past the lock timeout without a response is assumed to have crashed, and the key is claimed again
+func (igs *IdempotencyGuardService) Claim(ctx context.Context, key string, fingerprint string) (*IdempotencyRecord, error) {
+	now := time.Now()
+
+	record, err := igs.idempotencyStorer.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("idempotency guard: get record: %w", err)
+	}
+
+	if record != nil && !record.Expired(now) {
+		if record.RequestHash != fingerprint {
+			return nil, domain.ErrIdempotencyKeyMismatch
+		}
+		if record.Locked(now) {
+			return nil, domain.ErrIdempotencyKeyInProgress
+		}
+		if !record.InProgress() {
+			return record, nil
+		}
+		// The first request stopped without a response, its key is reclaimed below
+	}
+
+	if err := igs.idempotencyStorer.Start(ctx, NewIdempotencyRecord(key, fingerprint, now, igs.policy.TTL, igs.policy.LockTimeout)); err != nil {
+		if errors.Is(err, domain.ErrIdempotencyKeyInProgress) {
+			return nil, domain.ErrIdempotencyKeyInProgress
+		}
+		return nil, fmt.Errorf("idempotency guard: start record: %w", err)
+	}
+
+	return nil, nil
+}
+
+// Complete stores the response of the request that claimed the key, so retries replay it
+func (igs *IdempotencyGuardService) Complete(ctx context.Context, key string, responseCode int, responseBody []byte) error {
+	if err := igs.idempotencyStorer.Complete(ctx, key, responseCode, responseBody); err != nil {
+		return fmt.Errorf("idempotency guard: complete record: %w", err)
+	}
+
+	return nil
+}
+
+// Release gives up the key claimed by a request that failed unexpectedly, so the client can retry with it
+// Only a retry with the same request can claim it again, the payment created with the key may still exist
+func (igs *IdempotencyGuardService) Release(ctx context.Context, key string) error {
+	if err := igs.idempotencyStorer.Release(ctx, key); err != nil {
+		return fmt.Errorf("idempotency guard: release record: %w", err)
+	}
+
+	return nil
+}
+
+// Abandon gives up the key claimed by a request rejected by a transient failure, along with the failed payment that used it,
+// so a retry with the key creates a new payment instead of replaying the rejection
+// It does not take the payment lock: it only clears the key of a payment already failed, a final status no writer
+// changes, and the update matches the failed status so it never touches a payment another writer moved on
+func (igs *IdempotencyGuardService) Abandon(ctx context.Context, key string) error {
+	if err := igs.idempotencyStorer.Abandon(ctx, key); err != nil {
+		return fmt.Errorf("idempotency guard: abandon record: %w", err)
+	}
+
+	return nil
+}
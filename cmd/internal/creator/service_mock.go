@@ -20,3 +20,35 @@ func (m *MockPaymentCreatorService) Create(ctx context.Context, idempotencyKey s
 	}
 	return args.Get(0).(*domain.Payment), args.Error(1)
 }
+
+// MockIdempotencyGuardService is a mock implementation of IdempotencyGuardService
+type MockIdempotencyGuardService struct {
+	mock.Mock
+}
+
+// Claim claims an idempotency key
+func (m *MockIdempotencyGuardService) Claim(ctx context.Context, key string, fingerprint string) (*IdempotencyRecord, error) {
+	args := m.Called(ctx, key, fingerprint)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*IdempotencyRecord), args.Error(1)
+}
+
+// Complete stores the response of an idempotency key
+func (m *MockIdempotencyGuardService) Complete(ctx context.Context, key string, responseCode int, responseBody []byte) error {
+	args := m.Called(ctx, key, responseCode, responseBody)
+	return args.Error(0)
+}
+
+// Release releases an idempotency key
+func (m *MockIdempotencyGuardService) Release(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+// Abandon gives up an idempotency key and its failed payment
+func (m *MockIdempotencyGuardService) Abandon(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
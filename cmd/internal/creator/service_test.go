@@ -3,10 +3,14 @@ package creator
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentstorer"
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/walletclient"
 	"github.com/stretchr/testify/assert"
@@ -19,6 +23,7 @@ func TestNewPaymentCreatorService(t *testing.T) {
 		paymentStorer    PaymentStorer
 		walletReserver   WalletReserver
 		paymentPublisher PaymentPublisher
+		paymentLocker    PaymentLocker
 		expectedError    string
 	}{
 		{
@@ -26,6 +31,7 @@ func TestNewPaymentCreatorService(t *testing.T) {
 			paymentStorer:    new(paymentstorer.MockPaymentRepository),
 			walletReserver:   new(walletclient.MockWalletClient),
 			paymentPublisher: new(MockPaymentPublisherRepository),
+			paymentLocker:    new(paymentlocker.MockPaymentLockerRepository),
 			expectedError:    "",
 		},
 		{
@@ -33,6 +39,7 @@ func TestNewPaymentCreatorService(t *testing.T) {
 			paymentStorer:    nil,
 			walletReserver:   new(walletclient.MockWalletClient),
 			paymentPublisher: new(MockPaymentPublisherRepository),
+			paymentLocker:    new(paymentlocker.MockPaymentLockerRepository),
 			expectedError:    "payment creator: storer cannot be nil",
 		},
 		{
@@ -40,6 +47,7 @@ func TestNewPaymentCreatorService(t *testing.T) {
 			paymentStorer:    new(paymentstorer.MockPaymentRepository),
 			walletReserver:   nil,
 			paymentPublisher: new(MockPaymentPublisherRepository),
+			paymentLocker:    new(paymentlocker.MockPaymentLockerRepository),
 			expectedError:    "payment creator: wallet reserver cannot be nil",
 		},
 		{
@@ -47,8 +55,17 @@ func TestNewPaymentCreatorService(t *testing.T) {
 			paymentStorer:    new(paymentstorer.MockPaymentRepository),
 			walletReserver:   new(walletclient.MockWalletClient),
 			paymentPublisher: nil,
+			paymentLocker:    new(paymentlocker.MockPaymentLockerRepository),
 			expectedError:    "payment creator: publisher cannot be nil",
 		},
+		{
+			name:             "when payment locker is nil it should return error",
+			paymentStorer:    new(paymentstorer.MockPaymentRepository),
+			walletReserver:   new(walletclient.MockWalletClient),
+			paymentPublisher: new(MockPaymentPublisherRepository),
+			paymentLocker:    nil,
+			expectedError:    "payment creator: payment locker cannot be nil",
+		},
 	}
 
 	for _, tt := range tests {
@@ -57,7 +74,7 @@ func TestNewPaymentCreatorService(t *testing.T) {
 			// (Dependencies already prepared in test struct)
 
 			// Act
-			result, err := NewPaymentCreatorService(tt.paymentStorer, tt.walletReserver, tt.paymentPublisher)
+			result, err := NewPaymentCreatorService(tt.paymentStorer, tt.walletReserver, tt.paymentPublisher, tt.paymentLocker)
 
 			// Assert
 			if tt.expectedError != "" {
@@ -82,6 +99,7 @@ func TestPaymentCreatorService_Create(t *testing.T) {
 		mockExistingPayment *domain.Payment
 		mockGetError        error
 		mockSaveError       error
+		mockLockError       error
 		mockReserveError    error
 		mockUpdateError     error
 		mockPublishError    error
@@ -118,6 +136,32 @@ func TestPaymentCreatorService_Create(t *testing.T) {
 			expectedError:     nil,
 			expectPayment:     true,
 		},
+		{
+			name:           "when payment was created with the key for a different request it should return mismatch error",
+			idempotencyKey: "key_existing",
+			request: &PaymentRequest{
+				UserID:   "user_123",
+				Amount:   250.00,
+				Currency: domain.CurrencyUSD,
+			},
+			mockExistingPayment: &domain.Payment{
+				ID:             "pay_existing",
+				IdempotencyKey: "key_existing",
+				UserID:         "user_123",
+				Amount:         100.50,
+				Currency:       domain.CurrencyUSD,
+				Status:         domain.StatusFailed,
+				CreatedAt:      fixedTime,
+				UpdatedAt:      fixedTime,
+			},
+			mockGetError:      nil,
+			shouldCallSave:    false,
+			shouldCallReserve: false,
+			shouldCallUpdate:  false,
+			shouldCallPublish: false,
+			expectedError:     errors.New("payment creator: payment pay_existing: idempotency key was used with a different request"),
+			expectPayment:     false,
+		},
 		{
 			name:           "when new payment is created successfully it should return payment and no error",
 			idempotencyKey: "key_new",
@@ -174,6 +218,25 @@ func TestPaymentCreatorService_Create(t *testing.T) {
 			expectedError:        errors.New("payment creator: save payment: save failed"),
 			expectPayment:        false,
 		},
+		{
+			name:           "when the payment lock cannot be acquired it should return wrapped error without reserving funds",
+			idempotencyKey: "key_lock_error",
+			request: &PaymentRequest{
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+			},
+			mockExistingPayment: nil,
+			mockGetError:        nil,
+			mockSaveError:       nil,
+			mockLockError:       context.DeadlineExceeded,
+			shouldCallSave:      true,
+			shouldCallReserve:   false,
+			shouldCallUpdate:    false,
+			shouldCallPublish:   false,
+			expectedError:       errors.New("payment creator: lock payment: context deadline exceeded"),
+			expectPayment:       false,
+		},
 		{
 			name:           "when reserve funds fails it should update status to failed and return wrapped error",
 			idempotencyKey: "key_reserve_error",
@@ -263,6 +326,14 @@ func TestPaymentCreatorService_Create(t *testing.T) {
 			mockStorer := new(paymentstorer.MockPaymentRepository)
 			mockReserver := new(walletclient.MockWalletClient)
 			mockPublisher := new(MockPaymentPublisherRepository)
+			mockLocker := new(paymentlocker.MockPaymentLockerRepository)
+			mockUnlocker := new(paymentlocker.MockUnlocker)
+
+			// The funds are reserved and the status written only while the payment lock is held
+			locked := false
+			assertLocked := func(mock.Arguments) {
+				assert.True(t, locked, "the payment lock should be held")
+			}
 
 			mockStorer.On("GetByIDempotencyKey", mock.Anything, tt.idempotencyKey).Return(tt.mockExistingPayment, tt.mockGetError)
 
@@ -270,26 +341,36 @@ func TestPaymentCreatorService_Create(t *testing.T) {
 				mockStorer.On("Save", mock.Anything, mock.Anything).Return(tt.mockSaveError)
 			}
 
+			if tt.mockLockError != nil {
+				mockLocker.On("Lock", mock.Anything, mock.Anything).Return(nil, tt.mockLockError)
+			} else if tt.shouldCallReserve {
+				mockLocker.On("Lock", mock.Anything, mock.Anything).Run(func(mock.Arguments) { locked = true }).Return(mockUnlocker, nil)
+				mockUnlocker.On("Unlock", mock.Anything).Run(func(mock.Arguments) { locked = false }).Return(nil)
+			}
+
 			if tt.shouldCallReserve {
-				mockReserver.On("Reserve", mock.Anything, tt.request.UserID, tt.request.Amount, mock.Anything).Return(tt.mockReserveError)
+				mockReserver.On("Reserve", mock.Anything, tt.request.UserID, tt.request.Amount, mock.Anything).Run(assertLocked).Return(tt.mockReserveError)
 			}
 
 			if tt.shouldCallUpdate {
 				if tt.mockReserveError != nil {
-					mockStorer.On("UpdateStatus", mock.Anything, mock.Anything, domain.StatusFailed, "").Return(tt.mockUpdateError)
+					mockStorer.On("UpdateStatus", mock.Anything, mock.Anything, domain.StatusFailed, "").Run(assertLocked).Return(tt.mockUpdateError)
 				} else {
-					mockStorer.On("UpdateStatus", mock.Anything, mock.Anything, domain.StatusReserved, "").Return(tt.mockUpdateError)
+					mockStorer.On("UpdateStatus", mock.Anything, mock.Anything, domain.StatusReserved, "").Run(assertLocked).Return(tt.mockUpdateError)
 				}
 			}
 
 			if tt.shouldCallPublish {
-				mockPublisher.On("Publish", mock.Anything, mock.Anything).Return(tt.mockPublishError)
+				mockPublisher.On("Publish", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+					assert.False(t, locked, "the payment lock should be released before publishing")
+				}).Return(tt.mockPublishError)
 			}
 
 			service := &PaymentCreatorService{
 				paymentStorer:    mockStorer,
 				walletReserver:   mockReserver,
 				paymentPublisher: mockPublisher,
+				paymentLocker:    mockLocker,
 			}
 
 			// Act
@@ -310,6 +391,516 @@ func TestPaymentCreatorService_Create(t *testing.T) {
 			mockStorer.AssertExpectations(t)
 			mockReserver.AssertExpectations(t)
 			mockPublisher.AssertExpectations(t)
+			mockLocker.AssertExpectations(t)
+			mockUnlocker.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPaymentCreatorService_Create_DuplicateKey(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	winner := &domain.Payment{
+		ID:             "pay_winner",
+		IdempotencyKey: "key_123",
+		UserID:         "user_123",
+		Amount:         100.50,
+		Currency:       domain.CurrencyUSD,
+		Status:         domain.StatusPending,
+		CreatedAt:      fixedTime,
+		UpdatedAt:      fixedTime,
+	}
+
+	tests := []struct {
+		name            string
+		mockWinner      *domain.Payment
+		mockWinnerError error
+		expectedPayment *domain.Payment
+		expectedError   error
+	}{
+		{
+			name:            "when another request saved the key first it should return the winning payment and no error",
+			mockWinner:      winner,
+			expectedPayment: winner,
+			expectedError:   nil,
+		},
+		{
+			name: "when another request saved the key first with a different request it should return mismatch error",
+			mockWinner: &domain.Payment{
+				ID:             "pay_winner",
+				IdempotencyKey: "key_123",
+				UserID:         "user_123",
+				Amount:         250.00,
+				Currency:       domain.CurrencyUSD,
+				Status:         domain.StatusPending,
+				CreatedAt:      fixedTime,
+				UpdatedAt:      fixedTime,
+			},
+			expectedError: errors.New("payment creator: payment pay_winner: idempotency key was used with a different request"),
+		},
+		{
+			name:            "when winning payment lookup fails it should return wrapped error",
+			mockWinnerError: errors.New("database error"),
+			expectedError:   errors.New("payment creator: get winning payment: database error"),
+		},
+		{
+			name:          "when winning payment is not found it should return wrapped not found error",
+			mockWinner:    nil,
+			expectedError: errors.New("payment creator: get winning payment: payment not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockStorer := new(paymentstorer.MockPaymentRepository)
+			mockReserver := new(walletclient.MockWalletClient)
+			mockPublisher := new(MockPaymentPublisherRepository)
+
+			mockStorer.On("GetByIDempotencyKey", mock.Anything, "key_123").Return(nil, nil).Once()
+			mockStorer.On("Save", mock.Anything, mock.Anything).Return(fmt.Errorf("payment repository: save: insert payment: %w", domain.ErrDuplicateIdempotencyKey))
+			mockStorer.On("GetByIDempotencyKey", mock.Anything, "key_123").Return(tt.mockWinner, tt.mockWinnerError).Once()
+
+			service := &PaymentCreatorService{
+				paymentStorer:    mockStorer,
+				walletReserver:   mockReserver,
+				paymentPublisher: mockPublisher,
+			}
+
+			// Act
+			result, err := service.Create(context.Background(), "key_123", &PaymentRequest{UserID: "user_123", Amount: 100.50, Currency: domain.CurrencyUSD})
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedPayment, result)
+			}
+
+			mockStorer.AssertExpectations(t)
+			mockReserver.AssertNotCalled(t, "Reserve", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			mockPublisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// uniqueKeyStorer is an in-memory PaymentStorer that enforces the idempotency key unique constraint
+// The first lookup of every request waits for the others, so all of them miss the key before saving
+type uniqueKeyStorer struct {
+	mu       sync.Mutex
+	payments map[string]*domain.Payment
+	requests int32
+	lookups  atomic.Int32
+	barrier  sync.WaitGroup
+}
+
+func newUniqueKeyStorer(requests int) *uniqueKeyStorer {
+	s := &uniqueKeyStorer{payments: map[string]*domain.Payment{}, requests: int32(requests)}
+	s.barrier.Add(requests)
+	return s
+}
+
+func (s *uniqueKeyStorer) GetByIDempotencyKey(ctx context.Context, idempotencyKey string) (*domain.Payment, error) {
+	if s.lookups.Add(1) <= s.requests {
+		s.barrier.Done()
+		s.barrier.Wait()
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.payments[idempotencyKey], nil
+}
+
+func (s *uniqueKeyStorer) Save(ctx context.Context, payment *domain.Payment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.payments[payment.IdempotencyKey]; exists {
+		return fmt.Errorf("payment repository: save: insert payment: %w", domain.ErrDuplicateIdempotencyKey)
+	}
+	s.payments[payment.IdempotencyKey] = payment
+	return nil
+}
+
+func (s *uniqueKeyStorer) UpdateStatus(ctx context.Context, paymentID string, status domain.Status, gatewayRef string) error {
+	return nil
+}
+
+func TestPaymentCreatorService_Create_ConcurrentDuplicates(t *testing.T) {
+	// Arrange
+	const requests = 10
+
+	storer := newUniqueKeyStorer(requests)
+
+	mockReserver := new(walletclient.MockWalletClient)
+	mockReserver.On("Reserve", mock.Anything, "user_123", 100.50, mock.Anything).Return(nil)
+
+	mockPublisher := new(MockPaymentPublisherRepository)
+	mockPublisher.On("Publish", mock.Anything, mock.Anything).Return(nil)
+
+	mockUnlocker := new(paymentlocker.MockUnlocker)
+	mockUnlocker.On("Unlock", mock.Anything).Return(nil)
+	mockLocker := new(paymentlocker.MockPaymentLockerRepository)
+	mockLocker.On("Lock", mock.Anything, mock.Anything).Return(mockUnlocker, nil)
+
+	service := &PaymentCreatorService{
+		paymentStorer:    storer,
+		walletReserver:   mockReserver,
+		paymentPublisher: mockPublisher,
+		paymentLocker:    mockLocker,
+	}
+
+	request := &PaymentRequest{UserID: "user_123", Amount: 100.50, Currency: domain.CurrencyUSD}
+	results := make([]*domain.Payment, requests)
+	errs := make([]error, requests)
+
+	// Act
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = service.Create(context.Background(), "key_123", request)
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert
+	for i := 0; i < requests; i++ {
+		assert.NoError(t, errs[i])
+		assert.NotNil(t, results[i])
+		assert.Equal(t, results[0].ID, results[i].ID)
+	}
+
+	mockReserver.AssertNumberOfCalls(t, "Reserve", 1)
+	mockPublisher.AssertNumberOfCalls(t, "Publish", 1)
+}
+
+func TestNewIdempotencyGuardService(t *testing.T) {
+	tests := []struct {
+		name              string
+		idempotencyStorer IdempotencyStorer
+		policy            IdempotencyPolicy
+		expectedError     string
+	}{
+		{
+			name:              "when all dependencies are provided it should create service successfully and no error",
+			idempotencyStorer: new(MockIdempotencyKeyRepository),
+			policy:            IdempotencyPolicy{TTL: 24 * time.Hour, LockTimeout: time.Minute},
+			expectedError:     "",
+		},
+		{
+			name:              "when idempotency storer is nil it should return error",
+			idempotencyStorer: nil,
+			policy:            IdempotencyPolicy{TTL: 24 * time.Hour, LockTimeout: time.Minute},
+			expectedError:     "idempotency guard: storer cannot be nil",
+		},
+		{
+			name:              "when policy is invalid it should return error",
+			idempotencyStorer: new(MockIdempotencyKeyRepository),
+			policy:            IdempotencyPolicy{TTL: 0, LockTimeout: time.Minute},
+			expectedError:     "idempotency guard: invalid policy: ttl must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewIdempotencyGuardService(tt.idempotencyStorer, tt.policy)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestIdempotencyGuardService_Claim(t *testing.T) {
+	completed := &IdempotencyRecord{
+		Key:          "key_123",
+		RequestHash:  "hash_123",
+		ResponseCode: 201,
+		ResponseBody: []byte(`{"message":"payment created successfully"}`),
+		CreatedAt:    time.Now().Add(-time.Hour),
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+
+	tests := []struct {
+		name            string
+		fingerprint     string
+		mockRecord      *IdempotencyRecord
+		mockGetError    error
+		mockStartError  error
+		shouldCallStart bool
+		expectedRecord  *IdempotencyRecord
+		expectedError   error
+	}{
+		{
+			name:            "when key was never used it should start a record and return nil and no error",
+			fingerprint:     "hash_123",
+			mockRecord:      nil,
+			shouldCallStart: true,
+			expectedRecord:  nil,
+			expectedError:   nil,
+		},
+		{
+			name:            "when key has a stored response for the same request it should return the record and no error",
+			fingerprint:     "hash_123",
+			mockRecord:      completed,
+			shouldCallStart: false,
+			expectedRecord:  completed,
+			expectedError:   nil,
+		},
+		{
+			name:            "when key was used with a different request it should return mismatch error",
+			fingerprint:     "hash_456",
+			mockRecord:      completed,
+			shouldCallStart: false,
+			expectedError:   domain.ErrIdempotencyKeyMismatch,
+		},
+		{
+			name:        "when first request is still running it should return in progress error",
+			fingerprint: "hash_123",
+			mockRecord: &IdempotencyRecord{
+				Key:         "key_123",
+				RequestHash: "hash_123",
+				CreatedAt:   time.Now(),
+				ExpiresAt:   time.Now().Add(time.Hour),
+				LockedUntil: time.Now().Add(time.Minute),
+			},
+			shouldCallStart: false,
+			expectedError:   domain.ErrIdempotencyKeyInProgress,
+		},
+		{
+			name:        "when first request holds the key past its lock without a response it should start the record again and no error",
+			fingerprint: "hash_123",
+			mockRecord: &IdempotencyRecord{
+				Key:         "key_123",
+				RequestHash: "hash_123",
+				CreatedAt:   time.Now().Add(-10 * time.Minute),
+				ExpiresAt:   time.Now().Add(time.Hour),
+				LockedUntil: time.Now().Add(-9 * time.Minute),
+			},
+			shouldCallStart: true,
+			expectedRecord:  nil,
+			expectedError:   nil,
+		},
+		{
+			name:        "when key was released by a failed request and is retried with a different request it should return mismatch error",
+			fingerprint: "hash_456",
+			mockRecord: &IdempotencyRecord{
+				Key:         "key_123",
+				RequestHash: "hash_123",
+				CreatedAt:   time.Now().Add(-time.Minute),
+				ExpiresAt:   time.Now().Add(time.Hour),
+				LockedUntil: time.Now().Add(-time.Minute),
+			},
+			shouldCallStart: false,
+			expectedError:   domain.ErrIdempotencyKeyMismatch,
+		},
+		{
+			name:        "when key has expired it should start a new record even for a different request and no error",
+			fingerprint: "hash_456",
+			mockRecord: &IdempotencyRecord{
+				Key:          "key_123",
+				RequestHash:  "hash_123",
+				ResponseCode: 201,
+				CreatedAt:    time.Now().Add(-48 * time.Hour),
+				ExpiresAt:    time.Now().Add(-24 * time.Hour),
+			},
+			shouldCallStart: true,
+			expectedRecord:  nil,
+			expectedError:   nil,
+		},
+		{
+			name:            "when another request starts the key first it should return in progress error",
+			fingerprint:     "hash_123",
+			mockRecord:      nil,
+			mockStartError:  fmt.Errorf("idempotency key repository: start: %w", domain.ErrIdempotencyKeyInProgress),
+			shouldCallStart: true,
+			expectedError:   domain.ErrIdempotencyKeyInProgress,
+		},
+		{
+			name:          "when get fails it should return wrapped error",
+			fingerprint:   "hash_123",
+			mockGetError:  errors.New("database error"),
+			expectedError: errors.New("idempotency guard: get record: database error"),
+		},
+		{
+			name:            "when start fails it should return wrapped error",
+			fingerprint:     "hash_123",
+			mockRecord:      nil,
+			mockStartError:  errors.New("database error"),
+			shouldCallStart: true,
+			expectedError:   errors.New("idempotency guard: start record: database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockStorer := new(MockIdempotencyKeyRepository)
+			mockStorer.On("Get", mock.Anything, "key_123").Return(tt.mockRecord, tt.mockGetError)
+			if tt.shouldCallStart {
+				mockStorer.On("Start", mock.Anything, mock.MatchedBy(func(record *IdempotencyRecord) bool {
+					return record.Key == "key_123" && record.RequestHash == tt.fingerprint && record.InProgress() && record.ExpiresAt.Sub(record.CreatedAt) == 24*time.Hour && record.LockedUntil.Sub(record.CreatedAt) == time.Minute
+				})).Return(tt.mockStartError)
+			}
+
+			service := &IdempotencyGuardService{idempotencyStorer: mockStorer, policy: IdempotencyPolicy{TTL: 24 * time.Hour, LockTimeout: time.Minute}}
+
+			// Act
+			result, err := service.Claim(context.Background(), "key_123", tt.fingerprint)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedRecord, result)
+			}
+
+			mockStorer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIdempotencyGuardService_Complete(t *testing.T) {
+	tests := []struct {
+		name              string
+		mockCompleteError error
+		expectedError     error
+	}{
+		{
+			name:              "when response is stored it should return no error",
+			mockCompleteError: nil,
+			expectedError:     nil,
+		},
+		{
+			name:              "when storer fails it should return wrapped error",
+			mockCompleteError: errors.New("database error"),
+			expectedError:     errors.New("idempotency guard: complete record: database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			body := []byte(`{"message":"payment created successfully"}`)
+			mockStorer := new(MockIdempotencyKeyRepository)
+			mockStorer.On("Complete", mock.Anything, "key_123", 201, body).Return(tt.mockCompleteError)
+
+			service := &IdempotencyGuardService{idempotencyStorer: mockStorer, policy: IdempotencyPolicy{TTL: 24 * time.Hour, LockTimeout: time.Minute}}
+
+			// Act
+			err := service.Complete(context.Background(), "key_123", 201, body)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockStorer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIdempotencyGuardService_Release(t *testing.T) {
+	tests := []struct {
+		name             string
+		mockReleaseError error
+		expectedError    error
+	}{
+		{
+			name:             "when key is released it should return no error",
+			mockReleaseError: nil,
+			expectedError:    nil,
+		},
+		{
+			name:             "when storer fails it should return wrapped error",
+			mockReleaseError: errors.New("database error"),
+			expectedError:    errors.New("idempotency guard: release record: database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockStorer := new(MockIdempotencyKeyRepository)
+			mockStorer.On("Release", mock.Anything, "key_123").Return(tt.mockReleaseError)
+
+			service := &IdempotencyGuardService{idempotencyStorer: mockStorer, policy: IdempotencyPolicy{TTL: 24 * time.Hour, LockTimeout: time.Minute}}
+
+			// Act
+			err := service.Release(context.Background(), "key_123")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockStorer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIdempotencyGuardService_Abandon(t *testing.T) {
+	tests := []struct {
+		name             string
+		mockAbandonError error
+		expectedError    error
+	}{
+		{
+			name:             "when key is abandoned it should return no error",
+			mockAbandonError: nil,
+			expectedError:    nil,
+		},
+		{
+			name:             "when storer fails it should return wrapped error",
+			mockAbandonError: errors.New("database error"),
+			expectedError:    errors.New("idempotency guard: abandon record: database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockStorer := new(MockIdempotencyKeyRepository)
+			mockStorer.On("Abandon", mock.Anything, "key_123").Return(tt.mockAbandonError)
+
+			service := &IdempotencyGuardService{idempotencyStorer: mockStorer, policy: IdempotencyPolicy{TTL: 24 * time.Hour, LockTimeout: time.Minute}}
+
+			// Act
+			err := service.Abandon(context.Background(), "key_123")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockStorer.AssertExpectations(t)
 		})
 	}
 }
@@ -0,0 +1,42 @@
+package emitter
+
+import (
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+)
+
+// Build creates a new Handler with all dependencies wired up
+// It builds a new handler and returns an error if the builder fails
+func Build(db OutboxDB, mbc *messagebroker.Connection, exchange string, policy PublishPolicy) (*Handler, error) {
+	or, err := NewOutboxRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	// Routing key is set per event type, consumers bind the event types they care about
+	p, err := messagebroker.NewPublisher(
+		mbc,
+		messagebroker.PublisherConfig{
+			Exchange: exchange,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ep, err := NewEnvelopePublisherRepository(p)
+	if err != nil {
+		return nil, err
+	}
+
+	ees, err := NewEventEmitterService(or, ep, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := NewHandler(ees, NewSchema(exchange))
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
@@ -0,0 +1,123 @@
+package emitter
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+)
+
+const (
+	// SchemaVersion is the version of the event envelope, bumped on breaking changes so consumers can branch on it
+	SchemaVersion = 1
+
+	// RoutingKeyPrefix prefixes the routing key of every domain event, consumers bind "payments.events.#" to receive all of them
+	// The prefix keeps the events apart from the "payments.created" command the processor consumes
+	RoutingKeyPrefix = "payments.events."
+
+	// ContentType is the content type of the published messages
+	ContentType = "application/json"
+)
+
+// RoutingKey returns the routing key a payment event type is published with
+func RoutingKey(eventType string) string {
+	return RoutingKeyPrefix + eventType
+}
+
+// Envelope is the versioned message every payment event is published in
+type Envelope struct {
+	SchemaVersion int             `json:"schema_version"` // Version of the envelope
+	ID            string          `json:"id"`             // ID of the payment event, the same on every publish so consumers can deduplicate
+	Type          string          `json:"type"`           // Type of the payment event
+	PaymentID     string          `json:"payment_id"`     // Payment the event belongs to
+	Sequence      int             `json:"sequence"`       // Sequence number of the event for this payment, consumers order the events with it
+	OccurredAt    time.Time       `json:"occurred_at"`    // Timestamp when the event was appended
+	Data          json.RawMessage `json:"data"`           // Payload of the payment event
+}
+
+// NewEnvelope wraps a payment event in the current envelope version
+func NewEnvelope(event *domain.Event) *Envelope {
+	return &Envelope{
+		SchemaVersion: SchemaVersion,
+		ID:            event.ID,
+		Type:          event.EventType,
+		PaymentID:     event.PaymentID,
+		Sequence:      event.Sequence,
+		OccurredAt:    event.CreatedAt,
+		Data:          event.Payload,
+	}
+}
+
+// PublishPolicy defines how many events are published per run
+type PublishPolicy struct {
+	BatchSize int // Events published per run
+}
+
+// Validate validates the publish policy
+// It returns an error if the policy is invalid
+func (p *PublishPolicy) Validate() error {
+	if p.BatchSize <= 0 {
+		return errors.New("batch size must be greater than 0")
+	}
+	return nil
+}
+
+// EmitResult summarizes the outcome of a publish run
+type EmitResult struct {
+	Published int `json:"published"` // Events published to the exchange
+}
+
+// EventSchema describes an event type and the routing key it is published with
+type EventSchema struct {
+	Type       string `json:"type"`        // Type of the payment event
+	RoutingKey string `json:"routing_key"` // Routing key the event is published with
+}
+
+// Schema describes where and how the payment events are published, so consumers can bind without reading the code
+type Schema struct {
+	Exchange      string         `json:"exchange"`       // Topic exchange the events are published to
+	Binding       string         `json:"binding"`        // Binding key receiving every event
+	SchemaVersion int            `json:"schema_version"` // Current envelope version
+	ContentType   string         `json:"content_type"`   // Content type of the messages
+	Events        []EventSchema  `json:"events"`         // Event types and their routing keys
+	Envelope      map[string]any `json:"envelope"`       // JSON Schema of the envelope
+}
+
+// NewSchema describes the events published to the given exchange
+func NewSchema(exchange string) *Schema {
+	events := make([]EventSchema, 0, len(domain.EventTypes))
+	for _, eventType := range domain.EventTypes {
+		events = append(events, EventSchema{Type: eventType, RoutingKey: RoutingKey(eventType)})
+	}
+
+	return &Schema{
+		Exchange:      exchange,
+		Binding:       RoutingKeyPrefix + "#",
+		SchemaVersion: SchemaVersion,
+		ContentType:   ContentType,
+		Events:        events,
+		Envelope:      envelopeSchema(),
+	}
+}
+
+// envelopeSchema returns the JSON Schema of the envelope
+// Fields are only added to a version, removing or changing one bumps SchemaVersion
+func envelopeSchema() map[string]any {
+	return map[string]any{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"title":                "PaymentEvent",
+		"type":                 "object",
+		"required":             []string{"schema_version", "id", "type", "payment_id", "sequence", "occurred_at", "data"},
+		"additionalProperties": true,
+		"properties": map[string]any{
+			"schema_version": map[string]any{"type": "integer", "const": SchemaVersion, "description": "Version of the envelope"},
+			"id":             map[string]any{"type": "string", "description": "ID of the payment event, consumers deduplicate with it"},
+			"type":           map[string]any{"type": "string", "enum": domain.EventTypes, "description": "Type of the payment event"},
+			"payment_id":     map[string]any{"type": "string", "description": "Payment the event belongs to"},
+			"sequence":       map[string]any{"type": "integer", "minimum": 1, "description": "Sequence number of the event for this payment"},
+			"occurred_at":    map[string]any{"type": "string", "format": "date-time", "description": "Timestamp when the event was appended"},
+			"data":           map[string]any{"type": "object", "description": "Payload of the payment event"},
+		},
+	}
+}
@@ -0,0 +1,142 @@
+package emitter
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutingKey(t *testing.T) {
+	tests := []struct {
+		name               string
+		eventType          string
+		expectedRoutingKey string
+	}{
+		{
+			name:               "when event type is completed it should return the completed routing key",
+			eventType:          string(domain.StatusCompleted),
+			expectedRoutingKey: "payments.events.completed",
+		},
+		{
+			name:               "when event type is created it should not collide with the processor routing key",
+			eventType:          domain.EventTypeCreated,
+			expectedRoutingKey: "payments.events.created",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Event type already prepared in test struct)
+
+			// Act
+			result := RoutingKey(tt.eventType)
+
+			// Assert
+			assert.Equal(t, tt.expectedRoutingKey, result)
+		})
+	}
+}
+
+func TestNewEnvelope(t *testing.T) {
+	// Arrange
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	event := &domain.Event{
+		ID:        "evt_1",
+		PaymentID: "pay_123",
+		Sequence:  3,
+		EventType: "completed",
+		Payload:   json.RawMessage(`{"status":"completed"}`),
+		CreatedAt: fixedTime,
+	}
+
+	// Act
+	result := NewEnvelope(event)
+
+	// Assert
+	assert.Equal(t, &Envelope{
+		SchemaVersion: SchemaVersion,
+		ID:            "evt_1",
+		Type:          "completed",
+		PaymentID:     "pay_123",
+		Sequence:      3,
+		OccurredAt:    fixedTime,
+		Data:          json.RawMessage(`{"status":"completed"}`),
+	}, result)
+
+	body, err := json.Marshal(result)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"schema_version": 1,
+		"id": "evt_1",
+		"type": "completed",
+		"payment_id": "pay_123",
+		"sequence": 3,
+		"occurred_at": "2024-01-15T10:30:00Z",
+		"data": {"status": "completed"}
+	}`, string(body))
+}
+
+func TestPublishPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        PublishPolicy
+		expectedError string
+	}{
+		{
+			name:          "when batch size is positive it should pass validation and no error",
+			policy:        PublishPolicy{BatchSize: 100},
+			expectedError: "",
+		},
+		{
+			name:          "when batch size is zero it should return error with message 'batch size must be greater than 0'",
+			policy:        PublishPolicy{BatchSize: 0},
+			expectedError: "batch size must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Policy already prepared in test struct)
+
+			// Act
+			err := tt.policy.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewSchema(t *testing.T) {
+	// Arrange
+	// (No dependencies)
+
+	// Act
+	result := NewSchema("payments")
+
+	// Assert
+	assert.Equal(t, "payments", result.Exchange)
+	assert.Equal(t, "payments.events.#", result.Binding)
+	assert.Equal(t, SchemaVersion, result.SchemaVersion)
+	assert.Equal(t, ContentType, result.ContentType)
+	assert.Len(t, result.Events, len(domain.EventTypes))
+	for i, eventType := range domain.EventTypes {
+		assert.Equal(t, EventSchema{Type: eventType, RoutingKey: "payments.events." + eventType}, result.Events[i])
+	}
+
+	properties := result.Envelope["properties"].(map[string]any)
+	for _, field := range result.Envelope["required"].([]string) {
+		assert.Contains(t, properties, field)
+	}
+	assert.Equal(t, domain.EventTypes, properties["type"].(map[string]any)["enum"])
+}
@@ -0,0 +1,62 @@
+package emitter
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EventEmitter defines the interface for publishing the payment events
+type EventEmitter interface {
+	Emit(ctx context.Context) (*EmitResult, error)
+}
+
+// Handler handles scheduled event publishing and the event schema requests
+type Handler struct {
+	eventEmitter EventEmitter
+	schema       *Schema
+}
+
+// NewHandler creates a new emitter handler
+// It returns a new emitter handler and an error if the event emitter or schema is nil
+func NewHandler(ee EventEmitter, schema *Schema) (*Handler, error) {
+	if ee == nil {
+		return nil, errors.New("emitter handler: event emitter cannot be nil")
+	}
+	if schema == nil {
+		return nil, errors.New("emitter handler: schema cannot be nil")
+	}
+
+	return &Handler{
+		eventEmitter: ee,
+		schema:       schema,
+	}, nil
+}
+
+// Run publishes the payment events waiting in the outbox
+// It returns an error if a run fails, the events left are published on the next run
+func (h *Handler) Run(ctx context.Context) error {
+	result, err := h.eventEmitter.Emit(ctx)
+	if err != nil {
+		published := 0
+		if result != nil {
+			published = result.Published
+		}
+		slog.ErrorContext(ctx, "Failed to publish payment events", "error", err, "published", published)
+		return err
+	}
+
+	slog.InfoContext(ctx, "Payment events published", "published", result.Published)
+	return nil
+}
+
+// Schema handles GET /events/schema requests
+func (h *Handler) Schema(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": "event schema retrieved successfully",
+		"data":    h.schema,
+	})
+}
@@ -0,0 +1,24 @@
+package emitter
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHandler is a mock implementation of Handler
+type MockHandler struct {
+	mock.Mock
+}
+
+// Run mocks the Run method
+func (m *MockHandler) Run(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+// Schema handles GET /events/schema requests
+func (m *MockHandler) Schema(c *gin.Context) {
+	m.Called(c)
+}
@@ -0,0 +1,143 @@
+package emitter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		eventEmitter  EventEmitter
+		schema        *Schema
+		expectedError string
+	}{
+		{
+			name:          "when all dependencies are provided it should create handler successfully and no error",
+			eventEmitter:  new(MockEventEmitterService),
+			schema:        NewSchema("payments"),
+			expectedError: "",
+		},
+		{
+			name:          "when event emitter is nil it should return error",
+			eventEmitter:  nil,
+			schema:        NewSchema("payments"),
+			expectedError: "emitter handler: event emitter cannot be nil",
+		},
+		{
+			name:          "when schema is nil it should return error",
+			eventEmitter:  new(MockEventEmitterService),
+			schema:        nil,
+			expectedError: "emitter handler: schema cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewHandler(tt.eventEmitter, tt.schema)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestHandler_Run(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockResult    *EmitResult
+		mockError     error
+		expectedError error
+	}{
+		{
+			name:          "when emit succeeds it should return no error",
+			mockResult:    &EmitResult{Published: 2},
+			expectedError: nil,
+		},
+		{
+			name:          "when emit fails after publishing some events it should return the error",
+			mockResult:    &EmitResult{Published: 1},
+			mockError:     errors.New("event emitter: publish event evt_2: channel closed"),
+			expectedError: errors.New("event emitter: publish event evt_2: channel closed"),
+		},
+		{
+			name:          "when emit fails before publishing it should return the error",
+			mockError:     errors.New("event emitter: list pending events: connection refused"),
+			expectedError: errors.New("event emitter: list pending events: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockEmitter := new(MockEventEmitterService)
+			mockEmitter.On("Emit", mock.Anything).Return(tt.mockResult, tt.mockError)
+
+			handler := &Handler{eventEmitter: mockEmitter, schema: NewSchema("payments")}
+
+			// Act
+			err := handler.Run(context.Background())
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockEmitter.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_Schema(t *testing.T) {
+	// Arrange
+	handler := &Handler{eventEmitter: new(MockEventEmitterService), schema: NewSchema("payments")}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/events/schema", nil)
+
+	// Act
+	handler.Schema(c)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Message string `json:"message"`
+		Data    Schema `json:"data"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "event schema retrieved successfully", response.Message)
+	assert.Equal(t, "payments", response.Data.Exchange)
+	assert.Equal(t, "payments.events.#", response.Data.Binding)
+	assert.Equal(t, SchemaVersion, response.Data.SchemaVersion)
+	assert.Contains(t, response.Data.Events, EventSchema{Type: "completed", RoutingKey: "payments.events.completed"})
+	assert.Equal(t, "PaymentEvent", response.Data.Envelope["title"])
+}
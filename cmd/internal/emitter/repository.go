@@ -0,0 +1,124 @@
+package emitter
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+)
+
+// OutboxDB defines the database operations required by OutboxRepository
+type OutboxDB interface {
+	QueryContext(ctx context.Context, query string, args ...any) (database.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// OutboxRepository reads the payment events waiting to be published
+type OutboxRepository struct {
+	db OutboxDB
+}
+
+// NewOutboxRepository creates a new OutboxRepository
+// It returns a new OutboxRepository and an error if the database is nil
+func NewOutboxRepository(db OutboxDB) (*OutboxRepository, error) {
+	if db == nil {
+		return nil, errors.New("outbox repository: database cannot be nil")
+	}
+
+	return &OutboxRepository{db: db}, nil
+}
+
+// ListPending lists the events waiting to be published, in the order they were appended
+func (r *OutboxRepository) ListPending(ctx context.Context, limit int) ([]*domain.Event, error) {
+	query := `
+		SELECT e.id, e.payment_id, e.sequence, e.event_type, e.payload, e.created_at
+		FROM event_outbox o
+		JOIN payment_events e ON e.id = o.event_id
+		ORDER BY o.position ASC
+		LIMIT $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("outbox repository: list pending: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*domain.Event{}
+	for rows.Next() {
+		var event domain.Event
+		err := rows.Scan(
+			&event.ID,
+			&event.PaymentID,
+			&event.Sequence,
+			&event.EventType,
+			&event.Payload,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("outbox repository: scan pending event: %w", err)
+		}
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox repository: iterate pending events: %w", err)
+	}
+
+	return events, nil
+}
+
+// Remove removes a published event from the outbox
+func (r *OutboxRepository) Remove(ctx context.Context, eventID string) error {
+	query := `
+		DELETE FROM event_outbox
+		WHERE event_id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, eventID); err != nil {
+		return fmt.Errorf("outbox repository: remove %s: %w", eventID, err)
+	}
+
+	return nil
+}
+
+// MessageBroker interface for the infrastructure publisher
+type MessageBroker interface {
+	PublishWithRoutingKey(routingKey string, body []byte, headers map[string]interface{}) error
+}
+
+// EnvelopePublisherRepository publishes the event envelopes to the exchange
+type EnvelopePublisherRepository struct {
+	messageBroker MessageBroker
+}
+
+// NewEnvelopePublisherRepository creates a new EnvelopePublisherRepository
+// It returns a new EnvelopePublisherRepository and an error if the message broker is nil
+func NewEnvelopePublisherRepository(mb MessageBroker) (*EnvelopePublisherRepository, error) {
+	if mb == nil {
+		return nil, errors.New("envelope publisher: message broker cannot be nil")
+	}
+
+	return &EnvelopePublisherRepository{
+		messageBroker: mb,
+	}, nil
+}
+
+// Publish publishes an envelope with the routing key of its event type
+// It returns an error if the envelope cannot be marshalled or published
+func (r *EnvelopePublisherRepository) Publish(ctx context.Context, envelope *Envelope) error {
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("envelope publisher: failed to marshal envelope: %w", err)
+	}
+
+	if err := r.messageBroker.PublishWithRoutingKey(RoutingKey(envelope.Type), body, nil); err != nil {
+		return fmt.Errorf("envelope publisher: failed to publish envelope: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package emitter
+
+import (
+	"context"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOutboxRepository is a mock implementation of OutboxStore for testing
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+// ListPending mocks the ListPending method
+func (m *MockOutboxRepository) ListPending(ctx context.Context, limit int) ([]*domain.Event, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Event), args.Error(1)
+}
+
+// Remove mocks the Remove method
+func (m *MockOutboxRepository) Remove(ctx context.Context, eventID string) error {
+	args := m.Called(ctx, eventID)
+	return args.Error(0)
+}
+
+// MockEnvelopePublisherRepository is a mock implementation of EnvelopePublisher for testing
+type MockEnvelopePublisherRepository struct {
+	mock.Mock
+}
+
+// Publish mocks the Publish method
+func (m *MockEnvelopePublisherRepository) Publish(ctx context.Context, envelope *Envelope) error {
+	args := m.Called(ctx, envelope)
+	return args.Error(0)
+}
@@ -0,0 +1,279 @@
+package emitter
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewOutboxRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            OutboxDB
+		expectedError string
+	}{
+		{
+			name:          "when database is provided it should create repository successfully and no error",
+			db:            new(database.MockDB),
+			expectedError: "",
+		},
+		{
+			name:          "when database is nil it should return error with message 'outbox repository: database cannot be nil'",
+			db:            nil,
+			expectedError: "outbox repository: database cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Database already prepared in test struct)
+
+			// Act
+			result, err := NewOutboxRepository(tt.db)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestOutboxRepository_ListPending(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	event := &domain.Event{
+		ID:        "evt_1",
+		PaymentID: "pay_123",
+		Sequence:  3,
+		EventType: "completed",
+		Payload:   json.RawMessage(`{"status":"completed"}`),
+		CreatedAt: fixedTime,
+	}
+
+	tests := []struct {
+		name           string
+		mockEvents     []*domain.Event
+		mockQueryError error
+		mockScanError  error
+		expectedEvents []*domain.Event
+		expectedError  error
+	}{
+		{
+			name:           "when events are pending it should return them and no error",
+			mockEvents:     []*domain.Event{event},
+			expectedEvents: []*domain.Event{event},
+			expectedError:  nil,
+		},
+		{
+			name:           "when query fails it should return wrapped error",
+			mockQueryError: errors.New("connection refused"),
+			expectedError:  errors.New("outbox repository: list pending: connection refused"),
+		},
+		{
+			name:          "when scan fails it should return wrapped error",
+			mockScanError: errors.New("scan error"),
+			expectedError: errors.New("outbox repository: scan pending event: scan error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockRows := new(database.MockRows)
+
+			if tt.mockQueryError != nil {
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, []any{100}).Return(nil, tt.mockQueryError)
+			} else {
+				if tt.mockScanError != nil {
+					mockRows.On("Next").Return(true).Once()
+					mockRows.On("Scan", mock.Anything).Return(tt.mockScanError)
+				} else {
+					count := len(tt.mockEvents)
+					mockRows.On("Next").Return(true).Times(count)
+					scanCallCount := 0
+					mockRows.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+						dest := args.Get(0).([]any)
+						e := tt.mockEvents[scanCallCount]
+						*dest[0].(*string) = e.ID
+						*dest[1].(*string) = e.PaymentID
+						*dest[2].(*int) = e.Sequence
+						*dest[3].(*string) = e.EventType
+						*dest[4].(*json.RawMessage) = e.Payload
+						*dest[5].(*time.Time) = e.CreatedAt
+						scanCallCount++
+					}).Return(nil).Times(count)
+					mockRows.On("Next").Return(false).Once()
+					mockRows.On("Err").Return(nil)
+				}
+				mockRows.On("Close").Return(nil)
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, []any{100}).Return(mockRows, nil)
+			}
+
+			repo := &OutboxRepository{db: mockDB}
+
+			// Act
+			result, err := repo.ListPending(context.Background(), 100)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedEvents, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOutboxRepository_Remove(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockExecError error
+		expectedError error
+	}{
+		{
+			name:          "when event is removed it should return no error",
+			expectedError: nil,
+		},
+		{
+			name:          "when delete fails it should return wrapped error",
+			mockExecError: errors.New("connection refused"),
+			expectedError: errors.New("outbox repository: remove evt_1: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockDB.On("ExecContext", mock.Anything, mock.Anything, []any{"evt_1"}).Return(driver.RowsAffected(1), tt.mockExecError)
+
+			repo := &OutboxRepository{db: mockDB}
+
+			// Act
+			err := repo.Remove(context.Background(), "evt_1")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewEnvelopePublisherRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		messageBroker MessageBroker
+		expectedError string
+	}{
+		{
+			name:          "when message broker is provided it should create repository successfully and no error",
+			messageBroker: new(messagebroker.MockPublisher),
+			expectedError: "",
+		},
+		{
+			name:          "when message broker is nil it should return error",
+			messageBroker: nil,
+			expectedError: "envelope publisher: message broker cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Message broker already prepared in test struct)
+
+			// Act
+			result, err := NewEnvelopePublisherRepository(tt.messageBroker)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestEnvelopePublisherRepository_Publish(t *testing.T) {
+	envelope := &Envelope{
+		SchemaVersion: SchemaVersion,
+		ID:            "evt_1",
+		Type:          "completed",
+		PaymentID:     "pay_123",
+		Sequence:      3,
+		OccurredAt:    time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Data:          json.RawMessage(`{"status":"completed"}`),
+	}
+	body, _ := json.Marshal(envelope)
+
+	tests := []struct {
+		name             string
+		mockPublishError error
+		expectedError    error
+	}{
+		{
+			name:             "when publish succeeds it should publish to the routing key of the event type and no error",
+			mockPublishError: nil,
+			expectedError:    nil,
+		},
+		{
+			name:             "when publish fails it should return wrapped error",
+			mockPublishError: errors.New("channel closed"),
+			expectedError:    errors.New("envelope publisher: failed to publish envelope: channel closed"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockPublisher := new(messagebroker.MockPublisher)
+			mockPublisher.On("PublishWithRoutingKey", "payments.events.completed", body, map[string]interface{}(nil)).Return(tt.mockPublishError)
+
+			repo := &EnvelopePublisherRepository{messageBroker: mockPublisher}
+
+			// Act
+			err := repo.Publish(context.Background(), envelope)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockPublisher.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package emitter
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+)
+
+// Start starts the emitter router
+// It starts the emitter router and returns an error if the builder fails
+func Start(rg *gin.RouterGroup, db OutboxDB, mbc *messagebroker.Connection, exchange string, policy PublishPolicy) error {
+	h, err := Build(db, mbc, exchange, policy)
+	if err != nil {
+		return err
+	}
+
+	rg.GET("/events/schema", h.Schema)
+	return nil
+}
@@ -0,0 +1,47 @@
+package emitter
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            OutboxDB
+		mbc           *messagebroker.Connection
+		expectedError bool
+	}{
+		{
+			name:          "when database is nil it should return error",
+			db:            nil,
+			mbc:           &messagebroker.Connection{},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+			rg := router.Group("/api/v1")
+
+			// Act
+			err := Start(rg, tt.db, tt.mbc, "payments", PublishPolicy{BatchSize: 100})
+
+			// Assert
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package emitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+)
+
+// OutboxStore interface for reading the events waiting to be published and removing the published ones
+type OutboxStore interface {
+	ListPending(ctx context.Context, limit int) ([]*domain.Event, error)
+	Remove(ctx context.Context, eventID string) error
+}
+
+// EnvelopePublisher interface for publishing the event envelopes
+type EnvelopePublisher interface {
+	Publish(ctx context.Context, envelope *Envelope) error
+}
+
+// EventEmitterService is a service for publishing the payment events to the exchange
+type EventEmitterService struct {
+	outboxStore       OutboxStore
+	envelopePublisher EnvelopePublisher
+	policy            PublishPolicy
+}
+
+// NewEventEmitterService creates a new EventEmitterService
+// It returns a new EventEmitterService and an error if any dependency is nil or the policy is invalid
+func NewEventEmitterService(os OutboxStore, ep EnvelopePublisher, policy PublishPolicy) (*EventEmitterService, error) {
+	if os == nil {
+		return nil, errors.New("event emitter: outbox store cannot be nil")
+	}
+	if ep == nil {
+		return nil, errors.New("event emitter: envelope publisher cannot be nil")
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("event emitter: invalid policy: %w", err)
+	}
+
+	return &EventEmitterService{
+		outboxStore:       os,
+		envelopePublisher: ep,
+		policy:            policy,
+	}, nil
+}
+
+// Emit publishes a batch of the events waiting in the outbox, in the order they were appended
+// The run stops at the first event that cannot be published so later events of the same payment are not published before it.
+// An event published but not removed is published again on the next run, consumers deduplicate with the event ID
+// It returns the run summary and an error if the events cannot be listed, published or removed
+func (s *EventEmitterService) Emit(ctx context.Context) (*EmitResult, error) {
+	events, err := s.outboxStore.ListPending(ctx, s.policy.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("event emitter: list pending events: %w", err)
+	}
+
+	result := &EmitResult{}
+	for _, event := range events {
+		if err := s.envelopePublisher.Publish(ctx, NewEnvelope(event)); err != nil {
+			return result, fmt.Errorf("event emitter: publish event %s: %w", event.ID, err)
+		}
+
+		if err := s.outboxStore.Remove(ctx, event.ID); err != nil {
+			return result, fmt.Errorf("event emitter: remove event %s: %w", event.ID, err)
+		}
+
+		result.Published++
+	}
+
+	return result, nil
+}
@@ -0,0 +1,21 @@
+package emitter
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockEventEmitterService is a mock implementation of EventEmitter for testing
+type MockEventEmitterService struct {
+	mock.Mock
+}
+
+// Emit mocks the Emit method
+func (m *MockEventEmitterService) Emit(ctx context.Context) (*EmitResult, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*EmitResult), args.Error(1)
+}
@@ -0,0 +1,165 @@
+package emitter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewEventEmitterService(t *testing.T) {
+	tests := []struct {
+		name              string
+		outboxStore       OutboxStore
+		envelopePublisher EnvelopePublisher
+		policy            PublishPolicy
+		expectedError     string
+	}{
+		{
+			name:              "when all dependencies are provided it should create service successfully and no error",
+			outboxStore:       new(MockOutboxRepository),
+			envelopePublisher: new(MockEnvelopePublisherRepository),
+			policy:            PublishPolicy{BatchSize: 100},
+			expectedError:     "",
+		},
+		{
+			name:              "when outbox store is nil it should return error",
+			outboxStore:       nil,
+			envelopePublisher: new(MockEnvelopePublisherRepository),
+			policy:            PublishPolicy{BatchSize: 100},
+			expectedError:     "event emitter: outbox store cannot be nil",
+		},
+		{
+			name:              "when envelope publisher is nil it should return error",
+			outboxStore:       new(MockOutboxRepository),
+			envelopePublisher: nil,
+			policy:            PublishPolicy{BatchSize: 100},
+			expectedError:     "event emitter: envelope publisher cannot be nil",
+		},
+		{
+			name:              "when policy is invalid it should return error",
+			outboxStore:       new(MockOutboxRepository),
+			envelopePublisher: new(MockEnvelopePublisherRepository),
+			policy:            PublishPolicy{BatchSize: 0},
+			expectedError:     "event emitter: invalid policy: batch size must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewEventEmitterService(tt.outboxStore, tt.envelopePublisher, tt.policy)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestEventEmitterService_Emit(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	reserved := &domain.Event{ID: "evt_1", PaymentID: "pay_123", Sequence: 2, EventType: "reserved", Payload: json.RawMessage(`{}`), CreatedAt: fixedTime}
+	completed := &domain.Event{ID: "evt_2", PaymentID: "pay_123", Sequence: 3, EventType: "completed", Payload: json.RawMessage(`{}`), CreatedAt: fixedTime}
+
+	tests := []struct {
+		name             string
+		mockEvents       []*domain.Event
+		mockListError    error
+		mockPublishError error
+		mockRemoveError  error
+		expectedRemoved  []string
+		expectedResult   *EmitResult
+		expectedError    error
+	}{
+		{
+			name:            "when events are pending it should publish and remove each of them in order",
+			mockEvents:      []*domain.Event{reserved, completed},
+			expectedRemoved: []string{"evt_1", "evt_2"},
+			expectedResult:  &EmitResult{Published: 2},
+			expectedError:   nil,
+		},
+		{
+			name:           "when no events are pending it should publish nothing",
+			mockEvents:     []*domain.Event{},
+			expectedResult: &EmitResult{Published: 0},
+			expectedError:  nil,
+		},
+		{
+			name:          "when listing fails it should return wrapped error",
+			mockListError: errors.New("connection refused"),
+			expectedError: errors.New("event emitter: list pending events: connection refused"),
+		},
+		{
+			name:             "when publish fails it should stop the run and keep the event in the outbox",
+			mockEvents:       []*domain.Event{reserved, completed},
+			mockPublishError: errors.New("channel closed"),
+			expectedResult:   &EmitResult{Published: 0},
+			expectedError:    errors.New("event emitter: publish event evt_1: channel closed"),
+		},
+		{
+			name:            "when remove fails it should stop the run and return wrapped error",
+			mockEvents:      []*domain.Event{reserved, completed},
+			mockRemoveError: errors.New("database error"),
+			expectedRemoved: []string{"evt_1"},
+			expectedResult:  &EmitResult{Published: 0},
+			expectedError:   errors.New("event emitter: remove event evt_1: database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockOutbox := new(MockOutboxRepository)
+			mockPublisher := new(MockEnvelopePublisherRepository)
+
+			mockOutbox.On("ListPending", mock.Anything, 100).Return(tt.mockEvents, tt.mockListError)
+
+			var published []string
+			if tt.mockListError == nil && len(tt.mockEvents) > 0 {
+				mockPublisher.On("Publish", mock.Anything, mock.AnythingOfType("*emitter.Envelope")).Run(func(args mock.Arguments) {
+					published = append(published, args.Get(1).(*Envelope).ID)
+				}).Return(tt.mockPublishError)
+			}
+			for _, eventID := range tt.expectedRemoved {
+				mockOutbox.On("Remove", mock.Anything, eventID).Return(tt.mockRemoveError)
+			}
+
+			service := &EventEmitterService{
+				outboxStore:       mockOutbox,
+				envelopePublisher: mockPublisher,
+				policy:            PublishPolicy{BatchSize: 100},
+			}
+
+			// Act
+			result, err := service.Emit(context.Background())
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedRemoved, published)
+			}
+			assert.Equal(t, tt.expectedResult, result)
+
+			mockOutbox.AssertExpectations(t)
+			mockPublisher.AssertExpectations(t)
+		})
+	}
+}
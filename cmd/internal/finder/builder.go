@@ -6,7 +6,7 @@ import (
 
 // Build builds a new finder handler
 // It builds a new finder handler and returns an error if the builder fails
-func Build(db paymentstorer.PaymentDB) (*Handler, error) {
+func Build(db paymentstorer.PaymentDB, en EventNotifier, policy StreamPolicy) (*Handler, error) {
 	ps, err := paymentstorer.NewStorer(db)
 	if err != nil {
 		return nil, err
@@ -17,7 +17,7 @@ func Build(db paymentstorer.PaymentDB) (*Handler, error) {
 		return nil, err
 	}
 
-	h, err := NewHandler(pf)
+	h, err := NewHandler(pf, en, policy)
 	if err != nil {
 		return nil, err
 	}
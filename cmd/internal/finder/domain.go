@@ -1,6 +1,12 @@
 package finder
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
+
+// LastEventIDHeader is the header a reconnecting Server-Sent Events client sends with the last event ID it received
+const LastEventIDHeader = "Last-Event-ID"
 
 // PaymentFilter represents the filter criteria for finding a payment
 type PaymentFilter struct {
@@ -15,3 +21,39 @@ func (f *PaymentFilter) Validate() error {
 	}
 	return nil
 }
+
+// StreamFilter represents the criteria for streaming the events of a payment
+type StreamFilter struct {
+	PaymentID     string // Payment ID whose events are streamed
+	AfterSequence int    // Sequence of the last event the client received, 0 streams every event
+}
+
+// Validate validates the stream filter
+// It returns an error if the filter is invalid
+func (f *StreamFilter) Validate() error {
+	if f.PaymentID == "" {
+		return errors.New("payment ID is required")
+	}
+	if f.AfterSequence < 0 {
+		return errors.New("last event ID must be greater than or equal to 0")
+	}
+	return nil
+}
+
+// StreamPolicy holds the settings of the payment event streams
+type StreamPolicy struct {
+	KeepAlive   time.Duration // How often an idle stream sends a comment
+	MaxDuration time.Duration // Longest a stream stays open
+}
+
+// Validate validates the stream policy
+// It returns an error if the policy is invalid
+func (p StreamPolicy) Validate() error {
+	if p.KeepAlive <= 0 {
+		return errors.New("keepalive must be greater than 0")
+	}
+	if p.MaxDuration <= 0 {
+		return errors.New("max duration must be greater than 0")
+	}
+	return nil
+}
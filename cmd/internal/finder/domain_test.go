@@ -2,6 +2,7 @@ package finder
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -67,3 +68,102 @@ func TestPaymentFilter_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamFilter_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		filter        *StreamFilter
+		expectedError string
+	}{
+		{
+			name: "when payment ID is provided without a last event it should pass validation and no error",
+			filter: &StreamFilter{
+				PaymentID: "pay_123",
+			},
+			expectedError: "",
+		},
+		{
+			name: "when payment ID and last event are provided it should pass validation and no error",
+			filter: &StreamFilter{
+				PaymentID:     "pay_123",
+				AfterSequence: 3,
+			},
+			expectedError: "",
+		},
+		{
+			name: "when payment ID is empty it should return error with message 'payment ID is required'",
+			filter: &StreamFilter{
+				PaymentID: "",
+			},
+			expectedError: "payment ID is required",
+		},
+		{
+			name: "when last event is negative it should return error with message 'last event ID must be greater than or equal to 0'",
+			filter: &StreamFilter{
+				PaymentID:     "pay_123",
+				AfterSequence: -1,
+			},
+			expectedError: "last event ID must be greater than or equal to 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Filter already prepared in test struct)
+
+			// Act
+			err := tt.filter.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestStreamPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        StreamPolicy
+		expectedError string
+	}{
+		{
+			name:          "when keepalive and max duration are positive it should pass validation and no error",
+			policy:        StreamPolicy{KeepAlive: 15 * time.Second, MaxDuration: 30 * time.Minute},
+			expectedError: "",
+		},
+		{
+			name:          "when keepalive is zero it should return error with message 'keepalive must be greater than 0'",
+			policy:        StreamPolicy{KeepAlive: 0, MaxDuration: 30 * time.Minute},
+			expectedError: "keepalive must be greater than 0",
+		},
+		{
+			name:          "when max duration is zero it should return error with message 'max duration must be greater than 0'",
+			policy:        StreamPolicy{KeepAlive: 15 * time.Second, MaxDuration: 0},
+			expectedError: "max duration must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Policy already prepared in test struct)
+
+			// Act
+			err := tt.policy.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -2,11 +2,17 @@ package finder
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,22 +21,38 @@ import (
 type PaymentFinder interface {
 	Find(ctx context.Context, filter *PaymentFilter) (*domain.Payment, error)
 	FindEvents(ctx context.Context, paymentID string) ([]*domain.Event, error)
+	FindEventsAfter(ctx context.Context, payment *domain.Payment, sequence int) ([]*domain.Event, error)
+}
+
+// EventNotifier defines the interface for being signaled when events are appended to a payment
+type EventNotifier interface {
+	Subscribe(paymentID string) (<-chan struct{}, func())
 }
 
 // Handler handles HTTP requests for payment operations
 type Handler struct {
 	paymentFinder PaymentFinder
+	eventNotifier EventNotifier
+	streamPolicy  StreamPolicy
 }
 
 // NewHandler creates a new Payment controller
-// It returns a new Payment controller and an error if the payment finder is nil
-func NewHandler(pf PaymentFinder) (*Handler, error) {
+// It returns a new Payment controller and an error if the payment finder or event notifier is nil or the stream policy is invalid
+func NewHandler(pf PaymentFinder, en EventNotifier, policy StreamPolicy) (*Handler, error) {
 	if pf == nil {
 		return nil, errors.New("payment handler: payment finder cannot be nil")
 	}
+	if en == nil {
+		return nil, errors.New("payment handler: event notifier cannot be nil")
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("payment handler: invalid stream policy: %w", err)
+	}
 
 	return &Handler{
 		paymentFinder: pf,
+		eventNotifier: en,
+		streamPolicy:  policy,
 	}, nil
 }
 
@@ -42,28 +64,17 @@ func (h *Handler) Find(c *gin.Context) {
 	filter := &PaymentFilter{PaymentID: paymentID}
 
 	if err := filter.Validate(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": err.Error(),
-			"error":   "bad request",
-		})
+		problem.Respond(c, problem.Invalid(err))
 		return
 	}
 
 	payment, err := h.paymentFinder.Find(ctx, filter)
 	if err != nil {
-		if errors.Is(err, domain.ErrPaymentNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"message": "payment not found",
-				"error":   "not found",
-			})
-			return
+		p := problem.FromError(err, "failed to find payment")
+		if p.Internal() {
+			slog.ErrorContext(ctx, "Failed to find payment", "error", err)
 		}
-
-		slog.ErrorContext(ctx, "Failed to find payment", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "failed to find payment",
-			"error":   "internal server error",
-		})
+		problem.Respond(c, p)
 		return
 	}
 
@@ -81,20 +92,17 @@ func (h *Handler) FindEvents(c *gin.Context) {
 	filter := &PaymentFilter{PaymentID: paymentID}
 
 	if err := filter.Validate(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": err.Error(),
-			"error":   "bad request",
-		})
+		problem.Respond(c, problem.Invalid(err))
 		return
 	}
 
 	events, err := h.paymentFinder.FindEvents(ctx, paymentID)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to find events", "error", err, "payment_id", paymentID)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "failed to find events",
-			"error":   "internal server error",
-		})
+		p := problem.FromError(err, "failed to find events")
+		if p.Internal() {
+			slog.ErrorContext(ctx, "Failed to find events", "error", err, "payment_id", paymentID)
+		}
+		problem.Respond(c, p)
 		return
 	}
 
@@ -103,3 +111,106 @@ func (h *Handler) FindEvents(c *gin.Context) {
 		"data":    events,
 	})
 }
+
+// Stream handles GET /payments/:id/events/stream requests
+// It sends the events of the payment as Server-Sent Events as they are appended, starting after the Last-Event-ID sequence,
+// and closes the stream once the payment reaches a terminal status
+func (h *Handler) Stream(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	filter := &StreamFilter{PaymentID: c.Param("id")}
+	if lastEventID := c.GetHeader(LastEventIDHeader); lastEventID != "" {
+		sequence, err := strconv.Atoi(lastEventID)
+		if err != nil {
+			problem.Respond(c, problem.New(problem.CodeBadRequest, "last event ID must be an event sequence"))
+			return
+		}
+		filter.AfterSequence = sequence
+	}
+
+	if err := filter.Validate(); err != nil {
+		problem.Respond(c, problem.Invalid(err))
+		return
+	}
+
+	// The payment is found before the stream starts, so unknown and foreign payments still get a problem response
+	payment, err := h.paymentFinder.Find(ctx, &PaymentFilter{PaymentID: filter.PaymentID})
+	if err != nil {
+		p := problem.FromError(err, "failed to find payment")
+		if p.Internal() {
+			slog.ErrorContext(ctx, "Failed to find payment", "error", err, "payment_id", filter.PaymentID)
+		}
+		problem.Respond(c, p)
+		return
+	}
+
+	// Subscribing before reading the events, so an event appended in between still wakes the stream
+	notifications, unsubscribe := h.eventNotifier.Subscribe(payment.ID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(h.streamPolicy.KeepAlive)
+	defer keepAlive.Stop()
+
+	deadline := time.NewTimer(h.streamPolicy.MaxDuration)
+	defer deadline.Stop()
+
+	// A payment already terminal has all its events stored, the stream closes once they are sent
+	terminal := payment.Status.IsTerminal()
+	lastSequence := filter.AfterSequence
+	for {
+		events, err := h.paymentFinder.FindEventsAfter(ctx, payment, lastSequence)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Failed to find events for stream", "error", err, "payment_id", payment.ID)
+			}
+			return
+		}
+
+		for _, event := range events {
+			if err := writeEvent(c.Writer, event); err != nil {
+				return
+			}
+			lastSequence = event.Sequence
+			if domain.Status(event.EventType).IsTerminal() {
+				terminal = true
+			}
+		}
+		c.Writer.Flush()
+
+		if terminal {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			return
+		case <-notifications:
+		case <-keepAlive.C:
+			// Events are read again on keepalive too, in case a notification was lost
+			if _, err := io.WriteString(c.Writer, ": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeEvent writes an event in the Server-Sent Events format
+// The event ID is the sequence, so a reconnecting client resumes after the last event it received
+func writeEvent(w io.Writer, event *domain.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Sequence, event.EventType, data)
+	return err
+}
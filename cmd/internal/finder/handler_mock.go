@@ -30,3 +30,22 @@ func (m *MockPaymentFinder) FindEvents(ctx context.Context, paymentID string) ([
 	return args.Get(0).([]*domain.Event), args.Error(1)
 }
 
+// FindEventsAfter mocks the FindEventsAfter method
+func (m *MockPaymentFinder) FindEventsAfter(ctx context.Context, payment *domain.Payment, sequence int) ([]*domain.Event, error) {
+	args := m.Called(ctx, payment, sequence)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Event), args.Error(1)
+}
+
+// MockEventNotifier is a mock implementation of EventNotifier
+type MockEventNotifier struct {
+	mock.Mock
+}
+
+// Subscribe mocks the Subscribe method
+func (m *MockEventNotifier) Subscribe(paymentID string) (<-chan struct{}, func()) {
+	args := m.Called(paymentID)
+	return args.Get(0).(chan struct{}), args.Get(1).(func())
+}
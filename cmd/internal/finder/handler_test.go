@@ -3,13 +3,16 @@ package finder
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -19,30 +22,52 @@ func init() {
 }
 
 func TestNewHandler(t *testing.T) {
+	validPolicy := StreamPolicy{KeepAlive: 15 * time.Second, MaxDuration: 30 * time.Minute}
+
 	tests := []struct {
-		name           string
-		paymentFinder  PaymentFinder
-		expectedError  string
+		name          string
+		paymentFinder PaymentFinder
+		eventNotifier EventNotifier
+		policy        StreamPolicy
+		expectedError string
 	}{
 		{
-			name:           "when payment finder is provided it should create handler successfully and no error",
-			paymentFinder:  new(MockPaymentFinder),
-			expectedError:  "",
+			name:          "when all dependencies are provided it should create handler successfully and no error",
+			paymentFinder: new(MockPaymentFinder),
+			eventNotifier: new(MockEventNotifier),
+			policy:        validPolicy,
+			expectedError: "",
+		},
+		{
+			name:          "when payment finder is nil it should return error",
+			paymentFinder: nil,
+			eventNotifier: new(MockEventNotifier),
+			policy:        validPolicy,
+			expectedError: "payment handler: payment finder cannot be nil",
 		},
 		{
-			name:           "when payment finder is nil it should return error",
-			paymentFinder:  nil,
-			expectedError:  "payment handler: payment finder cannot be nil",
+			name:          "when event notifier is nil it should return error",
+			paymentFinder: new(MockPaymentFinder),
+			eventNotifier: nil,
+			policy:        validPolicy,
+			expectedError: "payment handler: event notifier cannot be nil",
+		},
+		{
+			name:          "when stream policy is invalid it should return error",
+			paymentFinder: new(MockPaymentFinder),
+			eventNotifier: new(MockEventNotifier),
+			policy:        StreamPolicy{KeepAlive: 0, MaxDuration: 30 * time.Minute},
+			expectedError: "payment handler: invalid stream policy: keepalive must be greater than 0",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Arrange
-			// (Payment finder already prepared in test struct)
+			// (Dependencies already prepared in test struct)
 
 			// Act
-			result, err := NewHandler(tt.paymentFinder)
+			result, err := NewHandler(tt.paymentFinder, tt.eventNotifier, tt.policy)
 
 			// Assert
 			if tt.expectedError != "" {
@@ -103,6 +128,15 @@ func TestHandler_Find(t *testing.T) {
 			expectedStatusCode: http.StatusNotFound,
 			expectedMessage:    "payment not found",
 		},
+		{
+			name:               "when payment belongs to another user it should return 404 as for a missing payment",
+			paymentID:          "pay_123",
+			mockPayment:        nil,
+			mockFindError:      fmt.Errorf("payment finder: get payment: %w", domain.ErrPaymentNotFound),
+			shouldCallFind:     true,
+			expectedStatusCode: http.StatusNotFound,
+			expectedMessage:    "payment not found",
+		},
 		{
 			name:               "when find fails with internal error it should return 500",
 			paymentID:          "pay_error",
@@ -140,7 +174,12 @@ func TestHandler_Find(t *testing.T) {
 			var response map[string]interface{}
 			err := json.Unmarshal(w.Body.Bytes(), &response)
 			assert.NoError(t, err)
-			assert.Equal(t, tt.expectedMessage, response["message"])
+			if w.Code >= http.StatusBadRequest {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedMessage, response["detail"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, response["message"])
+			}
 
 			if tt.shouldCallFind {
 				mockFinder.AssertExpectations(t)
@@ -203,6 +242,22 @@ func TestHandler_FindEvents(t *testing.T) {
 			expectedStatusCode:   http.StatusOK,
 			expectedMessage:      "events found successfully",
 		},
+		{
+			name:                 "when payment is not found it should return 404",
+			paymentID:            "pay_not_found",
+			mockFindEventsError:  fmt.Errorf("payment finder: get payment: %w", domain.ErrPaymentNotFound),
+			shouldCallFindEvents: true,
+			expectedStatusCode:   http.StatusNotFound,
+			expectedMessage:      "payment not found",
+		},
+		{
+			name:                 "when payment belongs to another user it should return 404 as for a missing payment",
+			paymentID:            "pay_123",
+			mockFindEventsError:  fmt.Errorf("payment finder: get payment: %w", domain.ErrPaymentNotFound),
+			shouldCallFindEvents: true,
+			expectedStatusCode:   http.StatusNotFound,
+			expectedMessage:      "payment not found",
+		},
 		{
 			name:                 "when find events fails with internal error it should return 500",
 			paymentID:            "pay_error",
@@ -239,7 +294,12 @@ func TestHandler_FindEvents(t *testing.T) {
 			var response map[string]interface{}
 			err := json.Unmarshal(w.Body.Bytes(), &response)
 			assert.NoError(t, err)
-			assert.Equal(t, tt.expectedMessage, response["message"])
+			if w.Code >= http.StatusBadRequest {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedMessage, response["detail"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, response["message"])
+			}
 
 			if tt.shouldCallFindEvents {
 				mockFinder.AssertExpectations(t)
@@ -248,3 +308,182 @@ func TestHandler_FindEvents(t *testing.T) {
 	}
 }
 
+func TestHandler_Stream(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	created := &domain.Event{ID: "evt_1", PaymentID: "pay_123", Sequence: 1, EventType: "created", Payload: json.RawMessage(`{"status":"pending"}`), CreatedAt: fixedTime}
+	reserved := &domain.Event{ID: "evt_2", PaymentID: "pay_123", Sequence: 2, EventType: "reserved", Payload: json.RawMessage(`{"status":"reserved"}`), CreatedAt: fixedTime}
+	completed := &domain.Event{ID: "evt_3", PaymentID: "pay_123", Sequence: 3, EventType: "completed", Payload: json.RawMessage(`{"status":"completed"}`), CreatedAt: fixedTime}
+
+	type eventsCall struct {
+		sequence int
+		events   []*domain.Event
+		err      error
+	}
+
+	tests := []struct {
+		name               string
+		paymentID          string
+		lastEventID        string
+		mockPayment        *domain.Payment
+		mockFindError      error
+		eventsCalls        []eventsCall
+		notified           bool
+		expectedStatusCode int
+		expectedMessage    string
+		expectedIDs        []string
+	}{
+		{
+			name:               "when payment is terminal it should send the stored events and close the stream",
+			paymentID:          "pay_123",
+			mockPayment:        &domain.Payment{ID: "pay_123", Status: domain.StatusCompleted},
+			eventsCalls:        []eventsCall{{sequence: 0, events: []*domain.Event{created, reserved, completed}}},
+			expectedStatusCode: http.StatusOK,
+			expectedIDs:        []string{"1", "2", "3"},
+		},
+		{
+			name:               "when last event ID is sent it should resume after that sequence",
+			paymentID:          "pay_123",
+			lastEventID:        "2",
+			mockPayment:        &domain.Payment{ID: "pay_123", Status: domain.StatusCompleted},
+			eventsCalls:        []eventsCall{{sequence: 2, events: []*domain.Event{completed}}},
+			expectedStatusCode: http.StatusOK,
+			expectedIDs:        []string{"3"},
+		},
+		{
+			name:        "when a new event is notified it should send it and close once the payment is terminal",
+			paymentID:   "pay_123",
+			mockPayment: &domain.Payment{ID: "pay_123", Status: domain.StatusReserved},
+			eventsCalls: []eventsCall{
+				{sequence: 0, events: []*domain.Event{created, reserved}},
+				{sequence: 2, events: []*domain.Event{completed}},
+			},
+			notified:           true,
+			expectedStatusCode: http.StatusOK,
+			expectedIDs:        []string{"1", "2", "3"},
+		},
+		{
+			name:               "when events cannot be read it should close the stream",
+			paymentID:          "pay_123",
+			mockPayment:        &domain.Payment{ID: "pay_123", Status: domain.StatusReserved},
+			eventsCalls:        []eventsCall{{sequence: 0, err: errors.New("database error")}},
+			expectedStatusCode: http.StatusOK,
+			expectedIDs:        nil,
+		},
+		{
+			name:               "when last event ID is not a number it should return 400",
+			paymentID:          "pay_123",
+			lastEventID:        "abc",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "last event ID must be an event sequence",
+		},
+		{
+			name:               "when last event ID is negative it should return 400",
+			paymentID:          "pay_123",
+			lastEventID:        "-1",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "last event ID must be greater than or equal to 0",
+		},
+		{
+			name:               "when payment is not found it should return 404",
+			paymentID:          "pay_not_found",
+			mockFindError:      fmt.Errorf("payment finder: get payment: %w", domain.ErrPaymentNotFound),
+			expectedStatusCode: http.StatusNotFound,
+			expectedMessage:    "payment not found",
+		},
+		{
+			name:               "when payment belongs to another user it should return 404 as for a missing payment",
+			paymentID:          "pay_123",
+			mockFindError:      fmt.Errorf("payment finder: get payment: %w", domain.ErrPaymentNotFound),
+			expectedStatusCode: http.StatusNotFound,
+			expectedMessage:    "payment not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockFinder := new(MockPaymentFinder)
+			mockNotifier := new(MockEventNotifier)
+
+			shouldFind := tt.expectedMessage == "" || tt.mockFindError != nil
+			if shouldFind {
+				mockFinder.On("Find", mock.Anything, &PaymentFilter{PaymentID: tt.paymentID}).Return(tt.mockPayment, tt.mockFindError)
+			}
+
+			notifications := make(chan struct{}, 1)
+			if tt.notified {
+				notifications <- struct{}{}
+			}
+			unsubscribed := false
+			if tt.mockPayment != nil {
+				mockNotifier.On("Subscribe", tt.paymentID).Return(notifications, func() { unsubscribed = true })
+			}
+			for _, call := range tt.eventsCalls {
+				mockFinder.On("FindEventsAfter", mock.Anything, tt.mockPayment, call.sequence).Return(call.events, call.err).Once()
+			}
+
+			handler := &Handler{
+				paymentFinder: mockFinder,
+				eventNotifier: mockNotifier,
+				streamPolicy:  StreamPolicy{KeepAlive: time.Minute, MaxDuration: time.Minute},
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/payments/"+tt.paymentID+"/events/stream", nil)
+			if tt.lastEventID != "" {
+				req.Header.Set(LastEventIDHeader, tt.lastEventID)
+			}
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = gin.Params{gin.Param{Key: "id", Value: tt.paymentID}}
+
+			// Act
+			handler.Stream(c)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+
+			if w.Code >= http.StatusBadRequest {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedMessage, response["detail"])
+			} else {
+				assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+				assert.True(t, unsubscribed)
+
+				var ids []string
+				for _, line := range strings.Split(w.Body.String(), "\n") {
+					if id, ok := strings.CutPrefix(line, "id: "); ok {
+						ids = append(ids, id)
+					}
+				}
+				assert.Equal(t, tt.expectedIDs, ids)
+			}
+
+			mockFinder.AssertExpectations(t)
+			mockNotifier.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWriteEvent(t *testing.T) {
+	// Arrange
+	event := &domain.Event{
+		ID:        "evt_2",
+		PaymentID: "pay_123",
+		Sequence:  2,
+		EventType: "reserved",
+		Payload:   json.RawMessage(`{"status":"reserved"}`),
+		CreatedAt: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+	}
+	var buf strings.Builder
+
+	// Act
+	err := writeEvent(&buf, event)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "id: 2\nevent: reserved\ndata: {\"id\":\"evt_2\",\"payment_id\":\"pay_123\",\"sequence\":2,\"event_type\":\"reserved\",\"payload\":{\"status\":\"reserved\"},\"created_at\":\"2024-01-15T10:30:00Z\"}\n\n", buf.String())
+}
@@ -7,13 +7,14 @@ import (
 
 // Start starts the finder router
 // It starts the finder router and returns an error if the builder fails
-func Start(rg *gin.RouterGroup, db paymentstorer.PaymentDB) error {
-	h, err := Build(db)
+func Start(rg *gin.RouterGroup, db paymentstorer.PaymentDB, en EventNotifier, policy StreamPolicy) error {
+	h, err := Build(db, en, policy)
 	if err != nil {
 		return err
 	}
 
 	rg.GET("/payments/:id", h.Find)
 	rg.GET("/payments/:id/events", h.FindEvents)
+	rg.GET("/payments/:id/events/stream", h.Stream)
 	return nil
 }
@@ -2,6 +2,7 @@ package finder
 
 import (
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentstorer"
@@ -38,7 +39,7 @@ func TestStart(t *testing.T) {
 			rg := router.Group("/api/v1")
 
 			// Act
-			err := Start(rg, tt.db)
+			err := Start(rg, tt.db, new(MockEventNotifier), StreamPolicy{KeepAlive: 15 * time.Second, MaxDuration: 30 * time.Minute})
 
 			// Assert
 			if tt.expectedError {
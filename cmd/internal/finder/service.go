@@ -12,6 +12,7 @@ import (
 type PaymentReader interface {
 	GetByID(ctx context.Context, paymentID string) (*domain.Payment, error)
 	GetEventsByPaymentID(ctx context.Context, paymentID string) ([]*domain.Event, error)
+	GetEventsAfterSequence(ctx context.Context, paymentID string, sequence int) ([]*domain.Event, error)
 }
 
 // PaymentFinderService is a service for finding payments and events
@@ -31,19 +32,32 @@ func NewPaymentFinderService(pr PaymentReader) (*PaymentFinderService, error) {
 }
 
 // Find finds a payment by ID
-// It finds a payment by ID and returns a payment and an error if the payment cannot be found
+// It finds a payment by ID and returns a payment and an error if the payment cannot be found or read by the caller
 func (pfs *PaymentFinderService) Find(ctx context.Context, filter *PaymentFilter) (*domain.Payment, error) {
 	payment, err := pfs.paymentReader.GetByID(ctx, filter.PaymentID)
 	if err != nil {
 		return nil, fmt.Errorf("payment finder: get payment: %w", err)
 	}
 
+	if err := authorize(ctx, payment); err != nil {
+		return nil, err
+	}
+
 	return payment, nil
 }
 
 // FindEvents finds all events for a payment by ID
-// It finds all events for a payment by ID and returns events and an error if the events cannot be found
+// It finds all events for a payment by ID and returns events and an error if the events cannot be found or read by the caller
 func (pfs *PaymentFinderService) FindEvents(ctx context.Context, paymentID string) ([]*domain.Event, error) {
+	payment, err := pfs.paymentReader.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("payment finder: get payment: %w", err)
+	}
+
+	if err := authorize(ctx, payment); err != nil {
+		return nil, err
+	}
+
 	events, err := pfs.paymentReader.GetEventsByPaymentID(ctx, paymentID)
 	if err != nil {
 		return nil, fmt.Errorf("payment finder: get events: %w", err)
@@ -51,3 +65,31 @@ func (pfs *PaymentFinderService) FindEvents(ctx context.Context, paymentID strin
 
 	return events, nil
 }
+
+// FindEventsAfter finds the events of a payment with a sequence greater than the given one
+// It takes the payment already found, so a stream reading on every new event does not read the payment again
+// It returns the events and an error if the events cannot be found or read by the caller
+func (pfs *PaymentFinderService) FindEventsAfter(ctx context.Context, payment *domain.Payment, sequence int) ([]*domain.Event, error) {
+	if err := authorize(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	events, err := pfs.paymentReader.GetEventsAfterSequence(ctx, payment.ID, sequence)
+	if err != nil {
+		return nil, fmt.Errorf("payment finder: get events after sequence: %w", err)
+	}
+
+	return events, nil
+}
+
+// authorize checks that the authenticated principal can read the payment
+// Only the owner of the payment and admins can read it
+// Another principal's payment gets the same error as a missing one, so callers cannot probe which payment IDs exist
+func authorize(ctx context.Context, payment *domain.Payment) error {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok || !principal.CanRead(payment.UserID) {
+		return fmt.Errorf("payment finder: get payment: %w", domain.ErrPaymentNotFound)
+	}
+
+	return nil
+}
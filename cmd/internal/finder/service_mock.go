@@ -29,3 +29,12 @@ func (m *MockPaymentFinderService) FindEvents(ctx context.Context, paymentID str
 	}
 	return args.Get(0).([]*domain.Event), args.Error(1)
 }
+
+// FindEventsAfter finds the events of a payment after a sequence
+func (m *MockPaymentFinderService) FindEventsAfter(ctx context.Context, payment *domain.Payment, sequence int) ([]*domain.Event, error) {
+	args := m.Called(ctx, payment, sequence)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Event), args.Error(1)
+}
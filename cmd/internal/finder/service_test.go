@@ -61,6 +61,7 @@ func TestPaymentFinderService_Find(t *testing.T) {
 		filter          *PaymentFilter
 		mockPayment     *domain.Payment
 		mockError       error
+		principal       *domain.Principal
 		expectedPayment *domain.Payment
 		expectedError   error
 	}{
@@ -92,6 +93,41 @@ func TestPaymentFinderService_Find(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			name: "when caller is admin it should return any payment and no error",
+			filter: &PaymentFilter{
+				PaymentID: "pay_123",
+			},
+			mockPayment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_789",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			principal: &domain.Principal{Subject: "ops", Scopes: []domain.Scope{domain.ScopeAdmin}},
+			expectedPayment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_789",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			expectedError: nil,
+		},
+		{
+			name: "when payment belongs to another user it should return the same not found error as a missing payment",
+			filter: &PaymentFilter{
+				PaymentID: "pay_123",
+			},
+			mockPayment: &domain.Payment{
+				ID:     "pay_123",
+				UserID: "user_789",
+			},
+			principal:       &domain.Principal{Subject: "user_456", Scopes: []domain.Scope{domain.ScopePaymentsRead}},
+			expectedPayment: nil,
+			expectedError:   errors.New("payment finder: get payment: payment not found"),
+		},
 		{
 			name: "when payment reader returns error it should return wrapped error",
 			filter: &PaymentFilter{
@@ -122,8 +158,14 @@ func TestPaymentFinderService_Find(t *testing.T) {
 
 			service := &PaymentFinderService{paymentReader: mockReader}
 
+			principal := tt.principal
+			if principal == nil {
+				principal = &domain.Principal{Subject: "user_789", Scopes: []domain.Scope{domain.ScopePaymentsRead}}
+			}
+			ctx := domain.WithPrincipal(context.Background(), principal)
+
 			// Act
-			result, err := service.Find(context.Background(), tt.filter)
+			result, err := service.Find(ctx, tt.filter)
 
 			// Assert
 			if tt.expectedError != nil {
@@ -152,8 +194,10 @@ func TestPaymentFinderService_FindEvents(t *testing.T) {
 	tests := []struct {
 		name           string
 		paymentID      string
+		mockPaymentErr error
 		mockEvents     []*domain.Event
 		mockError      error
+		principal      *domain.Principal
 		expectedEvents []*domain.Event
 		expectedError  error
 	}{
@@ -207,6 +251,20 @@ func TestPaymentFinderService_FindEvents(t *testing.T) {
 			expectedEvents: []*domain.Event{},
 			expectedError:  nil,
 		},
+		{
+			name:           "when payment does not exist it should return wrapped error",
+			paymentID:      "pay_123",
+			mockPaymentErr: domain.ErrPaymentNotFound,
+			expectedEvents: nil,
+			expectedError:  errors.New("payment finder: get payment: payment not found"),
+		},
+		{
+			name:           "when payment belongs to another user it should return the same not found error as a missing payment",
+			paymentID:      "pay_123",
+			principal:      &domain.Principal{Subject: "user_456", Scopes: []domain.Scope{domain.ScopePaymentsRead}},
+			expectedEvents: nil,
+			expectedError:  errors.New("payment finder: get payment: payment not found"),
+		},
 		{
 			name:           "when payment reader returns error it should return wrapped error",
 			paymentID:      "pay_123",
@@ -229,12 +287,25 @@ func TestPaymentFinderService_FindEvents(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Arrange
 			mockReader := new(paymentstorer.MockPaymentRepository)
-			mockReader.On("GetEventsByPaymentID", mock.Anything, tt.paymentID).Return(tt.mockEvents, tt.mockError)
+			if tt.mockPaymentErr != nil {
+				mockReader.On("GetByID", mock.Anything, tt.paymentID).Return(nil, tt.mockPaymentErr)
+			} else {
+				mockReader.On("GetByID", mock.Anything, tt.paymentID).Return(&domain.Payment{ID: tt.paymentID, UserID: "user_789"}, nil)
+			}
+
+			principal := tt.principal
+			if principal == nil {
+				principal = &domain.Principal{Subject: "user_789", Scopes: []domain.Scope{domain.ScopePaymentsRead}}
+			}
+			if tt.mockPaymentErr == nil && principal.CanRead("user_789") {
+				mockReader.On("GetEventsByPaymentID", mock.Anything, tt.paymentID).Return(tt.mockEvents, tt.mockError)
+			}
 
 			service := &PaymentFinderService{paymentReader: mockReader}
+			ctx := domain.WithPrincipal(context.Background(), principal)
 
 			// Act
-			result, err := service.FindEvents(context.Background(), tt.paymentID)
+			result, err := service.FindEvents(ctx, tt.paymentID)
 
 			// Assert
 			if tt.expectedError != nil {
@@ -256,3 +327,86 @@ func TestPaymentFinderService_FindEvents(t *testing.T) {
 		})
 	}
 }
+
+func TestPaymentFinderService_FindEventsAfter(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		sequence       int
+		mockEvents     []*domain.Event
+		mockError      error
+		principal      *domain.Principal
+		expectedEvents []*domain.Event
+		expectedError  error
+	}{
+		{
+			name:     "when events exist after the sequence it should return them and no error",
+			sequence: 1,
+			mockEvents: []*domain.Event{
+				{ID: "evt_2", PaymentID: "pay_123", Sequence: 2, EventType: "reserved", Payload: json.RawMessage(`{"status":"reserved"}`), CreatedAt: fixedTime},
+			},
+			expectedEvents: []*domain.Event{
+				{ID: "evt_2", PaymentID: "pay_123", Sequence: 2, EventType: "reserved", Payload: json.RawMessage(`{"status":"reserved"}`), CreatedAt: fixedTime},
+			},
+			expectedError: nil,
+		},
+		{
+			name:           "when admin reads another user's payment it should return the events and no error",
+			sequence:       0,
+			mockEvents:     []*domain.Event{},
+			principal:      &domain.Principal{Subject: "admin_1", Scopes: []domain.Scope{domain.ScopeAdmin}},
+			expectedEvents: []*domain.Event{},
+			expectedError:  nil,
+		},
+		{
+			name:           "when payment belongs to another user it should return the same not found error as a missing payment",
+			sequence:       0,
+			principal:      &domain.Principal{Subject: "user_456", Scopes: []domain.Scope{domain.ScopePaymentsRead}},
+			expectedEvents: nil,
+			expectedError:  errors.New("payment finder: get payment: payment not found"),
+		},
+		{
+			name:           "when payment reader returns error it should return wrapped error",
+			sequence:       2,
+			mockError:      errors.New("database error"),
+			expectedEvents: nil,
+			expectedError:  errors.New("payment finder: get events after sequence: database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			payment := &domain.Payment{ID: "pay_123", UserID: "user_789"}
+
+			principal := tt.principal
+			if principal == nil {
+				principal = &domain.Principal{Subject: "user_789", Scopes: []domain.Scope{domain.ScopePaymentsRead}}
+			}
+
+			mockReader := new(paymentstorer.MockPaymentRepository)
+			if principal.CanRead(payment.UserID) {
+				mockReader.On("GetEventsAfterSequence", mock.Anything, payment.ID, tt.sequence).Return(tt.mockEvents, tt.mockError)
+			}
+
+			service := &PaymentFinderService{paymentReader: mockReader}
+			ctx := domain.WithPrincipal(context.Background(), principal)
+
+			// Act
+			result, err := service.FindEventsAfter(ctx, payment, tt.sequence)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedEvents, result)
+			}
+
+			mockReader.AssertExpectations(t)
+		})
+	}
+}
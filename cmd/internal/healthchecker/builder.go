@@ -0,0 +1,17 @@
+package healthchecker
+
+// Build creates a new Handler with all dependencies wired up
+// It builds a new handler and returns an error if the builder fails
+func Build(br BreakerReporter) (*Handler, error) {
+	hcs, err := NewHealthCheckerService(br)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := NewHandler(hcs)
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
@@ -0,0 +1,37 @@
+package healthchecker
+
+import "github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/circuitbreaker"
+
+// HealthStatus is the overall status of the service
+type HealthStatus string
+
+const (
+	HealthStatusOK       HealthStatus = "ok"       // Every dependency is reachable
+	HealthStatusDegraded HealthStatus = "degraded" // At least one circuit breaker is not closed
+)
+
+// HealthReport is the health of the service and of its circuit breakers
+type HealthReport struct {
+	Status          HealthStatus              `json:"status"`
+	CircuitBreakers []circuitbreaker.Snapshot `json:"circuit_breakers"`
+}
+
+// NewHealthReport creates a health report from the circuit breaker snapshots
+// The service is degraded while any breaker is open or half-open
+func NewHealthReport(snapshots []circuitbreaker.Snapshot) *HealthReport {
+	report := &HealthReport{
+		Status:          HealthStatusOK,
+		CircuitBreakers: snapshots,
+	}
+	if report.CircuitBreakers == nil {
+		report.CircuitBreakers = []circuitbreaker.Snapshot{}
+	}
+
+	for _, snapshot := range snapshots {
+		if snapshot.State != circuitbreaker.StateClosed {
+			report.Status = HealthStatusDegraded
+		}
+	}
+
+	return report
+}
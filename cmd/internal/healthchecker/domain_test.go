@@ -0,0 +1,78 @@
+package healthchecker
+
+import (
+	"testing"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/circuitbreaker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHealthReport(t *testing.T) {
+	tests := []struct {
+		name           string
+		snapshots      []circuitbreaker.Snapshot
+		expectedReport *HealthReport
+	}{
+		{
+			name:      "when there are no breakers it should report ok with an empty list",
+			snapshots: nil,
+			expectedReport: &HealthReport{
+				Status:          HealthStatusOK,
+				CircuitBreakers: []circuitbreaker.Snapshot{},
+			},
+		},
+		{
+			name: "when every breaker is closed it should report ok",
+			snapshots: []circuitbreaker.Snapshot{
+				{Name: "wallet", State: circuitbreaker.StateClosed},
+				{Name: "gateway", State: circuitbreaker.StateClosed},
+			},
+			expectedReport: &HealthReport{
+				Status: HealthStatusOK,
+				CircuitBreakers: []circuitbreaker.Snapshot{
+					{Name: "wallet", State: circuitbreaker.StateClosed},
+					{Name: "gateway", State: circuitbreaker.StateClosed},
+				},
+			},
+		},
+		{
+			name: "when a breaker is open it should report degraded",
+			snapshots: []circuitbreaker.Snapshot{
+				{Name: "wallet", State: circuitbreaker.StateClosed},
+				{Name: "gateway", State: circuitbreaker.StateOpen},
+			},
+			expectedReport: &HealthReport{
+				Status: HealthStatusDegraded,
+				CircuitBreakers: []circuitbreaker.Snapshot{
+					{Name: "wallet", State: circuitbreaker.StateClosed},
+					{Name: "gateway", State: circuitbreaker.StateOpen},
+				},
+			},
+		},
+		{
+			name: "when a breaker is half-open it should report degraded",
+			snapshots: []circuitbreaker.Snapshot{
+				{Name: "wallet", State: circuitbreaker.StateHalfOpen},
+			},
+			expectedReport: &HealthReport{
+				Status: HealthStatusDegraded,
+				CircuitBreakers: []circuitbreaker.Snapshot{
+					{Name: "wallet", State: circuitbreaker.StateHalfOpen},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Snapshots already prepared in test struct)
+
+			// Act
+			result := NewHealthReport(tt.snapshots)
+
+			// Assert
+			assert.Equal(t, tt.expectedReport, result)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package healthchecker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthChecker defines the interface for health check business logic
+type HealthChecker interface {
+	Check(ctx context.Context) *HealthReport
+}
+
+// Handler handles HTTP requests for health checks
+type Handler struct {
+	healthChecker HealthChecker
+}
+
+// NewHandler creates a new health controller
+// It returns a new health controller and an error if the health checker is nil
+func NewHandler(hc HealthChecker) (*Handler, error) {
+	if hc == nil {
+		return nil, errors.New("healthchecker handler: health checker cannot be nil")
+	}
+
+	return &Handler{
+		healthChecker: hc,
+	}, nil
+}
+
+// Check handles GET /health requests
+// A degraded service still answers 200, open breakers fail fast on their own calls
+func (h *Handler) Check(c *gin.Context) {
+	report := h.healthChecker.Check(c.Request.Context())
+
+	message := "service is healthy"
+	if report.Status == HealthStatusDegraded {
+		message = "service is degraded"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    report,
+	})
+}
@@ -0,0 +1,111 @@
+package healthchecker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/circuitbreaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		healthChecker HealthChecker
+		expectedError string
+	}{
+		{
+			name:          "when health checker is provided it should create handler successfully and no error",
+			healthChecker: new(MockHealthCheckerService),
+			expectedError: "",
+		},
+		{
+			name:          "when health checker is nil it should return error",
+			healthChecker: nil,
+			expectedError: "healthchecker handler: health checker cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Health checker already prepared in test struct)
+
+			// Act
+			result, err := NewHandler(tt.healthChecker)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestHandler_Check(t *testing.T) {
+	tests := []struct {
+		name               string
+		mockReport         *HealthReport
+		expectedStatusCode int
+		expectedMessage    string
+		expectedStatus     string
+	}{
+		{
+			name: "when every breaker is closed it should return 200 and healthy message",
+			mockReport: &HealthReport{
+				Status:          HealthStatusOK,
+				CircuitBreakers: []circuitbreaker.Snapshot{{Name: "wallet", State: circuitbreaker.StateClosed}},
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "service is healthy",
+			expectedStatus:     "ok",
+		},
+		{
+			name: "when a breaker is open it should return 200 and degraded message",
+			mockReport: &HealthReport{
+				Status:          HealthStatusDegraded,
+				CircuitBreakers: []circuitbreaker.Snapshot{{Name: "gateway", State: circuitbreaker.StateOpen}},
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "service is degraded",
+			expectedStatus:     "degraded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockChecker := new(MockHealthCheckerService)
+			mockChecker.On("Check", mock.Anything).Return(tt.mockReport)
+
+			handler := &Handler{healthChecker: mockChecker}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/health", nil)
+
+			// Act
+			handler.Check(c)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedMessage, response["message"])
+			assert.Equal(t, tt.expectedStatus, response["data"].(map[string]interface{})["status"])
+
+			mockChecker.AssertExpectations(t)
+		})
+	}
+}
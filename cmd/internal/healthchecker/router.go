@@ -0,0 +1,15 @@
+package healthchecker
+
+import "github.com/gin-gonic/gin"
+
+// Start starts the health checker router
+// It starts the health checker router and returns an error if the builder fails
+func Start(rg *gin.RouterGroup, br BreakerReporter) error {
+	h, err := Build(br)
+	if err != nil {
+		return err
+	}
+
+	rg.GET("/health", h.Check)
+	return nil
+}
@@ -0,0 +1,49 @@
+package healthchecker
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/circuitbreaker"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name            string
+		breakerReporter BreakerReporter
+		expectedError   bool
+	}{
+		{
+			name:            "when breaker reporter is nil it should return error",
+			breakerReporter: nil,
+			expectedError:   true,
+		},
+		{
+			name:            "when breaker reporter is provided it should start router successfully and no error",
+			breakerReporter: circuitbreaker.NewRegistry(),
+			expectedError:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+
+			// Act
+			err := Start(&router.RouterGroup, tt.breakerReporter)
+
+			// Assert
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
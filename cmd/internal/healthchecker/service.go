@@ -0,0 +1,35 @@
+package healthchecker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/circuitbreaker"
+)
+
+// BreakerReporter interface for reading the state of the circuit breakers
+type BreakerReporter interface {
+	Snapshots() []circuitbreaker.Snapshot
+}
+
+// HealthCheckerService is a service for checking the health of the service
+type HealthCheckerService struct {
+	breakerReporter BreakerReporter
+}
+
+// NewHealthCheckerService creates a new HealthCheckerService
+func NewHealthCheckerService(br BreakerReporter) (*HealthCheckerService, error) {
+	if br == nil {
+		return nil, errors.New("health checker: breaker reporter cannot be nil")
+	}
+
+	return &HealthCheckerService{
+		breakerReporter: br,
+	}, nil
+}
+
+// Check checks the health of the service
+// It reports the state of every circuit breaker and whether the service is degraded
+func (hcs *HealthCheckerService) Check(ctx context.Context) *HealthReport {
+	return NewHealthReport(hcs.breakerReporter.Snapshots())
+}
@@ -0,0 +1,21 @@
+package healthchecker
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHealthCheckerService is a mock implementation of HealthCheckerService
+type MockHealthCheckerService struct {
+	mock.Mock
+}
+
+// Check checks the health of the service
+func (m *MockHealthCheckerService) Check(ctx context.Context) *HealthReport {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*HealthReport)
+}
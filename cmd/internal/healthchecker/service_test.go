@@ -0,0 +1,85 @@
+package healthchecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/circuitbreaker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHealthCheckerService(t *testing.T) {
+	tests := []struct {
+		name            string
+		breakerReporter BreakerReporter
+		expectedError   string
+	}{
+		{
+			name:            "when breaker reporter is provided it should create service successfully and no error",
+			breakerReporter: new(circuitbreaker.MockRegistry),
+			expectedError:   "",
+		},
+		{
+			name:            "when breaker reporter is nil it should return error with message 'health checker: breaker reporter cannot be nil'",
+			breakerReporter: nil,
+			expectedError:   "health checker: breaker reporter cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Breaker reporter already prepared in test struct)
+
+			// Act
+			result, err := NewHealthCheckerService(tt.breakerReporter)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestHealthCheckerService_Check(t *testing.T) {
+	tests := []struct {
+		name           string
+		snapshots      []circuitbreaker.Snapshot
+		expectedStatus HealthStatus
+	}{
+		{
+			name:           "when every breaker is closed it should report ok",
+			snapshots:      []circuitbreaker.Snapshot{{Name: "wallet", State: circuitbreaker.StateClosed}},
+			expectedStatus: HealthStatusOK,
+		},
+		{
+			name:           "when a breaker is open it should report degraded",
+			snapshots:      []circuitbreaker.Snapshot{{Name: "gateway", State: circuitbreaker.StateOpen}},
+			expectedStatus: HealthStatusDegraded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockReporter := new(circuitbreaker.MockRegistry)
+			mockReporter.On("Snapshots").Return(tt.snapshots)
+
+			service := &HealthCheckerService{breakerReporter: mockReporter}
+
+			// Act
+			result := service.Check(context.Background())
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, result.Status)
+			assert.Equal(t, tt.snapshots, result.CircuitBreakers)
+			mockReporter.AssertExpectations(t)
+		})
+	}
+}
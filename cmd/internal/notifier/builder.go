@@ -0,0 +1,47 @@
+package notifier
+
+import (
+	"net/http"
+)
+
+// NotifierDB defines the database operations required by the notifier
+type NotifierDB interface {
+	SubscriptionDB
+	DeliveryDB
+}
+
+// Build creates a new Handler with all dependencies wired up
+// It builds a new handler and returns an error if the builder fails
+func Build(db NotifierDB, client *http.Client, policy DeliveryPolicy) (*Handler, error) {
+	sr, err := NewSubscriptionRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	dr, err := NewDeliveryRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	sender, err := NewHTTPSender(client)
+	if err != nil {
+		return nil, err
+	}
+
+	ss, err := NewSubscriptionService(sr)
+	if err != nil {
+		return nil, err
+	}
+
+	ds, err := NewDeliveryService(dr, sender, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := NewHandler(ss, ds, dr)
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
@@ -0,0 +1,280 @@
+package notifier
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+)
+
+// ErrSubscriptionNotFound is returned when a webhook subscription is not found
+var ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+
+// ErrDeliveryNotFound is returned when a webhook delivery is not found
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+// Headers sent with every webhook request
+const (
+	EventIDHeader    = "X-Webhook-Id"        // ID of the payment event, the same for every attempt so receivers can deduplicate
+	EventTypeHeader  = "X-Webhook-Event"     // Type of the payment event
+	DeliveryIDHeader = "X-Webhook-Delivery"  // ID of the delivery to the subscriber
+	TimestampHeader  = "X-Webhook-Timestamp" // Unix time the request was signed at
+	SignatureHeader  = "X-Webhook-Signature" // "v1=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret
+)
+
+const (
+	signatureVersion = "v1"     // Version prefix of the signature, bumped if the signing scheme changes
+	secretPrefix     = "whsec_" // Prefix of the subscription secrets, makes them easy to spot in leaked logs
+	secretBytes      = 32       // Random bytes of a subscription secret
+)
+
+// Subscription represents a subscriber receiving the payment events at its URL
+type Subscription struct {
+	ID         string    `json:"id"`               // Unique identifier for the subscription
+	URL        string    `json:"url"`              // URL the events are posted to
+	EventTypes []string  `json:"event_types"`      // Event types delivered, empty delivers every event
+	Active     bool      `json:"active"`           // Whether new events are delivered
+	Secret     string    `json:"secret,omitempty"` // HMAC key of the signatures, only returned when the subscription is created
+	CreatedAt  time.Time `json:"created_at"`       // Timestamp when the subscription was created
+	UpdatedAt  time.Time `json:"updated_at"`       // Timestamp when the subscription was last updated
+}
+
+// SubscriptionRequest represents the request to create a webhook subscription
+type SubscriptionRequest struct {
+	URL        string   `json:"url"`         // URL the events are posted to
+	EventTypes []string `json:"event_types"` // Event types delivered, empty delivers every event
+}
+
+// Validate validates the subscription request
+// It returns a domain.ValidationError with every invalid field
+func (r *SubscriptionRequest) Validate() error {
+	var validation domain.ValidationError
+	if err := validateURL(r.URL); err != nil {
+		validation.Add("url", err.Error())
+	}
+	if err := validateEventTypes(r.EventTypes); err != nil {
+		validation.Add("event_types", err.Error())
+	}
+	return validation.Err()
+}
+
+// SubscriptionUpdate represents the request to update a webhook subscription, fields left out are not changed
+type SubscriptionUpdate struct {
+	URL        *string   `json:"url"`         // New URL
+	EventTypes *[]string `json:"event_types"` // New event types, empty delivers every event
+	Active     *bool     `json:"active"`      // Pauses or resumes the deliveries of new events
+}
+
+// Validate validates the subscription update
+// It returns a domain.ValidationError with every invalid field
+func (u *SubscriptionUpdate) Validate() error {
+	if u.URL == nil && u.EventTypes == nil && u.Active == nil {
+		return errors.New("at least one of url, event_types or active is required")
+	}
+
+	var validation domain.ValidationError
+	if u.URL != nil {
+		if err := validateURL(*u.URL); err != nil {
+			validation.Add("url", err.Error())
+		}
+	}
+	if u.EventTypes != nil {
+		if err := validateEventTypes(*u.EventTypes); err != nil {
+			validation.Add("event_types", err.Error())
+		}
+	}
+	return validation.Err()
+}
+
+// Apply applies the update to the subscription
+func (u *SubscriptionUpdate) Apply(subscription *Subscription, now time.Time) {
+	if u.URL != nil {
+		subscription.URL = *u.URL
+	}
+	if u.EventTypes != nil {
+		subscription.EventTypes = *u.EventTypes
+	}
+	if u.Active != nil {
+		subscription.Active = *u.Active
+	}
+	subscription.UpdatedAt = now
+}
+
+// validateURL checks the subscriber URL is an absolute HTTP or HTTPS URL
+func validateURL(value string) error {
+	if value == "" {
+		return errors.New("url is required")
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// validateEventTypes checks every event type is a known payment event type
+func validateEventTypes(types []string) error {
+	for _, eventType := range types {
+		if !slices.Contains(domain.EventTypes, eventType) {
+			return errors.New("unknown event type " + eventType)
+		}
+	}
+	return nil
+}
+
+// NewSecret generates a random subscription secret
+// It returns an error if the random source fails
+func NewSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretPrefix + hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature of a webhook body sent at the given time
+// Receivers recompute it with their secret and compare it in constant time, rejecting old timestamps to stop replays
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// DeliveryStatus represents the status of a webhook delivery
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "pending"   // The delivery is waiting for its next attempt
+	DeliveryStatusSucceeded DeliveryStatus = "succeeded" // The subscriber answered with a 2xx status
+	DeliveryStatusFailed    DeliveryStatus = "failed"    // Every attempt failed, only a manual redelivery sends it again
+)
+
+// Validate validates the delivery status
+// It returns an error if the status is unknown
+func (s DeliveryStatus) Validate() error {
+	switch s {
+	case DeliveryStatusPending, DeliveryStatusSucceeded, DeliveryStatusFailed:
+		return nil
+	}
+	return errors.New("invalid delivery status")
+}
+
+// Delivery represents a payment event to be delivered to a subscription
+type Delivery struct {
+	ID             string         `json:"id"`                   // Unique identifier for the delivery
+	SubscriptionID string         `json:"subscription_id"`      // Subscription the event is delivered to
+	EventID        string         `json:"event_id"`             // Payment event delivered
+	EventType      string         `json:"event_type"`           // Type of the payment event
+	PaymentID      string         `json:"payment_id"`           // Payment the event belongs to
+	Status         DeliveryStatus `json:"status"`               // Delivery status
+	Attempts       int            `json:"attempts"`             // Attempts made so far
+	NextAttemptAt  time.Time      `json:"next_attempt_at"`      // Timestamp of the next attempt while pending
+	LastError      string         `json:"last_error,omitempty"` // Error of the last failed attempt
+	CreatedAt      time.Time      `json:"created_at"`           // Timestamp when the event was enqueued for the subscription
+	UpdatedAt      time.Time      `json:"updated_at"`           // Timestamp of the last attempt
+}
+
+// OutgoingDelivery is a delivery with everything needed to send it
+type OutgoingDelivery struct {
+	Delivery *Delivery
+	URL      string        // Subscriber URL
+	Secret   string        // Subscription secret the request is signed with
+	Event    *domain.Event // Payment event sent as the request body
+}
+
+// DeliveryAttempt represents an entry of the delivery log, one per request sent to the subscriber
+type DeliveryAttempt struct {
+	ID          string    `json:"id"`                    // Unique identifier for the attempt
+	DeliveryID  string    `json:"delivery_id"`           // Delivery the attempt belongs to
+	Attempt     int       `json:"attempt"`               // Attempt number, starting at 1
+	StatusCode  int       `json:"status_code,omitempty"` // Status answered by the subscriber, 0 if the request failed
+	Error       string    `json:"error,omitempty"`       // Reason of the failure, empty if the attempt succeeded
+	DurationMS  int64     `json:"duration_ms"`           // Time the request took
+	AttemptedAt time.Time `json:"attempted_at"`          // Timestamp when the request was sent
+}
+
+// Succeeded reports whether the subscriber accepted the event
+func (a *DeliveryAttempt) Succeeded() bool {
+	return a.Error == ""
+}
+
+// DeliveryFilter represents the filter to list the deliveries of a subscription
+type DeliveryFilter struct {
+	SubscriptionID string
+	Status         DeliveryStatus
+	Limit          int
+	Offset         int
+}
+
+// Validate validates the delivery filter
+// It returns an error if the filter is invalid
+func (f *DeliveryFilter) Validate() error {
+	if f.Status != "" {
+		if err := f.Status.Validate(); err != nil {
+			return err
+		}
+	}
+	if f.Limit <= 0 {
+		return errors.New("limit must be greater than 0")
+	}
+	if f.Offset < 0 {
+		return errors.New("offset must be greater than or equal to 0")
+	}
+	return nil
+}
+
+// DeliveryPolicy defines how many deliveries are sent per run and how failed ones are retried
+type DeliveryPolicy struct {
+	BatchSize      int           // Deliveries sent per run
+	MaxAttempts    int           // Attempts before a delivery is failed
+	RetryBaseDelay time.Duration // Delay before the second attempt, doubled after every failure
+	RetryMaxDelay  time.Duration // Longest delay between two attempts
+}
+
+// Validate validates the delivery policy
+// It returns an error if the policy is invalid
+func (p *DeliveryPolicy) Validate() error {
+	if p.BatchSize <= 0 {
+		return errors.New("batch size must be greater than 0")
+	}
+	if p.MaxAttempts <= 0 {
+		return errors.New("max attempts must be greater than 0")
+	}
+	if p.RetryBaseDelay <= 0 {
+		return errors.New("retry base delay must be greater than 0")
+	}
+	if p.RetryMaxDelay < p.RetryBaseDelay {
+		return errors.New("retry max delay cannot be less than retry base delay")
+	}
+	return nil
+}
+
+// Backoff returns the delay after the given failed attempt, doubling from the base delay up to the max delay
+func (p *DeliveryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.RetryBaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= p.RetryMaxDelay {
+			return p.RetryMaxDelay
+		}
+	}
+	return delay
+}
+
+// DispatchResult summarizes the outcome of a dispatch run
+type DispatchResult struct {
+	Delivered int `json:"delivered"` // Deliveries the subscribers accepted
+	Retrying  int `json:"retrying"`  // Deliveries that failed and were scheduled for another attempt
+	Failed    int `json:"failed"`    // Deliveries that ran out of attempts
+	Errors    int `json:"errors"`    // Deliveries whose attempt could not be recorded, sent again on the next run
+}
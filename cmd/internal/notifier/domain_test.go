@@ -0,0 +1,370 @@
+package notifier
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		request       SubscriptionRequest
+		expectedError string
+	}{
+		{
+			name:          "when request is valid it should return no error",
+			request:       SubscriptionRequest{URL: "https://merchant.example.com/webhooks", EventTypes: []string{"completed", "failed"}},
+			expectedError: "",
+		},
+		{
+			name:          "when event types are empty it should return no error",
+			request:       SubscriptionRequest{URL: "http://localhost:8080/hooks"},
+			expectedError: "",
+		},
+		{
+			name:          "when url is empty it should return error",
+			request:       SubscriptionRequest{URL: ""},
+			expectedError: "url is required",
+		},
+		{
+			name:          "when url is not http it should return error",
+			request:       SubscriptionRequest{URL: "ftp://merchant.example.com/webhooks"},
+			expectedError: "url must be an absolute http or https URL",
+		},
+		{
+			name:          "when url is relative it should return error",
+			request:       SubscriptionRequest{URL: "/webhooks"},
+			expectedError: "url must be an absolute http or https URL",
+		},
+		{
+			name:          "when event type is unknown it should return error",
+			request:       SubscriptionRequest{URL: "https://merchant.example.com/webhooks", EventTypes: []string{"refunded"}},
+			expectedError: "unknown event type refunded",
+		},
+		{
+			name:          "when url and event types are invalid it should return every error",
+			request:       SubscriptionRequest{URL: "", EventTypes: []string{"refunded"}},
+			expectedError: "url is required; unknown event type refunded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Request already prepared in test struct)
+
+			// Act
+			err := tt.request.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSubscriptionUpdate_Validate(t *testing.T) {
+	validURL := "https://merchant.example.com/webhooks"
+	invalidURL := "merchant.example.com"
+	active := false
+
+	tests := []struct {
+		name          string
+		update        SubscriptionUpdate
+		expectedError string
+	}{
+		{
+			name:          "when update is valid it should return no error",
+			update:        SubscriptionUpdate{URL: &validURL, EventTypes: &[]string{"completed"}},
+			expectedError: "",
+		},
+		{
+			name:          "when only active is set it should return no error",
+			update:        SubscriptionUpdate{Active: &active},
+			expectedError: "",
+		},
+		{
+			name:          "when no field is set it should return error",
+			update:        SubscriptionUpdate{},
+			expectedError: "at least one of url, event_types or active is required",
+		},
+		{
+			name:          "when url is invalid it should return error",
+			update:        SubscriptionUpdate{URL: &invalidURL},
+			expectedError: "url must be an absolute http or https URL",
+		},
+		{
+			name:          "when event type is unknown it should return error",
+			update:        SubscriptionUpdate{EventTypes: &[]string{"pending"}},
+			expectedError: "unknown event type pending",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Update already prepared in test struct)
+
+			// Act
+			err := tt.update.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSubscriptionUpdate_Apply(t *testing.T) {
+	createdAt := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	updatedAt := createdAt.Add(time.Hour)
+	active := false
+
+	// Arrange
+	subscription := &Subscription{
+		ID:         "sub_1",
+		URL:        "https://merchant.example.com/webhooks",
+		EventTypes: []string{"completed"},
+		Active:     true,
+		CreatedAt:  createdAt,
+		UpdatedAt:  createdAt,
+	}
+	update := SubscriptionUpdate{Active: &active}
+
+	// Act
+	update.Apply(subscription, updatedAt)
+
+	// Assert
+	assert.Equal(t, &Subscription{
+		ID:         "sub_1",
+		URL:        "https://merchant.example.com/webhooks",
+		EventTypes: []string{"completed"},
+		Active:     false,
+		CreatedAt:  createdAt,
+		UpdatedAt:  updatedAt,
+	}, subscription)
+}
+
+func TestNewSecret(t *testing.T) {
+	// Act
+	first, err := NewSecret()
+	assert.NoError(t, err)
+	second, err := NewSecret()
+	assert.NoError(t, err)
+
+	// Assert
+	assert.True(t, strings.HasPrefix(first, "whsec_"))
+	assert.Len(t, first, len("whsec_")+64)
+	assert.NotEqual(t, first, second)
+}
+
+func TestSign(t *testing.T) {
+	timestamp := time.Unix(1705314600, 0)
+	body := []byte(`{"id":"evt_1"}`)
+
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte(`1705314600.{"id":"evt_1"}`))
+	expected := "v1=" + hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp time.Time
+		body      []byte
+		matches   bool
+	}{
+		{
+			name:      "when secret, timestamp and body are the signed ones it should return the same signature",
+			secret:    "whsec_test",
+			timestamp: timestamp,
+			body:      body,
+			matches:   true,
+		},
+		{
+			name:      "when secret differs it should return another signature",
+			secret:    "whsec_other",
+			timestamp: timestamp,
+			body:      body,
+			matches:   false,
+		},
+		{
+			name:      "when timestamp differs it should return another signature",
+			secret:    "whsec_test",
+			timestamp: timestamp.Add(time.Second),
+			body:      body,
+			matches:   false,
+		},
+		{
+			name:      "when body differs it should return another signature",
+			secret:    "whsec_test",
+			timestamp: timestamp,
+			body:      []byte(`{"id":"evt_2"}`),
+			matches:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Signature inputs already prepared in test struct)
+
+			// Act
+			result := Sign(tt.secret, tt.timestamp, tt.body)
+
+			// Assert
+			assert.Equal(t, tt.matches, result == expected)
+		})
+	}
+}
+
+func TestDeliveryFilter_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		filter        DeliveryFilter
+		expectedError string
+	}{
+		{
+			name:          "when filter is valid it should return no error",
+			filter:        DeliveryFilter{SubscriptionID: "sub_1", Status: DeliveryStatusFailed, Limit: 10},
+			expectedError: "",
+		},
+		{
+			name:          "when status is unknown it should return error",
+			filter:        DeliveryFilter{SubscriptionID: "sub_1", Status: "sent", Limit: 10},
+			expectedError: "invalid delivery status",
+		},
+		{
+			name:          "when limit is zero it should return error",
+			filter:        DeliveryFilter{SubscriptionID: "sub_1", Limit: 0},
+			expectedError: "limit must be greater than 0",
+		},
+		{
+			name:          "when offset is negative it should return error",
+			filter:        DeliveryFilter{SubscriptionID: "sub_1", Limit: 10, Offset: -1},
+			expectedError: "offset must be greater than or equal to 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Filter already prepared in test struct)
+
+			// Act
+			err := tt.filter.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDeliveryPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        DeliveryPolicy
+		expectedError string
+	}{
+		{
+			name:          "when policy is valid it should return no error",
+			policy:        DeliveryPolicy{BatchSize: 100, MaxAttempts: 8, RetryBaseDelay: 30 * time.Second, RetryMaxDelay: time.Hour},
+			expectedError: "",
+		},
+		{
+			name:          "when batch size is zero it should return error",
+			policy:        DeliveryPolicy{BatchSize: 0, MaxAttempts: 8, RetryBaseDelay: 30 * time.Second, RetryMaxDelay: time.Hour},
+			expectedError: "batch size must be greater than 0",
+		},
+		{
+			name:          "when max attempts is zero it should return error",
+			policy:        DeliveryPolicy{BatchSize: 100, MaxAttempts: 0, RetryBaseDelay: 30 * time.Second, RetryMaxDelay: time.Hour},
+			expectedError: "max attempts must be greater than 0",
+		},
+		{
+			name:          "when retry base delay is zero it should return error",
+			policy:        DeliveryPolicy{BatchSize: 100, MaxAttempts: 8, RetryBaseDelay: 0, RetryMaxDelay: time.Hour},
+			expectedError: "retry base delay must be greater than 0",
+		},
+		{
+			name:          "when retry max delay is less than base delay it should return error",
+			policy:        DeliveryPolicy{BatchSize: 100, MaxAttempts: 8, RetryBaseDelay: time.Minute, RetryMaxDelay: time.Second},
+			expectedError: "retry max delay cannot be less than retry base delay",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Policy already prepared in test struct)
+
+			// Act
+			err := tt.policy.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDeliveryPolicy_Backoff(t *testing.T) {
+	policy := DeliveryPolicy{BatchSize: 100, MaxAttempts: 8, RetryBaseDelay: 30 * time.Second, RetryMaxDelay: 5 * time.Minute}
+
+	tests := []struct {
+		name          string
+		attempt       int
+		expectedDelay time.Duration
+	}{
+		{
+			name:          "when first attempt failed it should return the base delay",
+			attempt:       1,
+			expectedDelay: 30 * time.Second,
+		},
+		{
+			name:          "when third attempt failed it should return the base delay doubled twice",
+			attempt:       3,
+			expectedDelay: 2 * time.Minute,
+		},
+		{
+			name:          "when doubled delay exceeds the max delay it should return the max delay",
+			attempt:       5,
+			expectedDelay: 5 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Attempt already prepared in test struct)
+
+			// Act
+			result := policy.Backoff(tt.attempt)
+
+			// Assert
+			assert.Equal(t, tt.expectedDelay, result)
+		})
+	}
+}
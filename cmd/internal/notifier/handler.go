@@ -0,0 +1,304 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultListLimit = 50 // Deliveries returned by a list request without a limit
+)
+
+// SubscriptionManager defines the interface for webhook subscription business logic
+type SubscriptionManager interface {
+	Create(ctx context.Context, sr *SubscriptionRequest) (*Subscription, error)
+	List(ctx context.Context) ([]*Subscription, error)
+	Get(ctx context.Context, subscriptionID string) (*Subscription, error)
+	Update(ctx context.Context, subscriptionID string, su *SubscriptionUpdate) (*Subscription, error)
+	Delete(ctx context.Context, subscriptionID string) error
+}
+
+// DeliveryDispatcher defines the interface for webhook delivery business logic
+type DeliveryDispatcher interface {
+	Dispatch(ctx context.Context) (*DispatchResult, error)
+	Redeliver(ctx context.Context, deliveryID string) (*Delivery, error)
+}
+
+// DeliveryReader defines the interface for reading the deliveries and their delivery log
+type DeliveryReader interface {
+	List(ctx context.Context, filter *DeliveryFilter) ([]*Delivery, error)
+	Get(ctx context.Context, deliveryID string) (*Delivery, error)
+	ListAttempts(ctx context.Context, deliveryID string) ([]*DeliveryAttempt, error)
+}
+
+// Handler handles scheduled webhook deliveries and webhook administration requests
+type Handler struct {
+	subscriptionManager SubscriptionManager
+	deliveryDispatcher  DeliveryDispatcher
+	deliveryReader      DeliveryReader
+}
+
+// NewHandler creates a new webhook handler
+// It returns a new webhook handler and an error if the subscription manager, delivery dispatcher or delivery reader is nil
+func NewHandler(sm SubscriptionManager, dd DeliveryDispatcher, dr DeliveryReader) (*Handler, error) {
+	if sm == nil {
+		return nil, errors.New("notifier handler: subscription manager cannot be nil")
+	}
+	if dd == nil {
+		return nil, errors.New("notifier handler: delivery dispatcher cannot be nil")
+	}
+	if dr == nil {
+		return nil, errors.New("notifier handler: delivery reader cannot be nil")
+	}
+
+	return &Handler{
+		subscriptionManager: sm,
+		deliveryDispatcher:  dd,
+		deliveryReader:      dr,
+	}, nil
+}
+
+// Run sends the webhook deliveries whose next attempt is due
+// It returns an error if the due deliveries cannot be listed
+func (h *Handler) Run(ctx context.Context) error {
+	result, err := h.deliveryDispatcher.Dispatch(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to dispatch webhook deliveries", "error", err)
+		return err
+	}
+
+	slog.InfoContext(ctx, "Webhook dispatch finished",
+		"delivered", result.Delivered,
+		"retrying", result.Retrying,
+		"failed", result.Failed,
+		"errors", result.Errors,
+	)
+	return nil
+}
+
+// Create handles POST /admin/webhooks/subscriptions requests
+func (h *Handler) Create(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var sr SubscriptionRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&sr); err != nil {
+		badRequest(c, "invalid request body")
+		return
+	}
+
+	if err := sr.Validate(); err != nil {
+		problem.Respond(c, problem.Invalid(err))
+		return
+	}
+
+	subscription, err := h.subscriptionManager.Create(ctx, &sr)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create webhook subscription", "error", err)
+		problem.Respond(c, problem.New(problem.CodeInternal, "failed to create webhook subscription"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "webhook subscription created successfully",
+		"data":    subscription,
+	})
+}
+
+// List handles GET /admin/webhooks/subscriptions requests
+func (h *Handler) List(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	subscriptions, err := h.subscriptionManager.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list webhook subscriptions", "error", err)
+		problem.Respond(c, problem.New(problem.CodeInternal, "failed to list webhook subscriptions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook subscriptions found successfully",
+		"data":    subscriptions,
+	})
+}
+
+// Show handles GET /admin/webhooks/subscriptions/:id requests
+func (h *Handler) Show(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	subscriptionID := c.Param("id")
+
+	subscription, err := h.subscriptionManager.Get(ctx, subscriptionID)
+	if err != nil {
+		fail(c, err, "failed to find webhook subscription", "subscription_id", subscriptionID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook subscription found successfully",
+		"data":    subscription,
+	})
+}
+
+// Update handles PATCH /admin/webhooks/subscriptions/:id requests
+func (h *Handler) Update(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	subscriptionID := c.Param("id")
+
+	var su SubscriptionUpdate
+	if err := json.NewDecoder(c.Request.Body).Decode(&su); err != nil {
+		badRequest(c, "invalid request body")
+		return
+	}
+
+	if err := su.Validate(); err != nil {
+		problem.Respond(c, problem.Invalid(err))
+		return
+	}
+
+	subscription, err := h.subscriptionManager.Update(ctx, subscriptionID, &su)
+	if err != nil {
+		fail(c, err, "failed to update webhook subscription", "subscription_id", subscriptionID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook subscription updated successfully",
+		"data":    subscription,
+	})
+}
+
+// Delete handles DELETE /admin/webhooks/subscriptions/:id requests
+func (h *Handler) Delete(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	subscriptionID := c.Param("id")
+
+	if err := h.subscriptionManager.Delete(ctx, subscriptionID); err != nil {
+		fail(c, err, "failed to delete webhook subscription", "subscription_id", subscriptionID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook subscription deleted successfully",
+	})
+}
+
+// ListDeliveries handles GET /admin/webhooks/subscriptions/:id/deliveries requests
+func (h *Handler) ListDeliveries(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	limit, err := intQuery(c, "limit", defaultListLimit)
+	if err != nil {
+		badRequest(c, "limit must be a number")
+		return
+	}
+
+	offset, err := intQuery(c, "offset", 0)
+	if err != nil {
+		badRequest(c, "offset must be a number")
+		return
+	}
+
+	filter := &DeliveryFilter{
+		SubscriptionID: c.Param("id"),
+		Status:         DeliveryStatus(c.Query("status")),
+		Limit:          limit,
+		Offset:         offset,
+	}
+
+	if err := filter.Validate(); err != nil {
+		problem.Respond(c, problem.Invalid(err))
+		return
+	}
+
+	deliveries, err := h.deliveryReader.List(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list webhook deliveries", "error", err, "subscription_id", filter.SubscriptionID)
+		problem.Respond(c, problem.New(problem.CodeInternal, "failed to list webhook deliveries"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook deliveries found successfully",
+		"data":    deliveries,
+	})
+}
+
+// ListAttempts handles GET /admin/webhooks/deliveries/:id/attempts requests
+func (h *Handler) ListAttempts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	deliveryID := c.Param("id")
+
+	// The delivery is looked up first, so an unknown delivery is told apart from one not attempted yet
+	if _, err := h.deliveryReader.Get(ctx, deliveryID); err != nil {
+		fail(c, err, "failed to find webhook delivery", "delivery_id", deliveryID)
+		return
+	}
+
+	attempts, err := h.deliveryReader.ListAttempts(ctx, deliveryID)
+	if err != nil {
+		fail(c, err, "failed to list webhook delivery attempts", "delivery_id", deliveryID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook delivery attempts found successfully",
+		"data":    attempts,
+	})
+}
+
+// Redeliver handles POST /admin/webhooks/deliveries/:id/redeliver requests
+// The delivery is attempted before responding, a failed attempt is reported in the delivery and not as an error
+func (h *Handler) Redeliver(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	deliveryID := c.Param("id")
+
+	delivery, err := h.deliveryDispatcher.Redeliver(ctx, deliveryID)
+	if err != nil {
+		fail(c, err, "failed to redeliver webhook delivery", "delivery_id", deliveryID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook delivery redelivered successfully",
+		"data":    delivery,
+	})
+}
+
+// fail writes the problem of an error, unknown subscriptions and deliveries are not found, anything else is logged
+func fail(c *gin.Context, err error, internalDetail string, args ...any) {
+	switch {
+	case errors.Is(err, ErrSubscriptionNotFound):
+		problem.Respond(c, problem.New(problem.CodeNotFound, ErrSubscriptionNotFound.Error()))
+	case errors.Is(err, ErrDeliveryNotFound):
+		problem.Respond(c, problem.New(problem.CodeNotFound, ErrDeliveryNotFound.Error()))
+	default:
+		slog.ErrorContext(c.Request.Context(), "Failed to handle webhook request", append([]any{"error", err}, args...)...)
+		problem.Respond(c, problem.New(problem.CodeInternal, internalDetail))
+	}
+}
+
+// badRequest writes a bad request problem with the given detail
+func badRequest(c *gin.Context, detail string) {
+	problem.Respond(c, problem.New(problem.CodeBadRequest, detail))
+}
+
+// intQuery reads an integer query parameter, returning the default value when it is not set
+func intQuery(c *gin.Context, key string, defaultValue int) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,59 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHandler is a mock implementation of Handler for testing
+type MockHandler struct {
+	mock.Mock
+}
+
+// Run mocks the Run method
+func (m *MockHandler) Run(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+// Create mocks the Create method
+func (m *MockHandler) Create(c *gin.Context) {
+	m.Called(c)
+}
+
+// List mocks the List method
+func (m *MockHandler) List(c *gin.Context) {
+	m.Called(c)
+}
+
+// Show mocks the Show method
+func (m *MockHandler) Show(c *gin.Context) {
+	m.Called(c)
+}
+
+// Update mocks the Update method
+func (m *MockHandler) Update(c *gin.Context) {
+	m.Called(c)
+}
+
+// Delete mocks the Delete method
+func (m *MockHandler) Delete(c *gin.Context) {
+	m.Called(c)
+}
+
+// ListDeliveries mocks the ListDeliveries method
+func (m *MockHandler) ListDeliveries(c *gin.Context) {
+	m.Called(c)
+}
+
+// ListAttempts mocks the ListAttempts method
+func (m *MockHandler) ListAttempts(c *gin.Context) {
+	m.Called(c)
+}
+
+// Redeliver mocks the Redeliver method
+func (m *MockHandler) Redeliver(c *gin.Context) {
+	m.Called(c)
+}
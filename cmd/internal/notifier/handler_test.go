@@ -0,0 +1,550 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		name                string
+		subscriptionManager SubscriptionManager
+		deliveryDispatcher  DeliveryDispatcher
+		deliveryReader      DeliveryReader
+		expectedError       string
+	}{
+		{
+			name:                "when all dependencies are provided it should create handler successfully and no error",
+			subscriptionManager: new(MockSubscriptionService),
+			deliveryDispatcher:  new(MockDeliveryService),
+			deliveryReader:      new(MockDeliveryRepository),
+			expectedError:       "",
+		},
+		{
+			name:                "when subscription manager is nil it should return error",
+			subscriptionManager: nil,
+			deliveryDispatcher:  new(MockDeliveryService),
+			deliveryReader:      new(MockDeliveryRepository),
+			expectedError:       "notifier handler: subscription manager cannot be nil",
+		},
+		{
+			name:                "when delivery dispatcher is nil it should return error",
+			subscriptionManager: new(MockSubscriptionService),
+			deliveryDispatcher:  nil,
+			deliveryReader:      new(MockDeliveryRepository),
+			expectedError:       "notifier handler: delivery dispatcher cannot be nil",
+		},
+		{
+			name:                "when delivery reader is nil it should return error",
+			subscriptionManager: new(MockSubscriptionService),
+			deliveryDispatcher:  new(MockDeliveryService),
+			deliveryReader:      nil,
+			expectedError:       "notifier handler: delivery reader cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewHandler(tt.subscriptionManager, tt.deliveryDispatcher, tt.deliveryReader)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestHandler_Run(t *testing.T) {
+	tests := []struct {
+		name              string
+		mockResult        *DispatchResult
+		mockDispatchError error
+		expectedError     error
+	}{
+		{
+			name:              "when deliveries are dispatched it should return no error",
+			mockResult:        &DispatchResult{Delivered: 2, Retrying: 1},
+			mockDispatchError: nil,
+			expectedError:     nil,
+		},
+		{
+			name:              "when dispatch fails it should return dispatch error",
+			mockResult:        nil,
+			mockDispatchError: errors.New("delivery service: list due deliveries: connection refused"),
+			expectedError:     errors.New("delivery service: list due deliveries: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDispatcher := new(MockDeliveryService)
+			mockDispatcher.On("Dispatch", mock.Anything).Return(tt.mockResult, tt.mockDispatchError)
+
+			handler := &Handler{deliveryDispatcher: mockDispatcher}
+
+			// Act
+			err := handler.Run(context.Background())
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockDispatcher.AssertExpectations(t)
+		})
+	}
+}
+
+// assertResponse asserts the status of the response and its message, or its problem detail for errors
+func assertResponse(t *testing.T, w *httptest.ResponseRecorder, expectedStatusCode int, expectedMessage string) {
+	t.Helper()
+
+	assert.Equal(t, expectedStatusCode, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	if w.Code >= http.StatusBadRequest {
+		assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, expectedMessage, response["detail"])
+	} else {
+		assert.Equal(t, expectedMessage, response["message"])
+	}
+}
+
+func TestHandler_Create(t *testing.T) {
+	tests := []struct {
+		name               string
+		body               string
+		mockSubscription   *Subscription
+		mockCreateError    error
+		shouldCallCreate   bool
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when request is valid it should return 201 with subscription",
+			body:               `{"url":"https://merchant.example.com/webhooks","event_types":["completed"]}`,
+			mockSubscription:   &Subscription{ID: "sub_1", URL: "https://merchant.example.com/webhooks", Secret: "whsec_test"},
+			shouldCallCreate:   true,
+			expectedStatusCode: http.StatusCreated,
+			expectedMessage:    "webhook subscription created successfully",
+		},
+		{
+			name:               "when body is invalid it should return 400",
+			body:               `{`,
+			shouldCallCreate:   false,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "invalid request body",
+		},
+		{
+			name:               "when url is invalid it should return 400 with validation error",
+			body:               `{"url":"merchant.example.com"}`,
+			shouldCallCreate:   false,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "url must be an absolute http or https URL",
+		},
+		{
+			name:               "when create fails it should return 500",
+			body:               `{"url":"https://merchant.example.com/webhooks"}`,
+			mockCreateError:    errors.New("database error"),
+			shouldCallCreate:   true,
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "failed to create webhook subscription",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockManager := new(MockSubscriptionService)
+			if tt.shouldCallCreate {
+				mockManager.On("Create", mock.Anything, mock.Anything).Return(tt.mockSubscription, tt.mockCreateError)
+			}
+
+			handler := &Handler{subscriptionManager: mockManager}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/webhooks/subscriptions", strings.NewReader(tt.body))
+
+			// Act
+			handler.Create(c)
+
+			// Assert
+			assertResponse(t, w, tt.expectedStatusCode, tt.expectedMessage)
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_Show(t *testing.T) {
+	tests := []struct {
+		name               string
+		mockSubscription   *Subscription
+		mockGetError       error
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when subscription exists it should return 200 with subscription",
+			mockSubscription:   &Subscription{ID: "sub_1", URL: "https://merchant.example.com/webhooks"},
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "webhook subscription found successfully",
+		},
+		{
+			name:               "when subscription does not exist it should return 404",
+			mockGetError:       ErrSubscriptionNotFound,
+			expectedStatusCode: http.StatusNotFound,
+			expectedMessage:    "webhook subscription not found",
+		},
+		{
+			name:               "when get fails it should return 500",
+			mockGetError:       errors.New("database error"),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "failed to find webhook subscription",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockManager := new(MockSubscriptionService)
+			mockManager.On("Get", mock.Anything, "sub_1").Return(tt.mockSubscription, tt.mockGetError)
+
+			handler := &Handler{subscriptionManager: mockManager}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/webhooks/subscriptions/sub_1", nil)
+			c.Params = gin.Params{{Key: "id", Value: "sub_1"}}
+
+			// Act
+			handler.Show(c)
+
+			// Assert
+			assertResponse(t, w, tt.expectedStatusCode, tt.expectedMessage)
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_Update(t *testing.T) {
+	tests := []struct {
+		name               string
+		body               string
+		mockSubscription   *Subscription
+		mockUpdateError    error
+		shouldCallUpdate   bool
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when update is valid it should return 200 with subscription",
+			body:               `{"active":false}`,
+			mockSubscription:   &Subscription{ID: "sub_1", Active: false},
+			shouldCallUpdate:   true,
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "webhook subscription updated successfully",
+		},
+		{
+			name:               "when update is empty it should return 400",
+			body:               `{}`,
+			shouldCallUpdate:   false,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "at least one of url, event_types or active is required",
+		},
+		{
+			name:               "when subscription does not exist it should return 404",
+			body:               `{"event_types":[]}`,
+			mockUpdateError:    ErrSubscriptionNotFound,
+			shouldCallUpdate:   true,
+			expectedStatusCode: http.StatusNotFound,
+			expectedMessage:    "webhook subscription not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockManager := new(MockSubscriptionService)
+			if tt.shouldCallUpdate {
+				mockManager.On("Update", mock.Anything, "sub_1", mock.Anything).Return(tt.mockSubscription, tt.mockUpdateError)
+			}
+
+			handler := &Handler{subscriptionManager: mockManager}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPatch, "/admin/webhooks/subscriptions/sub_1", strings.NewReader(tt.body))
+			c.Params = gin.Params{{Key: "id", Value: "sub_1"}}
+
+			// Act
+			handler.Update(c)
+
+			// Assert
+			assertResponse(t, w, tt.expectedStatusCode, tt.expectedMessage)
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_Delete(t *testing.T) {
+	tests := []struct {
+		name               string
+		mockDeleteError    error
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when subscription exists it should return 200",
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "webhook subscription deleted successfully",
+		},
+		{
+			name:               "when subscription does not exist it should return 404",
+			mockDeleteError:    ErrSubscriptionNotFound,
+			expectedStatusCode: http.StatusNotFound,
+			expectedMessage:    "webhook subscription not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockManager := new(MockSubscriptionService)
+			mockManager.On("Delete", mock.Anything, "sub_1").Return(tt.mockDeleteError)
+
+			handler := &Handler{subscriptionManager: mockManager}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodDelete, "/admin/webhooks/subscriptions/sub_1", nil)
+			c.Params = gin.Params{{Key: "id", Value: "sub_1"}}
+
+			// Act
+			handler.Delete(c)
+
+			// Assert
+			assertResponse(t, w, tt.expectedStatusCode, tt.expectedMessage)
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ListDeliveries(t *testing.T) {
+	tests := []struct {
+		name               string
+		query              string
+		mockDeliveries     []*Delivery
+		mockListError      error
+		shouldCallList     bool
+		expectedFilter     *DeliveryFilter
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when deliveries exist it should return 200 with deliveries",
+			query:              "?status=failed&limit=10&offset=5",
+			mockDeliveries:     []*Delivery{{ID: "del_1", Status: DeliveryStatusFailed}},
+			shouldCallList:     true,
+			expectedFilter:     &DeliveryFilter{SubscriptionID: "sub_1", Status: DeliveryStatusFailed, Limit: 10, Offset: 5},
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "webhook deliveries found successfully",
+		},
+		{
+			name:               "when status is invalid it should return 400",
+			query:              "?status=sent",
+			shouldCallList:     false,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "invalid delivery status",
+		},
+		{
+			name:               "when limit is not a number it should return 400",
+			query:              "?limit=abc",
+			shouldCallList:     false,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "limit must be a number",
+		},
+		{
+			name:               "when list fails it should return 500",
+			query:              "",
+			mockListError:      errors.New("database error"),
+			shouldCallList:     true,
+			expectedFilter:     &DeliveryFilter{SubscriptionID: "sub_1", Limit: defaultListLimit},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "failed to list webhook deliveries",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockReader := new(MockDeliveryRepository)
+			if tt.shouldCallList {
+				mockReader.On("List", mock.Anything, tt.expectedFilter).Return(tt.mockDeliveries, tt.mockListError)
+			}
+
+			handler := &Handler{deliveryReader: mockReader}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/webhooks/subscriptions/sub_1/deliveries"+tt.query, nil)
+			c.Params = gin.Params{{Key: "id", Value: "sub_1"}}
+
+			// Act
+			handler.ListDeliveries(c)
+
+			// Assert
+			assertResponse(t, w, tt.expectedStatusCode, tt.expectedMessage)
+
+			mockReader.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ListAttempts(t *testing.T) {
+	tests := []struct {
+		name               string
+		mockGetError       error
+		mockAttempts       []*DeliveryAttempt
+		mockListError      error
+		shouldCallList     bool
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when delivery exists it should return 200 with attempts",
+			mockAttempts:       []*DeliveryAttempt{{ID: "att_1", DeliveryID: "del_1", Attempt: 1, StatusCode: http.StatusOK}},
+			shouldCallList:     true,
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "webhook delivery attempts found successfully",
+		},
+		{
+			name:               "when delivery does not exist it should return 404",
+			mockGetError:       ErrDeliveryNotFound,
+			shouldCallList:     false,
+			expectedStatusCode: http.StatusNotFound,
+			expectedMessage:    "webhook delivery not found",
+		},
+		{
+			name:               "when list fails it should return 500",
+			mockListError:      errors.New("database error"),
+			shouldCallList:     true,
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "failed to list webhook delivery attempts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var delivery *Delivery
+			if tt.mockGetError == nil {
+				delivery = &Delivery{ID: "del_1"}
+			}
+
+			mockReader := new(MockDeliveryRepository)
+			mockReader.On("Get", mock.Anything, "del_1").Return(delivery, tt.mockGetError)
+			if tt.shouldCallList {
+				mockReader.On("ListAttempts", mock.Anything, "del_1").Return(tt.mockAttempts, tt.mockListError)
+			}
+
+			handler := &Handler{deliveryReader: mockReader}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/webhooks/deliveries/del_1/attempts", nil)
+			c.Params = gin.Params{{Key: "id", Value: "del_1"}}
+
+			// Act
+			handler.ListAttempts(c)
+
+			// Assert
+			assertResponse(t, w, tt.expectedStatusCode, tt.expectedMessage)
+
+			mockReader.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_Redeliver(t *testing.T) {
+	tests := []struct {
+		name               string
+		mockDelivery       *Delivery
+		mockRedeliverError error
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when delivery is attempted it should return 200 with delivery",
+			mockDelivery:       &Delivery{ID: "del_1", Status: DeliveryStatusSucceeded, Attempts: 4},
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "webhook delivery redelivered successfully",
+		},
+		{
+			name:               "when delivery does not exist it should return 404",
+			mockRedeliverError: ErrDeliveryNotFound,
+			expectedStatusCode: http.StatusNotFound,
+			expectedMessage:    "webhook delivery not found",
+		},
+		{
+			name:               "when redeliver fails it should return 500",
+			mockRedeliverError: errors.New("delivery service: record attempt: connection refused"),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "failed to redeliver webhook delivery",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDispatcher := new(MockDeliveryService)
+			mockDispatcher.On("Redeliver", mock.Anything, "del_1").Return(tt.mockDelivery, tt.mockRedeliverError)
+
+			handler := &Handler{deliveryDispatcher: mockDispatcher}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/webhooks/deliveries/del_1/redeliver", nil)
+			c.Params = gin.Params{{Key: "id", Value: "del_1"}}
+
+			// Act
+			handler.Redeliver(c)
+
+			// Assert
+			assertResponse(t, w, tt.expectedStatusCode, tt.expectedMessage)
+
+			mockDispatcher.AssertExpectations(t)
+		})
+	}
+}
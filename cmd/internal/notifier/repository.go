@@ -0,0 +1,491 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+)
+
+// SubscriptionDB defines the database operations required by SubscriptionRepository
+type SubscriptionDB interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) database.RowScanner
+	QueryContext(ctx context.Context, query string, args ...any) (database.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// SubscriptionRepository stores the webhook subscriptions
+type SubscriptionRepository struct {
+	db SubscriptionDB
+}
+
+// NewSubscriptionRepository creates a new SubscriptionRepository
+// It returns a new SubscriptionRepository and an error if the database is nil
+func NewSubscriptionRepository(db SubscriptionDB) (*SubscriptionRepository, error) {
+	if db == nil {
+		return nil, errors.New("subscription repository: database cannot be nil")
+	}
+
+	return &SubscriptionRepository{db: db}, nil
+}
+
+// Save stores a new subscription
+func (r *SubscriptionRepository) Save(ctx context.Context, subscription *Subscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (id, url, secret, event_types, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		subscription.ID,
+		subscription.URL,
+		subscription.Secret,
+		pq.Array(subscription.EventTypes),
+		subscription.Active,
+		subscription.CreatedAt,
+		subscription.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("subscription repository: save: %w", err)
+	}
+
+	return nil
+}
+
+// List lists the subscriptions, oldest first, without their secrets
+func (r *SubscriptionRepository) List(ctx context.Context) ([]*Subscription, error) {
+	query := `
+		SELECT id, url, event_types, active, created_at, updated_at
+		FROM webhook_subscriptions
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("subscription repository: list: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions := []*Subscription{}
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("subscription repository: scan subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("subscription repository: iterate subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// Get retrieves a subscription by its ID, without its secret
+// It returns ErrSubscriptionNotFound if the subscription does not exist
+func (r *SubscriptionRepository) Get(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	query := `
+		SELECT id, url, event_types, active, created_at, updated_at
+		FROM webhook_subscriptions
+		WHERE id = $1
+	`
+
+	subscription, err := scanSubscription(r.db.QueryRowContext(ctx, query, subscriptionID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("subscription repository: get: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// Update stores the URL, event types and active flag of a subscription
+// It returns ErrSubscriptionNotFound if the subscription does not exist
+func (r *SubscriptionRepository) Update(ctx context.Context, subscription *Subscription) error {
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = $2, event_types = $3, active = $4, updated_at = $5
+		WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		subscription.ID,
+		subscription.URL,
+		pq.Array(subscription.EventTypes),
+		subscription.Active,
+		subscription.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("subscription repository: update: %w", err)
+	}
+
+	return requireRow(result, "subscription repository: update", ErrSubscriptionNotFound)
+}
+
+// Delete deletes a subscription with its deliveries
+// It returns ErrSubscriptionNotFound if the subscription does not exist
+func (r *SubscriptionRepository) Delete(ctx context.Context, subscriptionID string) error {
+	query := `DELETE FROM webhook_subscriptions WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, subscriptionID)
+	if err != nil {
+		return fmt.Errorf("subscription repository: delete: %w", err)
+	}
+
+	return requireRow(result, "subscription repository: delete", ErrSubscriptionNotFound)
+}
+
+// requireRow returns notFound if the statement did not affect any row
+func requireRow(result sql.Result, operation string, notFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: rows affected: %w", operation, err)
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
+
+// scanSubscription scans a subscription row
+func scanSubscription(row database.RowScanner) (*Subscription, error) {
+	var subscription Subscription
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.URL,
+		pq.Array(&subscription.EventTypes),
+		&subscription.Active,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.EventTypes == nil {
+		subscription.EventTypes = []string{}
+	}
+
+	return &subscription, nil
+}
+
+// DeliveryDB defines the database operations required by DeliveryRepository
+type DeliveryDB interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) database.RowScanner
+	QueryContext(ctx context.Context, query string, args ...any) (database.Rows, error)
+	WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error
+}
+
+// DeliveryRepository stores the webhook deliveries and their attempts
+type DeliveryRepository struct {
+	db DeliveryDB
+}
+
+// NewDeliveryRepository creates a new DeliveryRepository
+// It returns a new DeliveryRepository and an error if the database is nil
+func NewDeliveryRepository(db DeliveryDB) (*DeliveryRepository, error) {
+	if db == nil {
+		return nil, errors.New("delivery repository: database cannot be nil")
+	}
+
+	return &DeliveryRepository{db: db}, nil
+}
+
+// outgoingQuery selects the deliveries with their subscription and event, the WHERE clause is appended by the callers
+const outgoingQuery = `
+	SELECT d.id, d.subscription_id, d.event_id, e.event_type, e.payment_id, d.status, d.attempts, d.next_attempt_at, d.last_error, d.created_at, d.updated_at,
+		s.url, s.secret,
+		e.id, e.payment_id, e.sequence, e.event_type, e.payload, e.created_at
+	FROM webhook_deliveries d
+	JOIN webhook_subscriptions s ON s.id = d.subscription_id
+	JOIN payment_events e ON e.id = d.event_id
+`
+
+// ListDue lists the pending deliveries of active subscriptions whose next attempt is due, oldest events first
+func (r *DeliveryRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*OutgoingDelivery, error) {
+	query := outgoingQuery + `
+		WHERE d.status = 'pending'
+			AND d.next_attempt_at <= $1
+			AND s.active
+		ORDER BY e.created_at ASC, e.sequence ASC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("delivery repository: list due: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*OutgoingDelivery{}
+	for rows.Next() {
+		delivery, err := scanOutgoing(rows)
+		if err != nil {
+			return nil, fmt.Errorf("delivery repository: scan due delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("delivery repository: iterate due deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// GetOutgoing retrieves a delivery with its subscription and event, whatever its status
+// It returns ErrDeliveryNotFound if the delivery does not exist
+func (r *DeliveryRepository) GetOutgoing(ctx context.Context, deliveryID string) (*OutgoingDelivery, error) {
+	query := outgoingQuery + `
+		WHERE d.id = $1
+	`
+
+	delivery, err := scanOutgoing(r.db.QueryRowContext(ctx, query, deliveryID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("delivery repository: get outgoing: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// RecordAttempt stores an attempt in the delivery log and the resulting delivery status in a single transaction
+func (r *DeliveryRepository) RecordAttempt(ctx context.Context, delivery *Delivery, attempt *DeliveryAttempt) error {
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		attemptQuery := `
+			INSERT INTO webhook_delivery_attempts (id, delivery_id, attempt, status_code, error, duration_ms, attempted_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`
+		_, err := tx.ExecContext(ctx, attemptQuery,
+			attempt.ID,
+			attempt.DeliveryID,
+			attempt.Attempt,
+			attempt.StatusCode,
+			attempt.Error,
+			attempt.DurationMS,
+			attempt.AttemptedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("insert attempt: %w", err)
+		}
+
+		deliveryQuery := `
+			UPDATE webhook_deliveries
+			SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5, updated_at = $6
+			WHERE id = $1
+		`
+		_, err = tx.ExecContext(ctx, deliveryQuery,
+			delivery.ID,
+			delivery.Status,
+			delivery.Attempts,
+			delivery.NextAttemptAt,
+			delivery.LastError,
+			delivery.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("update delivery: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("delivery repository: record attempt: %w", err)
+	}
+
+	return nil
+}
+
+// deliveryQuery selects the deliveries with the type and payment of their event, the WHERE clause is appended by the callers
+const deliveryQuery = `
+	SELECT d.id, d.subscription_id, d.event_id, e.event_type, e.payment_id, d.status, d.attempts, d.next_attempt_at, d.last_error, d.created_at, d.updated_at
+	FROM webhook_deliveries d
+	JOIN payment_events e ON e.id = d.event_id
+`
+
+// List lists the deliveries of a subscription matching the filter, most recent first
+func (r *DeliveryRepository) List(ctx context.Context, filter *DeliveryFilter) ([]*Delivery, error) {
+	query := deliveryQuery + `
+		WHERE d.subscription_id = $1
+			AND ($2 = '' OR d.status = $2)
+		ORDER BY d.created_at DESC, d.id ASC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.SubscriptionID, filter.Status, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("delivery repository: list: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*Delivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("delivery repository: scan delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("delivery repository: iterate deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Get retrieves a delivery by its ID
+// It returns ErrDeliveryNotFound if the delivery does not exist
+func (r *DeliveryRepository) Get(ctx context.Context, deliveryID string) (*Delivery, error) {
+	query := deliveryQuery + `
+		WHERE d.id = $1
+	`
+
+	delivery, err := scanDelivery(r.db.QueryRowContext(ctx, query, deliveryID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("delivery repository: get: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// ListAttempts lists the delivery log of a delivery, first attempt first
+func (r *DeliveryRepository) ListAttempts(ctx context.Context, deliveryID string) ([]*DeliveryAttempt, error) {
+	query := `
+		SELECT id, delivery_id, attempt, status_code, error, duration_ms, attempted_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY attempt ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("delivery repository: list attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []*DeliveryAttempt{}
+	for rows.Next() {
+		var attempt DeliveryAttempt
+		err := rows.Scan(
+			&attempt.ID,
+			&attempt.DeliveryID,
+			&attempt.Attempt,
+			&attempt.StatusCode,
+			&attempt.Error,
+			&attempt.DurationMS,
+			&attempt.AttemptedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("delivery repository: scan attempt: %w", err)
+		}
+		attempts = append(attempts, &attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("delivery repository: iterate attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// deliveryDest returns the scan destinations of the delivery columns
+func deliveryDest(delivery *Delivery) []any {
+	return []any{
+		&delivery.ID,
+		&delivery.SubscriptionID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.PaymentID,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+	}
+}
+
+// scanDelivery scans a delivery row
+func scanDelivery(row database.RowScanner) (*Delivery, error) {
+	var delivery Delivery
+	if err := row.Scan(deliveryDest(&delivery)...); err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// scanOutgoing scans a delivery row with its subscription and event
+func scanOutgoing(row database.RowScanner) (*OutgoingDelivery, error) {
+	outgoing := &OutgoingDelivery{Delivery: &Delivery{}, Event: &domain.Event{}}
+	dest := append(deliveryDest(outgoing.Delivery),
+		&outgoing.URL,
+		&outgoing.Secret,
+		&outgoing.Event.ID,
+		&outgoing.Event.PaymentID,
+		&outgoing.Event.Sequence,
+		&outgoing.Event.EventType,
+		&outgoing.Event.Payload,
+		&outgoing.Event.CreatedAt,
+	)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	return outgoing, nil
+}
+
+// HTTPSender posts the webhook requests to the subscribers
+type HTTPSender struct {
+	client *http.Client
+}
+
+// NewHTTPSender creates a new HTTPSender
+// It returns a new HTTPSender and an error if the client is nil
+func NewHTTPSender(client *http.Client) (*HTTPSender, error) {
+	if client == nil {
+		return nil, errors.New("webhook sender: client cannot be nil")
+	}
+
+	return &HTTPSender{client: client}, nil
+}
+
+// Send posts the body to the URL with the given headers
+// It returns the response status code, or an error if no response was received
+func (s *HTTPSender) Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("webhook sender: create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook sender: send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// The body is drained, so the connection is reused, but never read: subscribers only answer with a status
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,116 @@
+package notifier
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSubscriptionRepository is a mock implementation of SubscriptionStore for testing
+type MockSubscriptionRepository struct {
+	mock.Mock
+}
+
+// Save mocks the Save method
+func (m *MockSubscriptionRepository) Save(ctx context.Context, subscription *Subscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+// List mocks the List method
+func (m *MockSubscriptionRepository) List(ctx context.Context) ([]*Subscription, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*Subscription), args.Error(1)
+}
+
+// Get mocks the Get method
+func (m *MockSubscriptionRepository) Get(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	args := m.Called(ctx, subscriptionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Subscription), args.Error(1)
+}
+
+// Update mocks the Update method
+func (m *MockSubscriptionRepository) Update(ctx context.Context, subscription *Subscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+// Delete mocks the Delete method
+func (m *MockSubscriptionRepository) Delete(ctx context.Context, subscriptionID string) error {
+	args := m.Called(ctx, subscriptionID)
+	return args.Error(0)
+}
+
+// MockDeliveryRepository is a mock implementation of DeliveryStore and DeliveryReader for testing
+type MockDeliveryRepository struct {
+	mock.Mock
+}
+
+// ListDue mocks the ListDue method
+func (m *MockDeliveryRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*OutgoingDelivery, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*OutgoingDelivery), args.Error(1)
+}
+
+// GetOutgoing mocks the GetOutgoing method
+func (m *MockDeliveryRepository) GetOutgoing(ctx context.Context, deliveryID string) (*OutgoingDelivery, error) {
+	args := m.Called(ctx, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*OutgoingDelivery), args.Error(1)
+}
+
+// RecordAttempt mocks the RecordAttempt method
+func (m *MockDeliveryRepository) RecordAttempt(ctx context.Context, delivery *Delivery, attempt *DeliveryAttempt) error {
+	args := m.Called(ctx, delivery, attempt)
+	return args.Error(0)
+}
+
+// List mocks the List method
+func (m *MockDeliveryRepository) List(ctx context.Context, filter *DeliveryFilter) ([]*Delivery, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*Delivery), args.Error(1)
+}
+
+// Get mocks the Get method
+func (m *MockDeliveryRepository) Get(ctx context.Context, deliveryID string) (*Delivery, error) {
+	args := m.Called(ctx, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Delivery), args.Error(1)
+}
+
+// ListAttempts mocks the ListAttempts method
+func (m *MockDeliveryRepository) ListAttempts(ctx context.Context, deliveryID string) ([]*DeliveryAttempt, error) {
+	args := m.Called(ctx, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*DeliveryAttempt), args.Error(1)
+}
+
+// MockSender is a mock implementation of Sender for testing
+type MockSender struct {
+	mock.Mock
+}
+
+// Send mocks the Send method
+func (m *MockSender) Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error) {
+	args := m.Called(ctx, url, headers, body)
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,711 @@
+package notifier
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewSubscriptionRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            SubscriptionDB
+		expectedError string
+	}{
+		{
+			name:          "when database is provided it should create repository successfully and no error",
+			db:            new(database.MockDB),
+			expectedError: "",
+		},
+		{
+			name:          "when database is nil it should return error with message 'subscription repository: database cannot be nil'",
+			db:            nil,
+			expectedError: "subscription repository: database cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Database already prepared in test struct)
+
+			// Act
+			result, err := NewSubscriptionRepository(tt.db)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestSubscriptionRepository_Save(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockExecError error
+		expectedError error
+	}{
+		{
+			name:          "when insert succeeds it should return no error",
+			mockExecError: nil,
+			expectedError: nil,
+		},
+		{
+			name:          "when insert fails it should return wrapped error",
+			mockExecError: errors.New("connection refused"),
+			expectedError: errors.New("subscription repository: save: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			if tt.mockExecError != nil {
+				mockDB.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, tt.mockExecError)
+			} else {
+				mockDB.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(driver.RowsAffected(1), nil)
+			}
+
+			repo := &SubscriptionRepository{db: mockDB}
+			subscription := &Subscription{ID: "sub_1", URL: "https://merchant.example.com/webhooks", EventTypes: []string{"completed"}, Active: true, Secret: "whsec_test"}
+
+			// Act
+			err := repo.Save(context.Background(), subscription)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSubscriptionRepository_Get(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	subscription := &Subscription{
+		ID:         "sub_1",
+		URL:        "https://merchant.example.com/webhooks",
+		EventTypes: []string{"completed", "failed"},
+		Active:     true,
+		CreatedAt:  fixedTime,
+		UpdatedAt:  fixedTime,
+	}
+
+	tests := []struct {
+		name                 string
+		mockScanError        error
+		expectedSubscription *Subscription
+		expectedError        error
+	}{
+		{
+			name:                 "when subscription exists it should return subscription without secret and no error",
+			mockScanError:        nil,
+			expectedSubscription: subscription,
+			expectedError:        nil,
+		},
+		{
+			name:          "when subscription does not exist it should return not found error",
+			mockScanError: sql.ErrNoRows,
+			expectedError: ErrSubscriptionNotFound,
+		},
+		{
+			name:          "when scan fails it should return wrapped error",
+			mockScanError: errors.New("connection refused"),
+			expectedError: errors.New("subscription repository: get: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockScanner := new(database.MockRowScanner)
+
+			if tt.mockScanError == nil {
+				mockScanner.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+					dest := args.Get(0).([]any)
+					*dest[0].(*string) = subscription.ID
+					*dest[1].(*string) = subscription.URL
+					*dest[2].(*pq.StringArray) = subscription.EventTypes
+					*dest[3].(*bool) = subscription.Active
+					*dest[4].(*time.Time) = subscription.CreatedAt
+					*dest[5].(*time.Time) = subscription.UpdatedAt
+				}).Return(nil)
+			} else {
+				mockScanner.On("Scan", mock.Anything).Return(tt.mockScanError)
+			}
+			mockDB.On("QueryRowContext", mock.Anything, mock.Anything, mock.Anything).Return(mockScanner)
+
+			repo := &SubscriptionRepository{db: mockDB}
+
+			// Act
+			result, err := repo.Get(context.Background(), "sub_1")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedSubscription, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSubscriptionRepository_Update(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockResult    driver.Result
+		mockExecError error
+		expectedError error
+	}{
+		{
+			name:          "when subscription is updated it should return no error",
+			mockResult:    driver.RowsAffected(1),
+			expectedError: nil,
+		},
+		{
+			name:          "when subscription does not exist it should return not found error",
+			mockResult:    driver.RowsAffected(0),
+			expectedError: ErrSubscriptionNotFound,
+		},
+		{
+			name:          "when update fails it should return wrapped error",
+			mockExecError: errors.New("connection refused"),
+			expectedError: errors.New("subscription repository: update: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockDB.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(tt.mockResult, tt.mockExecError)
+
+			repo := &SubscriptionRepository{db: mockDB}
+			subscription := &Subscription{ID: "sub_1", URL: "https://merchant.example.com/webhooks", EventTypes: []string{}, Active: false}
+
+			// Act
+			err := repo.Update(context.Background(), subscription)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSubscriptionRepository_Delete(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockResult    driver.Result
+		mockExecError error
+		expectedError error
+	}{
+		{
+			name:          "when subscription is deleted it should return no error",
+			mockResult:    driver.RowsAffected(1),
+			expectedError: nil,
+		},
+		{
+			name:          "when subscription does not exist it should return not found error",
+			mockResult:    driver.RowsAffected(0),
+			expectedError: ErrSubscriptionNotFound,
+		},
+		{
+			name:          "when delete fails it should return wrapped error",
+			mockExecError: errors.New("connection refused"),
+			expectedError: errors.New("subscription repository: delete: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockDB.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(tt.mockResult, tt.mockExecError)
+
+			repo := &SubscriptionRepository{db: mockDB}
+
+			// Act
+			err := repo.Delete(context.Background(), "sub_1")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewDeliveryRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            DeliveryDB
+		expectedError string
+	}{
+		{
+			name:          "when database is provided it should create repository successfully and no error",
+			db:            new(database.MockDB),
+			expectedError: "",
+		},
+		{
+			name:          "when database is nil it should return error with message 'delivery repository: database cannot be nil'",
+			db:            nil,
+			expectedError: "delivery repository: database cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Database already prepared in test struct)
+
+			// Act
+			result, err := NewDeliveryRepository(tt.db)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestDeliveryRepository_ListDue(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	outgoing := &OutgoingDelivery{
+		Delivery: &Delivery{
+			ID:             "del_1",
+			SubscriptionID: "sub_1",
+			EventID:        "evt_1",
+			EventType:      "completed",
+			PaymentID:      "pay_1",
+			Status:         DeliveryStatusPending,
+			NextAttemptAt:  fixedTime,
+			CreatedAt:      fixedTime,
+			UpdatedAt:      fixedTime,
+		},
+		URL:    "https://merchant.example.com/webhooks",
+		Secret: "whsec_test",
+		Event: &domain.Event{
+			ID:        "evt_1",
+			PaymentID: "pay_1",
+			Sequence:  3,
+			EventType: "completed",
+			Payload:   json.RawMessage(`{"status":"completed"}`),
+			CreatedAt: fixedTime,
+		},
+	}
+
+	tests := []struct {
+		name               string
+		mockDeliveries     []*OutgoingDelivery
+		mockQueryError     error
+		mockScanError      error
+		expectedDeliveries []*OutgoingDelivery
+		expectedError      error
+	}{
+		{
+			name:               "when deliveries are due it should return them with subscription and event and no error",
+			mockDeliveries:     []*OutgoingDelivery{outgoing},
+			expectedDeliveries: []*OutgoingDelivery{outgoing},
+			expectedError:      nil,
+		},
+		{
+			name:           "when query fails it should return wrapped error",
+			mockQueryError: errors.New("connection refused"),
+			expectedError:  errors.New("delivery repository: list due: connection refused"),
+		},
+		{
+			name:          "when scan fails it should return wrapped error",
+			mockScanError: errors.New("scan error"),
+			expectedError: errors.New("delivery repository: scan due delivery: scan error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockRows := new(database.MockRows)
+
+			if tt.mockQueryError != nil {
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, tt.mockQueryError)
+			} else {
+				if tt.mockScanError != nil {
+					mockRows.On("Next").Return(true).Once()
+					mockRows.On("Scan", mock.Anything).Return(tt.mockScanError)
+				} else {
+					count := len(tt.mockDeliveries)
+					mockRows.On("Next").Return(true).Times(count)
+					scanCallCount := 0
+					mockRows.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+						dest := args.Get(0).([]any)
+						o := tt.mockDeliveries[scanCallCount]
+						*dest[0].(*string) = o.Delivery.ID
+						*dest[1].(*string) = o.Delivery.SubscriptionID
+						*dest[2].(*string) = o.Delivery.EventID
+						*dest[3].(*string) = o.Delivery.EventType
+						*dest[4].(*string) = o.Delivery.PaymentID
+						*dest[5].(*DeliveryStatus) = o.Delivery.Status
+						*dest[6].(*int) = o.Delivery.Attempts
+						*dest[7].(*time.Time) = o.Delivery.NextAttemptAt
+						*dest[8].(*string) = o.Delivery.LastError
+						*dest[9].(*time.Time) = o.Delivery.CreatedAt
+						*dest[10].(*time.Time) = o.Delivery.UpdatedAt
+						*dest[11].(*string) = o.URL
+						*dest[12].(*string) = o.Secret
+						*dest[13].(*string) = o.Event.ID
+						*dest[14].(*string) = o.Event.PaymentID
+						*dest[15].(*int) = o.Event.Sequence
+						*dest[16].(*string) = o.Event.EventType
+						*dest[17].(*json.RawMessage) = o.Event.Payload
+						*dest[18].(*time.Time) = o.Event.CreatedAt
+						scanCallCount++
+					}).Return(nil).Times(count)
+					mockRows.On("Next").Return(false).Once()
+					mockRows.On("Err").Return(nil)
+				}
+				mockRows.On("Close").Return(nil)
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(mockRows, nil)
+			}
+
+			repo := &DeliveryRepository{db: mockDB}
+
+			// Act
+			result, err := repo.ListDue(context.Background(), fixedTime, 100)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedDeliveries, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeliveryRepository_GetOutgoing(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockScanError error
+		expectedError error
+	}{
+		{
+			name:          "when delivery does not exist it should return not found error",
+			mockScanError: sql.ErrNoRows,
+			expectedError: ErrDeliveryNotFound,
+		},
+		{
+			name:          "when scan fails it should return wrapped error",
+			mockScanError: errors.New("connection refused"),
+			expectedError: errors.New("delivery repository: get outgoing: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockScanner := new(database.MockRowScanner)
+			mockScanner.On("Scan", mock.Anything).Return(tt.mockScanError)
+			mockDB.On("QueryRowContext", mock.Anything, mock.Anything, mock.Anything).Return(mockScanner)
+
+			repo := &DeliveryRepository{db: mockDB}
+
+			// Act
+			result, err := repo.GetOutgoing(context.Background(), "del_1")
+
+			// Assert
+			assert.Error(t, err)
+			assert.Equal(t, tt.expectedError.Error(), err.Error())
+			assert.Nil(t, result)
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeliveryRepository_RecordAttempt(t *testing.T) {
+	tests := []struct {
+		name                 string
+		mockTransactionError error
+		expectedError        error
+	}{
+		{
+			name:                 "when transaction succeeds it should return no error",
+			mockTransactionError: nil,
+			expectedError:        nil,
+		},
+		{
+			name:                 "when transaction fails it should return wrapped error",
+			mockTransactionError: errors.New("insert attempt: connection refused"),
+			expectedError:        errors.New("delivery repository: record attempt: insert attempt: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockDB.On("WithTransaction", mock.Anything, mock.Anything).Return(tt.mockTransactionError)
+
+			repo := &DeliveryRepository{db: mockDB}
+			delivery := &Delivery{ID: "del_1", Status: DeliveryStatusSucceeded, Attempts: 1}
+			attempt := &DeliveryAttempt{ID: "att_1", DeliveryID: "del_1", Attempt: 1, StatusCode: http.StatusOK}
+
+			// Act
+			err := repo.RecordAttempt(context.Background(), delivery, attempt)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeliveryRepository_ListAttempts(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		mockAttempts     []*DeliveryAttempt
+		mockQueryError   error
+		expectedAttempts []*DeliveryAttempt
+		expectedError    error
+	}{
+		{
+			name: "when delivery was attempted it should return attempts and no error",
+			mockAttempts: []*DeliveryAttempt{
+				{ID: "att_1", DeliveryID: "del_1", Attempt: 1, StatusCode: 503, Error: "unexpected status 503", DurationMS: 12, AttemptedAt: fixedTime},
+				{ID: "att_2", DeliveryID: "del_1", Attempt: 2, StatusCode: 200, DurationMS: 8, AttemptedAt: fixedTime.Add(time.Minute)},
+			},
+			expectedAttempts: []*DeliveryAttempt{
+				{ID: "att_1", DeliveryID: "del_1", Attempt: 1, StatusCode: 503, Error: "unexpected status 503", DurationMS: 12, AttemptedAt: fixedTime},
+				{ID: "att_2", DeliveryID: "del_1", Attempt: 2, StatusCode: 200, DurationMS: 8, AttemptedAt: fixedTime.Add(time.Minute)},
+			},
+			expectedError: nil,
+		},
+		{
+			name:             "when delivery was not attempted it should return empty slice and no error",
+			mockAttempts:     []*DeliveryAttempt{},
+			expectedAttempts: []*DeliveryAttempt{},
+			expectedError:    nil,
+		},
+		{
+			name:           "when query fails it should return wrapped error",
+			mockQueryError: errors.New("connection refused"),
+			expectedError:  errors.New("delivery repository: list attempts: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockRows := new(database.MockRows)
+
+			if tt.mockQueryError != nil {
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, tt.mockQueryError)
+			} else {
+				count := len(tt.mockAttempts)
+				if count > 0 {
+					mockRows.On("Next").Return(true).Times(count)
+					scanCallCount := 0
+					mockRows.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+						dest := args.Get(0).([]any)
+						attempt := tt.mockAttempts[scanCallCount]
+						*dest[0].(*string) = attempt.ID
+						*dest[1].(*string) = attempt.DeliveryID
+						*dest[2].(*int) = attempt.Attempt
+						*dest[3].(*int) = attempt.StatusCode
+						*dest[4].(*string) = attempt.Error
+						*dest[5].(*int64) = attempt.DurationMS
+						*dest[6].(*time.Time) = attempt.AttemptedAt
+						scanCallCount++
+					}).Return(nil).Times(count)
+				}
+				mockRows.On("Next").Return(false).Once()
+				mockRows.On("Err").Return(nil)
+				mockRows.On("Close").Return(nil)
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(mockRows, nil)
+			}
+
+			repo := &DeliveryRepository{db: mockDB}
+
+			// Act
+			result, err := repo.ListAttempts(context.Background(), "del_1")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedAttempts, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewHTTPSender(t *testing.T) {
+	tests := []struct {
+		name          string
+		client        *http.Client
+		expectedError string
+	}{
+		{
+			name:          "when client is provided it should create sender successfully and no error",
+			client:        &http.Client{},
+			expectedError: "",
+		},
+		{
+			name:          "when client is nil it should return error",
+			client:        nil,
+			expectedError: "webhook sender: client cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Client already prepared in test struct)
+
+			// Act
+			result, err := NewHTTPSender(tt.client)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestHTTPSender_Send(t *testing.T) {
+	tests := []struct {
+		name               string
+		receiverStatus     int
+		closeReceiver      bool
+		expectedStatusCode int
+		expectedError      bool
+	}{
+		{
+			name:               "when receiver accepts the request it should return its status and no error",
+			receiverStatus:     http.StatusAccepted,
+			expectedStatusCode: http.StatusAccepted,
+			expectedError:      false,
+		},
+		{
+			name:               "when receiver rejects the request it should return its status and no error",
+			receiverStatus:     http.StatusBadRequest,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      false,
+		},
+		{
+			name:               "when receiver is unreachable it should return error",
+			closeReceiver:      true,
+			expectedStatusCode: 0,
+			expectedError:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var receivedBody []byte
+			var receivedHeader http.Header
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				receivedBody, _ = io.ReadAll(r.Body)
+				receivedHeader = r.Header
+				w.WriteHeader(tt.receiverStatus)
+			}))
+			defer receiver.Close()
+			if tt.closeReceiver {
+				receiver.Close()
+			}
+
+			sender := &HTTPSender{client: receiver.Client()}
+			body := []byte(`{"id":"evt_1"}`)
+
+			// Act
+			statusCode, err := sender.Send(context.Background(), receiver.URL, map[string]string{SignatureHeader: "v1=abc"}, body)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, statusCode)
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, body, receivedBody)
+				assert.Equal(t, "v1=abc", receivedHeader.Get(SignatureHeader))
+				assert.Equal(t, "application/json", receivedHeader.Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package notifier
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Start starts the webhook router
+// It starts the webhook router and returns an error if the builder fails
+func Start(rg *gin.RouterGroup, db NotifierDB, client *http.Client, policy DeliveryPolicy) error {
+	h, err := Build(db, client, policy)
+	if err != nil {
+		return err
+	}
+
+	subscriptions := rg.Group("/admin/webhooks/subscriptions")
+	subscriptions.POST("", h.Create)
+	subscriptions.GET("", h.List)
+	subscriptions.GET("/:id", h.Show)
+	subscriptions.PATCH("/:id", h.Update)
+	subscriptions.DELETE("/:id", h.Delete)
+	subscriptions.GET("/:id/deliveries", h.ListDeliveries)
+
+	deliveries := rg.Group("/admin/webhooks/deliveries")
+	deliveries.GET("/:id/attempts", h.ListAttempts)
+	deliveries.POST("/:id/redeliver", h.Redeliver)
+	return nil
+}
@@ -0,0 +1,50 @@
+package notifier
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            NotifierDB
+		client        *http.Client
+		policy        DeliveryPolicy
+		expectedError bool
+	}{
+		{
+			name:          "when database is nil it should return error",
+			db:            nil,
+			client:        &http.Client{},
+			policy:        DeliveryPolicy{BatchSize: 100, MaxAttempts: 8, RetryBaseDelay: 30 * time.Second, RetryMaxDelay: time.Hour},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+			rg := router.Group("/api/v1")
+
+			// Act
+			err := Start(rg, tt.db, tt.client, tt.policy)
+
+			// Assert
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SubscriptionStore interface for storing the webhook subscriptions
+type SubscriptionStore interface {
+	Save(ctx context.Context, subscription *Subscription) error
+	List(ctx context.Context) ([]*Subscription, error)
+	Get(ctx context.Context, subscriptionID string) (*Subscription, error)
+	Update(ctx context.Context, subscription *Subscription) error
+	Delete(ctx context.Context, subscriptionID string) error
+}
+
+// SubscriptionService is a service for managing the webhook subscriptions
+type SubscriptionService struct {
+	subscriptionStore SubscriptionStore
+	now               func() time.Time
+}
+
+// NewSubscriptionService creates a new SubscriptionService
+// It returns a new SubscriptionService and an error if the subscription store is nil
+func NewSubscriptionService(ss SubscriptionStore) (*SubscriptionService, error) {
+	if ss == nil {
+		return nil, errors.New("subscription service: subscription store cannot be nil")
+	}
+
+	return &SubscriptionService{
+		subscriptionStore: ss,
+		now:               time.Now,
+	}, nil
+}
+
+// Create creates an active subscription with a new secret
+// The secret is only returned here, subscribers must keep it to verify the signatures
+func (s *SubscriptionService) Create(ctx context.Context, sr *SubscriptionRequest) (*Subscription, error) {
+	secret, err := NewSecret()
+	if err != nil {
+		return nil, fmt.Errorf("subscription service: generate secret: %w", err)
+	}
+
+	eventTypes := sr.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	now := s.now()
+	subscription := &Subscription{
+		ID:         uuid.New().String(),
+		URL:        sr.URL,
+		EventTypes: eventTypes,
+		Active:     true,
+		Secret:     secret,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.subscriptionStore.Save(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("subscription service: save: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// List lists the subscriptions
+func (s *SubscriptionService) List(ctx context.Context) ([]*Subscription, error) {
+	subscriptions, err := s.subscriptionStore.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("subscription service: list: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// Get retrieves a subscription by its ID
+// It returns ErrSubscriptionNotFound if the subscription does not exist
+func (s *SubscriptionService) Get(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	subscription, err := s.subscriptionStore.Get(ctx, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("subscription service: get: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// Update applies the update to a subscription
+// Only events stored after the update are delivered with the new URL and event types
+// It returns ErrSubscriptionNotFound if the subscription does not exist
+func (s *SubscriptionService) Update(ctx context.Context, subscriptionID string, su *SubscriptionUpdate) (*Subscription, error) {
+	subscription, err := s.subscriptionStore.Get(ctx, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("subscription service: get: %w", err)
+	}
+
+	su.Apply(subscription, s.now())
+
+	if err := s.subscriptionStore.Update(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("subscription service: update: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// Delete deletes a subscription, its pending deliveries are dropped
+// It returns ErrSubscriptionNotFound if the subscription does not exist
+func (s *SubscriptionService) Delete(ctx context.Context, subscriptionID string) error {
+	if err := s.subscriptionStore.Delete(ctx, subscriptionID); err != nil {
+		return fmt.Errorf("subscription service: delete: %w", err)
+	}
+
+	return nil
+}
+
+// DeliveryStore interface for reading the deliveries to send and recording their attempts
+type DeliveryStore interface {
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*OutgoingDelivery, error)
+	GetOutgoing(ctx context.Context, deliveryID string) (*OutgoingDelivery, error)
+	RecordAttempt(ctx context.Context, delivery *Delivery, attempt *DeliveryAttempt) error
+}
+
+// Sender interface for posting the webhook requests
+type Sender interface {
+	Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error)
+}
+
+// DeliveryService is a service for delivering the payment events to the subscribers
+type DeliveryService struct {
+	deliveryStore DeliveryStore
+	sender        Sender
+	policy        DeliveryPolicy
+	now           func() time.Time
+}
+
+// NewDeliveryService creates a new DeliveryService
+// It returns a new DeliveryService and an error if any dependency is nil or the policy is invalid
+func NewDeliveryService(ds DeliveryStore, sender Sender, policy DeliveryPolicy) (*DeliveryService, error) {
+	if ds == nil {
+		return nil, errors.New("delivery service: delivery store cannot be nil")
+	}
+	if sender == nil {
+		return nil, errors.New("delivery service: sender cannot be nil")
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("delivery service: invalid policy: %w", err)
+	}
+
+	return &DeliveryService{
+		deliveryStore: ds,
+		sender:        sender,
+		policy:        policy,
+		now:           time.Now,
+	}, nil
+}
+
+// Dispatch sends a batch of the deliveries whose next attempt is due
+// It returns the run summary and an error only if the due deliveries cannot be listed
+func (s *DeliveryService) Dispatch(ctx context.Context) (*DispatchResult, error) {
+	deliveries, err := s.deliveryStore.ListDue(ctx, s.now(), s.policy.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("delivery service: list due deliveries: %w", err)
+	}
+
+	result := &DispatchResult{}
+	for _, outgoing := range deliveries {
+		if err := s.attempt(ctx, outgoing); err != nil {
+			slog.ErrorContext(ctx, "Failed to attempt webhook delivery", "error", err, "delivery_id", outgoing.Delivery.ID)
+			result.Errors++
+			continue
+		}
+
+		switch outgoing.Delivery.Status {
+		case DeliveryStatusSucceeded:
+			result.Delivered++
+		case DeliveryStatusFailed:
+			slog.WarnContext(ctx, "Webhook delivery failed", "delivery_id", outgoing.Delivery.ID, "attempts", outgoing.Delivery.Attempts, "error", outgoing.Delivery.LastError)
+			result.Failed++
+		default:
+			result.Retrying++
+		}
+	}
+
+	return result, nil
+}
+
+// Redeliver sends a delivery again right away, whatever its status
+// A failed attempt is retried with backoff while attempts are left, otherwise the delivery is failed again
+// It returns ErrDeliveryNotFound if the delivery does not exist
+func (s *DeliveryService) Redeliver(ctx context.Context, deliveryID string) (*Delivery, error) {
+	outgoing, err := s.deliveryStore.GetOutgoing(ctx, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("delivery service: get delivery: %w", err)
+	}
+
+	if err := s.attempt(ctx, outgoing); err != nil {
+		return nil, err
+	}
+
+	return outgoing.Delivery, nil
+}
+
+// attempt sends a delivery once and records the attempt with the resulting delivery status
+func (s *DeliveryService) attempt(ctx context.Context, outgoing *OutgoingDelivery) error {
+	delivery := outgoing.Delivery
+
+	body, err := json.Marshal(outgoing.Event)
+	if err != nil {
+		return fmt.Errorf("delivery service: marshal event: %w", err)
+	}
+
+	sentAt := s.now()
+	headers := map[string]string{
+		EventIDHeader:    outgoing.Event.ID,
+		EventTypeHeader:  outgoing.Event.EventType,
+		DeliveryIDHeader: delivery.ID,
+		TimestampHeader:  strconv.FormatInt(sentAt.Unix(), 10),
+		SignatureHeader:  Sign(outgoing.Secret, sentAt, body),
+	}
+
+	statusCode, err := s.sender.Send(ctx, outgoing.URL, headers, body)
+	finishedAt := s.now()
+
+	attempt := &DeliveryAttempt{
+		ID:          uuid.New().String(),
+		DeliveryID:  delivery.ID,
+		Attempt:     delivery.Attempts + 1,
+		StatusCode:  statusCode,
+		DurationMS:  finishedAt.Sub(sentAt).Milliseconds(),
+		AttemptedAt: sentAt,
+	}
+	switch {
+	case err != nil:
+		attempt.Error = err.Error()
+	case statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices:
+		attempt.Error = "unexpected status " + strconv.Itoa(statusCode)
+	}
+
+	delivery.Attempts = attempt.Attempt
+	delivery.LastError = attempt.Error
+	delivery.UpdatedAt = finishedAt
+	switch {
+	case attempt.Succeeded():
+		delivery.Status = DeliveryStatusSucceeded
+	case delivery.Attempts >= s.policy.MaxAttempts:
+		delivery.Status = DeliveryStatusFailed
+	default:
+		delivery.Status = DeliveryStatusPending
+		delivery.NextAttemptAt = finishedAt.Add(s.policy.Backoff(delivery.Attempts))
+	}
+
+	if err := s.deliveryStore.RecordAttempt(ctx, delivery, attempt); err != nil {
+		return fmt.Errorf("delivery service: record attempt: %w", err)
+	}
+
+	return nil
+}
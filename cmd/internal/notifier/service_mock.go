@@ -0,0 +1,77 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSubscriptionService is a mock implementation of SubscriptionManager for testing
+type MockSubscriptionService struct {
+	mock.Mock
+}
+
+// Create mocks the Create method
+func (m *MockSubscriptionService) Create(ctx context.Context, sr *SubscriptionRequest) (*Subscription, error) {
+	args := m.Called(ctx, sr)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Subscription), args.Error(1)
+}
+
+// List mocks the List method
+func (m *MockSubscriptionService) List(ctx context.Context) ([]*Subscription, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*Subscription), args.Error(1)
+}
+
+// Get mocks the Get method
+func (m *MockSubscriptionService) Get(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	args := m.Called(ctx, subscriptionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Subscription), args.Error(1)
+}
+
+// Update mocks the Update method
+func (m *MockSubscriptionService) Update(ctx context.Context, subscriptionID string, su *SubscriptionUpdate) (*Subscription, error) {
+	args := m.Called(ctx, subscriptionID, su)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Subscription), args.Error(1)
+}
+
+// Delete mocks the Delete method
+func (m *MockSubscriptionService) Delete(ctx context.Context, subscriptionID string) error {
+	args := m.Called(ctx, subscriptionID)
+	return args.Error(0)
+}
+
+// MockDeliveryService is a mock implementation of DeliveryDispatcher for testing
+type MockDeliveryService struct {
+	mock.Mock
+}
+
+// Dispatch mocks the Dispatch method
+func (m *MockDeliveryService) Dispatch(ctx context.Context) (*DispatchResult, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*DispatchResult), args.Error(1)
+}
+
+// Redeliver mocks the Redeliver method
+func (m *MockDeliveryService) Redeliver(ctx context.Context, deliveryID string) (*Delivery, error) {
+	args := m.Called(ctx, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Delivery), args.Error(1)
+}
@@ -0,0 +1,504 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testDeliveryPolicy = DeliveryPolicy{BatchSize: 100, MaxAttempts: 3, RetryBaseDelay: 30 * time.Second, RetryMaxDelay: time.Hour}
+
+func TestNewSubscriptionService(t *testing.T) {
+	tests := []struct {
+		name              string
+		subscriptionStore SubscriptionStore
+		expectedError     string
+	}{
+		{
+			name:              "when subscription store is provided it should create service successfully and no error",
+			subscriptionStore: new(MockSubscriptionRepository),
+			expectedError:     "",
+		},
+		{
+			name:              "when subscription store is nil it should return error",
+			subscriptionStore: nil,
+			expectedError:     "subscription service: subscription store cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewSubscriptionService(tt.subscriptionStore)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestSubscriptionService_Create(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		request            *SubscriptionRequest
+		mockSaveError      error
+		expectedEventTypes []string
+		expectedError      error
+	}{
+		{
+			name:               "when subscription is saved it should return active subscription with secret and no error",
+			request:            &SubscriptionRequest{URL: "https://merchant.example.com/webhooks", EventTypes: []string{"completed"}},
+			expectedEventTypes: []string{"completed"},
+			expectedError:      nil,
+		},
+		{
+			name:               "when event types are not set it should subscribe to every event",
+			request:            &SubscriptionRequest{URL: "https://merchant.example.com/webhooks"},
+			expectedEventTypes: []string{},
+			expectedError:      nil,
+		},
+		{
+			name:          "when save fails it should return wrapped error",
+			request:       &SubscriptionRequest{URL: "https://merchant.example.com/webhooks"},
+			mockSaveError: errors.New("connection refused"),
+			expectedError: errors.New("subscription service: save: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockStore := new(MockSubscriptionRepository)
+			mockStore.On("Save", mock.Anything, mock.Anything).Return(tt.mockSaveError)
+
+			service := &SubscriptionService{subscriptionStore: mockStore, now: func() time.Time { return fixedTime }}
+
+			// Act
+			result, err := service.Create(context.Background(), tt.request)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, result.ID)
+				assert.Equal(t, tt.request.URL, result.URL)
+				assert.Equal(t, tt.expectedEventTypes, result.EventTypes)
+				assert.True(t, result.Active)
+				assert.True(t, strings.HasPrefix(result.Secret, "whsec_"))
+				assert.Equal(t, fixedTime, result.CreatedAt)
+				assert.Equal(t, fixedTime, result.UpdatedAt)
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSubscriptionService_Update(t *testing.T) {
+	createdAt := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	fixedTime := createdAt.Add(time.Hour)
+	newURL := "https://merchant.example.com/v2/webhooks"
+
+	tests := []struct {
+		name            string
+		mockGetError    error
+		mockUpdateError error
+		shouldUpdate    bool
+		expectedError   error
+	}{
+		{
+			name:          "when subscription exists it should return updated subscription and no error",
+			shouldUpdate:  true,
+			expectedError: nil,
+		},
+		{
+			name:          "when subscription does not exist it should return not found error",
+			mockGetError:  ErrSubscriptionNotFound,
+			expectedError: errors.New("subscription service: get: webhook subscription not found"),
+		},
+		{
+			name:            "when update fails it should return wrapped error",
+			mockUpdateError: errors.New("connection refused"),
+			shouldUpdate:    true,
+			expectedError:   errors.New("subscription service: update: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var subscription *Subscription
+			if tt.mockGetError == nil {
+				subscription = &Subscription{ID: "sub_1", URL: "https://merchant.example.com/webhooks", EventTypes: []string{}, Active: true, CreatedAt: createdAt, UpdatedAt: createdAt}
+			}
+
+			mockStore := new(MockSubscriptionRepository)
+			mockStore.On("Get", mock.Anything, "sub_1").Return(subscription, tt.mockGetError)
+			if tt.shouldUpdate {
+				mockStore.On("Update", mock.Anything, mock.MatchedBy(func(s *Subscription) bool {
+					return s.URL == newURL && s.UpdatedAt.Equal(fixedTime)
+				})).Return(tt.mockUpdateError)
+			}
+
+			service := &SubscriptionService{subscriptionStore: mockStore, now: func() time.Time { return fixedTime }}
+
+			// Act
+			result, err := service.Update(context.Background(), "sub_1", &SubscriptionUpdate{URL: &newURL})
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, newURL, result.URL)
+			}
+
+			if tt.mockGetError != nil {
+				assert.ErrorIs(t, err, tt.mockGetError)
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewDeliveryService(t *testing.T) {
+	tests := []struct {
+		name          string
+		deliveryStore DeliveryStore
+		sender        Sender
+		policy        DeliveryPolicy
+		expectedError string
+	}{
+		{
+			name:          "when all dependencies are provided it should create service successfully and no error",
+			deliveryStore: new(MockDeliveryRepository),
+			sender:        new(MockSender),
+			policy:        testDeliveryPolicy,
+			expectedError: "",
+		},
+		{
+			name:          "when delivery store is nil it should return error",
+			deliveryStore: nil,
+			sender:        new(MockSender),
+			policy:        testDeliveryPolicy,
+			expectedError: "delivery service: delivery store cannot be nil",
+		},
+		{
+			name:          "when sender is nil it should return error",
+			deliveryStore: new(MockDeliveryRepository),
+			sender:        nil,
+			policy:        testDeliveryPolicy,
+			expectedError: "delivery service: sender cannot be nil",
+		},
+		{
+			name:          "when policy is invalid it should return error",
+			deliveryStore: new(MockDeliveryRepository),
+			sender:        new(MockSender),
+			policy:        DeliveryPolicy{},
+			expectedError: "delivery service: invalid policy: batch size must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewDeliveryService(tt.deliveryStore, tt.sender, tt.policy)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+// newOutgoing creates an outgoing delivery of a completed payment event with the given attempts made
+func newOutgoing(attempts int) *OutgoingDelivery {
+	return &OutgoingDelivery{
+		Delivery: &Delivery{ID: "del_1", SubscriptionID: "sub_1", EventID: "evt_1", Status: DeliveryStatusPending, Attempts: attempts},
+		URL:      "https://merchant.example.com/webhooks",
+		Secret:   "whsec_test",
+		Event: &domain.Event{
+			ID:        "evt_1",
+			PaymentID: "pay_1",
+			Sequence:  3,
+			EventType: string(domain.StatusCompleted),
+			Payload:   json.RawMessage(`{"status":"completed"}`),
+			CreatedAt: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestDeliveryService_Dispatch(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                  string
+		attempts              int
+		mockListError         error
+		mockStatusCode        int
+		mockSendError         error
+		mockRecordError       error
+		expectedStatus        DeliveryStatus
+		expectedNextAttemptAt time.Time
+		expectedLastError     string
+		expectedResult        *DispatchResult
+		expectedError         error
+	}{
+		{
+			name:           "when subscriber accepts the event it should mark delivery as succeeded",
+			attempts:       0,
+			mockStatusCode: http.StatusNoContent,
+			expectedStatus: DeliveryStatusSucceeded,
+			expectedResult: &DispatchResult{Delivered: 1},
+		},
+		{
+			name:                  "when subscriber answers with a server error it should schedule a retry with backoff",
+			attempts:              1,
+			mockStatusCode:        http.StatusServiceUnavailable,
+			expectedStatus:        DeliveryStatusPending,
+			expectedNextAttemptAt: fixedTime.Add(time.Minute),
+			expectedLastError:     "unexpected status 503",
+			expectedResult:        &DispatchResult{Retrying: 1},
+		},
+		{
+			name:              "when last attempt fails it should mark delivery as failed",
+			attempts:          2,
+			mockSendError:     errors.New("webhook sender: send request: connection refused"),
+			expectedStatus:    DeliveryStatusFailed,
+			expectedLastError: "webhook sender: send request: connection refused",
+			expectedResult:    &DispatchResult{Failed: 1},
+		},
+		{
+			name:            "when attempt cannot be recorded it should count an error",
+			attempts:        0,
+			mockStatusCode:  http.StatusOK,
+			mockRecordError: errors.New("connection refused"),
+			expectedStatus:  DeliveryStatusSucceeded,
+			expectedResult:  &DispatchResult{Errors: 1},
+		},
+		{
+			name:          "when due deliveries cannot be listed it should return wrapped error",
+			mockListError: errors.New("connection refused"),
+			expectedError: errors.New("delivery service: list due deliveries: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			outgoing := newOutgoing(tt.attempts)
+
+			mockStore := new(MockDeliveryRepository)
+			mockSender := new(MockSender)
+			if tt.mockListError != nil {
+				mockStore.On("ListDue", mock.Anything, fixedTime, testDeliveryPolicy.BatchSize).Return(nil, tt.mockListError)
+			} else {
+				mockStore.On("ListDue", mock.Anything, fixedTime, testDeliveryPolicy.BatchSize).Return([]*OutgoingDelivery{outgoing}, nil)
+				mockSender.On("Send", mock.Anything, outgoing.URL, mock.Anything, mock.Anything).Return(tt.mockStatusCode, tt.mockSendError)
+				mockStore.On("RecordAttempt", mock.Anything, outgoing.Delivery, mock.MatchedBy(func(a *DeliveryAttempt) bool {
+					return a.DeliveryID == "del_1" && a.Attempt == tt.attempts+1 && a.StatusCode == tt.mockStatusCode && a.Error == tt.expectedLastError
+				})).Return(tt.mockRecordError)
+			}
+
+			service := &DeliveryService{
+				deliveryStore: mockStore,
+				sender:        mockSender,
+				policy:        testDeliveryPolicy,
+				now:           func() time.Time { return fixedTime },
+			}
+
+			// Act
+			result, err := service.Dispatch(context.Background())
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, result)
+				assert.Equal(t, tt.expectedStatus, outgoing.Delivery.Status)
+				assert.Equal(t, tt.attempts+1, outgoing.Delivery.Attempts)
+				assert.Equal(t, tt.expectedLastError, outgoing.Delivery.LastError)
+				assert.Equal(t, tt.expectedNextAttemptAt, outgoing.Delivery.NextAttemptAt)
+			}
+
+			mockStore.AssertExpectations(t)
+			mockSender.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeliveryService_Dispatch_SignedRequest(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	// Arrange
+	var received *http.Request
+	var receivedBody []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+
+		// The receiver verifies the signature the way subscribers are told to
+		timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		if err != nil || r.Header.Get(SignatureHeader) != Sign("whsec_test", time.Unix(timestamp, 0), receivedBody) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	outgoing := newOutgoing(0)
+	outgoing.URL = receiver.URL
+
+	mockStore := new(MockDeliveryRepository)
+	mockStore.On("ListDue", mock.Anything, fixedTime, testDeliveryPolicy.BatchSize).Return([]*OutgoingDelivery{outgoing}, nil)
+	mockStore.On("RecordAttempt", mock.Anything, outgoing.Delivery, mock.Anything).Return(nil)
+
+	sender, err := NewHTTPSender(receiver.Client())
+	assert.NoError(t, err)
+
+	service := &DeliveryService{
+		deliveryStore: mockStore,
+		sender:        sender,
+		policy:        testDeliveryPolicy,
+		now:           func() time.Time { return fixedTime },
+	}
+
+	// Act
+	result, err := service.Dispatch(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, &DispatchResult{Delivered: 1}, result)
+	assert.Equal(t, DeliveryStatusSucceeded, outgoing.Delivery.Status)
+
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+	assert.Equal(t, "evt_1", received.Header.Get(EventIDHeader))
+	assert.Equal(t, "completed", received.Header.Get(EventTypeHeader))
+	assert.Equal(t, "del_1", received.Header.Get(DeliveryIDHeader))
+	assert.Equal(t, strconv.FormatInt(fixedTime.Unix(), 10), received.Header.Get(TimestampHeader))
+
+	var event domain.Event
+	assert.NoError(t, json.Unmarshal(receivedBody, &event))
+	assert.Equal(t, *outgoing.Event, event)
+
+	mockStore.AssertExpectations(t)
+}
+
+func TestDeliveryService_Redeliver(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		status         DeliveryStatus
+		attempts       int
+		mockGetError   error
+		mockStatusCode int
+		expectedStatus DeliveryStatus
+		expectedError  error
+	}{
+		{
+			name:           "when failed delivery is accepted it should mark it as succeeded",
+			status:         DeliveryStatusFailed,
+			attempts:       3,
+			mockStatusCode: http.StatusOK,
+			expectedStatus: DeliveryStatusSucceeded,
+		},
+		{
+			name:           "when failed delivery without attempts left fails again it should keep it failed",
+			status:         DeliveryStatusFailed,
+			attempts:       3,
+			mockStatusCode: http.StatusInternalServerError,
+			expectedStatus: DeliveryStatusFailed,
+		},
+		{
+			name:          "when delivery does not exist it should return not found error",
+			mockGetError:  ErrDeliveryNotFound,
+			expectedError: errors.New("delivery service: get delivery: webhook delivery not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockStore := new(MockDeliveryRepository)
+			mockSender := new(MockSender)
+			if tt.mockGetError != nil {
+				mockStore.On("GetOutgoing", mock.Anything, "del_1").Return(nil, tt.mockGetError)
+			} else {
+				outgoing := newOutgoing(tt.attempts)
+				outgoing.Delivery.Status = tt.status
+				mockStore.On("GetOutgoing", mock.Anything, "del_1").Return(outgoing, nil)
+				mockSender.On("Send", mock.Anything, outgoing.URL, mock.Anything, mock.Anything).Return(tt.mockStatusCode, nil)
+				mockStore.On("RecordAttempt", mock.Anything, outgoing.Delivery, mock.Anything).Return(nil)
+			}
+
+			service := &DeliveryService{
+				deliveryStore: mockStore,
+				sender:        mockSender,
+				policy:        testDeliveryPolicy,
+				now:           func() time.Time { return fixedTime },
+			}
+
+			// Act
+			result, err := service.Redeliver(context.Background(), "del_1")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.ErrorIs(t, err, tt.mockGetError)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, result.Status)
+				assert.Equal(t, tt.attempts+1, result.Attempts)
+			}
+
+			mockStore.AssertExpectations(t)
+			mockSender.AssertExpectations(t)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"net/http"
 
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentstorer"
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/walletclient"
 	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/restclient"
@@ -13,7 +14,7 @@ import (
 
 // Build creates a new Handler with all dependencies wired up
 // It builds a new handler and returns an error if the builder fails
-func Build(db paymentstorer.PaymentDB, rc *http.Client) (*Handler, error) {
+func Build(db paymentstorer.PaymentDB, rc *http.Client, wb walletclient.CircuitBreaker, gb CircuitBreaker, locker paymentlocker.AdvisoryLocker) (*Handler, error) {
 	ps, err := paymentstorer.NewStorer(db)
 	if err != nil {
 		return nil, err
@@ -24,6 +25,11 @@ func Build(db paymentstorer.PaymentDB, rc *http.Client) (*Handler, error) {
 		return nil, err
 	}
 
+	bwc, err := walletclient.NewBreakerWalletClient(wc, wb)
+	if err != nil {
+		return nil, err
+	}
+
 	gatewayConfig := &restclient.Config{
 		BaseURL: "http://test-gateway-service.com",
 		Timeout: 300 * time.Millisecond,
@@ -39,7 +45,17 @@ func Build(db paymentstorer.PaymentDB, rc *http.Client) (*Handler, error) {
 		return nil, err
 	}
 
-	pps, err := NewPaymentProcessorService(ps, wc, gpr)
+	bgp, err := NewBreakerGatewayProcessor(gpr, gb)
+	if err != nil {
+		return nil, err
+	}
+
+	pl, err := paymentlocker.NewLocker(locker)
+	if err != nil {
+		return nil, err
+	}
+
+	pps, err := NewPaymentProcessorService(ps, bwc, bgp, pl)
 	if err != nil {
 		return nil, err
 	}
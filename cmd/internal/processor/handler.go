@@ -3,14 +3,19 @@ package processor
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
 )
 
+// HandlerName is the name the processor marks its processed messages with
+const HandlerName = "processor"
+
 // PaymentProcessor defines the interface for payment processing business logic
 type PaymentProcessor interface {
-	Process(ctx context.Context, payment *domain.Payment) error
+	Process(ctx context.Context, payment *domain.Payment, messageID string) error
 }
 
 // Handler handles incoming payment messages from the queue
@@ -30,16 +35,30 @@ func NewHandler(pp PaymentProcessor) (*Handler, error) {
 	}, nil
 }
 
-// HandleMessage handles incoming messages from the queue
-// It handles incoming messages from the queue and returns an error if the message cannot be parsed or processed
-func (h *Handler) HandleMessage(body []byte) error {
-	ctx := context.Background()
+// HandleDelivery handles incoming messages from the queue
+// It restores the request ID of the message and returns an error if the message cannot be parsed or processed
+func (h *Handler) HandleDelivery(msg *messagebroker.Message) error {
+	ctx := messagebroker.ContextFromHeaders(context.Background(), msg.Headers)
+
+	// The body is not logged, it carries the payment data the redaction layer cannot see
+	slog.DebugContext(ctx, "Processing payment message", "bytes", len(msg.Body))
 
-	slog.InfoContext(ctx, "Processing payment message", "body", string(body))
+	// Unwrap the payment of CloudEvents messages, legacy messages carry it as their body
+	body, contentType, err := paymentData(msg)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read payment event", "error", err)
+		return err
+	}
+
+	// Parse message with the codec of its content type, JSON and protobuf payments coexist while publishers migrate
+	codec, err := messagebroker.CodecFor(contentType)
+	if err != nil {
+		slog.ErrorContext(ctx, "Unsupported payment content type", "error", err, "content_type", contentType)
+		return err
+	}
 
-	// Parse message
 	var payment domain.Payment
-	if err := payment.Parse(body); err != nil {
+	if err := codec.Unmarshal(body, &payment); err != nil {
 		slog.ErrorContext(ctx, "Failed to parse payment message", "error", err)
 		return err
 	}
@@ -53,7 +72,13 @@ func (h *Handler) HandleMessage(body []byte) error {
 	slog.InfoContext(ctx, "Processing payment", "payment_id", payment.ID, "user_id", payment.UserID, "amount", payment.Amount)
 
 	// Process payment
-	if err := h.paymentProcessor.Process(ctx, &payment); err != nil {
+	err = h.paymentProcessor.Process(ctx, &payment, msg.ID)
+	if errors.Is(err, domain.ErrGatewayUnavailable) {
+		// Gateway breaker open → Defer the message without counting it as a failed attempt
+		slog.WarnContext(ctx, "Gateway unavailable, deferring payment", "error", err, "payment_id", payment.ID)
+		return fmt.Errorf("%w: %w", messagebroker.ErrDefer, err)
+	}
+	if err != nil {
 		slog.ErrorContext(ctx, "Failed to process payment", "error", err, "payment_id", payment.ID)
 		return err
 	}
@@ -61,3 +86,20 @@ func (h *Handler) HandleMessage(body []byte) error {
 	slog.InfoContext(ctx, "Payment processed successfully", "payment_id", payment.ID)
 	return nil
 }
+
+// paymentData returns the payment data of a message and its content type, accepting legacy and CloudEvents messages while publishers migrate
+// It returns an error if the message is a malformed CloudEvent or an event of another type
+func paymentData(msg *messagebroker.Message) ([]byte, string, error) {
+	event, err := messagebroker.ParseCloudEvent(msg)
+	if errors.Is(err, messagebroker.ErrNotCloudEvent) {
+		return msg.Body, msg.ContentType, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if event.Type != domain.CloudEventTypePaymentCreated {
+		return nil, "", fmt.Errorf("processor: unexpected event type %q", event.Type)
+	}
+	return event.Data, event.DataContentType, nil
+}
@@ -1,14 +1,17 @@
 package processor
 
-import "github.com/stretchr/testify/mock"
+import (
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+	"github.com/stretchr/testify/mock"
+)
 
 // MockHandler is a mock implementation of Handler for testing
 type MockHandler struct {
 	mock.Mock
 }
 
-// HandleMessage mocks the HandleMessage method
-func (m *MockHandler) HandleMessage(body []byte) error {
-	args := m.Called(body)
+// HandleDelivery mocks the HandleDelivery method
+func (m *MockHandler) HandleDelivery(msg *messagebroker.Message) error {
+	args := m.Called(msg)
 	return args.Error(0)
 }
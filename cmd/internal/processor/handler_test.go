@@ -1,12 +1,16 @@
 package processor
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/requestid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -50,12 +54,15 @@ func TestNewHandler(t *testing.T) {
 	}
 }
 
-func TestHandler_HandleMessage(t *testing.T) {
+func TestHandler_HandleDelivery(t *testing.T) {
 	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
 
 	tests := []struct {
 		name              string
 		messageBody       []byte
+		contentType       string
+		headers           map[string]interface{}
+		expectedRequestID string
 		mockProcessError  error
 		shouldCallProcess bool
 		expectedError     error
@@ -80,6 +87,93 @@ func TestHandler_HandleMessage(t *testing.T) {
 			shouldCallProcess: true,
 			expectedError:     nil,
 		},
+		{
+			name: "when message has a request ID it should process the payment with it",
+			messageBody: func() []byte {
+				payment := &domain.Payment{
+					ID:             "pay_123",
+					IdempotencyKey: "key_456",
+					UserID:         "user_789",
+					Amount:         100.50,
+					Currency:       domain.CurrencyUSD,
+					Status:         domain.StatusReserved,
+					CreatedAt:      fixedTime,
+					UpdatedAt:      fixedTime,
+				}
+				body, _ := json.Marshal(payment)
+				return body
+			}(),
+			headers:           map[string]interface{}{messagebroker.RequestIDHeader: "req_123"},
+			expectedRequestID: "req_123",
+			mockProcessError:  nil,
+			shouldCallProcess: true,
+			expectedError:     nil,
+		},
+		{
+			name:              "when message is a structured cloud event it should process the payment in its data",
+			messageBody:       encodeEvent(cloudEvent(domain.CloudEventTypePaymentCreated, messagebroker.JSONCodec{}, fixedTime), messagebroker.EventModeStructured).body,
+			contentType:       messagebroker.CloudEventsContentType,
+			shouldCallProcess: true,
+			expectedError:     nil,
+		},
+		{
+			name:              "when message is a binary cloud event it should process the payment in its body",
+			messageBody:       encodeEvent(cloudEvent(domain.CloudEventTypePaymentCreated, messagebroker.JSONCodec{}, fixedTime), messagebroker.EventModeBinary).body,
+			contentType:       messagebroker.JSONContentType,
+			headers:           encodeEvent(cloudEvent(domain.CloudEventTypePaymentCreated, messagebroker.JSONCodec{}, fixedTime), messagebroker.EventModeBinary).headers,
+			shouldCallProcess: true,
+			expectedError:     nil,
+		},
+		{
+			name:              "when structured cloud event lost its content type it should still process the payment in its data",
+			messageBody:       encodeEvent(cloudEvent(domain.CloudEventTypePaymentCreated, messagebroker.JSONCodec{}, fixedTime), messagebroker.EventModeStructured).body,
+			contentType:       "",
+			shouldCallProcess: true,
+			expectedError:     nil,
+		},
+		{
+			name:              "when message is a structured cloud event with protobuf data it should process the payment in its data",
+			messageBody:       encodeEvent(cloudEvent(domain.CloudEventTypePaymentCreated, messagebroker.ProtobufCodec{}, fixedTime), messagebroker.EventModeStructured).body,
+			contentType:       messagebroker.CloudEventsContentType,
+			shouldCallProcess: true,
+			expectedError:     nil,
+		},
+		{
+			name:              "when message is a binary cloud event with protobuf data it should process the payment in its body",
+			messageBody:       encodeEvent(cloudEvent(domain.CloudEventTypePaymentCreated, messagebroker.ProtobufCodec{}, fixedTime), messagebroker.EventModeBinary).body,
+			contentType:       messagebroker.ProtobufContentType,
+			headers:           encodeEvent(cloudEvent(domain.CloudEventTypePaymentCreated, messagebroker.ProtobufCodec{}, fixedTime), messagebroker.EventModeBinary).headers,
+			shouldCallProcess: true,
+			expectedError:     nil,
+		},
+		{
+			name:              "when message is a legacy protobuf payment it should process the payment in its body",
+			messageBody:       encodeEvent(cloudEvent(domain.CloudEventTypePaymentCreated, messagebroker.ProtobufCodec{}, fixedTime), messagebroker.EventModeLegacy).body,
+			contentType:       messagebroker.ProtobufContentType,
+			shouldCallProcess: true,
+			expectedError:     nil,
+		},
+		{
+			name:              "when message content type is not supported it should return error",
+			messageBody:       []byte("pay_123"),
+			contentType:       "text/plain",
+			shouldCallProcess: false,
+			expectedError:     errors.New(`codec: unsupported content type "text/plain"`),
+		},
+		{
+			name:              "when cloud event has another type it should return error",
+			messageBody:       encodeEvent(cloudEvent("com.example.refund.created", messagebroker.JSONCodec{}, fixedTime), messagebroker.EventModeStructured).body,
+			contentType:       messagebroker.CloudEventsContentType,
+			shouldCallProcess: false,
+			expectedError:     errors.New(`processor: unexpected event type "com.example.refund.created"`),
+		},
+		{
+			name:              "when cloud event has no source it should return error",
+			messageBody:       []byte(`{"specversion":"1.0","id":"evt_1","type":"com.nahuelsoma.payments.payment.created","data":{}}`),
+			contentType:       messagebroker.CloudEventsContentType,
+			shouldCallProcess: false,
+			expectedError:     errors.New("cloud event source is required"),
+		},
 		{
 			name:              "when message body is invalid JSON it should return parse error",
 			messageBody:       []byte("invalid json"),
@@ -163,6 +257,26 @@ func TestHandler_HandleMessage(t *testing.T) {
 			shouldCallProcess: true,
 			expectedError:     errors.New("processing failed"),
 		},
+		{
+			name: "when gateway is unavailable it should return deferred error",
+			messageBody: func() []byte {
+				payment := &domain.Payment{
+					ID:             "pay_123",
+					IdempotencyKey: "key_456",
+					UserID:         "user_789",
+					Amount:         100.50,
+					Currency:       domain.CurrencyUSD,
+					Status:         domain.StatusReserved,
+					CreatedAt:      fixedTime,
+					UpdatedAt:      fixedTime,
+				}
+				body, _ := json.Marshal(payment)
+				return body
+			}(),
+			mockProcessError:  fmt.Errorf("payment processor: failed to process with gateway: %w", domain.ErrGatewayUnavailable),
+			shouldCallProcess: true,
+			expectedError:     errors.New("message deferred: payment processor: failed to process with gateway: payment gateway unavailable"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -170,13 +284,20 @@ func TestHandler_HandleMessage(t *testing.T) {
 			// Arrange
 			mockProcessor := new(MockPaymentProcessorService)
 			if tt.shouldCallProcess {
-				mockProcessor.On("Process", mock.Anything, mock.AnythingOfType("*domain.Payment")).Return(tt.mockProcessError)
+				// Messages without a request ID are processed with a new one
+				matchesRequestID := mock.MatchedBy(func(ctx context.Context) bool {
+					if tt.expectedRequestID == "" {
+						return requestid.FromContext(ctx) != ""
+					}
+					return requestid.FromContext(ctx) == tt.expectedRequestID
+				})
+				mockProcessor.On("Process", matchesRequestID, mock.AnythingOfType("*domain.Payment"), "msg_123").Return(tt.mockProcessError)
 			}
 
 			handler := &Handler{paymentProcessor: mockProcessor}
 
 			// Act
-			err := handler.HandleMessage(tt.messageBody)
+			err := handler.HandleDelivery(&messagebroker.Message{ID: "msg_123", ContentType: tt.contentType, Body: tt.messageBody, Headers: tt.headers})
 
 			// Assert
 			if tt.expectedError != nil {
@@ -194,3 +315,118 @@ func TestHandler_HandleMessage(t *testing.T) {
 		})
 	}
 }
+
+// encodedEvent is a cloud event written in one of the modes
+type encodedEvent struct {
+	body    []byte
+	headers map[string]interface{}
+}
+
+// cloudEvent builds a cloud event carrying a valid payment encoded with the codec
+func cloudEvent(eventType string, codec messagebroker.Codec, at time.Time) *messagebroker.CloudEvent {
+	body, _ := codec.Marshal(&domain.Payment{
+		ID:             "pay_123",
+		IdempotencyKey: "key_456",
+		UserID:         "user_789",
+		Amount:         100.50,
+		Currency:       domain.CurrencyUSD,
+		Status:         domain.StatusReserved,
+		CreatedAt:      at,
+		UpdatedAt:      at,
+	})
+	event := messagebroker.NewCloudEvent(eventType, "pay_123", domain.PaymentDataSchema, codec.ContentType(), body)
+	event.Source = "/payments-api"
+	return event
+}
+
+// encodeEvent writes a cloud event in the given mode
+func encodeEvent(event *messagebroker.CloudEvent, mode messagebroker.EventMode) encodedEvent {
+	body, _, headers, _ := event.Encode(mode)
+	return encodedEvent{body: body, headers: headers}
+}
+
+func TestHandler_HandleDelivery_MemoryBroker(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                string
+		mode                messagebroker.EventMode
+		codec               messagebroker.Codec
+		maxAttempts         int
+		mockProcessError    error
+		expectedCalls       int
+		expectedDeadLetters int
+	}{
+		{
+			name:                "when a published payment is processed successfully it should be acked",
+			mode:                messagebroker.EventModeStructured,
+			codec:               messagebroker.JSONCodec{},
+			maxAttempts:         3,
+			mockProcessError:    nil,
+			expectedCalls:       1,
+			expectedDeadLetters: 0,
+		},
+		{
+			name:                "when a published protobuf payment is processed successfully it should be acked",
+			mode:                messagebroker.EventModeBinary,
+			codec:               messagebroker.ProtobufCodec{},
+			maxAttempts:         3,
+			mockProcessError:    nil,
+			expectedCalls:       1,
+			expectedDeadLetters: 0,
+		},
+		{
+			name:                "when processing keeps failing it should retry and dead-letter the payment",
+			mode:                messagebroker.EventModeStructured,
+			codec:               messagebroker.JSONCodec{},
+			maxAttempts:         2,
+			mockProcessError:    errors.New("gateway rejected"),
+			expectedCalls:       2,
+			expectedDeadLetters: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			broker := messagebroker.NewMemoryBroker()
+			conn, err := messagebroker.ConnectMemory(broker)
+			assert.NoError(t, err)
+			defer conn.Close()
+
+			mockProcessor := new(MockPaymentProcessorService)
+			mockProcessor.On("Process", mock.Anything, mock.MatchedBy(func(payment *domain.Payment) bool {
+				return payment.ID == "pay_123" && payment.Amount == 100.50
+			}), mock.AnythingOfType("string")).Return(tt.mockProcessError)
+
+			channel, err := conn.NewChannel()
+			assert.NoError(t, err)
+
+			consumer, err := messagebroker.NewConsumer(channel, messagebroker.ConsumerConfig{
+				Exchange:    "payments",
+				QueueName:   "payments.created",
+				Workers:     1,
+				MaxAttempts: tt.maxAttempts,
+			})
+			assert.NoError(t, err)
+			assert.NoError(t, consumer.StartDeliveries(&Handler{paymentProcessor: mockProcessor}))
+
+			publisher, err := messagebroker.NewPublisher(conn, messagebroker.PublisherConfig{
+				Exchange:    "payments",
+				RoutingKey:  "payments.created",
+				EventFormat: messagebroker.EventFormat{Mode: tt.mode, Source: "/payments-api"},
+			})
+			assert.NoError(t, err)
+
+			// Act
+			err = publisher.PublishEvent(cloudEvent(domain.CloudEventTypePaymentCreated, tt.codec, fixedTime), nil)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Eventually(t, func() bool {
+				return broker.Messages("payments.created") == 0 && broker.Messages("payments.created.dlq") == tt.expectedDeadLetters
+			}, time.Second, 5*time.Millisecond)
+			mockProcessor.AssertNumberOfCalls(t, "Process", tt.expectedCalls)
+		})
+	}
+}
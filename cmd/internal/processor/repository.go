@@ -3,10 +3,13 @@ package processor
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 
 	"github.com/google/uuid"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/circuitbreaker"
 )
 
 // GatewayProcessorRepository processes payments with external gateway
@@ -25,11 +28,54 @@ func NewGatewayProcessorRepository(client *http.Client) (*GatewayProcessorReposi
 }
 
 // Process processes a payment with the external gateway
-// It processes a payment with the external gateway and returns the gateway reference on success
-func (r *GatewayProcessorRepository) Process(ctx context.Context, paymentID string, amount float64) (string, error) {
+// It processes a payment with the external gateway and returns the gateway result on success
+// A pending result carries the gateway reference the callback reports the outcome for
+func (r *GatewayProcessorRepository) Process(ctx context.Context, paymentID string, amount float64) (*domain.GatewayResult, error) {
 	slog.DebugContext(ctx, "[DEBUG] GatewayProcessorRepository.Process called", "payment_id", paymentID, "amount", amount)
 	// TODO: Implement the logic to process the payment via external gateway
-	// For now, return a mock gateway reference
+	// For now, return a mock gateway reference charged synchronously
 	gatewayRef := "gw_" + uuid.New().String()
-	return gatewayRef, nil
+	return &domain.GatewayResult{Ref: gatewayRef, Status: domain.GatewayStatusSucceeded}, nil
+}
+
+// CircuitBreaker runs calls through a circuit breaker
+type CircuitBreaker interface {
+	Execute(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// BreakerGatewayProcessor guards the gateway with a circuit breaker
+// Calls rejected by the open breaker fail fast with domain.ErrGatewayUnavailable
+type BreakerGatewayProcessor struct {
+	next    GatewayProcessor
+	breaker CircuitBreaker
+}
+
+// NewBreakerGatewayProcessor creates a new BreakerGatewayProcessor
+// It returns a new BreakerGatewayProcessor and an error if the gateway processor or the breaker is nil
+func NewBreakerGatewayProcessor(next GatewayProcessor, breaker CircuitBreaker) (*BreakerGatewayProcessor, error) {
+	if next == nil {
+		return nil, errors.New("gateway processor: gateway processor cannot be nil")
+	}
+	if breaker == nil {
+		return nil, errors.New("gateway processor: breaker cannot be nil")
+	}
+
+	return &BreakerGatewayProcessor{next: next, breaker: breaker}, nil
+}
+
+// Process processes a payment with the external gateway through the breaker
+func (r *BreakerGatewayProcessor) Process(ctx context.Context, paymentID string, amount float64) (*domain.GatewayResult, error) {
+	var result *domain.GatewayResult
+	err := r.breaker.Execute(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = r.next.Process(ctx, paymentID, amount)
+		return err
+	})
+	if errors.Is(err, circuitbreaker.ErrOpen) {
+		return nil, fmt.Errorf("%w: %w", domain.ErrGatewayUnavailable, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
 }
@@ -3,6 +3,8 @@ package processor
 import (
 	"context"
 
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -12,7 +14,24 @@ type MockGatewayProcessor struct {
 }
 
 // Process mocks the Process method
-func (m *MockGatewayProcessor) Process(ctx context.Context, paymentID string, amount float64) (string, error) {
+func (m *MockGatewayProcessor) Process(ctx context.Context, paymentID string, amount float64) (*domain.GatewayResult, error) {
 	args := m.Called(ctx, paymentID, amount)
-	return args.String(0), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.GatewayResult), args.Error(1)
+}
+
+// MockPaymentLocker is a mock implementation of PaymentLocker for testing
+type MockPaymentLocker struct {
+	mock.Mock
+}
+
+// Lock mocks the Lock method
+func (m *MockPaymentLocker) Lock(ctx context.Context, paymentID string) (paymentlocker.Unlocker, error) {
+	args := m.Called(ctx, paymentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(paymentlocker.Unlocker), args.Error(1)
 }
@@ -2,11 +2,15 @@ package processor
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"regexp"
 	"testing"
 
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/circuitbreaker"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestNewGatewayProcessorRepository(t *testing.T) {
@@ -116,14 +120,131 @@ func TestGatewayProcessorRepository_Process(t *testing.T) {
 				assert.Equal(t, tt.expectedError, err)
 			} else {
 				assert.NoError(t, err)
-				assert.NotEmpty(t, result)
+				assert.NotNil(t, result)
+				assert.Equal(t, domain.GatewayStatusSucceeded, result.Status)
 				if tt.shouldMatchPattern {
-					matched, matchErr := regexp.MatchString(tt.expectedPattern, result)
+					matched, matchErr := regexp.MatchString(tt.expectedPattern, result.Ref)
 					assert.NoError(t, matchErr)
-					assert.True(t, matched, "gateway reference should match pattern %s, got %s", tt.expectedPattern, result)
+					assert.True(t, matched, "gateway reference should match pattern %s, got %s", tt.expectedPattern, result.Ref)
 				}
 			}
 		})
 	}
 }
 
+
+func TestNewBreakerGatewayProcessor(t *testing.T) {
+	tests := []struct {
+		name          string
+		next          GatewayProcessor
+		breaker       CircuitBreaker
+		expectedError string
+	}{
+		{
+			name:          "when gateway processor and breaker are provided it should create repository successfully and no error",
+			next:          &MockGatewayProcessor{},
+			breaker:       &circuitbreaker.MockBreaker{},
+			expectedError: "",
+		},
+		{
+			name:          "when gateway processor is nil it should return error with message 'gateway processor: gateway processor cannot be nil'",
+			next:          nil,
+			breaker:       &circuitbreaker.MockBreaker{},
+			expectedError: "gateway processor: gateway processor cannot be nil",
+		},
+		{
+			name:          "when breaker is nil it should return error with message 'gateway processor: breaker cannot be nil'",
+			next:          &MockGatewayProcessor{},
+			breaker:       nil,
+			expectedError: "gateway processor: breaker cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewBreakerGatewayProcessor(tt.next, tt.breaker)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestBreakerGatewayProcessor_Process(t *testing.T) {
+	tests := []struct {
+		name           string
+		breakerError   error
+		callsGateway   bool
+		gatewayResult  *domain.GatewayResult
+		gatewayError   error
+		expectedResult *domain.GatewayResult
+		expectedError  string
+	}{
+		{
+			name:           "when breaker is closed and gateway succeeds it should return gateway result and no error",
+			breakerError:   nil,
+			callsGateway:   true,
+			gatewayResult:  &domain.GatewayResult{Ref: "gw_123", Status: domain.GatewayStatusSucceeded},
+			gatewayError:   nil,
+			expectedResult: &domain.GatewayResult{Ref: "gw_123", Status: domain.GatewayStatusSucceeded},
+			expectedError:  "",
+		},
+		{
+			name:           "when breaker is closed and gateway fails it should return gateway error",
+			breakerError:   nil,
+			callsGateway:   true,
+			gatewayResult:  nil,
+			gatewayError:   errors.New("gateway timeout"),
+			expectedResult: nil,
+			expectedError:  "gateway timeout",
+		},
+		{
+			name:           "when breaker is open it should not call gateway and return gateway unavailable error",
+			breakerError:   circuitbreaker.ErrOpen,
+			callsGateway:   false,
+			expectedResult: nil,
+			expectedError:  "payment gateway unavailable: circuit breaker is open",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockGateway := &MockGatewayProcessor{}
+			mockBreaker := &circuitbreaker.MockBreaker{}
+
+			mockBreaker.On("Execute", mock.Anything).Return(tt.breakerError)
+			if tt.callsGateway {
+				mockGateway.On("Process", mock.Anything, "pay_123", 100.0).Return(tt.gatewayResult, tt.gatewayError)
+			}
+
+			repo, err := NewBreakerGatewayProcessor(mockGateway, mockBreaker)
+			assert.NoError(t, err)
+
+			// Act
+			result, err := repo.Process(context.Background(), "pay_123", 100.0)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedResult, result)
+			mockBreaker.AssertExpectations(t)
+			mockGateway.AssertExpectations(t)
+		})
+	}
+}
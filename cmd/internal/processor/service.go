@@ -4,14 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
 )
 
 // PaymentResolver is an interface for resolving payment status
 type PaymentResolver interface {
 	GetByID(ctx context.Context, paymentID string) (*domain.Payment, error)
 	UpdateStatus(ctx context.Context, paymentID string, status domain.Status, gatewayRef string) error
+	UpdateStatusForMessage(ctx context.Context, paymentID string, status domain.Status, gatewayRef string, messageID string, handler string) error
 }
 
 // WalletResolver is an interface for resolving funds
@@ -22,7 +25,12 @@ type WalletResolver interface {
 
 // GatewayProcessor is an interface for processing payments with external gateway
 type GatewayProcessor interface {
-	Process(ctx context.Context, paymentID string, amount float64) (string, error)
+	Process(ctx context.Context, paymentID string, amount float64) (*domain.GatewayResult, error)
+}
+
+// PaymentLocker is an interface for serializing the processing of each payment
+type PaymentLocker interface {
+	Lock(ctx context.Context, paymentID string) (paymentlocker.Unlocker, error)
 }
 
 // PaymentProcessorService is a service for processing payments
@@ -30,14 +38,16 @@ type PaymentProcessorService struct {
 	paymentResolver  PaymentResolver
 	walletResolver   WalletResolver
 	gatewayProcessor GatewayProcessor
+	paymentLocker    PaymentLocker
 }
 
 // NewPaymentProcessorService creates a new PaymentProcessorService
-// It returns a new PaymentProcessorService and an error if the payment resolver, wallet resolver, or gateway processor is nil
+// It returns a new PaymentProcessorService and an error if the payment resolver, wallet resolver, gateway processor, or payment locker is nil
 func NewPaymentProcessorService(
 	pr PaymentResolver,
 	wr WalletResolver,
 	gp GatewayProcessor,
+	pl PaymentLocker,
 ) (*PaymentProcessorService, error) {
 	if pr == nil {
 		return nil, errors.New("payment processor: resolver cannot be nil")
@@ -48,18 +58,38 @@ func NewPaymentProcessorService(
 	if gp == nil {
 		return nil, errors.New("payment processor: gateway processor cannot be nil")
 	}
+	if pl == nil {
+		return nil, errors.New("payment processor: payment locker cannot be nil")
+	}
 
 	return &PaymentProcessorService{
 		paymentResolver:  pr,
 		walletResolver:   wr,
 		gatewayProcessor: gp,
+		paymentLocker:    pl,
 	}, nil
 }
 
 // Process processes a payment
 // It processes a payment and returns an error if the payment cannot be processed
 // It checks the payment status for idempotency, processes the payment with the gateway, confirms/releases funds and updates the status
-func (pps *PaymentProcessorService) Process(ctx context.Context, payment *domain.Payment) error {
+// A payment already charged by the gateway is only confirmed, the gateway is never called twice for it
+// A charge the gateway answers as pending leaves the payment processing, its callback completes or fails it
+// Messages for the same payment are processed one at a time, the payment stays locked until its status is recorded
+// The status that finishes the message marks it as processed, so the consumer skips its redeliveries
+func (pps *PaymentProcessorService) Process(ctx context.Context, payment *domain.Payment, messageID string) error {
+	// Step 0: Lock the payment, so a redelivery or recovery republish handled concurrently waits for this one
+	// and then sees the recorded status instead of charging the gateway again
+	lock, err := pps.paymentLocker.Lock(ctx, payment.ID)
+	if err != nil {
+		return fmt.Errorf("payment processor: failed to lock payment: %w", err)
+	}
+	defer func() {
+		if err := lock.Unlock(ctx); err != nil {
+			slog.WarnContext(ctx, "Failed to unlock payment", "error", err, "payment_id", payment.ID)
+		}
+	}()
+
 	// Step 1: Check payment status for idempotency
 	existing, err := pps.paymentResolver.GetByID(ctx, payment.ID)
 	if err != nil {
@@ -70,6 +100,10 @@ func (pps *PaymentProcessorService) Process(ctx context.Context, payment *domain
 	switch existing.Status {
 	case domain.StatusCompleted, domain.StatusFailed:
 		return nil // Already processed, skip silently
+	case domain.StatusProcessing:
+		return nil // Already charged asynchronously, the gateway callback settles it and wallet reconciliation reports it once stale
+	case domain.StatusPendingConfirm:
+		return pps.confirm(ctx, payment, existing.GatewayRef, messageID) // Already charged, only retry the wallet confirmation
 	case domain.StatusReserved:
 		// Continue processing
 	default:
@@ -77,27 +111,50 @@ func (pps *PaymentProcessorService) Process(ctx context.Context, payment *domain
 	}
 
 	// Step 2: Process with gateway
-	gatewayRef, err := pps.gatewayProcessor.Process(ctx, payment.ID, payment.Amount)
+	result, err := pps.gatewayProcessor.Process(ctx, payment.ID, payment.Amount)
+	if errors.Is(err, domain.ErrGatewayUnavailable) {
+		// Gateway breaker open → Keep funds reserved so the payment is retried once the gateway recovers
+		return fmt.Errorf("payment processor: failed to process with gateway: %w", err)
+	}
 	if err != nil {
 		// Gateway failed → Release funds and mark as failed
 		if releaseErr := pps.walletResolver.Release(ctx, payment.UserID, payment.Amount, payment.ID); releaseErr != nil {
 			return fmt.Errorf("payment processor: failed to release funds: %w", releaseErr)
 		}
 
-		if updateErr := pps.paymentResolver.UpdateStatus(ctx, payment.ID, domain.StatusFailed, ""); updateErr != nil {
+		if updateErr := pps.paymentResolver.UpdateStatusForMessage(ctx, payment.ID, domain.StatusFailed, "", messageID, HandlerName); updateErr != nil {
 			return fmt.Errorf("payment processor: failed to update status to failed: %w", updateErr)
 		}
 
 		return nil // Payment failed but handled correctly
 	}
 
-	// Step 3: Gateway succeeded → Confirm funds
+	// Gateway pending → Keep funds reserved until the gateway callback reports the outcome
+	if result.Status == domain.GatewayStatusPending {
+		if err := pps.paymentResolver.UpdateStatusForMessage(ctx, payment.ID, domain.StatusProcessing, result.Ref, messageID, HandlerName); err != nil {
+			return fmt.Errorf("payment processor: failed to update status to processing: %w", err)
+		}
+
+		return nil
+	}
+
+	// Step 3: Gateway succeeded → Record the charge before touching the wallet so a redelivery does not charge again
+	if err := pps.paymentResolver.UpdateStatus(ctx, payment.ID, domain.StatusPendingConfirm, result.Ref); err != nil {
+		return fmt.Errorf("payment processor: failed to update status to pending_confirm: %w", err)
+	}
+
+	return pps.confirm(ctx, payment, result.Ref, messageID)
+}
+
+// confirm confirms the funds of a payment charged by the gateway and marks it as completed
+func (pps *PaymentProcessorService) confirm(ctx context.Context, payment *domain.Payment, gatewayRef string, messageID string) error {
+	// Step 4: Confirm funds
 	if err := pps.walletResolver.Confirm(ctx, payment.UserID, payment.Amount, payment.ID); err != nil {
 		return fmt.Errorf("payment processor: failed to confirm funds: %w", err)
 	}
 
-	// Step 4: Update status to completed
-	if err := pps.paymentResolver.UpdateStatus(ctx, payment.ID, domain.StatusCompleted, gatewayRef); err != nil {
+	// Step 5: Update status to completed
+	if err := pps.paymentResolver.UpdateStatusForMessage(ctx, payment.ID, domain.StatusCompleted, gatewayRef, messageID, HandlerName); err != nil {
 		return fmt.Errorf("payment processor: failed to update status to completed: %w", err)
 	}
 
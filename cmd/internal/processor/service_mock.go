@@ -13,7 +13,7 @@ type MockPaymentProcessorService struct {
 }
 
 // Process mocks the Process method
-func (m *MockPaymentProcessorService) Process(ctx context.Context, payment *domain.Payment) error {
-	args := m.Called(ctx, payment)
+func (m *MockPaymentProcessorService) Process(ctx context.Context, payment *domain.Payment, messageID string) error {
+	args := m.Called(ctx, payment, messageID)
 	return args.Error(0)
 }
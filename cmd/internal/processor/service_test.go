@@ -3,9 +3,13 @@ package processor
 import (
 	"context"
 	"errors"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentstorer"
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/walletclient"
 	"github.com/stretchr/testify/assert"
@@ -18,6 +22,7 @@ func TestNewPaymentProcessorService(t *testing.T) {
 		paymentResolver   PaymentResolver
 		walletResolver    WalletResolver
 		gatewayProcessor  GatewayProcessor
+		paymentLocker     PaymentLocker
 		expectedError     string
 	}{
 		{
@@ -25,6 +30,7 @@ func TestNewPaymentProcessorService(t *testing.T) {
 			paymentResolver:   new(paymentstorer.MockPaymentRepository),
 			walletResolver:    new(walletclient.MockWalletClient),
 			gatewayProcessor:  new(MockGatewayProcessor),
+			paymentLocker:     new(MockPaymentLocker),
 			expectedError:     "",
 		},
 		{
@@ -32,6 +38,7 @@ func TestNewPaymentProcessorService(t *testing.T) {
 			paymentResolver:   nil,
 			walletResolver:    new(walletclient.MockWalletClient),
 			gatewayProcessor:  new(MockGatewayProcessor),
+			paymentLocker:     new(MockPaymentLocker),
 			expectedError:     "payment processor: resolver cannot be nil",
 		},
 		{
@@ -39,6 +46,7 @@ func TestNewPaymentProcessorService(t *testing.T) {
 			paymentResolver:   new(paymentstorer.MockPaymentRepository),
 			walletResolver:    nil,
 			gatewayProcessor:  new(MockGatewayProcessor),
+			paymentLocker:     new(MockPaymentLocker),
 			expectedError:     "payment processor: wallet resolver cannot be nil",
 		},
 		{
@@ -46,8 +54,17 @@ func TestNewPaymentProcessorService(t *testing.T) {
 			paymentResolver:   new(paymentstorer.MockPaymentRepository),
 			walletResolver:    new(walletclient.MockWalletClient),
 			gatewayProcessor:  nil,
+			paymentLocker:     new(MockPaymentLocker),
 			expectedError:     "payment processor: gateway processor cannot be nil",
 		},
+		{
+			name:              "when payment locker is nil it should return error",
+			paymentResolver:   new(paymentstorer.MockPaymentRepository),
+			walletResolver:    new(walletclient.MockWalletClient),
+			gatewayProcessor:  new(MockGatewayProcessor),
+			paymentLocker:     nil,
+			expectedError:     "payment processor: payment locker cannot be nil",
+		},
 	}
 
 	for _, tt := range tests {
@@ -56,7 +73,7 @@ func TestNewPaymentProcessorService(t *testing.T) {
 			// (Dependencies already prepared in test struct)
 
 			// Act
-			result, err := NewPaymentProcessorService(tt.paymentResolver, tt.walletResolver, tt.gatewayProcessor)
+			result, err := NewPaymentProcessorService(tt.paymentResolver, tt.walletResolver, tt.gatewayProcessor, tt.paymentLocker)
 
 			// Assert
 			if tt.expectedError != "" {
@@ -77,15 +94,21 @@ func TestPaymentProcessorService_Process(t *testing.T) {
 		payment                 *domain.Payment
 		mockExistingPayment     *domain.Payment
 		mockGetError            error
+		mockLockError           error
 		mockGatewayRef          string
+		mockGatewayStatus       domain.GatewayStatus
 		mockGatewayError        error
+		mockProcessingError     error
+		shouldCallProcessing    bool
 		mockReleaseError        error
 		mockConfirmError        error
 		mockUpdateStatusError   error
+		mockPendingConfirmError error
 		shouldCallGateway       bool
 		shouldCallRelease       bool
 		shouldCallConfirm       bool
 		shouldCallUpdateStatus  bool
+		shouldCallPendingConfirm bool
 		expectedError           error
 	}{
 		{
@@ -182,6 +205,7 @@ func TestPaymentProcessorService_Process(t *testing.T) {
 			shouldCallRelease:     false,
 			shouldCallConfirm:     true,
 			shouldCallUpdateStatus: true,
+			shouldCallPendingConfirm: true,
 			expectedError:         nil,
 		},
 		{
@@ -227,6 +251,30 @@ func TestPaymentProcessorService_Process(t *testing.T) {
 			shouldCallUpdateStatus: true,
 			expectedError:         nil,
 		},
+		{
+			name: "when gateway is unavailable it should keep funds reserved and return wrapped error",
+			payment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			mockExistingPayment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			mockGetError:          nil,
+			mockGatewayError:      domain.ErrGatewayUnavailable,
+			shouldCallGateway:     true,
+			shouldCallRelease:     false,
+			shouldCallConfirm:     false,
+			shouldCallUpdateStatus: false,
+			expectedError:         errors.New("payment processor: failed to process with gateway: payment gateway unavailable"),
+		},
 		{
 			name: "when gateway processing fails and release funds fails it should return wrapped error",
 			payment: &domain.Payment{
@@ -302,6 +350,7 @@ func TestPaymentProcessorService_Process(t *testing.T) {
 			shouldCallRelease:     false,
 			shouldCallConfirm:     true,
 			shouldCallUpdateStatus: false,
+			shouldCallPendingConfirm: true,
 			expectedError:         errors.New("payment processor: failed to confirm funds: confirm failed"),
 		},
 		{
@@ -329,8 +378,178 @@ func TestPaymentProcessorService_Process(t *testing.T) {
 			shouldCallRelease:     false,
 			shouldCallConfirm:     true,
 			shouldCallUpdateStatus: true,
+			shouldCallPendingConfirm: true,
 			expectedError:         errors.New("payment processor: failed to update status to completed: update failed"),
 		},
+		{
+			name: "when recording pending confirm fails it should not confirm funds and return wrapped error",
+			payment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			mockExistingPayment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			mockGetError:             nil,
+			mockGatewayRef:           "gw_ref_123",
+			mockPendingConfirmError:  errors.New("update failed"),
+			shouldCallGateway:        true,
+			shouldCallRelease:        false,
+			shouldCallConfirm:        false,
+			shouldCallUpdateStatus:   false,
+			shouldCallPendingConfirm: true,
+			expectedError:            errors.New("payment processor: failed to update status to pending_confirm: update failed"),
+		},
+		{
+			name: "when payment is pending confirm it should skip gateway, confirm funds and return no error",
+			payment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			mockExistingPayment: &domain.Payment{
+				ID:         "pay_123",
+				UserID:     "user_123",
+				Amount:     100.50,
+				Currency:   domain.CurrencyUSD,
+				Status:     domain.StatusPendingConfirm,
+				GatewayRef: "gw_ref_123",
+			},
+			mockGetError:           nil,
+			mockGatewayRef:         "gw_ref_123",
+			shouldCallGateway:      false,
+			shouldCallRelease:      false,
+			shouldCallConfirm:      true,
+			shouldCallUpdateStatus: true,
+			expectedError:          nil,
+		},
+		{
+			name: "when payment is pending confirm and confirm funds fails it should skip gateway and return wrapped error",
+			payment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			mockExistingPayment: &domain.Payment{
+				ID:         "pay_123",
+				UserID:     "user_123",
+				Amount:     100.50,
+				Currency:   domain.CurrencyUSD,
+				Status:     domain.StatusPendingConfirm,
+				GatewayRef: "gw_ref_123",
+			},
+			mockGetError:           nil,
+			mockConfirmError:       errors.New("confirm failed"),
+			shouldCallGateway:      false,
+			shouldCallRelease:      false,
+			shouldCallConfirm:      true,
+			shouldCallUpdateStatus: false,
+			expectedError:          errors.New("payment processor: failed to confirm funds: confirm failed"),
+		},
+		{
+			name: "when payment is already processing it should skip processing and return no error",
+			payment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			mockExistingPayment: &domain.Payment{
+				ID:         "pay_123",
+				UserID:     "user_123",
+				Amount:     100.50,
+				Currency:   domain.CurrencyUSD,
+				Status:     domain.StatusProcessing,
+				GatewayRef: "gw_ref_123",
+			},
+			mockGetError:           nil,
+			shouldCallGateway:      false,
+			shouldCallRelease:      false,
+			shouldCallConfirm:      false,
+			shouldCallUpdateStatus: false,
+			expectedError:          nil,
+		},
+		{
+			name: "when gateway answers pending it should keep funds reserved, update status to processing and return no error",
+			payment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			mockExistingPayment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			mockGetError:           nil,
+			mockGatewayRef:         "gw_ref_123",
+			mockGatewayStatus:      domain.GatewayStatusPending,
+			shouldCallGateway:      true,
+			shouldCallProcessing:   true,
+			shouldCallRelease:      false,
+			shouldCallConfirm:      false,
+			shouldCallUpdateStatus: false,
+			expectedError:          nil,
+		},
+		{
+			name: "when gateway answers pending and recording processing fails it should return wrapped error",
+			payment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			mockExistingPayment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			mockGetError:           nil,
+			mockGatewayRef:         "gw_ref_123",
+			mockGatewayStatus:      domain.GatewayStatusPending,
+			mockProcessingError:    errors.New("database error"),
+			shouldCallGateway:      true,
+			shouldCallProcessing:   true,
+			shouldCallRelease:      false,
+			shouldCallConfirm:      false,
+			shouldCallUpdateStatus: false,
+			expectedError:          errors.New("payment processor: failed to update status to processing: database error"),
+		},
+		{
+			name: "when payment lock fails it should not read the payment and return wrapped error",
+			payment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			mockLockError:          errors.New("connection refused"),
+			shouldCallGateway:      false,
+			shouldCallRelease:      false,
+			shouldCallConfirm:      false,
+			shouldCallUpdateStatus: false,
+			expectedError:          errors.New("payment processor: failed to lock payment: connection refused"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -339,11 +558,27 @@ func TestPaymentProcessorService_Process(t *testing.T) {
 			mockPaymentResolver := new(paymentstorer.MockPaymentRepository)
 			mockWalletResolver := new(walletclient.MockWalletClient)
 			mockGatewayProcessor := new(MockGatewayProcessor)
+			mockPaymentLocker := new(MockPaymentLocker)
+			mockUnlocker := new(paymentlocker.MockUnlocker)
 
-			mockPaymentResolver.On("GetByID", mock.Anything, tt.payment.ID).Return(tt.mockExistingPayment, tt.mockGetError)
+			if tt.mockLockError != nil {
+				mockPaymentLocker.On("Lock", mock.Anything, tt.payment.ID).Return(nil, tt.mockLockError)
+			} else {
+				mockPaymentLocker.On("Lock", mock.Anything, tt.payment.ID).Return(mockUnlocker, nil)
+				mockUnlocker.On("Unlock", mock.Anything).Return(nil)
+				mockPaymentResolver.On("GetByID", mock.Anything, tt.payment.ID).Return(tt.mockExistingPayment, tt.mockGetError)
+			}
 
 			if tt.shouldCallGateway {
-				mockGatewayProcessor.On("Process", mock.Anything, tt.payment.ID, tt.payment.Amount).Return(tt.mockGatewayRef, tt.mockGatewayError)
+				var result *domain.GatewayResult
+				if tt.mockGatewayError == nil {
+					status := tt.mockGatewayStatus
+					if status == "" {
+						status = domain.GatewayStatusSucceeded
+					}
+					result = &domain.GatewayResult{Ref: tt.mockGatewayRef, Status: status}
+				}
+				mockGatewayProcessor.On("Process", mock.Anything, tt.payment.ID, tt.payment.Amount).Return(result, tt.mockGatewayError)
 			}
 
 			if tt.shouldCallRelease {
@@ -354,11 +589,19 @@ func TestPaymentProcessorService_Process(t *testing.T) {
 				mockWalletResolver.On("Confirm", mock.Anything, tt.payment.UserID, tt.payment.Amount, tt.payment.ID).Return(tt.mockConfirmError)
 			}
 
+			if tt.shouldCallProcessing {
+				mockPaymentResolver.On("UpdateStatusForMessage", mock.Anything, tt.payment.ID, domain.StatusProcessing, tt.mockGatewayRef, "msg_123", HandlerName).Return(tt.mockProcessingError)
+			}
+
+			if tt.shouldCallPendingConfirm {
+				mockPaymentResolver.On("UpdateStatus", mock.Anything, tt.payment.ID, domain.StatusPendingConfirm, tt.mockGatewayRef).Return(tt.mockPendingConfirmError)
+			}
+
 			if tt.shouldCallUpdateStatus {
 				if tt.mockGatewayError != nil {
-					mockPaymentResolver.On("UpdateStatus", mock.Anything, tt.payment.ID, domain.StatusFailed, "").Return(tt.mockUpdateStatusError)
+					mockPaymentResolver.On("UpdateStatusForMessage", mock.Anything, tt.payment.ID, domain.StatusFailed, "", "msg_123", HandlerName).Return(tt.mockUpdateStatusError)
 				} else {
-					mockPaymentResolver.On("UpdateStatus", mock.Anything, tt.payment.ID, domain.StatusCompleted, tt.mockGatewayRef).Return(tt.mockUpdateStatusError)
+					mockPaymentResolver.On("UpdateStatusForMessage", mock.Anything, tt.payment.ID, domain.StatusCompleted, tt.mockGatewayRef, "msg_123", HandlerName).Return(tt.mockUpdateStatusError)
 				}
 			}
 
@@ -366,10 +609,11 @@ func TestPaymentProcessorService_Process(t *testing.T) {
 				paymentResolver:  mockPaymentResolver,
 				walletResolver:   mockWalletResolver,
 				gatewayProcessor: mockGatewayProcessor,
+				paymentLocker:    mockPaymentLocker,
 			}
 
 			// Act
-			err := service.Process(context.Background(), tt.payment)
+			err := service.Process(context.Background(), tt.payment, "msg_123")
 
 			// Assert
 			if tt.expectedError != nil {
@@ -382,7 +626,120 @@ func TestPaymentProcessorService_Process(t *testing.T) {
 			mockPaymentResolver.AssertExpectations(t)
 			mockWalletResolver.AssertExpectations(t)
 			mockGatewayProcessor.AssertExpectations(t)
+			mockPaymentLocker.AssertExpectations(t)
+			mockUnlocker.AssertExpectations(t)
 		})
 	}
 }
 
+
+func TestPaymentProcessorService_Process_ConcurrentDeliveries(t *testing.T) {
+	// Arrange
+	payment := &domain.Payment{
+		ID:       "pay_123",
+		UserID:   "user_123",
+		Amount:   100.50,
+		Currency: domain.CurrencyUSD,
+		Status:   domain.StatusReserved,
+	}
+
+	paymentResolver := &memoryPaymentResolver{payment: *payment}
+	mockWalletResolver := new(walletclient.MockWalletClient)
+	mockGatewayProcessor := new(MockGatewayProcessor)
+
+	// The gateway is slow enough for every delivery to reach it if they were not serialized
+	mockGatewayProcessor.On("Process", mock.Anything, payment.ID, payment.Amount).
+		Run(func(mock.Arguments) { time.Sleep(20 * time.Millisecond) }).
+		Return(&domain.GatewayResult{Ref: "gw_ref_123", Status: domain.GatewayStatusSucceeded}, nil)
+	mockWalletResolver.On("Confirm", mock.Anything, payment.UserID, payment.Amount, payment.ID).Return(nil)
+
+	service, err := NewPaymentProcessorService(paymentResolver, mockWalletResolver, mockGatewayProcessor, &memoryPaymentLocker{})
+	assert.NoError(t, err)
+
+	// The original message and its recovery republish, plus redeliveries, handled by different workers
+	const deliveries = 3
+	start := make(chan struct{})
+	errs := make(chan error, deliveries)
+	var wg sync.WaitGroup
+	for i := 0; i < deliveries; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs <- service.Process(context.Background(), payment, "msg_"+strconv.Itoa(i))
+		}()
+	}
+
+	// Act
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	// Assert
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	mockGatewayProcessor.AssertNumberOfCalls(t, "Process", 1)
+	mockWalletResolver.AssertNumberOfCalls(t, "Confirm", 1)
+	assert.Equal(t, domain.StatusCompleted, paymentResolver.payment.Status)
+	assert.Equal(t, "gw_ref_123", paymentResolver.payment.GatewayRef)
+}
+
+// memoryPaymentResolver keeps a single payment in memory, recording its status like the read model
+type memoryPaymentResolver struct {
+	mu      sync.Mutex
+	payment domain.Payment
+}
+
+func (r *memoryPaymentResolver) GetByID(ctx context.Context, paymentID string) (*domain.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	payment := r.payment
+	return &payment, nil
+}
+
+func (r *memoryPaymentResolver) UpdateStatus(ctx context.Context, paymentID string, status domain.Status, gatewayRef string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.payment.Status = status
+	r.payment.GatewayRef = gatewayRef
+	return nil
+}
+
+func (r *memoryPaymentResolver) UpdateStatusForMessage(ctx context.Context, paymentID string, status domain.Status, gatewayRef string, messageID string, handler string) error {
+	return r.UpdateStatus(ctx, paymentID, status, gatewayRef)
+}
+
+// memoryPaymentLocker locks payments with an in-process mutex per payment, like the advisory locks do across processes
+type memoryPaymentLocker struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func (l *memoryPaymentLocker) Lock(ctx context.Context, paymentID string) (paymentlocker.Unlocker, error) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*sync.Mutex)
+	}
+	m, ok := l.locks[paymentID]
+	if !ok {
+		m = &sync.Mutex{}
+		l.locks[paymentID] = m
+	}
+	l.mu.Unlock()
+
+	m.Lock()
+	return memoryUnlocker{m}, nil
+}
+
+// memoryUnlocker releases a lock taken by memoryPaymentLocker
+type memoryUnlocker struct {
+	m *sync.Mutex
+}
+
+func (u memoryUnlocker) Unlock(ctx context.Context) error {
+	u.m.Unlock()
+	return nil
+}
@@ -0,0 +1,33 @@
+package ratelimiter
+
+import "fmt"
+
+// Build creates a new Handler with all dependencies wired up
+// It builds a new handler and returns an error if the builder fails
+func Build(db BucketDB, backend Backend, rules []Rule) (*Handler, error) {
+	var bs BucketStore
+	switch backend {
+	case BackendMemory:
+		bs = NewMemoryBucketRepository()
+	case BackendPostgres:
+		pr, err := NewPostgresBucketRepository(db)
+		if err != nil {
+			return nil, err
+		}
+		bs = pr
+	default:
+		return nil, fmt.Errorf("rate limiter: unknown backend %q", backend)
+	}
+
+	rls, err := NewRateLimiterService(bs, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := NewHandler(rls)
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
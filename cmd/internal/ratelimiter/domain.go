@@ -0,0 +1,174 @@
+package ratelimiter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Backend represents where the token buckets are stored
+type Backend string
+
+const (
+	BackendMemory   Backend = "memory"   // Buckets are kept by each replica, limits apply per replica
+	BackendPostgres Backend = "postgres" // Buckets are shared by all replicas
+)
+
+// KeyBy represents the identity a rule counts requests by
+type KeyBy string
+
+const (
+	KeyByPrincipal KeyBy = "principal" // API key or token the caller authenticated with
+	KeyByUser      KeyBy = "user"      // User the caller acts as, shared by all of its credentials
+	KeyByIP        KeyBy = "ip"        // Client IP address
+)
+
+// anyRoute is the route of the rule applied to routes without a rule of their own
+const anyRoute = "*"
+
+// Rule defines the token bucket of a route
+// The bucket holds up to Limit tokens and is refilled at Limit tokens per Period
+type Rule struct {
+	Route  string        // Method and route pattern, e.g. "POST /api/v1/payments", or "*" for any route
+	KeyBy  KeyBy         // Identity requests are counted by
+	Limit  int           // Bucket capacity, the maximum burst of requests
+	Period time.Duration // Time in which an empty bucket is refilled
+}
+
+// Validate validates the rule
+// It returns an error if the rule is invalid
+func (r *Rule) Validate() error {
+	if r.Route == "" {
+		return errors.New("route is required")
+	}
+	if r.KeyBy != KeyByPrincipal && r.KeyBy != KeyByUser && r.KeyBy != KeyByIP {
+		return fmt.Errorf("invalid key %q", r.KeyBy)
+	}
+	if r.Limit <= 0 {
+		return errors.New("limit must be greater than 0")
+	}
+	if r.Period <= 0 {
+		return errors.New("period must be greater than 0")
+	}
+	return nil
+}
+
+// rate returns the tokens added to the bucket per second
+func (r *Rule) rate() float64 {
+	return float64(r.Limit) / r.Period.Seconds()
+}
+
+// ParseRules parses a comma separated list of rules in the form "<route>=<key>:<limit>/<period>"
+// e.g. "POST /api/v1/payments=user:10/1m,*=principal:300/1m"
+func ParseRules(spec string) ([]Rule, error) {
+	rules := []Rule{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, limit, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("rule %q: missing limit", entry)
+		}
+		key, limit, ok := strings.Cut(limit, ":")
+		if !ok {
+			return nil, fmt.Errorf("rule %q: missing key", entry)
+		}
+		count, period, ok := strings.Cut(limit, "/")
+		if !ok {
+			return nil, fmt.Errorf("rule %q: missing period", entry)
+		}
+
+		rule := Rule{Route: strings.Join(strings.Fields(route), " "), KeyBy: KeyBy(strings.TrimSpace(key))}
+
+		var err error
+		if rule.Limit, err = strconv.Atoi(strings.TrimSpace(count)); err != nil {
+			return nil, fmt.Errorf("rule %q: invalid limit: %w", entry, err)
+		}
+		if rule.Period, err = time.ParseDuration(strings.TrimSpace(period)); err != nil {
+			return nil, fmt.Errorf("rule %q: invalid period: %w", entry, err)
+		}
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("rule %q: %w", entry, err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// Request represents the request being rate limited
+type Request struct {
+	Method   string // HTTP method
+	Route    string // Route pattern the request matched
+	ClientIP string // Client IP address
+}
+
+// Decision represents the outcome of taking a token from a bucket
+type Decision struct {
+	Allowed    bool          // Whether a token was available
+	Limit      int           // Bucket capacity
+	Remaining  int           // Whole tokens left in the bucket
+	Period     time.Duration // Time in which an empty bucket is refilled
+	ResetAfter time.Duration // Time until the bucket is full again
+	RetryAfter time.Duration // Time until a token is available, zero when allowed
+}
+
+// Bucket represents the state of a token bucket
+type Bucket struct {
+	Tokens    float64   // Tokens left, fractions accumulate until a whole token is available
+	UpdatedAt time.Time // Last time the bucket was refilled
+}
+
+// NewBucket creates a full bucket for the rule
+func NewBucket(rule Rule, now time.Time) *Bucket {
+	return &Bucket{
+		Tokens:    float64(rule.Limit),
+		UpdatedAt: now,
+	}
+}
+
+// Take refills the bucket for the time elapsed since its last update and takes one token if available
+// It returns the decision with the remaining tokens and when to retry
+func (b *Bucket) Take(rule Rule, now time.Time) *Decision {
+	rate := rule.rate()
+
+	// Clocks of different replicas can be slightly apart, a bucket updated in the future is not refilled
+	if elapsed := now.Sub(b.UpdatedAt); elapsed > 0 {
+		b.Tokens = math.Min(float64(rule.Limit), b.Tokens+elapsed.Seconds()*rate)
+		b.UpdatedAt = now
+	}
+
+	decision := &Decision{
+		Limit:  rule.Limit,
+		Period: rule.Period,
+	}
+
+	if b.Tokens >= 1 {
+		b.Tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = seconds((1 - b.Tokens) / rate)
+	}
+
+	decision.Remaining = int(math.Floor(b.Tokens))
+	decision.ResetAfter = b.FullAt(rule).Sub(now)
+
+	return decision
+}
+
+// FullAt returns when the bucket is full again, after which it can be forgotten
+func (b *Bucket) FullAt(rule Rule) time.Time {
+	return b.UpdatedAt.Add(seconds((float64(rule.Limit) - b.Tokens) / rule.rate()))
+}
+
+// seconds converts fractional seconds into a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,231 @@
+package ratelimiter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRule_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		rule          Rule
+		expectedError error
+	}{
+		{
+			name:          "when rule is valid it should return no error",
+			rule:          Rule{Route: "POST /api/v1/payments", KeyBy: KeyByUser, Limit: 10, Period: time.Minute},
+			expectedError: nil,
+		},
+		{
+			name:          "when route is empty it should return error",
+			rule:          Rule{KeyBy: KeyByUser, Limit: 10, Period: time.Minute},
+			expectedError: errors.New("route is required"),
+		},
+		{
+			name:          "when key is unknown it should return error",
+			rule:          Rule{Route: "*", KeyBy: KeyBy("country"), Limit: 10, Period: time.Minute},
+			expectedError: errors.New(`invalid key "country"`),
+		},
+		{
+			name:          "when limit is zero it should return error",
+			rule:          Rule{Route: "*", KeyBy: KeyByIP, Limit: 0, Period: time.Minute},
+			expectedError: errors.New("limit must be greater than 0"),
+		},
+		{
+			name:          "when period is zero it should return error",
+			rule:          Rule{Route: "*", KeyBy: KeyByIP, Limit: 10, Period: 0},
+			expectedError: errors.New("period must be greater than 0"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Rule already prepared in test struct)
+
+			// Act
+			err := tt.rule.Validate()
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name          string
+		spec          string
+		expectedRules []Rule
+		expectedError error
+	}{
+		{
+			name: "when spec has several rules it should return them in order and no error",
+			spec: "POST /api/v1/payments=user:10/1m, *=principal:300/1m",
+			expectedRules: []Rule{
+				{Route: "POST /api/v1/payments", KeyBy: KeyByUser, Limit: 10, Period: time.Minute},
+				{Route: "*", KeyBy: KeyByPrincipal, Limit: 300, Period: time.Minute},
+			},
+			expectedError: nil,
+		},
+		{
+			name:          "when spec is empty it should return no rules and no error",
+			spec:          "",
+			expectedRules: []Rule{},
+			expectedError: nil,
+		},
+		{
+			name:          "when rule has no limit it should return error",
+			spec:          "GET /api/v1/payments/:id",
+			expectedError: errors.New(`rule "GET /api/v1/payments/:id": missing limit`),
+		},
+		{
+			name:          "when rule has no key it should return error",
+			spec:          "*=300/1m",
+			expectedError: errors.New(`rule "*=300/1m": missing key`),
+		},
+		{
+			name:          "when rule has no period it should return error",
+			spec:          "*=ip:300",
+			expectedError: errors.New(`rule "*=ip:300": missing period`),
+		},
+		{
+			name:          "when limit is not a number it should return error",
+			spec:          "*=ip:many/1m",
+			expectedError: errors.New(`rule "*=ip:many/1m": invalid limit: strconv.Atoi: parsing "many": invalid syntax`),
+		},
+		{
+			name:          "when period is not a duration it should return error",
+			spec:          "*=ip:300/minute",
+			expectedError: errors.New(`rule "*=ip:300/minute": invalid period: time: invalid duration "minute"`),
+		},
+		{
+			name:          "when rule is invalid it should return error",
+			spec:          "*=ip:0/1m",
+			expectedError: errors.New(`rule "*=ip:0/1m": limit must be greater than 0`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Spec already prepared in test struct)
+
+			// Act
+			result, err := ParseRules(tt.spec)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedRules, result)
+			}
+		})
+	}
+}
+
+func TestBucket_Take(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	rule := Rule{Route: "*", KeyBy: KeyByIP, Limit: 10, Period: 10 * time.Second}
+
+	tests := []struct {
+		name             string
+		bucket           Bucket
+		now              time.Time
+		expectedDecision *Decision
+		expectedTokens   float64
+	}{
+		{
+			name:   "when bucket is full it should take a token and allow",
+			bucket: Bucket{Tokens: 10, UpdatedAt: fixedTime},
+			now:    fixedTime,
+			expectedDecision: &Decision{
+				Allowed:    true,
+				Limit:      10,
+				Remaining:  9,
+				Period:     10 * time.Second,
+				ResetAfter: time.Second,
+			},
+			expectedTokens: 9,
+		},
+		{
+			name:   "when bucket is empty it should deny with the time until the next token",
+			bucket: Bucket{Tokens: 0.5, UpdatedAt: fixedTime},
+			now:    fixedTime,
+			expectedDecision: &Decision{
+				Allowed:    false,
+				Limit:      10,
+				Remaining:  0,
+				Period:     10 * time.Second,
+				ResetAfter: 9500 * time.Millisecond,
+				RetryAfter: 500 * time.Millisecond,
+			},
+			expectedTokens: 0.5,
+		},
+		{
+			name:   "when time elapsed since the last update it should refill before taking",
+			bucket: Bucket{Tokens: 0, UpdatedAt: fixedTime},
+			now:    fixedTime.Add(3 * time.Second),
+			expectedDecision: &Decision{
+				Allowed:    true,
+				Limit:      10,
+				Remaining:  2,
+				Period:     10 * time.Second,
+				ResetAfter: 8 * time.Second,
+			},
+			expectedTokens: 2,
+		},
+		{
+			name:   "when refill exceeds the limit it should cap the bucket at the limit",
+			bucket: Bucket{Tokens: 5, UpdatedAt: fixedTime},
+			now:    fixedTime.Add(time.Hour),
+			expectedDecision: &Decision{
+				Allowed:    true,
+				Limit:      10,
+				Remaining:  9,
+				Period:     10 * time.Second,
+				ResetAfter: time.Second,
+			},
+			expectedTokens: 9,
+		},
+		{
+			name:   "when bucket was updated in the future it should not refill",
+			bucket: Bucket{Tokens: 0, UpdatedAt: fixedTime.Add(time.Second)},
+			now:    fixedTime,
+			expectedDecision: &Decision{
+				Allowed:    false,
+				Limit:      10,
+				Remaining:  0,
+				Period:     10 * time.Second,
+				ResetAfter: 11 * time.Second,
+				RetryAfter: time.Second,
+			},
+			expectedTokens: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			bucket := tt.bucket
+
+			// Act
+			result := bucket.Take(rule, tt.now)
+
+			// Assert
+			assert.Equal(t, tt.expectedDecision, result)
+			assert.InDelta(t, tt.expectedTokens, bucket.Tokens, 1e-9)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimiter defines the interface for rate limiting business logic
+type RateLimiter interface {
+	Limit(ctx context.Context, req *Request) (*Decision, error)
+	Prune(ctx context.Context) (int64, error)
+}
+
+// Handler provides the rate limiting middleware and the bucket cleanup runs
+type Handler struct {
+	rateLimiter RateLimiter
+}
+
+// NewHandler creates a new rate limiter handler
+// It returns a new handler and an error if the rate limiter is nil
+func NewHandler(rl RateLimiter) (*Handler, error) {
+	if rl == nil {
+		return nil, errors.New("rate limiter handler: rate limiter cannot be nil")
+	}
+
+	return &Handler{
+		rateLimiter: rl,
+	}, nil
+}
+
+// Limit rate limits the request with the rule of its route
+// It sets the RateLimit-* headers and aborts with 429 and Retry-After when the bucket is empty
+// Requests are allowed if the bucket store fails, so the limiter never takes the API down
+func (h *Handler) Limit(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	decision, err := h.rateLimiter.Limit(ctx, &Request{
+		Method:   c.Request.Method,
+		Route:    c.FullPath(),
+		ClientIP: c.ClientIP(),
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Rate limiter unavailable, allowing request", "error", err)
+		c.Next()
+		return
+	}
+	if decision == nil {
+		c.Next()
+		return
+	}
+
+	c.Header("RateLimit-Limit", strconv.Itoa(decision.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.ResetAfter)))
+	c.Header("RateLimit-Policy", strconv.Itoa(decision.Limit)+";w="+strconv.Itoa(ceilSeconds(decision.Period)))
+
+	if !decision.Allowed {
+		retryAfter := strconv.Itoa(ceilSeconds(decision.RetryAfter))
+		c.Header("Retry-After", retryAfter)
+		problem.Respond(c, problem.New(problem.CodeRateLimited, "rate limit exceeded, retry in "+retryAfter+" seconds"))
+		return
+	}
+
+	c.Next()
+}
+
+// Run deletes the buckets that are full again
+// It returns an error if the buckets cannot be pruned
+func (h *Handler) Run(ctx context.Context) error {
+	deleted, err := h.rateLimiter.Prune(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to prune rate limit buckets", "error", err)
+		return err
+	}
+
+	slog.DebugContext(ctx, "Rate limit buckets pruned", "deleted", deleted)
+	return nil
+}
+
+// ceilSeconds rounds a duration up to whole seconds, as the headers require
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,24 @@
+package ratelimiter
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHandler is a mock implementation of Handler
+type MockHandler struct {
+	mock.Mock
+}
+
+// Limit rate limits the request
+func (m *MockHandler) Limit(c *gin.Context) {
+	m.Called(c)
+}
+
+// Run deletes the buckets that are full again
+func (m *MockHandler) Run(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
@@ -0,0 +1,214 @@
+package ratelimiter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		rateLimiter   RateLimiter
+		expectedError string
+	}{
+		{
+			name:          "when rate limiter is provided it should create handler successfully and no error",
+			rateLimiter:   new(MockRateLimiterService),
+			expectedError: "",
+		},
+		{
+			name:          "when rate limiter is nil it should return error",
+			rateLimiter:   nil,
+			expectedError: "rate limiter handler: rate limiter cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Rate limiter already prepared in test struct)
+
+			// Act
+			result, err := NewHandler(tt.rateLimiter)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestHandler_Limit(t *testing.T) {
+	tests := []struct {
+		name               string
+		mockDecision       *Decision
+		mockError          error
+		expectedStatusCode int
+		expectedMessage    string
+		expectedHeaders    map[string]string
+	}{
+		{
+			name: "when token is available it should set the rate limit headers and continue",
+			mockDecision: &Decision{
+				Allowed:    true,
+				Limit:      10,
+				Remaining:  9,
+				Period:     time.Minute,
+				ResetAfter: 6 * time.Second,
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "ok",
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "9",
+				"RateLimit-Reset":     "6",
+				"RateLimit-Policy":    "10;w=60",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name: "when bucket is empty it should return 429 with retry after",
+			mockDecision: &Decision{
+				Allowed:    false,
+				Limit:      10,
+				Remaining:  0,
+				Period:     time.Minute,
+				ResetAfter: 59500 * time.Millisecond,
+				RetryAfter: 5500 * time.Millisecond,
+			},
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedMessage:    "rate limit exceeded, retry in 6 seconds",
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "60",
+				"Retry-After":         "6",
+			},
+		},
+		{
+			name:               "when no rule applies it should continue without headers",
+			mockDecision:       nil,
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "ok",
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit": "",
+			},
+		},
+		{
+			name:               "when rate limiter fails it should allow the request",
+			mockError:          errors.New("connection refused"),
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "ok",
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockLimiter := new(MockRateLimiterService)
+			mockLimiter.On("Limit", mock.Anything, &Request{
+				Method:   http.MethodPost,
+				Route:    "/api/v1/payments",
+				ClientIP: "192.0.2.1",
+			}).Return(tt.mockDecision, tt.mockError)
+
+			handler := &Handler{rateLimiter: mockLimiter}
+
+			router := gin.New()
+			router.POST("/api/v1/payments", handler.Limit, func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "ok"})
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/payments", nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			router.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if w.Code >= http.StatusBadRequest {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedMessage, response["detail"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, response["message"])
+			}
+
+			for header, value := range tt.expectedHeaders {
+				assert.Equal(t, value, w.Header().Get(header), header)
+			}
+
+			mockLimiter.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_Run(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockError     error
+		expectedError error
+	}{
+		{
+			name:          "when buckets are pruned it should return no error",
+			mockError:     nil,
+			expectedError: nil,
+		},
+		{
+			name:          "when prune fails it should return error",
+			mockError:     errors.New("rate limiter: prune buckets: connection refused"),
+			expectedError: errors.New("rate limiter: prune buckets: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockLimiter := new(MockRateLimiterService)
+			mockLimiter.On("Prune", mock.Anything).Return(int64(2), tt.mockError)
+
+			handler := &Handler{rateLimiter: mockLimiter}
+
+			// Act
+			err := handler.Run(context.Background())
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockLimiter.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package ratelimiter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval is how often the memory backend forgets full buckets
+const memorySweepInterval = time.Minute
+
+// memoryBucket is a bucket kept in memory with the time it is full again
+type memoryBucket struct {
+	bucket Bucket
+	fullAt time.Time
+}
+
+// MemoryBucketRepository stores the token buckets in memory
+// Each replica has its own buckets, so the limits apply per replica
+type MemoryBucketRepository struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+// NewMemoryBucketRepository creates a new MemoryBucketRepository
+func NewMemoryBucketRepository() *MemoryBucketRepository {
+	return &MemoryBucketRepository{
+		buckets: map[string]*memoryBucket{},
+	}
+}
+
+// Take takes a token from the bucket of the key, creating a full bucket if there is none
+func (r *MemoryBucketRepository) Take(ctx context.Context, key string, rule Rule, now time.Time) (*Decision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.Sub(r.lastSweep) >= memorySweepInterval {
+		r.sweep(now)
+	}
+
+	stored, ok := r.buckets[key]
+	if !ok {
+		stored = &memoryBucket{bucket: *NewBucket(rule, now)}
+		r.buckets[key] = stored
+	}
+
+	decision := stored.bucket.Take(rule, now)
+	stored.fullAt = stored.bucket.FullAt(rule)
+
+	return decision, nil
+}
+
+// Prune forgets the buckets that are full again, they are recreated full on the next request
+func (r *MemoryBucketRepository) Prune(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.sweep(now), nil
+}
+
+// sweep deletes the full buckets, the caller must hold the lock
+func (r *MemoryBucketRepository) sweep(now time.Time) int64 {
+	var deleted int64
+	for key, stored := range r.buckets {
+		if !now.Before(stored.fullAt) {
+			delete(r.buckets, key)
+			deleted++
+		}
+	}
+	r.lastSweep = now
+	return deleted
+}
+
+// BucketDB defines the database operations required by PostgresBucketRepository
+type BucketDB interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error
+}
+
+// PostgresBucketRepository stores the token buckets in Postgres
+// All replicas share the buckets, taking a token locks the row of the bucket
+type PostgresBucketRepository struct {
+	db BucketDB
+}
+
+// NewPostgresBucketRepository creates a new PostgresBucketRepository
+// It returns a new PostgresBucketRepository and an error if the database is nil
+func NewPostgresBucketRepository(db BucketDB) (*PostgresBucketRepository, error) {
+	if db == nil {
+		return nil, errors.New("bucket repository: database cannot be nil")
+	}
+
+	return &PostgresBucketRepository{db: db}, nil
+}
+
+// Take takes a token from the bucket of the key, creating a full bucket if there is none
+func (r *PostgresBucketRepository) Take(ctx context.Context, key string, rule Rule, now time.Time) (*Decision, error) {
+	var decision *Decision
+
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		insertQuery := `
+			INSERT INTO rate_limit_buckets (key, tokens, updated_at, full_at)
+			VALUES ($1, $2, $3, $3)
+			ON CONFLICT (key) DO NOTHING
+		`
+		if _, err := tx.ExecContext(ctx, insertQuery, key, float64(rule.Limit), now); err != nil {
+			return fmt.Errorf("insert bucket: %w", err)
+		}
+
+		selectQuery := `
+			SELECT tokens, updated_at
+			FROM rate_limit_buckets
+			WHERE key = $1
+			FOR UPDATE
+		`
+		var bucket Bucket
+		if err := tx.QueryRowContext(ctx, selectQuery, key).Scan(&bucket.Tokens, &bucket.UpdatedAt); err != nil {
+			return fmt.Errorf("lock bucket: %w", err)
+		}
+
+		decision = bucket.Take(rule, now)
+
+		updateQuery := `
+			UPDATE rate_limit_buckets
+			SET tokens = $2, updated_at = $3, full_at = $4
+			WHERE key = $1
+		`
+		if _, err := tx.ExecContext(ctx, updateQuery, key, bucket.Tokens, bucket.UpdatedAt, bucket.FullAt(rule)); err != nil {
+			return fmt.Errorf("update bucket: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("bucket repository: take: %w", err)
+	}
+
+	return decision, nil
+}
+
+// Prune deletes the buckets that are full again, they are recreated full on the next request
+func (r *PostgresBucketRepository) Prune(ctx context.Context, now time.Time) (int64, error) {
+	query := `
+		DELETE FROM rate_limit_buckets
+		WHERE full_at <= $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("bucket repository: prune: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("bucket repository: prune: rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
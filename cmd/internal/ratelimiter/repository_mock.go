@@ -0,0 +1,28 @@
+package ratelimiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockBucketRepository is a mock implementation of a bucket repository
+type MockBucketRepository struct {
+	mock.Mock
+}
+
+// Take takes a token from the bucket of the key
+func (m *MockBucketRepository) Take(ctx context.Context, key string, rule Rule, now time.Time) (*Decision, error) {
+	args := m.Called(ctx, key, rule, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Decision), args.Error(1)
+}
+
+// Prune deletes the buckets that are full again
+func (m *MockBucketRepository) Prune(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
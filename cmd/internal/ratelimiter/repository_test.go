@@ -0,0 +1,228 @@
+package ratelimiter
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMemoryBucketRepository_Take(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	rule := Rule{Route: "*", KeyBy: KeyByIP, Limit: 2, Period: time.Minute}
+
+	tests := []struct {
+		name              string
+		takes             int
+		key               string
+		expectedAllowed   bool
+		expectedRemaining int
+	}{
+		{
+			name:              "when key has no bucket it should start full and allow",
+			takes:             0,
+			key:               "ip:10.0.0.1",
+			expectedAllowed:   true,
+			expectedRemaining: 1,
+		},
+		{
+			name:              "when bucket is exhausted it should deny",
+			takes:             2,
+			key:               "ip:10.0.0.1",
+			expectedAllowed:   false,
+			expectedRemaining: 0,
+		},
+		{
+			name:              "when another key is exhausted it should allow",
+			takes:             2,
+			key:               "ip:10.0.0.2",
+			expectedAllowed:   true,
+			expectedRemaining: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := NewMemoryBucketRepository()
+			for i := 0; i < tt.takes; i++ {
+				_, _ = repo.Take(context.Background(), "ip:10.0.0.1", rule, fixedTime)
+			}
+
+			// Act
+			result, err := repo.Take(context.Background(), tt.key, rule, fixedTime)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedAllowed, result.Allowed)
+			assert.Equal(t, tt.expectedRemaining, result.Remaining)
+		})
+	}
+}
+
+func TestMemoryBucketRepository_Prune(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	rule := Rule{Route: "*", KeyBy: KeyByIP, Limit: 2, Period: time.Minute}
+
+	tests := []struct {
+		name            string
+		now             time.Time
+		expectedDeleted int64
+	}{
+		{
+			name:            "when buckets are not full yet it should keep them",
+			now:             fixedTime.Add(10 * time.Second),
+			expectedDeleted: 0,
+		},
+		{
+			name:            "when buckets are full again it should delete them",
+			now:             fixedTime.Add(time.Minute),
+			expectedDeleted: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := NewMemoryBucketRepository()
+			_, _ = repo.Take(context.Background(), "ip:10.0.0.1", rule, fixedTime)
+			_, _ = repo.Take(context.Background(), "ip:10.0.0.2", rule, fixedTime)
+
+			// Act
+			result, err := repo.Prune(context.Background(), tt.now)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedDeleted, result)
+		})
+	}
+}
+
+func TestNewPostgresBucketRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            BucketDB
+		expectedError string
+	}{
+		{
+			name:          "when database is provided it should create repository successfully and no error",
+			db:            new(database.MockDB),
+			expectedError: "",
+		},
+		{
+			name:          "when database is nil it should return error",
+			db:            nil,
+			expectedError: "bucket repository: database cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Database already prepared in test struct)
+
+			// Act
+			result, err := NewPostgresBucketRepository(tt.db)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestPostgresBucketRepository_Take(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	rule := Rule{Route: "*", KeyBy: KeyByIP, Limit: 2, Period: time.Minute}
+
+	tests := []struct {
+		name          string
+		mockTxError   error
+		expectedError error
+	}{
+		{
+			name:          "when transaction fails it should return wrapped error",
+			mockTxError:   errors.New("connection refused"),
+			expectedError: errors.New("bucket repository: take: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockDB.On("WithTransaction", mock.Anything, mock.Anything).Return(tt.mockTxError)
+
+			repo := &PostgresBucketRepository{db: mockDB}
+
+			// Act
+			result, err := repo.Take(context.Background(), "ip:10.0.0.1", rule, fixedTime)
+
+			// Assert
+			assert.Error(t, err)
+			assert.Equal(t, tt.expectedError.Error(), err.Error())
+			assert.Nil(t, result)
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPostgresBucketRepository_Prune(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		mockResult      driver.Result
+		mockError       error
+		expectedDeleted int64
+		expectedError   error
+	}{
+		{
+			name:            "when full buckets exist it should delete them and return the count",
+			mockResult:      driver.RowsAffected(3),
+			expectedDeleted: 3,
+			expectedError:   nil,
+		},
+		{
+			name:          "when delete fails it should return wrapped error",
+			mockError:     errors.New("connection refused"),
+			expectedError: errors.New("bucket repository: prune: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockDB.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(tt.mockResult, tt.mockError)
+
+			repo := &PostgresBucketRepository{db: mockDB}
+
+			// Act
+			result, err := repo.Prune(context.Background(), fixedTime)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedDeleted, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
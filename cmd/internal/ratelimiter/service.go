@@ -0,0 +1,93 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+)
+
+// BucketStore interface for storing token buckets
+type BucketStore interface {
+	Take(ctx context.Context, key string, rule Rule, now time.Time) (*Decision, error)
+	Prune(ctx context.Context, now time.Time) (int64, error)
+}
+
+// RateLimiterService is a service for rate limiting requests with token buckets
+type RateLimiterService struct {
+	bucketStore BucketStore
+	rules       map[string]Rule // Rules by route
+	now         func() time.Time
+}
+
+// NewRateLimiterService creates a new RateLimiterService
+// It returns a new RateLimiterService and an error if the bucket store is nil or a rule is invalid
+func NewRateLimiterService(bs BucketStore, rules []Rule) (*RateLimiterService, error) {
+	if bs == nil {
+		return nil, errors.New("rate limiter: bucket store cannot be nil")
+	}
+
+	byRoute := make(map[string]Rule, len(rules))
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("rate limiter: invalid rule %q: %w", rule.Route, err)
+		}
+		if _, ok := byRoute[rule.Route]; ok {
+			return nil, fmt.Errorf("rate limiter: duplicate rule %q", rule.Route)
+		}
+		byRoute[rule.Route] = rule
+	}
+
+	return &RateLimiterService{
+		bucketStore: bs,
+		rules:       byRoute,
+		now:         time.Now,
+	}, nil
+}
+
+// Limit takes a token from the bucket of the caller for the route of the request
+// It returns nil and no error if no rule applies to the route
+func (rls *RateLimiterService) Limit(ctx context.Context, req *Request) (*Decision, error) {
+	rule, ok := rls.rules[req.Method+" "+req.Route]
+	if !ok {
+		rule, ok = rls.rules[anyRoute]
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	key := rule.Route + "|" + identify(ctx, rule.KeyBy, req.ClientIP)
+
+	decision, err := rls.bucketStore.Take(ctx, key, rule, rls.now())
+	if err != nil {
+		return nil, fmt.Errorf("rate limiter: take token: %w", err)
+	}
+
+	return decision, nil
+}
+
+// Prune deletes the buckets that are full again
+// It returns the number of deleted buckets
+func (rls *RateLimiterService) Prune(ctx context.Context) (int64, error) {
+	deleted, err := rls.bucketStore.Prune(ctx, rls.now())
+	if err != nil {
+		return 0, fmt.Errorf("rate limiter: prune buckets: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// identify returns the identity requests are counted by
+// Requests without an authenticated principal are counted by client IP
+func identify(ctx context.Context, keyBy KeyBy, clientIP string) string {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok || keyBy == KeyByIP {
+		return string(KeyByIP) + ":" + clientIP
+	}
+	if keyBy == KeyByUser {
+		return string(KeyByUser) + ":" + principal.Subject
+	}
+	return string(KeyByPrincipal) + ":" + principal.Credential
+}
@@ -0,0 +1,27 @@
+package ratelimiter
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRateLimiterService is a mock implementation of RateLimiterService
+type MockRateLimiterService struct {
+	mock.Mock
+}
+
+// Limit takes a token for the request
+func (m *MockRateLimiterService) Limit(ctx context.Context, req *Request) (*Decision, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Decision), args.Error(1)
+}
+
+// Prune deletes the buckets that are full again
+func (m *MockRateLimiterService) Prune(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
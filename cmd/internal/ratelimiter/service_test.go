@@ -0,0 +1,224 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewRateLimiterService(t *testing.T) {
+	tests := []struct {
+		name          string
+		bucketStore   BucketStore
+		rules         []Rule
+		expectedError string
+	}{
+		{
+			name:          "when dependencies and rules are valid it should create service successfully and no error",
+			bucketStore:   new(MockBucketRepository),
+			rules:         []Rule{{Route: "*", KeyBy: KeyByIP, Limit: 10, Period: time.Minute}},
+			expectedError: "",
+		},
+		{
+			name:          "when bucket store is nil it should return error",
+			bucketStore:   nil,
+			expectedError: "rate limiter: bucket store cannot be nil",
+		},
+		{
+			name:          "when a rule is invalid it should return error",
+			bucketStore:   new(MockBucketRepository),
+			rules:         []Rule{{Route: "*", KeyBy: KeyByIP, Limit: 0, Period: time.Minute}},
+			expectedError: `rate limiter: invalid rule "*": limit must be greater than 0`,
+		},
+		{
+			name:        "when two rules share a route it should return error",
+			bucketStore: new(MockBucketRepository),
+			rules: []Rule{
+				{Route: "*", KeyBy: KeyByIP, Limit: 10, Period: time.Minute},
+				{Route: "*", KeyBy: KeyByUser, Limit: 10, Period: time.Minute},
+			},
+			expectedError: `rate limiter: duplicate rule "*"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewRateLimiterService(tt.bucketStore, tt.rules)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestRateLimiterService_Limit(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	createRule := Rule{Route: "POST /api/v1/payments", KeyBy: KeyByUser, Limit: 10, Period: time.Minute}
+	anyRule := Rule{Route: "*", KeyBy: KeyByPrincipal, Limit: 300, Period: time.Minute}
+	ipRule := Rule{Route: "GET /api/v1/payments/:id", KeyBy: KeyByIP, Limit: 60, Period: time.Minute}
+	principal := &domain.Principal{Subject: "user_123", Credential: "api_key:key_001"}
+	decision := &Decision{Allowed: true, Limit: 10, Remaining: 9}
+
+	tests := []struct {
+		name             string
+		rules            []Rule
+		request          *Request
+		principal        *domain.Principal
+		expectedKey      string
+		expectedRule     Rule
+		mockError        error
+		expectedDecision *Decision
+		expectedError    error
+	}{
+		{
+			name:             "when route has a rule keyed by user it should take from the user bucket",
+			rules:            []Rule{createRule, anyRule},
+			request:          &Request{Method: "POST", Route: "/api/v1/payments", ClientIP: "10.0.0.1"},
+			principal:        principal,
+			expectedKey:      "POST /api/v1/payments|user:user_123",
+			expectedRule:     createRule,
+			expectedDecision: decision,
+		},
+		{
+			name:             "when route has no rule it should take from the catch-all principal bucket",
+			rules:            []Rule{createRule, anyRule},
+			request:          &Request{Method: "GET", Route: "/api/v1/payments/:id", ClientIP: "10.0.0.1"},
+			principal:        principal,
+			expectedKey:      "*|principal:api_key:key_001",
+			expectedRule:     anyRule,
+			expectedDecision: decision,
+		},
+		{
+			name:             "when rule is keyed by ip it should take from the client ip bucket",
+			rules:            []Rule{ipRule},
+			request:          &Request{Method: "GET", Route: "/api/v1/payments/:id", ClientIP: "10.0.0.1"},
+			principal:        principal,
+			expectedKey:      "GET /api/v1/payments/:id|ip:10.0.0.1",
+			expectedRule:     ipRule,
+			expectedDecision: decision,
+		},
+		{
+			name:             "when request is not authenticated it should take from the client ip bucket",
+			rules:            []Rule{createRule},
+			request:          &Request{Method: "POST", Route: "/api/v1/payments", ClientIP: "10.0.0.1"},
+			principal:        nil,
+			expectedKey:      "POST /api/v1/payments|ip:10.0.0.1",
+			expectedRule:     createRule,
+			expectedDecision: decision,
+		},
+		{
+			name:             "when no rule applies it should return nil and no error",
+			rules:            []Rule{createRule},
+			request:          &Request{Method: "GET", Route: "/api/v1/payments/:id", ClientIP: "10.0.0.1"},
+			principal:        principal,
+			expectedDecision: nil,
+		},
+		{
+			name:          "when bucket store fails it should return wrapped error",
+			rules:         []Rule{createRule},
+			request:       &Request{Method: "POST", Route: "/api/v1/payments", ClientIP: "10.0.0.1"},
+			principal:     principal,
+			expectedKey:   "POST /api/v1/payments|user:user_123",
+			expectedRule:  createRule,
+			mockError:     errors.New("connection refused"),
+			expectedError: errors.New("rate limiter: take token: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockStore := new(MockBucketRepository)
+			if tt.expectedKey != "" {
+				mockStore.On("Take", mock.Anything, tt.expectedKey, tt.expectedRule, fixedTime).Return(tt.expectedDecision, tt.mockError)
+			}
+
+			service, err := NewRateLimiterService(mockStore, tt.rules)
+			assert.NoError(t, err)
+			service.now = func() time.Time { return fixedTime }
+
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = domain.WithPrincipal(ctx, tt.principal)
+			}
+
+			// Act
+			result, err := service.Limit(ctx, tt.request)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedDecision, result)
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRateLimiterService_Prune(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		mockDeleted     int64
+		mockError       error
+		expectedDeleted int64
+		expectedError   error
+	}{
+		{
+			name:            "when buckets are pruned it should return the count and no error",
+			mockDeleted:     5,
+			expectedDeleted: 5,
+		},
+		{
+			name:          "when bucket store fails it should return wrapped error",
+			mockError:     errors.New("connection refused"),
+			expectedError: errors.New("rate limiter: prune buckets: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockStore := new(MockBucketRepository)
+			mockStore.On("Prune", mock.Anything, fixedTime).Return(tt.mockDeleted, tt.mockError)
+
+			service := &RateLimiterService{bucketStore: mockStore, now: func() time.Time { return fixedTime }}
+
+			// Act
+			result, err := service.Prune(context.Background())
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedDeleted, result)
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package recoverer
+
+import (
+	"net/http"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentstorer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/walletclient"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+)
+
+// Build creates a new Handler with all dependencies wired up
+// It builds a new handler and returns an error if the builder fails
+func Build(db paymentstorer.PaymentDB, rc *http.Client, wb walletclient.CircuitBreaker, mbc *messagebroker.Connection, exchange, routingKey string, format messagebroker.EventFormat, locker paymentlocker.AdvisoryLocker, policy RecoveryPolicy) (*Handler, error) {
+	ps, err := paymentstorer.NewStorer(db)
+	if err != nil {
+		return nil, err
+	}
+
+	of, err := NewOrphanFinderRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	wc, err := walletclient.NewWalletClient(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	bwc, err := walletclient.NewBreakerWalletClient(wc, wb)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := messagebroker.NewPublisher(
+		mbc,
+		messagebroker.PublisherConfig{
+			Exchange:    exchange,   // Topic exchange for routing
+			RoutingKey:  routingKey, // Same routing key as new payments so the processor picks them up
+			EventFormat: format,     // Same CloudEvents mode as new payments
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := NewPaymentRepublisherRepository(p, format.DataCodec())
+	if err != nil {
+		return nil, err
+	}
+
+	pl, err := paymentlocker.NewLocker(locker)
+	if err != nil {
+		return nil, err
+	}
+
+	prs, err := NewPaymentRecovererService(of, pr, ps, bwc, pl, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := NewHandler(prs)
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
@@ -0,0 +1,48 @@
+package recoverer
+
+import (
+	"errors"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+)
+
+// RecoveryAttemptHeader is the message header that marks a payment republished by the recovery job
+const RecoveryAttemptHeader = "x-recovery-attempt"
+
+// Orphan represents a reserved payment without any progress since its last event
+type Orphan struct {
+	Payment  *domain.Payment // Payment stuck in reserved status
+	Attempts int             // Number of recovery attempts already recorded for the payment
+}
+
+// RecoveryPolicy defines when a reserved payment is considered orphaned and how many times it is republished
+type RecoveryPolicy struct {
+	Threshold   time.Duration // Time without new events after which a reserved payment is considered orphaned
+	MaxAttempts int           // Republish attempts before the payment is failed and its funds released
+	BatchSize   int           // Maximum number of orphaned payments handled per run
+}
+
+// Validate validates the recovery policy
+// It returns an error if the policy is invalid
+func (p *RecoveryPolicy) Validate() error {
+	if p.Threshold <= 0 {
+		return errors.New("threshold must be greater than 0")
+	}
+	if p.MaxAttempts <= 0 {
+		return errors.New("max attempts must be greater than 0")
+	}
+	if p.BatchSize <= 0 {
+		return errors.New("batch size must be greater than 0")
+	}
+	return nil
+}
+
+// RecoveryResult summarizes the outcome of a recovery run
+type RecoveryResult struct {
+	Found       int `json:"found"`       // Orphaned payments found
+	Republished int `json:"republished"` // Orphaned payments republished for processing
+	GivenUp     int `json:"given_up"`    // Orphaned payments failed after exhausting their attempts
+	Skipped     int `json:"skipped"`     // Orphaned payments that left the reserved status before being given up
+	Errors      int `json:"errors"`      // Orphaned payments that could not be handled in this run
+}
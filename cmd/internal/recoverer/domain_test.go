@@ -0,0 +1,55 @@
+package recoverer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        *RecoveryPolicy
+		expectedError string
+	}{
+		{
+			name:          "when policy has all fields it should pass validation and no error",
+			policy:        &RecoveryPolicy{Threshold: 10 * time.Minute, MaxAttempts: 3, BatchSize: 100},
+			expectedError: "",
+		},
+		{
+			name:          "when threshold is zero it should return error with message 'threshold must be greater than 0'",
+			policy:        &RecoveryPolicy{Threshold: 0, MaxAttempts: 3, BatchSize: 100},
+			expectedError: "threshold must be greater than 0",
+		},
+		{
+			name:          "when max attempts is zero it should return error with message 'max attempts must be greater than 0'",
+			policy:        &RecoveryPolicy{Threshold: 10 * time.Minute, MaxAttempts: 0, BatchSize: 100},
+			expectedError: "max attempts must be greater than 0",
+		},
+		{
+			name:          "when batch size is negative it should return error with message 'batch size must be greater than 0'",
+			policy:        &RecoveryPolicy{Threshold: 10 * time.Minute, MaxAttempts: 3, BatchSize: -1},
+			expectedError: "batch size must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Policy already prepared in test struct)
+
+			// Act
+			err := tt.policy.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
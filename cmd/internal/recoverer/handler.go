@@ -0,0 +1,53 @@
+package recoverer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// PaymentRecoverer defines the interface for payment recovery business logic
+type PaymentRecoverer interface {
+	Recover(ctx context.Context) (*RecoveryResult, error)
+}
+
+// Handler handles scheduled recovery runs
+type Handler struct {
+	paymentRecoverer PaymentRecoverer
+}
+
+// NewHandler creates a new recovery handler
+// It returns a new recovery handler and an error if the payment recoverer is nil
+func NewHandler(pr PaymentRecoverer) (*Handler, error) {
+	if pr == nil {
+		return nil, errors.New("recoverer handler: payment recoverer cannot be nil")
+	}
+
+	return &Handler{
+		paymentRecoverer: pr,
+	}, nil
+}
+
+// Run runs a recovery pass over orphaned payments
+// It returns an error if the orphaned payments cannot be looked up
+func (h *Handler) Run(ctx context.Context) error {
+	result, err := h.paymentRecoverer.Recover(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to recover orphaned payments", "error", err)
+		return err
+	}
+
+	if result.Found == 0 {
+		slog.DebugContext(ctx, "No orphaned payments found")
+		return nil
+	}
+
+	slog.InfoContext(ctx, "Orphaned payments recovered",
+		"found", result.Found,
+		"republished", result.Republished,
+		"given_up", result.GivenUp,
+		"skipped", result.Skipped,
+		"errors", result.Errors,
+	)
+	return nil
+}
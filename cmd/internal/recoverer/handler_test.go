@@ -0,0 +1,100 @@
+package recoverer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		name             string
+		paymentRecoverer PaymentRecoverer
+		expectedError    string
+	}{
+		{
+			name:             "when payment recoverer is provided it should create handler successfully and no error",
+			paymentRecoverer: new(MockPaymentRecovererService),
+			expectedError:    "",
+		},
+		{
+			name:             "when payment recoverer is nil it should return error",
+			paymentRecoverer: nil,
+			expectedError:    "recoverer handler: payment recoverer cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Payment recoverer already prepared in test struct)
+
+			// Act
+			result, err := NewHandler(tt.paymentRecoverer)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestHandler_Run(t *testing.T) {
+	tests := []struct {
+		name             string
+		mockResult       *RecoveryResult
+		mockRecoverError error
+		expectedError    error
+	}{
+		{
+			name:             "when orphaned payments are recovered it should return no error",
+			mockResult:       &RecoveryResult{Found: 2, Republished: 1, GivenUp: 1},
+			mockRecoverError: nil,
+			expectedError:    nil,
+		},
+		{
+			name:             "when no orphaned payments are found it should return no error",
+			mockResult:       &RecoveryResult{},
+			mockRecoverError: nil,
+			expectedError:    nil,
+		},
+		{
+			name:             "when recovery fails it should return recovery error",
+			mockResult:       nil,
+			mockRecoverError: errors.New("payment recoverer: find orphans: database error"),
+			expectedError:    errors.New("payment recoverer: find orphans: database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockRecoverer := new(MockPaymentRecovererService)
+			mockRecoverer.On("Recover", mock.Anything).Return(tt.mockResult, tt.mockRecoverError)
+
+			handler := &Handler{paymentRecoverer: mockRecoverer}
+
+			// Act
+			err := handler.Run(context.Background())
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRecoverer.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package recoverer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+)
+
+// OrphanDB defines the database operations required by OrphanFinderRepository
+type OrphanDB interface {
+	QueryContext(ctx context.Context, query string, args ...any) (database.Rows, error)
+}
+
+// OrphanFinderRepository finds reserved payments that stopped progressing
+type OrphanFinderRepository struct {
+	db OrphanDB
+}
+
+// NewOrphanFinderRepository creates a new OrphanFinderRepository
+// It returns a new OrphanFinderRepository and an error if the database is nil
+func NewOrphanFinderRepository(db OrphanDB) (*OrphanFinderRepository, error) {
+	if db == nil {
+		return nil, errors.New("orphan finder: database cannot be nil")
+	}
+
+	return &OrphanFinderRepository{db: db}, nil
+}
+
+// FindOrphans finds reserved payments whose latest event is older than the given time
+// It returns the orphans ordered by their latest event, along with the recovery attempts already recorded
+func (r *OrphanFinderRepository) FindOrphans(ctx context.Context, olderThan time.Time, limit int) ([]*Orphan, error) {
+	query := `
+		SELECT p.id, COALESCE(p.idempotency_key, ''), p.user_id, p.amount, p.currency, p.status, p.created_at, p.updated_at,
+			COUNT(e.id) FILTER (WHERE e.event_type = $1) AS attempts
+		FROM payments p
+		JOIN payment_events e ON e.payment_id = p.id
+		WHERE p.status = $2
+		GROUP BY p.id
+		HAVING MAX(e.created_at) < $3
+		ORDER BY MAX(e.created_at) ASC
+		LIMIT $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, domain.EventTypeRecoveryAttempted, domain.StatusReserved, olderThan, limit)
+	if err != nil {
+		return nil, fmt.Errorf("orphan finder: find orphans: %w", err)
+	}
+	defer rows.Close()
+
+	orphans := []*Orphan{}
+	for rows.Next() {
+		var payment domain.Payment
+		var attempts int
+		err := rows.Scan(
+			&payment.ID,
+			&payment.IdempotencyKey,
+			&payment.UserID,
+			&payment.Amount,
+			&payment.Currency,
+			&payment.Status,
+			&payment.CreatedAt,
+			&payment.UpdatedAt,
+			&attempts,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("orphan finder: scan orphan: %w", err)
+		}
+		orphans = append(orphans, &Orphan{Payment: &payment, Attempts: attempts})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("orphan finder: iterate orphans: %w", err)
+	}
+
+	return orphans, nil
+}
+
+// MessageBroker interface for the infrastructure publisher
+type MessageBroker interface {
+	PublishEvent(event *messagebroker.CloudEvent, headers map[string]interface{}) error
+}
+
+// PaymentRepublisherRepository republishes orphaned payments for processing
+type PaymentRepublisherRepository struct {
+	messageBroker MessageBroker
+	codec         messagebroker.Codec
+}
+
+// NewPaymentRepublisherRepository creates a new PaymentRepublisherRepository
+// It returns a new PaymentRepublisherRepository and an error if the message broker or codec is nil
+func NewPaymentRepublisherRepository(mb MessageBroker, codec messagebroker.Codec) (*PaymentRepublisherRepository, error) {
+	if mb == nil {
+		return nil, errors.New("payment republisher: message broker cannot be nil")
+	}
+	if codec == nil {
+		return nil, errors.New("payment republisher: codec cannot be nil")
+	}
+
+	return &PaymentRepublisherRepository{
+		messageBroker: mb,
+		codec:         codec,
+	}, nil
+}
+
+// Republish republishes a payment marked with the recovery attempt header
+// It returns an error if the payment cannot be marshaled or published
+func (r *PaymentRepublisherRepository) Republish(ctx context.Context, payment *domain.Payment, attempt int) error {
+	data, err := r.codec.Marshal(payment)
+	if err != nil {
+		return fmt.Errorf("republisher: failed to marshal payment: %w", err)
+	}
+
+	// A new event, so the processor does not skip it as a redelivery of the original message
+	event := messagebroker.NewCloudEvent(domain.CloudEventTypePaymentCreated, payment.ID, domain.PaymentDataSchema, r.codec.ContentType(), data)
+
+	headers := map[string]interface{}{
+		RecoveryAttemptHeader: int32(attempt),
+	}
+
+	if err := r.messageBroker.PublishEvent(event, messagebroker.WithRequestID(ctx, headers)); err != nil {
+		return fmt.Errorf("republisher: failed to publish payment: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package recoverer
+
+import (
+	"context"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOrphanFinder is a mock implementation of OrphanFinder for testing
+type MockOrphanFinder struct {
+	mock.Mock
+}
+
+// FindOrphans mocks the FindOrphans method
+func (m *MockOrphanFinder) FindOrphans(ctx context.Context, olderThan time.Time, limit int) ([]*Orphan, error) {
+	args := m.Called(ctx, olderThan, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*Orphan), args.Error(1)
+}
+
+// MockPaymentRepublisher is a mock implementation of PaymentRepublisher for testing
+type MockPaymentRepublisher struct {
+	mock.Mock
+}
+
+// Republish mocks the Republish method
+func (m *MockPaymentRepublisher) Republish(ctx context.Context, payment *domain.Payment, attempt int) error {
+	args := m.Called(ctx, payment, attempt)
+	return args.Error(0)
+}
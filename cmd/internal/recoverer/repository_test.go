@@ -0,0 +1,345 @@
+package recoverer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewOrphanFinderRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            OrphanDB
+		expectedError string
+	}{
+		{
+			name:          "when database is provided it should create repository successfully and no error",
+			db:            new(database.MockDB),
+			expectedError: "",
+		},
+		{
+			name:          "when database is nil it should return error with message 'orphan finder: database cannot be nil'",
+			db:            nil,
+			expectedError: "orphan finder: database cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Database already prepared in test struct)
+
+			// Act
+			result, err := NewOrphanFinderRepository(tt.db)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+				assert.NotNil(t, result.db)
+			}
+		})
+	}
+}
+
+func TestOrphanFinderRepository_FindOrphans(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		mockOrphans     []*Orphan
+		mockQueryError  error
+		mockScanError   error
+		mockRowsError   error
+		expectedOrphans []*Orphan
+		expectedError   error
+	}{
+		{
+			name: "when orphans exist it should return orphans with attempts and no error",
+			mockOrphans: []*Orphan{
+				{
+					Payment: &domain.Payment{
+						ID:             "pay_123",
+						IdempotencyKey: "key_123",
+						UserID:         "user_123",
+						Amount:         100.50,
+						Currency:       domain.CurrencyUSD,
+						Status:         domain.StatusReserved,
+						CreatedAt:      fixedTime,
+						UpdatedAt:      fixedTime,
+					},
+					Attempts: 2,
+				},
+			},
+			expectedOrphans: []*Orphan{
+				{
+					Payment: &domain.Payment{
+						ID:             "pay_123",
+						IdempotencyKey: "key_123",
+						UserID:         "user_123",
+						Amount:         100.50,
+						Currency:       domain.CurrencyUSD,
+						Status:         domain.StatusReserved,
+						CreatedAt:      fixedTime,
+						UpdatedAt:      fixedTime,
+					},
+					Attempts: 2,
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name:            "when no orphans exist it should return empty slice and no error",
+			mockOrphans:     []*Orphan{},
+			expectedOrphans: []*Orphan{},
+			expectedError:   nil,
+		},
+		{
+			name:           "when query fails it should return wrapped error",
+			mockQueryError: errors.New("connection refused"),
+			expectedError:  errors.New("orphan finder: find orphans: connection refused"),
+		},
+		{
+			name:          "when scan fails it should return wrapped error",
+			mockScanError: errors.New("scan error"),
+			expectedError: errors.New("orphan finder: scan orphan: scan error"),
+		},
+		{
+			name:          "when rows iteration fails it should return wrapped error",
+			mockOrphans:   []*Orphan{},
+			mockRowsError: errors.New("iteration error"),
+			expectedError: errors.New("orphan finder: iterate orphans: iteration error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockRows := new(database.MockRows)
+
+			if tt.mockQueryError != nil {
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, tt.mockQueryError)
+			} else {
+				if tt.mockScanError != nil {
+					mockRows.On("Next").Return(true).Once()
+					mockRows.On("Scan", mock.Anything).Return(tt.mockScanError)
+				} else {
+					orphanCount := len(tt.mockOrphans)
+					if orphanCount > 0 {
+						mockRows.On("Next").Return(true).Times(orphanCount)
+						scanCallCount := 0
+						mockRows.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+							dest := args.Get(0).([]any)
+							orphan := tt.mockOrphans[scanCallCount]
+							*dest[0].(*string) = orphan.Payment.ID
+							*dest[1].(*string) = orphan.Payment.IdempotencyKey
+							*dest[2].(*string) = orphan.Payment.UserID
+							*dest[3].(*float64) = orphan.Payment.Amount
+							*dest[4].(*domain.Currency) = orphan.Payment.Currency
+							*dest[5].(*domain.Status) = orphan.Payment.Status
+							*dest[6].(*time.Time) = orphan.Payment.CreatedAt
+							*dest[7].(*time.Time) = orphan.Payment.UpdatedAt
+							*dest[8].(*int) = orphan.Attempts
+							scanCallCount++
+						}).Return(nil).Times(orphanCount)
+					}
+					mockRows.On("Next").Return(false).Once()
+					mockRows.On("Err").Return(tt.mockRowsError)
+				}
+				mockRows.On("Close").Return(nil)
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(mockRows, nil)
+			}
+
+			repo := &OrphanFinderRepository{db: mockDB}
+
+			// Act
+			result, err := repo.FindOrphans(context.Background(), fixedTime, 100)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedOrphans, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewPaymentRepublisherRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		messageBroker MessageBroker
+		codec         messagebroker.Codec
+		expectedError string
+	}{
+		{
+			name:          "when message broker is provided it should create repository successfully and no error",
+			messageBroker: new(messagebroker.MockPublisher),
+			codec:         messagebroker.JSONCodec{},
+			expectedError: "",
+		},
+		{
+			name:          "when message broker is nil it should return error with message 'payment republisher: message broker cannot be nil'",
+			messageBroker: nil,
+			codec:         messagebroker.JSONCodec{},
+			expectedError: "payment republisher: message broker cannot be nil",
+		},
+		{
+			name:          "when codec is nil it should return error with message 'payment republisher: codec cannot be nil'",
+			messageBroker: new(messagebroker.MockPublisher),
+			codec:         nil,
+			expectedError: "payment republisher: codec cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Message broker already prepared in test struct)
+
+			// Act
+			result, err := NewPaymentRepublisherRepository(tt.messageBroker, tt.codec)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+				assert.NotNil(t, result.messageBroker)
+				assert.NotNil(t, result.codec)
+			}
+		})
+	}
+}
+
+func TestPaymentRepublisherRepository_Republish(t *testing.T) {
+	tests := []struct {
+		name             string
+		payment          *domain.Payment
+		codec            messagebroker.Codec
+		attempt          int
+		requestID        string
+		mockPublishError error
+		expectedHeaders  map[string]interface{}
+		expectedError    error
+	}{
+		{
+			name: "when broker publishes successfully it should publish with recovery attempt header and no error",
+			payment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			codec:            messagebroker.JSONCodec{},
+			attempt:          2,
+			mockPublishError: nil,
+			expectedHeaders:  map[string]interface{}{RecoveryAttemptHeader: int32(2)},
+			expectedError:    nil,
+		},
+		{
+			name: "when context has a request ID it should publish it with the recovery attempt header",
+			payment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			codec:            messagebroker.JSONCodec{},
+			attempt:          2,
+			requestID:        "req_123",
+			mockPublishError: nil,
+			expectedHeaders:  map[string]interface{}{RecoveryAttemptHeader: int32(2), messagebroker.RequestIDHeader: "req_123"},
+			expectedError:    nil,
+		},
+		{
+			name: "when codec is protobuf it should republish the payment as protobuf data",
+			payment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			codec:            messagebroker.ProtobufCodec{},
+			attempt:          2,
+			mockPublishError: nil,
+			expectedHeaders:  map[string]interface{}{RecoveryAttemptHeader: int32(2)},
+			expectedError:    nil,
+		},
+		{
+			name: "when broker fails to publish it should return wrapped error",
+			payment: &domain.Payment{
+				ID:       "pay_123",
+				UserID:   "user_123",
+				Amount:   100.50,
+				Currency: domain.CurrencyUSD,
+				Status:   domain.StatusReserved,
+			},
+			codec:            messagebroker.JSONCodec{},
+			attempt:          1,
+			mockPublishError: errors.New("channel closed"),
+			expectedHeaders:  map[string]interface{}{RecoveryAttemptHeader: int32(1)},
+			expectedError:    errors.New("republisher: failed to publish payment: channel closed"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockBroker := new(messagebroker.MockPublisher)
+			data, _ := tt.codec.Marshal(tt.payment)
+			mockBroker.On("PublishEvent", mock.MatchedBy(func(event *messagebroker.CloudEvent) bool {
+				return event.Type == domain.CloudEventTypePaymentCreated &&
+					event.Subject == tt.payment.ID &&
+					event.DataSchema == domain.PaymentDataSchema &&
+					event.DataContentType == tt.codec.ContentType() &&
+					event.ID != "" &&
+					string(event.Data) == string(data)
+			}), tt.expectedHeaders).Return(tt.mockPublishError)
+
+			repo := &PaymentRepublisherRepository{messageBroker: mockBroker, codec: tt.codec}
+
+			ctx := context.Background()
+			if tt.requestID != "" {
+				ctx = requestid.WithRequestID(ctx, tt.requestID)
+			}
+
+			// Act
+			err := repo.Republish(ctx, tt.payment, tt.attempt)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockBroker.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package recoverer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
+)
+
+// OrphanFinder interface for finding orphaned payments
+type OrphanFinder interface {
+	FindOrphans(ctx context.Context, olderThan time.Time, limit int) ([]*Orphan, error)
+}
+
+// PaymentRepublisher interface for republishing payments for processing
+type PaymentRepublisher interface {
+	Republish(ctx context.Context, payment *domain.Payment, attempt int) error
+}
+
+// PaymentRecorder interface for reading payments and recording their events and status changes
+type PaymentRecorder interface {
+	GetByID(ctx context.Context, paymentID string) (*domain.Payment, error)
+	AppendEvent(ctx context.Context, paymentID string, eventType string, data map[string]interface{}) error
+	UpdateStatus(ctx context.Context, paymentID string, status domain.Status, gatewayRef string) error
+}
+
+// WalletReleaser interface for releasing reserved funds
+type WalletReleaser interface {
+	Release(ctx context.Context, userID string, amount float64, paymentID string) error
+}
+
+// PaymentLocker interface for serializing the status changes of each payment
+type PaymentLocker interface {
+	Lock(ctx context.Context, paymentID string) (paymentlocker.Unlocker, error)
+}
+
+// PaymentRecovererService is a service for recovering orphaned payments
+type PaymentRecovererService struct {
+	orphanFinder       OrphanFinder
+	paymentRepublisher PaymentRepublisher
+	paymentRecorder    PaymentRecorder
+	walletReleaser     WalletReleaser
+	paymentLocker      PaymentLocker
+	policy             RecoveryPolicy
+}
+
+// NewPaymentRecovererService creates a new PaymentRecovererService
+// It returns a new PaymentRecovererService and an error if any dependency is nil or the policy is invalid
+func NewPaymentRecovererService(
+	of OrphanFinder,
+	pr PaymentRepublisher,
+	rec PaymentRecorder,
+	wr WalletReleaser,
+	pl PaymentLocker,
+	policy RecoveryPolicy,
+) (*PaymentRecovererService, error) {
+	if of == nil {
+		return nil, errors.New("payment recoverer: orphan finder cannot be nil")
+	}
+	if pr == nil {
+		return nil, errors.New("payment recoverer: republisher cannot be nil")
+	}
+	if rec == nil {
+		return nil, errors.New("payment recoverer: recorder cannot be nil")
+	}
+	if wr == nil {
+		return nil, errors.New("payment recoverer: wallet releaser cannot be nil")
+	}
+	if pl == nil {
+		return nil, errors.New("payment recoverer: payment locker cannot be nil")
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("payment recoverer: invalid policy: %w", err)
+	}
+
+	return &PaymentRecovererService{
+		orphanFinder:       of,
+		paymentRepublisher: pr,
+		paymentRecorder:    rec,
+		walletReleaser:     wr,
+		paymentLocker:      pl,
+		policy:             policy,
+	}, nil
+}
+
+// Recover recovers orphaned payments
+// It republishes every orphaned payment that still has attempts left and records the attempt,
+// and fails the payments that exhausted their attempts releasing their funds
+// Payments that left the reserved status before being given up are skipped
+// It returns the run summary and an error only if the orphaned payments cannot be found
+func (prs *PaymentRecovererService) Recover(ctx context.Context) (*RecoveryResult, error) {
+	olderThan := time.Now().Add(-prs.policy.Threshold)
+
+	orphans, err := prs.orphanFinder.FindOrphans(ctx, olderThan, prs.policy.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("payment recoverer: find orphans: %w", err)
+	}
+
+	result := &RecoveryResult{Found: len(orphans)}
+
+	for _, orphan := range orphans {
+		if orphan.Attempts >= prs.policy.MaxAttempts {
+			givenUp, err := prs.giveUp(ctx, orphan)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to give up orphaned payment", "error", err, "payment_id", orphan.Payment.ID)
+				result.Errors++
+				continue
+			}
+			if !givenUp {
+				result.Skipped++
+				continue
+			}
+			result.GivenUp++
+			continue
+		}
+
+		if err := prs.republish(ctx, orphan); err != nil {
+			slog.ErrorContext(ctx, "Failed to republish orphaned payment", "error", err, "payment_id", orphan.Payment.ID)
+			result.Errors++
+			continue
+		}
+		result.Republished++
+	}
+
+	return result, nil
+}
+
+// republish republishes an orphaned payment and records the recovery attempt
+func (prs *PaymentRecovererService) republish(ctx context.Context, orphan *Orphan) error {
+	attempt := orphan.Attempts + 1
+
+	if err := prs.paymentRepublisher.Republish(ctx, orphan.Payment, attempt); err != nil {
+		return fmt.Errorf("payment recoverer: republish payment: %w", err)
+	}
+
+	data := map[string]interface{}{
+		"payment_id": orphan.Payment.ID,
+		"attempt":    attempt,
+	}
+	if err := prs.paymentRecorder.AppendEvent(ctx, orphan.Payment.ID, domain.EventTypeRecoveryAttempted, data); err != nil {
+		return fmt.Errorf("payment recoverer: record recovery attempt: %w", err)
+	}
+
+	return nil
+}
+
+// giveUp releases the funds of an orphaned payment and marks it as failed
+// The payment is locked and read again first, so a late message the processor is handling finishes before,
+// and a payment it moved out of reserved is left alone instead of having its funds released
+// It returns false if the payment is no longer reserved
+func (prs *PaymentRecovererService) giveUp(ctx context.Context, orphan *Orphan) (bool, error) {
+	lock, err := prs.paymentLocker.Lock(ctx, orphan.Payment.ID)
+	if err != nil {
+		return false, fmt.Errorf("payment recoverer: lock payment: %w", err)
+	}
+	defer func() {
+		if err := lock.Unlock(ctx); err != nil {
+			slog.WarnContext(ctx, "Failed to unlock payment", "error", err, "payment_id", orphan.Payment.ID)
+		}
+	}()
+
+	payment, err := prs.paymentRecorder.GetByID(ctx, orphan.Payment.ID)
+	if err != nil {
+		return false, fmt.Errorf("payment recoverer: get payment: %w", err)
+	}
+	if payment.Status != domain.StatusReserved {
+		return false, nil
+	}
+
+	if err := prs.walletReleaser.Release(ctx, payment.UserID, payment.Amount, payment.ID); err != nil {
+		return false, fmt.Errorf("payment recoverer: release funds: %w", err)
+	}
+
+	if err := prs.paymentRecorder.UpdateStatus(ctx, payment.ID, domain.StatusFailed, ""); err != nil {
+		return false, fmt.Errorf("payment recoverer: update status to failed: %w", err)
+	}
+
+	return true, nil
+}
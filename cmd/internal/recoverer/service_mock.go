@@ -0,0 +1,21 @@
+package recoverer
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPaymentRecovererService is a mock implementation of PaymentRecoverer for testing
+type MockPaymentRecovererService struct {
+	mock.Mock
+}
+
+// Recover mocks the Recover method
+func (m *MockPaymentRecovererService) Recover(ctx context.Context) (*RecoveryResult, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RecoveryResult), args.Error(1)
+}
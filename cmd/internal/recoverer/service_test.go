@@ -0,0 +1,334 @@
+package recoverer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentstorer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/walletclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewPaymentRecovererService(t *testing.T) {
+	validPolicy := RecoveryPolicy{Threshold: 10 * time.Minute, MaxAttempts: 3, BatchSize: 100}
+
+	tests := []struct {
+		name               string
+		orphanFinder       OrphanFinder
+		paymentRepublisher PaymentRepublisher
+		paymentRecorder    PaymentRecorder
+		walletReleaser     WalletReleaser
+		paymentLocker      PaymentLocker
+		policy             RecoveryPolicy
+		expectedError      string
+	}{
+		{
+			name:               "when all dependencies are provided it should create service successfully and no error",
+			orphanFinder:       new(MockOrphanFinder),
+			paymentRepublisher: new(MockPaymentRepublisher),
+			paymentRecorder:    new(paymentstorer.MockPaymentRepository),
+			walletReleaser:     new(walletclient.MockWalletClient),
+			paymentLocker:      new(paymentlocker.MockPaymentLockerRepository),
+			policy:             validPolicy,
+			expectedError:      "",
+		},
+		{
+			name:               "when orphan finder is nil it should return error",
+			orphanFinder:       nil,
+			paymentRepublisher: new(MockPaymentRepublisher),
+			paymentRecorder:    new(paymentstorer.MockPaymentRepository),
+			walletReleaser:     new(walletclient.MockWalletClient),
+			paymentLocker:      new(paymentlocker.MockPaymentLockerRepository),
+			policy:             validPolicy,
+			expectedError:      "payment recoverer: orphan finder cannot be nil",
+		},
+		{
+			name:               "when payment republisher is nil it should return error",
+			orphanFinder:       new(MockOrphanFinder),
+			paymentRepublisher: nil,
+			paymentRecorder:    new(paymentstorer.MockPaymentRepository),
+			walletReleaser:     new(walletclient.MockWalletClient),
+			paymentLocker:      new(paymentlocker.MockPaymentLockerRepository),
+			policy:             validPolicy,
+			expectedError:      "payment recoverer: republisher cannot be nil",
+		},
+		{
+			name:               "when payment recorder is nil it should return error",
+			orphanFinder:       new(MockOrphanFinder),
+			paymentRepublisher: new(MockPaymentRepublisher),
+			paymentRecorder:    nil,
+			walletReleaser:     new(walletclient.MockWalletClient),
+			paymentLocker:      new(paymentlocker.MockPaymentLockerRepository),
+			policy:             validPolicy,
+			expectedError:      "payment recoverer: recorder cannot be nil",
+		},
+		{
+			name:               "when wallet releaser is nil it should return error",
+			orphanFinder:       new(MockOrphanFinder),
+			paymentRepublisher: new(MockPaymentRepublisher),
+			paymentRecorder:    new(paymentstorer.MockPaymentRepository),
+			walletReleaser:     nil,
+			paymentLocker:      new(paymentlocker.MockPaymentLockerRepository),
+			policy:             validPolicy,
+			expectedError:      "payment recoverer: wallet releaser cannot be nil",
+		},
+		{
+			name:               "when payment locker is nil it should return error",
+			orphanFinder:       new(MockOrphanFinder),
+			paymentRepublisher: new(MockPaymentRepublisher),
+			paymentRecorder:    new(paymentstorer.MockPaymentRepository),
+			walletReleaser:     new(walletclient.MockWalletClient),
+			paymentLocker:      nil,
+			policy:             validPolicy,
+			expectedError:      "payment recoverer: payment locker cannot be nil",
+		},
+		{
+			name:               "when policy is invalid it should return error",
+			orphanFinder:       new(MockOrphanFinder),
+			paymentRepublisher: new(MockPaymentRepublisher),
+			paymentRecorder:    new(paymentstorer.MockPaymentRepository),
+			walletReleaser:     new(walletclient.MockWalletClient),
+			paymentLocker:      new(paymentlocker.MockPaymentLockerRepository),
+			policy:             RecoveryPolicy{Threshold: 10 * time.Minute, MaxAttempts: 0, BatchSize: 100},
+			expectedError:      "payment recoverer: invalid policy: max attempts must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewPaymentRecovererService(tt.orphanFinder, tt.paymentRepublisher, tt.paymentRecorder, tt.walletReleaser, tt.paymentLocker, tt.policy)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestPaymentRecovererService_Recover(t *testing.T) {
+	payment := &domain.Payment{
+		ID:       "pay_123",
+		UserID:   "user_123",
+		Amount:   100.50,
+		Currency: domain.CurrencyUSD,
+		Status:   domain.StatusReserved,
+	}
+
+	tests := []struct {
+		name                   string
+		mockOrphans            []*Orphan
+		mockFindError          error
+		mockRepublishError     error
+		mockAppendEventError   error
+		mockLockError          error
+		mockCurrentStatus      domain.Status
+		mockGetError           error
+		mockReleaseError       error
+		mockUpdateStatusError  error
+		shouldCallRepublish    bool
+		shouldCallAppendEvent  bool
+		shouldCallLock         bool
+		shouldCallGet          bool
+		shouldCallRelease      bool
+		shouldCallUpdateStatus bool
+		expectedAttempt        int
+		expectedResult         *RecoveryResult
+		expectedError          error
+	}{
+		{
+			name:           "when no orphans are found it should return empty result and no error",
+			mockOrphans:    []*Orphan{},
+			expectedResult: &RecoveryResult{},
+			expectedError:  nil,
+		},
+		{
+			name:                  "when orphan has attempts left it should republish and record the attempt and no error",
+			mockOrphans:           []*Orphan{{Payment: payment, Attempts: 1}},
+			shouldCallRepublish:   true,
+			shouldCallAppendEvent: true,
+			expectedAttempt:       2,
+			expectedResult:        &RecoveryResult{Found: 1, Republished: 1},
+			expectedError:         nil,
+		},
+		{
+			name:                   "when orphan exhausted its attempts it should lock it, release funds and mark it as failed and no error",
+			mockOrphans:            []*Orphan{{Payment: payment, Attempts: 3}},
+			mockCurrentStatus:      domain.StatusReserved,
+			shouldCallLock:         true,
+			shouldCallGet:          true,
+			shouldCallRelease:      true,
+			shouldCallUpdateStatus: true,
+			expectedResult:         &RecoveryResult{Found: 1, GivenUp: 1},
+			expectedError:          nil,
+		},
+		{
+			name:              "when orphan left the reserved status once locked it should skip it without releasing funds",
+			mockOrphans:       []*Orphan{{Payment: payment, Attempts: 3}},
+			mockCurrentStatus: domain.StatusCompleted,
+			shouldCallLock:    true,
+			shouldCallGet:     true,
+			expectedResult:    &RecoveryResult{Found: 1, Skipped: 1},
+			expectedError:     nil,
+		},
+		{
+			name:           "when the payment lock cannot be acquired it should count the error and not release funds",
+			mockOrphans:    []*Orphan{{Payment: payment, Attempts: 3}},
+			mockLockError:  context.DeadlineExceeded,
+			shouldCallLock: true,
+			expectedResult: &RecoveryResult{Found: 1, Errors: 1},
+			expectedError:  nil,
+		},
+		{
+			name:           "when reading the locked payment fails it should count the error and not release funds",
+			mockOrphans:    []*Orphan{{Payment: payment, Attempts: 3}},
+			mockGetError:   errors.New("database error"),
+			shouldCallLock: true,
+			shouldCallGet:  true,
+			expectedResult: &RecoveryResult{Found: 1, Errors: 1},
+			expectedError:  nil,
+		},
+		{
+			name:                "when republish fails it should count the error and not record the attempt",
+			mockOrphans:         []*Orphan{{Payment: payment, Attempts: 0}},
+			mockRepublishError:  errors.New("channel closed"),
+			shouldCallRepublish: true,
+			expectedAttempt:     1,
+			expectedResult:      &RecoveryResult{Found: 1, Errors: 1},
+			expectedError:       nil,
+		},
+		{
+			name:                  "when recording the attempt fails it should count the error",
+			mockOrphans:           []*Orphan{{Payment: payment, Attempts: 0}},
+			mockAppendEventError:  errors.New("database error"),
+			shouldCallRepublish:   true,
+			shouldCallAppendEvent: true,
+			expectedAttempt:       1,
+			expectedResult:        &RecoveryResult{Found: 1, Errors: 1},
+			expectedError:         nil,
+		},
+		{
+			name:              "when release fails it should count the error and not mark it as failed",
+			mockOrphans:       []*Orphan{{Payment: payment, Attempts: 3}},
+			mockReleaseError:  errors.New("wallet unavailable"),
+			mockCurrentStatus: domain.StatusReserved,
+			shouldCallLock:    true,
+			shouldCallGet:     true,
+			shouldCallRelease: true,
+			expectedResult:    &RecoveryResult{Found: 1, Errors: 1},
+			expectedError:     nil,
+		},
+		{
+			name:                   "when update status to failed fails it should count the error",
+			mockOrphans:            []*Orphan{{Payment: payment, Attempts: 4}},
+			mockUpdateStatusError:  errors.New("database error"),
+			mockCurrentStatus:      domain.StatusReserved,
+			shouldCallLock:         true,
+			shouldCallGet:          true,
+			shouldCallRelease:      true,
+			shouldCallUpdateStatus: true,
+			expectedResult:         &RecoveryResult{Found: 1, Errors: 1},
+			expectedError:          nil,
+		},
+		{
+			name:           "when finding orphans fails it should return wrapped error",
+			mockOrphans:    nil,
+			mockFindError:  errors.New("database error"),
+			expectedResult: nil,
+			expectedError:  errors.New("payment recoverer: find orphans: database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockFinder := new(MockOrphanFinder)
+			mockRepublisher := new(MockPaymentRepublisher)
+			mockRecorder := new(paymentstorer.MockPaymentRepository)
+			mockReleaser := new(walletclient.MockWalletClient)
+			mockLocker := new(paymentlocker.MockPaymentLockerRepository)
+			mockUnlocker := new(paymentlocker.MockUnlocker)
+
+			mockFinder.On("FindOrphans", mock.Anything, mock.AnythingOfType("time.Time"), 100).Return(tt.mockOrphans, tt.mockFindError)
+
+			if tt.shouldCallRepublish {
+				mockRepublisher.On("Republish", mock.Anything, payment, tt.expectedAttempt).Return(tt.mockRepublishError)
+			}
+
+			if tt.shouldCallAppendEvent {
+				expectedData := map[string]interface{}{"payment_id": payment.ID, "attempt": tt.expectedAttempt}
+				mockRecorder.On("AppendEvent", mock.Anything, payment.ID, domain.EventTypeRecoveryAttempted, expectedData).Return(tt.mockAppendEventError)
+			}
+
+			if tt.shouldCallLock {
+				if tt.mockLockError != nil {
+					mockLocker.On("Lock", mock.Anything, payment.ID).Return(nil, tt.mockLockError)
+				} else {
+					mockLocker.On("Lock", mock.Anything, payment.ID).Return(mockUnlocker, nil)
+					mockUnlocker.On("Unlock", mock.Anything).Return(nil)
+				}
+			}
+
+			if tt.shouldCallGet {
+				if tt.mockGetError != nil {
+					mockRecorder.On("GetByID", mock.Anything, payment.ID).Return(nil, tt.mockGetError)
+				} else {
+					current := *payment
+					current.Status = tt.mockCurrentStatus
+					mockRecorder.On("GetByID", mock.Anything, payment.ID).Return(&current, nil)
+				}
+			}
+
+			if tt.shouldCallRelease {
+				mockReleaser.On("Release", mock.Anything, payment.UserID, payment.Amount, payment.ID).Return(tt.mockReleaseError)
+			}
+
+			if tt.shouldCallUpdateStatus {
+				mockRecorder.On("UpdateStatus", mock.Anything, payment.ID, domain.StatusFailed, "").Return(tt.mockUpdateStatusError)
+			}
+
+			service := &PaymentRecovererService{
+				orphanFinder:       mockFinder,
+				paymentRepublisher: mockRepublisher,
+				paymentRecorder:    mockRecorder,
+				walletReleaser:     mockReleaser,
+				paymentLocker:      mockLocker,
+				policy:             RecoveryPolicy{Threshold: 10 * time.Minute, MaxAttempts: 3, BatchSize: 100},
+			}
+
+			// Act
+			result, err := service.Recover(context.Background())
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, result)
+			}
+
+			mockFinder.AssertExpectations(t)
+			mockRepublisher.AssertExpectations(t)
+			mockRecorder.AssertExpectations(t)
+			mockReleaser.AssertExpectations(t)
+			mockLocker.AssertExpectations(t)
+			mockUnlocker.AssertExpectations(t)
+		})
+	}
+}
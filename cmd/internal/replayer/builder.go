@@ -0,0 +1,54 @@
+package replayer
+
+import (
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/deadletterstorer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+)
+
+// Build creates a new Handler with all dependencies wired up
+// It builds a new handler and returns an error if the builder fails
+func Build(db deadletterstorer.DeadLetterDB, mbc *messagebroker.Connection, exchange string, batchLimit int) (*Handler, error) {
+	dlrs, err := BuildService(db, mbc, exchange, batchLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := NewHandler(dlrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// BuildService creates a new DeadLetterReplayerService with all dependencies wired up
+// It is used directly by the command line, which does not go through the HTTP handler
+func BuildService(db deadletterstorer.DeadLetterDB, mbc *messagebroker.Connection, exchange string, batchLimit int) (*DeadLetterReplayerService, error) {
+	dls, err := deadletterstorer.NewStorer(db)
+	if err != nil {
+		return nil, err
+	}
+
+	// Routing key is set per dead letter, replays go back to the route they failed on
+	p, err := messagebroker.NewPublisher(
+		mbc,
+		messagebroker.PublisherConfig{
+			Exchange: exchange,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	dlr, err := NewDeadLetterRepublisherRepository(p)
+	if err != nil {
+		return nil, err
+	}
+
+	dlrs, err := NewDeadLetterReplayerService(dls, dls, dlr, batchLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return dlrs, nil
+}
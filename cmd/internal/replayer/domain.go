@@ -0,0 +1,85 @@
+package replayer
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+)
+
+const (
+	ReplayedFromHeader = "x-replayed-from" // Dead letter ID a replayed message comes from
+)
+
+// ActionRequest represents an operator action on a single dead letter
+type ActionRequest struct {
+	Actor  string `json:"actor"`  // Who applies the action, recorded in the audit trail
+	Reason string `json:"reason"` // Why the action is applied, recorded in the audit trail
+}
+
+// Validate validates the action request
+// It returns an error if the request is invalid
+func (r *ActionRequest) Validate() error {
+	if strings.TrimSpace(r.Actor) == "" {
+		return errors.New("actor is required")
+	}
+	return nil
+}
+
+// BatchRequest represents an operator action on the pending dead letters matching a filter
+type BatchRequest struct {
+	Actor         string `json:"actor"`          // Who applies the action, recorded in the audit trail
+	Reason        string `json:"reason"`         // Why the action is applied, recorded in the audit trail
+	Queue         string `json:"queue"`          // Queue to match, empty matches any queue
+	FailureReason string `json:"failure_reason"` // Text the failure reason must contain, empty matches any reason
+	Limit         int    `json:"limit"`          // Maximum number of dead letters to act on, capped by the batch limit
+}
+
+// Validate validates the batch request
+// It returns an error if the request is invalid
+func (r *BatchRequest) Validate() error {
+	if strings.TrimSpace(r.Actor) == "" {
+		return errors.New("actor is required")
+	}
+	if r.Limit < 0 {
+		return errors.New("limit cannot be negative")
+	}
+	return nil
+}
+
+// BatchResult summarizes a batch action
+type BatchResult struct {
+	Matched   int `json:"matched"`   // Pending dead letters matching the filter
+	Succeeded int `json:"succeeded"` // Dead letters the action was applied to
+	Skipped   int `json:"skipped"`   // Dead letters resolved by someone else in the meantime
+	Failed    int `json:"failed"`    // Dead letters the action could not be applied to
+}
+
+// deadLetterHeaders are the headers added when a message is dead-lettered
+var deadLetterHeaders = map[string]bool{
+	messagebroker.AttemptsHeader:           true,
+	messagebroker.FailureReasonHeader:      true,
+	messagebroker.OriginalQueueHeader:      true,
+	messagebroker.OriginalRoutingKeyHeader: true,
+	messagebroker.DeadLetteredAtHeader:     true,
+	messagebroker.DeadLetterIDHeader:       true,
+}
+
+// ReplayHeaders returns the headers to publish a dead letter with
+// Dead letter headers are dropped so the message starts over with fresh attempts, and
+// only scalar headers are kept since nested values do not survive the JSON round trip
+func ReplayHeaders(deadLetter *domain.DeadLetter) map[string]interface{} {
+	headers := map[string]interface{}{}
+	for key, value := range deadLetter.Headers {
+		if deadLetterHeaders[key] {
+			continue
+		}
+		switch value.(type) {
+		case string, bool, float64:
+			headers[key] = value
+		}
+	}
+	headers[ReplayedFromHeader] = deadLetter.ID
+	return headers
+}
@@ -0,0 +1,162 @@
+package replayer
+
+import (
+	"testing"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		request       *ActionRequest
+		expectedError string
+	}{
+		{
+			name:          "when actor is provided it should pass validation and no error",
+			request:       &ActionRequest{Actor: "ops@example.com", Reason: "gateway is back"},
+			expectedError: "",
+		},
+		{
+			name:          "when reason is empty it should pass validation and no error",
+			request:       &ActionRequest{Actor: "ops@example.com"},
+			expectedError: "",
+		},
+		{
+			name:          "when actor is blank it should return error with message 'actor is required'",
+			request:       &ActionRequest{Actor: "  ", Reason: "gateway is back"},
+			expectedError: "actor is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Request already prepared in test struct)
+
+			// Act
+			err := tt.request.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBatchRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		request       *BatchRequest
+		expectedError string
+	}{
+		{
+			name:          "when actor and filter are provided it should pass validation and no error",
+			request:       &BatchRequest{Actor: "ops@example.com", Queue: "payments.created", FailureReason: "timeout", Limit: 10},
+			expectedError: "",
+		},
+		{
+			name:          "when limit is zero it should pass validation and no error",
+			request:       &BatchRequest{Actor: "ops@example.com"},
+			expectedError: "",
+		},
+		{
+			name:          "when actor is empty it should return error with message 'actor is required'",
+			request:       &BatchRequest{Queue: "payments.created"},
+			expectedError: "actor is required",
+		},
+		{
+			name:          "when limit is negative it should return error with message 'limit cannot be negative'",
+			request:       &BatchRequest{Actor: "ops@example.com", Limit: -1},
+			expectedError: "limit cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Request already prepared in test struct)
+
+			// Act
+			err := tt.request.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestReplayHeaders(t *testing.T) {
+	tests := []struct {
+		name            string
+		deadLetter      *domain.DeadLetter
+		expectedHeaders map[string]interface{}
+	}{
+		{
+			name: "when dead letter has dead letter headers it should drop them and add the replayed from header",
+			deadLetter: &domain.DeadLetter{
+				ID: "dl_123",
+				Headers: map[string]interface{}{
+					"x-recovery-attempt":     float64(1),
+					"x-attempts":             float64(5),
+					"x-failure-reason":       "gateway timeout",
+					"x-original-queue":       "payments.created",
+					"x-original-routing-key": "payments.created",
+					"x-dead-lettered-at":     "2024-01-15T10:00:00Z",
+					"x-dead-letter-id":       "dl_123",
+				},
+			},
+			expectedHeaders: map[string]interface{}{
+				"x-recovery-attempt": float64(1),
+				"x-replayed-from":    "dl_123",
+			},
+		},
+		{
+			name: "when dead letter has nested headers it should keep only scalar headers",
+			deadLetter: &domain.DeadLetter{
+				ID: "dl_123",
+				Headers: map[string]interface{}{
+					"x-trace":  "abc",
+					"x-flag":   true,
+					"x-nested": map[string]interface{}{"a": "b"},
+					"x-list":   []interface{}{"a"},
+				},
+			},
+			expectedHeaders: map[string]interface{}{
+				"x-trace":         "abc",
+				"x-flag":          true,
+				"x-replayed-from": "dl_123",
+			},
+		},
+		{
+			name:       "when dead letter has no headers it should return only the replayed from header",
+			deadLetter: &domain.DeadLetter{ID: "dl_123"},
+			expectedHeaders: map[string]interface{}{
+				"x-replayed-from": "dl_123",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dead letter already prepared in test struct)
+
+			// Act
+			result := ReplayHeaders(tt.deadLetter)
+
+			// Assert
+			assert.Equal(t, tt.expectedHeaders, result)
+		})
+	}
+}
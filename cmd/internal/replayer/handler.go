@@ -0,0 +1,268 @@
+package replayer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultListLimit = 50 // Dead letters returned by a list request without a limit
+)
+
+// DeadLetterReplayer defines the interface for dead letter inspection and replay business logic
+type DeadLetterReplayer interface {
+	List(ctx context.Context, filter *domain.DeadLetterFilter) ([]*domain.DeadLetter, error)
+	Get(ctx context.Context, deadLetterID string) (*domain.DeadLetter, error)
+	Audit(ctx context.Context, deadLetterID string) ([]*domain.DeadLetterAudit, error)
+	Replay(ctx context.Context, deadLetterID string, ar *ActionRequest) error
+	Discard(ctx context.Context, deadLetterID string, ar *ActionRequest) error
+	ReplayBatch(ctx context.Context, br *BatchRequest) (*BatchResult, error)
+	DiscardBatch(ctx context.Context, br *BatchRequest) (*BatchResult, error)
+}
+
+// Handler handles HTTP requests for dead letter operations
+type Handler struct {
+	deadLetterReplayer DeadLetterReplayer
+}
+
+// NewHandler creates a new dead letter controller
+// It returns a new dead letter controller and an error if the dead letter replayer is nil
+func NewHandler(dlr DeadLetterReplayer) (*Handler, error) {
+	if dlr == nil {
+		return nil, errors.New("replayer handler: dead letter replayer cannot be nil")
+	}
+
+	return &Handler{
+		deadLetterReplayer: dlr,
+	}, nil
+}
+
+// List handles GET /admin/dlq requests
+func (h *Handler) List(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	limit, err := intQuery(c, "limit", defaultListLimit)
+	if err != nil {
+		badRequest(c, "limit must be a number")
+		return
+	}
+
+	offset, err := intQuery(c, "offset", 0)
+	if err != nil {
+		badRequest(c, "offset must be a number")
+		return
+	}
+
+	filter := &domain.DeadLetterFilter{
+		Status:        domain.DeadLetterStatus(c.Query("status")),
+		Queue:         c.Query("queue"),
+		FailureReason: c.Query("failure_reason"),
+		Limit:         limit,
+		Offset:        offset,
+	}
+
+	if err := filter.Validate(); err != nil {
+		problem.Respond(c, problem.Invalid(err))
+		return
+	}
+
+	deadLetters, err := h.deadLetterReplayer.List(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list dead letters", "error", err)
+		problem.Respond(c, problem.New(problem.CodeInternal, "failed to list dead letters"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "dead letters found successfully",
+		"data":    deadLetters,
+	})
+}
+
+// Show handles GET /admin/dlq/:id requests
+func (h *Handler) Show(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	deadLetterID := c.Param("id")
+
+	deadLetter, err := h.deadLetterReplayer.Get(ctx, deadLetterID)
+	if err != nil {
+		h.fail(c, err, "failed to find dead letter", deadLetterID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "dead letter found successfully",
+		"data":    deadLetter,
+	})
+}
+
+// Audit handles GET /admin/dlq/:id/audit requests
+func (h *Handler) Audit(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	deadLetterID := c.Param("id")
+
+	entries, err := h.deadLetterReplayer.Audit(ctx, deadLetterID)
+	if err != nil {
+		h.fail(c, err, "failed to find dead letter audit", deadLetterID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "dead letter audit found successfully",
+		"data":    entries,
+	})
+}
+
+// Replay handles POST /admin/dlq/:id/replay requests
+func (h *Handler) Replay(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	deadLetterID := c.Param("id")
+
+	ar, ok := decodeActionRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.deadLetterReplayer.Replay(ctx, deadLetterID, ar); err != nil {
+		h.fail(c, err, "failed to replay dead letter", deadLetterID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "dead letter replayed successfully",
+	})
+}
+
+// Discard handles POST /admin/dlq/:id/discard requests
+func (h *Handler) Discard(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	deadLetterID := c.Param("id")
+
+	ar, ok := decodeActionRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.deadLetterReplayer.Discard(ctx, deadLetterID, ar); err != nil {
+		h.fail(c, err, "failed to discard dead letter", deadLetterID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "dead letter discarded successfully",
+	})
+}
+
+// ReplayBatch handles POST /admin/dlq/replay requests
+func (h *Handler) ReplayBatch(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	br, ok := decodeBatchRequest(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.deadLetterReplayer.ReplayBatch(ctx, br)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to replay dead letters", "error", err)
+		problem.Respond(c, problem.New(problem.CodeInternal, "failed to replay dead letters"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "dead letters replayed",
+		"data":    result,
+	})
+}
+
+// DiscardBatch handles POST /admin/dlq/discard requests
+func (h *Handler) DiscardBatch(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	br, ok := decodeBatchRequest(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.deadLetterReplayer.DiscardBatch(ctx, br)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to discard dead letters", "error", err)
+		problem.Respond(c, problem.New(problem.CodeInternal, "failed to discard dead letters"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "dead letters discarded",
+		"data":    result,
+	})
+}
+
+// fail writes the error response for an operation on a single dead letter
+func (h *Handler) fail(c *gin.Context, err error, message string, deadLetterID string) {
+	p := problem.FromError(err, message)
+	if p.Internal() {
+		slog.ErrorContext(c.Request.Context(), "Failed to handle dead letter", "error", err, "dead_letter_id", deadLetterID)
+	}
+	problem.Respond(c, p)
+}
+
+// decodeActionRequest decodes and validates the action request body
+// It writes the error response and returns false if the body is invalid
+func decodeActionRequest(c *gin.Context) (*ActionRequest, bool) {
+	var ar ActionRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&ar); err != nil {
+		badRequest(c, "invalid request body")
+		return nil, false
+	}
+
+	if err := ar.Validate(); err != nil {
+		problem.Respond(c, problem.Invalid(err))
+		return nil, false
+	}
+
+	return &ar, true
+}
+
+// decodeBatchRequest decodes and validates the batch request body
+// It writes the error response and returns false if the body is invalid
+func decodeBatchRequest(c *gin.Context) (*BatchRequest, bool) {
+	var br BatchRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&br); err != nil {
+		badRequest(c, "invalid request body")
+		return nil, false
+	}
+
+	if err := br.Validate(); err != nil {
+		problem.Respond(c, problem.Invalid(err))
+		return nil, false
+	}
+
+	return &br, true
+}
+
+// badRequest writes a bad request problem with the given detail
+func badRequest(c *gin.Context, detail string) {
+	problem.Respond(c, problem.New(problem.CodeBadRequest, detail))
+}
+
+// intQuery reads an integer query parameter, returning the default value when it is not set
+func intQuery(c *gin.Context, key string, defaultValue int) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,46 @@
+package replayer
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHandler is a mock implementation of Handler
+type MockHandler struct {
+	mock.Mock
+}
+
+// List handles GET /admin/dlq requests
+func (m *MockHandler) List(c *gin.Context) {
+	m.Called(c)
+}
+
+// Show handles GET /admin/dlq/:id requests
+func (m *MockHandler) Show(c *gin.Context) {
+	m.Called(c)
+}
+
+// Audit handles GET /admin/dlq/:id/audit requests
+func (m *MockHandler) Audit(c *gin.Context) {
+	m.Called(c)
+}
+
+// Replay handles POST /admin/dlq/:id/replay requests
+func (m *MockHandler) Replay(c *gin.Context) {
+	m.Called(c)
+}
+
+// Discard handles POST /admin/dlq/:id/discard requests
+func (m *MockHandler) Discard(c *gin.Context) {
+	m.Called(c)
+}
+
+// ReplayBatch handles POST /admin/dlq/replay requests
+func (m *MockHandler) ReplayBatch(c *gin.Context) {
+	m.Called(c)
+}
+
+// DiscardBatch handles POST /admin/dlq/discard requests
+func (m *MockHandler) DiscardBatch(c *gin.Context) {
+	m.Called(c)
+}
@@ -0,0 +1,372 @@
+package replayer
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		deadLetterReplayer DeadLetterReplayer
+		expectedError      string
+	}{
+		{
+			name:               "when dead letter replayer is provided it should create handler successfully and no error",
+			deadLetterReplayer: new(MockDeadLetterReplayerService),
+			expectedError:      "",
+		},
+		{
+			name:               "when dead letter replayer is nil it should return error",
+			deadLetterReplayer: nil,
+			expectedError:      "replayer handler: dead letter replayer cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dead letter replayer already prepared in test struct)
+
+			// Act
+			result, err := NewHandler(tt.deadLetterReplayer)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestHandler_List(t *testing.T) {
+	tests := []struct {
+		name               string
+		query              string
+		expectedFilter     *domain.DeadLetterFilter
+		mockDeadLetters    []*domain.DeadLetter
+		mockListError      error
+		shouldCallList     bool
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when query is empty it should list with the default limit and return 200",
+			query:              "",
+			expectedFilter:     &domain.DeadLetterFilter{Limit: 50},
+			mockDeadLetters:    []*domain.DeadLetter{{ID: "dl_123"}},
+			shouldCallList:     true,
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "dead letters found successfully",
+		},
+		{
+			name:  "when filters are set it should pass them to the replayer and return 200",
+			query: "?status=pending&queue=payments.created&failure_reason=timeout&limit=10&offset=20",
+			expectedFilter: &domain.DeadLetterFilter{
+				Status:        domain.DeadLetterStatusPending,
+				Queue:         "payments.created",
+				FailureReason: "timeout",
+				Limit:         10,
+				Offset:        20,
+			},
+			mockDeadLetters:    []*domain.DeadLetter{},
+			shouldCallList:     true,
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "dead letters found successfully",
+		},
+		{
+			name:               "when limit is not a number it should return 400",
+			query:              "?limit=ten",
+			shouldCallList:     false,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "limit must be a number",
+		},
+		{
+			name:               "when status is unknown it should return 400",
+			query:              "?status=archived",
+			shouldCallList:     false,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "invalid dead letter status",
+		},
+		{
+			name:               "when listing fails it should return 500",
+			query:              "",
+			expectedFilter:     &domain.DeadLetterFilter{Limit: 50},
+			mockListError:      errors.New("database error"),
+			shouldCallList:     true,
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "failed to list dead letters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockReplayer := new(MockDeadLetterReplayerService)
+			if tt.shouldCallList {
+				mockReplayer.On("List", mock.Anything, tt.expectedFilter).Return(tt.mockDeadLetters, tt.mockListError)
+			}
+
+			handler := &Handler{deadLetterReplayer: mockReplayer}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/dlq"+tt.query, nil)
+
+			// Act
+			handler.List(c)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if w.Code >= http.StatusBadRequest {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedMessage, response["detail"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, response["message"])
+			}
+
+			mockReplayer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_Show(t *testing.T) {
+	tests := []struct {
+		name               string
+		deadLetterID       string
+		mockDeadLetter     *domain.DeadLetter
+		mockGetError       error
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when dead letter exists it should return 200",
+			deadLetterID:       "dl_123",
+			mockDeadLetter:     &domain.DeadLetter{ID: "dl_123", Body: []byte(`{"id":"pay_123"}`)},
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "dead letter found successfully",
+		},
+		{
+			name:               "when dead letter does not exist it should return 404",
+			deadLetterID:       "dl_nonexistent",
+			mockGetError:       domain.ErrDeadLetterNotFound,
+			expectedStatusCode: http.StatusNotFound,
+			expectedMessage:    "dead letter not found",
+		},
+		{
+			name:               "when lookup fails it should return 500",
+			deadLetterID:       "dl_123",
+			mockGetError:       errors.New("database error"),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "failed to find dead letter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockReplayer := new(MockDeadLetterReplayerService)
+			mockReplayer.On("Get", mock.Anything, tt.deadLetterID).Return(tt.mockDeadLetter, tt.mockGetError)
+
+			handler := &Handler{deadLetterReplayer: mockReplayer}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/dlq/"+tt.deadLetterID, nil)
+			c.Params = gin.Params{gin.Param{Key: "id", Value: tt.deadLetterID}}
+
+			// Act
+			handler.Show(c)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if w.Code >= http.StatusBadRequest {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedMessage, response["detail"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, response["message"])
+			}
+
+			mockReplayer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_Replay(t *testing.T) {
+	tests := []struct {
+		name               string
+		body               string
+		mockReplayError    error
+		shouldCallReplay   bool
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when request is valid and replay succeeds it should return 200",
+			body:               `{"actor":"ops@example.com","reason":"gateway is back"}`,
+			shouldCallReplay:   true,
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "dead letter replayed successfully",
+		},
+		{
+			name:               "when body is invalid JSON it should return 400",
+			body:               `invalid`,
+			shouldCallReplay:   false,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "invalid request body",
+		},
+		{
+			name:               "when actor is missing it should return 400",
+			body:               `{"reason":"gateway is back"}`,
+			shouldCallReplay:   false,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "actor is required",
+		},
+		{
+			name:               "when dead letter is not pending it should return 409",
+			body:               `{"actor":"ops@example.com","reason":"gateway is back"}`,
+			mockReplayError:    domain.ErrDeadLetterNotPending,
+			shouldCallReplay:   true,
+			expectedStatusCode: http.StatusConflict,
+			expectedMessage:    "dead letter is not pending",
+		},
+		{
+			name:               "when replay fails it should return 500",
+			body:               `{"actor":"ops@example.com","reason":"gateway is back"}`,
+			mockReplayError:    errors.New("channel closed"),
+			shouldCallReplay:   true,
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "failed to replay dead letter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockReplayer := new(MockDeadLetterReplayerService)
+			if tt.shouldCallReplay {
+				ar := &ActionRequest{Actor: "ops@example.com", Reason: "gateway is back"}
+				mockReplayer.On("Replay", mock.Anything, "dl_123", ar).Return(tt.mockReplayError)
+			}
+
+			handler := &Handler{deadLetterReplayer: mockReplayer}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/dlq/dl_123/replay", strings.NewReader(tt.body))
+			c.Params = gin.Params{gin.Param{Key: "id", Value: "dl_123"}}
+
+			// Act
+			handler.Replay(c)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if w.Code >= http.StatusBadRequest {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedMessage, response["detail"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, response["message"])
+			}
+
+			mockReplayer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ReplayBatch(t *testing.T) {
+	tests := []struct {
+		name               string
+		body               string
+		mockResult         *BatchResult
+		mockBatchError     error
+		shouldCallBatch    bool
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when request is valid it should return 200 with the batch summary",
+			body:               `{"actor":"ops@example.com","queue":"payments.created","limit":10}`,
+			mockResult:         &BatchResult{Matched: 2, Succeeded: 2},
+			shouldCallBatch:    true,
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "dead letters replayed",
+		},
+		{
+			name:               "when actor is missing it should return 400",
+			body:               `{"queue":"payments.created"}`,
+			shouldCallBatch:    false,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "actor is required",
+		},
+		{
+			name:               "when batch fails it should return 500",
+			body:               `{"actor":"ops@example.com","queue":"payments.created","limit":10}`,
+			mockBatchError:     errors.New("database error"),
+			shouldCallBatch:    true,
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "failed to replay dead letters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockReplayer := new(MockDeadLetterReplayerService)
+			if tt.shouldCallBatch {
+				br := &BatchRequest{Actor: "ops@example.com", Queue: "payments.created", Limit: 10}
+				mockReplayer.On("ReplayBatch", mock.Anything, br).Return(tt.mockResult, tt.mockBatchError)
+			}
+
+			handler := &Handler{deadLetterReplayer: mockReplayer}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/dlq/replay", strings.NewReader(tt.body))
+
+			// Act
+			handler.ReplayBatch(c)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if w.Code >= http.StatusBadRequest {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedMessage, response["detail"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, response["message"])
+			}
+
+			mockReplayer.AssertExpectations(t)
+		})
+	}
+}
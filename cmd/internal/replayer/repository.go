@@ -0,0 +1,49 @@
+package replayer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+)
+
+// MessageBroker interface for the infrastructure publisher
+type MessageBroker interface {
+	PublishMessage(msg *messagebroker.Message) error
+}
+
+// DeadLetterRepublisherRepository publishes dead letters back to the exchange
+type DeadLetterRepublisherRepository struct {
+	messageBroker MessageBroker
+}
+
+// NewDeadLetterRepublisherRepository creates a new DeadLetterRepublisherRepository
+// It returns a new DeadLetterRepublisherRepository and an error if the message broker is nil
+func NewDeadLetterRepublisherRepository(mb MessageBroker) (*DeadLetterRepublisherRepository, error) {
+	if mb == nil {
+		return nil, errors.New("dead letter republisher: message broker cannot be nil")
+	}
+
+	return &DeadLetterRepublisherRepository{
+		messageBroker: mb,
+	}, nil
+}
+
+// Republish publishes a dead letter with its original routing key, message ID, content type and body
+// It returns an error if the dead letter cannot be published
+func (r *DeadLetterRepublisherRepository) Republish(ctx context.Context, deadLetter *domain.DeadLetter) error {
+	err := r.messageBroker.PublishMessage(&messagebroker.Message{
+		ID:          deadLetter.MessageID,
+		ContentType: deadLetter.ContentType,
+		Body:        deadLetter.Body,
+		Headers:     ReplayHeaders(deadLetter),
+		RoutingKey:  deadLetter.RoutingKey,
+	})
+	if err != nil {
+		return fmt.Errorf("republisher: failed to publish dead letter: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package replayer
+
+import (
+	"context"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockDeadLetterRepublisherRepository is a mock implementation of DeadLetterRepublisherRepository
+type MockDeadLetterRepublisherRepository struct {
+	mock.Mock
+}
+
+// Republish publishes a dead letter back to the exchange
+func (m *MockDeadLetterRepublisherRepository) Republish(ctx context.Context, deadLetter *domain.DeadLetter) error {
+	args := m.Called(ctx, deadLetter)
+	return args.Error(0)
+}
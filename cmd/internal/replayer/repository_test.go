@@ -0,0 +1,239 @@
+package replayer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/archiver"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDeadLetterRepublisherRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		messageBroker MessageBroker
+		expectedError string
+	}{
+		{
+			name:          "when message broker is provided it should create repository successfully and no error",
+			messageBroker: new(messagebroker.MockPublisher),
+			expectedError: "",
+		},
+		{
+			name:          "when message broker is nil it should return error",
+			messageBroker: nil,
+			expectedError: "dead letter republisher: message broker cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Message broker already prepared in test struct)
+
+			// Act
+			result, err := NewDeadLetterRepublisherRepository(tt.messageBroker)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestDeadLetterRepublisherRepository_Republish(t *testing.T) {
+	deadLetter := &domain.DeadLetter{
+		ID:          "dl_123",
+		RoutingKey:  "payments.created",
+		Body:        []byte{0x0a, 0x07, 'p', 'a', 'y', '_', '1', '2', '3', 0x00, 0xff},
+		ContentType: messagebroker.ProtobufContentType,
+		MessageID:   "msg_123",
+		Headers:     map[string]interface{}{"x-attempts": float64(5)},
+	}
+
+	tests := []struct {
+		name             string
+		deadLetter       *domain.DeadLetter
+		mockPublishError error
+		expectedError    error
+	}{
+		{
+			name:             "when publish succeeds it should publish the original body, content type and message ID to the original routing key and no error",
+			deadLetter:       deadLetter,
+			mockPublishError: nil,
+			expectedError:    nil,
+		},
+		{
+			name:             "when publish fails it should return wrapped error",
+			deadLetter:       deadLetter,
+			mockPublishError: errors.New("channel closed"),
+			expectedError:    errors.New("republisher: failed to publish dead letter: channel closed"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockPublisher := new(messagebroker.MockPublisher)
+			mockPublisher.On("PublishMessage", &messagebroker.Message{
+				ID:          "msg_123",
+				ContentType: messagebroker.ProtobufContentType,
+				Body:        []byte{0x0a, 0x07, 'p', 'a', 'y', '_', '1', '2', '3', 0x00, 0xff},
+				Headers:     map[string]interface{}{ReplayedFromHeader: "dl_123"},
+				RoutingKey:  "payments.created",
+			}).Return(tt.mockPublishError)
+
+			repo := &DeadLetterRepublisherRepository{messageBroker: mockPublisher}
+
+			// Act
+			err := repo.Republish(context.Background(), tt.deadLetter)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockPublisher.AssertExpectations(t)
+		})
+	}
+}
+
+// failingHandler fails every message it handles
+type failingHandler struct{}
+
+// HandleMessage returns an error so the message is dead-lettered
+func (failingHandler) HandleMessage(body []byte) error {
+	return errors.New("gateway rejected")
+}
+
+// deliveryRecorder records the messages it handles
+type deliveryRecorder struct {
+	messages chan *messagebroker.Message
+}
+
+// HandleDelivery records the message
+func (r *deliveryRecorder) HandleDelivery(msg *messagebroker.Message) error {
+	r.messages <- msg
+	return nil
+}
+
+func TestDeadLetterRepublisherRepository_Republish_ArchivedProtobuf(t *testing.T) {
+	t.Run("when an archived protobuf message is replayed it should publish the same bytes with its content type and message ID", func(t *testing.T) {
+		// Arrange
+		broker := messagebroker.NewMemoryBroker()
+		conn, err := messagebroker.ConnectMemory(broker)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		at := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+		body, err := messagebroker.ProtobufCodec{}.Marshal(&domain.Payment{
+			ID:        "pay_123",
+			UserID:    "user_789",
+			Amount:    100.50,
+			Currency:  domain.CurrencyUSD,
+			Status:    domain.StatusReserved,
+			CreatedAt: at,
+			UpdatedAt: at,
+		})
+		require.NoError(t, err)
+		require.NotEqual(t, -1, bytes.IndexByte(body, 0x00), "the body should hold bytes a TEXT column rejects")
+		event := messagebroker.NewCloudEvent(domain.CloudEventTypePaymentCreated, "pay_123", domain.PaymentDataSchema, messagebroker.ProtobufContentType, body)
+
+		// The processor fails the message once, so it is dead-lettered
+		processorChannel, err := conn.NewChannel()
+		require.NoError(t, err)
+		processor, err := messagebroker.NewConsumer(processorChannel, messagebroker.ConsumerConfig{
+			Exchange:    "payments",
+			QueueName:   "payments.created",
+			MaxAttempts: 1,
+		})
+		require.NoError(t, err)
+		require.NoError(t, processor.Start(failingHandler{}))
+
+		// The archiver stores the dead letter it builds from the dead letter queue
+		archived := make(chan *domain.DeadLetter, 1)
+		mockArchiver := new(archiver.MockDeadLetterArchiverService)
+		mockArchiver.On("Archive", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			archived <- args.Get(1).(*domain.DeadLetter)
+		}).Return(nil)
+		archiverHandler, err := archiver.NewHandler(mockArchiver)
+		require.NoError(t, err)
+
+		archiverChannel, err := conn.NewChannel()
+		require.NoError(t, err)
+		archiverConsumer, err := messagebroker.NewConsumer(archiverChannel, messagebroker.ConsumerConfig{
+			Exchange:  "payments",
+			QueueName: "payments.created.dlq",
+		})
+		require.NoError(t, err)
+		require.NoError(t, archiverConsumer.StartDeliveries(archiverHandler))
+
+		publisher, err := messagebroker.NewPublisher(conn, messagebroker.PublisherConfig{
+			Exchange:   "payments",
+			RoutingKey: "payments.created",
+		})
+		require.NoError(t, err)
+		require.NoError(t, publisher.PublishEvent(event, nil))
+
+		var deadLetter *domain.DeadLetter
+		select {
+		case deadLetter = <-archived:
+		case <-time.After(time.Second):
+			t.Fatal("the dead letter was not archived")
+		}
+		require.NoError(t, processor.Stop(context.Background()))
+
+		// The replayed message is read from the original queue
+		recorder := &deliveryRecorder{messages: make(chan *messagebroker.Message, 1)}
+		replayChannel, err := conn.NewChannel()
+		require.NoError(t, err)
+		replayConsumer, err := messagebroker.NewConsumer(replayChannel, messagebroker.ConsumerConfig{
+			Exchange:  "payments",
+			QueueName: "payments.created",
+		})
+		require.NoError(t, err)
+		require.NoError(t, replayConsumer.StartDeliveries(recorder))
+
+		repo, err := NewDeadLetterRepublisherRepository(publisher)
+		require.NoError(t, err)
+
+		// Act
+		err = repo.Republish(context.Background(), deadLetter)
+
+		// Assert
+		assert.NoError(t, err)
+		select {
+		case msg := <-recorder.messages:
+			assert.Equal(t, body, msg.Body)
+			assert.Equal(t, messagebroker.ProtobufContentType, msg.ContentType)
+			assert.Equal(t, event.ID, msg.ID)
+			assert.Equal(t, deadLetter.ID, msg.Headers[ReplayedFromHeader])
+			assert.NotContains(t, msg.Headers, messagebroker.AttemptsHeader)
+
+			var payment domain.Payment
+			assert.NoError(t, messagebroker.ProtobufCodec{}.Unmarshal(msg.Body, &payment))
+			assert.Equal(t, "pay_123", payment.ID)
+			assert.Equal(t, 100.50, payment.Amount)
+		case <-time.After(time.Second):
+			t.Fatal("the dead letter was not replayed")
+		}
+		assert.NoError(t, archiverConsumer.Stop(context.Background()))
+		assert.NoError(t, replayConsumer.Stop(context.Background()))
+	})
+}
@@ -0,0 +1,26 @@
+package replayer
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/deadletterstorer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
+)
+
+// Start starts the replayer router
+// It starts the replayer router and returns an error if the builder fails
+func Start(rg *gin.RouterGroup, db deadletterstorer.DeadLetterDB, mbc *messagebroker.Connection, exchange string, batchLimit int) error {
+	h, err := Build(db, mbc, exchange, batchLimit)
+	if err != nil {
+		return err
+	}
+
+	dlq := rg.Group("/admin/dlq")
+	dlq.GET("", h.List)
+	dlq.POST("/replay", h.ReplayBatch)
+	dlq.POST("/discard", h.DiscardBatch)
+	dlq.GET("/:id", h.Show)
+	dlq.GET("/:id/audit", h.Audit)
+	dlq.POST("/:id/replay", h.Replay)
+	dlq.POST("/:id/discard", h.Discard)
+	return nil
+}
@@ -0,0 +1,47 @@
+package replayer
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/deadletterstorer"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            deadletterstorer.DeadLetterDB
+		batchLimit    int
+		expectedError bool
+	}{
+		{
+			name:          "when database is nil it should return error",
+			db:            nil,
+			batchLimit:    100,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+			rg := router.Group("/api/v1")
+
+			// Act
+			err := Start(rg, tt.db, nil, "payments", tt.batchLimit)
+
+			// Assert
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
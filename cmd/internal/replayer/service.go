@@ -0,0 +1,189 @@
+package replayer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+)
+
+// DeadLetterReader interface for reading dead letters and their audit trail
+type DeadLetterReader interface {
+	GetByID(ctx context.Context, deadLetterID string) (*domain.DeadLetter, error)
+	List(ctx context.Context, filter *domain.DeadLetterFilter) ([]*domain.DeadLetter, error)
+	GetAuditByDeadLetterID(ctx context.Context, deadLetterID string) ([]*domain.DeadLetterAudit, error)
+}
+
+// DeadLetterResolver interface for resolving pending dead letters
+type DeadLetterResolver interface {
+	Resolve(ctx context.Context, audit *domain.DeadLetterAudit, status domain.DeadLetterStatus, apply func(deadLetter *domain.DeadLetter) error) error
+}
+
+// DeadLetterRepublisher interface for publishing dead letters back to the exchange
+type DeadLetterRepublisher interface {
+	Republish(ctx context.Context, deadLetter *domain.DeadLetter) error
+}
+
+// DeadLetterReplayerService is a service for inspecting, replaying and discarding dead letters
+type DeadLetterReplayerService struct {
+	deadLetterReader      DeadLetterReader
+	deadLetterResolver    DeadLetterResolver
+	deadLetterRepublisher DeadLetterRepublisher
+	batchLimit            int
+}
+
+// NewDeadLetterReplayerService creates a new DeadLetterReplayerService
+// It returns a new DeadLetterReplayerService and an error if any dependency is nil or the batch limit is not positive
+func NewDeadLetterReplayerService(
+	dlr DeadLetterReader,
+	res DeadLetterResolver,
+	rep DeadLetterRepublisher,
+	batchLimit int,
+) (*DeadLetterReplayerService, error) {
+	if dlr == nil {
+		return nil, errors.New("dead letter replayer: reader cannot be nil")
+	}
+	if res == nil {
+		return nil, errors.New("dead letter replayer: resolver cannot be nil")
+	}
+	if rep == nil {
+		return nil, errors.New("dead letter replayer: republisher cannot be nil")
+	}
+	if batchLimit <= 0 {
+		return nil, errors.New("dead letter replayer: batch limit must be greater than 0")
+	}
+
+	return &DeadLetterReplayerService{
+		deadLetterReader:      dlr,
+		deadLetterResolver:    res,
+		deadLetterRepublisher: rep,
+		batchLimit:            batchLimit,
+	}, nil
+}
+
+// List lists the dead letters matching the filter
+// It returns the dead letters without their body and an error if they cannot be listed
+func (s *DeadLetterReplayerService) List(ctx context.Context, filter *domain.DeadLetterFilter) ([]*domain.DeadLetter, error) {
+	deadLetters, err := s.deadLetterReader.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("dead letter replayer: list: %w", err)
+	}
+
+	return deadLetters, nil
+}
+
+// Get gets a dead letter by ID
+// It returns the dead letter with its body and an error if it cannot be found
+func (s *DeadLetterReplayerService) Get(ctx context.Context, deadLetterID string) (*domain.DeadLetter, error) {
+	deadLetter, err := s.deadLetterReader.GetByID(ctx, deadLetterID)
+	if err != nil {
+		return nil, fmt.Errorf("dead letter replayer: get: %w", err)
+	}
+
+	return deadLetter, nil
+}
+
+// Audit gets the audit trail of a dead letter
+// It returns the audit entries and an error if the dead letter cannot be found
+func (s *DeadLetterReplayerService) Audit(ctx context.Context, deadLetterID string) ([]*domain.DeadLetterAudit, error) {
+	if _, err := s.deadLetterReader.GetByID(ctx, deadLetterID); err != nil {
+		return nil, fmt.Errorf("dead letter replayer: get: %w", err)
+	}
+
+	entries, err := s.deadLetterReader.GetAuditByDeadLetterID(ctx, deadLetterID)
+	if err != nil {
+		return nil, fmt.Errorf("dead letter replayer: get audit: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Replay publishes a pending dead letter back to the exchange and marks it as replayed
+// It returns an error if the dead letter is not found, is not pending or cannot be published
+func (s *DeadLetterReplayerService) Replay(ctx context.Context, deadLetterID string, ar *ActionRequest) error {
+	audit := &domain.DeadLetterAudit{
+		DeadLetterID: deadLetterID,
+		Action:       domain.DeadLetterActionReplayed,
+		Actor:        ar.Actor,
+		Reason:       ar.Reason,
+	}
+
+	err := s.deadLetterResolver.Resolve(ctx, audit, domain.DeadLetterStatusReplayed, func(deadLetter *domain.DeadLetter) error {
+		return s.deadLetterRepublisher.Republish(ctx, deadLetter)
+	})
+	if err != nil {
+		return fmt.Errorf("dead letter replayer: replay: %w", err)
+	}
+
+	return nil
+}
+
+// Discard marks a pending dead letter as discarded
+// It returns an error if the dead letter is not found or is not pending
+func (s *DeadLetterReplayerService) Discard(ctx context.Context, deadLetterID string, ar *ActionRequest) error {
+	audit := &domain.DeadLetterAudit{
+		DeadLetterID: deadLetterID,
+		Action:       domain.DeadLetterActionDiscarded,
+		Actor:        ar.Actor,
+		Reason:       ar.Reason,
+	}
+
+	if err := s.deadLetterResolver.Resolve(ctx, audit, domain.DeadLetterStatusDiscarded, nil); err != nil {
+		return fmt.Errorf("dead letter replayer: discard: %w", err)
+	}
+
+	return nil
+}
+
+// ReplayBatch replays the pending dead letters matching the request filter
+// It returns the batch summary and an error only if the dead letters cannot be listed
+func (s *DeadLetterReplayerService) ReplayBatch(ctx context.Context, br *BatchRequest) (*BatchResult, error) {
+	return s.batch(ctx, br, s.Replay)
+}
+
+// DiscardBatch discards the pending dead letters matching the request filter
+// It returns the batch summary and an error only if the dead letters cannot be listed
+func (s *DeadLetterReplayerService) DiscardBatch(ctx context.Context, br *BatchRequest) (*BatchResult, error) {
+	return s.batch(ctx, br, s.Discard)
+}
+
+// batch applies an action to every pending dead letter matching the request filter
+// Dead letters resolved concurrently are skipped, other errors are logged and counted
+func (s *DeadLetterReplayerService) batch(ctx context.Context, br *BatchRequest, action func(ctx context.Context, deadLetterID string, ar *ActionRequest) error) (*BatchResult, error) {
+	limit := br.Limit
+	if limit <= 0 || limit > s.batchLimit {
+		limit = s.batchLimit
+	}
+
+	filter := &domain.DeadLetterFilter{
+		Status:        domain.DeadLetterStatusPending,
+		Queue:         br.Queue,
+		FailureReason: br.FailureReason,
+		Limit:         limit,
+	}
+
+	deadLetters, err := s.deadLetterReader.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("dead letter replayer: list: %w", err)
+	}
+
+	result := &BatchResult{Matched: len(deadLetters)}
+	ar := &ActionRequest{Actor: br.Actor, Reason: br.Reason}
+
+	for _, deadLetter := range deadLetters {
+		err := action(ctx, deadLetter.ID, ar)
+		switch {
+		case err == nil:
+			result.Succeeded++
+		case errors.Is(err, domain.ErrDeadLetterNotPending):
+			result.Skipped++
+		default:
+			slog.ErrorContext(ctx, "Failed to apply batch action to dead letter", "error", err, "dead_letter_id", deadLetter.ID)
+			result.Failed++
+		}
+	}
+
+	return result, nil
+}
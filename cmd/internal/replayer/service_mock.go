@@ -0,0 +1,70 @@
+package replayer
+
+import (
+	"context"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockDeadLetterReplayerService is a mock implementation of DeadLetterReplayerService
+type MockDeadLetterReplayerService struct {
+	mock.Mock
+}
+
+// List lists the dead letters matching the filter
+func (m *MockDeadLetterReplayerService) List(ctx context.Context, filter *domain.DeadLetterFilter) ([]*domain.DeadLetter, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.DeadLetter), args.Error(1)
+}
+
+// Get gets a dead letter by ID
+func (m *MockDeadLetterReplayerService) Get(ctx context.Context, deadLetterID string) (*domain.DeadLetter, error) {
+	args := m.Called(ctx, deadLetterID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.DeadLetter), args.Error(1)
+}
+
+// Audit gets the audit trail of a dead letter
+func (m *MockDeadLetterReplayerService) Audit(ctx context.Context, deadLetterID string) ([]*domain.DeadLetterAudit, error) {
+	args := m.Called(ctx, deadLetterID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.DeadLetterAudit), args.Error(1)
+}
+
+// Replay replays a pending dead letter
+func (m *MockDeadLetterReplayerService) Replay(ctx context.Context, deadLetterID string, ar *ActionRequest) error {
+	args := m.Called(ctx, deadLetterID, ar)
+	return args.Error(0)
+}
+
+// Discard discards a pending dead letter
+func (m *MockDeadLetterReplayerService) Discard(ctx context.Context, deadLetterID string, ar *ActionRequest) error {
+	args := m.Called(ctx, deadLetterID, ar)
+	return args.Error(0)
+}
+
+// ReplayBatch replays the pending dead letters matching the request filter
+func (m *MockDeadLetterReplayerService) ReplayBatch(ctx context.Context, br *BatchRequest) (*BatchResult, error) {
+	args := m.Called(ctx, br)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*BatchResult), args.Error(1)
+}
+
+// DiscardBatch discards the pending dead letters matching the request filter
+func (m *MockDeadLetterReplayerService) DiscardBatch(ctx context.Context, br *BatchRequest) (*BatchResult, error) {
+	args := m.Called(ctx, br)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*BatchResult), args.Error(1)
+}
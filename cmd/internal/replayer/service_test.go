@@ -0,0 +1,372 @@
+package replayer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/deadletterstorer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewDeadLetterReplayerService(t *testing.T) {
+	tests := []struct {
+		name          string
+		reader        DeadLetterReader
+		resolver      DeadLetterResolver
+		republisher   DeadLetterRepublisher
+		batchLimit    int
+		expectedError string
+	}{
+		{
+			name:          "when all dependencies are provided it should create service successfully and no error",
+			reader:        new(deadletterstorer.MockDeadLetterRepository),
+			resolver:      new(deadletterstorer.MockDeadLetterRepository),
+			republisher:   new(MockDeadLetterRepublisherRepository),
+			batchLimit:    100,
+			expectedError: "",
+		},
+		{
+			name:          "when reader is nil it should return error",
+			reader:        nil,
+			resolver:      new(deadletterstorer.MockDeadLetterRepository),
+			republisher:   new(MockDeadLetterRepublisherRepository),
+			batchLimit:    100,
+			expectedError: "dead letter replayer: reader cannot be nil",
+		},
+		{
+			name:          "when resolver is nil it should return error",
+			reader:        new(deadletterstorer.MockDeadLetterRepository),
+			resolver:      nil,
+			republisher:   new(MockDeadLetterRepublisherRepository),
+			batchLimit:    100,
+			expectedError: "dead letter replayer: resolver cannot be nil",
+		},
+		{
+			name:          "when republisher is nil it should return error",
+			reader:        new(deadletterstorer.MockDeadLetterRepository),
+			resolver:      new(deadletterstorer.MockDeadLetterRepository),
+			republisher:   nil,
+			batchLimit:    100,
+			expectedError: "dead letter replayer: republisher cannot be nil",
+		},
+		{
+			name:          "when batch limit is zero it should return error",
+			reader:        new(deadletterstorer.MockDeadLetterRepository),
+			resolver:      new(deadletterstorer.MockDeadLetterRepository),
+			republisher:   new(MockDeadLetterRepublisherRepository),
+			batchLimit:    0,
+			expectedError: "dead letter replayer: batch limit must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewDeadLetterReplayerService(tt.reader, tt.resolver, tt.republisher, tt.batchLimit)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestDeadLetterReplayerService_Audit(t *testing.T) {
+	tests := []struct {
+		name            string
+		deadLetterID    string
+		mockGetError    error
+		mockEntries     []*domain.DeadLetterAudit
+		mockAuditError  error
+		shouldCallAudit bool
+		expectedEntries []*domain.DeadLetterAudit
+		expectedError   error
+	}{
+		{
+			name:            "when dead letter exists it should return its audit trail and no error",
+			deadLetterID:    "dl_123",
+			mockEntries:     []*domain.DeadLetterAudit{{ID: "aud_1", DeadLetterID: "dl_123", Action: domain.DeadLetterActionArchived}},
+			shouldCallAudit: true,
+			expectedEntries: []*domain.DeadLetterAudit{{ID: "aud_1", DeadLetterID: "dl_123", Action: domain.DeadLetterActionArchived}},
+			expectedError:   nil,
+		},
+		{
+			name:            "when dead letter does not exist it should return wrapped ErrDeadLetterNotFound",
+			deadLetterID:    "dl_nonexistent",
+			mockGetError:    domain.ErrDeadLetterNotFound,
+			shouldCallAudit: false,
+			expectedError:   errors.New("dead letter replayer: get: dead letter not found"),
+		},
+		{
+			name:            "when audit lookup fails it should return wrapped error",
+			deadLetterID:    "dl_123",
+			mockAuditError:  errors.New("connection refused"),
+			shouldCallAudit: true,
+			expectedError:   errors.New("dead letter replayer: get audit: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockReader := new(deadletterstorer.MockDeadLetterRepository)
+			if tt.mockGetError != nil {
+				mockReader.On("GetByID", mock.Anything, tt.deadLetterID).Return(nil, tt.mockGetError)
+			} else {
+				mockReader.On("GetByID", mock.Anything, tt.deadLetterID).Return(&domain.DeadLetter{ID: tt.deadLetterID}, nil)
+			}
+			if tt.shouldCallAudit {
+				mockReader.On("GetAuditByDeadLetterID", mock.Anything, tt.deadLetterID).Return(tt.mockEntries, tt.mockAuditError)
+			}
+
+			service := &DeadLetterReplayerService{deadLetterReader: mockReader}
+
+			// Act
+			result, err := service.Audit(context.Background(), tt.deadLetterID)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedEntries, result)
+			}
+
+			mockReader.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeadLetterReplayerService_Replay(t *testing.T) {
+	deadLetter := &domain.DeadLetter{ID: "dl_123", RoutingKey: "payments.created", Body: []byte(`{"id":"pay_123"}`), Status: domain.DeadLetterStatusPending}
+
+	tests := []struct {
+		name                string
+		mockResolveError    error
+		mockRepublishError  error
+		shouldCallRepublish bool
+		expectedError       error
+	}{
+		{
+			name:                "when dead letter is pending it should republish it and no error",
+			shouldCallRepublish: true,
+			expectedError:       nil,
+		},
+		{
+			name:                "when republish fails it should return wrapped error",
+			mockRepublishError:  errors.New("republisher: failed to publish dead letter: channel closed"),
+			shouldCallRepublish: true,
+			expectedError:       errors.New("dead letter replayer: replay: republisher: failed to publish dead letter: channel closed"),
+		},
+		{
+			name:                "when dead letter is not pending it should return wrapped ErrDeadLetterNotPending",
+			mockResolveError:    domain.ErrDeadLetterNotPending,
+			shouldCallRepublish: false,
+			expectedError:       errors.New("dead letter replayer: replay: dead letter is not pending"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockResolver := new(deadletterstorer.MockDeadLetterRepository)
+			mockRepublisher := new(MockDeadLetterRepublisherRepository)
+
+			audit := &domain.DeadLetterAudit{
+				DeadLetterID: "dl_123",
+				Action:       domain.DeadLetterActionReplayed,
+				Actor:        "ops@example.com",
+				Reason:       "gateway is back",
+			}
+			if tt.shouldCallRepublish {
+				mockResolver.On("Resolve", mock.Anything, audit, domain.DeadLetterStatusReplayed, mock.Anything).Return(tt.mockResolveError, deadLetter)
+				mockRepublisher.On("Republish", mock.Anything, deadLetter).Return(tt.mockRepublishError)
+			} else {
+				mockResolver.On("Resolve", mock.Anything, audit, domain.DeadLetterStatusReplayed, mock.Anything).Return(tt.mockResolveError, nil)
+			}
+
+			service := &DeadLetterReplayerService{
+				deadLetterResolver:    mockResolver,
+				deadLetterRepublisher: mockRepublisher,
+			}
+
+			// Act
+			err := service.Replay(context.Background(), "dl_123", &ActionRequest{Actor: "ops@example.com", Reason: "gateway is back"})
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockResolver.AssertExpectations(t)
+			mockRepublisher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeadLetterReplayerService_Discard(t *testing.T) {
+	tests := []struct {
+		name             string
+		mockResolveError error
+		expectedError    error
+	}{
+		{
+			name:             "when dead letter is pending it should discard it and no error",
+			mockResolveError: nil,
+			expectedError:    nil,
+		},
+		{
+			name:             "when dead letter does not exist it should return wrapped ErrDeadLetterNotFound",
+			mockResolveError: domain.ErrDeadLetterNotFound,
+			expectedError:    errors.New("dead letter replayer: discard: dead letter not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockResolver := new(deadletterstorer.MockDeadLetterRepository)
+			audit := &domain.DeadLetterAudit{
+				DeadLetterID: "dl_123",
+				Action:       domain.DeadLetterActionDiscarded,
+				Actor:        "ops@example.com",
+				Reason:       "duplicate",
+			}
+			mockResolver.On("Resolve", mock.Anything, audit, domain.DeadLetterStatusDiscarded, mock.Anything).Return(tt.mockResolveError, nil)
+
+			service := &DeadLetterReplayerService{deadLetterResolver: mockResolver}
+
+			// Act
+			err := service.Discard(context.Background(), "dl_123", &ActionRequest{Actor: "ops@example.com", Reason: "duplicate"})
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockResolver.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeadLetterReplayerService_ReplayBatch(t *testing.T) {
+	deadLetters := []*domain.DeadLetter{
+		{ID: "dl_1", RoutingKey: "payments.created", Status: domain.DeadLetterStatusPending},
+		{ID: "dl_2", RoutingKey: "payments.created", Status: domain.DeadLetterStatusPending},
+		{ID: "dl_3", RoutingKey: "payments.created", Status: domain.DeadLetterStatusPending},
+	}
+
+	tests := []struct {
+		name            string
+		request         *BatchRequest
+		expectedLimit   int
+		mockDeadLetters []*domain.DeadLetter
+		mockListError   error
+		mockResolveErrs map[string]error
+		expectedResult  *BatchResult
+		expectedError   error
+	}{
+		{
+			name:            "when every dead letter is replayed it should count them as succeeded and no error",
+			request:         &BatchRequest{Actor: "ops@example.com", Queue: "payments.created", Limit: 10},
+			expectedLimit:   10,
+			mockDeadLetters: deadLetters,
+			mockResolveErrs: map[string]error{},
+			expectedResult:  &BatchResult{Matched: 3, Succeeded: 3},
+			expectedError:   nil,
+		},
+		{
+			name:            "when limit exceeds the batch limit it should cap it and no error",
+			request:         &BatchRequest{Actor: "ops@example.com", Limit: 500},
+			expectedLimit:   100,
+			mockDeadLetters: []*domain.DeadLetter{},
+			mockResolveErrs: map[string]error{},
+			expectedResult:  &BatchResult{Matched: 0},
+			expectedError:   nil,
+		},
+		{
+			name:            "when some dead letters are resolved concurrently or fail it should count them separately and no error",
+			request:         &BatchRequest{Actor: "ops@example.com"},
+			expectedLimit:   100,
+			mockDeadLetters: deadLetters,
+			mockResolveErrs: map[string]error{
+				"dl_2": domain.ErrDeadLetterNotPending,
+				"dl_3": errors.New("connection refused"),
+			},
+			expectedResult: &BatchResult{Matched: 3, Succeeded: 1, Skipped: 1, Failed: 1},
+			expectedError:  nil,
+		},
+		{
+			name:          "when listing fails it should return wrapped error",
+			request:       &BatchRequest{Actor: "ops@example.com"},
+			expectedLimit: 100,
+			mockListError: errors.New("connection refused"),
+			expectedError: errors.New("dead letter replayer: list: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockStorer := new(deadletterstorer.MockDeadLetterRepository)
+			mockRepublisher := new(MockDeadLetterRepublisherRepository)
+
+			filter := &domain.DeadLetterFilter{
+				Status:        domain.DeadLetterStatusPending,
+				Queue:         tt.request.Queue,
+				FailureReason: tt.request.FailureReason,
+				Limit:         tt.expectedLimit,
+			}
+			mockStorer.On("List", mock.Anything, filter).Return(tt.mockDeadLetters, tt.mockListError)
+
+			for _, deadLetter := range tt.mockDeadLetters {
+				resolveErr := tt.mockResolveErrs[deadLetter.ID]
+				mockStorer.On("Resolve", mock.Anything, mock.MatchedBy(func(a *domain.DeadLetterAudit) bool {
+					return a.DeadLetterID == deadLetter.ID
+				}), domain.DeadLetterStatusReplayed, mock.Anything).Return(resolveErr, nil)
+			}
+
+			service := &DeadLetterReplayerService{
+				deadLetterReader:      mockStorer,
+				deadLetterResolver:    mockStorer,
+				deadLetterRepublisher: mockRepublisher,
+				batchLimit:            100,
+			}
+
+			// Act
+			result, err := service.ReplayBatch(context.Background(), tt.request)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, result)
+			}
+
+			mockStorer.AssertExpectations(t)
+		})
+	}
+}
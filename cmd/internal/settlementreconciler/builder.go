@@ -0,0 +1,38 @@
+package settlementreconciler
+
+// SettlementDB defines the database operations required by the settlement reconciler
+type SettlementDB interface {
+	PaymentMatcherDB
+	SettlementRunDB
+}
+
+// Build creates a new Handler with all dependencies wired up
+// It builds a new handler and returns an error if the builder fails
+func Build(db SettlementDB, dir string, policy ReconciliationPolicy) (*Handler, error) {
+	fs, err := NewSettlementFileRepository(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pm, err := NewPaymentMatcherRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := NewSettlementRunRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	srs, err := NewSettlementReconcilerService(fs, pm, rs, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := NewHandler(srs, rs)
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
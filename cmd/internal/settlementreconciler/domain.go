@@ -0,0 +1,359 @@
+package settlementreconciler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+)
+
+// ErrSettlementRunNotFound is returned when a settlement run is not found
+var ErrSettlementRunNotFound = errors.New("settlement run not found")
+
+// ErrUnsupportedFormat is returned when a settlement file is neither CSV nor JSON
+var ErrUnsupportedFormat = errors.New("unsupported settlement file format")
+
+// ItemStatus represents the result of matching a settlement row or a completed payment
+type ItemStatus string
+
+const (
+	ItemStatusMatched          ItemStatus = "matched"           // The row settles a completed payment with the same amount and currency
+	ItemStatusAmountMismatch   ItemStatus = "amount_mismatch"   // The row settles a payment for a different amount
+	ItemStatusCurrencyMismatch ItemStatus = "currency_mismatch" // The row settles a payment in a different currency
+	ItemStatusUnexpectedStatus ItemStatus = "unexpected_status" // The row settles a payment the gateway did not charge
+	ItemStatusDuplicate        ItemStatus = "duplicate"         // The gateway reference was already settled, in this file or an earlier one
+	ItemStatusUnknown          ItemStatus = "unknown_reference" // The gateway reference does not belong to any payment
+	ItemStatusMissing          ItemStatus = "missing"           // The completed payment was not settled within the grace period
+)
+
+// Validate validates the item status
+// It returns an error if the status is unknown
+func (s ItemStatus) Validate() error {
+	switch s {
+	case ItemStatusMatched, ItemStatusAmountMismatch, ItemStatusCurrencyMismatch, ItemStatusUnexpectedStatus,
+		ItemStatusDuplicate, ItemStatusUnknown, ItemStatusMissing:
+		return nil
+	}
+	return errors.New("invalid item status")
+}
+
+// RunStatus represents the status of a settlement file import
+type RunStatus string
+
+const (
+	RunStatusCompleted RunStatus = "completed" // The file was parsed and reconciled
+	RunStatusFailed    RunStatus = "failed"    // The file could not be parsed, no items were stored
+)
+
+// SettlementFile represents a settlement report dropped by the gateway
+type SettlementFile struct {
+	Name string // File name, its extension selects the format
+	Data []byte // File content
+}
+
+// Checksum returns the SHA-256 of the file content, used to import each report only once
+func (f *SettlementFile) Checksum() string {
+	sum := sha256.Sum256(f.Data)
+	return hex.EncodeToString(sum[:])
+}
+
+// SettlementRecord represents a row of a settlement report
+type SettlementRecord struct {
+	GatewayRef string          `json:"gateway_ref"` // Gateway reference of the settled payment
+	Amount     float64         `json:"amount"`      // Settled amount
+	Currency   domain.Currency `json:"currency"`    // Settled currency
+	SettledAt  time.Time       `json:"settled_at"`  // Timestamp when the gateway settled the payment, zero if not reported
+}
+
+// Validate validates the settlement record
+// It returns an error if the record is invalid
+func (r *SettlementRecord) Validate() error {
+	if r.GatewayRef == "" {
+		return errors.New("gateway_ref is required")
+	}
+	if r.Amount <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+	if r.Currency == "" {
+		return errors.New("currency is required")
+	}
+	return nil
+}
+
+// ParseSettlementFile parses a settlement file by its extension
+// It returns the records and an error if the format is unsupported or any row is invalid
+func ParseSettlementFile(file *SettlementFile) ([]*SettlementRecord, error) {
+	switch strings.ToLower(filepath.Ext(file.Name)) {
+	case ".csv":
+		return parseCSV(file.Data)
+	case ".json":
+		return parseJSON(file.Data)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// IsSettlementFile reports whether the file name has a supported settlement format
+func IsSettlementFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".csv" || ext == ".json"
+}
+
+// parseCSV parses a CSV report with a header row
+// The gateway_ref, amount and currency columns are required, settled_at is optional, other columns are ignored
+func parseCSV(data []byte) ([]*SettlementRecord, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []*SettlementRecord{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"gateway_ref", "amount", "currency"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column %s", required)
+		}
+	}
+
+	records := []*SettlementRecord{}
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		amount, err := strconv.ParseFloat(strings.TrimSpace(row[columns["amount"]]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid amount", line)
+		}
+
+		record := &SettlementRecord{
+			GatewayRef: strings.TrimSpace(row[columns["gateway_ref"]]),
+			Amount:     amount,
+			Currency:   domain.Currency(strings.ToUpper(strings.TrimSpace(row[columns["currency"]]))),
+		}
+
+		if i, ok := columns["settled_at"]; ok && strings.TrimSpace(row[i]) != "" {
+			settledAt, err := time.Parse(time.RFC3339, strings.TrimSpace(row[i]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid settled_at", line)
+			}
+			record.SettledAt = settledAt
+		}
+
+		if err := record.Validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+}
+
+// parseJSON parses a JSON report holding an array of records
+func parseJSON(data []byte) ([]*SettlementRecord, error) {
+	records := []*SettlementRecord{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	for i, record := range records {
+		record.GatewayRef = strings.TrimSpace(record.GatewayRef)
+		record.Currency = domain.Currency(strings.ToUpper(string(record.Currency)))
+		if err := record.Validate(); err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+	}
+
+	return records, nil
+}
+
+// SettledPayment represents a payment as seen by settlement matching
+type SettledPayment struct {
+	PaymentID  string          // Payment ID
+	GatewayRef string          // Gateway reference returned when the payment was processed
+	Amount     float64         // Amount of the payment
+	Currency   domain.Currency // Currency of the payment
+	Status     domain.Status   // Status of the payment
+}
+
+// SettlementItem represents the reconciliation result of a settlement row or of a completed payment missing from the reports
+type SettlementItem struct {
+	ID               string          `json:"id"`                          // Unique identifier for the item
+	RunID            string          `json:"run_id"`                      // Settlement run the item belongs to
+	GatewayRef       string          `json:"gateway_ref"`                 // Gateway reference of the row or payment
+	PaymentID        string          `json:"payment_id,omitempty"`        // Payment matched by the gateway reference, empty if unknown
+	Status           ItemStatus      `json:"status"`                      // Result of the match
+	ReportedAmount   float64         `json:"reported_amount,omitempty"`   // Amount in the settlement row, empty for missing payments
+	ReportedCurrency domain.Currency `json:"reported_currency,omitempty"` // Currency in the settlement row, empty for missing payments
+	ExpectedAmount   float64         `json:"expected_amount,omitempty"`   // Amount of the payment, empty for unknown references
+	ExpectedCurrency domain.Currency `json:"expected_currency,omitempty"` // Currency of the payment, empty for unknown references
+	SettledAt        *time.Time      `json:"settled_at,omitempty"`        // Timestamp when the gateway settled the payment, if reported
+}
+
+// Match matches settlement records to payments by gateway reference, then checks currency and amount
+// The settled set holds references reported by earlier runs, reporting them again is a duplicate
+// It returns one item per record, in the same order
+func Match(records []*SettlementRecord, payments map[string]*SettledPayment, settled map[string]bool) []*SettlementItem {
+	items := make([]*SettlementItem, 0, len(records))
+	seen := make(map[string]bool, len(records))
+
+	for _, record := range records {
+		item := &SettlementItem{
+			GatewayRef:       record.GatewayRef,
+			ReportedAmount:   record.Amount,
+			ReportedCurrency: record.Currency,
+		}
+		if !record.SettledAt.IsZero() {
+			settledAt := record.SettledAt
+			item.SettledAt = &settledAt
+		}
+
+		payment := payments[record.GatewayRef]
+		if payment != nil {
+			item.PaymentID = payment.PaymentID
+			item.ExpectedAmount = payment.Amount
+			item.ExpectedCurrency = payment.Currency
+		}
+
+		switch {
+		case seen[record.GatewayRef] || settled[record.GatewayRef]:
+			item.Status = ItemStatusDuplicate
+		case payment == nil:
+			item.Status = ItemStatusUnknown
+		case payment.Status != domain.StatusCompleted && payment.Status != domain.StatusPendingConfirm:
+			item.Status = ItemStatusUnexpectedStatus
+		case payment.Currency != record.Currency:
+			item.Status = ItemStatusCurrencyMismatch
+		case cents(payment.Amount) != cents(record.Amount):
+			item.Status = ItemStatusAmountMismatch
+		default:
+			item.Status = ItemStatusMatched
+		}
+
+		seen[record.GatewayRef] = true
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// Missing returns a missing item for every unsettled payment not reported in the current records
+func Missing(unsettled []*SettledPayment, records []*SettlementRecord) []*SettlementItem {
+	reported := make(map[string]bool, len(records))
+	for _, record := range records {
+		reported[record.GatewayRef] = true
+	}
+
+	items := []*SettlementItem{}
+	for _, payment := range unsettled {
+		if reported[payment.GatewayRef] {
+			continue
+		}
+		items = append(items, &SettlementItem{
+			GatewayRef:       payment.GatewayRef,
+			PaymentID:        payment.PaymentID,
+			Status:           ItemStatusMissing,
+			ExpectedAmount:   payment.Amount,
+			ExpectedCurrency: payment.Currency,
+		})
+	}
+
+	return items
+}
+
+// cents converts an amount to cents, so amounts are compared without floating point noise
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// SettlementRun represents the import of a settlement file
+type SettlementRun struct {
+	ID         string             `json:"id"`              // Unique identifier for the run
+	FileName   string             `json:"file_name"`       // Name of the imported file
+	Checksum   string             `json:"checksum"`        // SHA-256 of the file content
+	Status     RunStatus          `json:"status"`          // Status of the import
+	Error      string             `json:"error,omitempty"` // Parse error, only set when the import failed
+	TotalRows  int                `json:"total_rows"`      // Rows in the file
+	Summary    map[ItemStatus]int `json:"summary"`         // Items per status
+	ImportedAt time.Time          `json:"imported_at"`     // Timestamp when the file was imported
+}
+
+// Summarize counts the items per status
+func Summarize(items []*SettlementItem) map[ItemStatus]int {
+	summary := map[ItemStatus]int{}
+	for _, item := range items {
+		summary[item.Status]++
+	}
+	return summary
+}
+
+// ItemFilter represents the filter criteria for listing the items of a run
+type ItemFilter struct {
+	RunID  string     `json:"run_id"` // Run the items belong to
+	Status ItemStatus `json:"status"` // Status to match, empty matches any status
+	Limit  int        `json:"limit"`  // Maximum number of items to return
+	Offset int        `json:"offset"` // Number of items to skip
+}
+
+// Validate validates the item filter
+// It returns an error if the filter is invalid
+func (f *ItemFilter) Validate() error {
+	if f.Status != "" {
+		if err := f.Status.Validate(); err != nil {
+			return err
+		}
+	}
+	if f.Limit <= 0 {
+		return errors.New("limit must be greater than 0")
+	}
+	if f.Offset < 0 {
+		return errors.New("offset must be greater than or equal to 0")
+	}
+	return nil
+}
+
+// ReconciliationPolicy defines which completed payments are expected in the settlement reports
+type ReconciliationPolicy struct {
+	Grace    time.Duration // Time after completion the gateway has to settle a payment before it is missing
+	Lookback time.Duration // Time after completion after which a payment is no longer expected
+}
+
+// Validate validates the reconciliation policy
+// It returns an error if the policy is invalid
+func (p *ReconciliationPolicy) Validate() error {
+	if p.Grace <= 0 {
+		return errors.New("grace must be greater than 0")
+	}
+	if p.Lookback <= p.Grace {
+		return errors.New("lookback must be greater than grace")
+	}
+	return nil
+}
+
+// ImportResult summarizes the outcome of an import run
+type ImportResult struct {
+	Imported int `json:"imported"` // Files reconciled
+	Rejected int `json:"rejected"` // Files that could not be parsed, recorded as failed runs
+	Skipped  int `json:"skipped"`  // Files already imported
+	Errors   int `json:"errors"`   // Files that could not be imported in this run, retried on the next one
+}
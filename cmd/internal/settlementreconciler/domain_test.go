@@ -0,0 +1,317 @@
+package settlementreconciler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSettlementFile(t *testing.T) {
+	settledAt := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		file            *SettlementFile
+		expectedRecords []*SettlementRecord
+		expectedError   error
+	}{
+		{
+			name: "when CSV file is valid it should return records and no error",
+			file: &SettlementFile{
+				Name: "settlement-2024-01-15.csv",
+				Data: []byte("gateway_ref,amount,currency,settled_at\ngw_1,100.50,USD,2024-01-15T10:30:00Z\ngw_2, 20,eur,\n"),
+			},
+			expectedRecords: []*SettlementRecord{
+				{GatewayRef: "gw_1", Amount: 100.50, Currency: domain.CurrencyUSD, SettledAt: settledAt},
+				{GatewayRef: "gw_2", Amount: 20, Currency: domain.CurrencyEUR},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "when CSV columns are reordered it should return records by header and no error",
+			file: &SettlementFile{
+				Name: "SETTLEMENT.CSV",
+				Data: []byte("Currency,Fee,Gateway_Ref,Amount\nUSD,1.00,gw_1,100.50\n"),
+			},
+			expectedRecords: []*SettlementRecord{
+				{GatewayRef: "gw_1", Amount: 100.50, Currency: domain.CurrencyUSD},
+			},
+			expectedError: nil,
+		},
+		{
+			name:            "when CSV file is empty it should return no records and no error",
+			file:            &SettlementFile{Name: "empty.csv", Data: []byte("")},
+			expectedRecords: []*SettlementRecord{},
+			expectedError:   nil,
+		},
+		{
+			name:          "when CSV misses a required column it should return error",
+			file:          &SettlementFile{Name: "settlement.csv", Data: []byte("gateway_ref,amount\ngw_1,100.50\n")},
+			expectedError: errors.New("missing column currency"),
+		},
+		{
+			name:          "when CSV amount is invalid it should return error with line",
+			file:          &SettlementFile{Name: "settlement.csv", Data: []byte("gateway_ref,amount,currency\ngw_1,abc,USD\n")},
+			expectedError: errors.New("line 2: invalid amount"),
+		},
+		{
+			name:          "when CSV row has no gateway reference it should return error with line",
+			file:          &SettlementFile{Name: "settlement.csv", Data: []byte("gateway_ref,amount,currency\ngw_1,10,USD\n,10,USD\n")},
+			expectedError: errors.New("line 3: gateway_ref is required"),
+		},
+		{
+			name: "when JSON file is valid it should return records and no error",
+			file: &SettlementFile{
+				Name: "settlement.json",
+				Data: []byte(`[{"gateway_ref":"gw_1","amount":100.5,"currency":"usd","settled_at":"2024-01-15T10:30:00Z"}]`),
+			},
+			expectedRecords: []*SettlementRecord{
+				{GatewayRef: "gw_1", Amount: 100.50, Currency: domain.CurrencyUSD, SettledAt: settledAt},
+			},
+			expectedError: nil,
+		},
+		{
+			name:          "when JSON record amount is not positive it should return error with record index",
+			file:          &SettlementFile{Name: "settlement.json", Data: []byte(`[{"gateway_ref":"gw_1","amount":0,"currency":"USD"}]`)},
+			expectedError: errors.New("record 0: amount must be greater than 0"),
+		},
+		{
+			name:          "when file format is unsupported it should return unsupported format error",
+			file:          &SettlementFile{Name: "settlement.xml", Data: []byte("<settlement/>")},
+			expectedError: ErrUnsupportedFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (File already prepared in test struct)
+
+			// Act
+			result, err := ParseSettlementFile(tt.file)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedRecords, result)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	payments := map[string]*SettledPayment{
+		"gw_1": {PaymentID: "pay_1", GatewayRef: "gw_1", Amount: 100.50, Currency: domain.CurrencyUSD, Status: domain.StatusCompleted},
+		"gw_2": {PaymentID: "pay_2", GatewayRef: "gw_2", Amount: 20, Currency: domain.CurrencyEUR, Status: domain.StatusFailed},
+		"gw_3": {PaymentID: "pay_3", GatewayRef: "gw_3", Amount: 30, Currency: domain.CurrencyUSD, Status: domain.StatusCompleted},
+		"gw_4": {PaymentID: "pay_4", GatewayRef: "gw_4", Amount: 40, Currency: domain.CurrencyUSD, Status: domain.StatusPendingConfirm},
+	}
+
+	tests := []struct {
+		name            string
+		record          *SettlementRecord
+		settled         map[string]bool
+		expectedStatus  ItemStatus
+		expectedPayment string
+	}{
+		{
+			name:            "when amount and currency match it should return matched",
+			record:          &SettlementRecord{GatewayRef: "gw_1", Amount: 100.50, Currency: domain.CurrencyUSD},
+			expectedStatus:  ItemStatusMatched,
+			expectedPayment: "pay_1",
+		},
+		{
+			name:            "when amount differs it should return amount mismatch",
+			record:          &SettlementRecord{GatewayRef: "gw_1", Amount: 100.49, Currency: domain.CurrencyUSD},
+			expectedStatus:  ItemStatusAmountMismatch,
+			expectedPayment: "pay_1",
+		},
+		{
+			name:            "when currency differs it should return currency mismatch",
+			record:          &SettlementRecord{GatewayRef: "gw_1", Amount: 100.50, Currency: domain.CurrencyEUR},
+			expectedStatus:  ItemStatusCurrencyMismatch,
+			expectedPayment: "pay_1",
+		},
+		{
+			name:            "when payment is pending confirmation it should return matched",
+			record:          &SettlementRecord{GatewayRef: "gw_4", Amount: 40, Currency: domain.CurrencyUSD},
+			expectedStatus:  ItemStatusMatched,
+			expectedPayment: "pay_4",
+		},
+		{
+			name:            "when payment was not charged it should return unexpected status",
+			record:          &SettlementRecord{GatewayRef: "gw_2", Amount: 20, Currency: domain.CurrencyEUR},
+			expectedStatus:  ItemStatusUnexpectedStatus,
+			expectedPayment: "pay_2",
+		},
+		{
+			name:            "when gateway reference has no payment it should return unknown reference",
+			record:          &SettlementRecord{GatewayRef: "gw_404", Amount: 10, Currency: domain.CurrencyUSD},
+			expectedStatus:  ItemStatusUnknown,
+			expectedPayment: "",
+		},
+		{
+			name:            "when gateway reference was settled by an earlier run it should return duplicate",
+			record:          &SettlementRecord{GatewayRef: "gw_3", Amount: 30, Currency: domain.CurrencyUSD},
+			settled:         map[string]bool{"gw_3": true},
+			expectedStatus:  ItemStatusDuplicate,
+			expectedPayment: "pay_3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Record already prepared in test struct)
+
+			// Act
+			result := Match([]*SettlementRecord{tt.record}, payments, tt.settled)
+
+			// Assert
+			assert.Len(t, result, 1)
+			assert.Equal(t, tt.expectedStatus, result[0].Status)
+			assert.Equal(t, tt.expectedPayment, result[0].PaymentID)
+			assert.Equal(t, tt.record.Amount, result[0].ReportedAmount)
+		})
+	}
+}
+
+func TestMatch_DuplicateInFile(t *testing.T) {
+	// Arrange
+	payments := map[string]*SettledPayment{
+		"gw_1": {PaymentID: "pay_1", GatewayRef: "gw_1", Amount: 10, Currency: domain.CurrencyUSD, Status: domain.StatusCompleted},
+	}
+	records := []*SettlementRecord{
+		{GatewayRef: "gw_1", Amount: 10, Currency: domain.CurrencyUSD},
+		{GatewayRef: "gw_1", Amount: 10, Currency: domain.CurrencyUSD},
+	}
+
+	// Act
+	result := Match(records, payments, nil)
+
+	// Assert
+	assert.Equal(t, ItemStatusMatched, result[0].Status)
+	assert.Equal(t, ItemStatusDuplicate, result[1].Status)
+	assert.Equal(t, map[ItemStatus]int{ItemStatusMatched: 1, ItemStatusDuplicate: 1}, Summarize(result))
+}
+
+func TestMissing(t *testing.T) {
+	// Arrange
+	unsettled := []*SettledPayment{
+		{PaymentID: "pay_1", GatewayRef: "gw_1", Amount: 10, Currency: domain.CurrencyUSD, Status: domain.StatusCompleted},
+		{PaymentID: "pay_2", GatewayRef: "gw_2", Amount: 20, Currency: domain.CurrencyUSD, Status: domain.StatusCompleted},
+	}
+	records := []*SettlementRecord{
+		{GatewayRef: "gw_1", Amount: 10, Currency: domain.CurrencyUSD},
+	}
+
+	// Act
+	result := Missing(unsettled, records)
+
+	// Assert
+	assert.Equal(t, []*SettlementItem{
+		{
+			GatewayRef:       "gw_2",
+			PaymentID:        "pay_2",
+			Status:           ItemStatusMissing,
+			ExpectedAmount:   20,
+			ExpectedCurrency: domain.CurrencyUSD,
+		},
+	}, result)
+}
+
+func TestItemFilter_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		filter        ItemFilter
+		expectedError string
+	}{
+		{
+			name:          "when filter is valid it should return no error",
+			filter:        ItemFilter{RunID: "run_1", Status: ItemStatusMissing, Limit: 10},
+			expectedError: "",
+		},
+		{
+			name:          "when status is unknown it should return error",
+			filter:        ItemFilter{RunID: "run_1", Status: "settled", Limit: 10},
+			expectedError: "invalid item status",
+		},
+		{
+			name:          "when limit is zero it should return error",
+			filter:        ItemFilter{RunID: "run_1", Limit: 0},
+			expectedError: "limit must be greater than 0",
+		},
+		{
+			name:          "when offset is negative it should return error",
+			filter:        ItemFilter{RunID: "run_1", Limit: 10, Offset: -1},
+			expectedError: "offset must be greater than or equal to 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Filter already prepared in test struct)
+
+			// Act
+			err := tt.filter.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestReconciliationPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        ReconciliationPolicy
+		expectedError string
+	}{
+		{
+			name:          "when policy is valid it should return no error",
+			policy:        ReconciliationPolicy{Grace: 48 * time.Hour, Lookback: 720 * time.Hour},
+			expectedError: "",
+		},
+		{
+			name:          "when grace is zero it should return error",
+			policy:        ReconciliationPolicy{Grace: 0, Lookback: 720 * time.Hour},
+			expectedError: "grace must be greater than 0",
+		},
+		{
+			name:          "when lookback is not greater than grace it should return error",
+			policy:        ReconciliationPolicy{Grace: 48 * time.Hour, Lookback: 48 * time.Hour},
+			expectedError: "lookback must be greater than grace",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Policy already prepared in test struct)
+
+			// Act
+			err := tt.policy.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package settlementreconciler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultListLimit = 50 // Runs or items returned by a list request without a limit
+)
+
+// SettlementReconciler defines the interface for settlement reconciliation business logic
+type SettlementReconciler interface {
+	Import(ctx context.Context) (*ImportResult, error)
+}
+
+// SettlementReportReader defines the interface for reading the settlement runs and their items
+type SettlementReportReader interface {
+	ListRuns(ctx context.Context, limit, offset int) ([]*SettlementRun, error)
+	GetRun(ctx context.Context, runID string) (*SettlementRun, error)
+	ListItems(ctx context.Context, filter *ItemFilter) ([]*SettlementItem, error)
+}
+
+// Handler handles scheduled settlement imports and settlement report requests
+type Handler struct {
+	settlementReconciler SettlementReconciler
+	reportReader         SettlementReportReader
+}
+
+// NewHandler creates a new settlement reconciliation handler
+// It returns a new settlement reconciliation handler and an error if the settlement reconciler or report reader is nil
+func NewHandler(sr SettlementReconciler, rr SettlementReportReader) (*Handler, error) {
+	if sr == nil {
+		return nil, errors.New("settlementreconciler handler: settlement reconciler cannot be nil")
+	}
+	if rr == nil {
+		return nil, errors.New("settlementreconciler handler: report reader cannot be nil")
+	}
+
+	return &Handler{
+		settlementReconciler: sr,
+		reportReader:         rr,
+	}, nil
+}
+
+// Run imports the settlement files dropped since the last run
+// It returns an error if the settlement directory cannot be read
+func (h *Handler) Run(ctx context.Context) error {
+	result, err := h.settlementReconciler.Import(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to import settlement files", "error", err)
+		return err
+	}
+
+	slog.InfoContext(ctx, "Settlement import finished",
+		"imported", result.Imported,
+		"rejected", result.Rejected,
+		"skipped", result.Skipped,
+		"errors", result.Errors,
+	)
+	return nil
+}
+
+// ListRuns handles GET /admin/reconciliation/settlements requests
+func (h *Handler) ListRuns(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	runs, err := h.reportReader.ListRuns(ctx, limit, offset)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list settlement runs", "error", err)
+		problem.Respond(c, problem.New(problem.CodeInternal, "failed to list settlement runs"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "settlement runs found successfully",
+		"data":    runs,
+	})
+}
+
+// ShowRun handles GET /admin/reconciliation/settlements/:id requests
+func (h *Handler) ShowRun(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	runID := c.Param("id")
+
+	run, err := h.reportReader.GetRun(ctx, runID)
+	if err != nil {
+		if errors.Is(err, ErrSettlementRunNotFound) {
+			problem.Respond(c, problem.New(problem.CodeNotFound, "settlement run not found"))
+			return
+		}
+
+		slog.ErrorContext(ctx, "Failed to find settlement run", "error", err, "run_id", runID)
+		problem.Respond(c, problem.New(problem.CodeInternal, "failed to find settlement run"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "settlement run found successfully",
+		"data":    run,
+	})
+}
+
+// ListItems handles GET /admin/reconciliation/settlements/:id/items requests
+func (h *Handler) ListItems(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	filter := &ItemFilter{
+		RunID:  c.Param("id"),
+		Status: ItemStatus(c.Query("status")),
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if err := filter.Validate(); err != nil {
+		problem.Respond(c, problem.Invalid(err))
+		return
+	}
+
+	items, err := h.reportReader.ListItems(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list settlement items", "error", err, "run_id", filter.RunID)
+		problem.Respond(c, problem.New(problem.CodeInternal, "failed to list settlement items"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "settlement items found successfully",
+		"data":    items,
+	})
+}
+
+// pagination reads the limit and offset query parameters
+// It writes the error response and returns false if they are invalid
+func pagination(c *gin.Context) (int, int, bool) {
+	limit, err := intQuery(c, "limit", defaultListLimit)
+	if err != nil || limit <= 0 {
+		badRequest(c, "limit must be a positive number")
+		return 0, 0, false
+	}
+
+	offset, err := intQuery(c, "offset", 0)
+	if err != nil || offset < 0 {
+		badRequest(c, "offset must be a non-negative number")
+		return 0, 0, false
+	}
+
+	return limit, offset, true
+}
+
+// badRequest writes a bad request problem with the given detail
+func badRequest(c *gin.Context, detail string) {
+	problem.Respond(c, problem.New(problem.CodeBadRequest, detail))
+}
+
+// intQuery reads an integer query parameter, returning the default value when it is not set
+func intQuery(c *gin.Context, key string, defaultValue int) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
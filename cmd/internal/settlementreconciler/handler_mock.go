@@ -0,0 +1,34 @@
+package settlementreconciler
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHandler is a mock implementation of Handler for testing
+type MockHandler struct {
+	mock.Mock
+}
+
+// Run mocks the Run method
+func (m *MockHandler) Run(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+// ListRuns mocks the ListRuns method
+func (m *MockHandler) ListRuns(c *gin.Context) {
+	m.Called(c)
+}
+
+// ShowRun mocks the ShowRun method
+func (m *MockHandler) ShowRun(c *gin.Context) {
+	m.Called(c)
+}
+
+// ListItems mocks the ListItems method
+func (m *MockHandler) ListItems(c *gin.Context) {
+	m.Called(c)
+}
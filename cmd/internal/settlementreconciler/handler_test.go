@@ -0,0 +1,339 @@
+package settlementreconciler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		settlementReconciler SettlementReconciler
+		reportReader         SettlementReportReader
+		expectedError        string
+	}{
+		{
+			name:                 "when all dependencies are provided it should create handler successfully and no error",
+			settlementReconciler: new(MockSettlementReconcilerService),
+			reportReader:         new(MockSettlementRunRepository),
+			expectedError:        "",
+		},
+		{
+			name:                 "when settlement reconciler is nil it should return error",
+			settlementReconciler: nil,
+			reportReader:         new(MockSettlementRunRepository),
+			expectedError:        "settlementreconciler handler: settlement reconciler cannot be nil",
+		},
+		{
+			name:                 "when report reader is nil it should return error",
+			settlementReconciler: new(MockSettlementReconcilerService),
+			reportReader:         nil,
+			expectedError:        "settlementreconciler handler: report reader cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewHandler(tt.settlementReconciler, tt.reportReader)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestHandler_Run(t *testing.T) {
+	tests := []struct {
+		name            string
+		mockResult      *ImportResult
+		mockImportError error
+		expectedError   error
+	}{
+		{
+			name:            "when files are imported it should return no error",
+			mockResult:      &ImportResult{Imported: 1, Skipped: 2},
+			mockImportError: nil,
+			expectedError:   nil,
+		},
+		{
+			name:            "when import fails it should return import error",
+			mockResult:      nil,
+			mockImportError: errors.New("settlement reconciler: list files: permission denied"),
+			expectedError:   errors.New("settlement reconciler: list files: permission denied"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockReconciler := new(MockSettlementReconcilerService)
+			mockReconciler.On("Import", mock.Anything).Return(tt.mockResult, tt.mockImportError)
+
+			handler := &Handler{settlementReconciler: mockReconciler}
+
+			// Act
+			err := handler.Run(context.Background())
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockReconciler.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ListRuns(t *testing.T) {
+	tests := []struct {
+		name               string
+		query              string
+		mockRuns           []*SettlementRun
+		mockListError      error
+		shouldCallList     bool
+		expectedLimit      int
+		expectedOffset     int
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when runs exist it should return 200 with runs",
+			query:              "?limit=10&offset=5",
+			mockRuns:           []*SettlementRun{{ID: "run_1", Status: RunStatusCompleted}},
+			shouldCallList:     true,
+			expectedLimit:      10,
+			expectedOffset:     5,
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "settlement runs found successfully",
+		},
+		{
+			name:               "when limit is invalid it should return 400",
+			query:              "?limit=0",
+			shouldCallList:     false,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "limit must be a positive number",
+		},
+		{
+			name:               "when offset is invalid it should return 400",
+			query:              "?offset=-1",
+			shouldCallList:     false,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "offset must be a non-negative number",
+		},
+		{
+			name:               "when list fails it should return 500",
+			query:              "",
+			mockListError:      errors.New("database error"),
+			shouldCallList:     true,
+			expectedLimit:      defaultListLimit,
+			expectedOffset:     0,
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "failed to list settlement runs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockReader := new(MockSettlementRunRepository)
+			if tt.shouldCallList {
+				mockReader.On("ListRuns", mock.Anything, tt.expectedLimit, tt.expectedOffset).Return(tt.mockRuns, tt.mockListError)
+			}
+
+			handler := &Handler{reportReader: mockReader}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/reconciliation/settlements"+tt.query, nil)
+
+			// Act
+			handler.ListRuns(c)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if w.Code >= http.StatusBadRequest {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedMessage, response["detail"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, response["message"])
+			}
+
+			mockReader.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ShowRun(t *testing.T) {
+	tests := []struct {
+		name               string
+		runID              string
+		mockRun            *SettlementRun
+		mockGetError       error
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:  "when run exists it should return 200 with run",
+			runID: "run_1",
+			mockRun: &SettlementRun{
+				ID:         "run_1",
+				FileName:   "settlement.csv",
+				Status:     RunStatusCompleted,
+				Summary:    map[ItemStatus]int{ItemStatusMatched: 1},
+				ImportedAt: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "settlement run found successfully",
+		},
+		{
+			name:               "when run does not exist it should return 404",
+			runID:              "run_404",
+			mockGetError:       ErrSettlementRunNotFound,
+			expectedStatusCode: http.StatusNotFound,
+			expectedMessage:    "settlement run not found",
+		},
+		{
+			name:               "when get fails it should return 500",
+			runID:              "run_1",
+			mockGetError:       errors.New("database error"),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "failed to find settlement run",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockReader := new(MockSettlementRunRepository)
+			mockReader.On("GetRun", mock.Anything, tt.runID).Return(tt.mockRun, tt.mockGetError)
+
+			handler := &Handler{reportReader: mockReader}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/reconciliation/settlements/"+tt.runID, nil)
+			c.Params = gin.Params{{Key: "id", Value: tt.runID}}
+
+			// Act
+			handler.ShowRun(c)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if w.Code >= http.StatusBadRequest {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedMessage, response["detail"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, response["message"])
+			}
+
+			mockReader.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ListItems(t *testing.T) {
+	tests := []struct {
+		name               string
+		query              string
+		mockItems          []*SettlementItem
+		mockListError      error
+		shouldCallList     bool
+		expectedFilter     *ItemFilter
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when status is provided it should list filtered items and return 200",
+			query:              "?status=missing",
+			mockItems:          []*SettlementItem{{ID: "item_1", RunID: "run_1", Status: ItemStatusMissing}},
+			shouldCallList:     true,
+			expectedFilter:     &ItemFilter{RunID: "run_1", Status: ItemStatusMissing, Limit: defaultListLimit},
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "settlement items found successfully",
+		},
+		{
+			name:               "when status is invalid it should return 400",
+			query:              "?status=settled",
+			shouldCallList:     false,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "invalid item status",
+		},
+		{
+			name:               "when list fails it should return 500",
+			query:              "",
+			mockListError:      errors.New("database error"),
+			shouldCallList:     true,
+			expectedFilter:     &ItemFilter{RunID: "run_1", Limit: defaultListLimit},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "failed to list settlement items",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockReader := new(MockSettlementRunRepository)
+			if tt.shouldCallList {
+				mockReader.On("ListItems", mock.Anything, tt.expectedFilter).Return(tt.mockItems, tt.mockListError)
+			}
+
+			handler := &Handler{reportReader: mockReader}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/reconciliation/settlements/run_1/items"+tt.query, nil)
+			c.Params = gin.Params{{Key: "id", Value: "run_1"}}
+
+			// Act
+			handler.ListItems(c)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if w.Code >= http.StatusBadRequest {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedMessage, response["detail"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, response["message"])
+			}
+
+			mockReader.AssertExpectations(t)
+		})
+	}
+}
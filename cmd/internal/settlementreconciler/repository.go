@@ -0,0 +1,395 @@
+package settlementreconciler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+)
+
+// SettlementFileRepository reads settlement reports from a local directory
+type SettlementFileRepository struct {
+	dir string
+}
+
+// NewSettlementFileRepository creates a new SettlementFileRepository
+// It returns a new SettlementFileRepository and an error if the directory is empty
+func NewSettlementFileRepository(dir string) (*SettlementFileRepository, error) {
+	if dir == "" {
+		return nil, errors.New("settlement files: directory cannot be empty")
+	}
+
+	return &SettlementFileRepository{dir: dir}, nil
+}
+
+// ListFiles reads every CSV and JSON file in the directory, ordered by name
+// A directory that does not exist yet holds no files
+func (r *SettlementFileRepository) ListFiles(ctx context.Context) ([]*SettlementFile, error) {
+	entries, err := os.ReadDir(r.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []*SettlementFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("settlement files: read directory: %w", err)
+	}
+
+	files := []*SettlementFile{}
+	for _, entry := range entries {
+		if entry.IsDir() || !IsSettlementFile(entry.Name()) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(r.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("settlement files: read %s: %w", entry.Name(), err)
+		}
+		files = append(files, &SettlementFile{Name: entry.Name(), Data: data})
+	}
+
+	return files, nil
+}
+
+// PaymentMatcherDB defines the database operations required by PaymentMatcherRepository
+type PaymentMatcherDB interface {
+	QueryContext(ctx context.Context, query string, args ...any) (database.Rows, error)
+}
+
+// PaymentMatcherRepository finds payments and earlier settlements by gateway reference
+type PaymentMatcherRepository struct {
+	db PaymentMatcherDB
+}
+
+// NewPaymentMatcherRepository creates a new PaymentMatcherRepository
+// It returns a new PaymentMatcherRepository and an error if the database is nil
+func NewPaymentMatcherRepository(db PaymentMatcherDB) (*PaymentMatcherRepository, error) {
+	if db == nil {
+		return nil, errors.New("payment matcher: database cannot be nil")
+	}
+
+	return &PaymentMatcherRepository{db: db}, nil
+}
+
+// FindByGatewayRefs finds the payments with the given gateway references
+// It returns the payments keyed by gateway reference
+func (r *PaymentMatcherRepository) FindByGatewayRefs(ctx context.Context, gatewayRefs []string) (map[string]*SettledPayment, error) {
+	query := `
+		SELECT id, gateway_ref, amount, currency, status
+		FROM payments
+		WHERE gateway_ref = ANY($1)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(gatewayRefs))
+	if err != nil {
+		return nil, fmt.Errorf("payment matcher: find payments: %w", err)
+	}
+	defer rows.Close()
+
+	payments := map[string]*SettledPayment{}
+	for rows.Next() {
+		var payment SettledPayment
+		if err := rows.Scan(&payment.PaymentID, &payment.GatewayRef, &payment.Amount, &payment.Currency, &payment.Status); err != nil {
+			return nil, fmt.Errorf("payment matcher: scan payment: %w", err)
+		}
+		payments[payment.GatewayRef] = &payment
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("payment matcher: iterate payments: %w", err)
+	}
+
+	return payments, nil
+}
+
+// FindSettledRefs finds which of the given gateway references were reported by earlier runs
+func (r *PaymentMatcherRepository) FindSettledRefs(ctx context.Context, gatewayRefs []string) (map[string]bool, error) {
+	query := `
+		SELECT DISTINCT gateway_ref
+		FROM settlement_items
+		WHERE gateway_ref = ANY($1)
+			AND status NOT IN ('duplicate', 'missing')
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(gatewayRefs))
+	if err != nil {
+		return nil, fmt.Errorf("payment matcher: find settled references: %w", err)
+	}
+	defer rows.Close()
+
+	settled := map[string]bool{}
+	for rows.Next() {
+		var gatewayRef string
+		if err := rows.Scan(&gatewayRef); err != nil {
+			return nil, fmt.Errorf("payment matcher: scan settled reference: %w", err)
+		}
+		settled[gatewayRef] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("payment matcher: iterate settled references: %w", err)
+	}
+
+	return settled, nil
+}
+
+// FindUnsettled finds the payments charged by the gateway between the given times that no run has reported
+func (r *PaymentMatcherRepository) FindUnsettled(ctx context.Context, completedAfter, completedBefore time.Time) ([]*SettledPayment, error) {
+	query := `
+		SELECT p.id, p.gateway_ref, p.amount, p.currency, p.status
+		FROM payments p
+		WHERE p.status IN ('completed', 'pending_confirm')
+			AND p.gateway_ref IS NOT NULL
+			AND p.gateway_ref <> ''
+			AND p.updated_at >= $1
+			AND p.updated_at < $2
+			AND NOT EXISTS (
+				SELECT 1 FROM settlement_items i
+				WHERE i.gateway_ref = p.gateway_ref
+					AND i.status NOT IN ('duplicate', 'missing')
+			)
+		ORDER BY p.updated_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, completedAfter, completedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("payment matcher: find unsettled payments: %w", err)
+	}
+	defer rows.Close()
+
+	payments := []*SettledPayment{}
+	for rows.Next() {
+		var payment SettledPayment
+		if err := rows.Scan(&payment.PaymentID, &payment.GatewayRef, &payment.Amount, &payment.Currency, &payment.Status); err != nil {
+			return nil, fmt.Errorf("payment matcher: scan unsettled payment: %w", err)
+		}
+		payments = append(payments, &payment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("payment matcher: iterate unsettled payments: %w", err)
+	}
+
+	return payments, nil
+}
+
+// SettlementRunDB defines the database operations required by SettlementRunRepository
+type SettlementRunDB interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) database.RowScanner
+	QueryContext(ctx context.Context, query string, args ...any) (database.Rows, error)
+	WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error
+}
+
+// SettlementRunRepository stores settlement runs and their items
+type SettlementRunRepository struct {
+	db SettlementRunDB
+}
+
+// NewSettlementRunRepository creates a new SettlementRunRepository
+// It returns a new SettlementRunRepository and an error if the database is nil
+func NewSettlementRunRepository(db SettlementRunDB) (*SettlementRunRepository, error) {
+	if db == nil {
+		return nil, errors.New("settlement run repository: database cannot be nil")
+	}
+
+	return &SettlementRunRepository{db: db}, nil
+}
+
+// Exists reports whether a file with the given checksum was already imported
+func (r *SettlementRunRepository) Exists(ctx context.Context, checksum string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM settlement_runs WHERE checksum = $1)`
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, checksum).Scan(&exists); err != nil {
+		return false, fmt.Errorf("settlement run repository: exists: %w", err)
+	}
+
+	return exists, nil
+}
+
+// Save stores a run with its items in a single transaction
+func (r *SettlementRunRepository) Save(ctx context.Context, run *SettlementRun, items []*SettlementItem) error {
+	summary, err := json.Marshal(run.Summary)
+	if err != nil {
+		return fmt.Errorf("settlement run repository: marshal summary: %w", err)
+	}
+
+	err = r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		runQuery := `
+			INSERT INTO settlement_runs (id, file_name, checksum, status, error, total_rows, summary, imported_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`
+		_, err := tx.ExecContext(ctx, runQuery,
+			run.ID,
+			run.FileName,
+			run.Checksum,
+			run.Status,
+			run.Error,
+			run.TotalRows,
+			summary,
+			run.ImportedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("insert run: %w", err)
+		}
+
+		itemQuery := `
+			INSERT INTO settlement_items (id, run_id, gateway_ref, payment_id, status, reported_amount, reported_currency, expected_amount, expected_currency, settled_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`
+		for _, item := range items {
+			_, err := tx.ExecContext(ctx, itemQuery,
+				item.ID,
+				item.RunID,
+				item.GatewayRef,
+				item.PaymentID,
+				item.Status,
+				item.ReportedAmount,
+				item.ReportedCurrency,
+				item.ExpectedAmount,
+				item.ExpectedCurrency,
+				item.SettledAt,
+			)
+			if err != nil {
+				return fmt.Errorf("insert item: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("settlement run repository: save: %w", err)
+	}
+
+	return nil
+}
+
+// ListRuns lists the runs, most recently imported first
+func (r *SettlementRunRepository) ListRuns(ctx context.Context, limit, offset int) ([]*SettlementRun, error) {
+	query := `
+		SELECT id, file_name, checksum, status, error, total_rows, summary, imported_at
+		FROM settlement_runs
+		ORDER BY imported_at DESC, id ASC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("settlement run repository: list runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []*SettlementRun{}
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("settlement run repository: scan run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("settlement run repository: iterate runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// GetRun retrieves a run by its ID
+// It returns ErrSettlementRunNotFound if the run does not exist
+func (r *SettlementRunRepository) GetRun(ctx context.Context, runID string) (*SettlementRun, error) {
+	query := `
+		SELECT id, file_name, checksum, status, error, total_rows, summary, imported_at
+		FROM settlement_runs
+		WHERE id = $1
+	`
+
+	run, err := scanRun(r.db.QueryRowContext(ctx, query, runID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSettlementRunNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("settlement run repository: get run: %w", err)
+	}
+
+	return run, nil
+}
+
+// ListItems lists the items of a run matching the filter, in file order with missing payments last
+func (r *SettlementRunRepository) ListItems(ctx context.Context, filter *ItemFilter) ([]*SettlementItem, error) {
+	query := `
+		SELECT id, run_id, gateway_ref, payment_id, status, reported_amount, reported_currency, expected_amount, expected_currency, settled_at
+		FROM settlement_items
+		WHERE run_id = $1
+			AND ($2 = '' OR status = $2)
+		ORDER BY seq ASC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.RunID, filter.Status, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("settlement run repository: list items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []*SettlementItem{}
+	for rows.Next() {
+		var item SettlementItem
+		var settledAt sql.NullTime
+		err := rows.Scan(
+			&item.ID,
+			&item.RunID,
+			&item.GatewayRef,
+			&item.PaymentID,
+			&item.Status,
+			&item.ReportedAmount,
+			&item.ReportedCurrency,
+			&item.ExpectedAmount,
+			&item.ExpectedCurrency,
+			&settledAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("settlement run repository: scan item: %w", err)
+		}
+		if settledAt.Valid {
+			item.SettledAt = &settledAt.Time
+		}
+		items = append(items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("settlement run repository: iterate items: %w", err)
+	}
+
+	return items, nil
+}
+
+// scanRun scans a settlement run row
+func scanRun(row database.RowScanner) (*SettlementRun, error) {
+	var run SettlementRun
+	var summary []byte
+	err := row.Scan(
+		&run.ID,
+		&run.FileName,
+		&run.Checksum,
+		&run.Status,
+		&run.Error,
+		&run.TotalRows,
+		&summary,
+		&run.ImportedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(summary, &run.Summary); err != nil {
+		return nil, fmt.Errorf("unmarshal summary: %w", err)
+	}
+
+	return &run, nil
+}
@@ -0,0 +1,98 @@
+package settlementreconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockFileSource is a mock implementation of FileSource for testing
+type MockFileSource struct {
+	mock.Mock
+}
+
+// ListFiles mocks the ListFiles method
+func (m *MockFileSource) ListFiles(ctx context.Context) ([]*SettlementFile, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*SettlementFile), args.Error(1)
+}
+
+// MockPaymentMatcher is a mock implementation of PaymentMatcher for testing
+type MockPaymentMatcher struct {
+	mock.Mock
+}
+
+// FindByGatewayRefs mocks the FindByGatewayRefs method
+func (m *MockPaymentMatcher) FindByGatewayRefs(ctx context.Context, gatewayRefs []string) (map[string]*SettledPayment, error) {
+	args := m.Called(ctx, gatewayRefs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*SettledPayment), args.Error(1)
+}
+
+// FindSettledRefs mocks the FindSettledRefs method
+func (m *MockPaymentMatcher) FindSettledRefs(ctx context.Context, gatewayRefs []string) (map[string]bool, error) {
+	args := m.Called(ctx, gatewayRefs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]bool), args.Error(1)
+}
+
+// FindUnsettled mocks the FindUnsettled method
+func (m *MockPaymentMatcher) FindUnsettled(ctx context.Context, completedAfter, completedBefore time.Time) ([]*SettledPayment, error) {
+	args := m.Called(ctx, completedAfter, completedBefore)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*SettledPayment), args.Error(1)
+}
+
+// MockSettlementRunRepository is a mock implementation of RunStore and SettlementReportReader for testing
+type MockSettlementRunRepository struct {
+	mock.Mock
+}
+
+// Exists mocks the Exists method
+func (m *MockSettlementRunRepository) Exists(ctx context.Context, checksum string) (bool, error) {
+	args := m.Called(ctx, checksum)
+	return args.Bool(0), args.Error(1)
+}
+
+// Save mocks the Save method
+func (m *MockSettlementRunRepository) Save(ctx context.Context, run *SettlementRun, items []*SettlementItem) error {
+	args := m.Called(ctx, run, items)
+	return args.Error(0)
+}
+
+// ListRuns mocks the ListRuns method
+func (m *MockSettlementRunRepository) ListRuns(ctx context.Context, limit, offset int) ([]*SettlementRun, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*SettlementRun), args.Error(1)
+}
+
+// GetRun mocks the GetRun method
+func (m *MockSettlementRunRepository) GetRun(ctx context.Context, runID string) (*SettlementRun, error) {
+	args := m.Called(ctx, runID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*SettlementRun), args.Error(1)
+}
+
+// ListItems mocks the ListItems method
+func (m *MockSettlementRunRepository) ListItems(ctx context.Context, filter *ItemFilter) ([]*SettlementItem, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*SettlementItem), args.Error(1)
+}
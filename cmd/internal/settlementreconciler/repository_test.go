@@ -0,0 +1,506 @@
+package settlementreconciler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewSettlementFileRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		dir           string
+		expectedError string
+	}{
+		{
+			name:          "when directory is provided it should create repository successfully and no error",
+			dir:           "settlements",
+			expectedError: "",
+		},
+		{
+			name:          "when directory is empty it should return error with message 'settlement files: directory cannot be empty'",
+			dir:           "",
+			expectedError: "settlement files: directory cannot be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Directory already prepared in test struct)
+
+			// Act
+			result, err := NewSettlementFileRepository(tt.dir)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestSettlementFileRepository_ListFiles(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         map[string]string
+		missingDir    bool
+		expectedFiles []*SettlementFile
+	}{
+		{
+			name: "when directory has settlement files it should return CSV and JSON files ordered by name and no error",
+			files: map[string]string{
+				"b.json":     "[]",
+				"a.csv":      "gateway_ref,amount,currency\n",
+				"readme.txt": "ignored",
+			},
+			expectedFiles: []*SettlementFile{
+				{Name: "a.csv", Data: []byte("gateway_ref,amount,currency\n")},
+				{Name: "b.json", Data: []byte("[]")},
+			},
+		},
+		{
+			name:          "when directory does not exist it should return empty slice and no error",
+			missingDir:    true,
+			expectedFiles: []*SettlementFile{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)
+				assert.NoError(t, err)
+			}
+			if tt.missingDir {
+				dir = filepath.Join(dir, "missing")
+			}
+
+			repo := &SettlementFileRepository{dir: dir}
+
+			// Act
+			result, err := repo.ListFiles(context.Background())
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedFiles, result)
+		})
+	}
+}
+
+func TestNewPaymentMatcherRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            PaymentMatcherDB
+		expectedError string
+	}{
+		{
+			name:          "when database is provided it should create repository successfully and no error",
+			db:            new(database.MockDB),
+			expectedError: "",
+		},
+		{
+			name:          "when database is nil it should return error with message 'payment matcher: database cannot be nil'",
+			db:            nil,
+			expectedError: "payment matcher: database cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Database already prepared in test struct)
+
+			// Act
+			result, err := NewPaymentMatcherRepository(tt.db)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestPaymentMatcherRepository_FindByGatewayRefs(t *testing.T) {
+	tests := []struct {
+		name             string
+		mockPayments     []*SettledPayment
+		mockQueryError   error
+		mockScanError    error
+		expectedPayments map[string]*SettledPayment
+		expectedError    error
+	}{
+		{
+			name: "when payments exist it should return payments keyed by gateway reference and no error",
+			mockPayments: []*SettledPayment{
+				{PaymentID: "pay_1", GatewayRef: "gw_1", Amount: 100.50, Currency: domain.CurrencyUSD, Status: domain.StatusCompleted},
+			},
+			expectedPayments: map[string]*SettledPayment{
+				"gw_1": {PaymentID: "pay_1", GatewayRef: "gw_1", Amount: 100.50, Currency: domain.CurrencyUSD, Status: domain.StatusCompleted},
+			},
+			expectedError: nil,
+		},
+		{
+			name:           "when query fails it should return wrapped error",
+			mockQueryError: errors.New("connection refused"),
+			expectedError:  errors.New("payment matcher: find payments: connection refused"),
+		},
+		{
+			name:          "when scan fails it should return wrapped error",
+			mockScanError: errors.New("scan error"),
+			expectedError: errors.New("payment matcher: scan payment: scan error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockRows := new(database.MockRows)
+
+			if tt.mockQueryError != nil {
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, tt.mockQueryError)
+			} else {
+				if tt.mockScanError != nil {
+					mockRows.On("Next").Return(true).Once()
+					mockRows.On("Scan", mock.Anything).Return(tt.mockScanError)
+				} else {
+					count := len(tt.mockPayments)
+					mockRows.On("Next").Return(true).Times(count)
+					scanCallCount := 0
+					mockRows.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+						dest := args.Get(0).([]any)
+						payment := tt.mockPayments[scanCallCount]
+						*dest[0].(*string) = payment.PaymentID
+						*dest[1].(*string) = payment.GatewayRef
+						*dest[2].(*float64) = payment.Amount
+						*dest[3].(*domain.Currency) = payment.Currency
+						*dest[4].(*domain.Status) = payment.Status
+						scanCallCount++
+					}).Return(nil).Times(count)
+					mockRows.On("Next").Return(false).Once()
+					mockRows.On("Err").Return(nil)
+				}
+				mockRows.On("Close").Return(nil)
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(mockRows, nil)
+			}
+
+			repo := &PaymentMatcherRepository{db: mockDB}
+
+			// Act
+			result, err := repo.FindByGatewayRefs(context.Background(), []string{"gw_1"})
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedPayments, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPaymentMatcherRepository_FindSettledRefs(t *testing.T) {
+	tests := []struct {
+		name            string
+		mockRefs        []string
+		mockQueryError  error
+		expectedSettled map[string]bool
+		expectedError   error
+	}{
+		{
+			name:            "when references were settled it should return them and no error",
+			mockRefs:        []string{"gw_1"},
+			expectedSettled: map[string]bool{"gw_1": true},
+			expectedError:   nil,
+		},
+		{
+			name:           "when query fails it should return wrapped error",
+			mockQueryError: errors.New("connection refused"),
+			expectedError:  errors.New("payment matcher: find settled references: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockRows := new(database.MockRows)
+
+			if tt.mockQueryError != nil {
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, tt.mockQueryError)
+			} else {
+				count := len(tt.mockRefs)
+				mockRows.On("Next").Return(true).Times(count)
+				scanCallCount := 0
+				mockRows.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+					dest := args.Get(0).([]any)
+					*dest[0].(*string) = tt.mockRefs[scanCallCount]
+					scanCallCount++
+				}).Return(nil).Times(count)
+				mockRows.On("Next").Return(false).Once()
+				mockRows.On("Err").Return(nil)
+				mockRows.On("Close").Return(nil)
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(mockRows, nil)
+			}
+
+			repo := &PaymentMatcherRepository{db: mockDB}
+
+			// Act
+			result, err := repo.FindSettledRefs(context.Background(), []string{"gw_1", "gw_2"})
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedSettled, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewSettlementRunRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            SettlementRunDB
+		expectedError string
+	}{
+		{
+			name:          "when database is provided it should create repository successfully and no error",
+			db:            new(database.MockDB),
+			expectedError: "",
+		},
+		{
+			name:          "when database is nil it should return error with message 'settlement run repository: database cannot be nil'",
+			db:            nil,
+			expectedError: "settlement run repository: database cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Database already prepared in test struct)
+
+			// Act
+			result, err := NewSettlementRunRepository(tt.db)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestSettlementRunRepository_Save(t *testing.T) {
+	tests := []struct {
+		name                 string
+		mockTransactionError error
+		expectedError        error
+	}{
+		{
+			name:                 "when transaction succeeds it should return no error",
+			mockTransactionError: nil,
+			expectedError:        nil,
+		},
+		{
+			name:                 "when transaction fails it should return wrapped error",
+			mockTransactionError: errors.New("insert run: connection refused"),
+			expectedError:        errors.New("settlement run repository: save: insert run: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockDB.On("WithTransaction", mock.Anything, mock.Anything).Return(tt.mockTransactionError)
+
+			repo := &SettlementRunRepository{db: mockDB}
+			run := &SettlementRun{ID: "run_1", Status: RunStatusCompleted, Summary: map[ItemStatus]int{ItemStatusMatched: 1}}
+
+			// Act
+			err := repo.Save(context.Background(), run, []*SettlementItem{{ID: "item_1", RunID: "run_1"}})
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSettlementRunRepository_GetRun(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	run := &SettlementRun{
+		ID:         "run_1",
+		FileName:   "settlement.csv",
+		Checksum:   "abc",
+		Status:     RunStatusCompleted,
+		TotalRows:  2,
+		Summary:    map[ItemStatus]int{ItemStatusMatched: 1, ItemStatusDuplicate: 1},
+		ImportedAt: fixedTime,
+	}
+
+	tests := []struct {
+		name          string
+		mockScanError error
+		expectedRun   *SettlementRun
+		expectedError error
+	}{
+		{
+			name:          "when run exists it should return run and no error",
+			mockScanError: nil,
+			expectedRun:   run,
+			expectedError: nil,
+		},
+		{
+			name:          "when run does not exist it should return not found error",
+			mockScanError: sql.ErrNoRows,
+			expectedError: ErrSettlementRunNotFound,
+		},
+		{
+			name:          "when scan fails it should return wrapped error",
+			mockScanError: errors.New("connection refused"),
+			expectedError: errors.New("settlement run repository: get run: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockScanner := new(database.MockRowScanner)
+
+			if tt.mockScanError == nil {
+				summary, _ := json.Marshal(run.Summary)
+				mockScanner.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+					dest := args.Get(0).([]any)
+					*dest[0].(*string) = run.ID
+					*dest[1].(*string) = run.FileName
+					*dest[2].(*string) = run.Checksum
+					*dest[3].(*RunStatus) = run.Status
+					*dest[4].(*string) = run.Error
+					*dest[5].(*int) = run.TotalRows
+					*dest[6].(*[]byte) = summary
+					*dest[7].(*time.Time) = run.ImportedAt
+				}).Return(nil)
+			} else {
+				mockScanner.On("Scan", mock.Anything).Return(tt.mockScanError)
+			}
+			mockDB.On("QueryRowContext", mock.Anything, mock.Anything, mock.Anything).Return(mockScanner)
+
+			repo := &SettlementRunRepository{db: mockDB}
+
+			// Act
+			result, err := repo.GetRun(context.Background(), "run_1")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedRun, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSettlementRunRepository_Exists(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockExists     bool
+		mockScanError  error
+		expectedExists bool
+		expectedError  error
+	}{
+		{
+			name:           "when checksum was imported it should return true and no error",
+			mockExists:     true,
+			expectedExists: true,
+			expectedError:  nil,
+		},
+		{
+			name:          "when scan fails it should return wrapped error",
+			mockScanError: errors.New("connection refused"),
+			expectedError: errors.New("settlement run repository: exists: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockScanner := new(database.MockRowScanner)
+			mockScanner.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+				dest := args.Get(0).([]any)
+				*dest[0].(*bool) = tt.mockExists
+			}).Return(tt.mockScanError)
+			mockDB.On("QueryRowContext", mock.Anything, mock.Anything, mock.Anything).Return(mockScanner)
+
+			repo := &SettlementRunRepository{db: mockDB}
+
+			// Act
+			result, err := repo.Exists(context.Background(), "abc")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedExists, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package settlementreconciler
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Start starts the settlement reconciler router
+// It starts the settlement reconciler router and returns an error if the builder fails
+func Start(rg *gin.RouterGroup, db SettlementDB, dir string, policy ReconciliationPolicy) error {
+	h, err := Build(db, dir, policy)
+	if err != nil {
+		return err
+	}
+
+	settlements := rg.Group("/admin/reconciliation/settlements")
+	settlements.GET("", h.ListRuns)
+	settlements.GET("/:id", h.ShowRun)
+	settlements.GET("/:id/items", h.ListItems)
+	return nil
+}
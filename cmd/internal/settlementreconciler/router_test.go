@@ -0,0 +1,49 @@
+package settlementreconciler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            SettlementDB
+		dir           string
+		policy        ReconciliationPolicy
+		expectedError bool
+	}{
+		{
+			name:          "when database is nil it should return error",
+			db:            nil,
+			dir:           "settlements",
+			policy:        ReconciliationPolicy{Grace: 48 * time.Hour, Lookback: 720 * time.Hour},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+			rg := router.Group("/api/v1")
+
+			// Act
+			err := Start(rg, tt.db, tt.dir, tt.policy)
+
+			// Assert
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
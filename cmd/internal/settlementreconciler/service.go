@@ -0,0 +1,172 @@
+package settlementreconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileSource interface for reading the settlement reports
+type FileSource interface {
+	ListFiles(ctx context.Context) ([]*SettlementFile, error)
+}
+
+// PaymentMatcher interface for finding the payments and earlier settlements of a report
+type PaymentMatcher interface {
+	FindByGatewayRefs(ctx context.Context, gatewayRefs []string) (map[string]*SettledPayment, error)
+	FindSettledRefs(ctx context.Context, gatewayRefs []string) (map[string]bool, error)
+	FindUnsettled(ctx context.Context, completedAfter, completedBefore time.Time) ([]*SettledPayment, error)
+}
+
+// RunStore interface for storing the settlement runs
+type RunStore interface {
+	Exists(ctx context.Context, checksum string) (bool, error)
+	Save(ctx context.Context, run *SettlementRun, items []*SettlementItem) error
+}
+
+// SettlementReconcilerService is a service for reconciling completed payments with the gateway settlement reports
+type SettlementReconcilerService struct {
+	fileSource     FileSource
+	paymentMatcher PaymentMatcher
+	runStore       RunStore
+	policy         ReconciliationPolicy
+}
+
+// NewSettlementReconcilerService creates a new SettlementReconcilerService
+// It returns a new SettlementReconcilerService and an error if any dependency is nil or the policy is invalid
+func NewSettlementReconcilerService(fs FileSource, pm PaymentMatcher, rs RunStore, policy ReconciliationPolicy) (*SettlementReconcilerService, error) {
+	if fs == nil {
+		return nil, errors.New("settlement reconciler: file source cannot be nil")
+	}
+	if pm == nil {
+		return nil, errors.New("settlement reconciler: payment matcher cannot be nil")
+	}
+	if rs == nil {
+		return nil, errors.New("settlement reconciler: run store cannot be nil")
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("settlement reconciler: invalid policy: %w", err)
+	}
+
+	return &SettlementReconcilerService{
+		fileSource:     fs,
+		paymentMatcher: pm,
+		runStore:       rs,
+		policy:         policy,
+	}, nil
+}
+
+// Import imports every settlement file not imported yet
+// It returns the run summary and an error only if the files cannot be listed
+func (s *SettlementReconcilerService) Import(ctx context.Context) (*ImportResult, error) {
+	files, err := s.fileSource.ListFiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("settlement reconciler: list files: %w", err)
+	}
+
+	result := &ImportResult{}
+	for _, file := range files {
+		run, err := s.importFile(ctx, file)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to import settlement file", "error", err, "file", file.Name)
+			result.Errors++
+			continue
+		}
+
+		switch {
+		case run == nil:
+			result.Skipped++
+		case run.Status == RunStatusFailed:
+			slog.WarnContext(ctx, "Settlement file rejected", "file", file.Name, "run_id", run.ID, "error", run.Error)
+			result.Rejected++
+		default:
+			slog.InfoContext(ctx, "Settlement file imported", "file", file.Name, "run_id", run.ID, "summary", run.Summary)
+			result.Imported++
+		}
+	}
+
+	return result, nil
+}
+
+// importFile reconciles a single file and stores the run
+// It returns a nil run if the file was already imported
+func (s *SettlementReconcilerService) importFile(ctx context.Context, file *SettlementFile) (*SettlementRun, error) {
+	checksum := file.Checksum()
+
+	exists, err := s.runStore.Exists(ctx, checksum)
+	if err != nil {
+		return nil, fmt.Errorf("settlement reconciler: check file: %w", err)
+	}
+	if exists {
+		return nil, nil
+	}
+
+	now := time.Now()
+	run := &SettlementRun{
+		ID:         uuid.New().String(),
+		FileName:   file.Name,
+		Checksum:   checksum,
+		Status:     RunStatusCompleted,
+		Summary:    map[ItemStatus]int{},
+		ImportedAt: now,
+	}
+
+	records, err := ParseSettlementFile(file)
+	if err != nil {
+		// A malformed file is recorded once so it is not parsed again on every run
+		run.Status = RunStatusFailed
+		run.Error = err.Error()
+		if err := s.runStore.Save(ctx, run, nil); err != nil {
+			return nil, fmt.Errorf("settlement reconciler: save run: %w", err)
+		}
+		return run, nil
+	}
+
+	items, err := s.reconcile(ctx, records, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		item.ID = uuid.New().String()
+		item.RunID = run.ID
+	}
+	run.TotalRows = len(records)
+	run.Summary = Summarize(items)
+
+	if err := s.runStore.Save(ctx, run, items); err != nil {
+		return nil, fmt.Errorf("settlement reconciler: save run: %w", err)
+	}
+
+	return run, nil
+}
+
+// reconcile matches the records to payments and appends the completed payments that are still unsettled
+func (s *SettlementReconcilerService) reconcile(ctx context.Context, records []*SettlementRecord, now time.Time) ([]*SettlementItem, error) {
+	gatewayRefs := make([]string, 0, len(records))
+	for _, record := range records {
+		gatewayRefs = append(gatewayRefs, record.GatewayRef)
+	}
+
+	payments, err := s.paymentMatcher.FindByGatewayRefs(ctx, gatewayRefs)
+	if err != nil {
+		return nil, fmt.Errorf("settlement reconciler: find payments: %w", err)
+	}
+
+	settled, err := s.paymentMatcher.FindSettledRefs(ctx, gatewayRefs)
+	if err != nil {
+		return nil, fmt.Errorf("settlement reconciler: find settled references: %w", err)
+	}
+
+	unsettled, err := s.paymentMatcher.FindUnsettled(ctx, now.Add(-s.policy.Lookback), now.Add(-s.policy.Grace))
+	if err != nil {
+		return nil, fmt.Errorf("settlement reconciler: find unsettled payments: %w", err)
+	}
+
+	items := Match(records, payments, settled)
+	return append(items, Missing(unsettled, records)...), nil
+}
@@ -0,0 +1,21 @@
+package settlementreconciler
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSettlementReconcilerService is a mock implementation of SettlementReconciler for testing
+type MockSettlementReconcilerService struct {
+	mock.Mock
+}
+
+// Import mocks the Import method
+func (m *MockSettlementReconcilerService) Import(ctx context.Context) (*ImportResult, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ImportResult), args.Error(1)
+}
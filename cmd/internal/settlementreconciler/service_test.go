@@ -0,0 +1,246 @@
+package settlementreconciler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewSettlementReconcilerService(t *testing.T) {
+	validPolicy := ReconciliationPolicy{Grace: 48 * time.Hour, Lookback: 720 * time.Hour}
+
+	tests := []struct {
+		name           string
+		fileSource     FileSource
+		paymentMatcher PaymentMatcher
+		runStore       RunStore
+		policy         ReconciliationPolicy
+		expectedError  string
+	}{
+		{
+			name:           "when all dependencies are provided it should create service successfully and no error",
+			fileSource:     new(MockFileSource),
+			paymentMatcher: new(MockPaymentMatcher),
+			runStore:       new(MockSettlementRunRepository),
+			policy:         validPolicy,
+			expectedError:  "",
+		},
+		{
+			name:           "when file source is nil it should return error",
+			fileSource:     nil,
+			paymentMatcher: new(MockPaymentMatcher),
+			runStore:       new(MockSettlementRunRepository),
+			policy:         validPolicy,
+			expectedError:  "settlement reconciler: file source cannot be nil",
+		},
+		{
+			name:           "when payment matcher is nil it should return error",
+			fileSource:     new(MockFileSource),
+			paymentMatcher: nil,
+			runStore:       new(MockSettlementRunRepository),
+			policy:         validPolicy,
+			expectedError:  "settlement reconciler: payment matcher cannot be nil",
+		},
+		{
+			name:           "when run store is nil it should return error",
+			fileSource:     new(MockFileSource),
+			paymentMatcher: new(MockPaymentMatcher),
+			runStore:       nil,
+			policy:         validPolicy,
+			expectedError:  "settlement reconciler: run store cannot be nil",
+		},
+		{
+			name:           "when policy is invalid it should return error",
+			fileSource:     new(MockFileSource),
+			paymentMatcher: new(MockPaymentMatcher),
+			runStore:       new(MockSettlementRunRepository),
+			policy:         ReconciliationPolicy{Grace: 0, Lookback: 720 * time.Hour},
+			expectedError:  "settlement reconciler: invalid policy: grace must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewSettlementReconcilerService(tt.fileSource, tt.paymentMatcher, tt.runStore, tt.policy)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestSettlementReconcilerService_Import(t *testing.T) {
+	policy := ReconciliationPolicy{Grace: 48 * time.Hour, Lookback: 720 * time.Hour}
+
+	validFile := &SettlementFile{Name: "settlement.csv", Data: []byte("gateway_ref,amount,currency\ngw_1,100.50,USD\n")}
+	invalidFile := &SettlementFile{Name: "settlement.csv", Data: []byte("gateway_ref,amount\ngw_1,100.50\n")}
+
+	payments := map[string]*SettledPayment{
+		"gw_1": {PaymentID: "pay_1", GatewayRef: "gw_1", Amount: 100.50, Currency: domain.CurrencyUSD, Status: domain.StatusCompleted},
+	}
+	unsettled := []*SettledPayment{
+		{PaymentID: "pay_2", GatewayRef: "gw_2", Amount: 20, Currency: domain.CurrencyUSD, Status: domain.StatusCompleted},
+	}
+
+	tests := []struct {
+		name              string
+		mockFiles         []*SettlementFile
+		mockListError     error
+		mockExists        bool
+		mockExistsError   error
+		mockFindError     error
+		mockSaveError     error
+		shouldCallExists  bool
+		shouldCallMatcher bool
+		shouldCallSave    bool
+		expectedRunStatus RunStatus
+		expectedSummary   map[ItemStatus]int
+		expectedResult    *ImportResult
+		expectedError     error
+	}{
+		{
+			name:           "when there are no files it should return empty result and no error",
+			mockFiles:      []*SettlementFile{},
+			expectedResult: &ImportResult{},
+			expectedError:  nil,
+		},
+		{
+			name:          "when files cannot be listed it should return wrapped error",
+			mockListError: errors.New("permission denied"),
+			expectedError: errors.New("settlement reconciler: list files: permission denied"),
+		},
+		{
+			name:             "when file was already imported it should skip it and no error",
+			mockFiles:        []*SettlementFile{validFile},
+			mockExists:       true,
+			shouldCallExists: true,
+			expectedResult:   &ImportResult{Skipped: 1},
+			expectedError:    nil,
+		},
+		{
+			name:              "when file is valid it should save a completed run with matched and missing items and no error",
+			mockFiles:         []*SettlementFile{validFile},
+			shouldCallExists:  true,
+			shouldCallMatcher: true,
+			shouldCallSave:    true,
+			expectedRunStatus: RunStatusCompleted,
+			expectedSummary:   map[ItemStatus]int{ItemStatusMatched: 1, ItemStatusMissing: 1},
+			expectedResult:    &ImportResult{Imported: 1},
+			expectedError:     nil,
+		},
+		{
+			name:              "when file cannot be parsed it should save a failed run and no error",
+			mockFiles:         []*SettlementFile{invalidFile},
+			shouldCallExists:  true,
+			shouldCallSave:    true,
+			expectedRunStatus: RunStatusFailed,
+			expectedSummary:   map[ItemStatus]int{},
+			expectedResult:    &ImportResult{Rejected: 1},
+			expectedError:     nil,
+		},
+		{
+			name:             "when import check fails it should count the error and no error",
+			mockFiles:        []*SettlementFile{validFile},
+			mockExistsError:  errors.New("database error"),
+			shouldCallExists: true,
+			expectedResult:   &ImportResult{Errors: 1},
+			expectedError:    nil,
+		},
+		{
+			name:              "when payments cannot be found it should count the error and no error",
+			mockFiles:         []*SettlementFile{validFile},
+			mockFindError:     errors.New("database error"),
+			shouldCallExists:  true,
+			shouldCallMatcher: true,
+			expectedResult:    &ImportResult{Errors: 1},
+			expectedError:     nil,
+		},
+		{
+			name:              "when run cannot be saved it should count the error and no error",
+			mockFiles:         []*SettlementFile{validFile},
+			mockSaveError:     errors.New("database error"),
+			shouldCallExists:  true,
+			shouldCallMatcher: true,
+			shouldCallSave:    true,
+			expectedRunStatus: RunStatusCompleted,
+			expectedSummary:   map[ItemStatus]int{ItemStatusMatched: 1, ItemStatusMissing: 1},
+			expectedResult:    &ImportResult{Errors: 1},
+			expectedError:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockSource := new(MockFileSource)
+			mockMatcher := new(MockPaymentMatcher)
+			mockStore := new(MockSettlementRunRepository)
+
+			if tt.mockListError != nil {
+				mockSource.On("ListFiles", mock.Anything).Return(nil, tt.mockListError)
+			} else {
+				mockSource.On("ListFiles", mock.Anything).Return(tt.mockFiles, nil)
+			}
+
+			if tt.shouldCallExists {
+				mockStore.On("Exists", mock.Anything, tt.mockFiles[0].Checksum()).Return(tt.mockExists, tt.mockExistsError)
+			}
+
+			if tt.shouldCallMatcher {
+				if tt.mockFindError != nil {
+					mockMatcher.On("FindByGatewayRefs", mock.Anything, []string{"gw_1"}).Return(nil, tt.mockFindError)
+				} else {
+					mockMatcher.On("FindByGatewayRefs", mock.Anything, []string{"gw_1"}).Return(payments, nil)
+					mockMatcher.On("FindSettledRefs", mock.Anything, []string{"gw_1"}).Return(map[string]bool{}, nil)
+					mockMatcher.On("FindUnsettled", mock.Anything, mock.Anything, mock.Anything).Return(unsettled, nil)
+				}
+			}
+
+			if tt.shouldCallSave {
+				mockStore.On("Save", mock.Anything, mock.MatchedBy(func(run *SettlementRun) bool {
+					return run.ID != "" && run.Status == tt.expectedRunStatus && assert.ObjectsAreEqual(tt.expectedSummary, run.Summary)
+				}), mock.Anything).Return(tt.mockSaveError)
+			}
+
+			service := &SettlementReconcilerService{
+				fileSource:     mockSource,
+				paymentMatcher: mockMatcher,
+				runStore:       mockStore,
+				policy:         policy,
+			}
+
+			// Act
+			result, err := service.Import(context.Background())
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, result)
+			}
+
+			mockSource.AssertExpectations(t)
+			mockMatcher.AssertExpectations(t)
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package settler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+)
+
+const (
+	SignatureHeader = "X-Gateway-Signature" // Signature of the callback body, "v1=" followed by the hex HMAC-SHA256
+	TimestampHeader = "X-Gateway-Timestamp" // Unix time the gateway signed the callback at
+)
+
+const (
+	signatureVersion = "v1"    // Version prefix of the signature, bumped if the signing scheme changes
+	maxCallbackBytes = 1 << 20 // Largest callback body read, anything bigger is rejected before verifying it
+)
+
+// ErrInvalidSignature is returned when a callback is not signed with the shared secret
+var ErrInvalidSignature = errors.New("invalid gateway signature")
+
+// ErrSignatureExpired is returned when a callback was signed outside the tolerance, as a replayed callback would be
+var ErrSignatureExpired = errors.New("gateway signature timestamp is outside the tolerance")
+
+// Callback represents the outcome of an asynchronous charge reported by the gateway
+type Callback struct {
+	EventID    string               `json:"event_id"`         // ID of the callback, the same on every retry of the gateway
+	PaymentID  string               `json:"payment_id"`       // Payment ID the charge was made for
+	GatewayRef string               `json:"gateway_ref"`      // Gateway reference returned when the charge was accepted
+	Status     domain.GatewayStatus `json:"status"`           // Outcome of the charge, succeeded or failed
+	Reason     string               `json:"reason,omitempty"` // Why the gateway declined the charge
+}
+
+// Validate validates the callback
+// It returns a validation error with every invalid field if the callback is invalid
+func (c *Callback) Validate() error {
+	var validation domain.ValidationError
+	if c.PaymentID == "" {
+		validation.Add("payment_id", "payment ID is required")
+	}
+	if c.GatewayRef == "" {
+		validation.Add("gateway_ref", "gateway reference is required")
+	}
+	if c.Status != domain.GatewayStatusSucceeded && c.Status != domain.GatewayStatusFailed {
+		validation.Add("status", "status must be succeeded or failed")
+	}
+	return validation.Err()
+}
+
+// Outcome represents what a callback did to its payment
+type Outcome string
+
+const (
+	OutcomeApplied   Outcome = "applied"   // The callback moved the payment to its final status
+	OutcomeDuplicate Outcome = "duplicate" // The payment already had the reported outcome, the callback was a retry
+)
+
+// Settlement represents the payment after its gateway callback
+type Settlement struct {
+	PaymentID string        `json:"payment_id"` // Payment ID the callback was applied to
+	Status    domain.Status `json:"status"`     // Status of the payment after the callback
+	Outcome   Outcome       `json:"outcome"`    // Whether the callback changed the payment
+}
+
+// CallbackPolicy defines how gateway callbacks are authenticated
+type CallbackPolicy struct {
+	Secret    []byte        // Shared secret the gateway signs the callbacks with
+	Tolerance time.Duration // Largest difference between the signature timestamp and now
+}
+
+// Validate validates the callback policy
+// It returns an error if the policy is invalid
+func (p CallbackPolicy) Validate() error {
+	if len(p.Secret) == 0 {
+		return errors.New("secret is required")
+	}
+	if p.Tolerance <= 0 {
+		return errors.New("tolerance must be greater than 0")
+	}
+	return nil
+}
+
+// Verify checks that a callback body was signed with the shared secret within the tolerance
+// It returns ErrInvalidSignature or ErrSignatureExpired if the callback must be rejected
+func (p CallbackPolicy) Verify(signature string, timestamp string, body []byte, now time.Time) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	signedAt := time.Unix(unix, 0)
+	if age := now.Sub(signedAt); age > p.Tolerance || age < -p.Tolerance {
+		return ErrSignatureExpired
+	}
+
+	if !hmac.Equal([]byte(Sign(p.Secret, signedAt, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Sign returns the signature of a callback body signed at the given time
+func Sign(secret []byte, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
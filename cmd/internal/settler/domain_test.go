@@ -0,0 +1,183 @@
+package settler
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallback_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		callback      *Callback
+		expectedError string
+	}{
+		{
+			name:          "when callback reports a succeeded charge it should pass validation and no error",
+			callback:      &Callback{EventID: "evt_1", PaymentID: "pay_123", GatewayRef: "gw_123", Status: domain.GatewayStatusSucceeded},
+			expectedError: "",
+		},
+		{
+			name:          "when callback reports a failed charge it should pass validation and no error",
+			callback:      &Callback{PaymentID: "pay_123", GatewayRef: "gw_123", Status: domain.GatewayStatusFailed, Reason: "card_declined"},
+			expectedError: "",
+		},
+		{
+			name:          "when payment ID and gateway reference are empty it should return error with every invalid field",
+			callback:      &Callback{Status: domain.GatewayStatusSucceeded},
+			expectedError: "payment ID is required; gateway reference is required",
+		},
+		{
+			name:          "when status is pending it should return error with message 'status must be succeeded or failed'",
+			callback:      &Callback{PaymentID: "pay_123", GatewayRef: "gw_123", Status: domain.GatewayStatusPending},
+			expectedError: "status must be succeeded or failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Callback already prepared in test struct)
+
+			// Act
+			err := tt.callback.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCallbackPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        CallbackPolicy
+		expectedError string
+	}{
+		{
+			name:          "when secret and tolerance are set it should pass validation and no error",
+			policy:        CallbackPolicy{Secret: []byte("secret"), Tolerance: 5 * time.Minute},
+			expectedError: "",
+		},
+		{
+			name:          "when secret is empty it should return error with message 'secret is required'",
+			policy:        CallbackPolicy{Secret: nil, Tolerance: 5 * time.Minute},
+			expectedError: "secret is required",
+		},
+		{
+			name:          "when tolerance is zero it should return error with message 'tolerance must be greater than 0'",
+			policy:        CallbackPolicy{Secret: []byte("secret"), Tolerance: 0},
+			expectedError: "tolerance must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Policy already prepared in test struct)
+
+			// Act
+			err := tt.policy.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCallbackPolicy_Verify(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	body := []byte(`{"payment_id":"pay_123","gateway_ref":"gw_123","status":"succeeded"}`)
+	policy := CallbackPolicy{Secret: []byte("secret"), Tolerance: 5 * time.Minute}
+
+	tests := []struct {
+		name          string
+		signature     string
+		timestamp     string
+		body          []byte
+		expectedError error
+	}{
+		{
+			name:          "when signature matches the body and timestamp it should return no error",
+			signature:     Sign([]byte("secret"), now.Add(-time.Minute), body),
+			timestamp:     strconv.FormatInt(now.Add(-time.Minute).Unix(), 10),
+			body:          body,
+			expectedError: nil,
+		},
+		{
+			name:          "when body was changed after signing it should return invalid signature",
+			signature:     Sign([]byte("secret"), now, body),
+			timestamp:     strconv.FormatInt(now.Unix(), 10),
+			body:          []byte(`{"payment_id":"pay_123","gateway_ref":"gw_123","status":"failed"}`),
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			name:          "when signature uses another secret it should return invalid signature",
+			signature:     Sign([]byte("other"), now, body),
+			timestamp:     strconv.FormatInt(now.Unix(), 10),
+			body:          body,
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			name:          "when timestamp is missing it should return invalid signature",
+			signature:     Sign([]byte("secret"), now, body),
+			timestamp:     "",
+			body:          body,
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			name:          "when timestamp is older than the tolerance it should return signature expired",
+			signature:     Sign([]byte("secret"), now.Add(-10*time.Minute), body),
+			timestamp:     strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10),
+			body:          body,
+			expectedError: ErrSignatureExpired,
+		},
+		{
+			name:          "when timestamp is further in the future than the tolerance it should return signature expired",
+			signature:     Sign([]byte("secret"), now.Add(10*time.Minute), body),
+			timestamp:     strconv.FormatInt(now.Add(10*time.Minute).Unix(), 10),
+			body:          body,
+			expectedError: ErrSignatureExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Signature and timestamp already prepared in test struct)
+
+			// Act
+			err := policy.Verify(tt.signature, tt.timestamp, tt.body, now)
+
+			// Assert
+			assert.Equal(t, tt.expectedError, err)
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	// Arrange
+	timestamp := time.Unix(1705314600, 0)
+	body := []byte(`{"payment_id":"pay_123"}`)
+
+	// Act
+	signature := Sign([]byte("secret"), timestamp, body)
+
+	// Assert
+	assert.Regexp(t, `^v1=[a-f0-9]{64}$`, signature)
+	assert.Equal(t, signature, Sign([]byte("secret"), timestamp, body))
+	assert.NotEqual(t, signature, Sign([]byte("secret"), timestamp.Add(time.Second), body))
+}
@@ -0,0 +1,103 @@
+package settler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentSettler defines the interface for settling payments with gateway callbacks
+type PaymentSettler interface {
+	Settle(ctx context.Context, cb *Callback) (*Settlement, error)
+}
+
+// Handler handles the callbacks of the payment gateway
+type Handler struct {
+	paymentSettler PaymentSettler
+	policy         CallbackPolicy
+	now            func() time.Time
+}
+
+// NewHandler creates a new settler handler
+// It returns a new settler handler and an error if the payment settler is nil or the policy is invalid
+func NewHandler(ps PaymentSettler, policy CallbackPolicy) (*Handler, error) {
+	if ps == nil {
+		return nil, errors.New("settler handler: payment settler cannot be nil")
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("settler handler: invalid callback policy: %w", err)
+	}
+
+	return &Handler{
+		paymentSettler: ps,
+		policy:         policy,
+		now:            time.Now,
+	}, nil
+}
+
+// Callback handles POST /gateway/callbacks requests
+// The signature is verified on the raw body before parsing it, duplicate callbacks are acknowledged without changes
+func (h *Handler) Callback(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxCallbackBytes+1))
+	if err != nil || len(body) > maxCallbackBytes {
+		problem.Respond(c, problem.New(problem.CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	if err := h.policy.Verify(c.GetHeader(SignatureHeader), c.GetHeader(TimestampHeader), body, h.now()); err != nil {
+		slog.WarnContext(ctx, "Rejected gateway callback", "error", err)
+		problem.Respond(c, problem.New(problem.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	var cb Callback
+	if err := json.Unmarshal(body, &cb); err != nil {
+		problem.Respond(c, problem.New(problem.CodeBadRequest, "invalid request body"))
+		return
+	}
+
+	if err := cb.Validate(); err != nil {
+		problem.Respond(c, problem.Invalid(err))
+		return
+	}
+
+	settlement, err := h.paymentSettler.Settle(ctx, &cb)
+	if err != nil {
+		p := problem.FromError(err, "failed to settle payment")
+		if p.Internal() {
+			slog.ErrorContext(ctx, "Failed to settle payment", "error", err, "payment_id", cb.PaymentID, "event_id", cb.EventID)
+		} else {
+			slog.WarnContext(ctx, "Rejected gateway callback", "error", err, "payment_id", cb.PaymentID, "event_id", cb.EventID)
+		}
+		problem.Respond(c, p)
+		return
+	}
+
+	message := "gateway callback applied successfully"
+	if settlement.Outcome == OutcomeDuplicate {
+		message = "gateway callback already applied"
+	}
+
+	slog.InfoContext(ctx, "Gateway callback handled",
+		"payment_id", settlement.PaymentID,
+		"event_id", cb.EventID,
+		"status", settlement.Status,
+		"outcome", settlement.Outcome,
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    settlement,
+	})
+}
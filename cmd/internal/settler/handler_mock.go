@@ -0,0 +1,16 @@
+package settler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHandler is a mock implementation of Handler for testing
+type MockHandler struct {
+	mock.Mock
+}
+
+// Callback mocks the Callback method
+func (m *MockHandler) Callback(c *gin.Context) {
+	m.Called(c)
+}
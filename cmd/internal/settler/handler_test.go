@@ -0,0 +1,228 @@
+package settler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		paymentSettler PaymentSettler
+		policy         CallbackPolicy
+		expectedError  string
+	}{
+		{
+			name:           "when all dependencies are provided it should create handler successfully and no error",
+			paymentSettler: new(MockPaymentSettlerService),
+			policy:         CallbackPolicy{Secret: []byte("secret"), Tolerance: 5 * time.Minute},
+			expectedError:  "",
+		},
+		{
+			name:           "when payment settler is nil it should return error",
+			paymentSettler: nil,
+			policy:         CallbackPolicy{Secret: []byte("secret"), Tolerance: 5 * time.Minute},
+			expectedError:  "settler handler: payment settler cannot be nil",
+		},
+		{
+			name:           "when policy has no secret it should return error",
+			paymentSettler: new(MockPaymentSettlerService),
+			policy:         CallbackPolicy{Tolerance: 5 * time.Minute},
+			expectedError:  "settler handler: invalid callback policy: secret is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewHandler(tt.paymentSettler, tt.policy)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestHandler_Callback(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	secret := []byte("secret")
+	validBody := `{"event_id":"evt_1","payment_id":"pay_123","gateway_ref":"gw_123","status":"succeeded"}`
+
+	tests := []struct {
+		name               string
+		body               string
+		signedWith         []byte
+		signedAt           time.Time
+		mockSettlement     *Settlement
+		mockError          error
+		shouldCallSettle   bool
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when callback is signed and applied it should return 200",
+			body:               validBody,
+			signedWith:         secret,
+			signedAt:           now,
+			mockSettlement:     &Settlement{PaymentID: "pay_123", Status: domain.StatusCompleted, Outcome: OutcomeApplied},
+			shouldCallSettle:   true,
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "gateway callback applied successfully",
+		},
+		{
+			name:               "when callback is a duplicate it should return 200 without changes",
+			body:               validBody,
+			signedWith:         secret,
+			signedAt:           now,
+			mockSettlement:     &Settlement{PaymentID: "pay_123", Status: domain.StatusCompleted, Outcome: OutcomeDuplicate},
+			shouldCallSettle:   true,
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "gateway callback already applied",
+		},
+		{
+			name:               "when callback is signed with another secret it should return 401",
+			body:               validBody,
+			signedWith:         []byte("other"),
+			signedAt:           now,
+			shouldCallSettle:   false,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedMessage:    "invalid gateway signature",
+		},
+		{
+			name:               "when callback was signed outside the tolerance it should return 401",
+			body:               validBody,
+			signedWith:         secret,
+			signedAt:           now.Add(-time.Hour),
+			shouldCallSettle:   false,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedMessage:    "gateway signature timestamp is outside the tolerance",
+		},
+		{
+			name:               "when body is not json it should return 400",
+			body:               `not json`,
+			signedWith:         secret,
+			signedAt:           now,
+			shouldCallSettle:   false,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "invalid request body",
+		},
+		{
+			name:               "when callback status is pending it should return 400",
+			body:               `{"payment_id":"pay_123","gateway_ref":"gw_123","status":"pending"}`,
+			signedWith:         secret,
+			signedAt:           now,
+			shouldCallSettle:   false,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "status must be succeeded or failed",
+		},
+		{
+			name:               "when payment does not exist it should return 404",
+			body:               validBody,
+			signedWith:         secret,
+			signedAt:           now,
+			mockError:          fmt.Errorf("payment settler: get payment: %w", domain.ErrPaymentNotFound),
+			shouldCallSettle:   true,
+			expectedStatusCode: http.StatusNotFound,
+			expectedMessage:    "payment not found",
+		},
+		{
+			name:               "when callback conflicts with the payment it should return 409",
+			body:               validBody,
+			signedWith:         secret,
+			signedAt:           now,
+			mockError:          fmt.Errorf("payment settler: succeeded callback for failed payment pay_123: %w", domain.ErrGatewayOutcomeConflict),
+			shouldCallSettle:   true,
+			expectedStatusCode: http.StatusConflict,
+			expectedMessage:    "gateway outcome conflicts with the payment",
+		},
+		{
+			name:               "when wallet is unavailable it should return 503 so the gateway retries",
+			body:               validBody,
+			signedWith:         secret,
+			signedAt:           now,
+			mockError:          fmt.Errorf("payment settler: confirm funds: %w", domain.ErrWalletUnavailable),
+			shouldCallSettle:   true,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedMessage:    "wallet service unavailable",
+		},
+		{
+			name:               "when settle fails with internal error it should return 500",
+			body:               validBody,
+			signedWith:         secret,
+			signedAt:           now,
+			mockError:          errors.New("database error"),
+			shouldCallSettle:   true,
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "failed to settle payment",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockSettler := new(MockPaymentSettlerService)
+			if tt.shouldCallSettle {
+				mockSettler.On("Settle", mock.Anything, mock.AnythingOfType("*settler.Callback")).Return(tt.mockSettlement, tt.mockError)
+			}
+
+			handler := &Handler{
+				paymentSettler: mockSettler,
+				policy:         CallbackPolicy{Secret: secret, Tolerance: 5 * time.Minute},
+				now:            func() time.Time { return now },
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/gateway/callbacks", strings.NewReader(tt.body))
+			req.Header.Set(TimestampHeader, strconv.FormatInt(tt.signedAt.Unix(), 10))
+			req.Header.Set(SignatureHeader, Sign(tt.signedWith, tt.signedAt, []byte(tt.body)))
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			// Act
+			handler.Callback(c)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if w.Code >= http.StatusBadRequest {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedMessage, response["detail"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, response["message"])
+			}
+
+			mockSettler.AssertExpectations(t)
+		})
+	}
+}
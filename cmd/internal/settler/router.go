@@ -0,0 +1,22 @@
+package settler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentstorer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/walletclient"
+)
+
+// Start starts the settler router
+// It starts the settler router and returns an error if the builder fails
+func Start(rg *gin.RouterGroup, db paymentstorer.PaymentDB, rc *http.Client, wb walletclient.CircuitBreaker, locker paymentlocker.AdvisoryLocker, policy CallbackPolicy) error {
+	h, err := Build(db, rc, wb, locker, policy)
+	if err != nil {
+		return err
+	}
+
+	rg.POST("/gateway/callbacks", h.Callback)
+	return nil
+}
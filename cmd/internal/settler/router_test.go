@@ -0,0 +1,48 @@
+package settler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentstorer"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            paymentstorer.PaymentDB
+		expectedError bool
+	}{
+		{
+			name:          "when database is nil it should return error",
+			db:            nil,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+			rg := router.Group("/api/v1")
+
+			// Act
+			err := Start(rg, tt.db, &http.Client{}, nil, new(paymentlocker.MockAdvisoryLocker), CallbackPolicy{Secret: []byte("secret"), Tolerance: 5 * time.Minute})
+
+			// Assert
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package settler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
+)
+
+// PaymentRecorder is an interface for reading payments and recording their status
+type PaymentRecorder interface {
+	GetByID(ctx context.Context, paymentID string) (*domain.Payment, error)
+	UpdateStatus(ctx context.Context, paymentID string, status domain.Status, gatewayRef string) error
+}
+
+// WalletResolver is an interface for resolving funds
+type WalletResolver interface {
+	Confirm(ctx context.Context, userID string, amount float64, paymentID string) error
+	Release(ctx context.Context, userID string, amount float64, paymentID string) error
+}
+
+// PaymentLocker is an interface for serializing the status changes of each payment
+type PaymentLocker interface {
+	Lock(ctx context.Context, paymentID string) (paymentlocker.Unlocker, error)
+}
+
+// PaymentSettlerService is a service for settling payments with the outcome reported by the gateway
+type PaymentSettlerService struct {
+	paymentRecorder PaymentRecorder
+	walletResolver  WalletResolver
+	paymentLocker   PaymentLocker
+}
+
+// NewPaymentSettlerService creates a new PaymentSettlerService
+// It returns a new PaymentSettlerService and an error if the payment recorder, wallet resolver or payment locker is nil
+func NewPaymentSettlerService(pr PaymentRecorder, wr WalletResolver, pl PaymentLocker) (*PaymentSettlerService, error) {
+	if pr == nil {
+		return nil, errors.New("payment settler: payment recorder cannot be nil")
+	}
+	if wr == nil {
+		return nil, errors.New("payment settler: wallet resolver cannot be nil")
+	}
+	if pl == nil {
+		return nil, errors.New("payment settler: payment locker cannot be nil")
+	}
+
+	return &PaymentSettlerService{
+		paymentRecorder: pr,
+		walletResolver:  wr,
+		paymentLocker:   pl,
+	}, nil
+}
+
+// Settle applies a gateway callback to its payment
+// A succeeded charge confirms the funds and completes the payment, a failed charge releases the funds and fails it
+// A callback for a payment that already has the reported outcome is a duplicate and changes nothing
+// The payment is locked while the callback is applied, so a callback racing the processor or a job waits for them
+// and is checked against the status they recorded
+// It returns domain.ErrGatewayOutcomeConflict if the callback does not match the payment reference or status
+func (s *PaymentSettlerService) Settle(ctx context.Context, cb *Callback) (*Settlement, error) {
+	lock, err := s.paymentLocker.Lock(ctx, cb.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("payment settler: lock payment: %w", err)
+	}
+	defer func() {
+		if err := lock.Unlock(ctx); err != nil {
+			slog.WarnContext(ctx, "Failed to unlock payment", "error", err, "payment_id", cb.PaymentID)
+		}
+	}()
+
+	payment, err := s.paymentRecorder.GetByID(ctx, cb.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("payment settler: get payment: %w", err)
+	}
+
+	if payment.GatewayRef != cb.GatewayRef {
+		return nil, fmt.Errorf("payment settler: gateway reference %s does not match payment %s: %w", cb.GatewayRef, payment.ID, domain.ErrGatewayOutcomeConflict)
+	}
+
+	switch {
+	case cb.Status == domain.GatewayStatusSucceeded && payment.Status == domain.StatusCompleted,
+		cb.Status == domain.GatewayStatusFailed && payment.Status == domain.StatusFailed:
+		return settlement(payment.ID, payment.Status, OutcomeDuplicate), nil
+	case cb.Status == domain.GatewayStatusSucceeded && payment.Status == domain.StatusProcessing:
+		// Record the charge before touching the wallet, so a retried callback only confirms the funds
+		if err := s.paymentRecorder.UpdateStatus(ctx, payment.ID, domain.StatusPendingConfirm, cb.GatewayRef); err != nil {
+			return nil, fmt.Errorf("payment settler: update status to pending_confirm: %w", err)
+		}
+		return s.complete(ctx, payment, cb.GatewayRef)
+	case cb.Status == domain.GatewayStatusSucceeded && payment.Status == domain.StatusPendingConfirm:
+		return s.complete(ctx, payment, cb.GatewayRef)
+	case cb.Status == domain.GatewayStatusFailed && payment.Status == domain.StatusProcessing:
+		return s.fail(ctx, payment, cb.GatewayRef)
+	default:
+		return nil, fmt.Errorf("payment settler: %s callback for %s payment %s: %w", cb.Status, payment.Status, payment.ID, domain.ErrGatewayOutcomeConflict)
+	}
+}
+
+// complete confirms the funds of a payment charged by the gateway and marks it as completed
+func (s *PaymentSettlerService) complete(ctx context.Context, payment *domain.Payment, gatewayRef string) (*Settlement, error) {
+	if err := s.walletResolver.Confirm(ctx, payment.UserID, payment.Amount, payment.ID); err != nil {
+		return nil, fmt.Errorf("payment settler: confirm funds: %w", err)
+	}
+
+	if err := s.paymentRecorder.UpdateStatus(ctx, payment.ID, domain.StatusCompleted, gatewayRef); err != nil {
+		return nil, fmt.Errorf("payment settler: update status to completed: %w", err)
+	}
+
+	return settlement(payment.ID, domain.StatusCompleted, OutcomeApplied), nil
+}
+
+// fail releases the funds of a payment declined by the gateway and marks it as failed
+func (s *PaymentSettlerService) fail(ctx context.Context, payment *domain.Payment, gatewayRef string) (*Settlement, error) {
+	if err := s.walletResolver.Release(ctx, payment.UserID, payment.Amount, payment.ID); err != nil {
+		return nil, fmt.Errorf("payment settler: release funds: %w", err)
+	}
+
+	if err := s.paymentRecorder.UpdateStatus(ctx, payment.ID, domain.StatusFailed, gatewayRef); err != nil {
+		return nil, fmt.Errorf("payment settler: update status to failed: %w", err)
+	}
+
+	return settlement(payment.ID, domain.StatusFailed, OutcomeApplied), nil
+}
+
+// settlement returns the settlement of a payment with the given status and outcome
+func settlement(paymentID string, status domain.Status, outcome Outcome) *Settlement {
+	return &Settlement{PaymentID: paymentID, Status: status, Outcome: outcome}
+}
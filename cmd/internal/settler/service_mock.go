@@ -0,0 +1,21 @@
+package settler
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPaymentSettlerService is a mock implementation of PaymentSettler for testing
+type MockPaymentSettlerService struct {
+	mock.Mock
+}
+
+// Settle mocks the Settle method
+func (m *MockPaymentSettlerService) Settle(ctx context.Context, cb *Callback) (*Settlement, error) {
+	args := m.Called(ctx, cb)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Settlement), args.Error(1)
+}
@@ -0,0 +1,269 @@
+package settler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentstorer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/walletclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewPaymentSettlerService(t *testing.T) {
+	tests := []struct {
+		name            string
+		paymentRecorder PaymentRecorder
+		walletResolver  WalletResolver
+		paymentLocker   PaymentLocker
+		expectedError   string
+	}{
+		{
+			name:            "when all dependencies are provided it should create service successfully and no error",
+			paymentRecorder: new(paymentstorer.MockPaymentRepository),
+			walletResolver:  new(walletclient.MockWalletClient),
+			paymentLocker:   new(paymentlocker.MockPaymentLockerRepository),
+			expectedError:   "",
+		},
+		{
+			name:            "when payment recorder is nil it should return error",
+			paymentRecorder: nil,
+			walletResolver:  new(walletclient.MockWalletClient),
+			paymentLocker:   new(paymentlocker.MockPaymentLockerRepository),
+			expectedError:   "payment settler: payment recorder cannot be nil",
+		},
+		{
+			name:            "when wallet resolver is nil it should return error",
+			paymentRecorder: new(paymentstorer.MockPaymentRepository),
+			walletResolver:  nil,
+			paymentLocker:   new(paymentlocker.MockPaymentLockerRepository),
+			expectedError:   "payment settler: wallet resolver cannot be nil",
+		},
+		{
+			name:            "when payment locker is nil it should return error",
+			paymentRecorder: new(paymentstorer.MockPaymentRepository),
+			walletResolver:  new(walletclient.MockWalletClient),
+			paymentLocker:   nil,
+			expectedError:   "payment settler: payment locker cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewPaymentSettlerService(tt.paymentRecorder, tt.walletResolver, tt.paymentLocker)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestPaymentSettlerService_Settle(t *testing.T) {
+	tests := []struct {
+		name                     string
+		callback                 *Callback
+		paymentStatus            domain.Status
+		paymentGatewayRef        string
+		mockLockError            error
+		mockGetError             error
+		mockConfirmError         error
+		mockReleaseError         error
+		mockUpdateError          error
+		shouldCallPendingConfirm bool
+		shouldCallConfirm        bool
+		shouldCallRelease        bool
+		expectedUpdateStatus     domain.Status
+		expectedSettlement       *Settlement
+		expectedError            error
+	}{
+		{
+			name:                     "when processing payment succeeds it should record the charge, confirm funds and complete the payment",
+			callback:                 &Callback{PaymentID: "pay_123", GatewayRef: "gw_123", Status: domain.GatewayStatusSucceeded},
+			paymentStatus:            domain.StatusProcessing,
+			paymentGatewayRef:        "gw_123",
+			shouldCallPendingConfirm: true,
+			shouldCallConfirm:        true,
+			expectedUpdateStatus:     domain.StatusCompleted,
+			expectedSettlement:       &Settlement{PaymentID: "pay_123", Status: domain.StatusCompleted, Outcome: OutcomeApplied},
+			expectedError:            nil,
+		},
+		{
+			name:                 "when pending confirm payment succeeds again it should only confirm funds and complete the payment",
+			callback:             &Callback{PaymentID: "pay_123", GatewayRef: "gw_123", Status: domain.GatewayStatusSucceeded},
+			paymentStatus:        domain.StatusPendingConfirm,
+			paymentGatewayRef:    "gw_123",
+			shouldCallConfirm:    true,
+			expectedUpdateStatus: domain.StatusCompleted,
+			expectedSettlement:   &Settlement{PaymentID: "pay_123", Status: domain.StatusCompleted, Outcome: OutcomeApplied},
+			expectedError:        nil,
+		},
+		{
+			name:                 "when processing payment fails it should release funds and fail the payment",
+			callback:             &Callback{PaymentID: "pay_123", GatewayRef: "gw_123", Status: domain.GatewayStatusFailed},
+			paymentStatus:        domain.StatusProcessing,
+			paymentGatewayRef:    "gw_123",
+			shouldCallRelease:    true,
+			expectedUpdateStatus: domain.StatusFailed,
+			expectedSettlement:   &Settlement{PaymentID: "pay_123", Status: domain.StatusFailed, Outcome: OutcomeApplied},
+			expectedError:        nil,
+		},
+		{
+			name:               "when completed payment succeeds again it should return duplicate without changes",
+			callback:           &Callback{PaymentID: "pay_123", GatewayRef: "gw_123", Status: domain.GatewayStatusSucceeded},
+			paymentStatus:      domain.StatusCompleted,
+			paymentGatewayRef:  "gw_123",
+			expectedSettlement: &Settlement{PaymentID: "pay_123", Status: domain.StatusCompleted, Outcome: OutcomeDuplicate},
+			expectedError:      nil,
+		},
+		{
+			name:               "when failed payment fails again it should return duplicate without changes",
+			callback:           &Callback{PaymentID: "pay_123", GatewayRef: "gw_123", Status: domain.GatewayStatusFailed},
+			paymentStatus:      domain.StatusFailed,
+			paymentGatewayRef:  "gw_123",
+			expectedSettlement: &Settlement{PaymentID: "pay_123", Status: domain.StatusFailed, Outcome: OutcomeDuplicate},
+			expectedError:      nil,
+		},
+		{
+			name:              "when completed payment is reported as failed it should return conflict error",
+			callback:          &Callback{PaymentID: "pay_123", GatewayRef: "gw_123", Status: domain.GatewayStatusFailed},
+			paymentStatus:     domain.StatusCompleted,
+			paymentGatewayRef: "gw_123",
+			expectedError:     errors.New("payment settler: failed callback for completed payment pay_123: gateway outcome conflicts with the payment"),
+		},
+		{
+			name:              "when reserved payment was never charged asynchronously it should return conflict error",
+			callback:          &Callback{PaymentID: "pay_123", GatewayRef: "", Status: domain.GatewayStatusSucceeded},
+			paymentStatus:     domain.StatusReserved,
+			paymentGatewayRef: "",
+			expectedError:     errors.New("payment settler: succeeded callback for reserved payment pay_123: gateway outcome conflicts with the payment"),
+		},
+		{
+			name:              "when gateway reference does not match the payment it should return conflict error",
+			callback:          &Callback{PaymentID: "pay_123", GatewayRef: "gw_other", Status: domain.GatewayStatusSucceeded},
+			paymentStatus:     domain.StatusProcessing,
+			paymentGatewayRef: "gw_123",
+			expectedError:     errors.New("payment settler: gateway reference gw_other does not match payment pay_123: gateway outcome conflicts with the payment"),
+		},
+		{
+			name:          "when the payment lock cannot be acquired it should return wrapped error without reading the payment",
+			callback:      &Callback{PaymentID: "pay_123", GatewayRef: "gw_123", Status: domain.GatewayStatusSucceeded},
+			mockLockError: context.DeadlineExceeded,
+			expectedError: errors.New("payment settler: lock payment: context deadline exceeded"),
+		},
+		{
+			name:          "when payment does not exist it should return wrapped error",
+			callback:      &Callback{PaymentID: "pay_123", GatewayRef: "gw_123", Status: domain.GatewayStatusSucceeded},
+			mockGetError:  domain.ErrPaymentNotFound,
+			expectedError: errors.New("payment settler: get payment: payment not found"),
+		},
+		{
+			name:                     "when wallet is unavailable it should keep the charge recorded and return wrapped error",
+			callback:                 &Callback{PaymentID: "pay_123", GatewayRef: "gw_123", Status: domain.GatewayStatusSucceeded},
+			paymentStatus:            domain.StatusProcessing,
+			paymentGatewayRef:        "gw_123",
+			mockConfirmError:         domain.ErrWalletUnavailable,
+			shouldCallPendingConfirm: true,
+			shouldCallConfirm:        true,
+			expectedError:            fmt.Errorf("payment settler: confirm funds: %w", domain.ErrWalletUnavailable),
+		},
+		{
+			name:              "when release funds fails it should not fail the payment and return wrapped error",
+			callback:          &Callback{PaymentID: "pay_123", GatewayRef: "gw_123", Status: domain.GatewayStatusFailed},
+			paymentStatus:     domain.StatusProcessing,
+			paymentGatewayRef: "gw_123",
+			mockReleaseError:  errors.New("release failed"),
+			shouldCallRelease: true,
+			expectedError:     errors.New("payment settler: release funds: release failed"),
+		},
+		{
+			name:                 "when update status to failed fails it should return wrapped error",
+			callback:             &Callback{PaymentID: "pay_123", GatewayRef: "gw_123", Status: domain.GatewayStatusFailed},
+			paymentStatus:        domain.StatusProcessing,
+			paymentGatewayRef:    "gw_123",
+			mockUpdateError:      errors.New("database error"),
+			shouldCallRelease:    true,
+			expectedUpdateStatus: domain.StatusFailed,
+			expectedError:        errors.New("payment settler: update status to failed: database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockRecorder := new(paymentstorer.MockPaymentRepository)
+			mockWallet := new(walletclient.MockWalletClient)
+			mockLocker := new(paymentlocker.MockPaymentLockerRepository)
+			mockUnlocker := new(paymentlocker.MockUnlocker)
+
+			if tt.mockLockError != nil {
+				mockLocker.On("Lock", mock.Anything, tt.callback.PaymentID).Return(nil, tt.mockLockError)
+			} else {
+				mockLocker.On("Lock", mock.Anything, tt.callback.PaymentID).Return(mockUnlocker, nil)
+				mockUnlocker.On("Unlock", mock.Anything).Return(nil)
+			}
+
+			// The payment is only read once locked
+			switch {
+			case tt.mockLockError != nil:
+			case tt.mockGetError != nil:
+				mockRecorder.On("GetByID", mock.Anything, tt.callback.PaymentID).Return(nil, tt.mockGetError)
+			default:
+				mockRecorder.On("GetByID", mock.Anything, tt.callback.PaymentID).Return(&domain.Payment{
+					ID:         "pay_123",
+					UserID:     "user_123",
+					Amount:     100.50,
+					Status:     tt.paymentStatus,
+					GatewayRef: tt.paymentGatewayRef,
+				}, nil)
+			}
+
+			if tt.shouldCallPendingConfirm {
+				mockRecorder.On("UpdateStatus", mock.Anything, "pay_123", domain.StatusPendingConfirm, tt.callback.GatewayRef).Return(nil)
+			}
+			if tt.shouldCallConfirm {
+				mockWallet.On("Confirm", mock.Anything, "user_123", 100.50, "pay_123").Return(tt.mockConfirmError)
+			}
+			if tt.shouldCallRelease {
+				mockWallet.On("Release", mock.Anything, "user_123", 100.50, "pay_123").Return(tt.mockReleaseError)
+			}
+			if tt.expectedUpdateStatus != "" {
+				mockRecorder.On("UpdateStatus", mock.Anything, "pay_123", tt.expectedUpdateStatus, tt.callback.GatewayRef).Return(tt.mockUpdateError)
+			}
+
+			service := &PaymentSettlerService{paymentRecorder: mockRecorder, walletResolver: mockWallet, paymentLocker: mockLocker}
+
+			// Act
+			result, err := service.Settle(context.Background(), tt.callback)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedSettlement, result)
+			}
+
+			mockRecorder.AssertExpectations(t)
+			mockWallet.AssertExpectations(t)
+			mockLocker.AssertExpectations(t)
+			mockUnlocker.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+	"unicode/utf8"
+)
+
+// DeadLetterStatus represents the status of a dead letter
+type DeadLetterStatus string
+
+const (
+	DeadLetterStatusPending   DeadLetterStatus = "pending"   // The dead letter waits for an operator
+	DeadLetterStatusReplayed  DeadLetterStatus = "replayed"  // The dead letter was published back to the exchange
+	DeadLetterStatusDiscarded DeadLetterStatus = "discarded" // The dead letter was dropped
+)
+
+// Validate validates the dead letter status
+// It returns an error if the status is unknown
+func (s DeadLetterStatus) Validate() error {
+	if s != DeadLetterStatusPending && s != DeadLetterStatusReplayed && s != DeadLetterStatusDiscarded {
+		return errors.New("invalid dead letter status")
+	}
+	return nil
+}
+
+const (
+	DeadLetterActionArchived  = "archived"  // The dead letter was stored from the dead letter queue
+	DeadLetterActionReplayed  = "replayed"  // The dead letter was replayed by an operator
+	DeadLetterActionDiscarded = "discarded" // The dead letter was discarded by an operator
+)
+
+// ErrDeadLetterNotFound is returned when a dead letter is not found
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// ErrDeadLetterNotPending is returned when a dead letter was already replayed or discarded
+var ErrDeadLetterNotPending = errors.New("dead letter is not pending")
+
+// DeadLetter represents a message that exhausted its delivery attempts
+type DeadLetter struct {
+	ID             string                 `json:"id"`                     // Unique identifier for the dead letter
+	Queue          string                 `json:"queue"`                  // Queue the message failed on
+	RoutingKey     string                 `json:"routing_key"`            // Routing key the message was published with, used on replay
+	Body           []byte                 `json:"-"`                      // Original message body, as published
+	ContentType    string                 `json:"content_type,omitempty"` // Content type the message was published with
+	MessageID      string                 `json:"message_id,omitempty"`   // Message ID the message was published with
+	Headers        map[string]interface{} `json:"headers,omitempty"`      // Original message headers
+	FailureReason  string                 `json:"failure_reason"`         // Error returned by the last delivery
+	Attempts       int                    `json:"attempts"`               // Failed deliveries before the message was dead-lettered
+	Status         DeadLetterStatus       `json:"status"`                 // Status of the dead letter
+	DeadLetteredAt time.Time              `json:"dead_lettered_at"`       // Timestamp when the message was dead-lettered
+	UpdatedAt      time.Time              `json:"updated_at"`             // Timestamp when the dead letter was updated
+}
+
+// MarshalJSON writes the dead letter with its body as text when it is valid UTF-8, like JSON bodies,
+// and base64 encoded otherwise, like protobuf bodies
+func (d DeadLetter) MarshalJSON() ([]byte, error) {
+	type deadLetter DeadLetter // Drops the method so marshaling does not recurse
+	wire := struct {
+		deadLetter
+		Body       string `json:"body,omitempty"`
+		BodyBase64 string `json:"body_base64,omitempty"`
+	}{deadLetter: deadLetter(d)}
+
+	if utf8.Valid(d.Body) {
+		wire.Body = string(d.Body)
+	} else {
+		wire.BodyBase64 = base64.StdEncoding.EncodeToString(d.Body)
+	}
+
+	return json.Marshal(wire)
+}
+
+// DeadLetterFilter represents the filter criteria for listing dead letters
+type DeadLetterFilter struct {
+	Status        DeadLetterStatus `json:"status"`         // Status to match, empty matches any status
+	Queue         string           `json:"queue"`          // Queue to match, empty matches any queue
+	FailureReason string           `json:"failure_reason"` // Text the failure reason must contain, empty matches any reason
+	Limit         int              `json:"limit"`          // Maximum number of dead letters to return
+	Offset        int              `json:"offset"`         // Number of dead letters to skip
+}
+
+// Validate validates the dead letter filter
+// It returns an error if the filter is invalid
+func (f *DeadLetterFilter) Validate() error {
+	if f.Status != "" {
+		if err := f.Status.Validate(); err != nil {
+			return err
+		}
+	}
+	if f.Limit <= 0 {
+		return errors.New("limit must be greater than 0")
+	}
+	if f.Offset < 0 {
+		return errors.New("offset cannot be negative")
+	}
+	return nil
+}
+
+// DeadLetterAudit represents an entry of the dead letter audit trail
+type DeadLetterAudit struct {
+	ID           string    `json:"id"`             // Unique identifier for the audit entry
+	DeadLetterID string    `json:"dead_letter_id"` // Dead letter the action was applied to
+	Action       string    `json:"action"`         // Action applied (archived, replayed, discarded)
+	Actor        string    `json:"actor"`          // Who applied the action
+	Reason       string    `json:"reason"`         // Why the action was applied
+	CreatedAt    time.Time `json:"created_at"`     // Timestamp when the action was applied
+}
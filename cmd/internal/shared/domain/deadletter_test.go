@@ -0,0 +1,174 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadLetterStatus_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        DeadLetterStatus
+		expectedError string
+	}{
+		{
+			name:          "when status is pending it should pass validation and no error",
+			status:        DeadLetterStatusPending,
+			expectedError: "",
+		},
+		{
+			name:          "when status is replayed it should pass validation and no error",
+			status:        DeadLetterStatusReplayed,
+			expectedError: "",
+		},
+		{
+			name:          "when status is discarded it should pass validation and no error",
+			status:        DeadLetterStatusDiscarded,
+			expectedError: "",
+		},
+		{
+			name:          "when status is unknown it should return error with message 'invalid dead letter status'",
+			status:        DeadLetterStatus("archived"),
+			expectedError: "invalid dead letter status",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Status already prepared in test struct)
+
+			// Act
+			err := tt.status.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDeadLetterFilter_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		filter        *DeadLetterFilter
+		expectedError string
+	}{
+		{
+			name:          "when filter has only a limit it should pass validation and no error",
+			filter:        &DeadLetterFilter{Limit: 50},
+			expectedError: "",
+		},
+		{
+			name: "when filter has every field set it should pass validation and no error",
+			filter: &DeadLetterFilter{
+				Status:        DeadLetterStatusPending,
+				Queue:         "payments.created",
+				FailureReason: "timeout",
+				Limit:         10,
+				Offset:        20,
+			},
+			expectedError: "",
+		},
+		{
+			name:          "when status is unknown it should return error with message 'invalid dead letter status'",
+			filter:        &DeadLetterFilter{Status: DeadLetterStatus("archived"), Limit: 50},
+			expectedError: "invalid dead letter status",
+		},
+		{
+			name:          "when limit is zero it should return error with message 'limit must be greater than 0'",
+			filter:        &DeadLetterFilter{Limit: 0},
+			expectedError: "limit must be greater than 0",
+		},
+		{
+			name:          "when offset is negative it should return error with message 'offset cannot be negative'",
+			filter:        &DeadLetterFilter{Limit: 50, Offset: -1},
+			expectedError: "offset cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Filter already prepared in test struct)
+
+			// Act
+			err := tt.filter.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDeadLetter_MarshalJSON(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		body               []byte
+		contentType        string
+		expectedBody       interface{}
+		expectedBodyBase64 interface{}
+	}{
+		{
+			name:         "when body is JSON it should write it as text",
+			body:         []byte(`{"id":"pay_123"}`),
+			contentType:  "application/json",
+			expectedBody: `{"id":"pay_123"}`,
+		},
+		{
+			name:               "when body is protobuf it should write it base64 encoded",
+			body:               []byte{0x0a, 0x07, 'p', 'a', 'y', '_', '1', '2', '3', 0x00, 0xff},
+			contentType:        "application/protobuf",
+			expectedBodyBase64: "CgdwYXlfMTIzAP8=",
+		},
+		{
+			name:        "when body is empty it should write neither",
+			contentType: "application/json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			deadLetter := DeadLetter{
+				ID:             "dl_123",
+				Queue:          "payments.created",
+				RoutingKey:     "payments.created",
+				Body:           tt.body,
+				ContentType:    tt.contentType,
+				MessageID:      "msg_123",
+				Status:         DeadLetterStatusPending,
+				DeadLetteredAt: fixedTime,
+				UpdatedAt:      fixedTime,
+			}
+
+			// Act
+			data, err := json.Marshal(deadLetter)
+
+			// Assert
+			assert.NoError(t, err)
+
+			var result map[string]interface{}
+			assert.NoError(t, json.Unmarshal(data, &result))
+			assert.Equal(t, "dl_123", result["id"])
+			assert.Equal(t, tt.contentType, result["content_type"])
+			assert.Equal(t, "msg_123", result["message_id"])
+			assert.Equal(t, tt.expectedBody, result["body"])
+			assert.Equal(t, tt.expectedBodyBase64, result["body_base64"])
+		})
+	}
+}
@@ -5,3 +5,27 @@ import "errors"
 // ErrPaymentNotFound is returned when a payment is not found
 var ErrPaymentNotFound = errors.New("payment not found")
 
+// ErrDuplicateIdempotencyKey is returned when another payment was saved first with the same idempotency key
+var ErrDuplicateIdempotencyKey = errors.New("idempotency key already exists")
+
+// ErrIdempotencyKeyMismatch is returned when an idempotency key is reused with a different request
+var ErrIdempotencyKeyMismatch = errors.New("idempotency key was used with a different request")
+
+// ErrIdempotencyKeyInProgress is returned when the first request with an idempotency key is still running
+var ErrIdempotencyKeyInProgress = errors.New("idempotency key request is still in progress")
+
+// ErrInsufficientFunds is returned when the wallet balance does not cover the payment amount
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+
+// ErrForbidden is returned when the authenticated principal cannot access a resource
+var ErrForbidden = errors.New("access denied")
+
+// ErrWalletUnavailable is returned when the wallet circuit breaker is open
+var ErrWalletUnavailable = errors.New("wallet service unavailable")
+
+// ErrGatewayUnavailable is returned when the gateway circuit breaker is open
+var ErrGatewayUnavailable = errors.New("payment gateway unavailable")
+
+// ErrGatewayOutcomeConflict is returned when a gateway outcome does not match the payment it is reported for
+var ErrGatewayOutcomeConflict = errors.New("gateway outcome conflicts with the payment")
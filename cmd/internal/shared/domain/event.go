@@ -5,12 +5,36 @@ import (
 	"time"
 )
 
+const (
+	EventTypeCreated           = "created"            // The payment was created
+	EventTypeRecoveryAttempted = "recovery_attempted" // The payment was republished by the recovery job
+	EventTypeReconciled        = "reconciled"         // The payment was repaired by a reconciliation job
+)
+
+// EventTypes lists every payment event type, the status changes are recorded with the name of the new status
+var EventTypes = []string{
+	EventTypeCreated,
+	string(StatusReserved),
+	string(StatusProcessing),
+	string(StatusPendingConfirm),
+	string(StatusCompleted),
+	string(StatusFailed),
+	EventTypeRecoveryAttempted,
+	EventTypeReconciled,
+}
+
+// CloudEvents attributes of the messages asking the processor to process a payment
+const (
+	CloudEventTypePaymentCreated = "com.nahuelsoma.payments.payment.created" // Type of the messages carrying a payment to process
+	PaymentDataSchema            = "urn:nahuelsoma:payments:payment:v1"      // Schema of their data, a Payment as JSON
+)
+
 // Event represents a payment event in the event store
 type Event struct {
 	ID        string          `json:"id"`         // Unique identifier for the event
 	PaymentID string          `json:"payment_id"` // Payment ID associated with the event
 	Sequence  int             `json:"sequence"`   // Sequence number of the event for this payment
-	EventType string          `json:"event_type"` // Type of the event (created, reserved, completed, failed)
+	EventType string          `json:"event_type"` // Type of the event (created, reserved, pending_confirm, completed, failed, recovery_attempted)
 	Payload   json.RawMessage `json:"payload"`    // Event payload as JSON
 	CreatedAt time.Time       `json:"created_at"` // Timestamp when the event was created
 }
@@ -0,0 +1,16 @@
+package domain
+
+// GatewayStatus represents the outcome of a charge with the gateway
+type GatewayStatus string
+
+const (
+	GatewayStatusSucceeded GatewayStatus = "succeeded" // The gateway charged the payment
+	GatewayStatusPending   GatewayStatus = "pending"   // The gateway accepted the charge and reports its outcome later with a callback
+	GatewayStatusFailed    GatewayStatus = "failed"    // The gateway declined the charge, synchronous declines are returned as errors
+)
+
+// GatewayResult represents the answer of the gateway to a charge
+type GatewayResult struct {
+	Ref    string        // Gateway reference of the charge
+	Status GatewayStatus // Outcome of the charge, pending until the gateway calls back
+}
@@ -4,18 +4,23 @@ import (
 	"encoding/json"
 	"errors"
 	"time"
+
+	paymentsv1 "github.com/nahuelsoma/event-driven-challenge-payments/proto/payments/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // Payment represents a payment transaction
 type Payment struct {
-	ID             string    `json:"id"`              // Unique identifier for the payment
-	IdempotencyKey string    `json:"idempotency_key"` // Idempotency key for the payment
-	UserID         string    `json:"user_id"`         // User ID of the payment owner
-	Amount         float64   `json:"amount"`          // Amount of the payment
-	Currency       Currency  `json:"currency"`        // Currency of the payment
-	Status         Status    `json:"status"`          // Status of the payment
-	CreatedAt      time.Time `json:"created_at"`      // Timestamp when the payment was created
-	UpdatedAt      time.Time `json:"updated_at"`      // Timestamp when the payment was updated
+	ID             string    `json:"id"`                    // Unique identifier for the payment
+	IdempotencyKey string    `json:"idempotency_key"`       // Idempotency key for the payment
+	UserID         string    `json:"user_id"`               // User ID of the payment owner
+	Amount         float64   `json:"amount"`                // Amount of the payment
+	Currency       Currency  `json:"currency"`              // Currency of the payment
+	Status         Status    `json:"status"`                // Status of the payment
+	GatewayRef     string    `json:"gateway_ref,omitempty"` // Gateway reference, set once the gateway charged the payment
+	CreatedAt      time.Time `json:"created_at"`            // Timestamp when the payment was created
+	UpdatedAt      time.Time `json:"updated_at"`            // Timestamp when the payment was updated
 }
 
 // Validate validates the payment
@@ -51,3 +56,41 @@ func (p *Payment) Parse(body []byte) error {
 func (p *Payment) Marshal() ([]byte, error) {
 	return json.Marshal(p)
 }
+
+// MarshalProto marshals a payment to its protobuf message
+// It returns an error if the marshalling fails
+func (p *Payment) MarshalProto() ([]byte, error) {
+	return proto.Marshal(&paymentsv1.Payment{
+		Id:             p.ID,
+		IdempotencyKey: p.IdempotencyKey,
+		UserId:         p.UserID,
+		Amount:         p.Amount,
+		Currency:       string(p.Currency),
+		Status:         string(p.Status),
+		GatewayRef:     p.GatewayRef,
+		CreatedAt:      timestamppb.New(p.CreatedAt),
+		UpdatedAt:      timestamppb.New(p.UpdatedAt),
+	})
+}
+
+// UnmarshalProto parses a payment from its protobuf message
+// It returns an error if the parsing fails
+func (p *Payment) UnmarshalProto(body []byte) error {
+	var msg paymentsv1.Payment
+	if err := proto.Unmarshal(body, &msg); err != nil {
+		return err
+	}
+
+	*p = Payment{
+		ID:             msg.GetId(),
+		IdempotencyKey: msg.GetIdempotencyKey(),
+		UserID:         msg.GetUserId(),
+		Amount:         msg.GetAmount(),
+		Currency:       Currency(msg.GetCurrency()),
+		Status:         Status(msg.GetStatus()),
+		GatewayRef:     msg.GetGatewayRef(),
+		CreatedAt:      msg.GetCreatedAt().AsTime(),
+		UpdatedAt:      msg.GetUpdatedAt().AsTime(),
+	}
+	return nil
+}
@@ -294,3 +294,85 @@ func TestPayment_Marshal(t *testing.T) {
 	}
 }
 
+
+func TestPayment_MarshalProto(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		payment *Payment
+	}{
+		{
+			name: "when payment has all fields it should round-trip through protobuf",
+			payment: &Payment{
+				ID:             "pay_123",
+				IdempotencyKey: "key_456",
+				UserID:         "user_789",
+				Amount:         100.50,
+				Currency:       CurrencyUSD,
+				Status:         StatusCompleted,
+				GatewayRef:     "gw_123",
+				CreatedAt:      fixedTime,
+				UpdatedAt:      fixedTime.Add(time.Minute),
+			},
+		},
+		{
+			name:    "when payment is empty it should round-trip through protobuf",
+			payment: &Payment{CreatedAt: time.Time{}.UTC(), UpdatedAt: time.Time{}.UTC()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Payment already prepared in test struct)
+
+			// Act
+			result, err := tt.payment.MarshalProto()
+
+			// Assert
+			assert.NoError(t, err)
+
+			parsedPayment := &Payment{}
+			assert.NoError(t, parsedPayment.UnmarshalProto(result))
+			assert.Equal(t, tt.payment, parsedPayment)
+		})
+	}
+}
+
+func TestPayment_UnmarshalProto(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          []byte
+		expectedError bool
+	}{
+		{
+			name:          "when body is empty it should parse an empty payment and no error",
+			body:          []byte{},
+			expectedError: false,
+		},
+		{
+			name:          "when body is not protobuf it should return error",
+			body:          []byte(`{"id":"pay_123"}`),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			payment := &Payment{}
+
+			// Act
+			err := payment.UnmarshalProto(tt.body)
+
+			// Assert
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Empty(t, payment.ID)
+			}
+		})
+	}
+}
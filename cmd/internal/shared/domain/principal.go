@@ -0,0 +1,52 @@
+package domain
+
+import "context"
+
+// Scope represents a permission granted to an authenticated principal
+type Scope string
+
+const (
+	ScopePaymentsRead  Scope = "payments:read"  // Read own payments
+	ScopePaymentsWrite Scope = "payments:write" // Create payments for itself
+	ScopeAdmin         Scope = "admin"          // Read any payment and use the admin API
+)
+
+// Principal represents the authenticated caller of the API
+type Principal struct {
+	Subject    string  // User ID the caller acts as
+	Credential string  // Identifier of the API key or token the caller authenticated with
+	Scopes     []Scope // Permissions granted to the caller
+}
+
+// HasScope reports whether the principal was granted the scope
+func (p *Principal) HasScope(scope Scope) bool {
+	for _, granted := range p.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CanRead reports whether the principal can read data owned by the given user
+// Owners need the payments read scope, admins can read any user's data
+func (p *Principal) CanRead(ownerID string) bool {
+	if p.HasScope(ScopeAdmin) {
+		return true
+	}
+	return p.Subject == ownerID && p.HasScope(ScopePaymentsRead)
+}
+
+// principalKey is the context key of the authenticated principal
+type principalKey struct{}
+
+// WithPrincipal returns a copy of the context carrying the authenticated principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the authenticated principal carried by the context
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipal_HasScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   []Scope
+		scope    Scope
+		expected bool
+	}{
+		{
+			name:     "when scope was granted it should return true",
+			scopes:   []Scope{ScopePaymentsRead, ScopePaymentsWrite},
+			scope:    ScopePaymentsWrite,
+			expected: true,
+		},
+		{
+			name:     "when scope was not granted it should return false",
+			scopes:   []Scope{ScopePaymentsRead},
+			scope:    ScopePaymentsWrite,
+			expected: false,
+		},
+		{
+			name:     "when principal has no scopes it should return false",
+			scopes:   nil,
+			scope:    ScopePaymentsRead,
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			principal := &Principal{Subject: "user_123", Scopes: tt.scopes}
+
+			// Act
+			result := principal.HasScope(tt.scope)
+
+			// Assert
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestPrincipal_CanRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		subject  string
+		scopes   []Scope
+		ownerID  string
+		expected bool
+	}{
+		{
+			name:     "when principal owns the data and can read payments it should return true",
+			subject:  "user_123",
+			scopes:   []Scope{ScopePaymentsRead},
+			ownerID:  "user_123",
+			expected: true,
+		},
+		{
+			name:     "when principal owns the data without read scope it should return false",
+			subject:  "user_123",
+			scopes:   []Scope{ScopePaymentsWrite},
+			ownerID:  "user_123",
+			expected: false,
+		},
+		{
+			name:     "when principal does not own the data it should return false",
+			subject:  "user_123",
+			scopes:   []Scope{ScopePaymentsRead},
+			ownerID:  "user_456",
+			expected: false,
+		},
+		{
+			name:     "when principal is admin it should return true for any owner",
+			subject:  "ops",
+			scopes:   []Scope{ScopeAdmin},
+			ownerID:  "user_456",
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			principal := &Principal{Subject: tt.subject, Scopes: tt.scopes}
+
+			// Act
+			result := principal.CanRead(tt.ownerID)
+
+			// Assert
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	principal := &Principal{Subject: "user_123", Scopes: []Scope{ScopePaymentsRead}}
+
+	tests := []struct {
+		name              string
+		ctx               context.Context
+		expectedPrincipal *Principal
+		expectedOK        bool
+	}{
+		{
+			name:              "when context carries a principal it should return it",
+			ctx:               WithPrincipal(context.Background(), principal),
+			expectedPrincipal: principal,
+			expectedOK:        true,
+		},
+		{
+			name:              "when context has no principal it should return false",
+			ctx:               context.Background(),
+			expectedPrincipal: nil,
+			expectedOK:        false,
+		},
+		{
+			name:              "when context carries a nil principal it should return false",
+			ctx:               WithPrincipal(context.Background(), nil),
+			expectedPrincipal: nil,
+			expectedOK:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Context already prepared in test struct)
+
+			// Act
+			result, ok := PrincipalFromContext(tt.ctx)
+
+			// Assert
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.Equal(t, tt.expectedPrincipal, result)
+		})
+	}
+}
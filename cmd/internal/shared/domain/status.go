@@ -4,8 +4,15 @@ package domain
 type Status string
 
 const (
-	StatusPending   Status = "pending"   // The payment is pending
-	StatusReserved  Status = "reserved"  // The payment is reserved
-	StatusCompleted Status = "completed" // The payment is completed
-	StatusFailed    Status = "failed"    // The payment is failed
+	StatusPending        Status = "pending"         // The payment is pending
+	StatusReserved       Status = "reserved"        // The payment is reserved
+	StatusProcessing     Status = "processing"      // The gateway accepted the charge, its outcome arrives with a callback
+	StatusPendingConfirm Status = "pending_confirm" // The gateway charged the payment, the wallet confirmation is pending
+	StatusCompleted      Status = "completed"       // The payment is completed
+	StatusFailed         Status = "failed"          // The payment is failed
 )
+
+// IsTerminal reports whether the payment saga is over once the payment reaches the status
+func (s Status) IsTerminal() bool {
+	return s == StatusCompleted || s == StatusFailed
+}
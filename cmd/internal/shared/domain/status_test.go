@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus_IsTerminal(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   Status
+		expected bool
+	}{
+		{
+			name:     "when status is pending it should return false",
+			status:   StatusPending,
+			expected: false,
+		},
+		{
+			name:     "when status is reserved it should return false",
+			status:   StatusReserved,
+			expected: false,
+		},
+		{
+			name:     "when status is processing it should return false",
+			status:   StatusProcessing,
+			expected: false,
+		},
+		{
+			name:     "when status is pending confirm it should return false",
+			status:   StatusPendingConfirm,
+			expected: false,
+		},
+		{
+			name:     "when status is completed it should return true",
+			status:   StatusCompleted,
+			expected: true,
+		},
+		{
+			name:     "when status is failed it should return true",
+			status:   StatusFailed,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Status already prepared in test struct)
+
+			// Act
+			result := tt.status.IsTerminal()
+
+			// Assert
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package domain
+
+import "strings"
+
+// FieldError describes why a request field is invalid
+type FieldError struct {
+	Field   string `json:"field"`   // Name of the field as sent by the client
+	Message string `json:"message"` // Why the value was rejected
+}
+
+// ValidationError is returned when one or more request fields are invalid
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Add records an invalid field
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err returns the validation error if any field was recorded, or nil
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Error returns the messages of the invalid fields
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Message)
+	}
+	return strings.Join(messages, "; ")
+}
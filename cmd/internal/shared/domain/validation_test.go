@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationError_Err(t *testing.T) {
+	tests := []struct {
+		name          string
+		fields        []FieldError
+		expectedError string
+	}{
+		{
+			name:          "when no field was recorded it should return nil",
+			fields:        nil,
+			expectedError: "",
+		},
+		{
+			name:          "when one field was recorded it should return its message",
+			fields:        []FieldError{{Field: "amount", Message: "amount must be greater than 0"}},
+			expectedError: "amount must be greater than 0",
+		},
+		{
+			name: "when several fields were recorded it should join their messages",
+			fields: []FieldError{
+				{Field: "amount", Message: "amount must be greater than 0"},
+				{Field: "currency", Message: "invalid currency"},
+			},
+			expectedError: "amount must be greater than 0; invalid currency",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var validation ValidationError
+			for _, field := range tt.fields {
+				validation.Add(field.Field, field.Message)
+			}
+
+			// Act
+			err := validation.Err()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package domain
+
+// WalletOperationStatus represents the status of the wallet operation backing a payment
+type WalletOperationStatus string
+
+const (
+	WalletOperationStatusReserved  WalletOperationStatus = "reserved"  // The funds are held for the payment
+	WalletOperationStatusConfirmed WalletOperationStatus = "confirmed" // The funds were deducted
+	WalletOperationStatusReleased  WalletOperationStatus = "released"  // The funds were returned to the available balance
+	WalletOperationStatusNotFound  WalletOperationStatus = "not_found" // The wallet has no operation for the payment
+	WalletOperationStatusUnknown   WalletOperationStatus = "unknown"   // The wallet could not tell the operation status
+)
+
+// WalletOperation represents the wallet operation backing a payment
+type WalletOperation struct {
+	PaymentID string                `json:"payment_id"` // Payment ID the operation belongs to
+	UserID    string                `json:"user_id"`    // User ID of the wallet owner
+	Amount    float64               `json:"amount"`     // Amount held or deducted
+	Status    WalletOperationStatus `json:"status"`     // Status of the operation
+}
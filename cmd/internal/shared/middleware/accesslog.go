@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLog logs every request once it is handled, with its status and latency
+// Server errors are logged at error level, client errors at warn level and the rest at info level
+// The query string is left out, so credentials sent in it never reach the logs
+func AccessLog(c *gin.Context) {
+	start := time.Now()
+
+	c.Next()
+
+	status := c.Writer.Status()
+	level := slog.LevelInfo
+	switch {
+	case status >= http.StatusInternalServerError:
+		level = slog.LevelError
+	case status >= http.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+
+	slog.Log(c.Request.Context(), level, "HTTP request",
+		"method", c.Request.Method,
+		"route", c.FullPath(),
+		"path", c.Request.URL.Path,
+		"status", status,
+		"latency_ms", float64(time.Since(start).Microseconds())/1000,
+		"bytes", c.Writer.Size(),
+		"client_ip", c.ClientIP(),
+	)
+}
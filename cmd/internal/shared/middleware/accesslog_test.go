@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLog(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		expectedLevel string
+	}{
+		{
+			name:          "when request succeeds it should log it at info level",
+			status:        http.StatusOK,
+			expectedLevel: "INFO",
+		},
+		{
+			name:          "when request fails with a client error it should log it at warn level",
+			status:        http.StatusNotFound,
+			expectedLevel: "WARN",
+		},
+		{
+			name:          "when request fails with a server error it should log it at error level",
+			status:        http.StatusInternalServerError,
+			expectedLevel: "ERROR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var logs bytes.Buffer
+			defaultLogger := slog.Default()
+			slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+			defer slog.SetDefault(defaultLogger)
+
+			router := gin.New()
+			router.Use(AccessLog)
+			router.GET("/api/v1/payments/:id", func(c *gin.Context) {
+				c.Status(tt.status)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/payments/pay_123?api_key=secret", nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			router.ServeHTTP(w, req)
+
+			// Assert
+			var record map[string]interface{}
+			err := json.Unmarshal(logs.Bytes(), &record)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedLevel, record["level"])
+			assert.Equal(t, "HTTP request", record["msg"])
+			assert.Equal(t, http.MethodGet, record["method"])
+			assert.Equal(t, "/api/v1/payments/:id", record["route"])
+			assert.Equal(t, "/api/v1/payments/pay_123", record["path"])
+			assert.Equal(t, float64(tt.status), record["status"])
+			assert.Contains(t, record, "latency_ms")
+			assert.NotContains(t, logs.String(), "secret")
+		})
+	}
+}
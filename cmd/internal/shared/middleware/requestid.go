@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/requestid"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID assigns an ID to the request, reusing the X-Request-ID header when it is valid
+// The ID is put in the request context, so every log, outgoing call and published message carries it,
+// and it is echoed in the X-Request-ID response header
+func RequestID(c *gin.Context) {
+	id := c.GetHeader(requestid.Header)
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+
+	c.Header(requestid.Header, id)
+	c.Request = c.Request.WithContext(requestid.WithRequestID(c.Request.Context(), id))
+	c.Next()
+}
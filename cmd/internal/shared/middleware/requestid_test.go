@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/requestid"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name             string
+		requestID        string
+		shouldReuseID    bool
+		shouldGenerateID bool
+	}{
+		{
+			name:          "when request has a valid ID it should reuse it",
+			requestID:     "req_123",
+			shouldReuseID: true,
+		},
+		{
+			name:             "when request has no ID it should generate one",
+			requestID:        "",
+			shouldGenerateID: true,
+		},
+		{
+			name:             "when request ID has spaces it should generate one",
+			requestID:        "req 123",
+			shouldGenerateID: true,
+		},
+		{
+			name:          "when request ID has 128 characters it should reuse it",
+			requestID:     strings.Repeat("a", 128),
+			shouldReuseID: true,
+		},
+		{
+			name:             "when request ID has non ASCII characters it should generate one",
+			requestID:        "req_ñ123",
+			shouldGenerateID: true,
+		},
+		{
+			name:             "when request ID is too long it should generate one",
+			requestID:        strings.Repeat("a", 129),
+			shouldGenerateID: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var contextID string
+			router := gin.New()
+			router.Use(RequestID)
+			router.GET("/api/v1/payments", func(c *gin.Context) {
+				contextID = requestid.FromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/payments", nil)
+			if tt.requestID != "" {
+				req.Header.Set(requestid.Header, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			router.ServeHTTP(w, req)
+
+			// Assert
+			responseID := w.Header().Get(requestid.Header)
+			assert.Equal(t, responseID, contextID)
+			if tt.shouldReuseID {
+				assert.Equal(t, tt.requestID, responseID)
+			}
+			if tt.shouldGenerateID {
+				assert.NotEmpty(t, responseID)
+				assert.NotEqual(t, tt.requestID, responseID)
+			}
+		})
+	}
+}
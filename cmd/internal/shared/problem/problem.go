@@ -0,0 +1,156 @@
+package problem
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/requestid"
+)
+
+// ContentType is the media type of problem details responses (RFC 7807)
+const ContentType = "application/problem+json"
+
+// typeBase is the base of the problem type URIs, the code identifies the problem type
+const typeBase = "/problems/"
+
+// Code is a stable, machine-readable error code clients can branch on
+type Code string
+
+const (
+	CodeBadRequest               Code = "bad_request"                 // The request cannot be read
+	CodeValidationFailed         Code = "validation_failed"           // One or more fields are invalid, see errors
+	CodeUnauthorized             Code = "unauthorized"                // Credentials are missing or invalid
+	CodeForbidden                Code = "forbidden"                   // The caller cannot access the resource
+	CodeNotFound                 Code = "not_found"                   // The route or resource does not exist
+	CodePaymentNotFound          Code = "payment_not_found"           // The payment does not exist
+	CodeDeadLetterNotFound       Code = "dead_letter_not_found"       // The dead letter does not exist
+	CodeIdempotencyKeyInProgress Code = "idempotency_key_in_progress" // The first request with the key is still running
+	CodeInvalidTransition        Code = "invalid_transition"          // The resource is not in a status that allows the operation
+	CodeIdempotencyKeyMismatch   Code = "idempotency_key_mismatch"    // The key was used with a different request
+	CodeInsufficientFunds        Code = "insufficient_funds"          // The wallet balance does not cover the amount
+	CodeRateLimited              Code = "rate_limited"                // The caller exceeded its rate limit
+	CodeInternal                 Code = "internal_error"              // Unexpected failure, safe to retry
+	CodeWalletUnavailable        Code = "wallet_unavailable"          // The wallet is failing, retry later
+	CodeGatewayUnavailable       Code = "gateway_unavailable"         // The payment gateway is failing, retry later
+)
+
+// definition holds the HTTP status and title of a code
+type definition struct {
+	status int
+	title  string
+}
+
+// definitions maps every code to its HTTP status and title
+var definitions = map[Code]definition{
+	CodeBadRequest:               {http.StatusBadRequest, "Bad Request"},
+	CodeValidationFailed:         {http.StatusBadRequest, "Validation Failed"},
+	CodeUnauthorized:             {http.StatusUnauthorized, "Unauthorized"},
+	CodeForbidden:                {http.StatusForbidden, "Forbidden"},
+	CodeNotFound:                 {http.StatusNotFound, "Not Found"},
+	CodePaymentNotFound:          {http.StatusNotFound, "Payment Not Found"},
+	CodeDeadLetterNotFound:       {http.StatusNotFound, "Dead Letter Not Found"},
+	CodeIdempotencyKeyInProgress: {http.StatusConflict, "Idempotency Key In Progress"},
+	CodeInvalidTransition:        {http.StatusConflict, "Invalid Status Transition"},
+	CodeIdempotencyKeyMismatch:   {http.StatusUnprocessableEntity, "Idempotency Key Mismatch"},
+	CodeInsufficientFunds:        {http.StatusUnprocessableEntity, "Insufficient Funds"},
+	CodeRateLimited:              {http.StatusTooManyRequests, "Too Many Requests"},
+	CodeInternal:                 {http.StatusInternalServerError, "Internal Server Error"},
+	CodeWalletUnavailable:        {http.StatusServiceUnavailable, "Wallet Unavailable"},
+	CodeGatewayUnavailable:       {http.StatusServiceUnavailable, "Gateway Unavailable"},
+}
+
+// domainErrors maps the domain errors to their codes, the first one wrapped by an error wins
+var domainErrors = []struct {
+	err  error
+	code Code
+}{
+	{domain.ErrPaymentNotFound, CodePaymentNotFound},
+	{domain.ErrDeadLetterNotFound, CodeDeadLetterNotFound},
+	{domain.ErrDeadLetterNotPending, CodeInvalidTransition},
+	{domain.ErrGatewayOutcomeConflict, CodeInvalidTransition},
+	{domain.ErrForbidden, CodeForbidden},
+	{domain.ErrIdempotencyKeyMismatch, CodeIdempotencyKeyMismatch},
+	{domain.ErrIdempotencyKeyInProgress, CodeIdempotencyKeyInProgress},
+	{domain.ErrInsufficientFunds, CodeInsufficientFunds},
+	{domain.ErrWalletUnavailable, CodeWalletUnavailable},
+	{domain.ErrGatewayUnavailable, CodeGatewayUnavailable},
+}
+
+// Problem represents a problem details object (RFC 7807) with the code, request ID and invalid fields as extensions
+type Problem struct {
+	Type      string              `json:"type"`                 // URI reference identifying the problem type
+	Title     string              `json:"title"`                // Short summary of the problem type
+	Status    int                 `json:"status"`               // HTTP status code
+	Detail    string              `json:"detail,omitempty"`     // Explanation of this occurrence
+	Instance  string              `json:"instance,omitempty"`   // Path of the request that failed
+	Code      Code                `json:"code"`                 // Stable machine-readable error code
+	RequestID string              `json:"request_id,omitempty"` // ID of the request, to correlate with the logs
+	Errors    []domain.FieldError `json:"errors,omitempty"`     // Invalid fields, only for validation_failed
+}
+
+// New creates a problem with the status and title of the code
+func New(code Code, detail string) *Problem {
+	def, ok := definitions[code]
+	if !ok {
+		code, def = CodeInternal, definitions[CodeInternal]
+	}
+
+	return &Problem{
+		Type:   typeBase + string(code),
+		Title:  def.title,
+		Status: def.status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// FromError creates the problem of the first domain error wrapped by err
+// Errors without a known domain error become an internal error with the given detail, their message is never exposed
+func FromError(err error, internalDetail string) *Problem {
+	var validation *domain.ValidationError
+	if errors.As(err, &validation) {
+		p := New(CodeValidationFailed, validation.Error())
+		p.Errors = validation.Fields
+		return p
+	}
+
+	for _, known := range domainErrors {
+		if errors.Is(err, known.err) {
+			return New(known.code, known.err.Error())
+		}
+	}
+
+	return New(CodeInternal, internalDetail)
+}
+
+// Invalid creates the problem of a request that failed validation
+// Validation errors keep their invalid fields, any other error becomes a bad request with its message
+func Invalid(err error) *Problem {
+	var validation *domain.ValidationError
+	if errors.As(err, &validation) {
+		return FromError(err, "")
+	}
+
+	return New(CodeBadRequest, err.Error())
+}
+
+// Internal reports whether the problem is a server side failure that should be logged
+func (p *Problem) Internal() bool {
+	return p.Status >= http.StatusInternalServerError
+}
+
+// ForRequest sets the path and ID of the request the problem occurred in
+func (p *Problem) ForRequest(c *gin.Context) *Problem {
+	p.Instance = c.Request.URL.Path
+	p.RequestID = requestid.FromContext(c.Request.Context())
+
+	return p
+}
+
+// Respond writes the problem as application/problem+json and aborts the request
+func Respond(c *gin.Context, p *Problem) {
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(p.Status, p.ForRequest(c))
+}
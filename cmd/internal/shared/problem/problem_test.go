@@ -0,0 +1,246 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name            string
+		code            Code
+		detail          string
+		expectedProblem *Problem
+	}{
+		{
+			name:   "when code is known it should use its status and title",
+			code:   CodeInsufficientFunds,
+			detail: "insufficient funds",
+			expectedProblem: &Problem{
+				Type:   "/problems/insufficient_funds",
+				Title:  "Insufficient Funds",
+				Status: http.StatusUnprocessableEntity,
+				Detail: "insufficient funds",
+				Code:   CodeInsufficientFunds,
+			},
+		},
+		{
+			name:   "when code is unknown it should fall back to an internal error",
+			code:   Code("teapot"),
+			detail: "failed to brew",
+			expectedProblem: &Problem{
+				Type:   "/problems/internal_error",
+				Title:  "Internal Server Error",
+				Status: http.StatusInternalServerError,
+				Detail: "failed to brew",
+				Code:   CodeInternal,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Code and detail already prepared in test struct)
+
+			// Act
+			result := New(tt.code, tt.detail)
+
+			// Assert
+			assert.Equal(t, tt.expectedProblem, result)
+		})
+	}
+}
+
+func TestFromError(t *testing.T) {
+	validation := &domain.ValidationError{}
+	validation.Add("amount", "amount must be greater than 0")
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedCode   Code
+		expectedStatus int
+		expectedDetail string
+		expectedFields []domain.FieldError
+	}{
+		{
+			name:           "when error wraps payment not found it should return 404 payment_not_found",
+			err:            fmt.Errorf("payment finder: get payment: %w", domain.ErrPaymentNotFound),
+			expectedCode:   CodePaymentNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedDetail: "payment not found",
+		},
+		{
+			name:           "when error wraps insufficient funds it should return 422 insufficient_funds",
+			err:            fmt.Errorf("payment creator: reserve funds: %w", domain.ErrInsufficientFunds),
+			expectedCode:   CodeInsufficientFunds,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedDetail: "insufficient funds",
+		},
+		{
+			name:           "when error wraps wallet unavailable it should return 503 wallet_unavailable",
+			err:            fmt.Errorf("payment creator: reserve funds: %w", domain.ErrWalletUnavailable),
+			expectedCode:   CodeWalletUnavailable,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedDetail: "wallet service unavailable",
+		},
+		{
+			name:           "when error wraps idempotency key mismatch it should return 422 idempotency_key_mismatch",
+			err:            domain.ErrIdempotencyKeyMismatch,
+			expectedCode:   CodeIdempotencyKeyMismatch,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedDetail: "idempotency key was used with a different request",
+		},
+		{
+			name:           "when error wraps dead letter not pending it should return 409 invalid_transition",
+			err:            fmt.Errorf("dead letter replayer: %w", domain.ErrDeadLetterNotPending),
+			expectedCode:   CodeInvalidTransition,
+			expectedStatus: http.StatusConflict,
+			expectedDetail: "dead letter is not pending",
+		},
+		{
+			name:           "when error wraps gateway outcome conflict it should return 409 invalid_transition",
+			err:            fmt.Errorf("payment settler: settle payment pay_123: %w", domain.ErrGatewayOutcomeConflict),
+			expectedCode:   CodeInvalidTransition,
+			expectedStatus: http.StatusConflict,
+			expectedDetail: "gateway outcome conflicts with the payment",
+		},
+		{
+			name:           "when error is a validation error it should return 400 validation_failed with the fields",
+			err:            validation,
+			expectedCode:   CodeValidationFailed,
+			expectedStatus: http.StatusBadRequest,
+			expectedDetail: "amount must be greater than 0",
+			expectedFields: []domain.FieldError{{Field: "amount", Message: "amount must be greater than 0"}},
+		},
+		{
+			name:           "when error is unknown it should return 500 with the internal detail",
+			err:            errors.New("pq: connection refused"),
+			expectedCode:   CodeInternal,
+			expectedStatus: http.StatusInternalServerError,
+			expectedDetail: "failed to create payment",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Error already prepared in test struct)
+
+			// Act
+			result := FromError(tt.err, "failed to create payment")
+
+			// Assert
+			assert.Equal(t, tt.expectedCode, result.Code)
+			assert.Equal(t, tt.expectedStatus, result.Status)
+			assert.Equal(t, tt.expectedDetail, result.Detail)
+			assert.Equal(t, tt.expectedFields, result.Errors)
+		})
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	validation := &domain.ValidationError{}
+	validation.Add("payment_id", "payment ID is required")
+
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode Code
+	}{
+		{
+			name:         "when error is a validation error it should return validation_failed",
+			err:          validation,
+			expectedCode: CodeValidationFailed,
+		},
+		{
+			name:         "when error is a plain error it should return bad_request",
+			err:          errors.New("limit must be greater than 0"),
+			expectedCode: CodeBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Error already prepared in test struct)
+
+			// Act
+			result := Invalid(tt.err)
+
+			// Assert
+			assert.Equal(t, tt.expectedCode, result.Code)
+			assert.Equal(t, http.StatusBadRequest, result.Status)
+			assert.Equal(t, tt.err.Error(), result.Detail)
+		})
+	}
+}
+
+func TestRespond(t *testing.T) {
+	tests := []struct {
+		name              string
+		requestID         string
+		expectedRequestID string
+	}{
+		{
+			name:              "when request context has an ID it should echo it in the problem",
+			requestID:         "req_123",
+			expectedRequestID: "req_123",
+		},
+		{
+			name:              "when request has no ID it should omit it",
+			requestID:         "",
+			expectedRequestID: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+			router.GET("/api/v1/payments/:id", func(c *gin.Context) {
+				Respond(c, New(CodePaymentNotFound, "payment not found"))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/payments/pay_123", nil)
+			if tt.requestID != "" {
+				req = req.WithContext(requestid.WithRequestID(req.Context(), tt.requestID))
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			router.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, "/problems/payment_not_found", response["type"])
+			assert.Equal(t, "payment_not_found", response["code"])
+			assert.Equal(t, "/api/v1/payments/pay_123", response["instance"])
+			assert.Equal(t, float64(http.StatusNotFound), response["status"])
+			if tt.expectedRequestID != "" {
+				assert.Equal(t, tt.expectedRequestID, response["request_id"])
+			} else {
+				assert.NotContains(t, response, "request_id")
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package deadletterstorer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+)
+
+const (
+	systemActor = "system" // Actor recorded for actions not taken by an operator
+)
+
+// DeadLetterDB defines the database operations required by DeadLetterRepository
+type DeadLetterDB interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) database.RowScanner
+	QueryContext(ctx context.Context, query string, args ...any) (database.Rows, error)
+	WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error
+}
+
+// DeadLetterRepository handles all database operations for dead letters
+type DeadLetterRepository struct {
+	db DeadLetterDB
+}
+
+// NewStorer creates a new DeadLetterRepository
+func NewStorer(db DeadLetterDB) (*DeadLetterRepository, error) {
+	if db == nil {
+		return nil, errors.New("dead letter repository: database cannot be nil")
+	}
+
+	return &DeadLetterRepository{db: db}, nil
+}
+
+// Save archives a new dead letter with its audit entry
+// Saving a dead letter that was already archived is a no-op
+func (r *DeadLetterRepository) Save(ctx context.Context, deadLetter *domain.DeadLetter) error {
+	headers, err := json.Marshal(deadLetter.Headers)
+	if err != nil {
+		return fmt.Errorf("dead letter repository: marshal headers: %w", err)
+	}
+
+	err = r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		insertQuery := `
+			INSERT INTO dead_letters (id, queue, routing_key, body, content_type, message_id, headers, failure_reason, attempts, status, dead_lettered_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			ON CONFLICT (id) DO NOTHING
+		`
+		result, err := tx.ExecContext(ctx, insertQuery,
+			deadLetter.ID,
+			deadLetter.Queue,
+			deadLetter.RoutingKey,
+			deadLetter.Body,
+			deadLetter.ContentType,
+			deadLetter.MessageID,
+			headers,
+			deadLetter.FailureReason,
+			deadLetter.Attempts,
+			deadLetter.Status,
+			deadLetter.DeadLetteredAt,
+			deadLetter.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("insert dead letter: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected: %w", err)
+		}
+
+		// Redelivered dead letter, already archived and audited
+		if rowsAffected == 0 {
+			return nil
+		}
+
+		return insertAudit(ctx, tx, &domain.DeadLetterAudit{
+			DeadLetterID: deadLetter.ID,
+			Action:       domain.DeadLetterActionArchived,
+			Actor:        systemActor,
+			Reason:       deadLetter.FailureReason,
+		})
+	})
+
+	if err != nil {
+		return fmt.Errorf("dead letter repository: save: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a dead letter by ID, including its body and headers
+func (r *DeadLetterRepository) GetByID(ctx context.Context, deadLetterID string) (*domain.DeadLetter, error) {
+	query := `
+		SELECT id, queue, routing_key, body, content_type, message_id, headers, failure_reason, attempts, status, dead_lettered_at, updated_at
+		FROM dead_letters
+		WHERE id = $1
+	`
+
+	deadLetter, err := scanDeadLetter(r.db.QueryRowContext(ctx, query, deadLetterID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrDeadLetterNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("dead letter repository: get by id: %w", err)
+	}
+
+	return deadLetter, nil
+}
+
+// List retrieves the dead letters matching the filter, oldest first and without their body and headers
+func (r *DeadLetterRepository) List(ctx context.Context, filter *domain.DeadLetterFilter) ([]*domain.DeadLetter, error) {
+	query := `
+		SELECT id, queue, routing_key, failure_reason, attempts, status, dead_lettered_at, updated_at
+		FROM dead_letters
+		WHERE ($1 = '' OR status = $1)
+			AND ($2 = '' OR queue = $2)
+			AND ($3 = '' OR failure_reason ILIKE '%' || $3 || '%')
+		ORDER BY dead_lettered_at ASC, id ASC
+		LIMIT $4 OFFSET $5
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, string(filter.Status), filter.Queue, filter.FailureReason, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("dead letter repository: list: %w", err)
+	}
+	defer rows.Close()
+
+	deadLetters := []*domain.DeadLetter{}
+	for rows.Next() {
+		var deadLetter domain.DeadLetter
+		err := rows.Scan(
+			&deadLetter.ID,
+			&deadLetter.Queue,
+			&deadLetter.RoutingKey,
+			&deadLetter.FailureReason,
+			&deadLetter.Attempts,
+			&deadLetter.Status,
+			&deadLetter.DeadLetteredAt,
+			&deadLetter.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("dead letter repository: scan dead letter: %w", err)
+		}
+		deadLetters = append(deadLetters, &deadLetter)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dead letter repository: iterate dead letters: %w", err)
+	}
+
+	return deadLetters, nil
+}
+
+// Resolve moves a pending dead letter to the given status and records the audit entry
+// The apply function runs while the dead letter is locked, and its error rolls the change back
+func (r *DeadLetterRepository) Resolve(ctx context.Context, audit *domain.DeadLetterAudit, status domain.DeadLetterStatus, apply func(deadLetter *domain.DeadLetter) error) error {
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// Lock the dead letter so concurrent operators cannot resolve it twice
+		selectQuery := `
+			SELECT id, queue, routing_key, body, content_type, message_id, headers, failure_reason, attempts, status, dead_lettered_at, updated_at
+			FROM dead_letters
+			WHERE id = $1
+			FOR UPDATE
+		`
+		deadLetter, err := scanDeadLetter(tx.QueryRowContext(ctx, selectQuery, audit.DeadLetterID))
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrDeadLetterNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("get dead letter: %w", err)
+		}
+
+		if deadLetter.Status != domain.DeadLetterStatusPending {
+			return domain.ErrDeadLetterNotPending
+		}
+
+		if apply != nil {
+			if err := apply(deadLetter); err != nil {
+				return err
+			}
+		}
+
+		updateQuery := `
+			UPDATE dead_letters
+			SET status = $1, updated_at = $2
+			WHERE id = $3
+		`
+		if _, err := tx.ExecContext(ctx, updateQuery, status, time.Now(), deadLetter.ID); err != nil {
+			return fmt.Errorf("update status: %w", err)
+		}
+
+		return insertAudit(ctx, tx, audit)
+	})
+
+	if err != nil {
+		return fmt.Errorf("dead letter repository: resolve: %w", err)
+	}
+
+	return nil
+}
+
+// GetAuditByDeadLetterID retrieves the audit trail of a dead letter, oldest first
+func (r *DeadLetterRepository) GetAuditByDeadLetterID(ctx context.Context, deadLetterID string) ([]*domain.DeadLetterAudit, error) {
+	query := `
+		SELECT id, dead_letter_id, action, actor, reason, created_at
+		FROM dead_letter_audit
+		WHERE dead_letter_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, deadLetterID)
+	if err != nil {
+		return nil, fmt.Errorf("dead letter repository: get audit by dead letter id: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*domain.DeadLetterAudit{}
+	for rows.Next() {
+		var entry domain.DeadLetterAudit
+		err := rows.Scan(
+			&entry.ID,
+			&entry.DeadLetterID,
+			&entry.Action,
+			&entry.Actor,
+			&entry.Reason,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("dead letter repository: scan audit: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dead letter repository: iterate audit: %w", err)
+	}
+
+	return entries, nil
+}
+
+// scanDeadLetter scans a full dead letter row, decoding its headers
+func scanDeadLetter(row database.RowScanner) (*domain.DeadLetter, error) {
+	var deadLetter domain.DeadLetter
+	var headers []byte
+	err := row.Scan(
+		&deadLetter.ID,
+		&deadLetter.Queue,
+		&deadLetter.RoutingKey,
+		&deadLetter.Body,
+		&deadLetter.ContentType,
+		&deadLetter.MessageID,
+		&headers,
+		&deadLetter.FailureReason,
+		&deadLetter.Attempts,
+		&deadLetter.Status,
+		&deadLetter.DeadLetteredAt,
+		&deadLetter.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(headers) > 0 {
+		if err := json.Unmarshal(headers, &deadLetter.Headers); err != nil {
+			return nil, fmt.Errorf("unmarshal headers: %w", err)
+		}
+	}
+
+	return &deadLetter, nil
+}
+
+// insertAudit inserts an audit entry within the given transaction
+func insertAudit(ctx context.Context, tx *sql.Tx, audit *domain.DeadLetterAudit) error {
+	query := `
+		INSERT INTO dead_letter_audit (id, dead_letter_id, action, actor, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := tx.ExecContext(ctx, query,
+		uuid.New().String(),
+		audit.DeadLetterID,
+		audit.Action,
+		audit.Actor,
+		audit.Reason,
+		time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert audit: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package deadletterstorer
+
+import (
+	"context"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockDeadLetterRepository is a mock implementation of DeadLetterRepository for external testing
+type MockDeadLetterRepository struct {
+	mock.Mock
+}
+
+// Save archives a new dead letter
+func (m *MockDeadLetterRepository) Save(ctx context.Context, deadLetter *domain.DeadLetter) error {
+	args := m.Called(ctx, deadLetter)
+	return args.Error(0)
+}
+
+// GetByID retrieves a dead letter by ID
+func (m *MockDeadLetterRepository) GetByID(ctx context.Context, deadLetterID string) (*domain.DeadLetter, error) {
+	args := m.Called(ctx, deadLetterID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.DeadLetter), args.Error(1)
+}
+
+// List retrieves the dead letters matching the filter
+func (m *MockDeadLetterRepository) List(ctx context.Context, filter *domain.DeadLetterFilter) ([]*domain.DeadLetter, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.DeadLetter), args.Error(1)
+}
+
+// Resolve moves a pending dead letter to the given status
+// The apply function is called with the dead letter configured in the second return value, if any
+func (m *MockDeadLetterRepository) Resolve(ctx context.Context, audit *domain.DeadLetterAudit, status domain.DeadLetterStatus, apply func(deadLetter *domain.DeadLetter) error) error {
+	args := m.Called(ctx, audit, status, apply)
+	if deadLetter, ok := args.Get(1).(*domain.DeadLetter); ok && apply != nil {
+		if err := apply(deadLetter); err != nil {
+			return err
+		}
+	}
+	return args.Error(0)
+}
+
+// GetAuditByDeadLetterID retrieves the audit trail of a dead letter
+func (m *MockDeadLetterRepository) GetAuditByDeadLetterID(ctx context.Context, deadLetterID string) ([]*domain.DeadLetterAudit, error) {
+	args := m.Called(ctx, deadLetterID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.DeadLetterAudit), args.Error(1)
+}
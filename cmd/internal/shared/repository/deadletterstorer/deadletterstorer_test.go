@@ -0,0 +1,462 @@
+package deadletterstorer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewStorer(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            DeadLetterDB
+		expectedError string
+	}{
+		{
+			name:          "when database is provided it should create repository successfully and no error",
+			db:            new(database.MockDB),
+			expectedError: "",
+		},
+		{
+			name:          "when database is nil it should return error with message 'dead letter repository: database cannot be nil'",
+			db:            nil,
+			expectedError: "dead letter repository: database cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Database already prepared in test struct)
+
+			// Act
+			result, err := NewStorer(tt.db)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+				assert.NotNil(t, result.db)
+			}
+		})
+	}
+}
+
+func TestDeadLetterRepository_Save(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	deadLetter := &domain.DeadLetter{
+		ID:             "dl_123",
+		Queue:          "payments.created",
+		RoutingKey:     "payments.created",
+		Body:           []byte{0x0a, 0x07, 'p', 'a', 'y', '_', '1', '2', '3', 0x00, 0xff},
+		ContentType:    "application/protobuf",
+		MessageID:      "msg_123",
+		Headers:        map[string]interface{}{"x-attempts": 5},
+		FailureReason:  "gateway timeout",
+		Attempts:       5,
+		Status:         domain.DeadLetterStatusPending,
+		DeadLetteredAt: fixedTime,
+		UpdatedAt:      fixedTime,
+	}
+
+	tests := []struct {
+		name                 string
+		deadLetter           *domain.DeadLetter
+		mockTransactionError error
+		expectedError        error
+	}{
+		{
+			name:                 "when dead letter is valid it should save successfully and no error",
+			deadLetter:           deadLetter,
+			mockTransactionError: nil,
+			expectedError:        nil,
+		},
+		{
+			name:                 "when transaction fails it should return wrapped error",
+			deadLetter:           deadLetter,
+			mockTransactionError: errors.New("insert dead letter: connection refused"),
+			expectedError:        errors.New("dead letter repository: save: insert dead letter: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockDB.On("WithTransaction", mock.Anything, mock.Anything).Return(tt.mockTransactionError)
+
+			repo := &DeadLetterRepository{db: mockDB}
+
+			// Act
+			err := repo.Save(context.Background(), tt.deadLetter)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeadLetterRepository_GetByID(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		deadLetterID       string
+		mockHeaders        []byte
+		mockScanError      error
+		expectedDeadLetter *domain.DeadLetter
+		expectedError      error
+	}{
+		{
+			name:          "when dead letter exists it should return dead letter with its raw body and decoded headers and no error",
+			deadLetterID:  "dl_123",
+			mockHeaders:   []byte(`{"x-attempts":5}`),
+			mockScanError: nil,
+			expectedDeadLetter: &domain.DeadLetter{
+				ID:             "dl_123",
+				Queue:          "payments.created",
+				RoutingKey:     "payments.created",
+				Body:           []byte{0x0a, 0x07, 'p', 'a', 'y', '_', '1', '2', '3', 0x00, 0xff},
+				ContentType:    "application/protobuf",
+				MessageID:      "msg_123",
+				Headers:        map[string]interface{}{"x-attempts": float64(5)},
+				FailureReason:  "gateway timeout",
+				Attempts:       5,
+				Status:         domain.DeadLetterStatusPending,
+				DeadLetteredAt: fixedTime,
+				UpdatedAt:      fixedTime,
+			},
+			expectedError: nil,
+		},
+		{
+			name:               "when dead letter does not exist it should return ErrDeadLetterNotFound",
+			deadLetterID:       "dl_nonexistent",
+			mockScanError:      sql.ErrNoRows,
+			expectedDeadLetter: nil,
+			expectedError:      domain.ErrDeadLetterNotFound,
+		},
+		{
+			name:               "when database error occurs it should return wrapped error",
+			deadLetterID:       "dl_123",
+			mockScanError:      errors.New("connection refused"),
+			expectedDeadLetter: nil,
+			expectedError:      errors.New("dead letter repository: get by id: connection refused"),
+		},
+		{
+			name:               "when headers are not valid JSON it should return wrapped error",
+			deadLetterID:       "dl_123",
+			mockHeaders:        []byte(`{invalid`),
+			mockScanError:      nil,
+			expectedDeadLetter: nil,
+			expectedError:      errors.New("dead letter repository: get by id: unmarshal headers: invalid character 'i' looking for beginning of object key string"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockScanner := new(database.MockRowScanner)
+
+			if tt.mockScanError == nil {
+				mockScanner.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+					dest := args.Get(0).([]any)
+					*dest[0].(*string) = tt.deadLetterID
+					*dest[1].(*string) = "payments.created"
+					*dest[2].(*string) = "payments.created"
+					*dest[3].(*[]byte) = []byte{0x0a, 0x07, 'p', 'a', 'y', '_', '1', '2', '3', 0x00, 0xff}
+					*dest[4].(*string) = "application/protobuf"
+					*dest[5].(*string) = "msg_123"
+					*dest[6].(*[]byte) = tt.mockHeaders
+					*dest[7].(*string) = "gateway timeout"
+					*dest[8].(*int) = 5
+					*dest[9].(*domain.DeadLetterStatus) = domain.DeadLetterStatusPending
+					*dest[10].(*time.Time) = fixedTime
+					*dest[11].(*time.Time) = fixedTime
+				}).Return(nil)
+			} else {
+				mockScanner.On("Scan", mock.Anything).Return(tt.mockScanError)
+			}
+
+			mockDB.On("QueryRowContext", mock.Anything, mock.Anything, mock.Anything).Return(mockScanner)
+
+			repo := &DeadLetterRepository{db: mockDB}
+
+			// Act
+			result, err := repo.GetByID(context.Background(), tt.deadLetterID)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedDeadLetter, result)
+			}
+
+			mockDB.AssertExpectations(t)
+			mockScanner.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeadLetterRepository_List(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		filter          *domain.DeadLetterFilter
+		mockDeadLetters []*domain.DeadLetter
+		mockQueryError  error
+		mockScanError   error
+		mockRowsError   error
+		expectedCount   int
+		expectedError   error
+	}{
+		{
+			name:   "when dead letters match it should return them and no error",
+			filter: &domain.DeadLetterFilter{Status: domain.DeadLetterStatusPending, Limit: 50},
+			mockDeadLetters: []*domain.DeadLetter{
+				{ID: "dl_1", Queue: "payments.created", RoutingKey: "payments.created", FailureReason: "gateway timeout", Attempts: 5, Status: domain.DeadLetterStatusPending, DeadLetteredAt: fixedTime, UpdatedAt: fixedTime},
+				{ID: "dl_2", Queue: "payments.created", RoutingKey: "payments.created", FailureReason: "invalid payload", Attempts: 5, Status: domain.DeadLetterStatusPending, DeadLetteredAt: fixedTime, UpdatedAt: fixedTime},
+			},
+			expectedCount: 2,
+			expectedError: nil,
+		},
+		{
+			name:            "when no dead letters match it should return empty slice and no error",
+			filter:          &domain.DeadLetterFilter{Queue: "payments.other", Limit: 50},
+			mockDeadLetters: []*domain.DeadLetter{},
+			expectedCount:   0,
+			expectedError:   nil,
+		},
+		{
+			name:           "when query fails it should return wrapped error",
+			filter:         &domain.DeadLetterFilter{Limit: 50},
+			mockQueryError: errors.New("connection refused"),
+			expectedError:  errors.New("dead letter repository: list: connection refused"),
+		},
+		{
+			name:          "when scan fails it should return wrapped error",
+			filter:        &domain.DeadLetterFilter{Limit: 50},
+			mockScanError: errors.New("scan error"),
+			expectedError: errors.New("dead letter repository: scan dead letter: scan error"),
+		},
+		{
+			name:          "when rows iteration fails it should return wrapped error",
+			filter:        &domain.DeadLetterFilter{Limit: 50},
+			mockRowsError: errors.New("iteration error"),
+			expectedError: errors.New("dead letter repository: iterate dead letters: iteration error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockRows := new(database.MockRows)
+
+			if tt.mockQueryError != nil {
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, tt.mockQueryError)
+			} else {
+				if tt.mockScanError != nil {
+					mockRows.On("Next").Return(true).Once()
+					mockRows.On("Scan", mock.Anything).Return(tt.mockScanError)
+				} else {
+					if len(tt.mockDeadLetters) > 0 {
+						mockRows.On("Next").Return(true).Times(len(tt.mockDeadLetters))
+						scanCallCount := 0
+						mockRows.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+							dest := args.Get(0).([]any)
+							deadLetter := tt.mockDeadLetters[scanCallCount]
+							*dest[0].(*string) = deadLetter.ID
+							*dest[1].(*string) = deadLetter.Queue
+							*dest[2].(*string) = deadLetter.RoutingKey
+							*dest[3].(*string) = deadLetter.FailureReason
+							*dest[4].(*int) = deadLetter.Attempts
+							*dest[5].(*domain.DeadLetterStatus) = deadLetter.Status
+							*dest[6].(*time.Time) = deadLetter.DeadLetteredAt
+							*dest[7].(*time.Time) = deadLetter.UpdatedAt
+							scanCallCount++
+						}).Return(nil)
+					}
+					mockRows.On("Next").Return(false).Once()
+					mockRows.On("Err").Return(tt.mockRowsError)
+				}
+				mockRows.On("Close").Return(nil)
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(mockRows, nil)
+			}
+
+			repo := &DeadLetterRepository{db: mockDB}
+
+			// Act
+			result, err := repo.List(context.Background(), tt.filter)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, result, tt.expectedCount)
+				for i, deadLetter := range result {
+					assert.Equal(t, tt.mockDeadLetters[i], deadLetter)
+				}
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeadLetterRepository_Resolve(t *testing.T) {
+	tests := []struct {
+		name                 string
+		audit                *domain.DeadLetterAudit
+		status               domain.DeadLetterStatus
+		mockTransactionError error
+		expectedError        error
+	}{
+		{
+			name:                 "when dead letter is pending it should resolve successfully and no error",
+			audit:                &domain.DeadLetterAudit{DeadLetterID: "dl_123", Action: domain.DeadLetterActionDiscarded, Actor: "ops"},
+			status:               domain.DeadLetterStatusDiscarded,
+			mockTransactionError: nil,
+			expectedError:        nil,
+		},
+		{
+			name:                 "when dead letter is not pending it should return wrapped ErrDeadLetterNotPending",
+			audit:                &domain.DeadLetterAudit{DeadLetterID: "dl_123", Action: domain.DeadLetterActionReplayed, Actor: "ops"},
+			status:               domain.DeadLetterStatusReplayed,
+			mockTransactionError: domain.ErrDeadLetterNotPending,
+			expectedError:        domain.ErrDeadLetterNotPending,
+		},
+		{
+			name:                 "when dead letter does not exist it should return wrapped ErrDeadLetterNotFound",
+			audit:                &domain.DeadLetterAudit{DeadLetterID: "dl_nonexistent", Action: domain.DeadLetterActionReplayed, Actor: "ops"},
+			status:               domain.DeadLetterStatusReplayed,
+			mockTransactionError: domain.ErrDeadLetterNotFound,
+			expectedError:        domain.ErrDeadLetterNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockDB.On("WithTransaction", mock.Anything, mock.Anything).Return(tt.mockTransactionError)
+
+			repo := &DeadLetterRepository{db: mockDB}
+
+			// Act
+			err := repo.Resolve(context.Background(), tt.audit, tt.status, nil)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Equal(t, "dead letter repository: resolve: "+tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeadLetterRepository_GetAuditByDeadLetterID(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		deadLetterID   string
+		mockEntries    []*domain.DeadLetterAudit
+		mockQueryError error
+		expectedError  error
+	}{
+		{
+			name:         "when audit entries exist it should return them and no error",
+			deadLetterID: "dl_123",
+			mockEntries: []*domain.DeadLetterAudit{
+				{ID: "aud_1", DeadLetterID: "dl_123", Action: domain.DeadLetterActionArchived, Actor: "system", Reason: "gateway timeout", CreatedAt: fixedTime},
+				{ID: "aud_2", DeadLetterID: "dl_123", Action: domain.DeadLetterActionReplayed, Actor: "ops", Reason: "gateway is back", CreatedAt: fixedTime},
+			},
+			expectedError: nil,
+		},
+		{
+			name:           "when query fails it should return wrapped error",
+			deadLetterID:   "dl_123",
+			mockQueryError: errors.New("connection refused"),
+			expectedError:  errors.New("dead letter repository: get audit by dead letter id: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockRows := new(database.MockRows)
+
+			if tt.mockQueryError != nil {
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, tt.mockQueryError)
+			} else {
+				mockRows.On("Next").Return(true).Times(len(tt.mockEntries))
+				mockRows.On("Next").Return(false).Once()
+				scanCallCount := 0
+				mockRows.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+					dest := args.Get(0).([]any)
+					entry := tt.mockEntries[scanCallCount]
+					*dest[0].(*string) = entry.ID
+					*dest[1].(*string) = entry.DeadLetterID
+					*dest[2].(*string) = entry.Action
+					*dest[3].(*string) = entry.Actor
+					*dest[4].(*string) = entry.Reason
+					*dest[5].(*time.Time) = entry.CreatedAt
+					scanCallCount++
+				}).Return(nil)
+				mockRows.On("Err").Return(nil)
+				mockRows.On("Close").Return(nil)
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(mockRows, nil)
+			}
+
+			repo := &DeadLetterRepository{db: mockDB}
+
+			// Act
+			result, err := repo.GetAuditByDeadLetterID(context.Background(), tt.deadLetterID)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.mockEntries, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
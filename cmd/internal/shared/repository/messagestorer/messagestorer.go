@@ -0,0 +1,86 @@
+package messagestorer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+)
+
+// MessageDB defines the database operations required by MessageRepository
+type MessageDB interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) database.RowScanner
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// MessageRepository handles all database operations for processed messages
+type MessageRepository struct {
+	db MessageDB
+}
+
+// NewStorer creates a new MessageRepository
+func NewStorer(db MessageDB) (*MessageRepository, error) {
+	if db == nil {
+		return nil, errors.New("message repository: database cannot be nil")
+	}
+
+	return &MessageRepository{db: db}, nil
+}
+
+// Processed reports whether a handler already processed the message
+func (r *MessageRepository) Processed(ctx context.Context, messageID string, handler string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM processed_messages
+			WHERE message_id = $1 AND handler = $2
+		)
+	`
+
+	var processed bool
+	if err := r.db.QueryRowContext(ctx, query, messageID, handler).Scan(&processed); err != nil {
+		return false, fmt.Errorf("message repository: processed: %w", err)
+	}
+
+	return processed, nil
+}
+
+// Prune deletes the messages processed before the given time, their redeliveries are no longer expected
+func (r *MessageRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM processed_messages
+		WHERE processed_at < $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("message repository: prune: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("message repository: prune: rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// MarkProcessed records a message as processed by a handler within the given transaction
+// It runs in the transaction of the change the message caused, so the message is only skipped once that change is committed
+// Marking a message that was already marked is a no-op
+func MarkProcessed(ctx context.Context, tx *sql.Tx, messageID string, handler string, processedAt time.Time) error {
+	query := `
+		INSERT INTO processed_messages (message_id, handler, processed_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (message_id, handler) DO NOTHING
+	`
+
+	if _, err := tx.ExecContext(ctx, query, messageID, handler, processedAt); err != nil {
+		return fmt.Errorf("mark message processed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package messagestorer
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMessageRepository is a mock implementation of MessageRepository for external testing
+type MockMessageRepository struct {
+	mock.Mock
+}
+
+// Processed reports whether a handler already processed the message
+func (m *MockMessageRepository) Processed(ctx context.Context, messageID string, handler string) (bool, error) {
+	args := m.Called(ctx, messageID, handler)
+	return args.Bool(0), args.Error(1)
+}
+
+// Prune deletes the messages processed before the given time
+func (m *MockMessageRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,161 @@
+package messagestorer
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewStorer(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            MessageDB
+		expectedError string
+	}{
+		{
+			name:          "when database is provided it should create repository successfully and no error",
+			db:            new(database.MockDB),
+			expectedError: "",
+		},
+		{
+			name:          "when database is nil it should return error with message 'message repository: database cannot be nil'",
+			db:            nil,
+			expectedError: "message repository: database cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Database already prepared in test struct)
+
+			// Act
+			result, err := NewStorer(tt.db)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+				assert.NotNil(t, result.db)
+			}
+		})
+	}
+}
+
+func TestMessageRepository_Processed(t *testing.T) {
+	tests := []struct {
+		name              string
+		mockProcessed     bool
+		mockScanError     error
+		expectedProcessed bool
+		expectedError     error
+	}{
+		{
+			name:              "when message was processed by the handler it should return true and no error",
+			mockProcessed:     true,
+			expectedProcessed: true,
+			expectedError:     nil,
+		},
+		{
+			name:              "when message was not processed by the handler it should return false and no error",
+			mockProcessed:     false,
+			expectedProcessed: false,
+			expectedError:     nil,
+		},
+		{
+			name:              "when query fails it should return false and wrapped error",
+			mockScanError:     errors.New("connection refused"),
+			expectedProcessed: false,
+			expectedError:     errors.New("message repository: processed: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockScanner := new(database.MockRowScanner)
+
+			mockScanner.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+				dest := args.Get(0).([]any)
+				*dest[0].(*bool) = tt.mockProcessed
+			}).Return(tt.mockScanError)
+			mockDB.On("QueryRowContext", mock.Anything, mock.Anything, []any{"msg_123", "processor"}).Return(mockScanner)
+
+			repo := &MessageRepository{db: mockDB}
+
+			// Act
+			result, err := repo.Processed(context.Background(), "msg_123", "processor")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedProcessed, result)
+
+			mockDB.AssertExpectations(t)
+			mockScanner.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMessageRepository_Prune(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		mockResult      driver.Result
+		mockError       error
+		expectedDeleted int64
+		expectedError   error
+	}{
+		{
+			name:            "when old messages exist it should delete them and return the count",
+			mockResult:      driver.RowsAffected(3),
+			expectedDeleted: 3,
+			expectedError:   nil,
+		},
+		{
+			name:          "when delete fails it should return wrapped error",
+			mockError:     errors.New("connection refused"),
+			expectedError: errors.New("message repository: prune: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockDB.On("ExecContext", mock.Anything, mock.Anything, []any{fixedTime}).Return(tt.mockResult, tt.mockError)
+
+			repo := &MessageRepository{db: mockDB}
+
+			// Act
+			result, err := repo.Prune(context.Background(), fixedTime)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedDeleted, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
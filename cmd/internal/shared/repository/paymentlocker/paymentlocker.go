@@ -0,0 +1,48 @@
+package paymentlocker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/lock"
+)
+
+// lockPrefix namespaces payment locks from the other advisory locks, like the job locks
+const lockPrefix = "payment:"
+
+// AdvisoryLocker acquires advisory locks, waiting until they are released
+type AdvisoryLocker interface {
+	Lock(ctx context.Context, name string) (*lock.AdvisoryLock, error)
+}
+
+// Unlocker releases a held payment lock
+type Unlocker interface {
+	Unlock(ctx context.Context) error
+}
+
+// PaymentLockerRepository locks payments with PostgreSQL advisory locks
+// The locks are shared by every replica and every writer of a payment status, so status changes of a payment
+// are serialized across instances, whether they come from the processor, a job or a gateway callback
+type PaymentLockerRepository struct {
+	locker AdvisoryLocker
+}
+
+// NewLocker creates a new PaymentLockerRepository
+// It returns a new PaymentLockerRepository and an error if the locker is nil
+func NewLocker(locker AdvisoryLocker) (*PaymentLockerRepository, error) {
+	if locker == nil {
+		return nil, errors.New("payment locker: locker cannot be nil")
+	}
+
+	return &PaymentLockerRepository{locker: locker}, nil
+}
+
+// Lock acquires the lock of a payment, waiting until it is released or the context is done
+func (r *PaymentLockerRepository) Lock(ctx context.Context, paymentID string) (Unlocker, error) {
+	al, err := r.locker.Lock(ctx, lockPrefix+paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("payment locker: lock payment %s: %w", paymentID, err)
+	}
+	return al, nil
+}
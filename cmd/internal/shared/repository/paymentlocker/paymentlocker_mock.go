@@ -0,0 +1,47 @@
+package paymentlocker
+
+import (
+	"context"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/lock"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPaymentLockerRepository is a mock implementation of PaymentLockerRepository for external testing
+type MockPaymentLockerRepository struct {
+	mock.Mock
+}
+
+// Lock acquires the lock of a payment
+func (m *MockPaymentLockerRepository) Lock(ctx context.Context, paymentID string) (Unlocker, error) {
+	args := m.Called(ctx, paymentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(Unlocker), args.Error(1)
+}
+
+// MockUnlocker is a mock implementation of Unlocker for external testing
+type MockUnlocker struct {
+	mock.Mock
+}
+
+// Unlock releases the lock
+func (m *MockUnlocker) Unlock(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+// MockAdvisoryLocker is a mock implementation of AdvisoryLocker for testing
+type MockAdvisoryLocker struct {
+	mock.Mock
+}
+
+// Lock acquires an advisory lock
+func (m *MockAdvisoryLocker) Lock(ctx context.Context, name string) (*lock.AdvisoryLock, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*lock.AdvisoryLock), args.Error(1)
+}
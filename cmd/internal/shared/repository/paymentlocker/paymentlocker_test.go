@@ -0,0 +1,100 @@
+package paymentlocker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/lock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewLocker(t *testing.T) {
+	tests := []struct {
+		name          string
+		locker        AdvisoryLocker
+		expectedError string
+	}{
+		{
+			name:          "when locker is provided it should create repository successfully and no error",
+			locker:        &MockAdvisoryLocker{},
+			expectedError: "",
+		},
+		{
+			name:          "when locker is nil it should return error",
+			locker:        nil,
+			expectedError: "payment locker: locker cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewLocker(tt.locker)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestPaymentLockerRepository_Lock(t *testing.T) {
+	tests := []struct {
+		name          string
+		lock          *lock.AdvisoryLock
+		lockError     error
+		expectedError string
+	}{
+		{
+			name:          "when lock is acquired it should return the lock and no error",
+			lock:          &lock.AdvisoryLock{},
+			lockError:     nil,
+			expectedError: "",
+		},
+		{
+			name:          "when lock cannot be acquired it should return wrapped error",
+			lock:          nil,
+			lockError:     context.DeadlineExceeded,
+			expectedError: "payment locker: lock payment pay_123: context deadline exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockLocker := &MockAdvisoryLocker{}
+			if tt.lock != nil {
+				mockLocker.On("Lock", mock.Anything, "payment:pay_123").Return(tt.lock, tt.lockError)
+			} else {
+				mockLocker.On("Lock", mock.Anything, "payment:pay_123").Return(nil, tt.lockError)
+			}
+
+			repo, err := NewLocker(mockLocker)
+			assert.NoError(t, err)
+
+			// Act
+			result, err := repo.Lock(context.Background(), "pay_123")
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.lock, result)
+			}
+			mockLocker.AssertExpectations(t)
+		})
+	}
+}
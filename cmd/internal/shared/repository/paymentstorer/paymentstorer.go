@@ -10,6 +10,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/messagestorer"
 	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
 )
 
@@ -20,6 +21,12 @@ type PaymentDB interface {
 	WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error
 }
 
+// idempotencyKeyConstraint is the unique constraint on the payments idempotency key
+const idempotencyKeyConstraint = "payments_idempotency_key_key"
+
+// EventsChannel is the notification channel every appended event is notified on, with its payment ID as payload
+const EventsChannel = "payment_events"
+
 // PaymentRepository handles all database operations for payments
 type PaymentRepository struct {
 	db PaymentDB
@@ -37,7 +44,7 @@ func NewStorer(db PaymentDB) (*PaymentRepository, error) {
 // GetByID retrieves a payment by ID
 func (r *PaymentRepository) GetByID(ctx context.Context, paymentID string) (*domain.Payment, error) {
 	query := `
-		SELECT id, idempotency_key, user_id, amount, currency, status, created_at, updated_at
+		SELECT id, COALESCE(idempotency_key, ''), user_id, amount, currency, status, created_at, updated_at, COALESCE(gateway_ref, '')
 		FROM payments
 		WHERE id = $1
 	`
@@ -52,6 +59,7 @@ func (r *PaymentRepository) GetByID(ctx context.Context, paymentID string) (*dom
 		&payment.Status,
 		&payment.CreatedAt,
 		&payment.UpdatedAt,
+		&payment.GatewayRef,
 	)
 
 	if errors.Is(err, sql.ErrNoRows) {
@@ -68,7 +76,7 @@ func (r *PaymentRepository) GetByID(ctx context.Context, paymentID string) (*dom
 // GetByIDempotencyKey retrieves a payment by idempotency key
 func (r *PaymentRepository) GetByIDempotencyKey(ctx context.Context, idempotencyKey string) (*domain.Payment, error) {
 	query := `
-		SELECT id, idempotency_key, user_id, amount, currency, status, created_at, updated_at
+		SELECT id, COALESCE(idempotency_key, ''), user_id, amount, currency, status, created_at, updated_at, COALESCE(gateway_ref, '')
 		FROM payments
 		WHERE idempotency_key = $1
 	`
@@ -83,6 +91,7 @@ func (r *PaymentRepository) GetByIDempotencyKey(ctx context.Context, idempotency
 		&payment.Status,
 		&payment.CreatedAt,
 		&payment.UpdatedAt,
+		&payment.GatewayRef,
 	)
 
 	if errors.Is(err, sql.ErrNoRows) {
@@ -97,6 +106,7 @@ func (r *PaymentRepository) GetByIDempotencyKey(ctx context.Context, idempotency
 }
 
 // Save saves a new payment with its initial event
+// It returns domain.ErrDuplicateIdempotencyKey if another payment was saved first with the same idempotency key
 func (r *PaymentRepository) Save(ctx context.Context, payment *domain.Payment) error {
 	payload, err := json.Marshal(map[string]interface{}{
 		"payment_id":      payment.ID,
@@ -120,7 +130,7 @@ func (r *PaymentRepository) Save(ctx context.Context, payment *domain.Payment) e
 			uuid.New().String(),
 			payment.ID,
 			1,
-			"created",
+			domain.EventTypeCreated,
 			payload,
 			time.Now(),
 		)
@@ -143,6 +153,9 @@ func (r *PaymentRepository) Save(ctx context.Context, payment *domain.Payment) e
 			payment.CreatedAt,
 			payment.UpdatedAt,
 		)
+		if database.IsUniqueViolation(err, idempotencyKeyConstraint) {
+			return fmt.Errorf("insert payment: %w", domain.ErrDuplicateIdempotencyKey)
+		}
 		if err != nil {
 			return fmt.Errorf("insert payment: %w", err)
 		}
@@ -159,6 +172,17 @@ func (r *PaymentRepository) Save(ctx context.Context, payment *domain.Payment) e
 
 // UpdateStatus updates the payment status with optional gateway reference
 func (r *PaymentRepository) UpdateStatus(ctx context.Context, paymentID string, status domain.Status, gatewayRef string) error {
+	return r.updateStatus(ctx, paymentID, status, gatewayRef, "", "")
+}
+
+// UpdateStatusForMessage updates the payment status like UpdateStatus and marks the message that caused it as processed by the handler
+// Both are written in the same transaction, so a redelivered message is skipped only once the status change is committed
+func (r *PaymentRepository) UpdateStatusForMessage(ctx context.Context, paymentID string, status domain.Status, gatewayRef string, messageID string, handler string) error {
+	return r.updateStatus(ctx, paymentID, status, gatewayRef, messageID, handler)
+}
+
+// updateStatus updates the payment status, marking the message as processed when there is one
+func (r *PaymentRepository) updateStatus(ctx context.Context, paymentID string, status domain.Status, gatewayRef string, messageID string, handler string) error {
 	payload, err := json.Marshal(map[string]interface{}{
 		"payment_id":  paymentID,
 		"status":      status,
@@ -171,33 +195,9 @@ func (r *PaymentRepository) UpdateStatus(ctx context.Context, paymentID string,
 	now := time.Now()
 
 	err = r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
-		// Get next sequence number for this payment
-		var nextSequence int
-		sequenceQuery := `
-			SELECT COALESCE(MAX(sequence), 0) + 1
-			FROM payment_events
-			WHERE payment_id = $1
-		`
-		err := tx.QueryRowContext(ctx, sequenceQuery, paymentID).Scan(&nextSequence)
-		if err != nil {
-			return fmt.Errorf("get sequence: %w", err)
-		}
-
 		// Insert into Event Store
-		eventQuery := `
-			INSERT INTO payment_events (id, payment_id, sequence, event_type, payload, created_at)
-			VALUES ($1, $2, $3, $4, $5, $6)
-		`
-		_, err = tx.ExecContext(ctx, eventQuery,
-			uuid.New().String(),
-			paymentID,
-			nextSequence,
-			string(status),
-			payload,
-			now,
-		)
-		if err != nil {
-			return fmt.Errorf("insert event: %w", err)
+		if err := appendEvent(ctx, tx, paymentID, string(status), payload, now); err != nil {
+			return err
 		}
 
 		// Update Read Model
@@ -220,6 +220,13 @@ func (r *PaymentRepository) UpdateStatus(ctx context.Context, paymentID string,
 			return fmt.Errorf("payment not found: %s", paymentID)
 		}
 
+		// Mark the message in the same transaction, so it is not skipped if the status change is rolled back
+		if messageID != "" {
+			if err := messagestorer.MarkProcessed(ctx, tx, messageID, handler, now); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 
@@ -230,6 +237,57 @@ func (r *PaymentRepository) UpdateStatus(ctx context.Context, paymentID string,
 	return nil
 }
 
+// AppendEvent appends an event to the payment event store without changing the read model
+func (r *PaymentRepository) AppendEvent(ctx context.Context, paymentID string, eventType string, data map[string]interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("payment repository: marshal payload: %w", err)
+	}
+
+	err = r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		return appendEvent(ctx, tx, paymentID, eventType, payload, time.Now())
+	})
+
+	if err != nil {
+		return fmt.Errorf("payment repository: append event: %w", err)
+	}
+
+	return nil
+}
+
+// appendEvent inserts the next event for a payment in the Event Store within the given transaction
+func appendEvent(ctx context.Context, tx *sql.Tx, paymentID string, eventType string, payload []byte, createdAt time.Time) error {
+	// Get next sequence number for this payment
+	var nextSequence int
+	sequenceQuery := `
+		SELECT COALESCE(MAX(sequence), 0) + 1
+		FROM payment_events
+		WHERE payment_id = $1
+	`
+	err := tx.QueryRowContext(ctx, sequenceQuery, paymentID).Scan(&nextSequence)
+	if err != nil {
+		return fmt.Errorf("get sequence: %w", err)
+	}
+
+	eventQuery := `
+		INSERT INTO payment_events (id, payment_id, sequence, event_type, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err = tx.ExecContext(ctx, eventQuery,
+		uuid.New().String(),
+		paymentID,
+		nextSequence,
+		eventType,
+		payload,
+		createdAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert event: %w", err)
+	}
+
+	return nil
+}
+
 // GetEventsByPaymentID retrieves all events for a payment
 func (r *PaymentRepository) GetEventsByPaymentID(ctx context.Context, paymentID string) ([]*domain.Event, error) {
 	query := `
@@ -243,6 +301,29 @@ func (r *PaymentRepository) GetEventsByPaymentID(ctx context.Context, paymentID
 	if err != nil {
 		return nil, fmt.Errorf("payment repository: get events by payment id: %w", err)
 	}
+
+	return scanEvents(rows)
+}
+
+// GetEventsAfterSequence retrieves the events of a payment with a sequence greater than the given one
+func (r *PaymentRepository) GetEventsAfterSequence(ctx context.Context, paymentID string, sequence int) ([]*domain.Event, error) {
+	query := `
+		SELECT id, payment_id, sequence, event_type, payload, created_at
+		FROM payment_events
+		WHERE payment_id = $1 AND sequence > $2
+		ORDER BY sequence ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, paymentID, sequence)
+	if err != nil {
+		return nil, fmt.Errorf("payment repository: get events after sequence: %w", err)
+	}
+
+	return scanEvents(rows)
+}
+
+// scanEvents reads and closes the rows of an events query
+func scanEvents(rows database.Rows) ([]*domain.Event, error) {
 	defer rows.Close()
 
 	events := []*domain.Event{}
@@ -42,6 +42,18 @@ func (m *MockPaymentRepository) UpdateStatus(ctx context.Context, paymentID stri
 	return args.Error(0)
 }
 
+// UpdateStatusForMessage updates the payment status and marks the message as processed
+func (m *MockPaymentRepository) UpdateStatusForMessage(ctx context.Context, paymentID string, status domain.Status, gatewayRef string, messageID string, handler string) error {
+	args := m.Called(ctx, paymentID, status, gatewayRef, messageID, handler)
+	return args.Error(0)
+}
+
+// AppendEvent appends an event to the payment event store without changing the read model
+func (m *MockPaymentRepository) AppendEvent(ctx context.Context, paymentID string, eventType string, data map[string]interface{}) error {
+	args := m.Called(ctx, paymentID, eventType, data)
+	return args.Error(0)
+}
+
 // GetEventsByPaymentID retrieves all events for a payment
 func (m *MockPaymentRepository) GetEventsByPaymentID(ctx context.Context, paymentID string) ([]*domain.Event, error) {
 	args := m.Called(ctx, paymentID)
@@ -50,3 +62,12 @@ func (m *MockPaymentRepository) GetEventsByPaymentID(ctx context.Context, paymen
 	}
 	return args.Get(0).([]*domain.Event), args.Error(1)
 }
+
+// GetEventsAfterSequence retrieves the events of a payment after a sequence
+func (m *MockPaymentRepository) GetEventsAfterSequence(ctx context.Context, paymentID string, sequence int) ([]*domain.Event, error) {
+	args := m.Called(ctx, paymentID, sequence)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Event), args.Error(1)
+}
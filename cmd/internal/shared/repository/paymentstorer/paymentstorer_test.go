@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -94,6 +95,34 @@ func TestPaymentRepository_GetByID(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			name:      "when payment was charged by the gateway it should return payment with gateway reference and no error",
+			paymentID: "pay_123",
+			mockPayment: &domain.Payment{
+				ID:             "pay_123",
+				IdempotencyKey: "key_456",
+				UserID:         "user_789",
+				Amount:         100.50,
+				Currency:       domain.CurrencyUSD,
+				Status:         domain.StatusPendingConfirm,
+				GatewayRef:     "gw_123",
+				CreatedAt:      fixedTime,
+				UpdatedAt:      fixedTime,
+			},
+			mockScanError: nil,
+			expectedPayment: &domain.Payment{
+				ID:             "pay_123",
+				IdempotencyKey: "key_456",
+				UserID:         "user_789",
+				Amount:         100.50,
+				Currency:       domain.CurrencyUSD,
+				Status:         domain.StatusPendingConfirm,
+				GatewayRef:     "gw_123",
+				CreatedAt:      fixedTime,
+				UpdatedAt:      fixedTime,
+			},
+			expectedError: nil,
+		},
 		{
 			name:          "when payment does not exist it should return ErrPaymentNotFound",
 			paymentID:     "pay_nonexistent",
@@ -129,6 +158,7 @@ func TestPaymentRepository_GetByID(t *testing.T) {
 					*dest[5].(*domain.Status) = tt.mockPayment.Status
 					*dest[6].(*time.Time) = tt.mockPayment.CreatedAt
 					*dest[7].(*time.Time) = tt.mockPayment.UpdatedAt
+					*dest[8].(*string) = tt.mockPayment.GatewayRef
 				}).Return(tt.mockScanError)
 			} else {
 				mockScanner.On("Scan", mock.Anything).Return(tt.mockScanError)
@@ -155,6 +185,7 @@ func TestPaymentRepository_GetByID(t *testing.T) {
 				assert.Equal(t, tt.expectedPayment.Amount, result.Amount)
 				assert.Equal(t, tt.expectedPayment.Currency, result.Currency)
 				assert.Equal(t, tt.expectedPayment.Status, result.Status)
+				assert.Equal(t, tt.expectedPayment.GatewayRef, result.GatewayRef)
 			}
 
 			mockDB.AssertExpectations(t)
@@ -235,6 +266,7 @@ func TestPaymentRepository_GetByIDempotencyKey(t *testing.T) {
 					*dest[5].(*domain.Status) = tt.mockPayment.Status
 					*dest[6].(*time.Time) = tt.mockPayment.CreatedAt
 					*dest[7].(*time.Time) = tt.mockPayment.UpdatedAt
+					*dest[8].(*string) = tt.mockPayment.GatewayRef
 				}).Return(tt.mockScanError)
 			} else {
 				mockScanner.On("Scan", mock.Anything).Return(tt.mockScanError)
@@ -264,6 +296,7 @@ func TestPaymentRepository_GetByIDempotencyKey(t *testing.T) {
 				assert.Equal(t, tt.expectedPayment.Amount, result.Amount)
 				assert.Equal(t, tt.expectedPayment.Currency, result.Currency)
 				assert.Equal(t, tt.expectedPayment.Status, result.Status)
+				assert.Equal(t, tt.expectedPayment.GatewayRef, result.GatewayRef)
 			}
 
 			mockDB.AssertExpectations(t)
@@ -311,6 +344,21 @@ func TestPaymentRepository_Save(t *testing.T) {
 			mockTransactionError: errors.New("insert event: duplicate key"),
 			expectedError:        errors.New("payment repository: save: insert event: duplicate key"),
 		},
+		{
+			name: "when idempotency key is already taken it should return wrapped duplicate key error",
+			payment: &domain.Payment{
+				ID:             "pay_123",
+				IdempotencyKey: "key_456",
+				UserID:         "user_789",
+				Amount:         100.50,
+				Currency:       domain.CurrencyUSD,
+				Status:         domain.StatusPending,
+				CreatedAt:      fixedTime,
+				UpdatedAt:      fixedTime,
+			},
+			mockTransactionError: fmt.Errorf("insert payment: %w", domain.ErrDuplicateIdempotencyKey),
+			expectedError:        errors.New("payment repository: save: insert payment: idempotency key already exists"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -396,6 +444,122 @@ func TestPaymentRepository_UpdateStatus(t *testing.T) {
 	}
 }
 
+func TestPaymentRepository_UpdateStatusForMessage(t *testing.T) {
+	tests := []struct {
+		name                 string
+		mockTransactionError error
+		expectedError        error
+	}{
+		{
+			name:                 "when payment exists it should update status, mark the message and no error",
+			mockTransactionError: nil,
+			expectedError:        nil,
+		},
+		{
+			name:                 "when transaction fails it should return wrapped error",
+			mockTransactionError: errors.New("mark message processed: connection refused"),
+			expectedError:        errors.New("payment repository: update status: mark message processed: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockDB.On("WithTransaction", mock.Anything, mock.Anything).Return(tt.mockTransactionError)
+
+			repo := &PaymentRepository{db: mockDB}
+
+			// Act
+			err := repo.UpdateStatusForMessage(context.Background(), "pay_123", domain.StatusCompleted, "gw_ref_456", "msg_123", "processor")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPaymentRepository_AppendEvent(t *testing.T) {
+	tests := []struct {
+		name                  string
+		paymentID             string
+		eventType             string
+		data                  map[string]interface{}
+		mockTransactionError  error
+		shouldCallTransaction bool
+		expectedError         error
+	}{
+		{
+			name:                  "when event is valid it should append event successfully and no error",
+			paymentID:             "pay_123",
+			eventType:             domain.EventTypeRecoveryAttempted,
+			data:                  map[string]interface{}{"attempt": 1},
+			mockTransactionError:  nil,
+			shouldCallTransaction: true,
+			expectedError:         nil,
+		},
+		{
+			name:                  "when data is nil it should append event successfully and no error",
+			paymentID:             "pay_123",
+			eventType:             domain.EventTypeRecoveryAttempted,
+			data:                  nil,
+			mockTransactionError:  nil,
+			shouldCallTransaction: true,
+			expectedError:         nil,
+		},
+		{
+			name:                  "when data cannot be marshaled it should return wrapped error",
+			paymentID:             "pay_123",
+			eventType:             domain.EventTypeRecoveryAttempted,
+			data:                  map[string]interface{}{"invalid": make(chan int)},
+			mockTransactionError:  nil,
+			shouldCallTransaction: false,
+			expectedError:         errors.New("payment repository: marshal payload: json: unsupported type: chan int"),
+		},
+		{
+			name:                  "when transaction fails it should return wrapped error",
+			paymentID:             "pay_123",
+			eventType:             domain.EventTypeRecoveryAttempted,
+			data:                  map[string]interface{}{"attempt": 1},
+			mockTransactionError:  errors.New("insert event: duplicate key"),
+			shouldCallTransaction: true,
+			expectedError:         errors.New("payment repository: append event: insert event: duplicate key"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			if tt.shouldCallTransaction {
+				mockDB.On("WithTransaction", mock.Anything, mock.Anything).Return(tt.mockTransactionError)
+			}
+
+			repo := &PaymentRepository{db: mockDB}
+
+			// Act
+			err := repo.AppendEvent(context.Background(), tt.paymentID, tt.eventType, tt.data)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
 func TestPaymentRepository_GetEventsByPaymentID(t *testing.T) {
 	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
 
@@ -563,3 +727,123 @@ func TestPaymentRepository_GetEventsByPaymentID(t *testing.T) {
 		})
 	}
 }
+
+func TestPaymentRepository_GetEventsAfterSequence(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		paymentID      string
+		sequence       int
+		mockEvents     []*domain.Event
+		mockQueryError error
+		mockScanError  error
+		expectedEvents []*domain.Event
+		expectedError  error
+	}{
+		{
+			name:      "when events exist after the sequence it should return them and no error",
+			paymentID: "pay_123",
+			sequence:  1,
+			mockEvents: []*domain.Event{
+				{
+					ID:        "evt_2",
+					PaymentID: "pay_123",
+					Sequence:  2,
+					EventType: "completed",
+					Payload:   json.RawMessage(`{"status":"completed"}`),
+					CreatedAt: fixedTime,
+				},
+			},
+			expectedEvents: []*domain.Event{
+				{
+					ID:        "evt_2",
+					PaymentID: "pay_123",
+					Sequence:  2,
+					EventType: "completed",
+					Payload:   json.RawMessage(`{"status":"completed"}`),
+					CreatedAt: fixedTime,
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name:           "when no events exist after the sequence it should return empty slice and no error",
+			paymentID:      "pay_123",
+			sequence:       2,
+			mockEvents:     []*domain.Event{},
+			expectedEvents: []*domain.Event{},
+			expectedError:  nil,
+		},
+		{
+			name:           "when query fails it should return wrapped error",
+			paymentID:      "pay_123",
+			sequence:       0,
+			mockQueryError: errors.New("connection refused"),
+			expectedEvents: nil,
+			expectedError:  errors.New("payment repository: get events after sequence: connection refused"),
+		},
+		{
+			name:           "when scan fails it should return wrapped error",
+			paymentID:      "pay_123",
+			sequence:       0,
+			mockScanError:  errors.New("scan error"),
+			expectedEvents: nil,
+			expectedError:  errors.New("payment repository: scan event: scan error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockRows := new(database.MockRows)
+
+			if tt.mockQueryError != nil {
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, []any{tt.paymentID, tt.sequence}).Return(nil, tt.mockQueryError)
+			} else {
+				if tt.mockScanError != nil {
+					mockRows.On("Next").Return(true).Once()
+					mockRows.On("Scan", mock.Anything).Return(tt.mockScanError)
+				} else {
+					if len(tt.mockEvents) > 0 {
+						mockRows.On("Next").Return(true).Times(len(tt.mockEvents))
+						scanCallCount := 0
+						mockRows.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+							dest := args.Get(0).([]any)
+							event := tt.mockEvents[scanCallCount]
+							*dest[0].(*string) = event.ID
+							*dest[1].(*string) = event.PaymentID
+							*dest[2].(*int) = event.Sequence
+							*dest[3].(*string) = event.EventType
+							*dest[4].(*json.RawMessage) = event.Payload
+							*dest[5].(*time.Time) = event.CreatedAt
+							scanCallCount++
+						}).Return(nil).Times(len(tt.mockEvents))
+					}
+					mockRows.On("Next").Return(false).Once()
+					mockRows.On("Err").Return(nil)
+				}
+				mockRows.On("Close").Return(nil)
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, []any{tt.paymentID, tt.sequence}).Return(mockRows, nil)
+			}
+
+			repo := &PaymentRepository{db: mockDB}
+
+			// Act
+			result, err := repo.GetEventsAfterSequence(context.Background(), tt.paymentID, tt.sequence)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedEvents, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
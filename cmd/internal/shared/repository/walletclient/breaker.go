@@ -0,0 +1,102 @@
+package walletclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/circuitbreaker"
+)
+
+// CircuitBreaker runs calls through a circuit breaker
+type CircuitBreaker interface {
+	Execute(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// BreakerWalletClient guards every wallet operation with a circuit breaker
+// Calls rejected by the open breaker fail fast with domain.ErrWalletUnavailable
+// The breaker is expected to be configured with IsFailure, so business rejections do not open it
+type BreakerWalletClient struct {
+	next    Client
+	breaker CircuitBreaker
+}
+
+// NewBreakerWalletClient creates a new BreakerWalletClient
+func NewBreakerWalletClient(next Client, breaker CircuitBreaker) (*BreakerWalletClient, error) {
+	if next == nil {
+		return nil, errors.New("wallet client: client cannot be nil")
+	}
+	if breaker == nil {
+		return nil, errors.New("wallet client: breaker cannot be nil")
+	}
+
+	return &BreakerWalletClient{next: next, breaker: breaker}, nil
+}
+
+// Reserve reserves funds in the wallet for a payment
+func (bc *BreakerWalletClient) Reserve(ctx context.Context, userID string, amount float64, paymentID string) error {
+	return unavailable(bc.breaker.Execute(ctx, func(ctx context.Context) error {
+		return bc.next.Reserve(ctx, userID, amount, paymentID)
+	}))
+}
+
+// Confirm confirms the reserved funds deduction in the wallet
+func (bc *BreakerWalletClient) Confirm(ctx context.Context, userID string, amount float64, paymentID string) error {
+	return unavailable(bc.breaker.Execute(ctx, func(ctx context.Context) error {
+		return bc.next.Confirm(ctx, userID, amount, paymentID)
+	}))
+}
+
+// Release releases reserved funds back to available balance
+func (bc *BreakerWalletClient) Release(ctx context.Context, userID string, amount float64, paymentID string) error {
+	return unavailable(bc.breaker.Execute(ctx, func(ctx context.Context) error {
+		return bc.next.Release(ctx, userID, amount, paymentID)
+	}))
+}
+
+// GetOperation retrieves the status of the wallet operation for a payment
+func (bc *BreakerWalletClient) GetOperation(ctx context.Context, paymentID string) (*domain.WalletOperation, error) {
+	var operation *domain.WalletOperation
+	err := bc.breaker.Execute(ctx, func(ctx context.Context) error {
+		var err error
+		operation, err = bc.next.GetOperation(ctx, paymentID)
+		return err
+	})
+	if err != nil {
+		return nil, unavailable(err)
+	}
+	return operation, nil
+}
+
+// IsFailure reports whether a wallet error counts as a failure of the wallet in its circuit breaker
+// Business rejections, like insufficient funds, and 4xx responses are answers of a healthy wallet and never open the breaker
+// 408 and 429 responses are the wallet shedding load, so they count as failures like 5xx responses and network errors
+func IsFailure(err error) bool {
+	if errors.Is(err, domain.ErrInsufficientFunds) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return !isRejection(statusErr.StatusCode)
+	}
+	return true
+}
+
+// isRejection reports whether the status code is a 4xx rejection of the request
+func isRejection(statusCode int) bool {
+	if statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests {
+		return false
+	}
+	return statusCode >= 400 && statusCode < 500
+}
+
+// unavailable maps the open breaker error to domain.ErrWalletUnavailable
+func unavailable(err error) error {
+	if errors.Is(err, circuitbreaker.ErrOpen) {
+		return fmt.Errorf("%w: %w", domain.ErrWalletUnavailable, err)
+	}
+	return err
+}
@@ -0,0 +1,398 @@
+package walletclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/circuitbreaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testCooldown = 20 * time.Millisecond
+
+var errConnection = errors.New("connection refused")
+
+// newTestBreaker creates a wallet breaker opening after 4 calls with half of them failed
+// and letting a single probe through after a short cooldown
+func newTestBreaker(t *testing.T) *circuitbreaker.Breaker {
+	t.Helper()
+
+	breaker, err := circuitbreaker.New(circuitbreaker.Config{
+		Name:        "wallet",
+		Window:      time.Minute,
+		MinRequests: 4,
+		FailureRate: 0.5,
+		Cooldown:    testCooldown,
+		Probes:      1,
+		IsFailure:   IsFailure,
+	})
+	require.NoError(t, err)
+	return breaker
+}
+
+// reserve reserves funds through the client, returning the error
+func reserve(client *BreakerWalletClient) error {
+	return client.Reserve(context.Background(), "user_123", 100.50, "pay_123")
+}
+
+// trip opens the breaker with failed wallet calls
+func trip(t *testing.T, client *BreakerWalletClient, wallet *MockWalletClient) {
+	t.Helper()
+
+	wallet.On("Reserve", mock.Anything, "user_123", 100.50, "pay_123").Return(errConnection).Times(4)
+	for i := 0; i < 4; i++ {
+		reserve(client)
+	}
+}
+
+func TestNewBreakerWalletClient(t *testing.T) {
+	tests := []struct {
+		name          string
+		next          Client
+		breaker       CircuitBreaker
+		expectedError string
+	}{
+		{
+			name:    "when dependencies are valid it should create client and no error",
+			next:    new(MockWalletClient),
+			breaker: new(circuitbreaker.Breaker),
+		},
+		{
+			name:          "when client is nil it should return error",
+			next:          nil,
+			breaker:       new(circuitbreaker.Breaker),
+			expectedError: "wallet client: client cannot be nil",
+		},
+		{
+			name:          "when breaker is nil it should return error",
+			next:          new(MockWalletClient),
+			breaker:       nil,
+			expectedError: "wallet client: breaker cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			client, err := NewBreakerWalletClient(tt.next, tt.breaker)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, client)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, client)
+			}
+		})
+	}
+}
+
+func TestIsFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "when wallet rejects for insufficient funds it should not count as failure",
+			err:      domain.ErrInsufficientFunds,
+			expected: false,
+		},
+		{
+			name:     "when insufficient funds is wrapped it should not count as failure",
+			err:      fmt.Errorf("reserve: %w", domain.ErrInsufficientFunds),
+			expected: false,
+		},
+		{
+			name:     "when wallet answers 400 it should not count as failure",
+			err:      &StatusError{StatusCode: http.StatusBadRequest},
+			expected: false,
+		},
+		{
+			name:     "when wallet answers 404 it should not count as failure",
+			err:      &StatusError{StatusCode: http.StatusNotFound},
+			expected: false,
+		},
+		{
+			name:     "when wallet answers 409 wrapped it should not count as failure",
+			err:      fmt.Errorf("confirm: %w", &StatusError{StatusCode: http.StatusConflict}),
+			expected: false,
+		},
+		{
+			name:     "when wallet answers 408 it should count as failure",
+			err:      &StatusError{StatusCode: http.StatusRequestTimeout},
+			expected: true,
+		},
+		{
+			name:     "when wallet answers 429 it should count as failure",
+			err:      &StatusError{StatusCode: http.StatusTooManyRequests},
+			expected: true,
+		},
+		{
+			name:     "when wallet answers 500 it should count as failure",
+			err:      &StatusError{StatusCode: http.StatusInternalServerError},
+			expected: true,
+		},
+		{
+			name:     "when wallet answers 503 it should count as failure",
+			err:      &StatusError{StatusCode: http.StatusServiceUnavailable},
+			expected: true,
+		},
+		{
+			name:     "when wallet cannot be reached it should count as failure",
+			err:      errConnection,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			result := IsFailure(tt.err)
+
+			// Assert
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestBreakerWalletClient_Closed(t *testing.T) {
+	tests := []struct {
+		name          string
+		walletErrors  []error
+		expectedState circuitbreaker.State
+	}{
+		{
+			name:          "when every call fails it should open after the min requests",
+			walletErrors:  []error{errConnection, errConnection, errConnection, errConnection},
+			expectedState: circuitbreaker.StateOpen,
+		},
+		{
+			name:          "when the failure rate reaches the threshold it should open",
+			walletErrors:  []error{nil, errConnection, nil, &StatusError{StatusCode: http.StatusBadGateway}},
+			expectedState: circuitbreaker.StateOpen,
+		},
+		{
+			name:          "when calls fail below the min requests it should stay closed",
+			walletErrors:  []error{errConnection, errConnection, errConnection},
+			expectedState: circuitbreaker.StateClosed,
+		},
+		{
+			name:          "when the failure rate stays below the threshold it should stay closed",
+			walletErrors:  []error{nil, nil, nil, errConnection},
+			expectedState: circuitbreaker.StateClosed,
+		},
+		{
+			name:          "when the wallet rejects for insufficient funds it should stay closed",
+			walletErrors:  []error{domain.ErrInsufficientFunds, domain.ErrInsufficientFunds, domain.ErrInsufficientFunds, domain.ErrInsufficientFunds},
+			expectedState: circuitbreaker.StateClosed,
+		},
+		{
+			name: "when the wallet answers 4xx it should stay closed",
+			walletErrors: []error{
+				&StatusError{StatusCode: http.StatusBadRequest},
+				&StatusError{StatusCode: http.StatusNotFound},
+				&StatusError{StatusCode: http.StatusConflict},
+				&StatusError{StatusCode: http.StatusUnprocessableEntity},
+			},
+			expectedState: circuitbreaker.StateClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			wallet := new(MockWalletClient)
+			for _, err := range tt.walletErrors {
+				wallet.On("Reserve", mock.Anything, "user_123", 100.50, "pay_123").Return(err).Once()
+			}
+			breaker := newTestBreaker(t)
+			client, err := NewBreakerWalletClient(wallet, breaker)
+			require.NoError(t, err)
+
+			// Act
+			var errs []error
+			for range tt.walletErrors {
+				errs = append(errs, reserve(client))
+			}
+
+			// Assert
+			for i, err := range errs {
+				assert.Equal(t, tt.walletErrors[i], err)
+			}
+			assert.Equal(t, tt.expectedState, breaker.State())
+			wallet.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBreakerWalletClient_Open(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(client *BreakerWalletClient) error
+	}{
+		{
+			name: "when breaker is open it should reject reserve without calling the wallet",
+			call: func(client *BreakerWalletClient) error {
+				return client.Reserve(context.Background(), "user_123", 100.50, "pay_123")
+			},
+		},
+		{
+			name: "when breaker is open it should reject confirm without calling the wallet",
+			call: func(client *BreakerWalletClient) error {
+				return client.Confirm(context.Background(), "user_123", 100.50, "pay_123")
+			},
+		},
+		{
+			name: "when breaker is open it should reject release without calling the wallet",
+			call: func(client *BreakerWalletClient) error {
+				return client.Release(context.Background(), "user_123", 100.50, "pay_123")
+			},
+		},
+		{
+			name: "when breaker is open it should reject get operation without calling the wallet",
+			call: func(client *BreakerWalletClient) error {
+				operation, err := client.GetOperation(context.Background(), "pay_123")
+				if operation != nil {
+					return errors.New("operation returned while open")
+				}
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			wallet := new(MockWalletClient)
+			breaker := newTestBreaker(t)
+			client, err := NewBreakerWalletClient(wallet, breaker)
+			require.NoError(t, err)
+			trip(t, client, wallet)
+
+			// Act
+			err = tt.call(client)
+
+			// Assert
+			assert.Error(t, err)
+			assert.True(t, errors.Is(err, domain.ErrWalletUnavailable))
+			assert.True(t, errors.Is(err, circuitbreaker.ErrOpen))
+			assert.Equal(t, "wallet service unavailable: circuit breaker is open", err.Error())
+			assert.Equal(t, circuitbreaker.StateOpen, breaker.State())
+			wallet.AssertNumberOfCalls(t, "Reserve", 4)
+			wallet.AssertNumberOfCalls(t, "Confirm", 0)
+			wallet.AssertNumberOfCalls(t, "Release", 0)
+			wallet.AssertNumberOfCalls(t, "GetOperation", 0)
+		})
+	}
+}
+
+func TestBreakerWalletClient_HalfOpen(t *testing.T) {
+	tests := []struct {
+		name          string
+		probeError    error
+		expectedState circuitbreaker.State
+	}{
+		{
+			name:          "when the probe succeeds it should close",
+			probeError:    nil,
+			expectedState: circuitbreaker.StateClosed,
+		},
+		{
+			name:          "when the probe is rejected for insufficient funds it should close",
+			probeError:    domain.ErrInsufficientFunds,
+			expectedState: circuitbreaker.StateClosed,
+		},
+		{
+			name:          "when the probe is answered with 4xx it should close",
+			probeError:    &StatusError{StatusCode: http.StatusNotFound},
+			expectedState: circuitbreaker.StateClosed,
+		},
+		{
+			name:          "when the probe fails it should open again",
+			probeError:    errConnection,
+			expectedState: circuitbreaker.StateOpen,
+		},
+		{
+			name:          "when the probe is answered with 5xx it should open again",
+			probeError:    &StatusError{StatusCode: http.StatusServiceUnavailable},
+			expectedState: circuitbreaker.StateOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			wallet := new(MockWalletClient)
+			breaker := newTestBreaker(t)
+			client, err := NewBreakerWalletClient(wallet, breaker)
+			require.NoError(t, err)
+			trip(t, client, wallet)
+			time.Sleep(testCooldown)
+			require.Equal(t, circuitbreaker.StateHalfOpen, breaker.State())
+			wallet.On("Confirm", mock.Anything, "user_123", 100.50, "pay_123").Return(tt.probeError).Once()
+
+			// Act
+			err = client.Confirm(context.Background(), "user_123", 100.50, "pay_123")
+
+			// Assert
+			assert.Equal(t, tt.probeError, err)
+			assert.Equal(t, tt.expectedState, breaker.State())
+			wallet.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBreakerWalletClient_HalfOpenSingleProbe(t *testing.T) {
+	t.Run("when a probe is in flight it should reject other calls without calling the wallet", func(t *testing.T) {
+		// Arrange
+		wallet := new(MockWalletClient)
+		breaker := newTestBreaker(t)
+		client, err := NewBreakerWalletClient(wallet, breaker)
+		require.NoError(t, err)
+		trip(t, client, wallet)
+		time.Sleep(testCooldown)
+
+		probing := make(chan struct{})
+		release := make(chan struct{})
+		wallet.On("Confirm", mock.Anything, "user_123", 100.50, "pay_123").Return(nil).Once().Run(func(args mock.Arguments) {
+			close(probing)
+			<-release
+		})
+
+		var wg sync.WaitGroup
+		var probeErr error
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			probeErr = client.Confirm(context.Background(), "user_123", 100.50, "pay_123")
+		}()
+		<-probing
+
+		// Act
+		reserveErr := reserve(client)
+		_, operationErr := client.GetOperation(context.Background(), "pay_123")
+		close(release)
+		wg.Wait()
+
+		// Assert
+		assert.True(t, errors.Is(reserveErr, domain.ErrWalletUnavailable))
+		assert.True(t, errors.Is(operationErr, domain.ErrWalletUnavailable))
+		assert.NoError(t, probeErr)
+		assert.Equal(t, circuitbreaker.StateClosed, breaker.State())
+		wallet.AssertNumberOfCalls(t, "Reserve", 4)
+		wallet.AssertNumberOfCalls(t, "Confirm", 1)
+		wallet.AssertNumberOfCalls(t, "GetOperation", 0)
+		assert.Equal(t, int64(2), breaker.Snapshot().Rejected)
+	})
+}
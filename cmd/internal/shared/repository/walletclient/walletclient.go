@@ -3,11 +3,32 @@ package walletclient
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 
 	"net/http"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
 )
 
+// Client is the set of wallet operations
+type Client interface {
+	Reserve(ctx context.Context, userID string, amount float64, paymentID string) error
+	Confirm(ctx context.Context, userID string, amount float64, paymentID string) error
+	Release(ctx context.Context, userID string, amount float64, paymentID string) error
+	GetOperation(ctx context.Context, paymentID string) (*domain.WalletOperation, error)
+}
+
+// StatusError is returned when the wallet answers with an unexpected status code
+type StatusError struct {
+	StatusCode int
+}
+
+// Error returns the status code of the wallet response
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("wallet responded with status %d", e.StatusCode)
+}
+
 // WalletClient implements all wallet operations
 type WalletClient struct {
 	client *http.Client
@@ -26,7 +47,8 @@ func NewWalletClient(client *http.Client) (*WalletClient, error) {
 func (wc *WalletClient) Reserve(ctx context.Context, userID string, amount float64, paymentID string) error {
 	slog.DebugContext(ctx, "[DEBUG] WalletClient.Reserve called", "user_id", userID, "amount", amount, "payment_id", paymentID)
 	// TODO: Implement the logic to reserve the funds via HTTP client
-	// POST /api/v1/wallets/:user_id/reserve
+	// POST /api/v1/wallets/:user_id/reserve, a 422 response is returned as domain.ErrInsufficientFunds
+	// and any other unexpected status as *StatusError
 	return nil
 }
 
@@ -45,3 +67,14 @@ func (wc *WalletClient) Release(ctx context.Context, userID string, amount float
 	// POST /api/v1/wallets/:user_id/release
 	return nil
 }
+
+// GetOperation retrieves the status of the wallet operation for a payment
+func (wc *WalletClient) GetOperation(ctx context.Context, paymentID string) (*domain.WalletOperation, error) {
+	slog.DebugContext(ctx, "[DEBUG] WalletClient.GetOperation called", "payment_id", paymentID)
+	// TODO: Implement the logic to get the operation via HTTP client
+	// GET /api/v1/wallets/operations/:payment_id
+	return &domain.WalletOperation{
+		PaymentID: paymentID,
+		Status:    domain.WalletOperationStatusUnknown,
+	}, nil
+}
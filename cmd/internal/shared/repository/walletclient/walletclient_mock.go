@@ -3,6 +3,7 @@ package walletclient
 import (
 	"context"
 
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -28,3 +29,12 @@ func (m *MockWalletClient) Release(ctx context.Context, userID string, amount fl
 	args := m.Called(ctx, userID, amount, paymentID)
 	return args.Error(0)
 }
+
+// GetOperation retrieves the status of the wallet operation for a payment
+func (m *MockWalletClient) GetOperation(ctx context.Context, paymentID string) (*domain.WalletOperation, error) {
+	args := m.Called(ctx, paymentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WalletOperation), args.Error(1)
+}
@@ -0,0 +1,61 @@
+package walletreconciler
+
+import (
+	"net/http"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentstorer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/walletclient"
+)
+
+// ReconcilerDB defines the database operations required by the wallet reconciler
+type ReconcilerDB interface {
+	paymentstorer.PaymentDB
+	DiscrepancyDB
+}
+
+// Build creates a new Handler with all dependencies wired up
+// It builds a new handler and returns an error if the builder fails
+func Build(db ReconcilerDB, rc *http.Client, wb walletclient.CircuitBreaker, locker paymentlocker.AdvisoryLocker, policy ReconciliationPolicy) (*Handler, error) {
+	ps, err := paymentstorer.NewStorer(db)
+	if err != nil {
+		return nil, err
+	}
+
+	cf, err := NewCandidateFinderRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	dr, err := NewDiscrepancyRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	wc, err := walletclient.NewWalletClient(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	bwc, err := walletclient.NewBreakerWalletClient(wc, wb)
+	if err != nil {
+		return nil, err
+	}
+
+	pl, err := paymentlocker.NewLocker(locker)
+	if err != nil {
+		return nil, err
+	}
+
+	wrs, err := NewWalletReconcilerService(cf, bwc, ps, dr, pl, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := NewHandler(wrs, dr)
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
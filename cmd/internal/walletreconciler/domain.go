@@ -0,0 +1,151 @@
+package walletreconciler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+)
+
+// Outcome represents the result of reconciling a payment with its wallet operation
+type Outcome string
+
+const (
+	OutcomeConsistent  Outcome = "consistent"  // The payment and the wallet agree
+	OutcomeRepaired    Outcome = "repaired"    // The mismatch is safe to repair automatically
+	OutcomeDiscrepancy Outcome = "discrepancy" // The mismatch needs an operator
+	OutcomeUnverified  Outcome = "unverified"  // The wallet could not tell the operation status
+)
+
+// Repair represents the corrective action for a mismatch that is safe to repair
+type Repair string
+
+const (
+	RepairNone          Repair = ""               // Nothing to repair
+	RepairMarkReserved  Repair = "mark_reserved"  // Funds are held, the payment missed the reserved status
+	RepairMarkCompleted Repair = "mark_completed" // Funds were deducted after a gateway success, the payment missed the completed status
+	RepairMarkFailed    Repair = "mark_failed"    // No funds are held, the payment can no longer progress
+	RepairConfirmFunds  Repair = "confirm_funds"  // The payment completed, the wallet missed the confirmation
+	RepairReleaseFunds  Repair = "release_funds"  // The payment failed, the wallet missed the release
+)
+
+// Decision represents what to do with a payment after comparing it with its wallet operation
+type Decision struct {
+	Outcome Outcome // Result of the comparison
+	Repair  Repair  // Corrective action, only set when the outcome is repaired
+}
+
+// Decide compares a payment status with its wallet operation status
+// It returns a repair only for mismatches where the money movement is known, everything else is a discrepancy
+// Stale reports whether a processing payment has waited for its gateway callback longer than the policy allows
+func Decide(payment domain.Status, wallet domain.WalletOperationStatus, stale bool) Decision {
+	if wallet == domain.WalletOperationStatusUnknown {
+		return Decision{Outcome: OutcomeUnverified}
+	}
+
+	switch payment {
+	case domain.StatusPending:
+		switch wallet {
+		case domain.WalletOperationStatusReserved:
+			return repaired(RepairMarkReserved)
+		case domain.WalletOperationStatusNotFound:
+			return repaired(RepairMarkFailed)
+		}
+	case domain.StatusReserved:
+		// Confirmed funds on a reserved payment have no known gateway reference, so they need an operator
+		switch wallet {
+		case domain.WalletOperationStatusReserved:
+			return Decision{Outcome: OutcomeConsistent}
+		case domain.WalletOperationStatusReleased:
+			return repaired(RepairMarkFailed)
+		}
+	case domain.StatusProcessing:
+		// The gateway callback owns payments waiting for their outcome, any other money movement needs an operator
+		// A callback that never arrives leaves the funds held forever, so a stale payment needs an operator too
+		if wallet == domain.WalletOperationStatusReserved && !stale {
+			return Decision{Outcome: OutcomeConsistent}
+		}
+	case domain.StatusPendingConfirm:
+		// The gateway already charged, the confirm retry job owns payments with funds still held
+		switch wallet {
+		case domain.WalletOperationStatusReserved:
+			return Decision{Outcome: OutcomeConsistent}
+		case domain.WalletOperationStatusConfirmed:
+			return repaired(RepairMarkCompleted)
+		}
+	case domain.StatusCompleted:
+		switch wallet {
+		case domain.WalletOperationStatusConfirmed:
+			return Decision{Outcome: OutcomeConsistent}
+		case domain.WalletOperationStatusReserved:
+			return repaired(RepairConfirmFunds)
+		}
+	case domain.StatusFailed:
+		switch wallet {
+		case domain.WalletOperationStatusReleased, domain.WalletOperationStatusNotFound:
+			return Decision{Outcome: OutcomeConsistent}
+		case domain.WalletOperationStatusReserved:
+			return repaired(RepairReleaseFunds)
+		}
+	}
+
+	return Decision{Outcome: OutcomeDiscrepancy}
+}
+
+// repaired returns a repaired decision with the given repair
+func repaired(repair Repair) Decision {
+	return Decision{Outcome: OutcomeRepaired, Repair: repair}
+}
+
+// Discrepancy represents a payment whose wallet operation disagrees in a way that cannot be repaired automatically
+type Discrepancy struct {
+	PaymentID     string                       `json:"payment_id"`     // Payment ID with the mismatch
+	UserID        string                       `json:"user_id"`        // User ID of the payment owner
+	Amount        float64                      `json:"amount"`         // Amount of the payment
+	PaymentStatus domain.Status                `json:"payment_status"` // Status of the payment when last checked
+	WalletStatus  domain.WalletOperationStatus `json:"wallet_status"`  // Status of the wallet operation when last checked
+	DetectedAt    time.Time                    `json:"detected_at"`    // Timestamp when the mismatch was first detected
+	LastSeenAt    time.Time                    `json:"last_seen_at"`   // Timestamp when the mismatch was last detected
+}
+
+// Cursor represents the position of the last payment checked, used to page through candidates
+type Cursor struct {
+	UpdatedAt time.Time // Updated at timestamp of the last payment checked
+	PaymentID string    // ID of the last payment checked, breaks ties on the same timestamp
+}
+
+// ReconciliationPolicy defines which payments are checked against the wallet
+type ReconciliationPolicy struct {
+	MinAge            time.Duration // Time since the last update before a payment is checked, so in-flight payments are left alone
+	Lookback          time.Duration // Time since the last update after which a payment is no longer checked
+	ProcessingTimeout time.Duration // Time a payment may wait for its gateway callback before it is reported as a discrepancy
+	BatchSize         int           // Payments loaded per page
+}
+
+// Validate validates the reconciliation policy
+// It returns an error if the policy is invalid
+func (p *ReconciliationPolicy) Validate() error {
+	if p.MinAge <= 0 {
+		return errors.New("min age must be greater than 0")
+	}
+	if p.Lookback <= p.MinAge {
+		return errors.New("lookback must be greater than min age")
+	}
+	if p.ProcessingTimeout <= 0 || p.ProcessingTimeout >= p.Lookback {
+		return errors.New("processing timeout must be greater than 0 and less than lookback")
+	}
+	if p.BatchSize <= 0 {
+		return errors.New("batch size must be greater than 0")
+	}
+	return nil
+}
+
+// ReconciliationResult summarizes the outcome of a reconciliation run
+type ReconciliationResult struct {
+	Checked       int `json:"checked"`       // Payments compared with the wallet
+	Consistent    int `json:"consistent"`    // Payments that agree with the wallet
+	Repaired      int `json:"repaired"`      // Payments repaired automatically
+	Discrepancies int `json:"discrepancies"` // Payments reported for an operator
+	Unverified    int `json:"unverified"`    // Payments the wallet could not tell about
+	Errors        int `json:"errors"`        // Payments that could not be reconciled in this run
+}
@@ -0,0 +1,224 @@
+package walletreconciler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecide(t *testing.T) {
+	tests := []struct {
+		name             string
+		paymentStatus    domain.Status
+		walletStatus     domain.WalletOperationStatus
+		stale            bool
+		expectedDecision Decision
+	}{
+		{
+			name:             "when wallet status is unknown it should return unverified",
+			paymentStatus:    domain.StatusReserved,
+			walletStatus:     domain.WalletOperationStatusUnknown,
+			expectedDecision: Decision{Outcome: OutcomeUnverified},
+		},
+		{
+			name:             "when pending payment has reserved funds it should repair to reserved",
+			paymentStatus:    domain.StatusPending,
+			walletStatus:     domain.WalletOperationStatusReserved,
+			expectedDecision: Decision{Outcome: OutcomeRepaired, Repair: RepairMarkReserved},
+		},
+		{
+			name:             "when pending payment has no wallet operation it should repair to failed",
+			paymentStatus:    domain.StatusPending,
+			walletStatus:     domain.WalletOperationStatusNotFound,
+			expectedDecision: Decision{Outcome: OutcomeRepaired, Repair: RepairMarkFailed},
+		},
+		{
+			name:             "when pending payment has confirmed funds it should return discrepancy",
+			paymentStatus:    domain.StatusPending,
+			walletStatus:     domain.WalletOperationStatusConfirmed,
+			expectedDecision: Decision{Outcome: OutcomeDiscrepancy},
+		},
+		{
+			name:             "when reserved payment has reserved funds it should return consistent",
+			paymentStatus:    domain.StatusReserved,
+			walletStatus:     domain.WalletOperationStatusReserved,
+			expectedDecision: Decision{Outcome: OutcomeConsistent},
+		},
+		{
+			name:             "when reserved payment has confirmed funds it should report a discrepancy since there is no gateway reference",
+			paymentStatus:    domain.StatusReserved,
+			walletStatus:     domain.WalletOperationStatusConfirmed,
+			expectedDecision: Decision{Outcome: OutcomeDiscrepancy},
+		},
+		{
+			name:             "when reserved payment has released funds it should repair to failed",
+			paymentStatus:    domain.StatusReserved,
+			walletStatus:     domain.WalletOperationStatusReleased,
+			expectedDecision: Decision{Outcome: OutcomeRepaired, Repair: RepairMarkFailed},
+		},
+		{
+			name:             "when reserved payment has no wallet operation it should return discrepancy",
+			paymentStatus:    domain.StatusReserved,
+			walletStatus:     domain.WalletOperationStatusNotFound,
+			expectedDecision: Decision{Outcome: OutcomeDiscrepancy},
+		},
+		{
+			name:             "when processing payment has reserved funds it should return consistent",
+			paymentStatus:    domain.StatusProcessing,
+			walletStatus:     domain.WalletOperationStatusReserved,
+			expectedDecision: Decision{Outcome: OutcomeConsistent},
+		},
+		{
+			name:             "when stale processing payment has reserved funds it should return discrepancy since the gateway callback never arrived",
+			paymentStatus:    domain.StatusProcessing,
+			walletStatus:     domain.WalletOperationStatusReserved,
+			stale:            true,
+			expectedDecision: Decision{Outcome: OutcomeDiscrepancy},
+		},
+		{
+			name:             "when stale processing payment has an unknown wallet operation it should return unverified",
+			paymentStatus:    domain.StatusProcessing,
+			walletStatus:     domain.WalletOperationStatusUnknown,
+			stale:            true,
+			expectedDecision: Decision{Outcome: OutcomeUnverified},
+		},
+		{
+			name:             "when processing payment has confirmed funds it should return discrepancy",
+			paymentStatus:    domain.StatusProcessing,
+			walletStatus:     domain.WalletOperationStatusConfirmed,
+			expectedDecision: Decision{Outcome: OutcomeDiscrepancy},
+		},
+		{
+			name:             "when pending confirm payment has reserved funds it should return consistent",
+			paymentStatus:    domain.StatusPendingConfirm,
+			walletStatus:     domain.WalletOperationStatusReserved,
+			expectedDecision: Decision{Outcome: OutcomeConsistent},
+		},
+		{
+			name:             "when pending confirm payment has confirmed funds it should repair to completed",
+			paymentStatus:    domain.StatusPendingConfirm,
+			walletStatus:     domain.WalletOperationStatusConfirmed,
+			expectedDecision: Decision{Outcome: OutcomeRepaired, Repair: RepairMarkCompleted},
+		},
+		{
+			name:             "when pending confirm payment has released funds it should return discrepancy",
+			paymentStatus:    domain.StatusPendingConfirm,
+			walletStatus:     domain.WalletOperationStatusReleased,
+			expectedDecision: Decision{Outcome: OutcomeDiscrepancy},
+		},
+		{
+			name:             "when completed payment has confirmed funds it should return consistent",
+			paymentStatus:    domain.StatusCompleted,
+			walletStatus:     domain.WalletOperationStatusConfirmed,
+			expectedDecision: Decision{Outcome: OutcomeConsistent},
+		},
+		{
+			name:             "when completed payment has reserved funds it should repair by confirming funds",
+			paymentStatus:    domain.StatusCompleted,
+			walletStatus:     domain.WalletOperationStatusReserved,
+			expectedDecision: Decision{Outcome: OutcomeRepaired, Repair: RepairConfirmFunds},
+		},
+		{
+			name:             "when completed payment has released funds it should return discrepancy",
+			paymentStatus:    domain.StatusCompleted,
+			walletStatus:     domain.WalletOperationStatusReleased,
+			expectedDecision: Decision{Outcome: OutcomeDiscrepancy},
+		},
+		{
+			name:             "when failed payment has released funds it should return consistent",
+			paymentStatus:    domain.StatusFailed,
+			walletStatus:     domain.WalletOperationStatusReleased,
+			expectedDecision: Decision{Outcome: OutcomeConsistent},
+		},
+		{
+			name:             "when failed payment has no wallet operation it should return consistent",
+			paymentStatus:    domain.StatusFailed,
+			walletStatus:     domain.WalletOperationStatusNotFound,
+			expectedDecision: Decision{Outcome: OutcomeConsistent},
+		},
+		{
+			name:             "when failed payment has reserved funds it should repair by releasing funds",
+			paymentStatus:    domain.StatusFailed,
+			walletStatus:     domain.WalletOperationStatusReserved,
+			expectedDecision: Decision{Outcome: OutcomeRepaired, Repair: RepairReleaseFunds},
+		},
+		{
+			name:             "when failed payment has confirmed funds it should return discrepancy",
+			paymentStatus:    domain.StatusFailed,
+			walletStatus:     domain.WalletOperationStatusConfirmed,
+			expectedDecision: Decision{Outcome: OutcomeDiscrepancy},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Statuses already prepared in test struct)
+
+			// Act
+			result := Decide(tt.paymentStatus, tt.walletStatus, tt.stale)
+
+			// Assert
+			assert.Equal(t, tt.expectedDecision, result)
+		})
+	}
+}
+
+func TestReconciliationPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        ReconciliationPolicy
+		expectedError string
+	}{
+		{
+			name:          "when policy is valid it should return no error",
+			policy:        ReconciliationPolicy{MinAge: 5 * time.Minute, Lookback: 24 * time.Hour, ProcessingTimeout: 30 * time.Minute, BatchSize: 200},
+			expectedError: "",
+		},
+		{
+			name:          "when min age is zero it should return error",
+			policy:        ReconciliationPolicy{MinAge: 0, Lookback: 24 * time.Hour, ProcessingTimeout: 30 * time.Minute, BatchSize: 200},
+			expectedError: "min age must be greater than 0",
+		},
+		{
+			name:          "when lookback is not greater than min age it should return error",
+			policy:        ReconciliationPolicy{MinAge: 5 * time.Minute, Lookback: 5 * time.Minute, BatchSize: 200},
+			expectedError: "lookback must be greater than min age",
+		},
+		{
+			name:          "when processing timeout is zero it should return error",
+			policy:        ReconciliationPolicy{MinAge: 5 * time.Minute, Lookback: 24 * time.Hour, BatchSize: 200},
+			expectedError: "processing timeout must be greater than 0 and less than lookback",
+		},
+		{
+			name:          "when processing timeout is not less than lookback it should return error",
+			policy:        ReconciliationPolicy{MinAge: 5 * time.Minute, Lookback: 24 * time.Hour, ProcessingTimeout: 24 * time.Hour, BatchSize: 200},
+			expectedError: "processing timeout must be greater than 0 and less than lookback",
+		},
+		{
+			name:          "when batch size is zero it should return error",
+			policy:        ReconciliationPolicy{MinAge: 5 * time.Minute, Lookback: 24 * time.Hour, ProcessingTimeout: 30 * time.Minute, BatchSize: 0},
+			expectedError: "batch size must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Policy already prepared in test struct)
+
+			// Act
+			err := tt.policy.Validate()
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package walletreconciler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultDiscrepancyLimit = 100 // Discrepancies returned by a list request without a limit
+)
+
+// WalletReconciler defines the interface for wallet reconciliation business logic
+type WalletReconciler interface {
+	Reconcile(ctx context.Context) (*ReconciliationResult, error)
+}
+
+// DiscrepancyLister defines the interface for reading the discrepancy report
+type DiscrepancyLister interface {
+	List(ctx context.Context, limit int) ([]*Discrepancy, error)
+}
+
+// Handler handles scheduled wallet reconciliation runs and discrepancy report requests
+type Handler struct {
+	walletReconciler  WalletReconciler
+	discrepancyLister DiscrepancyLister
+}
+
+// NewHandler creates a new wallet reconciliation handler
+// It returns a new wallet reconciliation handler and an error if the wallet reconciler or discrepancy lister is nil
+func NewHandler(wr WalletReconciler, dl DiscrepancyLister) (*Handler, error) {
+	if wr == nil {
+		return nil, errors.New("walletreconciler handler: wallet reconciler cannot be nil")
+	}
+	if dl == nil {
+		return nil, errors.New("walletreconciler handler: discrepancy lister cannot be nil")
+	}
+
+	return &Handler{
+		walletReconciler:  wr,
+		discrepancyLister: dl,
+	}, nil
+}
+
+// Run runs a reconciliation pass over recently updated payments
+// It returns an error if the payments cannot be looked up
+func (h *Handler) Run(ctx context.Context) error {
+	result, err := h.walletReconciler.Reconcile(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to reconcile payments with wallet", "error", err)
+		return err
+	}
+
+	slog.InfoContext(ctx, "Wallet reconciliation finished",
+		"checked", result.Checked,
+		"consistent", result.Consistent,
+		"repaired", result.Repaired,
+		"discrepancies", result.Discrepancies,
+		"unverified", result.Unverified,
+		"errors", result.Errors,
+	)
+	return nil
+}
+
+// ListDiscrepancies handles GET /admin/reconciliation/wallet/discrepancies requests
+func (h *Handler) ListDiscrepancies(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	limit := defaultDiscrepancyLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			problem.Respond(c, problem.New(problem.CodeBadRequest, "limit must be a positive number"))
+			return
+		}
+		limit = parsed
+	}
+
+	discrepancies, err := h.discrepancyLister.List(ctx, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list wallet discrepancies", "error", err)
+		problem.Respond(c, problem.New(problem.CodeInternal, "failed to list wallet discrepancies"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "wallet discrepancies found successfully",
+		"data":    discrepancies,
+	})
+}
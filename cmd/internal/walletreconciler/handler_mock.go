@@ -0,0 +1,24 @@
+package walletreconciler
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHandler is a mock implementation of Handler for testing
+type MockHandler struct {
+	mock.Mock
+}
+
+// Run mocks the Run method
+func (m *MockHandler) Run(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+// ListDiscrepancies mocks the ListDiscrepancies method
+func (m *MockHandler) ListDiscrepancies(c *gin.Context) {
+	m.Called(c)
+}
@@ -0,0 +1,203 @@
+package walletreconciler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		name              string
+		walletReconciler  WalletReconciler
+		discrepancyLister DiscrepancyLister
+		expectedError     string
+	}{
+		{
+			name:              "when all dependencies are provided it should create handler successfully and no error",
+			walletReconciler:  new(MockWalletReconcilerService),
+			discrepancyLister: new(MockDiscrepancyRepository),
+			expectedError:     "",
+		},
+		{
+			name:              "when wallet reconciler is nil it should return error",
+			walletReconciler:  nil,
+			discrepancyLister: new(MockDiscrepancyRepository),
+			expectedError:     "walletreconciler handler: wallet reconciler cannot be nil",
+		},
+		{
+			name:              "when discrepancy lister is nil it should return error",
+			walletReconciler:  new(MockWalletReconcilerService),
+			discrepancyLister: nil,
+			expectedError:     "walletreconciler handler: discrepancy lister cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewHandler(tt.walletReconciler, tt.discrepancyLister)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestHandler_Run(t *testing.T) {
+	tests := []struct {
+		name               string
+		mockResult         *ReconciliationResult
+		mockReconcileError error
+		expectedError      error
+	}{
+		{
+			name:               "when payments are reconciled it should return no error",
+			mockResult:         &ReconciliationResult{Checked: 3, Consistent: 1, Repaired: 1, Discrepancies: 1},
+			mockReconcileError: nil,
+			expectedError:      nil,
+		},
+		{
+			name:               "when reconciliation fails it should return reconciliation error",
+			mockResult:         nil,
+			mockReconcileError: errors.New("wallet reconciler: find candidates: database error"),
+			expectedError:      errors.New("wallet reconciler: find candidates: database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockReconciler := new(MockWalletReconcilerService)
+			mockReconciler.On("Reconcile", mock.Anything).Return(tt.mockResult, tt.mockReconcileError)
+
+			handler := &Handler{walletReconciler: mockReconciler}
+
+			// Act
+			err := handler.Run(context.Background())
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockReconciler.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ListDiscrepancies(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	discrepancy := &Discrepancy{
+		PaymentID:     "pay_123",
+		UserID:        "user_123",
+		Amount:        100.50,
+		PaymentStatus: domain.StatusCompleted,
+		WalletStatus:  domain.WalletOperationStatusReleased,
+		DetectedAt:    fixedTime,
+		LastSeenAt:    fixedTime,
+	}
+
+	tests := []struct {
+		name               string
+		query              string
+		mockDiscrepancies  []*Discrepancy
+		mockListError      error
+		shouldCallList     bool
+		expectedLimit      int
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{
+			name:               "when no limit is provided it should list with default limit and return 200",
+			query:              "",
+			mockDiscrepancies:  []*Discrepancy{discrepancy},
+			shouldCallList:     true,
+			expectedLimit:      defaultDiscrepancyLimit,
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "wallet discrepancies found successfully",
+		},
+		{
+			name:               "when limit is provided it should list with limit and return 200",
+			query:              "?limit=10",
+			mockDiscrepancies:  []*Discrepancy{},
+			shouldCallList:     true,
+			expectedLimit:      10,
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "wallet discrepancies found successfully",
+		},
+		{
+			name:               "when limit is invalid it should return 400",
+			query:              "?limit=abc",
+			shouldCallList:     false,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedMessage:    "limit must be a positive number",
+		},
+		{
+			name:               "when list fails it should return 500",
+			query:              "",
+			mockListError:      errors.New("database error"),
+			shouldCallList:     true,
+			expectedLimit:      defaultDiscrepancyLimit,
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "failed to list wallet discrepancies",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockLister := new(MockDiscrepancyRepository)
+			if tt.shouldCallList {
+				mockLister.On("List", mock.Anything, tt.expectedLimit).Return(tt.mockDiscrepancies, tt.mockListError)
+			}
+
+			handler := &Handler{discrepancyLister: mockLister}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/reconciliation/wallet/discrepancies"+tt.query, nil)
+
+			// Act
+			handler.ListDiscrepancies(c)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if w.Code >= http.StatusBadRequest {
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedMessage, response["detail"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, response["message"])
+			}
+
+			mockLister.AssertExpectations(t)
+		})
+	}
+}
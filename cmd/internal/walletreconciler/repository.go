@@ -0,0 +1,167 @@
+package walletreconciler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+)
+
+// CandidateDB defines the database operations required by CandidateFinderRepository
+type CandidateDB interface {
+	QueryContext(ctx context.Context, query string, args ...any) (database.Rows, error)
+}
+
+// CandidateFinderRepository finds payments to compare with the wallet
+type CandidateFinderRepository struct {
+	db CandidateDB
+}
+
+// NewCandidateFinderRepository creates a new CandidateFinderRepository
+// It returns a new CandidateFinderRepository and an error if the database is nil
+func NewCandidateFinderRepository(db CandidateDB) (*CandidateFinderRepository, error) {
+	if db == nil {
+		return nil, errors.New("candidate finder: database cannot be nil")
+	}
+
+	return &CandidateFinderRepository{db: db}, nil
+}
+
+// FindCandidates finds payments last updated between the given times, after the cursor
+// It returns the payments ordered by their last update and ID, so the last one is the next cursor
+func (r *CandidateFinderRepository) FindCandidates(ctx context.Context, updatedAfter, updatedBefore time.Time, after Cursor, limit int) ([]*domain.Payment, error) {
+	query := `
+		SELECT id, COALESCE(idempotency_key, ''), user_id, amount, currency, status, created_at, updated_at, COALESCE(gateway_ref, '')
+		FROM payments
+		WHERE updated_at >= $1
+			AND updated_at < $2
+			AND (updated_at, id) > ($3, $4)
+		ORDER BY updated_at ASC, id ASC
+		LIMIT $5
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, updatedAfter, updatedBefore, after.UpdatedAt, after.PaymentID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("candidate finder: find candidates: %w", err)
+	}
+	defer rows.Close()
+
+	payments := []*domain.Payment{}
+	for rows.Next() {
+		var payment domain.Payment
+		err := rows.Scan(
+			&payment.ID,
+			&payment.IdempotencyKey,
+			&payment.UserID,
+			&payment.Amount,
+			&payment.Currency,
+			&payment.Status,
+			&payment.CreatedAt,
+			&payment.UpdatedAt,
+			&payment.GatewayRef,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("candidate finder: scan candidate: %w", err)
+		}
+		payments = append(payments, &payment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("candidate finder: iterate candidates: %w", err)
+	}
+
+	return payments, nil
+}
+
+// DiscrepancyDB defines the database operations required by DiscrepancyRepository
+type DiscrepancyDB interface {
+	QueryContext(ctx context.Context, query string, args ...any) (database.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// DiscrepancyRepository stores the discrepancy report
+type DiscrepancyRepository struct {
+	db DiscrepancyDB
+}
+
+// NewDiscrepancyRepository creates a new DiscrepancyRepository
+// It returns a new DiscrepancyRepository and an error if the database is nil
+func NewDiscrepancyRepository(db DiscrepancyDB) (*DiscrepancyRepository, error) {
+	if db == nil {
+		return nil, errors.New("discrepancy repository: database cannot be nil")
+	}
+
+	return &DiscrepancyRepository{db: db}, nil
+}
+
+// Record records a discrepancy, refreshing it if the payment was already reported
+// It keeps the first detection time and updates the statuses and the last detection time
+func (r *DiscrepancyRepository) Record(ctx context.Context, discrepancy *Discrepancy) error {
+	query := `
+		INSERT INTO wallet_discrepancies (payment_id, user_id, amount, payment_status, wallet_status, detected_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (payment_id) DO UPDATE
+		SET payment_status = EXCLUDED.payment_status,
+			wallet_status = EXCLUDED.wallet_status,
+			last_seen_at = EXCLUDED.last_seen_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		discrepancy.PaymentID,
+		discrepancy.UserID,
+		discrepancy.Amount,
+		discrepancy.PaymentStatus,
+		discrepancy.WalletStatus,
+		discrepancy.DetectedAt,
+		discrepancy.LastSeenAt,
+	)
+	if err != nil {
+		return fmt.Errorf("discrepancy repository: record: %w", err)
+	}
+
+	return nil
+}
+
+// List lists the discrepancies, most recently seen first
+func (r *DiscrepancyRepository) List(ctx context.Context, limit int) ([]*Discrepancy, error) {
+	query := `
+		SELECT payment_id, user_id, amount, payment_status, wallet_status, detected_at, last_seen_at
+		FROM wallet_discrepancies
+		ORDER BY last_seen_at DESC, payment_id ASC
+		LIMIT $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("discrepancy repository: list: %w", err)
+	}
+	defer rows.Close()
+
+	discrepancies := []*Discrepancy{}
+	for rows.Next() {
+		var discrepancy Discrepancy
+		err := rows.Scan(
+			&discrepancy.PaymentID,
+			&discrepancy.UserID,
+			&discrepancy.Amount,
+			&discrepancy.PaymentStatus,
+			&discrepancy.WalletStatus,
+			&discrepancy.DetectedAt,
+			&discrepancy.LastSeenAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("discrepancy repository: scan discrepancy: %w", err)
+		}
+		discrepancies = append(discrepancies, &discrepancy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("discrepancy repository: iterate discrepancies: %w", err)
+	}
+
+	return discrepancies, nil
+}
@@ -0,0 +1,43 @@
+package walletreconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockCandidateFinder is a mock implementation of CandidateFinder for testing
+type MockCandidateFinder struct {
+	mock.Mock
+}
+
+// FindCandidates mocks the FindCandidates method
+func (m *MockCandidateFinder) FindCandidates(ctx context.Context, updatedAfter, updatedBefore time.Time, after Cursor, limit int) ([]*domain.Payment, error) {
+	args := m.Called(ctx, updatedAfter, updatedBefore, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Payment), args.Error(1)
+}
+
+// MockDiscrepancyRepository is a mock implementation of DiscrepancyRecorder and DiscrepancyLister for testing
+type MockDiscrepancyRepository struct {
+	mock.Mock
+}
+
+// Record mocks the Record method
+func (m *MockDiscrepancyRepository) Record(ctx context.Context, discrepancy *Discrepancy) error {
+	args := m.Called(ctx, discrepancy)
+	return args.Error(0)
+}
+
+// List mocks the List method
+func (m *MockDiscrepancyRepository) List(ctx context.Context, limit int) ([]*Discrepancy, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*Discrepancy), args.Error(1)
+}
@@ -0,0 +1,389 @@
+package walletreconciler
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewCandidateFinderRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            CandidateDB
+		expectedError string
+	}{
+		{
+			name:          "when database is provided it should create repository successfully and no error",
+			db:            new(database.MockDB),
+			expectedError: "",
+		},
+		{
+			name:          "when database is nil it should return error with message 'candidate finder: database cannot be nil'",
+			db:            nil,
+			expectedError: "candidate finder: database cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Database already prepared in test struct)
+
+			// Act
+			result, err := NewCandidateFinderRepository(tt.db)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+				assert.NotNil(t, result.db)
+			}
+		})
+	}
+}
+
+func TestCandidateFinderRepository_FindCandidates(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		mockPayments     []*domain.Payment
+		mockQueryError   error
+		mockScanError    error
+		mockRowsError    error
+		expectedPayments []*domain.Payment
+		expectedError    error
+	}{
+		{
+			name: "when candidates exist it should return payments and no error",
+			mockPayments: []*domain.Payment{
+				{
+					ID:             "pay_123",
+					IdempotencyKey: "key_123",
+					UserID:         "user_123",
+					Amount:         100.50,
+					Currency:       domain.CurrencyUSD,
+					Status:         domain.StatusReserved,
+					CreatedAt:      fixedTime,
+					UpdatedAt:      fixedTime,
+				},
+				{
+					ID:             "pay_456",
+					IdempotencyKey: "key_456",
+					UserID:         "user_123",
+					Amount:         50.00,
+					Currency:       domain.CurrencyUSD,
+					Status:         domain.StatusPendingConfirm,
+					CreatedAt:      fixedTime,
+					UpdatedAt:      fixedTime,
+					GatewayRef:     "gw_456",
+				},
+			},
+			expectedPayments: []*domain.Payment{
+				{
+					ID:             "pay_123",
+					IdempotencyKey: "key_123",
+					UserID:         "user_123",
+					Amount:         100.50,
+					Currency:       domain.CurrencyUSD,
+					Status:         domain.StatusReserved,
+					CreatedAt:      fixedTime,
+					UpdatedAt:      fixedTime,
+				},
+				{
+					ID:             "pay_456",
+					IdempotencyKey: "key_456",
+					UserID:         "user_123",
+					Amount:         50.00,
+					Currency:       domain.CurrencyUSD,
+					Status:         domain.StatusPendingConfirm,
+					CreatedAt:      fixedTime,
+					UpdatedAt:      fixedTime,
+					GatewayRef:     "gw_456",
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name:             "when no candidates exist it should return empty slice and no error",
+			mockPayments:     []*domain.Payment{},
+			expectedPayments: []*domain.Payment{},
+			expectedError:    nil,
+		},
+		{
+			name:           "when query fails it should return wrapped error",
+			mockQueryError: errors.New("connection refused"),
+			expectedError:  errors.New("candidate finder: find candidates: connection refused"),
+		},
+		{
+			name:          "when scan fails it should return wrapped error",
+			mockScanError: errors.New("scan error"),
+			expectedError: errors.New("candidate finder: scan candidate: scan error"),
+		},
+		{
+			name:          "when rows iteration fails it should return wrapped error",
+			mockPayments:  []*domain.Payment{},
+			mockRowsError: errors.New("iteration error"),
+			expectedError: errors.New("candidate finder: iterate candidates: iteration error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockRows := new(database.MockRows)
+
+			if tt.mockQueryError != nil {
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, tt.mockQueryError)
+			} else {
+				if tt.mockScanError != nil {
+					mockRows.On("Next").Return(true).Once()
+					mockRows.On("Scan", mock.Anything).Return(tt.mockScanError)
+				} else {
+					paymentCount := len(tt.mockPayments)
+					if paymentCount > 0 {
+						mockRows.On("Next").Return(true).Times(paymentCount)
+						scanCallCount := 0
+						mockRows.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+							dest := args.Get(0).([]any)
+							payment := tt.mockPayments[scanCallCount]
+							*dest[0].(*string) = payment.ID
+							*dest[1].(*string) = payment.IdempotencyKey
+							*dest[2].(*string) = payment.UserID
+							*dest[3].(*float64) = payment.Amount
+							*dest[4].(*domain.Currency) = payment.Currency
+							*dest[5].(*domain.Status) = payment.Status
+							*dest[6].(*time.Time) = payment.CreatedAt
+							*dest[7].(*time.Time) = payment.UpdatedAt
+							*dest[8].(*string) = payment.GatewayRef
+							scanCallCount++
+						}).Return(nil).Times(paymentCount)
+					}
+					mockRows.On("Next").Return(false).Once()
+					mockRows.On("Err").Return(tt.mockRowsError)
+				}
+				mockRows.On("Close").Return(nil)
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(mockRows, nil)
+			}
+
+			repo := &CandidateFinderRepository{db: mockDB}
+
+			// Act
+			result, err := repo.FindCandidates(context.Background(), fixedTime.Add(-24*time.Hour), fixedTime, Cursor{}, 100)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedPayments, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewDiscrepancyRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		db            DiscrepancyDB
+		expectedError string
+	}{
+		{
+			name:          "when database is provided it should create repository successfully and no error",
+			db:            new(database.MockDB),
+			expectedError: "",
+		},
+		{
+			name:          "when database is nil it should return error with message 'discrepancy repository: database cannot be nil'",
+			db:            nil,
+			expectedError: "discrepancy repository: database cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Database already prepared in test struct)
+
+			// Act
+			result, err := NewDiscrepancyRepository(tt.db)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+				assert.NotNil(t, result.db)
+			}
+		})
+	}
+}
+
+func TestDiscrepancyRepository_Record(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	discrepancy := &Discrepancy{
+		PaymentID:     "pay_123",
+		UserID:        "user_123",
+		Amount:        100.50,
+		PaymentStatus: domain.StatusCompleted,
+		WalletStatus:  domain.WalletOperationStatusReleased,
+		DetectedAt:    fixedTime,
+		LastSeenAt:    fixedTime,
+	}
+
+	tests := []struct {
+		name          string
+		mockExecError error
+		expectedError error
+	}{
+		{
+			name:          "when discrepancy is recorded it should return no error",
+			mockExecError: nil,
+			expectedError: nil,
+		},
+		{
+			name:          "when exec fails it should return wrapped error",
+			mockExecError: errors.New("connection refused"),
+			expectedError: errors.New("discrepancy repository: record: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			if tt.mockExecError != nil {
+				mockDB.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, tt.mockExecError)
+			} else {
+				mockDB.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(driver.RowsAffected(1), nil)
+			}
+
+			repo := &DiscrepancyRepository{db: mockDB}
+
+			// Act
+			err := repo.Record(context.Background(), discrepancy)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDiscrepancyRepository_List(t *testing.T) {
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	discrepancy := &Discrepancy{
+		PaymentID:     "pay_123",
+		UserID:        "user_123",
+		Amount:        100.50,
+		PaymentStatus: domain.StatusCompleted,
+		WalletStatus:  domain.WalletOperationStatusReleased,
+		DetectedAt:    fixedTime,
+		LastSeenAt:    fixedTime,
+	}
+
+	tests := []struct {
+		name                  string
+		mockDiscrepancies     []*Discrepancy
+		mockQueryError        error
+		mockScanError         error
+		expectedDiscrepancies []*Discrepancy
+		expectedError         error
+	}{
+		{
+			name:                  "when discrepancies exist it should return discrepancies and no error",
+			mockDiscrepancies:     []*Discrepancy{discrepancy},
+			expectedDiscrepancies: []*Discrepancy{discrepancy},
+			expectedError:         nil,
+		},
+		{
+			name:           "when query fails it should return wrapped error",
+			mockQueryError: errors.New("connection refused"),
+			expectedError:  errors.New("discrepancy repository: list: connection refused"),
+		},
+		{
+			name:          "when scan fails it should return wrapped error",
+			mockScanError: errors.New("scan error"),
+			expectedError: errors.New("discrepancy repository: scan discrepancy: scan error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockRows := new(database.MockRows)
+
+			if tt.mockQueryError != nil {
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, tt.mockQueryError)
+			} else {
+				if tt.mockScanError != nil {
+					mockRows.On("Next").Return(true).Once()
+					mockRows.On("Scan", mock.Anything).Return(tt.mockScanError)
+				} else {
+					count := len(tt.mockDiscrepancies)
+					mockRows.On("Next").Return(true).Times(count)
+					scanCallCount := 0
+					mockRows.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+						dest := args.Get(0).([]any)
+						d := tt.mockDiscrepancies[scanCallCount]
+						*dest[0].(*string) = d.PaymentID
+						*dest[1].(*string) = d.UserID
+						*dest[2].(*float64) = d.Amount
+						*dest[3].(*domain.Status) = d.PaymentStatus
+						*dest[4].(*domain.WalletOperationStatus) = d.WalletStatus
+						*dest[5].(*time.Time) = d.DetectedAt
+						*dest[6].(*time.Time) = d.LastSeenAt
+						scanCallCount++
+					}).Return(nil).Times(count)
+					mockRows.On("Next").Return(false).Once()
+					mockRows.On("Err").Return(nil)
+				}
+				mockRows.On("Close").Return(nil)
+				mockDB.On("QueryContext", mock.Anything, mock.Anything, mock.Anything).Return(mockRows, nil)
+			}
+
+			repo := &DiscrepancyRepository{db: mockDB}
+
+			// Act
+			result, err := repo.List(context.Background(), 100)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedDiscrepancies, result)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
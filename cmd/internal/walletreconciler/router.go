@@ -0,0 +1,21 @@
+package walletreconciler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/walletclient"
+)
+
+// Start starts the wallet reconciler router
+// It starts the wallet reconciler router and returns an error if the builder fails
+func Start(rg *gin.RouterGroup, db ReconcilerDB, rc *http.Client, wb walletclient.CircuitBreaker, locker paymentlocker.AdvisoryLocker, policy ReconciliationPolicy) error {
+	h, err := Build(db, rc, wb, locker, policy)
+	if err != nil {
+		return err
+	}
+
+	rg.GET("/admin/reconciliation/wallet/discrepancies", h.ListDiscrepancies)
+	return nil
+}
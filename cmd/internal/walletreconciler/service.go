@@ -0,0 +1,233 @@
+package walletreconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
+)
+
+// CandidateFinder interface for finding payments to compare with the wallet
+type CandidateFinder interface {
+	FindCandidates(ctx context.Context, updatedAfter, updatedBefore time.Time, after Cursor, limit int) ([]*domain.Payment, error)
+}
+
+// WalletInspector interface for reading wallet operations and applying corrective wallet actions
+type WalletInspector interface {
+	GetOperation(ctx context.Context, paymentID string) (*domain.WalletOperation, error)
+	Confirm(ctx context.Context, userID string, amount float64, paymentID string) error
+	Release(ctx context.Context, userID string, amount float64, paymentID string) error
+}
+
+// PaymentRecorder interface for reading payments and recording their events and status changes
+type PaymentRecorder interface {
+	GetByID(ctx context.Context, paymentID string) (*domain.Payment, error)
+	AppendEvent(ctx context.Context, paymentID string, eventType string, data map[string]interface{}) error
+	UpdateStatus(ctx context.Context, paymentID string, status domain.Status, gatewayRef string) error
+}
+
+// PaymentLocker interface for serializing the status changes of each payment
+type PaymentLocker interface {
+	Lock(ctx context.Context, paymentID string) (paymentlocker.Unlocker, error)
+}
+
+// DiscrepancyRecorder interface for recording the discrepancy report
+type DiscrepancyRecorder interface {
+	Record(ctx context.Context, discrepancy *Discrepancy) error
+}
+
+// WalletReconcilerService is a service for reconciling payments with their wallet operations
+type WalletReconcilerService struct {
+	candidateFinder     CandidateFinder
+	walletInspector     WalletInspector
+	paymentRecorder     PaymentRecorder
+	discrepancyRecorder DiscrepancyRecorder
+	paymentLocker       PaymentLocker
+	policy              ReconciliationPolicy
+}
+
+// NewWalletReconcilerService creates a new WalletReconcilerService
+// It returns a new WalletReconcilerService and an error if any dependency is nil or the policy is invalid
+func NewWalletReconcilerService(
+	cf CandidateFinder,
+	wi WalletInspector,
+	pr PaymentRecorder,
+	dr DiscrepancyRecorder,
+	pl PaymentLocker,
+	policy ReconciliationPolicy,
+) (*WalletReconcilerService, error) {
+	if cf == nil {
+		return nil, errors.New("wallet reconciler: candidate finder cannot be nil")
+	}
+	if wi == nil {
+		return nil, errors.New("wallet reconciler: wallet inspector cannot be nil")
+	}
+	if pr == nil {
+		return nil, errors.New("wallet reconciler: payment recorder cannot be nil")
+	}
+	if dr == nil {
+		return nil, errors.New("wallet reconciler: discrepancy recorder cannot be nil")
+	}
+	if pl == nil {
+		return nil, errors.New("wallet reconciler: payment locker cannot be nil")
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("wallet reconciler: invalid policy: %w", err)
+	}
+
+	return &WalletReconcilerService{
+		candidateFinder:     cf,
+		walletInspector:     wi,
+		paymentRecorder:     pr,
+		discrepancyRecorder: dr,
+		paymentLocker:       pl,
+		policy:              policy,
+	}, nil
+}
+
+// Reconcile compares every payment updated within the policy window with its wallet operation
+// It repairs the safe mismatches with corrective events and reports the rest as discrepancies
+// It returns the run summary and an error only if the candidates cannot be found
+func (s *WalletReconcilerService) Reconcile(ctx context.Context) (*ReconciliationResult, error) {
+	now := time.Now()
+	updatedAfter := now.Add(-s.policy.Lookback)
+	updatedBefore := now.Add(-s.policy.MinAge)
+
+	result := &ReconciliationResult{}
+	cursor := Cursor{}
+
+	for {
+		payments, err := s.candidateFinder.FindCandidates(ctx, updatedAfter, updatedBefore, cursor, s.policy.BatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("wallet reconciler: find candidates: %w", err)
+		}
+
+		for _, payment := range payments {
+			result.Checked++
+
+			outcome, err := s.reconcile(ctx, payment, now)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to reconcile payment", "error", err, "payment_id", payment.ID)
+				result.Errors++
+				continue
+			}
+
+			switch outcome {
+			case OutcomeConsistent:
+				result.Consistent++
+			case OutcomeRepaired:
+				result.Repaired++
+			case OutcomeDiscrepancy:
+				result.Discrepancies++
+			case OutcomeUnverified:
+				result.Unverified++
+			}
+		}
+
+		if len(payments) < s.policy.BatchSize {
+			return result, nil
+		}
+
+		last := payments[len(payments)-1]
+		cursor = Cursor{UpdatedAt: last.UpdatedAt, PaymentID: last.ID}
+	}
+}
+
+// reconcile compares a single payment with its wallet operation and acts on the decision
+// The payment is locked and read again first, so a status change by the processor, a job or a gateway callback
+// is not raced by a repair decided on the status the candidate had when it was found
+func (s *WalletReconcilerService) reconcile(ctx context.Context, candidate *domain.Payment, now time.Time) (Outcome, error) {
+	lock, err := s.paymentLocker.Lock(ctx, candidate.ID)
+	if err != nil {
+		return "", fmt.Errorf("wallet reconciler: lock payment: %w", err)
+	}
+	defer func() {
+		if err := lock.Unlock(ctx); err != nil {
+			slog.WarnContext(ctx, "Failed to unlock payment", "error", err, "payment_id", candidate.ID)
+		}
+	}()
+
+	payment, err := s.paymentRecorder.GetByID(ctx, candidate.ID)
+	if err != nil {
+		return "", fmt.Errorf("wallet reconciler: get payment: %w", err)
+	}
+
+	operation, err := s.walletInspector.GetOperation(ctx, payment.ID)
+	if err != nil {
+		return "", fmt.Errorf("wallet reconciler: get wallet operation: %w", err)
+	}
+
+	stale := payment.Status == domain.StatusProcessing && now.Sub(payment.UpdatedAt) >= s.policy.ProcessingTimeout
+	decision := Decide(payment.Status, operation.Status, stale)
+
+	switch decision.Outcome {
+	case OutcomeRepaired:
+		if err := s.repair(ctx, payment, operation, decision.Repair); err != nil {
+			return "", err
+		}
+		slog.WarnContext(ctx, "Payment repaired by wallet reconciliation",
+			"payment_id", payment.ID,
+			"payment_status", payment.Status,
+			"wallet_status", operation.Status,
+			"repair", decision.Repair,
+		)
+	case OutcomeDiscrepancy:
+		discrepancy := &Discrepancy{
+			PaymentID:     payment.ID,
+			UserID:        payment.UserID,
+			Amount:        payment.Amount,
+			PaymentStatus: payment.Status,
+			WalletStatus:  operation.Status,
+			DetectedAt:    now,
+			LastSeenAt:    now,
+		}
+		if err := s.discrepancyRecorder.Record(ctx, discrepancy); err != nil {
+			return "", fmt.Errorf("wallet reconciler: record discrepancy: %w", err)
+		}
+		slog.WarnContext(ctx, "Wallet discrepancy detected",
+			"payment_id", payment.ID,
+			"payment_status", payment.Status,
+			"wallet_status", operation.Status,
+		)
+	}
+
+	return decision.Outcome, nil
+}
+
+// repair applies the corrective action and records it in the payment event store
+// Status repairs append the new status event, wallet repairs leave the payment status untouched
+func (s *WalletReconcilerService) repair(ctx context.Context, payment *domain.Payment, operation *domain.WalletOperation, repair Repair) error {
+	var err error
+	switch repair {
+	case RepairMarkReserved:
+		err = s.paymentRecorder.UpdateStatus(ctx, payment.ID, domain.StatusReserved, payment.GatewayRef)
+	case RepairMarkCompleted:
+		err = s.paymentRecorder.UpdateStatus(ctx, payment.ID, domain.StatusCompleted, payment.GatewayRef)
+	case RepairMarkFailed:
+		err = s.paymentRecorder.UpdateStatus(ctx, payment.ID, domain.StatusFailed, payment.GatewayRef)
+	case RepairConfirmFunds:
+		err = s.walletInspector.Confirm(ctx, payment.UserID, payment.Amount, payment.ID)
+	case RepairReleaseFunds:
+		err = s.walletInspector.Release(ctx, payment.UserID, payment.Amount, payment.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("wallet reconciler: repair %s: %w", repair, err)
+	}
+
+	data := map[string]interface{}{
+		"payment_id":     payment.ID,
+		"source":         "wallet",
+		"repair":         repair,
+		"payment_status": payment.Status,
+		"wallet_status":  operation.Status,
+	}
+	if err := s.paymentRecorder.AppendEvent(ctx, payment.ID, domain.EventTypeReconciled, data); err != nil {
+		return fmt.Errorf("wallet reconciler: append event: %w", err)
+	}
+
+	return nil
+}
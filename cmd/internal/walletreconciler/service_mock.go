@@ -0,0 +1,21 @@
+package walletreconciler
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWalletReconcilerService is a mock implementation of WalletReconciler for testing
+type MockWalletReconcilerService struct {
+	mock.Mock
+}
+
+// Reconcile mocks the Reconcile method
+func (m *MockWalletReconcilerService) Reconcile(ctx context.Context) (*ReconciliationResult, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ReconciliationResult), args.Error(1)
+}
@@ -0,0 +1,466 @@
+package walletreconciler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/domain"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentlocker"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/paymentstorer"
+	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/internal/shared/repository/walletclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewWalletReconcilerService(t *testing.T) {
+	validPolicy := ReconciliationPolicy{MinAge: 5 * time.Minute, Lookback: 24 * time.Hour, ProcessingTimeout: 30 * time.Minute, BatchSize: 200}
+
+	tests := []struct {
+		name                string
+		candidateFinder     CandidateFinder
+		walletInspector     WalletInspector
+		paymentRecorder     PaymentRecorder
+		discrepancyRecorder DiscrepancyRecorder
+		paymentLocker       PaymentLocker
+		policy              ReconciliationPolicy
+		expectedError       string
+	}{
+		{
+			name:                "when all dependencies are provided it should create service successfully and no error",
+			candidateFinder:     new(MockCandidateFinder),
+			walletInspector:     new(walletclient.MockWalletClient),
+			paymentRecorder:     new(paymentstorer.MockPaymentRepository),
+			discrepancyRecorder: new(MockDiscrepancyRepository),
+			paymentLocker:       new(paymentlocker.MockPaymentLockerRepository),
+			policy:              validPolicy,
+			expectedError:       "",
+		},
+		{
+			name:                "when candidate finder is nil it should return error",
+			candidateFinder:     nil,
+			walletInspector:     new(walletclient.MockWalletClient),
+			paymentRecorder:     new(paymentstorer.MockPaymentRepository),
+			discrepancyRecorder: new(MockDiscrepancyRepository),
+			paymentLocker:       new(paymentlocker.MockPaymentLockerRepository),
+			policy:              validPolicy,
+			expectedError:       "wallet reconciler: candidate finder cannot be nil",
+		},
+		{
+			name:                "when wallet inspector is nil it should return error",
+			candidateFinder:     new(MockCandidateFinder),
+			walletInspector:     nil,
+			paymentRecorder:     new(paymentstorer.MockPaymentRepository),
+			discrepancyRecorder: new(MockDiscrepancyRepository),
+			paymentLocker:       new(paymentlocker.MockPaymentLockerRepository),
+			policy:              validPolicy,
+			expectedError:       "wallet reconciler: wallet inspector cannot be nil",
+		},
+		{
+			name:                "when payment recorder is nil it should return error",
+			candidateFinder:     new(MockCandidateFinder),
+			walletInspector:     new(walletclient.MockWalletClient),
+			paymentRecorder:     nil,
+			discrepancyRecorder: new(MockDiscrepancyRepository),
+			paymentLocker:       new(paymentlocker.MockPaymentLockerRepository),
+			policy:              validPolicy,
+			expectedError:       "wallet reconciler: payment recorder cannot be nil",
+		},
+		{
+			name:                "when discrepancy recorder is nil it should return error",
+			candidateFinder:     new(MockCandidateFinder),
+			walletInspector:     new(walletclient.MockWalletClient),
+			paymentRecorder:     new(paymentstorer.MockPaymentRepository),
+			discrepancyRecorder: nil,
+			paymentLocker:       new(paymentlocker.MockPaymentLockerRepository),
+			policy:              validPolicy,
+			expectedError:       "wallet reconciler: discrepancy recorder cannot be nil",
+		},
+		{
+			name:                "when payment locker is nil it should return error",
+			candidateFinder:     new(MockCandidateFinder),
+			walletInspector:     new(walletclient.MockWalletClient),
+			paymentRecorder:     new(paymentstorer.MockPaymentRepository),
+			discrepancyRecorder: new(MockDiscrepancyRepository),
+			paymentLocker:       nil,
+			policy:              validPolicy,
+			expectedError:       "wallet reconciler: payment locker cannot be nil",
+		},
+		{
+			name:                "when policy is invalid it should return error",
+			candidateFinder:     new(MockCandidateFinder),
+			walletInspector:     new(walletclient.MockWalletClient),
+			paymentRecorder:     new(paymentstorer.MockPaymentRepository),
+			discrepancyRecorder: new(MockDiscrepancyRepository),
+			paymentLocker:       new(paymentlocker.MockPaymentLockerRepository),
+			policy:              ReconciliationPolicy{MinAge: 5 * time.Minute, Lookback: 24 * time.Hour, ProcessingTimeout: 30 * time.Minute, BatchSize: 0},
+			expectedError:       "wallet reconciler: invalid policy: batch size must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			// (Dependencies already prepared in test struct)
+
+			// Act
+			result, err := NewWalletReconcilerService(tt.candidateFinder, tt.walletInspector, tt.paymentRecorder, tt.discrepancyRecorder, tt.paymentLocker, tt.policy)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestWalletReconcilerService_Reconcile(t *testing.T) {
+	policy := ReconciliationPolicy{MinAge: 5 * time.Minute, Lookback: 24 * time.Hour, ProcessingTimeout: 30 * time.Minute, BatchSize: 200}
+
+	newPayment := func(status domain.Status) *domain.Payment {
+		return &domain.Payment{
+			ID:        "pay_123",
+			UserID:    "user_123",
+			Amount:    100.50,
+			Currency:  domain.CurrencyUSD,
+			Status:    status,
+			UpdatedAt: time.Now().Add(-10 * time.Minute),
+		}
+	}
+
+	stalePayment := newPayment(domain.StatusProcessing)
+	stalePayment.UpdatedAt = time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name                   string
+		payment                *domain.Payment
+		mockFindError          error
+		mockLockError          error
+		mockCurrentStatus      domain.Status
+		mockGetError           error
+		mockWalletStatus       domain.WalletOperationStatus
+		mockGetOperationError  error
+		mockRepairError        error
+		mockAppendEventError   error
+		mockRecordError        error
+		shouldCallGetOperation bool
+		expectedRepairMethod   string
+		expectedStatus         domain.Status
+		shouldCallAppendEvent  bool
+		shouldCallRecord       bool
+		expectedResult         *ReconciliationResult
+		expectedError          error
+	}{
+		{
+			name:           "when no candidates are found it should return empty result and no error",
+			payment:        nil,
+			expectedResult: &ReconciliationResult{},
+			expectedError:  nil,
+		},
+		{
+			name:          "when candidates cannot be found it should return wrapped error",
+			mockFindError: errors.New("database error"),
+			expectedError: errors.New("wallet reconciler: find candidates: database error"),
+		},
+		{
+			name:           "when the payment lock cannot be acquired it should count the error without reading the wallet and no error",
+			payment:        newPayment(domain.StatusReserved),
+			mockLockError:  context.DeadlineExceeded,
+			expectedResult: &ReconciliationResult{Checked: 1, Errors: 1},
+			expectedError:  nil,
+		},
+		{
+			name:           "when the locked payment cannot be read it should count the error without reading the wallet and no error",
+			payment:        newPayment(domain.StatusReserved),
+			mockGetError:   errors.New("database error"),
+			expectedResult: &ReconciliationResult{Checked: 1, Errors: 1},
+			expectedError:  nil,
+		},
+		{
+			name:                   "when payment changed status since it was found it should decide on the locked status and no error",
+			payment:                newPayment(domain.StatusReserved),
+			mockCurrentStatus:      domain.StatusCompleted,
+			mockWalletStatus:       domain.WalletOperationStatusConfirmed,
+			shouldCallGetOperation: true,
+			expectedResult:         &ReconciliationResult{Checked: 1, Consistent: 1},
+			expectedError:          nil,
+		},
+		{
+			name:                   "when payment agrees with wallet it should count it as consistent and no error",
+			payment:                newPayment(domain.StatusReserved),
+			mockWalletStatus:       domain.WalletOperationStatusReserved,
+			shouldCallGetOperation: true,
+			expectedResult:         &ReconciliationResult{Checked: 1, Consistent: 1},
+			expectedError:          nil,
+		},
+		{
+			name:                   "when payment missed a status it should update status, append event and no error",
+			payment:                newPayment(domain.StatusPendingConfirm),
+			mockWalletStatus:       domain.WalletOperationStatusConfirmed,
+			shouldCallGetOperation: true,
+			expectedRepairMethod:   "UpdateStatus",
+			expectedStatus:         domain.StatusCompleted,
+			shouldCallAppendEvent:  true,
+			expectedResult:         &ReconciliationResult{Checked: 1, Repaired: 1},
+			expectedError:          nil,
+		},
+		{
+			name:                   "when wallet missed a confirmation it should confirm funds, append event and no error",
+			payment:                newPayment(domain.StatusCompleted),
+			mockWalletStatus:       domain.WalletOperationStatusReserved,
+			shouldCallGetOperation: true,
+			expectedRepairMethod:   "Confirm",
+			shouldCallAppendEvent:  true,
+			expectedResult:         &ReconciliationResult{Checked: 1, Repaired: 1},
+			expectedError:          nil,
+		},
+		{
+			name:                   "when wallet missed a release it should release funds, append event and no error",
+			payment:                newPayment(domain.StatusFailed),
+			mockWalletStatus:       domain.WalletOperationStatusReserved,
+			shouldCallGetOperation: true,
+			expectedRepairMethod:   "Release",
+			shouldCallAppendEvent:  true,
+			expectedResult:         &ReconciliationResult{Checked: 1, Repaired: 1},
+			expectedError:          nil,
+		},
+		{
+			name:                   "when reserved payment has confirmed funds it should record discrepancy instead of completing it and no error",
+			payment:                newPayment(domain.StatusReserved),
+			mockWalletStatus:       domain.WalletOperationStatusConfirmed,
+			shouldCallGetOperation: true,
+			shouldCallRecord:       true,
+			expectedResult:         &ReconciliationResult{Checked: 1, Discrepancies: 1},
+			expectedError:          nil,
+		},
+		{
+			name:                   "when processing payment waits for the gateway callback it should count it as consistent and no error",
+			payment:                newPayment(domain.StatusProcessing),
+			mockWalletStatus:       domain.WalletOperationStatusReserved,
+			shouldCallGetOperation: true,
+			expectedResult:         &ReconciliationResult{Checked: 1, Consistent: 1},
+			expectedError:          nil,
+		},
+		{
+			name:                   "when processing payment is older than the processing timeout it should record discrepancy and no error",
+			payment:                stalePayment,
+			mockWalletStatus:       domain.WalletOperationStatusReserved,
+			shouldCallGetOperation: true,
+			shouldCallRecord:       true,
+			expectedResult:         &ReconciliationResult{Checked: 1, Discrepancies: 1},
+			expectedError:          nil,
+		},
+		{
+			name:                   "when mismatch cannot be repaired it should record discrepancy and no error",
+			payment:                newPayment(domain.StatusCompleted),
+			mockWalletStatus:       domain.WalletOperationStatusReleased,
+			shouldCallGetOperation: true,
+			shouldCallRecord:       true,
+			expectedResult:         &ReconciliationResult{Checked: 1, Discrepancies: 1},
+			expectedError:          nil,
+		},
+		{
+			name:                   "when wallet cannot tell the operation status it should count it as unverified and no error",
+			payment:                newPayment(domain.StatusReserved),
+			mockWalletStatus:       domain.WalletOperationStatusUnknown,
+			shouldCallGetOperation: true,
+			expectedResult:         &ReconciliationResult{Checked: 1, Unverified: 1},
+			expectedError:          nil,
+		},
+		{
+			name:                   "when wallet operation cannot be read it should count the error and no error",
+			payment:                newPayment(domain.StatusReserved),
+			mockGetOperationError:  errors.New("wallet unavailable"),
+			shouldCallGetOperation: true,
+			expectedResult:         &ReconciliationResult{Checked: 1, Errors: 1},
+			expectedError:          nil,
+		},
+		{
+			name:                   "when repair fails it should count the error without appending event and no error",
+			payment:                newPayment(domain.StatusFailed),
+			mockWalletStatus:       domain.WalletOperationStatusReserved,
+			mockRepairError:        errors.New("wallet unavailable"),
+			shouldCallGetOperation: true,
+			expectedRepairMethod:   "Release",
+			expectedResult:         &ReconciliationResult{Checked: 1, Errors: 1},
+			expectedError:          nil,
+		},
+		{
+			name:                   "when repair event cannot be appended it should count the error and no error",
+			payment:                newPayment(domain.StatusPending),
+			mockWalletStatus:       domain.WalletOperationStatusReserved,
+			mockAppendEventError:   errors.New("database error"),
+			shouldCallGetOperation: true,
+			expectedRepairMethod:   "UpdateStatus",
+			expectedStatus:         domain.StatusReserved,
+			shouldCallAppendEvent:  true,
+			expectedResult:         &ReconciliationResult{Checked: 1, Errors: 1},
+			expectedError:          nil,
+		},
+		{
+			name:                   "when discrepancy cannot be recorded it should count the error and no error",
+			payment:                newPayment(domain.StatusCompleted),
+			mockWalletStatus:       domain.WalletOperationStatusReleased,
+			mockRecordError:        errors.New("database error"),
+			shouldCallGetOperation: true,
+			shouldCallRecord:       true,
+			expectedResult:         &ReconciliationResult{Checked: 1, Errors: 1},
+			expectedError:          nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockFinder := new(MockCandidateFinder)
+			mockWallet := new(walletclient.MockWalletClient)
+			mockRecorder := new(paymentstorer.MockPaymentRepository)
+			mockDiscrepancies := new(MockDiscrepancyRepository)
+			mockLocker := new(paymentlocker.MockPaymentLockerRepository)
+			mockUnlocker := new(paymentlocker.MockUnlocker)
+
+			if tt.mockFindError != nil {
+				mockFinder.On("FindCandidates", mock.Anything, mock.Anything, mock.Anything, Cursor{}, policy.BatchSize).Return(nil, tt.mockFindError)
+			} else if tt.payment == nil {
+				mockFinder.On("FindCandidates", mock.Anything, mock.Anything, mock.Anything, Cursor{}, policy.BatchSize).Return([]*domain.Payment{}, nil)
+			} else {
+				mockFinder.On("FindCandidates", mock.Anything, mock.Anything, mock.Anything, Cursor{}, policy.BatchSize).Return([]*domain.Payment{tt.payment}, nil)
+			}
+
+			if tt.payment != nil {
+				if tt.mockLockError != nil {
+					mockLocker.On("Lock", mock.Anything, tt.payment.ID).Return(nil, tt.mockLockError)
+				} else {
+					mockLocker.On("Lock", mock.Anything, tt.payment.ID).Return(mockUnlocker, nil)
+					mockUnlocker.On("Unlock", mock.Anything).Return(nil)
+				}
+			}
+
+			// The payment is read again once locked
+			switch {
+			case tt.payment == nil, tt.mockLockError != nil:
+			case tt.mockGetError != nil:
+				mockRecorder.On("GetByID", mock.Anything, tt.payment.ID).Return(nil, tt.mockGetError)
+			default:
+				current := *tt.payment
+				if tt.mockCurrentStatus != "" {
+					current.Status = tt.mockCurrentStatus
+				}
+				mockRecorder.On("GetByID", mock.Anything, tt.payment.ID).Return(&current, nil)
+			}
+
+			if tt.shouldCallGetOperation {
+				if tt.mockGetOperationError != nil {
+					mockWallet.On("GetOperation", mock.Anything, tt.payment.ID).Return(nil, tt.mockGetOperationError)
+				} else {
+					operation := &domain.WalletOperation{PaymentID: tt.payment.ID, Status: tt.mockWalletStatus}
+					mockWallet.On("GetOperation", mock.Anything, tt.payment.ID).Return(operation, nil)
+				}
+			}
+
+			switch tt.expectedRepairMethod {
+			case "UpdateStatus":
+				mockRecorder.On("UpdateStatus", mock.Anything, tt.payment.ID, tt.expectedStatus, "").Return(tt.mockRepairError)
+			case "Confirm", "Release":
+				mockWallet.On(tt.expectedRepairMethod, mock.Anything, tt.payment.UserID, tt.payment.Amount, tt.payment.ID).Return(tt.mockRepairError)
+			}
+
+			if tt.shouldCallAppendEvent {
+				mockRecorder.On("AppendEvent", mock.Anything, tt.payment.ID, domain.EventTypeReconciled, mock.Anything).Return(tt.mockAppendEventError)
+			}
+
+			if tt.shouldCallRecord {
+				mockDiscrepancies.On("Record", mock.Anything, mock.MatchedBy(func(d *Discrepancy) bool {
+					return d.PaymentID == tt.payment.ID && d.PaymentStatus == tt.payment.Status && d.WalletStatus == tt.mockWalletStatus
+				})).Return(tt.mockRecordError)
+			}
+
+			service := &WalletReconcilerService{
+				candidateFinder:     mockFinder,
+				walletInspector:     mockWallet,
+				paymentRecorder:     mockRecorder,
+				discrepancyRecorder: mockDiscrepancies,
+				paymentLocker:       mockLocker,
+				policy:              policy,
+			}
+
+			// Act
+			result, err := service.Reconcile(context.Background())
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, result)
+			}
+
+			mockFinder.AssertExpectations(t)
+			mockWallet.AssertExpectations(t)
+			mockRecorder.AssertExpectations(t)
+			mockDiscrepancies.AssertExpectations(t)
+			mockLocker.AssertExpectations(t)
+			mockUnlocker.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWalletReconcilerService_Reconcile_Paging(t *testing.T) {
+	// Arrange
+	policy := ReconciliationPolicy{MinAge: 5 * time.Minute, Lookback: 24 * time.Hour, ProcessingTimeout: 30 * time.Minute, BatchSize: 2}
+	fixedTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	firstPage := []*domain.Payment{
+		{ID: "pay_1", Status: domain.StatusFailed, UpdatedAt: fixedTime},
+		{ID: "pay_2", Status: domain.StatusFailed, UpdatedAt: fixedTime.Add(time.Second)},
+	}
+	secondPage := []*domain.Payment{
+		{ID: "pay_3", Status: domain.StatusFailed, UpdatedAt: fixedTime.Add(2 * time.Second)},
+	}
+
+	mockFinder := new(MockCandidateFinder)
+	mockFinder.On("FindCandidates", mock.Anything, mock.Anything, mock.Anything, Cursor{}, 2).Return(firstPage, nil).Once()
+	mockFinder.On("FindCandidates", mock.Anything, mock.Anything, mock.Anything, Cursor{UpdatedAt: firstPage[1].UpdatedAt, PaymentID: "pay_2"}, 2).Return(secondPage, nil).Once()
+
+	mockWallet := new(walletclient.MockWalletClient)
+	mockWallet.On("GetOperation", mock.Anything, mock.Anything).Return(&domain.WalletOperation{Status: domain.WalletOperationStatusReleased}, nil).Times(3)
+
+	mockRecorder := new(paymentstorer.MockPaymentRepository)
+	for _, payment := range append(firstPage, secondPage...) {
+		mockRecorder.On("GetByID", mock.Anything, payment.ID).Return(payment, nil).Once()
+	}
+
+	mockUnlocker := new(paymentlocker.MockUnlocker)
+	mockUnlocker.On("Unlock", mock.Anything).Return(nil).Times(3)
+	mockLocker := new(paymentlocker.MockPaymentLockerRepository)
+	mockLocker.On("Lock", mock.Anything, mock.Anything).Return(mockUnlocker, nil).Times(3)
+
+	service := &WalletReconcilerService{
+		candidateFinder:     mockFinder,
+		walletInspector:     mockWallet,
+		paymentRecorder:     mockRecorder,
+		discrepancyRecorder: new(MockDiscrepancyRepository),
+		paymentLocker:       mockLocker,
+		policy:              policy,
+	}
+
+	// Act
+	result, err := service.Reconcile(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, &ReconciliationResult{Checked: 3, Consistent: 3}, result)
+
+	mockFinder.AssertExpectations(t)
+	mockWallet.AssertExpectations(t)
+	mockRecorder.AssertExpectations(t)
+	mockLocker.AssertExpectations(t)
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// AuthConfig holds the bearer token verification configuration
+// API keys are stored hashed in the database and need no configuration
+type AuthConfig struct {
+	JWTHMACKeyFile      string        // File with the shared secret of HS256 tokens, empty disables HS256
+	JWTRSAPublicKeyFile string        // PEM file with the public key of RS256 tokens
+	JWTJWKSFile         string        // JWKS file with the public keys of RS256 tokens
+	JWTIssuer           string        // Expected iss claim, empty skips the check
+	JWTAudience         string        // Expected aud claim, empty skips the check
+	JWTLeeway           time.Duration // Clock skew tolerated on the exp and nbf claims
+}
+
+const (
+	defaultJWTLeeway = 30 * time.Second
+)
+
+// loadAuthConfig reads bearer token verification configuration from environment variables
+func loadAuthConfig(invalidVars *[]string) AuthConfig {
+	return AuthConfig{
+		JWTHMACKeyFile:      os.Getenv("AUTH_JWT_HMAC_KEY_FILE"),
+		JWTRSAPublicKeyFile: os.Getenv("AUTH_JWT_RSA_PUBLIC_KEY_FILE"),
+		JWTJWKSFile:         os.Getenv("AUTH_JWT_JWKS_FILE"),
+		JWTIssuer:           os.Getenv("AUTH_JWT_ISSUER"),
+		JWTAudience:         os.Getenv("AUTH_JWT_AUDIENCE"),
+		JWTLeeway:           getDurationEnv("AUTH_JWT_LEEWAY", defaultJWTLeeway, invalidVars),
+	}
+}
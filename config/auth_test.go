@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadAuthConfig(t *testing.T) {
+	authVars := []string{
+		"AUTH_JWT_HMAC_KEY_FILE",
+		"AUTH_JWT_RSA_PUBLIC_KEY_FILE",
+		"AUTH_JWT_JWKS_FILE",
+		"AUTH_JWT_ISSUER",
+		"AUTH_JWT_AUDIENCE",
+		"AUTH_JWT_LEEWAY",
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      AuthConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:                "when no environment variables are set it should return default values and no invalid variables",
+			envVars:             map[string]string{},
+			expectedConfig:      AuthConfig{JWTLeeway: 30 * time.Second},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"AUTH_JWT_HMAC_KEY_FILE":       "/run/secrets/jwt_hmac_key",
+				"AUTH_JWT_RSA_PUBLIC_KEY_FILE": "/run/secrets/jwt_public_key.pem",
+				"AUTH_JWT_JWKS_FILE":           "/run/secrets/jwks.json",
+				"AUTH_JWT_ISSUER":              "https://auth.example.com",
+				"AUTH_JWT_AUDIENCE":            "payments-api",
+				"AUTH_JWT_LEEWAY":              "1m",
+			},
+			expectedConfig: AuthConfig{
+				JWTHMACKeyFile:      "/run/secrets/jwt_hmac_key",
+				JWTRSAPublicKeyFile: "/run/secrets/jwt_public_key.pem",
+				JWTJWKSFile:         "/run/secrets/jwks.json",
+				JWTIssuer:           "https://auth.example.com",
+				JWTAudience:         "payments-api",
+				JWTLeeway:           time.Minute,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when leeway is not a valid duration it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"AUTH_JWT_LEEWAY": "soon",
+			},
+			expectedConfig:      AuthConfig{JWTLeeway: 30 * time.Second},
+			expectedInvalidVars: []string{"AUTH_JWT_LEEWAY"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range authVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range authVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadAuthConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import "time"
+
+// BreakerConfig holds the configuration of a circuit breaker
+type BreakerConfig struct {
+	Window      time.Duration // Rolling window the failure rate is computed over
+	MinRequests int           // Calls in the window before the failure rate can open the breaker
+	FailureRate int           // Failure rate in the window that opens the breaker, as a percentage
+	Cooldown    time.Duration // Time the breaker stays open before letting probes through
+	Probes      int           // Successful probes needed to close the breaker again
+}
+
+// CircuitBreakerConfig holds the circuit breaker configuration of the external dependencies
+type CircuitBreakerConfig struct {
+	Wallet     BreakerConfig
+	Gateway    BreakerConfig
+	DeferDelay time.Duration // Time a consumer worker pauses before requeuing a message deferred by an open breaker
+}
+
+const (
+	defaultBreakerWindow      = time.Minute
+	defaultBreakerMinRequests = 10
+	defaultBreakerFailureRate = 50
+	defaultBreakerCooldown    = 30 * time.Second
+	defaultBreakerProbes      = 1
+	defaultConsumerDeferDelay = 5 * time.Second
+)
+
+// loadCircuitBreakerConfig reads circuit breaker configuration from environment variables
+func loadCircuitBreakerConfig(invalidVars *[]string) CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		Wallet:     loadBreakerConfig("WALLET_BREAKER", invalidVars),
+		Gateway:    loadBreakerConfig("GATEWAY_BREAKER", invalidVars),
+		DeferDelay: getDurationEnv("CONSUMER_DEFER_DELAY", defaultConsumerDeferDelay, invalidVars),
+	}
+}
+
+// loadBreakerConfig reads the configuration of a single breaker from the variables with the prefix
+func loadBreakerConfig(prefix string, invalidVars *[]string) BreakerConfig {
+	failureRate := getIntEnv(prefix+"_FAILURE_RATE", defaultBreakerFailureRate, invalidVars)
+	if failureRate > 100 {
+		*invalidVars = append(*invalidVars, prefix+"_FAILURE_RATE")
+		failureRate = defaultBreakerFailureRate
+	}
+
+	return BreakerConfig{
+		Window:      getDurationEnv(prefix+"_WINDOW", defaultBreakerWindow, invalidVars),
+		MinRequests: getIntEnv(prefix+"_MIN_REQUESTS", defaultBreakerMinRequests, invalidVars),
+		FailureRate: failureRate,
+		Cooldown:    getDurationEnv(prefix+"_COOLDOWN", defaultBreakerCooldown, invalidVars),
+		Probes:      getIntEnv(prefix+"_PROBES", defaultBreakerProbes, invalidVars),
+	}
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCircuitBreakerConfig(t *testing.T) {
+	circuitBreakerVars := []string{
+		"WALLET_BREAKER_WINDOW",
+		"WALLET_BREAKER_MIN_REQUESTS",
+		"WALLET_BREAKER_FAILURE_RATE",
+		"WALLET_BREAKER_COOLDOWN",
+		"WALLET_BREAKER_PROBES",
+		"GATEWAY_BREAKER_WINDOW",
+		"GATEWAY_BREAKER_MIN_REQUESTS",
+		"GATEWAY_BREAKER_FAILURE_RATE",
+		"GATEWAY_BREAKER_COOLDOWN",
+		"GATEWAY_BREAKER_PROBES",
+		"CONSUMER_DEFER_DELAY",
+	}
+
+	defaultBreaker := BreakerConfig{
+		Window:      time.Minute,
+		MinRequests: 10,
+		FailureRate: 50,
+		Cooldown:    30 * time.Second,
+		Probes:      1,
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      CircuitBreakerConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:    "when no environment variables are set it should return default values and no invalid variables",
+			envVars: map[string]string{},
+			expectedConfig: CircuitBreakerConfig{
+				Wallet:     defaultBreaker,
+				Gateway:    defaultBreaker,
+				DeferDelay: 5 * time.Second,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all environment variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"WALLET_BREAKER_WINDOW":        "30s",
+				"WALLET_BREAKER_MIN_REQUESTS":  "5",
+				"WALLET_BREAKER_FAILURE_RATE":  "25",
+				"WALLET_BREAKER_COOLDOWN":      "10s",
+				"WALLET_BREAKER_PROBES":        "2",
+				"GATEWAY_BREAKER_WINDOW":       "2m",
+				"GATEWAY_BREAKER_MIN_REQUESTS": "20",
+				"GATEWAY_BREAKER_FAILURE_RATE": "100",
+				"GATEWAY_BREAKER_COOLDOWN":     "1m",
+				"GATEWAY_BREAKER_PROBES":       "3",
+				"CONSUMER_DEFER_DELAY":         "1s",
+			},
+			expectedConfig: CircuitBreakerConfig{
+				Wallet: BreakerConfig{
+					Window:      30 * time.Second,
+					MinRequests: 5,
+					FailureRate: 25,
+					Cooldown:    10 * time.Second,
+					Probes:      2,
+				},
+				Gateway: BreakerConfig{
+					Window:      2 * time.Minute,
+					MinRequests: 20,
+					FailureRate: 100,
+					Cooldown:    time.Minute,
+					Probes:      3,
+				},
+				DeferDelay: time.Second,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when failure rate is above 100 it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"WALLET_BREAKER_FAILURE_RATE": "150",
+			},
+			expectedConfig: CircuitBreakerConfig{
+				Wallet:     defaultBreaker,
+				Gateway:    defaultBreaker,
+				DeferDelay: 5 * time.Second,
+			},
+			expectedInvalidVars: []string{"WALLET_BREAKER_FAILURE_RATE"},
+		},
+		{
+			name: "when cooldown is not a valid duration it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"GATEWAY_BREAKER_COOLDOWN": "soon",
+			},
+			expectedConfig: CircuitBreakerConfig{
+				Wallet:     defaultBreaker,
+				Gateway:    defaultBreaker,
+				DeferDelay: 5 * time.Second,
+			},
+			expectedInvalidVars: []string{"GATEWAY_BREAKER_COOLDOWN"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range circuitBreakerVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range circuitBreakerVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadCircuitBreakerConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
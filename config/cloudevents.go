@@ -0,0 +1,52 @@
+package config
+
+import "os"
+
+// CloudEventsConfig holds the configuration of the CloudEvents published to the broker
+type CloudEventsConfig struct {
+	Mode         string // How the events are written, legacy (raw payment), structured or binary
+	Source       string // Source attribute of the published events
+	DataEncoding string // How the payment data is encoded, json or protobuf
+}
+
+const (
+	// Publishers keep writing the raw payment until structured or binary is opted into,
+	// so consumers deployed before CloudEvents support keep reading the messages
+	defaultCloudEventsMode   = "legacy"
+	defaultCloudEventsSource = "/payments-api"
+	defaultDataEncoding      = "json"
+)
+
+// loadCloudEventsConfig reads CloudEvents configuration from environment variables
+func loadCloudEventsConfig(invalidVars *[]string) CloudEventsConfig {
+	mode := os.Getenv("CLOUDEVENTS_MODE")
+	switch mode {
+	case "":
+		mode = defaultCloudEventsMode
+	case "legacy", "structured", "binary":
+	default:
+		*invalidVars = append(*invalidVars, "CLOUDEVENTS_MODE")
+		mode = defaultCloudEventsMode
+	}
+
+	source := os.Getenv("CLOUDEVENTS_SOURCE")
+	if source == "" {
+		source = defaultCloudEventsSource
+	}
+
+	dataEncoding := os.Getenv("CLOUDEVENTS_DATA_ENCODING")
+	switch dataEncoding {
+	case "":
+		dataEncoding = defaultDataEncoding
+	case "json", "protobuf":
+	default:
+		*invalidVars = append(*invalidVars, "CLOUDEVENTS_DATA_ENCODING")
+		dataEncoding = defaultDataEncoding
+	}
+
+	return CloudEventsConfig{
+		Mode:         mode,
+		Source:       source,
+		DataEncoding: dataEncoding,
+	}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCloudEventsConfig(t *testing.T) {
+	cloudEventsVars := []string{
+		"CLOUDEVENTS_MODE",
+		"CLOUDEVENTS_SOURCE",
+		"CLOUDEVENTS_DATA_ENCODING",
+	}
+
+	defaultConfig := CloudEventsConfig{
+		Mode:         "legacy",
+		Source:       "/payments-api",
+		DataEncoding: "json",
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      CloudEventsConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:                "when no environment variables are set it should return default values and no invalid variables",
+			envVars:             map[string]string{},
+			expectedConfig:      defaultConfig,
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all environment variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"CLOUDEVENTS_MODE":          "binary",
+				"CLOUDEVENTS_SOURCE":        "https://payments.example.com",
+				"CLOUDEVENTS_DATA_ENCODING": "protobuf",
+			},
+			expectedConfig: CloudEventsConfig{
+				Mode:         "binary",
+				Source:       "https://payments.example.com",
+				DataEncoding: "protobuf",
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when mode is structured it should return structured mode and no invalid variables",
+			envVars: map[string]string{
+				"CLOUDEVENTS_MODE": "structured",
+			},
+			expectedConfig: CloudEventsConfig{
+				Mode:         "structured",
+				Source:       "/payments-api",
+				DataEncoding: "json",
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when mode is unknown it should return default values and track invalid variables",
+			envVars: map[string]string{
+				"CLOUDEVENTS_MODE": "xml",
+			},
+			expectedConfig:      defaultConfig,
+			expectedInvalidVars: []string{"CLOUDEVENTS_MODE"},
+		},
+		{
+			name: "when data encoding is unknown it should return default values and track invalid variables",
+			envVars: map[string]string{
+				"CLOUDEVENTS_DATA_ENCODING": "avro",
+			},
+			expectedConfig:      defaultConfig,
+			expectedInvalidVars: []string{"CLOUDEVENTS_DATA_ENCODING"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range cloudEventsVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range cloudEventsVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadCloudEventsConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
@@ -3,15 +3,35 @@ package config
 import (
 	"errors"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Config holds all application configuration
 type Config struct {
-	Database      DatabaseConfig
-	MessageBroker MessageBrokerConfig
-	Exchange      string // Exchange name for topic-based routing
-	QueueName     string // Queue name for this consumer
+	Database                 DatabaseConfig
+	MessageBroker            MessageBrokerConfig
+	Recovery                 RecoveryConfig
+	ConfirmRetry             ConfirmRetryConfig
+	Idempotency              IdempotencyConfig
+	Auth                     AuthConfig
+	RateLimit                RateLimitConfig
+	Logging                  LoggingConfig
+	Scheduler                SchedulerConfig
+	DeadLetter               DeadLetterConfig
+	WalletReconciliation     WalletReconciliationConfig
+	SettlementReconciliation SettlementReconciliationConfig
+	Webhook                  WebhookConfig
+	EventStream              EventStreamConfig
+	GatewayCallback          GatewayCallbackConfig
+	ProcessedMessage         ProcessedMessageConfig
+	EventPublisher           EventPublisherConfig
+	CloudEvents              CloudEventsConfig
+	CircuitBreaker           CircuitBreakerConfig
+	Run                      RunConfig
+	Exchange                 string // Exchange name for topic-based routing
+	QueueName                string // Queue name for this consumer
 }
 
 const (
@@ -22,19 +42,60 @@ const (
 // Load reads environment variables and returns the application configuration
 func Load() (*Config, error) {
 	var missingVars []string
+	var invalidVars []string
 
 	dbConfig := loadDatabaseConfig(&missingVars)
-	messageBrokerConfig := loadMessageBrokerConfig(&missingVars)
+	messageBrokerConfig := loadMessageBrokerConfig(&missingVars, &invalidVars)
+	recoveryConfig := loadRecoveryConfig(&invalidVars)
+	confirmRetryConfig := loadConfirmRetryConfig(&invalidVars)
+	idempotencyConfig := loadIdempotencyConfig(&invalidVars)
+	authConfig := loadAuthConfig(&invalidVars)
+	rateLimitConfig := loadRateLimitConfig(&invalidVars)
+	loggingConfig := loadLoggingConfig(&invalidVars)
+	schedulerConfig := loadSchedulerConfig(&invalidVars)
+	deadLetterConfig := loadDeadLetterConfig(&invalidVars)
+	walletReconciliationConfig := loadWalletReconciliationConfig(&invalidVars)
+	settlementReconciliationConfig := loadSettlementReconciliationConfig(&invalidVars)
+	webhookConfig := loadWebhookConfig(&invalidVars)
+	eventStreamConfig := loadEventStreamConfig(&invalidVars)
+	gatewayCallbackConfig := loadGatewayCallbackConfig(&invalidVars)
+	processedMessageConfig := loadProcessedMessageConfig(&invalidVars)
+	eventPublisherConfig := loadEventPublisherConfig(&invalidVars)
+	cloudEventsConfig := loadCloudEventsConfig(&invalidVars)
+	circuitBreakerConfig := loadCircuitBreakerConfig(&invalidVars)
+	runConfig := loadRunConfig(&invalidVars)
 
 	if len(missingVars) > 0 {
 		return nil, errors.New("missing required environment variables: " + strings.Join(missingVars, ", "))
 	}
 
+	if len(invalidVars) > 0 {
+		return nil, errors.New("invalid environment variables: " + strings.Join(invalidVars, ", "))
+	}
+
 	return &Config{
-		Database:      dbConfig,
-		MessageBroker: messageBrokerConfig,
-		Exchange:      exchangeName,
-		QueueName:     queueName,
+		Database:                 dbConfig,
+		MessageBroker:            messageBrokerConfig,
+		Recovery:                 recoveryConfig,
+		ConfirmRetry:             confirmRetryConfig,
+		Idempotency:              idempotencyConfig,
+		Auth:                     authConfig,
+		RateLimit:                rateLimitConfig,
+		Logging:                  loggingConfig,
+		Scheduler:                schedulerConfig,
+		DeadLetter:               deadLetterConfig,
+		WalletReconciliation:     walletReconciliationConfig,
+		SettlementReconciliation: settlementReconciliationConfig,
+		Webhook:                  webhookConfig,
+		EventStream:              eventStreamConfig,
+		GatewayCallback:          gatewayCallbackConfig,
+		ProcessedMessage:         processedMessageConfig,
+		EventPublisher:           eventPublisherConfig,
+		CloudEvents:              cloudEventsConfig,
+		CircuitBreaker:           circuitBreakerConfig,
+		Run:                      runConfig,
+		Exchange:                 exchangeName,
+		QueueName:                queueName,
 	}, nil
 }
 
@@ -46,3 +107,67 @@ func getRequiredEnv(key string, missingVars *[]string) string {
 	}
 	return value
 }
+
+// getDurationEnv retrieves an optional duration environment variable and tracks if it's invalid
+// It returns the default value when the variable is not set
+func getDurationEnv(key string, defaultValue time.Duration, invalidVars *[]string) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		*invalidVars = append(*invalidVars, key)
+		return defaultValue
+	}
+	return duration
+}
+
+// getNonNegativeDurationEnv retrieves an optional duration environment variable that may be zero and tracks if it's invalid
+// It returns the default value when the variable is not set
+func getNonNegativeDurationEnv(key string, defaultValue time.Duration, invalidVars *[]string) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		*invalidVars = append(*invalidVars, key)
+		return defaultValue
+	}
+	return duration
+}
+
+// getIntEnv retrieves an optional positive integer environment variable and tracks if it's invalid
+// It returns the default value when the variable is not set
+func getIntEnv(key string, defaultValue int, invalidVars *[]string) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number <= 0 {
+		*invalidVars = append(*invalidVars, key)
+		return defaultValue
+	}
+	return number
+}
+
+// getBoolEnv retrieves an optional boolean environment variable and tracks if it's invalid
+// It returns the default value when the variable is not set
+func getBoolEnv(key string, defaultValue bool, invalidVars *[]string) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		*invalidVars = append(*invalidVars, key)
+		return defaultValue
+	}
+	return enabled
+}
@@ -20,6 +20,9 @@ func TestLoad(t *testing.T) {
 		"BROKER_PORT",
 		"BROKER_USER",
 		"BROKER_PASSWORD",
+		"BROKER_DRIVER",     // Optional, cleared so defaults apply
+		"RECOVERY_INTERVAL", // Optional, cleared so defaults apply
+		"PORT",              // Optional, cleared so defaults apply
 	}
 
 	tests := []struct {
@@ -172,6 +175,52 @@ func TestLoad(t *testing.T) {
 			},
 			expectedError: "missing required environment variables: BROKER_PASSWORD",
 		},
+		{
+			name: "when broker driver is memory it should load config successfully without the broker variables",
+			envVars: map[string]string{
+				"DB_HOST":       "localhost",
+				"DB_PORT":       "5432",
+				"DB_USER":       "postgres",
+				"DB_PASSWORD":   "password",
+				"DB_NAME":       "payments_db",
+				"BROKER_DRIVER": "memory",
+			},
+			expectedError:     "",
+			expectedExchange:  "payments",
+			expectedQueueName: "payments.created",
+		},
+		{
+			name: "when broker driver is unknown it should return error with invalid variable",
+			envVars: map[string]string{
+				"DB_HOST":         "localhost",
+				"DB_PORT":         "5432",
+				"DB_USER":         "postgres",
+				"DB_PASSWORD":     "password",
+				"DB_NAME":         "payments_db",
+				"BROKER_HOST":     "rabbitmq",
+				"BROKER_PORT":     "5672",
+				"BROKER_USER":     "guest",
+				"BROKER_PASSWORD": "guest",
+				"BROKER_DRIVER":   "kafka",
+			},
+			expectedError: "invalid environment variables: BROKER_DRIVER",
+		},
+		{
+			name: "when an optional environment variable is invalid it should return error with invalid variable",
+			envVars: map[string]string{
+				"DB_HOST":           "localhost",
+				"DB_PORT":           "5432",
+				"DB_USER":           "postgres",
+				"DB_PASSWORD":       "password",
+				"DB_NAME":           "payments_db",
+				"BROKER_HOST":       "rabbitmq",
+				"BROKER_PORT":       "5672",
+				"BROKER_USER":       "guest",
+				"BROKER_PASSWORD":   "guest",
+				"RECOVERY_INTERVAL": "invalid",
+			},
+			expectedError: "invalid environment variables: RECOVERY_INTERVAL",
+		},
 		{
 			name: "when multiple environment variables are missing it should return error with all missing variables",
 			envVars: map[string]string{
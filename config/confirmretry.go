@@ -0,0 +1,28 @@
+package config
+
+import "time"
+
+// ConfirmRetryConfig holds the confirm retry job configuration
+type ConfirmRetryConfig struct {
+	Interval  time.Duration // How often the job retries payments pending confirmation
+	Threshold time.Duration // Time without progress after which a payment pending confirmation is retried
+	BatchSize int           // Maximum number of payments retried per run
+	Timeout   time.Duration // Maximum duration of a single run
+}
+
+const (
+	defaultConfirmRetryInterval  = time.Minute
+	defaultConfirmRetryThreshold = time.Minute
+	defaultConfirmRetryBatchSize = 100
+	defaultConfirmRetryTimeout   = time.Minute
+)
+
+// loadConfirmRetryConfig reads confirm retry job configuration from environment variables
+func loadConfirmRetryConfig(invalidVars *[]string) ConfirmRetryConfig {
+	return ConfirmRetryConfig{
+		Interval:  getDurationEnv("CONFIRM_RETRY_INTERVAL", defaultConfirmRetryInterval, invalidVars),
+		Threshold: getDurationEnv("CONFIRM_RETRY_THRESHOLD", defaultConfirmRetryThreshold, invalidVars),
+		BatchSize: getIntEnv("CONFIRM_RETRY_BATCH_SIZE", defaultConfirmRetryBatchSize, invalidVars),
+		Timeout:   getDurationEnv("CONFIRM_RETRY_TIMEOUT", defaultConfirmRetryTimeout, invalidVars),
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfirmRetryConfig(t *testing.T) {
+	confirmRetryVars := []string{
+		"CONFIRM_RETRY_INTERVAL",
+		"CONFIRM_RETRY_THRESHOLD",
+		"CONFIRM_RETRY_BATCH_SIZE",
+		"CONFIRM_RETRY_TIMEOUT",
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      ConfirmRetryConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:    "when no environment variables are set it should return default values and no invalid variables",
+			envVars: map[string]string{},
+			expectedConfig: ConfirmRetryConfig{
+				Interval:  time.Minute,
+				Threshold: time.Minute,
+				BatchSize: 100,
+				Timeout:   time.Minute,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all environment variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"CONFIRM_RETRY_INTERVAL":   "30s",
+				"CONFIRM_RETRY_THRESHOLD":  "2m",
+				"CONFIRM_RETRY_BATCH_SIZE": "50",
+				"CONFIRM_RETRY_TIMEOUT":    "20s",
+			},
+			expectedConfig: ConfirmRetryConfig{
+				Interval:  30 * time.Second,
+				Threshold: 2 * time.Minute,
+				BatchSize: 50,
+				Timeout:   20 * time.Second,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when batch size is not a positive number it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"CONFIRM_RETRY_BATCH_SIZE": "-5",
+			},
+			expectedConfig: ConfirmRetryConfig{
+				Interval:  time.Minute,
+				Threshold: time.Minute,
+				BatchSize: 100,
+				Timeout:   time.Minute,
+			},
+			expectedInvalidVars: []string{"CONFIRM_RETRY_BATCH_SIZE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range confirmRetryVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range confirmRetryVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadConfirmRetryConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
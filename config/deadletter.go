@@ -0,0 +1,23 @@
+package config
+
+// DeadLetterConfig holds the dead letter queue configuration
+type DeadLetterConfig struct {
+	QueueName   string // Queue where messages land after exhausting their attempts
+	MaxAttempts int    // Failed deliveries before a message is dead-lettered
+	BatchLimit  int    // Maximum number of dead letters replayed or discarded per batch
+}
+
+const (
+	deadLetterQueueName          = queueName + ".dlq" // Dead letter queue for payments pending processing
+	defaultDeadLetterMaxAttempts = 5
+	defaultDeadLetterBatchLimit  = 100
+)
+
+// loadDeadLetterConfig reads dead letter queue configuration from environment variables
+func loadDeadLetterConfig(invalidVars *[]string) DeadLetterConfig {
+	return DeadLetterConfig{
+		QueueName:   deadLetterQueueName,
+		MaxAttempts: getIntEnv("DLQ_MAX_ATTEMPTS", defaultDeadLetterMaxAttempts, invalidVars),
+		BatchLimit:  getIntEnv("DLQ_BATCH_LIMIT", defaultDeadLetterBatchLimit, invalidVars),
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadDeadLetterConfig(t *testing.T) {
+	deadLetterVars := []string{
+		"DLQ_MAX_ATTEMPTS",
+		"DLQ_BATCH_LIMIT",
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      DeadLetterConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:    "when no environment variables are set it should return default values and no invalid variables",
+			envVars: map[string]string{},
+			expectedConfig: DeadLetterConfig{
+				QueueName:   "payments.created.dlq",
+				MaxAttempts: 5,
+				BatchLimit:  100,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all environment variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"DLQ_MAX_ATTEMPTS": "3",
+				"DLQ_BATCH_LIMIT":  "20",
+			},
+			expectedConfig: DeadLetterConfig{
+				QueueName:   "payments.created.dlq",
+				MaxAttempts: 3,
+				BatchLimit:  20,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when max attempts is not a positive number it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"DLQ_MAX_ATTEMPTS": "zero",
+			},
+			expectedConfig: DeadLetterConfig{
+				QueueName:   "payments.created.dlq",
+				MaxAttempts: 5,
+				BatchLimit:  100,
+			},
+			expectedInvalidVars: []string{"DLQ_MAX_ATTEMPTS"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range deadLetterVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range deadLetterVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadDeadLetterConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import "time"
+
+// EventPublisherConfig holds the configuration of the job publishing the payment events to the exchange
+type EventPublisherConfig struct {
+	Interval  time.Duration // How often the job publishes the events waiting in the outbox
+	Timeout   time.Duration // Maximum duration of a single run
+	BatchSize int           // Maximum number of events published per run
+}
+
+const (
+	defaultEventPublisherInterval  = 2 * time.Second
+	defaultEventPublisherTimeout   = time.Minute
+	defaultEventPublisherBatchSize = 100
+)
+
+// loadEventPublisherConfig reads payment event publishing configuration from environment variables
+func loadEventPublisherConfig(invalidVars *[]string) EventPublisherConfig {
+	return EventPublisherConfig{
+		Interval:  getDurationEnv("EVENT_PUBLISHER_INTERVAL", defaultEventPublisherInterval, invalidVars),
+		Timeout:   getDurationEnv("EVENT_PUBLISHER_TIMEOUT", defaultEventPublisherTimeout, invalidVars),
+		BatchSize: getIntEnv("EVENT_PUBLISHER_BATCH_SIZE", defaultEventPublisherBatchSize, invalidVars),
+	}
+}
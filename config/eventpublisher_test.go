@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadEventPublisherConfig(t *testing.T) {
+	eventPublisherVars := []string{
+		"EVENT_PUBLISHER_INTERVAL",
+		"EVENT_PUBLISHER_TIMEOUT",
+		"EVENT_PUBLISHER_BATCH_SIZE",
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      EventPublisherConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:    "when no environment variables are set it should return default values and no invalid variables",
+			envVars: map[string]string{},
+			expectedConfig: EventPublisherConfig{
+				Interval:  2 * time.Second,
+				Timeout:   time.Minute,
+				BatchSize: 100,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all environment variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"EVENT_PUBLISHER_INTERVAL":   "500ms",
+				"EVENT_PUBLISHER_TIMEOUT":    "30s",
+				"EVENT_PUBLISHER_BATCH_SIZE": "500",
+			},
+			expectedConfig: EventPublisherConfig{
+				Interval:  500 * time.Millisecond,
+				Timeout:   30 * time.Second,
+				BatchSize: 500,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when batch size is invalid it should return default values and track invalid variables",
+			envVars: map[string]string{
+				"EVENT_PUBLISHER_BATCH_SIZE": "many",
+			},
+			expectedConfig: EventPublisherConfig{
+				Interval:  2 * time.Second,
+				Timeout:   time.Minute,
+				BatchSize: 100,
+			},
+			expectedInvalidVars: []string{"EVENT_PUBLISHER_BATCH_SIZE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range eventPublisherVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range eventPublisherVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadEventPublisherConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// EventStreamConfig holds the payment event stream configuration
+type EventStreamConfig struct {
+	KeepAlive   time.Duration // How often an idle stream sends a comment, so proxies do not close it
+	MaxDuration time.Duration // Longest a stream stays open, clients resume with Last-Event-ID afterwards
+}
+
+const (
+	defaultEventStreamKeepAlive   = 15 * time.Second
+	defaultEventStreamMaxDuration = 30 * time.Minute
+)
+
+// loadEventStreamConfig reads payment event stream configuration from environment variables
+func loadEventStreamConfig(invalidVars *[]string) EventStreamConfig {
+	return EventStreamConfig{
+		KeepAlive:   getDurationEnv("EVENT_STREAM_KEEPALIVE", defaultEventStreamKeepAlive, invalidVars),
+		MaxDuration: getDurationEnv("EVENT_STREAM_MAX_DURATION", defaultEventStreamMaxDuration, invalidVars),
+	}
+}
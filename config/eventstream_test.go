@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadEventStreamConfig(t *testing.T) {
+	eventStreamVars := []string{
+		"EVENT_STREAM_KEEPALIVE",
+		"EVENT_STREAM_MAX_DURATION",
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      EventStreamConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:    "when no environment variables are set it should return default values and no invalid variables",
+			envVars: map[string]string{},
+			expectedConfig: EventStreamConfig{
+				KeepAlive:   15 * time.Second,
+				MaxDuration: 30 * time.Minute,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all environment variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"EVENT_STREAM_KEEPALIVE":    "5s",
+				"EVENT_STREAM_MAX_DURATION": "1h",
+			},
+			expectedConfig: EventStreamConfig{
+				KeepAlive:   5 * time.Second,
+				MaxDuration: time.Hour,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when keepalive is invalid it should return default values and track invalid variables",
+			envVars: map[string]string{
+				"EVENT_STREAM_KEEPALIVE": "often",
+			},
+			expectedConfig: EventStreamConfig{
+				KeepAlive:   15 * time.Second,
+				MaxDuration: 30 * time.Minute,
+			},
+			expectedInvalidVars: []string{"EVENT_STREAM_KEEPALIVE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range eventStreamVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range eventStreamVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadEventStreamConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// GatewayCallbackConfig holds the payment gateway callback configuration
+type GatewayCallbackConfig struct {
+	SecretFile string        // File with the secret shared with the gateway, empty disables callbacks
+	Tolerance  time.Duration // Maximum age of a callback signature, rejects replayed callbacks
+}
+
+const (
+	defaultGatewayCallbackTolerance = 5 * time.Minute
+)
+
+// loadGatewayCallbackConfig reads payment gateway callback configuration from environment variables
+func loadGatewayCallbackConfig(invalidVars *[]string) GatewayCallbackConfig {
+	return GatewayCallbackConfig{
+		SecretFile: os.Getenv("GATEWAY_CALLBACK_SECRET_FILE"),
+		Tolerance:  getDurationEnv("GATEWAY_CALLBACK_TOLERANCE", defaultGatewayCallbackTolerance, invalidVars),
+	}
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadGatewayCallbackConfig(t *testing.T) {
+	gatewayCallbackVars := []string{
+		"GATEWAY_CALLBACK_SECRET_FILE",
+		"GATEWAY_CALLBACK_TOLERANCE",
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      GatewayCallbackConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:    "when no environment variables are set it should return default values and no invalid variables",
+			envVars: map[string]string{},
+			expectedConfig: GatewayCallbackConfig{
+				SecretFile: "",
+				Tolerance:  5 * time.Minute,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all environment variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"GATEWAY_CALLBACK_SECRET_FILE": "/run/secrets/gateway_callback",
+				"GATEWAY_CALLBACK_TOLERANCE":   "1m",
+			},
+			expectedConfig: GatewayCallbackConfig{
+				SecretFile: "/run/secrets/gateway_callback",
+				Tolerance:  time.Minute,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when tolerance is invalid it should return default values and track invalid variables",
+			envVars: map[string]string{
+				"GATEWAY_CALLBACK_TOLERANCE": "-1m",
+			},
+			expectedConfig: GatewayCallbackConfig{
+				SecretFile: "",
+				Tolerance:  5 * time.Minute,
+			},
+			expectedInvalidVars: []string{"GATEWAY_CALLBACK_TOLERANCE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range gatewayCallbackVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range gatewayCallbackVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadGatewayCallbackConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
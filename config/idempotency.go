@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// IdempotencyConfig holds the idempotency key configuration
+type IdempotencyConfig struct {
+	TTL         time.Duration // Time during which an idempotency key replays its first response
+	LockTimeout time.Duration // Time a request holds its key before a retry can reclaim it
+}
+
+const (
+	defaultIdempotencyKeyTTL         = 24 * time.Hour
+	defaultIdempotencyKeyLockTimeout = time.Minute
+)
+
+// loadIdempotencyConfig reads idempotency key configuration from environment variables
+func loadIdempotencyConfig(invalidVars *[]string) IdempotencyConfig {
+	return IdempotencyConfig{
+		TTL:         getDurationEnv("IDEMPOTENCY_KEY_TTL", defaultIdempotencyKeyTTL, invalidVars),
+		LockTimeout: getDurationEnv("IDEMPOTENCY_KEY_LOCK_TIMEOUT", defaultIdempotencyKeyLockTimeout, invalidVars),
+	}
+}
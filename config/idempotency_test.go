@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadIdempotencyConfig(t *testing.T) {
+	idempotencyVars := []string{
+		"IDEMPOTENCY_KEY_TTL",
+		"IDEMPOTENCY_KEY_LOCK_TIMEOUT",
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      IdempotencyConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:                "when no environment variables are set it should return default values and no invalid variables",
+			envVars:             map[string]string{},
+			expectedConfig:      IdempotencyConfig{TTL: 24 * time.Hour, LockTimeout: time.Minute},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when ttl and lock timeout are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"IDEMPOTENCY_KEY_TTL":          "1h",
+				"IDEMPOTENCY_KEY_LOCK_TIMEOUT": "30s",
+			},
+			expectedConfig:      IdempotencyConfig{TTL: time.Hour, LockTimeout: 30 * time.Second},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when ttl is not a valid duration it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"IDEMPOTENCY_KEY_TTL": "forever",
+			},
+			expectedConfig:      IdempotencyConfig{TTL: 24 * time.Hour, LockTimeout: time.Minute},
+			expectedInvalidVars: []string{"IDEMPOTENCY_KEY_TTL"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range idempotencyVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range idempotencyVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadIdempotencyConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// LoggingConfig holds the logging configuration
+type LoggingConfig struct {
+	Level           string   // Minimum level logged: debug, info, warn or error
+	Format          string   // Record format: text or json
+	Output          string   // Where logs are written: stdout, stderr or a file path
+	RedactKeys      []string // Attribute keys whose values are replaced in every record
+	RedactAmounts   bool     // Whether payment amounts are redacted too
+	DebugSampleRate int      // Keep 1 of every N debug records of the same message, 1 keeps them all
+	AccessLog       bool     // Whether every HTTP request is logged with its status and latency
+}
+
+const (
+	defaultLogLevel           = "info"
+	defaultLogFormat          = "text"
+	defaultLogOutput          = "stdout"
+	defaultLogRedactKeys      = "user_id,token,api_key,authorization,password,secret"
+	defaultLogDebugSampleRate = 1
+	defaultLogAccessLog       = true
+)
+
+// loadLoggingConfig reads logging configuration from environment variables
+func loadLoggingConfig(invalidVars *[]string) LoggingConfig {
+	level := strings.ToLower(os.Getenv("LOG_LEVEL"))
+	switch level {
+	case "":
+		level = defaultLogLevel
+	case "debug", "info", "warn", "error":
+	default:
+		*invalidVars = append(*invalidVars, "LOG_LEVEL")
+		level = defaultLogLevel
+	}
+
+	format := strings.ToLower(os.Getenv("LOG_FORMAT"))
+	switch format {
+	case "":
+		format = defaultLogFormat
+	case "text", "json":
+	default:
+		*invalidVars = append(*invalidVars, "LOG_FORMAT")
+		format = defaultLogFormat
+	}
+
+	output := os.Getenv("LOG_OUTPUT")
+	if output == "" {
+		output = defaultLogOutput
+	}
+
+	redactKeys := os.Getenv("LOG_REDACT_KEYS")
+	if redactKeys == "" {
+		redactKeys = defaultLogRedactKeys
+	}
+
+	return LoggingConfig{
+		Level:           level,
+		Format:          format,
+		Output:          output,
+		RedactKeys:      splitList(redactKeys),
+		RedactAmounts:   getBoolEnv("LOG_REDACT_AMOUNTS", false, invalidVars),
+		DebugSampleRate: getIntEnv("LOG_DEBUG_SAMPLE_RATE", defaultLogDebugSampleRate, invalidVars),
+		AccessLog:       getBoolEnv("LOG_ACCESS_LOG", defaultLogAccessLog, invalidVars),
+	}
+}
+
+// splitList splits a comma separated list, trimming spaces and dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadLoggingConfig(t *testing.T) {
+	loggingVars := []string{
+		"LOG_LEVEL",
+		"LOG_FORMAT",
+		"LOG_OUTPUT",
+		"LOG_REDACT_KEYS",
+		"LOG_REDACT_AMOUNTS",
+		"LOG_DEBUG_SAMPLE_RATE",
+		"LOG_ACCESS_LOG",
+	}
+
+	defaultConfig := LoggingConfig{
+		Level:           "info",
+		Format:          "text",
+		Output:          "stdout",
+		RedactKeys:      []string{"user_id", "token", "api_key", "authorization", "password", "secret"},
+		RedactAmounts:   false,
+		DebugSampleRate: 1,
+		AccessLog:       true,
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      LoggingConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:                "when no environment variables are set it should return default values and no invalid variables",
+			envVars:             map[string]string{},
+			expectedConfig:      defaultConfig,
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"LOG_LEVEL":             "DEBUG",
+				"LOG_FORMAT":            "json",
+				"LOG_OUTPUT":            "/var/log/payments.log",
+				"LOG_REDACT_KEYS":       " user_id , card_number ,",
+				"LOG_REDACT_AMOUNTS":    "true",
+				"LOG_DEBUG_SAMPLE_RATE": "10",
+				"LOG_ACCESS_LOG":        "false",
+			},
+			expectedConfig: LoggingConfig{
+				Level:           "debug",
+				Format:          "json",
+				Output:          "/var/log/payments.log",
+				RedactKeys:      []string{"user_id", "card_number"},
+				RedactAmounts:   true,
+				DebugSampleRate: 10,
+				AccessLog:       false,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when level is unknown it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"LOG_LEVEL": "verbose",
+			},
+			expectedConfig:      defaultConfig,
+			expectedInvalidVars: []string{"LOG_LEVEL"},
+		},
+		{
+			name: "when format is unknown it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"LOG_FORMAT": "xml",
+			},
+			expectedConfig:      defaultConfig,
+			expectedInvalidVars: []string{"LOG_FORMAT"},
+		},
+		{
+			name: "when booleans are not valid it should return default values and track invalid variables",
+			envVars: map[string]string{
+				"LOG_REDACT_AMOUNTS": "maybe",
+				"LOG_ACCESS_LOG":     "sometimes",
+			},
+			expectedConfig:      defaultConfig,
+			expectedInvalidVars: []string{"LOG_REDACT_AMOUNTS", "LOG_ACCESS_LOG"},
+		},
+		{
+			name: "when sample rate is not a positive number it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"LOG_DEBUG_SAMPLE_RATE": "0",
+			},
+			expectedConfig:      defaultConfig,
+			expectedInvalidVars: []string{"LOG_DEBUG_SAMPLE_RATE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range loggingVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range loggingVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadLoggingConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
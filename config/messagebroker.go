@@ -1,13 +1,34 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Message broker drivers
+const (
+	BrokerDriverRabbitMQ = "rabbitmq" // RabbitMQ reached over AMQP
+	BrokerDriverMemory   = "memory"   // In-process broker, for tests and single binary local runs
+	BrokerDriverPostgres = "postgres" // Queue tables in the payments database, for environments without RabbitMQ
+)
+
+const (
+	defaultBrokerPollInterval      = 500 * time.Millisecond
+	defaultBrokerVisibilityTimeout = 30 * time.Second
+	defaultBrokerMaxDeliveries     = 20
+)
 
 // MessageBrokerConfig holds RabbitMQ configuration
 type MessageBrokerConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
+	Driver            string // Broker implementation, rabbitmq, memory or postgres
+	Host              string
+	Port              string
+	User              string
+	Password          string
+	PollInterval      time.Duration // Time Postgres consumers wait before polling an empty queue again
+	VisibilityTimeout time.Duration // Time a message claimed by a Postgres consumer stays hidden without being acked
+	MaxDeliveries     int           // Deliveries of a Postgres message never acked before it is dead-lettered
 }
 
 // URL returns the RabbitMQ connection URL
@@ -16,8 +37,32 @@ func (c MessageBrokerConfig) URL() string {
 }
 
 // loadMessageBrokerConfig reads message broker configuration from environment variables
-func loadMessageBrokerConfig(missingVars *[]string) MessageBrokerConfig {
+// The connection variables are only required by the RabbitMQ driver, the polling variables only apply to the Postgres one
+func loadMessageBrokerConfig(missingVars *[]string, invalidVars *[]string) MessageBrokerConfig {
+	driver := os.Getenv("BROKER_DRIVER")
+	switch driver {
+	case "":
+		driver = BrokerDriverRabbitMQ
+	case BrokerDriverRabbitMQ, BrokerDriverMemory, BrokerDriverPostgres:
+	default:
+		*invalidVars = append(*invalidVars, "BROKER_DRIVER")
+		driver = BrokerDriverRabbitMQ
+	}
+
+	switch driver {
+	case BrokerDriverMemory:
+		return MessageBrokerConfig{Driver: driver}
+	case BrokerDriverPostgres:
+		return MessageBrokerConfig{
+			Driver:            driver,
+			PollInterval:      getDurationEnv("BROKER_POLL_INTERVAL", defaultBrokerPollInterval, invalidVars),
+			VisibilityTimeout: getDurationEnv("BROKER_VISIBILITY_TIMEOUT", defaultBrokerVisibilityTimeout, invalidVars),
+			MaxDeliveries:     getIntEnv("BROKER_MAX_DELIVERIES", defaultBrokerMaxDeliveries, invalidVars),
+		}
+	}
+
 	return MessageBrokerConfig{
+		Driver:   driver,
 		Host:     getRequiredEnv("BROKER_HOST", missingVars),
 		Port:     getRequiredEnv("BROKER_PORT", missingVars),
 		User:     getRequiredEnv("BROKER_USER", missingVars),
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -100,3 +102,151 @@ func TestMessageBrokerConfig_URL(t *testing.T) {
 	}
 }
 
+
+func TestLoadMessageBrokerConfig(t *testing.T) {
+	messageBrokerVars := []string{
+		"BROKER_DRIVER",
+		"BROKER_HOST",
+		"BROKER_PORT",
+		"BROKER_USER",
+		"BROKER_PASSWORD",
+		"BROKER_POLL_INTERVAL",
+		"BROKER_VISIBILITY_TIMEOUT",
+		"BROKER_MAX_DELIVERIES",
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      MessageBrokerConfig
+		expectedMissingVars []string
+		expectedInvalidVars []string
+	}{
+		{
+			name: "when driver is not set it should use rabbitmq and read the connection variables",
+			envVars: map[string]string{
+				"BROKER_HOST":     "rabbitmq",
+				"BROKER_PORT":     "5672",
+				"BROKER_USER":     "guest",
+				"BROKER_PASSWORD": "guest",
+			},
+			expectedConfig: MessageBrokerConfig{
+				Driver:   "rabbitmq",
+				Host:     "rabbitmq",
+				Port:     "5672",
+				User:     "guest",
+				Password: "guest",
+			},
+			expectedMissingVars: nil,
+			expectedInvalidVars: nil,
+		},
+		{
+			name:                "when driver is rabbitmq and connection variables are missing it should track missing variables",
+			envVars:             map[string]string{"BROKER_DRIVER": "rabbitmq"},
+			expectedConfig:      MessageBrokerConfig{Driver: "rabbitmq"},
+			expectedMissingVars: []string{"BROKER_HOST", "BROKER_PORT", "BROKER_USER", "BROKER_PASSWORD"},
+			expectedInvalidVars: nil,
+		},
+		{
+			name:                "when driver is memory it should not require the connection variables",
+			envVars:             map[string]string{"BROKER_DRIVER": "memory"},
+			expectedConfig:      MessageBrokerConfig{Driver: "memory"},
+			expectedMissingVars: nil,
+			expectedInvalidVars: nil,
+		},
+		{
+			name:    "when driver is postgres it should return default polling values without the connection variables",
+			envVars: map[string]string{"BROKER_DRIVER": "postgres"},
+			expectedConfig: MessageBrokerConfig{
+				Driver:            "postgres",
+				PollInterval:      500 * time.Millisecond,
+				VisibilityTimeout: 30 * time.Second,
+				MaxDeliveries:     20,
+			},
+			expectedMissingVars: nil,
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when driver is postgres with polling variables it should return custom values",
+			envVars: map[string]string{
+				"BROKER_DRIVER":             "postgres",
+				"BROKER_POLL_INTERVAL":      "1s",
+				"BROKER_VISIBILITY_TIMEOUT": "2m",
+				"BROKER_MAX_DELIVERIES":     "5",
+			},
+			expectedConfig: MessageBrokerConfig{
+				Driver:            "postgres",
+				PollInterval:      time.Second,
+				VisibilityTimeout: 2 * time.Minute,
+				MaxDeliveries:     5,
+			},
+			expectedMissingVars: nil,
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when driver is postgres with invalid polling variables it should return default values and track invalid variables",
+			envVars: map[string]string{
+				"BROKER_DRIVER":             "postgres",
+				"BROKER_POLL_INTERVAL":      "soon",
+				"BROKER_VISIBILITY_TIMEOUT": "-1s",
+				"BROKER_MAX_DELIVERIES":     "0",
+			},
+			expectedConfig: MessageBrokerConfig{
+				Driver:            "postgres",
+				PollInterval:      500 * time.Millisecond,
+				VisibilityTimeout: 30 * time.Second,
+				MaxDeliveries:     20,
+			},
+			expectedMissingVars: nil,
+			expectedInvalidVars: []string{"BROKER_POLL_INTERVAL", "BROKER_VISIBILITY_TIMEOUT", "BROKER_MAX_DELIVERIES"},
+		},
+		{
+			name: "when driver is unknown it should use rabbitmq and track invalid variables",
+			envVars: map[string]string{
+				"BROKER_DRIVER":   "kafka",
+				"BROKER_HOST":     "rabbitmq",
+				"BROKER_PORT":     "5672",
+				"BROKER_USER":     "guest",
+				"BROKER_PASSWORD": "guest",
+			},
+			expectedConfig: MessageBrokerConfig{
+				Driver:   "rabbitmq",
+				Host:     "rabbitmq",
+				Port:     "5672",
+				User:     "guest",
+				Password: "guest",
+			},
+			expectedMissingVars: nil,
+			expectedInvalidVars: []string{"BROKER_DRIVER"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var missingVars []string
+			var invalidVars []string
+
+			for _, key := range messageBrokerVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range messageBrokerVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadMessageBrokerConfig(&missingVars, &invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedMissingVars, missingVars)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// ProcessedMessageConfig holds the consumer message deduplication configuration
+type ProcessedMessageConfig struct {
+	TTL             time.Duration // How long processed messages are kept, redeliveries after it are handled again
+	CleanupInterval time.Duration // How often the job deletes the processed messages older than the TTL
+}
+
+const (
+	defaultProcessedMessageTTL             = 7 * 24 * time.Hour
+	defaultProcessedMessageCleanupInterval = time.Hour
+)
+
+// loadProcessedMessageConfig reads consumer message deduplication configuration from environment variables
+func loadProcessedMessageConfig(invalidVars *[]string) ProcessedMessageConfig {
+	return ProcessedMessageConfig{
+		TTL:             getDurationEnv("PROCESSED_MESSAGE_TTL", defaultProcessedMessageTTL, invalidVars),
+		CleanupInterval: getDurationEnv("PROCESSED_MESSAGE_CLEANUP_INTERVAL", defaultProcessedMessageCleanupInterval, invalidVars),
+	}
+}
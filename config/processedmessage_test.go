@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadProcessedMessageConfig(t *testing.T) {
+	processedMessageVars := []string{
+		"PROCESSED_MESSAGE_TTL",
+		"PROCESSED_MESSAGE_CLEANUP_INTERVAL",
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      ProcessedMessageConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:    "when no environment variables are set it should return default values and no invalid variables",
+			envVars: map[string]string{},
+			expectedConfig: ProcessedMessageConfig{
+				TTL:             7 * 24 * time.Hour,
+				CleanupInterval: time.Hour,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all environment variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"PROCESSED_MESSAGE_TTL":              "24h",
+				"PROCESSED_MESSAGE_CLEANUP_INTERVAL": "10m",
+			},
+			expectedConfig: ProcessedMessageConfig{
+				TTL:             24 * time.Hour,
+				CleanupInterval: 10 * time.Minute,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when ttl is invalid it should return default values and track invalid variables",
+			envVars: map[string]string{
+				"PROCESSED_MESSAGE_TTL": "forever",
+			},
+			expectedConfig: ProcessedMessageConfig{
+				TTL:             7 * 24 * time.Hour,
+				CleanupInterval: time.Hour,
+			},
+			expectedInvalidVars: []string{"PROCESSED_MESSAGE_TTL"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range processedMessageVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range processedMessageVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadProcessedMessageConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
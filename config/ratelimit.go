@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// RateLimitConfig holds the rate limiting configuration
+type RateLimitConfig struct {
+	Backend         string        // Where the token buckets are stored, memory (per replica) or postgres (shared)
+	Rules           string        // Comma separated "<route>=<key>:<limit>/<period>" rules, "*" applies to routes without a rule
+	CleanupInterval time.Duration // How often full buckets are deleted from Postgres
+}
+
+const (
+	defaultRateLimitBackend         = "memory"
+	defaultRateLimitRules           = "POST /api/v1/payments=user:10/1m,*=principal:300/1m"
+	defaultRateLimitCleanupInterval = 10 * time.Minute
+)
+
+// loadRateLimitConfig reads rate limiting configuration from environment variables
+func loadRateLimitConfig(invalidVars *[]string) RateLimitConfig {
+	backend := os.Getenv("RATE_LIMIT_BACKEND")
+	switch backend {
+	case "":
+		backend = defaultRateLimitBackend
+	case "memory", "postgres":
+	default:
+		*invalidVars = append(*invalidVars, "RATE_LIMIT_BACKEND")
+		backend = defaultRateLimitBackend
+	}
+
+	rules := os.Getenv("RATE_LIMIT_RULES")
+	if rules == "" {
+		rules = defaultRateLimitRules
+	}
+
+	return RateLimitConfig{
+		Backend:         backend,
+		Rules:           rules,
+		CleanupInterval: getDurationEnv("RATE_LIMIT_CLEANUP_INTERVAL", defaultRateLimitCleanupInterval, invalidVars),
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRateLimitConfig(t *testing.T) {
+	rateLimitVars := []string{
+		"RATE_LIMIT_BACKEND",
+		"RATE_LIMIT_RULES",
+		"RATE_LIMIT_CLEANUP_INTERVAL",
+	}
+
+	defaultConfig := RateLimitConfig{
+		Backend:         "memory",
+		Rules:           "POST /api/v1/payments=user:10/1m,*=principal:300/1m",
+		CleanupInterval: 10 * time.Minute,
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      RateLimitConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:                "when no environment variables are set it should return default values and no invalid variables",
+			envVars:             map[string]string{},
+			expectedConfig:      defaultConfig,
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"RATE_LIMIT_BACKEND":          "postgres",
+				"RATE_LIMIT_RULES":            "*=ip:100/1s",
+				"RATE_LIMIT_CLEANUP_INTERVAL": "1h",
+			},
+			expectedConfig: RateLimitConfig{
+				Backend:         "postgres",
+				Rules:           "*=ip:100/1s",
+				CleanupInterval: time.Hour,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when backend is unknown it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"RATE_LIMIT_BACKEND": "redis",
+			},
+			expectedConfig:      defaultConfig,
+			expectedInvalidVars: []string{"RATE_LIMIT_BACKEND"},
+		},
+		{
+			name: "when cleanup interval is not a valid duration it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"RATE_LIMIT_CLEANUP_INTERVAL": "often",
+			},
+			expectedConfig:      defaultConfig,
+			expectedInvalidVars: []string{"RATE_LIMIT_CLEANUP_INTERVAL"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range rateLimitVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range rateLimitVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadRateLimitConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package config
+
+import "time"
+
+// RecoveryConfig holds the recovery job configuration
+type RecoveryConfig struct {
+	Interval    time.Duration // How often the recovery job looks for orphaned payments
+	Threshold   time.Duration // Time without new events after which a reserved payment is considered orphaned
+	MaxAttempts int           // Republish attempts before the payment is failed and its funds released
+	BatchSize   int           // Maximum number of orphaned payments handled per run
+	Timeout     time.Duration // Maximum duration of a single run
+}
+
+const (
+	defaultRecoveryInterval    = 5 * time.Minute
+	defaultRecoveryThreshold   = 10 * time.Minute
+	defaultRecoveryMaxAttempts = 3
+	defaultRecoveryBatchSize   = 100
+	defaultRecoveryTimeout     = time.Minute
+)
+
+// loadRecoveryConfig reads recovery job configuration from environment variables
+func loadRecoveryConfig(invalidVars *[]string) RecoveryConfig {
+	return RecoveryConfig{
+		Interval:    getDurationEnv("RECOVERY_INTERVAL", defaultRecoveryInterval, invalidVars),
+		Threshold:   getDurationEnv("RECOVERY_THRESHOLD", defaultRecoveryThreshold, invalidVars),
+		MaxAttempts: getIntEnv("RECOVERY_MAX_ATTEMPTS", defaultRecoveryMaxAttempts, invalidVars),
+		BatchSize:   getIntEnv("RECOVERY_BATCH_SIZE", defaultRecoveryBatchSize, invalidVars),
+		Timeout:     getDurationEnv("RECOVERY_TIMEOUT", defaultRecoveryTimeout, invalidVars),
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRecoveryConfig(t *testing.T) {
+	recoveryVars := []string{
+		"RECOVERY_INTERVAL",
+		"RECOVERY_THRESHOLD",
+		"RECOVERY_MAX_ATTEMPTS",
+		"RECOVERY_BATCH_SIZE",
+		"RECOVERY_TIMEOUT",
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      RecoveryConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:    "when no environment variables are set it should return default values and no invalid variables",
+			envVars: map[string]string{},
+			expectedConfig: RecoveryConfig{
+				Interval:    5 * time.Minute,
+				Threshold:   10 * time.Minute,
+				MaxAttempts: 3,
+				BatchSize:   100,
+				Timeout:     time.Minute,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all environment variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"RECOVERY_INTERVAL":     "1m",
+				"RECOVERY_THRESHOLD":    "30s",
+				"RECOVERY_MAX_ATTEMPTS": "5",
+				"RECOVERY_BATCH_SIZE":   "10",
+				"RECOVERY_TIMEOUT":      "30s",
+			},
+			expectedConfig: RecoveryConfig{
+				Interval:    time.Minute,
+				Threshold:   30 * time.Second,
+				MaxAttempts: 5,
+				BatchSize:   10,
+				Timeout:     30 * time.Second,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when duration is invalid it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"RECOVERY_INTERVAL": "five minutes",
+			},
+			expectedConfig: RecoveryConfig{
+				Interval:    5 * time.Minute,
+				Threshold:   10 * time.Minute,
+				MaxAttempts: 3,
+				BatchSize:   100,
+				Timeout:     time.Minute,
+			},
+			expectedInvalidVars: []string{"RECOVERY_INTERVAL"},
+		},
+		{
+			name: "when integers are not positive it should return default values and track invalid variables",
+			envVars: map[string]string{
+				"RECOVERY_MAX_ATTEMPTS": "0",
+				"RECOVERY_BATCH_SIZE":   "-1",
+			},
+			expectedConfig: RecoveryConfig{
+				Interval:    5 * time.Minute,
+				Threshold:   10 * time.Minute,
+				MaxAttempts: 3,
+				BatchSize:   100,
+				Timeout:     time.Minute,
+			},
+			expectedInvalidVars: []string{"RECOVERY_MAX_ATTEMPTS", "RECOVERY_BATCH_SIZE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range recoveryVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range recoveryVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadRecoveryConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
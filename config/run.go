@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// RunConfig holds the configuration of the run modes, flags of the serve-api, consume, jobs and all commands override it
+type RunConfig struct {
+	Port            string        // Port the API listens on
+	ConsumerWorkers int           // Workers of the payment processor consumer
+	ShutdownTimeout time.Duration // Time in-flight requests, messages and job runs get to finish on shutdown
+}
+
+const (
+	defaultPort            = "3000"
+	defaultConsumerWorkers = 3
+	defaultShutdownTimeout = 30 * time.Second
+)
+
+// loadRunConfig reads run mode configuration from environment variables
+func loadRunConfig(invalidVars *[]string) RunConfig {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = defaultPort
+	} else if !ValidPort(port) {
+		*invalidVars = append(*invalidVars, "PORT")
+		port = defaultPort
+	}
+
+	return RunConfig{
+		Port:            port,
+		ConsumerWorkers: getIntEnv("CONSUMER_WORKERS", defaultConsumerWorkers, invalidVars),
+		ShutdownTimeout: getDurationEnv("SHUTDOWN_TIMEOUT", defaultShutdownTimeout, invalidVars),
+	}
+}
+
+// ValidPort reports whether port is a TCP port number between 1 and 65535
+func ValidPort(port string) bool {
+	number, err := strconv.Atoi(port)
+	return err == nil && number > 0 && number <= 65535
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRunConfig(t *testing.T) {
+	runVars := []string{
+		"PORT",
+		"CONSUMER_WORKERS",
+		"SHUTDOWN_TIMEOUT",
+	}
+
+	defaultConfig := RunConfig{
+		Port:            "3000",
+		ConsumerWorkers: 3,
+		ShutdownTimeout: 30 * time.Second,
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      RunConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:                "when no environment variables are set it should return default values and no invalid variables",
+			envVars:             map[string]string{},
+			expectedConfig:      defaultConfig,
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all environment variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"PORT":             "8080",
+				"CONSUMER_WORKERS": "10",
+				"SHUTDOWN_TIMEOUT": "1m",
+			},
+			expectedConfig: RunConfig{
+				Port:            "8080",
+				ConsumerWorkers: 10,
+				ShutdownTimeout: time.Minute,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when port is not a number it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"PORT": "http",
+			},
+			expectedConfig:      defaultConfig,
+			expectedInvalidVars: []string{"PORT"},
+		},
+		{
+			name: "when port is out of range it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"PORT": "70000",
+			},
+			expectedConfig:      defaultConfig,
+			expectedInvalidVars: []string{"PORT"},
+		},
+		{
+			name: "when consumer workers and shutdown timeout are invalid it should return default values and track invalid variables",
+			envVars: map[string]string{
+				"CONSUMER_WORKERS": "0",
+				"SHUTDOWN_TIMEOUT": "soon",
+			},
+			expectedConfig:      defaultConfig,
+			expectedInvalidVars: []string{"CONSUMER_WORKERS", "SHUTDOWN_TIMEOUT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range runVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range runVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadRunConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// SchedulerConfig holds the job scheduler configuration
+type SchedulerConfig struct {
+	HolderID string        // Identity of this replica in leader election and run history
+	LeaseTTL time.Duration // Time the scheduler lease is held without renewal
+	Jitter   time.Duration // Maximum random delay added to every job interval, 0 disables it
+}
+
+const (
+	defaultSchedulerLeaseTTL = 15 * time.Second
+	defaultSchedulerJitter   = 10 * time.Second
+)
+
+// loadSchedulerConfig reads job scheduler configuration from environment variables
+func loadSchedulerConfig(invalidVars *[]string) SchedulerConfig {
+	holderID := os.Getenv("SCHEDULER_HOLDER_ID")
+	if holderID == "" {
+		holderID = defaultHolderID()
+	}
+
+	return SchedulerConfig{
+		HolderID: holderID,
+		LeaseTTL: getDurationEnv("SCHEDULER_LEASE_TTL", defaultSchedulerLeaseTTL, invalidVars),
+		Jitter:   getNonNegativeDurationEnv("SCHEDULER_JITTER", defaultSchedulerJitter, invalidVars),
+	}
+}
+
+// defaultHolderID identifies the replica by hostname and process ID
+func defaultHolderID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSchedulerConfig(t *testing.T) {
+	schedulerVars := []string{
+		"SCHEDULER_HOLDER_ID",
+		"SCHEDULER_LEASE_TTL",
+		"SCHEDULER_JITTER",
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      SchedulerConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:    "when no environment variables are set it should return default values and no invalid variables",
+			envVars: map[string]string{},
+			expectedConfig: SchedulerConfig{
+				HolderID: defaultHolderID(),
+				LeaseTTL: 15 * time.Second,
+				Jitter:   10 * time.Second,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all environment variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"SCHEDULER_HOLDER_ID": "replica-1",
+				"SCHEDULER_LEASE_TTL": "30s",
+				"SCHEDULER_JITTER":    "1s",
+			},
+			expectedConfig: SchedulerConfig{
+				HolderID: "replica-1",
+				LeaseTTL: 30 * time.Second,
+				Jitter:   time.Second,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when lease TTL is invalid it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"SCHEDULER_HOLDER_ID": "replica-1",
+				"SCHEDULER_LEASE_TTL": "-5s",
+			},
+			expectedConfig: SchedulerConfig{
+				HolderID: "replica-1",
+				LeaseTTL: 15 * time.Second,
+				Jitter:   10 * time.Second,
+			},
+			expectedInvalidVars: []string{"SCHEDULER_LEASE_TTL"},
+		},
+		{
+			name: "when jitter is zero it should disable it and not track it as invalid",
+			envVars: map[string]string{
+				"SCHEDULER_HOLDER_ID": "replica-1",
+				"SCHEDULER_JITTER":    "0",
+			},
+			expectedConfig: SchedulerConfig{
+				HolderID: "replica-1",
+				LeaseTTL: 15 * time.Second,
+				Jitter:   0,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when jitter is negative it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"SCHEDULER_HOLDER_ID": "replica-1",
+				"SCHEDULER_JITTER":    "-1s",
+			},
+			expectedConfig: SchedulerConfig{
+				HolderID: "replica-1",
+				LeaseTTL: 15 * time.Second,
+				Jitter:   10 * time.Second,
+			},
+			expectedInvalidVars: []string{"SCHEDULER_JITTER"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range schedulerVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range schedulerVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadSchedulerConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// SettlementReconciliationConfig holds the settlement reconciliation job configuration
+type SettlementReconciliationConfig struct {
+	Dir      string        // Local directory the gateway settlement reports are dropped into
+	Interval time.Duration // How often the directory is scanned for new reports
+	Grace    time.Duration // Time after completion the gateway has to settle a payment before it is missing
+	Lookback time.Duration // Time after completion after which a payment is no longer expected
+	Timeout  time.Duration // Maximum duration of a single run
+}
+
+const (
+	defaultSettlementDir                    = "settlements"
+	defaultSettlementReconciliationInterval = time.Hour
+	defaultSettlementReconciliationGrace    = 48 * time.Hour
+	defaultSettlementReconciliationLookback = 30 * 24 * time.Hour
+	defaultSettlementReconciliationTimeout  = 5 * time.Minute
+)
+
+// loadSettlementReconciliationConfig reads settlement reconciliation job configuration from environment variables
+func loadSettlementReconciliationConfig(invalidVars *[]string) SettlementReconciliationConfig {
+	dir := os.Getenv("SETTLEMENT_DIR")
+	if dir == "" {
+		dir = defaultSettlementDir
+	}
+
+	return SettlementReconciliationConfig{
+		Dir:      dir,
+		Interval: getDurationEnv("SETTLEMENT_RECONCILIATION_INTERVAL", defaultSettlementReconciliationInterval, invalidVars),
+		Grace:    getDurationEnv("SETTLEMENT_RECONCILIATION_GRACE", defaultSettlementReconciliationGrace, invalidVars),
+		Lookback: getDurationEnv("SETTLEMENT_RECONCILIATION_LOOKBACK", defaultSettlementReconciliationLookback, invalidVars),
+		Timeout:  getDurationEnv("SETTLEMENT_RECONCILIATION_TIMEOUT", defaultSettlementReconciliationTimeout, invalidVars),
+	}
+}
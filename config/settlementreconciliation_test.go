@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSettlementReconciliationConfig(t *testing.T) {
+	settlementReconciliationVars := []string{
+		"SETTLEMENT_DIR",
+		"SETTLEMENT_RECONCILIATION_INTERVAL",
+		"SETTLEMENT_RECONCILIATION_GRACE",
+		"SETTLEMENT_RECONCILIATION_LOOKBACK",
+		"SETTLEMENT_RECONCILIATION_TIMEOUT",
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      SettlementReconciliationConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:    "when no environment variables are set it should return default values and no invalid variables",
+			envVars: map[string]string{},
+			expectedConfig: SettlementReconciliationConfig{
+				Dir:      "settlements",
+				Interval: time.Hour,
+				Grace:    48 * time.Hour,
+				Lookback: 720 * time.Hour,
+				Timeout:  5 * time.Minute,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all environment variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"SETTLEMENT_DIR":                     "/var/settlements",
+				"SETTLEMENT_RECONCILIATION_INTERVAL": "15m",
+				"SETTLEMENT_RECONCILIATION_GRACE":    "24h",
+				"SETTLEMENT_RECONCILIATION_LOOKBACK": "168h",
+				"SETTLEMENT_RECONCILIATION_TIMEOUT":  "1m",
+			},
+			expectedConfig: SettlementReconciliationConfig{
+				Dir:      "/var/settlements",
+				Interval: 15 * time.Minute,
+				Grace:    24 * time.Hour,
+				Lookback: 168 * time.Hour,
+				Timeout:  time.Minute,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when duration is invalid it should return default value and track invalid variable",
+			envVars: map[string]string{
+				"SETTLEMENT_RECONCILIATION_GRACE": "two days",
+			},
+			expectedConfig: SettlementReconciliationConfig{
+				Dir:      "settlements",
+				Interval: time.Hour,
+				Grace:    48 * time.Hour,
+				Lookback: 720 * time.Hour,
+				Timeout:  5 * time.Minute,
+			},
+			expectedInvalidVars: []string{"SETTLEMENT_RECONCILIATION_GRACE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range settlementReconciliationVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range settlementReconciliationVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadSettlementReconciliationConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
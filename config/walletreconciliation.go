@@ -0,0 +1,34 @@
+package config
+
+import "time"
+
+// WalletReconciliationConfig holds the wallet reconciliation job configuration
+type WalletReconciliationConfig struct {
+	Interval          time.Duration // How often payments are compared with their wallet operations
+	MinAge            time.Duration // Time since the last update before a payment is checked, so in-flight payments are left alone
+	Lookback          time.Duration // Time since the last update after which a payment is no longer checked
+	ProcessingTimeout time.Duration // Time a payment may wait for its gateway callback before it is reported as a discrepancy
+	BatchSize         int           // Payments loaded per page
+	Timeout           time.Duration // Maximum duration of a single run
+}
+
+const (
+	defaultWalletReconciliationInterval          = 15 * time.Minute
+	defaultWalletReconciliationMinAge            = 5 * time.Minute
+	defaultWalletReconciliationLookback          = 24 * time.Hour
+	defaultWalletReconciliationProcessingTimeout = 30 * time.Minute
+	defaultWalletReconciliationBatchSize         = 200
+	defaultWalletReconciliationTimeout           = 5 * time.Minute
+)
+
+// loadWalletReconciliationConfig reads wallet reconciliation job configuration from environment variables
+func loadWalletReconciliationConfig(invalidVars *[]string) WalletReconciliationConfig {
+	return WalletReconciliationConfig{
+		Interval:          getDurationEnv("WALLET_RECONCILIATION_INTERVAL", defaultWalletReconciliationInterval, invalidVars),
+		MinAge:            getDurationEnv("WALLET_RECONCILIATION_MIN_AGE", defaultWalletReconciliationMinAge, invalidVars),
+		Lookback:          getDurationEnv("WALLET_RECONCILIATION_LOOKBACK", defaultWalletReconciliationLookback, invalidVars),
+		ProcessingTimeout: getDurationEnv("WALLET_RECONCILIATION_PROCESSING_TIMEOUT", defaultWalletReconciliationProcessingTimeout, invalidVars),
+		BatchSize:         getIntEnv("WALLET_RECONCILIATION_BATCH_SIZE", defaultWalletReconciliationBatchSize, invalidVars),
+		Timeout:           getDurationEnv("WALLET_RECONCILIATION_TIMEOUT", defaultWalletReconciliationTimeout, invalidVars),
+	}
+}
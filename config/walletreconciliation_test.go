@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadWalletReconciliationConfig(t *testing.T) {
+	walletReconciliationVars := []string{
+		"WALLET_RECONCILIATION_INTERVAL",
+		"WALLET_RECONCILIATION_MIN_AGE",
+		"WALLET_RECONCILIATION_LOOKBACK",
+		"WALLET_RECONCILIATION_PROCESSING_TIMEOUT",
+		"WALLET_RECONCILIATION_BATCH_SIZE",
+		"WALLET_RECONCILIATION_TIMEOUT",
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      WalletReconciliationConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:    "when no environment variables are set it should return default values and no invalid variables",
+			envVars: map[string]string{},
+			expectedConfig: WalletReconciliationConfig{
+				Interval:          15 * time.Minute,
+				MinAge:            5 * time.Minute,
+				Lookback:          24 * time.Hour,
+				ProcessingTimeout: 30 * time.Minute,
+				BatchSize:         200,
+				Timeout:           5 * time.Minute,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all environment variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"WALLET_RECONCILIATION_INTERVAL":           "1h",
+				"WALLET_RECONCILIATION_MIN_AGE":            "10m",
+				"WALLET_RECONCILIATION_LOOKBACK":           "48h",
+				"WALLET_RECONCILIATION_PROCESSING_TIMEOUT": "2h",
+				"WALLET_RECONCILIATION_BATCH_SIZE":         "50",
+				"WALLET_RECONCILIATION_TIMEOUT":            "1m",
+			},
+			expectedConfig: WalletReconciliationConfig{
+				Interval:          time.Hour,
+				MinAge:            10 * time.Minute,
+				Lookback:          48 * time.Hour,
+				ProcessingTimeout: 2 * time.Hour,
+				BatchSize:         50,
+				Timeout:           time.Minute,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when values are invalid it should return default values and track invalid variables",
+			envVars: map[string]string{
+				"WALLET_RECONCILIATION_LOOKBACK":           "one day",
+				"WALLET_RECONCILIATION_PROCESSING_TIMEOUT": "0s",
+				"WALLET_RECONCILIATION_BATCH_SIZE":         "0",
+			},
+			expectedConfig: WalletReconciliationConfig{
+				Interval:          15 * time.Minute,
+				MinAge:            5 * time.Minute,
+				Lookback:          24 * time.Hour,
+				ProcessingTimeout: 30 * time.Minute,
+				BatchSize:         200,
+				Timeout:           5 * time.Minute,
+			},
+			expectedInvalidVars: []string{"WALLET_RECONCILIATION_LOOKBACK", "WALLET_RECONCILIATION_PROCESSING_TIMEOUT", "WALLET_RECONCILIATION_BATCH_SIZE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range walletReconciliationVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range walletReconciliationVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadWalletReconciliationConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package config
+
+import "time"
+
+// WebhookConfig holds the outbound webhook delivery configuration
+type WebhookConfig struct {
+	Interval       time.Duration // How often the job sends the deliveries that are due
+	Timeout        time.Duration // Maximum duration of a single run
+	RequestTimeout time.Duration // Maximum duration of a single request to a subscriber
+	BatchSize      int           // Maximum number of deliveries sent per run
+	MaxAttempts    int           // Attempts before a delivery is failed
+	RetryBaseDelay time.Duration // Delay before the second attempt, doubled after every failure
+	RetryMaxDelay  time.Duration // Longest delay between two attempts
+}
+
+const (
+	defaultWebhookInterval       = 5 * time.Second
+	defaultWebhookTimeout        = time.Minute
+	defaultWebhookRequestTimeout = 10 * time.Second
+	defaultWebhookBatchSize      = 100
+	defaultWebhookMaxAttempts    = 8
+	defaultWebhookRetryBaseDelay = 30 * time.Second
+	defaultWebhookRetryMaxDelay  = time.Hour
+)
+
+// loadWebhookConfig reads outbound webhook delivery configuration from environment variables
+func loadWebhookConfig(invalidVars *[]string) WebhookConfig {
+	return WebhookConfig{
+		Interval:       getDurationEnv("WEBHOOK_DELIVERY_INTERVAL", defaultWebhookInterval, invalidVars),
+		Timeout:        getDurationEnv("WEBHOOK_DELIVERY_TIMEOUT", defaultWebhookTimeout, invalidVars),
+		RequestTimeout: getDurationEnv("WEBHOOK_REQUEST_TIMEOUT", defaultWebhookRequestTimeout, invalidVars),
+		BatchSize:      getIntEnv("WEBHOOK_BATCH_SIZE", defaultWebhookBatchSize, invalidVars),
+		MaxAttempts:    getIntEnv("WEBHOOK_MAX_ATTEMPTS", defaultWebhookMaxAttempts, invalidVars),
+		RetryBaseDelay: getDurationEnv("WEBHOOK_RETRY_BASE_DELAY", defaultWebhookRetryBaseDelay, invalidVars),
+		RetryMaxDelay:  getDurationEnv("WEBHOOK_RETRY_MAX_DELAY", defaultWebhookRetryMaxDelay, invalidVars),
+	}
+}
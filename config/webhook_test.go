@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadWebhookConfig(t *testing.T) {
+	webhookVars := []string{
+		"WEBHOOK_DELIVERY_INTERVAL",
+		"WEBHOOK_DELIVERY_TIMEOUT",
+		"WEBHOOK_REQUEST_TIMEOUT",
+		"WEBHOOK_BATCH_SIZE",
+		"WEBHOOK_MAX_ATTEMPTS",
+		"WEBHOOK_RETRY_BASE_DELAY",
+		"WEBHOOK_RETRY_MAX_DELAY",
+	}
+
+	tests := []struct {
+		name                string
+		envVars             map[string]string
+		expectedConfig      WebhookConfig
+		expectedInvalidVars []string
+	}{
+		{
+			name:    "when no environment variables are set it should return default values and no invalid variables",
+			envVars: map[string]string{},
+			expectedConfig: WebhookConfig{
+				Interval:       5 * time.Second,
+				Timeout:        time.Minute,
+				RequestTimeout: 10 * time.Second,
+				BatchSize:      100,
+				MaxAttempts:    8,
+				RetryBaseDelay: 30 * time.Second,
+				RetryMaxDelay:  time.Hour,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when all environment variables are set it should return custom values and no invalid variables",
+			envVars: map[string]string{
+				"WEBHOOK_DELIVERY_INTERVAL": "1s",
+				"WEBHOOK_DELIVERY_TIMEOUT":  "30s",
+				"WEBHOOK_REQUEST_TIMEOUT":   "3s",
+				"WEBHOOK_BATCH_SIZE":        "20",
+				"WEBHOOK_MAX_ATTEMPTS":      "5",
+				"WEBHOOK_RETRY_BASE_DELAY":  "10s",
+				"WEBHOOK_RETRY_MAX_DELAY":   "10m",
+			},
+			expectedConfig: WebhookConfig{
+				Interval:       time.Second,
+				Timeout:        30 * time.Second,
+				RequestTimeout: 3 * time.Second,
+				BatchSize:      20,
+				MaxAttempts:    5,
+				RetryBaseDelay: 10 * time.Second,
+				RetryMaxDelay:  10 * time.Minute,
+			},
+			expectedInvalidVars: nil,
+		},
+		{
+			name: "when max attempts and retry delay are invalid it should return default values and track invalid variables",
+			envVars: map[string]string{
+				"WEBHOOK_MAX_ATTEMPTS":     "0",
+				"WEBHOOK_RETRY_BASE_DELAY": "soon",
+			},
+			expectedConfig: WebhookConfig{
+				Interval:       5 * time.Second,
+				Timeout:        time.Minute,
+				RequestTimeout: 10 * time.Second,
+				BatchSize:      100,
+				MaxAttempts:    8,
+				RetryBaseDelay: 30 * time.Second,
+				RetryMaxDelay:  time.Hour,
+			},
+			expectedInvalidVars: []string{"WEBHOOK_MAX_ATTEMPTS", "WEBHOOK_RETRY_BASE_DELAY"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var invalidVars []string
+
+			for _, key := range webhookVars {
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+
+			defer func() {
+				for _, key := range webhookVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			// Act
+			result := loadWebhookConfig(&invalidVars)
+
+			// Assert
+			assert.Equal(t, tt.expectedConfig, result)
+			assert.Equal(t, tt.expectedInvalidVars, invalidVars)
+		})
+	}
+}
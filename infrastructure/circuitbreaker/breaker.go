@@ -0,0 +1,339 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned without running the call while the breaker is open
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the state of a circuit breaker
+type State string
+
+const (
+	StateClosed   State = "closed"    // Calls run and their failures are tracked
+	StateOpen     State = "open"      // Calls are rejected until the cooldown elapses
+	StateHalfOpen State = "half_open" // A limited number of probe calls decide whether to close again
+)
+
+const (
+	windowBuckets = 10 // Buckets the failure-rate window is split into
+)
+
+// Config configures a circuit breaker
+type Config struct {
+	Name        string        // Name of the guarded dependency, used in logs, health and metrics
+	Window      time.Duration // Rolling window the failure rate is computed over
+	MinRequests int           // Calls in the window before the failure rate can open the breaker
+	FailureRate float64       // Failure rate in the window that opens the breaker, between 0 and 1
+	Cooldown    time.Duration // Time the breaker stays open before letting probes through
+	Probes      int           // Successful probes needed to close the breaker again
+
+	// IsFailure reports whether an error returned by a call counts as a failure of the dependency
+	// Errors it excludes, like business rejections, count as successful calls. Nil counts every error
+	IsFailure func(err error) bool
+}
+
+// Validate validates the circuit breaker configuration
+func (c *Config) Validate() error {
+	if c.Name == "" {
+		return errors.New("name cannot be empty")
+	}
+	if c.Window <= 0 {
+		return errors.New("window must be greater than 0")
+	}
+	if c.MinRequests <= 0 {
+		return errors.New("min requests must be greater than 0")
+	}
+	if c.FailureRate <= 0 || c.FailureRate > 1 {
+		return errors.New("failure rate must be greater than 0 and at most 1")
+	}
+	if c.Cooldown <= 0 {
+		return errors.New("cooldown must be greater than 0")
+	}
+	if c.Probes <= 0 {
+		return errors.New("probes must be greater than 0")
+	}
+	return nil
+}
+
+// Snapshot is a point-in-time view of a circuit breaker, exposed to health checks and metrics
+type Snapshot struct {
+	Name        string     `json:"name"`
+	State       State      `json:"state"`
+	Requests    int        `json:"requests"`     // Calls in the current window
+	Failures    int        `json:"failures"`     // Failed calls in the current window
+	FailureRate float64    `json:"failure_rate"` // Failures over requests in the current window
+	Rejected    int64      `json:"rejected"`     // Calls rejected while open since the process started
+	Opened      int64      `json:"opened"`       // Times the breaker opened since the process started
+	OpenedAt    *time.Time `json:"opened_at,omitempty"`
+}
+
+// bucket counts the calls of a slice of the window
+type bucket struct {
+	start    time.Time
+	requests int
+	failures int
+}
+
+// Breaker is a circuit breaker guarding calls to an external dependency
+// It opens when the failure rate over a rolling window crosses the threshold, rejects calls
+// while open, and lets a limited number of probes through once the cooldown elapses
+type Breaker struct {
+	config Config
+	width  time.Duration
+	now    func() time.Time
+
+	mu          sync.Mutex
+	state       State
+	generation  uint64 // Incremented on every transition, results from a previous state are ignored
+	buckets     [windowBuckets]bucket
+	openedAt    time.Time
+	probing     int // Probes in flight while half-open
+	probesOK    int // Successful probes while half-open
+	rejected    int64
+	openedTotal int64
+}
+
+// New creates a new Breaker
+func New(config Config) (*Breaker, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("circuit breaker: invalid config: %w", err)
+	}
+
+	width := config.Window / windowBuckets
+	if width <= 0 {
+		width = config.Window
+	}
+
+	return &Breaker{
+		config: config,
+		width:  width,
+		now:    time.Now,
+		state:  StateClosed,
+	}, nil
+}
+
+// Name returns the name of the guarded dependency
+func (b *Breaker) Name() string {
+	return b.config.Name
+}
+
+// Execute runs the call through the breaker
+// It returns ErrOpen without running the call while the breaker is open or out of probes
+func (b *Breaker) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	generation, err := b.before()
+	if err != nil {
+		return err
+	}
+
+	err = fn(ctx)
+
+	// A call abandoned by its caller says nothing about the health of the dependency
+	if err != nil && ctx.Err() != nil {
+		b.abandon(generation)
+		return err
+	}
+
+	b.after(generation, !b.failed(err))
+	return err
+}
+
+// failed reports whether the error of a call counts as a failure of the dependency
+func (b *Breaker) failed(err error) bool {
+	if err == nil {
+		return false
+	}
+	if b.config.IsFailure == nil {
+		return true
+	}
+	return b.config.IsFailure(err)
+}
+
+// State returns the current state of the breaker
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expireCooldown(b.now())
+	return b.state
+}
+
+// Snapshot returns a point-in-time view of the breaker
+func (b *Breaker) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.expireCooldown(now)
+	requests, failures := b.totals(now)
+
+	snapshot := Snapshot{
+		Name:     b.config.Name,
+		State:    b.state,
+		Requests: requests,
+		Failures: failures,
+		Rejected: b.rejected,
+		Opened:   b.openedTotal,
+	}
+	if requests > 0 {
+		snapshot.FailureRate = float64(failures) / float64(requests)
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		snapshot.OpenedAt = &openedAt
+	}
+	return snapshot
+}
+
+// before admits or rejects a call, returning the generation the call belongs to
+func (b *Breaker) before() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expireCooldown(b.now())
+
+	switch b.state {
+	case StateOpen:
+		b.rejected++
+		return 0, ErrOpen
+	case StateHalfOpen:
+		if b.probing+b.probesOK >= b.config.Probes {
+			b.rejected++
+			return 0, ErrOpen
+		}
+		b.probing++
+	}
+
+	return b.generation, nil
+}
+
+// after records the result of a call admitted in the given generation
+func (b *Breaker) after(generation uint64, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+
+	now := b.now()
+
+	switch b.state {
+	case StateClosed:
+		b.record(now, success)
+		requests, failures := b.totals(now)
+		if requests >= b.config.MinRequests && float64(failures)/float64(requests) >= b.config.FailureRate {
+			b.open(now)
+		}
+	case StateHalfOpen:
+		b.probing--
+		if !success {
+			b.open(now)
+			return
+		}
+		b.probesOK++
+		if b.probesOK >= b.config.Probes {
+			b.transition(StateClosed)
+			slog.Info("Circuit breaker closed", "breaker", b.config.Name)
+		}
+	}
+}
+
+// abandon releases the probe slot of a call abandoned by its caller
+func (b *Breaker) abandon(generation uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation == b.generation && b.state == StateHalfOpen {
+		b.probing--
+	}
+}
+
+// expireCooldown moves an open breaker to half-open once the cooldown elapses
+func (b *Breaker) expireCooldown(now time.Time) {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.config.Cooldown {
+		b.transition(StateHalfOpen)
+		slog.Info("Circuit breaker half-open", "breaker", b.config.Name)
+	}
+}
+
+// open opens the breaker
+func (b *Breaker) open(now time.Time) {
+	requests, failures := b.totals(now)
+	b.transition(StateOpen)
+	b.openedAt = now
+	b.openedTotal++
+	slog.Warn("Circuit breaker opened", "breaker", b.config.Name, "requests", requests, "failures", failures, "cooldown", b.config.Cooldown)
+}
+
+// transition moves the breaker to the state, starting a new generation with a clean window
+func (b *Breaker) transition(state State) {
+	b.state = state
+	b.generation++
+	b.buckets = [windowBuckets]bucket{}
+	b.probing = 0
+	b.probesOK = 0
+}
+
+// record counts a call in the bucket of the current time
+func (b *Breaker) record(now time.Time, success bool) {
+	start := now.Truncate(b.width)
+	bk := &b.buckets[(start.UnixNano()/int64(b.width))%windowBuckets]
+	if !bk.start.Equal(start) {
+		*bk = bucket{start: start}
+	}
+
+	bk.requests++
+	if !success {
+		bk.failures++
+	}
+}
+
+// totals sums the calls of the buckets still inside the window
+func (b *Breaker) totals(now time.Time) (int, int) {
+	var requests, failures int
+	for _, bk := range b.buckets {
+		if bk.requests > 0 && now.Sub(bk.start) < b.config.Window {
+			requests += bk.requests
+			failures += bk.failures
+		}
+	}
+	return requests, failures
+}
+
+// Registry keeps the circuit breakers of the process for health checks and metrics
+type Registry struct {
+	mu       sync.RWMutex
+	breakers []*Breaker
+}
+
+// NewRegistry creates a new empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a breaker to the registry
+func (r *Registry) Register(b *Breaker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.breakers = append(r.breakers, b)
+}
+
+// Snapshots returns a snapshot of every registered breaker, in registration order
+func (r *Registry) Snapshots() []Snapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshots := make([]Snapshot, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		snapshots = append(snapshots, b.Snapshot())
+	}
+	return snapshots
+}
@@ -0,0 +1,36 @@
+package circuitbreaker
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockBreaker is a mock implementation of Breaker for testing
+// Execute runs the call unless the mock returns an error
+type MockBreaker struct {
+	mock.Mock
+}
+
+// Execute runs the call through the breaker
+func (m *MockBreaker) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	args := m.Called(ctx)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	return fn(ctx)
+}
+
+// MockRegistry is a mock implementation of Registry for testing
+type MockRegistry struct {
+	mock.Mock
+}
+
+// Snapshots returns a snapshot of every registered breaker
+func (m *MockRegistry) Snapshots() []Snapshot {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]Snapshot)
+}
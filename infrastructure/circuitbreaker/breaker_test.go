@@ -0,0 +1,501 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errDependency = errors.New("connection refused")
+	errBusiness   = errors.New("insufficient funds")
+)
+
+// fakeClock is a clock moved by hand, so windows and cooldowns elapse without sleeping
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// call is a call run through the breaker after moving the clock
+type call struct {
+	advance time.Duration
+	err     error
+}
+
+func testConfig() Config {
+	return Config{
+		Name:        "wallet",
+		Window:      time.Minute,
+		MinRequests: 4,
+		FailureRate: 0.5,
+		Cooldown:    30 * time.Second,
+		Probes:      2,
+		IsFailure: func(err error) bool {
+			return !errors.Is(err, errBusiness)
+		},
+	}
+}
+
+// newTestBreaker creates a breaker driven by a fake clock
+func newTestBreaker(t *testing.T, config Config) (*Breaker, *fakeClock) {
+	t.Helper()
+
+	b, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create breaker: %v", err)
+	}
+
+	clock := &fakeClock{now: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)}
+	b.now = clock.Now
+	return b, clock
+}
+
+// run runs the calls through the breaker
+func run(b *Breaker, clock *fakeClock, calls []call) {
+	for _, c := range calls {
+		clock.Advance(c.advance)
+		b.Execute(context.Background(), func(ctx context.Context) error {
+			return c.err
+		})
+	}
+}
+
+// trip opens the breaker with failed calls
+func trip(b *Breaker, clock *fakeClock) {
+	run(b, clock, []call{{err: errDependency}, {err: errDependency}, {err: errDependency}, {err: errDependency}})
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name          string
+		mutate        func(c *Config)
+		expectedError string
+	}{
+		{
+			name:          "when config is valid it should create breaker and no error",
+			mutate:        func(c *Config) {},
+			expectedError: "",
+		},
+		{
+			name:          "when name is empty it should return error",
+			mutate:        func(c *Config) { c.Name = "" },
+			expectedError: "circuit breaker: invalid config: name cannot be empty",
+		},
+		{
+			name:          "when window is zero it should return error",
+			mutate:        func(c *Config) { c.Window = 0 },
+			expectedError: "circuit breaker: invalid config: window must be greater than 0",
+		},
+		{
+			name:          "when min requests is zero it should return error",
+			mutate:        func(c *Config) { c.MinRequests = 0 },
+			expectedError: "circuit breaker: invalid config: min requests must be greater than 0",
+		},
+		{
+			name:          "when failure rate is above 1 it should return error",
+			mutate:        func(c *Config) { c.FailureRate = 1.5 },
+			expectedError: "circuit breaker: invalid config: failure rate must be greater than 0 and at most 1",
+		},
+		{
+			name:          "when cooldown is zero it should return error",
+			mutate:        func(c *Config) { c.Cooldown = 0 },
+			expectedError: "circuit breaker: invalid config: cooldown must be greater than 0",
+		},
+		{
+			name:          "when probes is zero it should return error",
+			mutate:        func(c *Config) { c.Probes = 0 },
+			expectedError: "circuit breaker: invalid config: probes must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			config := testConfig()
+			tt.mutate(&config)
+
+			// Act
+			result, err := New(config)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, StateClosed, result.State())
+			}
+		})
+	}
+}
+
+func TestBreaker_Execute_Closed(t *testing.T) {
+	tests := []struct {
+		name             string
+		calls            []call
+		expectedState    State
+		expectedRequests int
+		expectedFailures int
+		expectedOpened   int64
+	}{
+		{
+			name:             "when every call succeeds it should stay closed",
+			calls:            []call{{}, {}, {}, {}, {}},
+			expectedState:    StateClosed,
+			expectedRequests: 5,
+			expectedFailures: 0,
+		},
+		{
+			name:             "when calls fail below the min requests it should stay closed",
+			calls:            []call{{err: errDependency}, {err: errDependency}, {err: errDependency}},
+			expectedState:    StateClosed,
+			expectedRequests: 3,
+			expectedFailures: 3,
+		},
+		{
+			name:             "when the failure rate reaches the threshold with the min requests it should open",
+			calls:            []call{{}, {}, {err: errDependency}, {err: errDependency}},
+			expectedState:    StateOpen,
+			expectedRequests: 0,
+			expectedFailures: 0,
+			expectedOpened:   1,
+		},
+		{
+			name:             "when the failure rate stays below the threshold it should stay closed",
+			calls:            []call{{}, {}, {}, {err: errDependency}, {}, {err: errDependency}},
+			expectedState:    StateClosed,
+			expectedRequests: 6,
+			expectedFailures: 2,
+		},
+		{
+			name:             "when calls fail with errors excluded by is failure it should count them as successes and stay closed",
+			calls:            []call{{err: errBusiness}, {err: errBusiness}, {err: errBusiness}, {err: errBusiness}},
+			expectedState:    StateClosed,
+			expectedRequests: 4,
+			expectedFailures: 0,
+		},
+		{
+			name: "when failures leave the window it should not count them",
+			calls: []call{
+				{err: errDependency}, {err: errDependency}, {err: errDependency},
+				{advance: 2 * time.Minute}, {}, {err: errDependency},
+			},
+			expectedState:    StateClosed,
+			expectedRequests: 3,
+			expectedFailures: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			b, clock := newTestBreaker(t, testConfig())
+
+			// Act
+			run(b, clock, tt.calls)
+
+			// Assert
+			snapshot := b.Snapshot()
+			assert.Equal(t, tt.expectedState, snapshot.State)
+			assert.Equal(t, tt.expectedRequests, snapshot.Requests)
+			assert.Equal(t, tt.expectedFailures, snapshot.Failures)
+			assert.Equal(t, tt.expectedOpened, snapshot.Opened)
+		})
+	}
+}
+
+func TestBreaker_Execute_WithoutIsFailure(t *testing.T) {
+	// Arrange
+	config := testConfig()
+	config.IsFailure = nil
+	b, clock := newTestBreaker(t, config)
+
+	// Act
+	run(b, clock, []call{{err: errBusiness}, {err: errBusiness}, {err: errBusiness}, {err: errBusiness}})
+
+	// Assert
+	assert.Equal(t, StateOpen, b.State())
+}
+
+func TestBreaker_Execute_Open(t *testing.T) {
+	tests := []struct {
+		name          string
+		advance       time.Duration
+		expectedState State
+		expectedError error
+		expectedRun   bool
+	}{
+		{
+			name:          "when the cooldown has not elapsed it should reject the call without running it",
+			advance:       10 * time.Second,
+			expectedState: StateOpen,
+			expectedError: ErrOpen,
+			expectedRun:   false,
+		},
+		{
+			name:          "when the cooldown elapses it should move to half-open and run the call as a probe",
+			advance:       30 * time.Second,
+			expectedState: StateHalfOpen,
+			expectedError: nil,
+			expectedRun:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			b, clock := newTestBreaker(t, testConfig())
+			trip(b, clock)
+			clock.Advance(tt.advance)
+
+			// Act
+			state := b.State()
+			ran := false
+			err := b.Execute(context.Background(), func(ctx context.Context) error {
+				ran = true
+				return nil
+			})
+
+			// Assert
+			assert.Equal(t, tt.expectedState, state)
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedRun, ran)
+			if !tt.expectedRun {
+				assert.Equal(t, int64(1), b.Snapshot().Rejected)
+			}
+		})
+	}
+}
+
+func TestBreaker_Execute_HalfOpen(t *testing.T) {
+	tests := []struct {
+		name           string
+		probes         []error
+		expectedState  State
+		expectedOpened int64
+	}{
+		{
+			name:           "when a probe succeeds below the probes needed it should stay half-open",
+			probes:         []error{nil},
+			expectedState:  StateHalfOpen,
+			expectedOpened: 1,
+		},
+		{
+			name:           "when the probes needed succeed it should close",
+			probes:         []error{nil, nil},
+			expectedState:  StateClosed,
+			expectedOpened: 1,
+		},
+		{
+			name:           "when a probe fails it should open again",
+			probes:         []error{nil, errDependency},
+			expectedState:  StateOpen,
+			expectedOpened: 2,
+		},
+		{
+			name:           "when a probe fails with an error excluded by is failure it should count as a success",
+			probes:         []error{errBusiness, nil},
+			expectedState:  StateClosed,
+			expectedOpened: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			b, clock := newTestBreaker(t, testConfig())
+			trip(b, clock)
+			clock.Advance(30 * time.Second)
+
+			// Act
+			for _, probe := range tt.probes {
+				b.Execute(context.Background(), func(ctx context.Context) error {
+					return probe
+				})
+			}
+
+			// Assert
+			snapshot := b.Snapshot()
+			assert.Equal(t, tt.expectedState, snapshot.State)
+			assert.Equal(t, tt.expectedOpened, snapshot.Opened)
+		})
+	}
+}
+
+func TestBreaker_Execute_HalfOpenProbeLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		probes        int
+		nested        int
+		expectedError []error
+	}{
+		{
+			name:          "when probes in flight reach the limit it should reject further calls",
+			probes:        1,
+			nested:        1,
+			expectedError: []error{ErrOpen},
+		},
+		{
+			name:          "when probes in flight are below the limit it should let calls through",
+			probes:        3,
+			nested:        2,
+			expectedError: []error{nil, nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			config := testConfig()
+			config.Probes = tt.probes
+			b, clock := newTestBreaker(t, config)
+			trip(b, clock)
+			clock.Advance(30 * time.Second)
+
+			// Act
+			var errs []error
+			b.Execute(context.Background(), func(ctx context.Context) error {
+				// Calls made while the first probe is in flight
+				for i := 0; i < tt.nested; i++ {
+					errs = append(errs, b.Execute(ctx, func(ctx context.Context) error {
+						return nil
+					}))
+				}
+				return nil
+			})
+
+			// Assert
+			assert.Equal(t, tt.expectedError, errs)
+		})
+	}
+}
+
+func TestBreaker_Execute_StaleGeneration(t *testing.T) {
+	tests := []struct {
+		name          string
+		staleErr      error
+		expectedState State
+	}{
+		{
+			name:          "when a call admitted before the breaker opened fails it should not reopen the half-open breaker",
+			staleErr:      errDependency,
+			expectedState: StateHalfOpen,
+		},
+		{
+			name:          "when a call admitted before the breaker opened succeeds it should not count as a probe",
+			staleErr:      nil,
+			expectedState: StateHalfOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			config := testConfig()
+			config.Probes = 1
+			b, clock := newTestBreaker(t, config)
+
+			// Act
+			b.Execute(context.Background(), func(ctx context.Context) error {
+				// The breaker opens and reaches half-open while this call is in flight
+				trip(b, clock)
+				clock.Advance(30 * time.Second)
+				b.State()
+				return tt.staleErr
+			})
+
+			// Assert
+			snapshot := b.Snapshot()
+			assert.Equal(t, tt.expectedState, snapshot.State)
+			assert.Equal(t, int64(1), snapshot.Opened)
+		})
+	}
+}
+
+func TestBreaker_Execute_Abandoned(t *testing.T) {
+	tests := []struct {
+		name          string
+		halfOpen      bool
+		expectedState State
+	}{
+		{
+			name:          "when calls are abandoned by their caller it should not count them",
+			halfOpen:      false,
+			expectedState: StateClosed,
+		},
+		{
+			name:          "when a probe is abandoned by its caller it should release its slot",
+			halfOpen:      true,
+			expectedState: StateHalfOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			config := testConfig()
+			config.Probes = 1
+			b, clock := newTestBreaker(t, config)
+			if tt.halfOpen {
+				trip(b, clock)
+				clock.Advance(30 * time.Second)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			// Act
+			for i := 0; i < 4; i++ {
+				err := b.Execute(ctx, func(ctx context.Context) error {
+					return ctx.Err()
+				})
+				assert.ErrorIs(t, err, context.Canceled)
+			}
+
+			// Assert
+			snapshot := b.Snapshot()
+			assert.Equal(t, tt.expectedState, snapshot.State)
+			assert.Equal(t, 0, snapshot.Requests)
+			assert.Equal(t, int64(0), snapshot.Rejected)
+		})
+	}
+}
+
+func TestRegistry_Snapshots(t *testing.T) {
+	// Arrange
+	wallet, clock := newTestBreaker(t, testConfig())
+	gatewayConfig := testConfig()
+	gatewayConfig.Name = "gateway"
+	gateway, _ := newTestBreaker(t, gatewayConfig)
+	gateway.now = clock.Now
+
+	trip(wallet, clock)
+
+	registry := NewRegistry()
+	registry.Register(wallet)
+	registry.Register(gateway)
+
+	// Act
+	snapshots := registry.Snapshots()
+
+	// Assert
+	assert.Len(t, snapshots, 2)
+	assert.Equal(t, "wallet", snapshots[0].Name)
+	assert.Equal(t, StateOpen, snapshots[0].State)
+	assert.Equal(t, clock.Now(), *snapshots[0].OpenedAt)
+	assert.Equal(t, "gateway", snapshots[1].Name)
+	assert.Equal(t, StateClosed, snapshots[1].State)
+	assert.Nil(t, snapshots[1].OpenedAt)
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Listener fans out the notifications of a PostgreSQL channel to the subscribers of their payload
+// A single connection listens for the whole process, whatever the number of subscribers
+type Listener struct {
+	listener    *pq.Listener
+	channel     string
+	mu          sync.Mutex
+	subscribers map[string]map[chan struct{}]struct{}
+	done        chan struct{}
+}
+
+// NewListener creates a listener on the given channel with a dedicated connection
+// The connection is re-established with backoff when it is lost
+func NewListener(url string, channel string) (*Listener, error) {
+	if url == "" {
+		return nil, errors.New("database listener: url cannot be empty")
+	}
+	if channel == "" {
+		return nil, errors.New("database listener: channel cannot be empty")
+	}
+
+	l := &Listener{
+		channel:     channel,
+		subscribers: make(map[string]map[chan struct{}]struct{}),
+		done:        make(chan struct{}),
+	}
+
+	l.listener = pq.NewListener(url, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Warn("Database listener connection event", "channel", channel, "event", event, "error", err)
+		}
+	})
+
+	if err := l.listener.Listen(channel); err != nil {
+		l.listener.Close()
+		return nil, err
+	}
+
+	go l.run()
+
+	return l, nil
+}
+
+// Subscribe returns a channel signaled when a notification with the given payload arrives, and a function to unsubscribe
+// Signals are coalesced, a subscriber must read the whole state it follows on every signal
+// Every subscriber is also signaled after a reconnection, since notifications may have been missed meanwhile
+func (l *Listener) Subscribe(payload string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	l.mu.Lock()
+	if l.subscribers[payload] == nil {
+		l.subscribers[payload] = make(map[chan struct{}]struct{})
+	}
+	l.subscribers[payload][ch] = struct{}{}
+	l.mu.Unlock()
+
+	unsubscribe := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		delete(l.subscribers[payload], ch)
+		if len(l.subscribers[payload]) == 0 {
+			delete(l.subscribers, payload)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+// Close stops listening and closes the dedicated connection
+func (l *Listener) Close() error {
+	close(l.done)
+	return l.listener.Close()
+}
+
+// run dispatches the notifications until the listener is closed
+// The connection is pinged when idle, so a lost connection is noticed without waiting for a notification
+func (l *Listener) run() {
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case n, ok := <-l.listener.Notify:
+			if !ok {
+				return
+			}
+			// A nil notification means the connection was re-established
+			if n == nil {
+				l.signalAll()
+				continue
+			}
+			l.signal(n.Extra)
+		case <-ping.C:
+			if err := l.listener.Ping(); err != nil {
+				slog.Warn("Database listener ping failed", "channel", l.channel, "error", err)
+			}
+		}
+	}
+}
+
+// signal signals the subscribers of a payload without blocking on the ones already signaled
+func (l *Listener) signal(payload string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for ch := range l.subscribers[payload] {
+		notify(ch)
+	}
+}
+
+// signalAll signals every subscriber
+func (l *Listener) signalAll() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, subscribers := range l.subscribers {
+		for ch := range subscribers {
+			notify(ch)
+		}
+	}
+}
+
+// notify sends a signal unless one is already pending
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -8,9 +8,12 @@ import (
 	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+// uniqueViolationCode is the PostgreSQL error code for a unique constraint violation
+const uniqueViolationCode = "23505"
+
 // RowScanner is an interface for scanning a single row
 type RowScanner interface {
 	Scan(dest ...any) error
@@ -74,6 +77,11 @@ func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (Rows
 	return db.conn.QueryContext(ctx, query, args...)
 }
 
+// ExecContext executes a query that doesn't return rows
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return db.conn.ExecContext(ctx, query, args...)
+}
+
 // Close closes the database connection
 func (db *DB) Close() error {
 	return db.conn.Close()
@@ -119,6 +127,15 @@ func (db *DB) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) er
 	return lastErr
 }
 
+// IsUniqueViolation checks if an error is a unique constraint violation on the given constraint
+func IsUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == uniqueViolationCode && pqErr.Constraint == constraint
+}
+
 // isTransientError checks if an error is transient and can be retried
 func isTransientError(err error) bool {
 	if err == nil {
@@ -27,6 +27,15 @@ func (m *MockDB) QueryContext(ctx context.Context, query string, args ...any) (R
 	return callArgs.Get(0).(Rows), callArgs.Error(1)
 }
 
+// ExecContext executes a query that doesn't return rows
+func (m *MockDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	callArgs := m.Called(ctx, query, args)
+	if callArgs.Get(0) == nil {
+		return nil, callArgs.Error(1)
+	}
+	return callArgs.Get(0).(sql.Result), callArgs.Error(1)
+}
+
 // WithTransaction executes a function within a transaction
 func (m *MockDB) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
 	args := m.Called(ctx, fn)
@@ -0,0 +1,326 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned when a token cannot be trusted
+var ErrInvalidToken = errors.New("invalid token")
+
+// Supported signing algorithms
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+)
+
+// Config defines the keys and the expected claims of the tokens
+type Config struct {
+	HMACKeyFile      string        // File with the shared secret for HS256 tokens, empty disables HS256
+	RSAPublicKeyFile string        // PEM file with the public key for RS256 tokens
+	JWKSFile         string        // JWKS file with public keys for RS256 tokens, selected by the kid header
+	Issuer           string        // Expected iss claim, empty skips the check
+	Audience         string        // Expected aud claim, empty skips the check
+	Leeway           time.Duration // Clock skew tolerated on exp and nbf
+}
+
+// Claims represents the registered claims of a token plus its scopes
+type Claims struct {
+	Subject   string   `json:"sub"`   // Principal the token was issued for
+	Issuer    string   `json:"iss"`   // Party that issued the token
+	Audience  Audience `json:"aud"`   // Recipients the token is intended for
+	ExpiresAt int64    `json:"exp"`   // Unix time after which the token is rejected
+	NotBefore int64    `json:"nbf"`   // Unix time before which the token is rejected
+	Scope     string   `json:"scope"` // Space separated scopes granted to the principal
+}
+
+// Scopes returns the scopes granted by the token
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// Audience represents the aud claim, which can be a single string or a list
+type Audience []string
+
+// UnmarshalJSON accepts both forms of the aud claim
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Contains reports whether the audience includes the given value
+func (a Audience) Contains(value string) bool {
+	for _, audience := range a {
+		if audience == value {
+			return true
+		}
+	}
+	return false
+}
+
+// header represents the JOSE header of a token
+type header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// Verifier verifies the signature and the claims of HS256 and RS256 tokens
+type Verifier struct {
+	hmacKey  []byte
+	rsaKeys  map[string]*rsa.PublicKey // RS256 keys by kid, the PEM key has an empty kid
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// NewVerifier creates a new Verifier loading the configured key files
+// A verifier without keys is valid and rejects every token
+func NewVerifier(cfg Config) (*Verifier, error) {
+	v := &Verifier{
+		rsaKeys:  map[string]*rsa.PublicKey{},
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		leeway:   cfg.Leeway,
+		now:      time.Now,
+	}
+
+	if cfg.HMACKeyFile != "" {
+		key, err := os.ReadFile(cfg.HMACKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("jwt verifier: read hmac key: %w", err)
+		}
+		key = []byte(strings.TrimSpace(string(key)))
+		if len(key) < sha256.Size {
+			return nil, fmt.Errorf("jwt verifier: hmac key must be at least %d bytes", sha256.Size)
+		}
+		v.hmacKey = key
+	}
+
+	if cfg.RSAPublicKeyFile != "" {
+		key, err := loadRSAPublicKey(cfg.RSAPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("jwt verifier: %w", err)
+		}
+		v.rsaKeys[""] = key
+	}
+
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("jwt verifier: %w", err)
+		}
+		for kid, key := range keys {
+			v.rsaKeys[kid] = key
+		}
+	}
+
+	return v, nil
+}
+
+// Verify verifies the token signature and its time, issuer and audience claims
+// It returns the claims and an error wrapping ErrInvalidToken if the token cannot be trusted
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("%w: decode header: %v", ErrInvalidToken, err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode signature: %v", ErrInvalidToken, err)
+	}
+
+	signingInput := parts[0] + "." + parts[1]
+	if err := v.verifySignature(h, signingInput, signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: decode claims: %v", ErrInvalidToken, err)
+	}
+
+	if err := v.verifyClaims(&claims); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+// verifySignature checks the signature with the key matching the algorithm of the header
+// The algorithm is only trusted if a key of that type is configured, so RS256 keys are never used as HMAC secrets
+func (v *Verifier) verifySignature(h header, signingInput string, signature []byte) error {
+	switch h.Algorithm {
+	case AlgorithmHS256:
+		if v.hmacKey == nil {
+			return fmt.Errorf("%w: HS256 is not enabled", ErrInvalidToken)
+		}
+		mac := hmac.New(sha256.New, v.hmacKey)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+		return nil
+	case AlgorithmRS256:
+		key, ok := v.rsaKeys[h.KeyID]
+		if !ok {
+			// The PEM key has no kid, so it verifies any token whose kid is not in the JWKS
+			key, ok = v.rsaKeys[""]
+		}
+		if !ok && h.KeyID == "" && len(v.rsaKeys) == 1 {
+			// A token without kid is accepted when there is a single key to choose from
+			for _, only := range v.rsaKeys {
+				key, ok = only, true
+			}
+		}
+		if !ok {
+			return fmt.Errorf("%w: unknown key %q", ErrInvalidToken, h.KeyID)
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, h.Algorithm)
+	}
+}
+
+// verifyClaims checks the expiration, not before, issuer and audience claims
+func (v *Verifier) verifyClaims(claims *Claims) error {
+	now := v.now()
+
+	if claims.ExpiresAt == 0 {
+		return fmt.Errorf("%w: missing exp claim", ErrInvalidToken)
+	}
+	if now.After(time.Unix(claims.ExpiresAt, 0).Add(v.leeway)) {
+		return fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	if claims.NotBefore != 0 && now.Add(v.leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if v.audience != "" && !claims.Audience.Contains(v.audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+
+	return nil
+}
+
+// decodeSegment decodes a base64url JSON segment of a token
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// loadRSAPublicKey reads an RSA public key from a PEM file in PKIX or PKCS#1 form
+func loadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rsa public key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("rsa public key: no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse rsa public key: %w", err)
+	}
+
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("rsa public key: not an RSA key")
+	}
+
+	return key, nil
+}
+
+// jwk represents a JSON Web Key, only RSA signing keys are used
+type jwk struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+}
+
+// loadJWKS reads the RSA signing keys of a JWKS file by kid
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read jwks: %w", err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, key := range set.Keys {
+		if key.KeyType != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		if key.KeyID == "" {
+			return nil, errors.New("jwks: RSA key without kid")
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwks: decode modulus of %q: %w", key.KeyID, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwks: decode exponent of %q: %w", key.KeyID, err)
+		}
+
+		keys[key.KeyID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,17 @@
+package jwt
+
+import "github.com/stretchr/testify/mock"
+
+// MockVerifier is a mock implementation of Verifier
+type MockVerifier struct {
+	mock.Mock
+}
+
+// Verify verifies a token
+func (m *MockVerifier) Verify(token string) (*Claims, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Claims), args.Error(1)
+}
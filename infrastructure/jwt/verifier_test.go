@@ -0,0 +1,660 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hmacSecret is a shared secret of the minimum accepted length
+const hmacSecret = "0123456789abcdef0123456789abcdef"
+
+// fixedNow is the time the verifiers under test see
+var fixedNow = time.Unix(1700000000, 0)
+
+var (
+	rsaKeyOnce sync.Once
+	rsaKey     *rsa.PrivateKey
+	otherKey   *rsa.PrivateKey
+)
+
+// testRSAKeys returns two RSA keys, generated once for the whole package
+func testRSAKeys(t *testing.T) (*rsa.PrivateKey, *rsa.PrivateKey) {
+	t.Helper()
+
+	rsaKeyOnce.Do(func() {
+		var err error
+		if rsaKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			panic(err)
+		}
+		if otherKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			panic(err)
+		}
+	})
+	return rsaKey, otherKey
+}
+
+// writeFile writes a file in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// pkixPEM encodes a public key as a PKIX PEM block
+func pkixPEM(t *testing.T, key any) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// jwksOf encodes public keys as a JWKS document by kid
+func jwksOf(keys map[string]*rsa.PublicKey) string {
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	for kid, key := range keys {
+		set.Keys = append(set.Keys, jwk{
+			KeyType: "RSA",
+			KeyID:   kid,
+			Use:     "sig",
+			N:       base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	data, _ := json.Marshal(set)
+	return string(data)
+}
+
+// segment encodes a value as a base64url JSON token segment
+func segment(v any) string {
+	data, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// signHS256 builds a token signed with the HMAC secret
+func signHS256(secret []byte, header, claims map[string]any) string {
+	input := segment(header) + "." + segment(claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signRS256 builds a token signed with the RSA key
+func signRS256(t *testing.T, key *rsa.PrivateKey, header, claims map[string]any) string {
+	t.Helper()
+
+	input := segment(header) + "." + segment(claims)
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// validClaims returns claims accepted by a verifier expecting the payments issuer and audience at fixedNow
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":   "user_123",
+		"iss":   "https://auth.example.com",
+		"aud":   "payments-api",
+		"exp":   fixedNow.Add(time.Hour).Unix(),
+		"scope": "payments:read payments:write",
+	}
+}
+
+// withClaims returns the valid claims with the given ones replaced, nil values are removed
+func withClaims(overrides map[string]any) map[string]any {
+	claims := validClaims()
+	for k, v := range overrides {
+		if v == nil {
+			delete(claims, k)
+			continue
+		}
+		claims[k] = v
+	}
+	return claims
+}
+
+func TestNewVerifier(t *testing.T) {
+	key, _ := testRSAKeys(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		hmacKey       string
+		rsaPEM        string
+		jwks          string
+		expectedKeys  int
+		expectedError string
+	}{
+		{
+			name: "when no key is configured it should create a verifier rejecting every token",
+		},
+		{
+			name:    "when the hmac key has the minimum length it should enable HS256 ignoring surrounding whitespace",
+			hmacKey: hmacSecret + "\n",
+		},
+		{
+			name:          "when the hmac key is shorter than the minimum it should return error",
+			hmacKey:       hmacSecret[:31],
+			expectedError: "jwt verifier: hmac key must be at least 32 bytes",
+		},
+		{
+			name:          "when the hmac key is only long enough with whitespace it should return error",
+			hmacKey:       "  " + hmacSecret[:31] + "\n",
+			expectedError: "jwt verifier: hmac key must be at least 32 bytes",
+		},
+		{
+			name:         "when the PEM key is PKIX it should load it",
+			rsaPEM:       pkixPEM(t, &key.PublicKey),
+			expectedKeys: 1,
+		},
+		{
+			name:         "when the PEM key is PKCS#1 it should load it",
+			rsaPEM:       string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)})),
+			expectedKeys: 1,
+		},
+		{
+			name:          "when the PEM file has no PEM block it should return error",
+			rsaPEM:        "not a pem file",
+			expectedError: "jwt verifier: rsa public key: no PEM block found",
+		},
+		{
+			name:          "when the PEM block is not a public key it should return error",
+			rsaPEM:        string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte{0x30, 0x00}})),
+			expectedError: "jwt verifier: parse rsa public key: asn1: syntax error: sequence truncated",
+		},
+		{
+			name:          "when the PEM key is not RSA it should return error",
+			rsaPEM:        pkixPEM(t, &ecKey.PublicKey),
+			expectedError: "jwt verifier: rsa public key: not an RSA key",
+		},
+		{
+			name:         "when the JWKS has RSA signing keys it should load them by kid",
+			jwks:         jwksOf(map[string]*rsa.PublicKey{"key-1": &key.PublicKey, "key-2": &key.PublicKey}),
+			expectedKeys: 2,
+		},
+		{
+			name:         "when the JWKS has encryption or non RSA keys it should skip them",
+			jwks:         `{"keys":[{"kty":"RSA","kid":"enc","use":"enc","n":"AQAB","e":"AQAB"},{"kty":"EC","kid":"ec"}]}`,
+			expectedKeys: 0,
+		},
+		{
+			name:          "when the JWKS is not valid JSON it should return error",
+			jwks:          `{"keys":`,
+			expectedError: "jwt verifier: parse jwks: unexpected end of JSON input",
+		},
+		{
+			name:          "when a JWKS RSA key has no kid it should return error",
+			jwks:          `{"keys":[{"kty":"RSA","n":"AQAB","e":"AQAB"}]}`,
+			expectedError: "jwt verifier: jwks: RSA key without kid",
+		},
+		{
+			name:          "when a JWKS modulus is not base64url it should return error",
+			jwks:          `{"keys":[{"kty":"RSA","kid":"key-1","n":"!!!","e":"AQAB"}]}`,
+			expectedError: `jwt verifier: jwks: decode modulus of "key-1": illegal base64 data at input byte 0`,
+		},
+		{
+			name:          "when a JWKS exponent is not base64url it should return error",
+			jwks:          `{"keys":[{"kty":"RSA","kid":"key-1","n":"AQAB","e":"!!!"}]}`,
+			expectedError: `jwt verifier: jwks: decode exponent of "key-1": illegal base64 data at input byte 0`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var cfg Config
+			if tt.hmacKey != "" {
+				cfg.HMACKeyFile = writeFile(t, "hmac.key", tt.hmacKey)
+			}
+			if tt.rsaPEM != "" {
+				cfg.RSAPublicKeyFile = writeFile(t, "public.pem", tt.rsaPEM)
+			}
+			if tt.jwks != "" {
+				cfg.JWKSFile = writeFile(t, "jwks.json", tt.jwks)
+			}
+
+			// Act
+			verifier, err := NewVerifier(cfg)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, verifier)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, verifier.rsaKeys, tt.expectedKeys)
+				if tt.hmacKey != "" {
+					assert.Equal(t, []byte(hmacSecret), verifier.hmacKey)
+				} else {
+					assert.Nil(t, verifier.hmacKey)
+				}
+			}
+		})
+	}
+}
+
+func TestNewVerifier_MissingFiles(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	tests := []struct {
+		name          string
+		cfg           Config
+		expectedError string
+	}{
+		{
+			name:          "when the hmac key file does not exist it should return error",
+			cfg:           Config{HMACKeyFile: missing},
+			expectedError: "jwt verifier: read hmac key: open " + missing + ": no such file or directory",
+		},
+		{
+			name:          "when the PEM file does not exist it should return error",
+			cfg:           Config{RSAPublicKeyFile: missing},
+			expectedError: "jwt verifier: read rsa public key: open " + missing + ": no such file or directory",
+		},
+		{
+			name:          "when the JWKS file does not exist it should return error",
+			cfg:           Config{JWKSFile: missing},
+			expectedError: "jwt verifier: read jwks: open " + missing + ": no such file or directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			verifier, err := NewVerifier(tt.cfg)
+
+			// Assert
+			assert.Error(t, err)
+			assert.Equal(t, tt.expectedError, err.Error())
+			assert.Nil(t, verifier)
+		})
+	}
+}
+
+func TestVerifier_Verify_Signature(t *testing.T) {
+	key, other := testRSAKeys(t)
+	publicPEM := pkixPEM(t, &key.PublicKey)
+
+	tests := []struct {
+		name          string
+		hmacEnabled   bool
+		pemKey        bool
+		jwks          map[string]*rsa.PublicKey
+		token         func(t *testing.T) string
+		expectedError string
+	}{
+		{
+			name:        "when an HS256 token is signed with the secret it should accept it",
+			hmacEnabled: true,
+			token: func(t *testing.T) string {
+				return signHS256([]byte(hmacSecret), map[string]any{"alg": "HS256", "typ": "JWT"}, validClaims())
+			},
+		},
+		{
+			name:   "when HS256 is disabled it should reject an HS256 token",
+			pemKey: true,
+			token: func(t *testing.T) string {
+				return signHS256([]byte(hmacSecret), map[string]any{"alg": "HS256"}, validClaims())
+			},
+			expectedError: "invalid token: HS256 is not enabled",
+		},
+		{
+			name:   "when an HS256 token is signed with the RSA public key it should reject it",
+			pemKey: true,
+			token: func(t *testing.T) string {
+				return signHS256([]byte(publicPEM), map[string]any{"alg": "HS256"}, validClaims())
+			},
+			expectedError: "invalid token: HS256 is not enabled",
+		},
+		{
+			name:        "when HS256 is enabled and a token is signed with the RSA public key it should reject it",
+			hmacEnabled: true,
+			pemKey:      true,
+			token: func(t *testing.T) string {
+				return signHS256([]byte(publicPEM), map[string]any{"alg": "HS256"}, validClaims())
+			},
+			expectedError: "invalid token: signature mismatch",
+		},
+		{
+			name:        "when the algorithm is none it should reject the unsigned token",
+			hmacEnabled: true,
+			pemKey:      true,
+			token: func(t *testing.T) string {
+				return segment(map[string]any{"alg": "none"}) + "." + segment(validClaims()) + "."
+			},
+			expectedError: `invalid token: unsupported algorithm "none"`,
+		},
+		{
+			name:   "when the algorithm is unsupported it should reject the token",
+			pemKey: true,
+			token: func(t *testing.T) string {
+				return signRS256(t, key, map[string]any{"alg": "RS512"}, validClaims())
+			},
+			expectedError: `invalid token: unsupported algorithm "RS512"`,
+		},
+		{
+			name:   "when an RS256 token without kid is signed with the PEM key it should accept it",
+			pemKey: true,
+			token: func(t *testing.T) string {
+				return signRS256(t, key, map[string]any{"alg": "RS256"}, validClaims())
+			},
+		},
+		{
+			name: "when an RS256 token has the kid of a JWKS key it should verify it with that key",
+			jwks: map[string]*rsa.PublicKey{"key-1": &other.PublicKey, "key-2": &key.PublicKey},
+			token: func(t *testing.T) string {
+				return signRS256(t, key, map[string]any{"alg": "RS256", "kid": "key-2"}, validClaims())
+			},
+		},
+		{
+			name: "when an RS256 token has an unknown kid it should reject it",
+			jwks: map[string]*rsa.PublicKey{"key-1": &key.PublicKey},
+			token: func(t *testing.T) string {
+				return signRS256(t, key, map[string]any{"alg": "RS256", "kid": "key-9"}, validClaims())
+			},
+			expectedError: `invalid token: unknown key "key-9"`,
+		},
+		{
+			name:   "when an RS256 token has a kid missing from the JWKS it should fall back to the PEM key",
+			pemKey: true,
+			jwks:   map[string]*rsa.PublicKey{"key-1": &other.PublicKey},
+			token: func(t *testing.T) string {
+				return signRS256(t, key, map[string]any{"alg": "RS256", "kid": "key-9"}, validClaims())
+			},
+		},
+		{
+			name: "when an RS256 token has no kid and the JWKS has a single key it should use that key",
+			jwks: map[string]*rsa.PublicKey{"key-1": &key.PublicKey},
+			token: func(t *testing.T) string {
+				return signRS256(t, key, map[string]any{"alg": "RS256"}, validClaims())
+			},
+		},
+		{
+			name: "when an RS256 token has no kid and the JWKS has several keys it should reject it",
+			jwks: map[string]*rsa.PublicKey{"key-1": &key.PublicKey, "key-2": &other.PublicKey},
+			token: func(t *testing.T) string {
+				return signRS256(t, key, map[string]any{"alg": "RS256"}, validClaims())
+			},
+			expectedError: `invalid token: unknown key ""`,
+		},
+		{
+			name:   "when an RS256 token is signed with another key it should reject it",
+			pemKey: true,
+			token: func(t *testing.T) string {
+				return signRS256(t, other, map[string]any{"alg": "RS256"}, validClaims())
+			},
+			expectedError: "invalid token: signature mismatch",
+		},
+		{
+			name:   "when the claims were tampered with after signing it should reject the token",
+			pemKey: true,
+			token: func(t *testing.T) string {
+				parts := strings.Split(signRS256(t, key, map[string]any{"alg": "RS256"}, validClaims()), ".")
+				parts[1] = segment(withClaims(map[string]any{"sub": "admin"}))
+				return strings.Join(parts, ".")
+			},
+			expectedError: "invalid token: signature mismatch",
+		},
+		{
+			name:        "when the HS256 signature was tampered with it should reject the token",
+			hmacEnabled: true,
+			token: func(t *testing.T) string {
+				token := signHS256([]byte(hmacSecret), map[string]any{"alg": "HS256"}, validClaims())
+				return token[:len(token)-2] + "AA"
+			},
+			expectedError: "invalid token: signature mismatch",
+		},
+		{
+			name:   "when the token has two segments it should reject it",
+			pemKey: true,
+			token: func(t *testing.T) string {
+				return segment(map[string]any{"alg": "RS256"}) + "." + segment(validClaims())
+			},
+			expectedError: "invalid token: malformed token",
+		},
+		{
+			name:   "when the token has four segments it should reject it",
+			pemKey: true,
+			token: func(t *testing.T) string {
+				return signRS256(t, key, map[string]any{"alg": "RS256"}, validClaims()) + ".extra"
+			},
+			expectedError: "invalid token: malformed token",
+		},
+		{
+			name:          "when the header is not base64url it should reject the token",
+			pemKey:        true,
+			token:         func(t *testing.T) string { return "!!!." + segment(validClaims()) + ".c2ln" },
+			expectedError: "invalid token: decode header: illegal base64 data at input byte 0",
+		},
+		{
+			name:   "when the header is not JSON it should reject the token",
+			pemKey: true,
+			token: func(t *testing.T) string {
+				return base64.RawURLEncoding.EncodeToString([]byte("alg")) + "." + segment(validClaims()) + ".c2ln"
+			},
+			expectedError: "invalid token: decode header: invalid character 'a' looking for beginning of value",
+		},
+		{
+			name:   "when the signature is not base64url it should reject the token",
+			pemKey: true,
+			token: func(t *testing.T) string {
+				return segment(map[string]any{"alg": "RS256"}) + "." + segment(validClaims()) + ".!!!"
+			},
+			expectedError: "invalid token: decode signature: illegal base64 data at input byte 0",
+		},
+		{
+			name:        "when the signed claims are not JSON it should reject the token",
+			hmacEnabled: true,
+			token: func(t *testing.T) string {
+				input := segment(map[string]any{"alg": "HS256"}) + "." + base64.RawURLEncoding.EncodeToString([]byte("claims"))
+				mac := hmac.New(sha256.New, []byte(hmacSecret))
+				mac.Write([]byte(input))
+				return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+			},
+			expectedError: "invalid token: decode claims: invalid character 'c' looking for beginning of value",
+		},
+		{
+			name: "when no key is configured it should reject every token",
+			token: func(t *testing.T) string {
+				return signRS256(t, key, map[string]any{"alg": "RS256"}, validClaims())
+			},
+			expectedError: `invalid token: unknown key ""`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			cfg := Config{Issuer: "https://auth.example.com", Audience: "payments-api"}
+			if tt.hmacEnabled {
+				cfg.HMACKeyFile = writeFile(t, "hmac.key", hmacSecret)
+			}
+			if tt.pemKey {
+				cfg.RSAPublicKeyFile = writeFile(t, "public.pem", publicPEM)
+			}
+			if tt.jwks != nil {
+				cfg.JWKSFile = writeFile(t, "jwks.json", jwksOf(tt.jwks))
+			}
+			verifier, err := NewVerifier(cfg)
+			require.NoError(t, err)
+			verifier.now = func() time.Time { return fixedNow }
+
+			// Act
+			claims, err := verifier.Verify(tt.token(t))
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.ErrorIs(t, err, ErrInvalidToken)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, claims)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "user_123", claims.Subject)
+				assert.Equal(t, []string{"payments:read", "payments:write"}, claims.Scopes())
+			}
+		})
+	}
+}
+
+func TestVerifier_Verify_Claims(t *testing.T) {
+	tests := []struct {
+		name          string
+		leeway        time.Duration
+		issuer        string
+		audience      string
+		claims        map[string]any
+		expectedError string
+	}{
+		{
+			name:   "when exp is exactly now it should accept the token",
+			claims: withClaims(map[string]any{"exp": fixedNow.Unix()}),
+		},
+		{
+			name:          "when exp is a second ago it should reject the token",
+			claims:        withClaims(map[string]any{"exp": fixedNow.Add(-time.Second).Unix()}),
+			expectedError: "invalid token: token expired",
+		},
+		{
+			name:   "when exp is within the leeway it should accept the token",
+			leeway: 30 * time.Second,
+			claims: withClaims(map[string]any{"exp": fixedNow.Add(-30 * time.Second).Unix()}),
+		},
+		{
+			name:          "when exp is past the leeway it should reject the token",
+			leeway:        30 * time.Second,
+			claims:        withClaims(map[string]any{"exp": fixedNow.Add(-31 * time.Second).Unix()}),
+			expectedError: "invalid token: token expired",
+		},
+		{
+			name:          "when exp is missing it should reject the token",
+			claims:        withClaims(map[string]any{"exp": nil}),
+			expectedError: "invalid token: missing exp claim",
+		},
+		{
+			name:   "when nbf is exactly now it should accept the token",
+			claims: withClaims(map[string]any{"nbf": fixedNow.Unix()}),
+		},
+		{
+			name:          "when nbf is a second ahead it should reject the token",
+			claims:        withClaims(map[string]any{"nbf": fixedNow.Add(time.Second).Unix()}),
+			expectedError: "invalid token: token not valid yet",
+		},
+		{
+			name:   "when nbf is within the leeway it should accept the token",
+			leeway: 30 * time.Second,
+			claims: withClaims(map[string]any{"nbf": fixedNow.Add(30 * time.Second).Unix()}),
+		},
+		{
+			name:          "when nbf is past the leeway it should reject the token",
+			leeway:        30 * time.Second,
+			claims:        withClaims(map[string]any{"nbf": fixedNow.Add(31 * time.Second).Unix()}),
+			expectedError: "invalid token: token not valid yet",
+		},
+		{
+			name:     "when iss and aud match it should accept the token",
+			issuer:   "https://auth.example.com",
+			audience: "payments-api",
+			claims:   validClaims(),
+		},
+		{
+			name:          "when iss does not match it should reject the token",
+			issuer:        "https://auth.example.com",
+			claims:        withClaims(map[string]any{"iss": "https://evil.example.com"}),
+			expectedError: "invalid token: unexpected issuer",
+		},
+		{
+			name:          "when iss is missing and an issuer is expected it should reject the token",
+			issuer:        "https://auth.example.com",
+			claims:        withClaims(map[string]any{"iss": nil}),
+			expectedError: "invalid token: unexpected issuer",
+		},
+		{
+			name:   "when no issuer is expected it should accept any iss",
+			claims: withClaims(map[string]any{"iss": "https://other.example.com"}),
+		},
+		{
+			name:          "when aud does not match it should reject the token",
+			audience:      "payments-api",
+			claims:        withClaims(map[string]any{"aud": "wallet-api"}),
+			expectedError: "invalid token: unexpected audience",
+		},
+		{
+			name:     "when aud is a list containing the audience it should accept the token",
+			audience: "payments-api",
+			claims:   withClaims(map[string]any{"aud": []string{"wallet-api", "payments-api"}}),
+		},
+		{
+			name:          "when aud is a list without the audience it should reject the token",
+			audience:      "payments-api",
+			claims:        withClaims(map[string]any{"aud": []string{"wallet-api", "ledger-api"}}),
+			expectedError: "invalid token: unexpected audience",
+		},
+		{
+			name:          "when aud is missing and an audience is expected it should reject the token",
+			audience:      "payments-api",
+			claims:        withClaims(map[string]any{"aud": nil}),
+			expectedError: "invalid token: unexpected audience",
+		},
+		{
+			name:          "when aud is neither a string nor a list it should reject the token",
+			audience:      "payments-api",
+			claims:        withClaims(map[string]any{"aud": 42}),
+			expectedError: "invalid token: decode claims: json: cannot unmarshal number into Go value of type []string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			verifier, err := NewVerifier(Config{
+				HMACKeyFile: writeFile(t, "hmac.key", hmacSecret),
+				Issuer:      tt.issuer,
+				Audience:    tt.audience,
+				Leeway:      tt.leeway,
+			})
+			require.NoError(t, err)
+			verifier.now = func() time.Time { return fixedNow }
+
+			token := signHS256([]byte(hmacSecret), map[string]any{"alg": "HS256"}, tt.claims)
+
+			// Act
+			claims, err := verifier.Verify(token)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.ErrorIs(t, err, ErrInvalidToken)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, claims)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "user_123", claims.Subject)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+)
+
+// ErrNotAcquired is returned when a lock is held by another session
+var ErrNotAcquired = errors.New("lock: not acquired")
+
+// AdvisoryDB defines the database operations required by AdvisoryLocker
+type AdvisoryDB interface {
+	Conn() *sql.DB
+}
+
+// AdvisoryLocker provides session-level PostgreSQL advisory locks
+// Each lock pins a dedicated connection, the lock lives as long as that session
+type AdvisoryLocker struct {
+	db AdvisoryDB
+}
+
+// NewAdvisoryLocker creates a new AdvisoryLocker
+func NewAdvisoryLocker(db AdvisoryDB) (*AdvisoryLocker, error) {
+	if db == nil {
+		return nil, errors.New("advisory locker: database cannot be nil")
+	}
+
+	return &AdvisoryLocker{db: db}, nil
+}
+
+// AdvisoryLock is a held session-level advisory lock
+type AdvisoryLock struct {
+	name string
+	key  int64
+	conn *sql.Conn
+}
+
+// TryLock tries to acquire the lock without waiting
+// It returns ErrNotAcquired if another session holds the lock
+func (l *AdvisoryLocker) TryLock(ctx context.Context, name string) (*AdvisoryLock, error) {
+	return l.acquire(ctx, name, "SELECT pg_try_advisory_lock($1)")
+}
+
+// Lock acquires the lock waiting until it is released by other sessions or the context is done
+func (l *AdvisoryLocker) Lock(ctx context.Context, name string) (*AdvisoryLock, error) {
+	return l.acquire(ctx, name, "SELECT TRUE FROM pg_advisory_lock($1)")
+}
+
+// acquire pins a connection and runs the given lock query on it
+func (l *AdvisoryLocker) acquire(ctx context.Context, name string, query string) (*AdvisoryLock, error) {
+	conn, err := l.db.Conn().Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("advisory locker: get connection: %w", err)
+	}
+
+	key := Key(name)
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, query, key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("advisory locker: lock %s: %w", name, err)
+	}
+
+	if !acquired {
+		conn.Close()
+		return nil, ErrNotAcquired
+	}
+
+	return &AdvisoryLock{name: name, key: key, conn: conn}, nil
+}
+
+// Unlock releases the lock and returns its connection to the pool
+func (al *AdvisoryLock) Unlock(ctx context.Context) error {
+	defer al.conn.Close()
+
+	var released bool
+	if err := al.conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", al.key).Scan(&released); err != nil {
+		return fmt.Errorf("advisory lock: unlock %s: %w", al.name, err)
+	}
+
+	if !released {
+		return fmt.Errorf("advisory lock: unlock %s: lock was not held", al.name)
+	}
+
+	return nil
+}
+
+// Key derives the advisory lock key for a lock name
+func Key(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
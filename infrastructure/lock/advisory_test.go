@@ -0,0 +1,202 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sqlmockDB serves the connections of a mocked database
+type sqlmockDB struct {
+	db *sql.DB
+}
+
+// Conn returns the mocked database
+func (d sqlmockDB) Conn() *sql.DB {
+	return d.db
+}
+
+// newTestAdvisoryLocker creates an AdvisoryLocker on a mocked database
+func newTestAdvisoryLocker(t *testing.T) (*AdvisoryLocker, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	locker, err := NewAdvisoryLocker(sqlmockDB{db: db})
+	require.NoError(t, err)
+
+	return locker, mock
+}
+
+func TestNewAdvisoryLocker(t *testing.T) {
+	t.Run("when database is nil it should return error", func(t *testing.T) {
+		// Act
+		locker, err := NewAdvisoryLocker(nil)
+
+		// Assert
+		assert.EqualError(t, err, "advisory locker: database cannot be nil")
+		assert.Nil(t, locker)
+	})
+}
+
+func TestAdvisoryLocker_Acquire(t *testing.T) {
+	tryLockQuery := regexp.QuoteMeta("SELECT pg_try_advisory_lock($1)")
+	lockQuery := regexp.QuoteMeta("SELECT TRUE FROM pg_advisory_lock($1)")
+
+	tests := []struct {
+		name          string
+		acquire       func(l *AdvisoryLocker) (*AdvisoryLock, error)
+		query         string
+		acquired      bool
+		queryError    error
+		expectedError error
+	}{
+		{
+			name:     "when try lock gets the lock it should return it",
+			acquire:  func(l *AdvisoryLocker) (*AdvisoryLock, error) { return l.TryLock(context.Background(), "payments") },
+			query:    tryLockQuery,
+			acquired: true,
+		},
+		{
+			name:          "when another session holds the lock try lock should return not acquired",
+			acquire:       func(l *AdvisoryLocker) (*AdvisoryLock, error) { return l.TryLock(context.Background(), "payments") },
+			query:         tryLockQuery,
+			acquired:      false,
+			expectedError: ErrNotAcquired,
+		},
+		{
+			name:          "when the try lock query fails it should return error",
+			acquire:       func(l *AdvisoryLocker) (*AdvisoryLock, error) { return l.TryLock(context.Background(), "payments") },
+			query:         tryLockQuery,
+			queryError:    errors.New("connection reset"),
+			expectedError: errors.New("advisory locker: lock payments: connection reset"),
+		},
+		{
+			name:     "when lock gets the lock it should return it",
+			acquire:  func(l *AdvisoryLocker) (*AdvisoryLock, error) { return l.Lock(context.Background(), "payments") },
+			query:    lockQuery,
+			acquired: true,
+		},
+		{
+			name:          "when the lock query fails it should return error",
+			acquire:       func(l *AdvisoryLocker) (*AdvisoryLock, error) { return l.Lock(context.Background(), "payments") },
+			query:         lockQuery,
+			queryError:    errors.New("canceling statement due to user request"),
+			expectedError: errors.New("advisory locker: lock payments: canceling statement due to user request"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			locker, mock := newTestAdvisoryLocker(t)
+
+			expectation := mock.ExpectQuery(tt.query).WithArgs(Key("payments"))
+			if tt.queryError != nil {
+				expectation.WillReturnError(tt.queryError)
+			} else {
+				expectation.WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(tt.acquired))
+			}
+
+			// Act
+			lock, err := tt.acquire(locker)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, lock)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "payments", lock.name)
+				assert.Equal(t, Key("payments"), lock.key)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAdvisoryLock_Unlock(t *testing.T) {
+	unlockQuery := regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")
+
+	tests := []struct {
+		name          string
+		released      bool
+		queryError    error
+		expectedError error
+	}{
+		{
+			name:     "when the lock is held it should release it",
+			released: true,
+		},
+		{
+			name:          "when the lock was not held by the session it should return error",
+			released:      false,
+			expectedError: errors.New("advisory lock: unlock payments: lock was not held"),
+		},
+		{
+			name:          "when the unlock query fails it should return error",
+			queryError:    errors.New("connection reset"),
+			expectedError: errors.New("advisory lock: unlock payments: connection reset"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			locker, mock := newTestAdvisoryLocker(t)
+
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_try_advisory_lock($1)")).WithArgs(Key("payments")).
+				WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(true))
+			expectation := mock.ExpectQuery(unlockQuery).WithArgs(Key("payments"))
+			if tt.queryError != nil {
+				expectation.WillReturnError(tt.queryError)
+			} else {
+				expectation.WillReturnRows(sqlmock.NewRows([]string{"released"}).AddRow(tt.released))
+			}
+
+			lock, err := locker.TryLock(context.Background(), "payments")
+			require.NoError(t, err)
+
+			// Act
+			err = lock.Unlock(context.Background())
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	t.Run("when the name is the same it should derive the same key", func(t *testing.T) {
+		// Act
+		first := Key("payment:pay_123")
+		second := Key("payment:pay_123")
+
+		// Assert
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("when the names differ it should derive different keys", func(t *testing.T) {
+		// Act
+		first := Key("payment:pay_123")
+		second := Key("payment:pay_124")
+
+		// Assert
+		assert.NotEqual(t, first, second)
+	})
+}
@@ -0,0 +1,189 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+)
+
+// LeaseDB defines the database operations required by LeaseStore
+type LeaseDB interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) database.RowScanner
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// LeaseStore manages time-bound named leases stored in PostgreSQL
+// Unlike advisory locks, leases survive connection loss until they expire
+type LeaseStore struct {
+	db LeaseDB
+}
+
+// NewLeaseStore creates a new LeaseStore
+func NewLeaseStore(db LeaseDB) (*LeaseStore, error) {
+	if db == nil {
+		return nil, errors.New("lease store: database cannot be nil")
+	}
+
+	return &LeaseStore{db: db}, nil
+}
+
+// Acquire acquires or renews the lease for the holder
+// It succeeds if the lease is free, expired or already owned by the holder
+func (s *LeaseStore) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	query := `
+		INSERT INTO leases (name, holder, expires_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 millisecond')
+		ON CONFLICT (name) DO UPDATE
+		SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+		WHERE leases.holder = EXCLUDED.holder OR leases.expires_at < NOW()
+		RETURNING holder
+	`
+
+	var owner string
+	err := s.db.QueryRowContext(ctx, query, name, holder, ttl.Milliseconds()).Scan(&owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("lease store: acquire %s: %w", name, err)
+	}
+
+	return owner == holder, nil
+}
+
+// Renew extends the lease if it is still owned by the holder and not expired
+func (s *LeaseStore) Renew(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	query := `
+		UPDATE leases
+		SET expires_at = NOW() + $3 * INTERVAL '1 millisecond'
+		WHERE name = $1 AND holder = $2 AND expires_at >= NOW()
+	`
+
+	result, err := s.db.ExecContext(ctx, query, name, holder, ttl.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("lease store: renew %s: %w", name, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("lease store: rows affected: %w", err)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// Release releases the lease if it is owned by the holder
+func (s *LeaseStore) Release(ctx context.Context, name, holder string) error {
+	query := `
+		DELETE FROM leases
+		WHERE name = $1 AND holder = $2
+	`
+
+	if _, err := s.db.ExecContext(ctx, query, name, holder); err != nil {
+		return fmt.Errorf("lease store: release %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// Elector runs leader election on top of a lease
+// The leader keeps renewing the lease, the rest keep trying to acquire it once it expires
+type Elector struct {
+	leases *LeaseStore
+	name   string
+	holder string
+	ttl    time.Duration
+	leader atomic.Bool
+}
+
+// NewElector creates a new Elector for the lease name and holder identity
+func NewElector(leases *LeaseStore, name, holder string, ttl time.Duration) (*Elector, error) {
+	if leases == nil {
+		return nil, errors.New("elector: lease store cannot be nil")
+	}
+	if name == "" {
+		return nil, errors.New("elector: name cannot be empty")
+	}
+	if holder == "" {
+		return nil, errors.New("elector: holder cannot be empty")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("elector: ttl must be greater than 0")
+	}
+
+	return &Elector{
+		leases: leases,
+		name:   name,
+		holder: holder,
+		ttl:    ttl,
+	}, nil
+}
+
+// Holder returns the identity this elector campaigns with
+func (e *Elector) Holder() string {
+	return e.holder
+}
+
+// IsLeader reports whether this elector currently holds the lease
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run campaigns for the lease until the context is done, then releases it
+// The lease is renewed every third of its TTL so a missed renewal does not lose leadership
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		e.campaign(ctx)
+
+		select {
+		case <-ctx.Done():
+			e.resign()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// campaign acquires or renews the lease and updates the leadership state
+func (e *Elector) campaign(ctx context.Context) {
+	var acquired bool
+	var err error
+
+	if e.IsLeader() {
+		acquired, err = e.leases.Renew(ctx, e.name, e.holder, e.ttl)
+	} else {
+		acquired, err = e.leases.Acquire(ctx, e.name, e.holder, e.ttl)
+	}
+
+	if err != nil {
+		slog.WarnContext(ctx, "Leader election failed", "error", err, "lease", e.name, "holder", e.holder)
+		acquired = false
+	}
+
+	if acquired != e.leader.Swap(acquired) {
+		slog.InfoContext(ctx, "Leadership changed", "lease", e.name, "holder", e.holder, "leader", acquired)
+	}
+}
+
+// resign releases the lease if this elector is the leader
+func (e *Elector) resign() {
+	if !e.leader.Swap(false) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.ttl)
+	defer cancel()
+
+	if err := e.leases.Release(ctx, e.name, e.holder); err != nil {
+		slog.WarnContext(ctx, "Failed to release leadership", "error", err, "lease", e.name, "holder", e.holder)
+	}
+}
@@ -0,0 +1,393 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAcquire makes the lease acquire query return the owner of the lease, or the scan error
+func mockAcquire(mockDB *database.MockDB, owner string, scanErr error) {
+	mockScanner := new(database.MockRowScanner)
+	mockScanner.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+		if scanErr == nil {
+			dest := args.Get(0).([]any)
+			*dest[0].(*string) = owner
+		}
+	}).Return(scanErr)
+	mockDB.On("QueryRowContext", mock.Anything, mock.Anything, []any{"scheduler", "replica-1", int64(15000)}).Return(mockScanner)
+}
+
+func TestNewLeaseStore(t *testing.T) {
+	t.Run("when database is nil it should return error", func(t *testing.T) {
+		// Act
+		store, err := NewLeaseStore(nil)
+
+		// Assert
+		assert.EqualError(t, err, "lease store: database cannot be nil")
+		assert.Nil(t, store)
+	})
+}
+
+func TestLeaseStore_Acquire(t *testing.T) {
+	tests := []struct {
+		name          string
+		owner         string
+		scanErr       error
+		expected      bool
+		expectedError error
+	}{
+		{
+			name:     "when the lease is free, expired or owned by the holder it should acquire it",
+			owner:    "replica-1",
+			expected: true,
+		},
+		{
+			name:     "when the lease is held by another holder it should not acquire it",
+			scanErr:  sql.ErrNoRows,
+			expected: false,
+		},
+		{
+			name:          "when the query fails it should return error",
+			scanErr:       errors.New("connection refused"),
+			expected:      false,
+			expectedError: errors.New("lease store: acquire scheduler: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockAcquire(mockDB, tt.owner, tt.scanErr)
+			store := &LeaseStore{db: mockDB}
+
+			// Act
+			acquired, err := store.Acquire(context.Background(), "scheduler", "replica-1", 15*time.Second)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, acquired)
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLeaseStore_Renew(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockResult    sql.Result
+		mockError     error
+		expected      bool
+		expectedError error
+	}{
+		{
+			name:       "when the holder still owns the lease it should renew it",
+			mockResult: driver.RowsAffected(1),
+			expected:   true,
+		},
+		{
+			name:       "when the lease expired or was taken it should not renew it",
+			mockResult: driver.RowsAffected(0),
+			expected:   false,
+		},
+		{
+			name:          "when the query fails it should return error",
+			mockError:     errors.New("connection refused"),
+			expected:      false,
+			expectedError: errors.New("lease store: renew scheduler: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockDB.On("ExecContext", mock.Anything, mock.Anything, []any{"scheduler", "replica-1", int64(15000)}).Return(tt.mockResult, tt.mockError)
+			store := &LeaseStore{db: mockDB}
+
+			// Act
+			renewed, err := store.Renew(context.Background(), "scheduler", "replica-1", 15*time.Second)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, renewed)
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLeaseStore_Release(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockError     error
+		expectedError error
+	}{
+		{
+			name: "when the query succeeds it should release the lease",
+		},
+		{
+			name:          "when the query fails it should return error",
+			mockError:     errors.New("connection refused"),
+			expectedError: errors.New("lease store: release scheduler: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			var result sql.Result
+			if tt.mockError == nil {
+				result = driver.RowsAffected(1)
+			}
+			mockDB.On("ExecContext", mock.Anything, mock.Anything, []any{"scheduler", "replica-1"}).Return(result, tt.mockError)
+			store := &LeaseStore{db: mockDB}
+
+			// Act
+			err := store.Release(context.Background(), "scheduler", "replica-1")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewElector(t *testing.T) {
+	tests := []struct {
+		name          string
+		leases        *LeaseStore
+		leaseName     string
+		holder        string
+		ttl           time.Duration
+		expectedError error
+	}{
+		{
+			name:      "when all dependencies are valid it should create elector",
+			leases:    &LeaseStore{},
+			leaseName: "scheduler",
+			holder:    "replica-1",
+			ttl:       15 * time.Second,
+		},
+		{
+			name:          "when lease store is nil it should return error",
+			leaseName:     "scheduler",
+			holder:        "replica-1",
+			ttl:           15 * time.Second,
+			expectedError: errors.New("elector: lease store cannot be nil"),
+		},
+		{
+			name:          "when name is empty it should return error",
+			leases:        &LeaseStore{},
+			holder:        "replica-1",
+			ttl:           15 * time.Second,
+			expectedError: errors.New("elector: name cannot be empty"),
+		},
+		{
+			name:          "when holder is empty it should return error",
+			leases:        &LeaseStore{},
+			leaseName:     "scheduler",
+			ttl:           15 * time.Second,
+			expectedError: errors.New("elector: holder cannot be empty"),
+		},
+		{
+			name:          "when ttl is not positive it should return error",
+			leases:        &LeaseStore{},
+			leaseName:     "scheduler",
+			holder:        "replica-1",
+			expectedError: errors.New("elector: ttl must be greater than 0"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			elector, err := NewElector(tt.leases, tt.leaseName, tt.holder, tt.ttl)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, elector)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.holder, elector.Holder())
+				assert.False(t, elector.IsLeader())
+			}
+		})
+	}
+}
+
+func TestElector_Campaign(t *testing.T) {
+	tests := []struct {
+		name           string
+		leader         bool
+		setupMock      func(mockDB *database.MockDB)
+		expectedLeader bool
+	}{
+		{
+			name:   "when a follower acquires the lease it should become leader",
+			leader: false,
+			setupMock: func(mockDB *database.MockDB) {
+				mockAcquire(mockDB, "replica-1", nil)
+			},
+			expectedLeader: true,
+		},
+		{
+			name:   "when a follower finds the lease held by another holder it should stay follower",
+			leader: false,
+			setupMock: func(mockDB *database.MockDB) {
+				mockAcquire(mockDB, "", sql.ErrNoRows)
+			},
+			expectedLeader: false,
+		},
+		{
+			name:   "when a follower fails to acquire the lease it should stay follower",
+			leader: false,
+			setupMock: func(mockDB *database.MockDB) {
+				mockAcquire(mockDB, "", errors.New("connection refused"))
+			},
+			expectedLeader: false,
+		},
+		{
+			name:   "when the leader renews the lease it should stay leader",
+			leader: true,
+			setupMock: func(mockDB *database.MockDB) {
+				mockDB.On("ExecContext", mock.Anything, mock.Anything, []any{"scheduler", "replica-1", int64(15000)}).Return(driver.RowsAffected(1), nil)
+			},
+			expectedLeader: true,
+		},
+		{
+			name:   "when the leader lease expired and was taken it should step down",
+			leader: true,
+			setupMock: func(mockDB *database.MockDB) {
+				mockDB.On("ExecContext", mock.Anything, mock.Anything, []any{"scheduler", "replica-1", int64(15000)}).Return(driver.RowsAffected(0), nil)
+			},
+			expectedLeader: false,
+		},
+		{
+			name:   "when the leader fails to renew the lease it should step down",
+			leader: true,
+			setupMock: func(mockDB *database.MockDB) {
+				mockDB.On("ExecContext", mock.Anything, mock.Anything, []any{"scheduler", "replica-1", int64(15000)}).Return(nil, errors.New("connection refused"))
+			},
+			expectedLeader: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			tt.setupMock(mockDB)
+
+			elector, err := NewElector(&LeaseStore{db: mockDB}, "scheduler", "replica-1", 15*time.Second)
+			require.NoError(t, err)
+			elector.leader.Store(tt.leader)
+
+			// Act
+			elector.campaign(context.Background())
+
+			// Assert
+			assert.Equal(t, tt.expectedLeader, elector.IsLeader())
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestElector_Resign(t *testing.T) {
+	tests := []struct {
+		name          string
+		leader        bool
+		releaseError  error
+		expectRelease bool
+	}{
+		{
+			name:          "when the elector is leader it should release the lease",
+			leader:        true,
+			expectRelease: true,
+		},
+		{
+			name:          "when releasing the lease fails it should still step down",
+			leader:        true,
+			releaseError:  errors.New("connection refused"),
+			expectRelease: true,
+		},
+		{
+			name:          "when the elector is follower it should not touch the lease",
+			leader:        false,
+			expectRelease: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			if tt.expectRelease {
+				var result sql.Result
+				if tt.releaseError == nil {
+					result = driver.RowsAffected(1)
+				}
+				mockDB.On("ExecContext", mock.Anything, mock.Anything, []any{"scheduler", "replica-1"}).Return(result, tt.releaseError)
+			}
+
+			elector, err := NewElector(&LeaseStore{db: mockDB}, "scheduler", "replica-1", 15*time.Second)
+			require.NoError(t, err)
+			elector.leader.Store(tt.leader)
+
+			// Act
+			elector.resign()
+
+			// Assert
+			assert.False(t, elector.IsLeader())
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestElector_Run(t *testing.T) {
+	t.Run("when the context is done it should stop campaigning and release the lease it won", func(t *testing.T) {
+		// Arrange
+		mockDB := new(database.MockDB)
+		mockAcquire(mockDB, "replica-1", nil)
+		mockDB.On("ExecContext", mock.Anything, mock.Anything, []any{"scheduler", "replica-1"}).Return(driver.RowsAffected(1), nil)
+
+		elector, err := NewElector(&LeaseStore{db: mockDB}, "scheduler", "replica-1", 15*time.Second)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// Act
+		elector.Run(ctx)
+
+		// Assert
+		assert.False(t, elector.IsLeader())
+		mockDB.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/requestid"
+)
+
+// RequestIDKey is the attribute key of the request ID in log records
+const RequestIDKey = "request_id"
+
+// ContextHandler adds the request ID of the context to every record logged with a context
+type ContextHandler struct {
+	next slog.Handler
+}
+
+// NewContextHandler creates a handler that adds the request ID before passing records to next
+func NewContextHandler(next slog.Handler) *ContextHandler {
+	return &ContextHandler{next: next}
+}
+
+// Enabled reports whether the next handler handles records at the level
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle adds the request ID to the record and passes it to the next handler
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		r.AddAttrs(slog.String(RequestIDKey, id))
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs returns a handler whose records include the attributes
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{next: h.next.WithAttrs(attrs)}
+}
+
+// WithGroup returns a handler that qualifies the record attributes with the group
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{next: h.next.WithGroup(name)}
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		log      func(ctx context.Context, logger *slog.Logger)
+		expected map[string]any
+	}{
+		{
+			name: "when the context carries a request ID it should add it to the record",
+			ctx:  requestid.WithRequestID(context.Background(), "req_123"),
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.InfoContext(ctx, "payment created", "payment_id", "pay_123")
+			},
+			expected: map[string]any{
+				"level":      "INFO",
+				"msg":        "payment created",
+				"payment_id": "pay_123",
+				RequestIDKey: "req_123",
+			},
+		},
+		{
+			name: "when the context carries no request ID it should leave the record unchanged",
+			ctx:  context.Background(),
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.InfoContext(ctx, "payment created", "payment_id", "pay_123")
+			},
+			expected: map[string]any{
+				"level":      "INFO",
+				"msg":        "payment created",
+				"payment_id": "pay_123",
+			},
+		},
+		{
+			name: "when attributes were added with WithAttrs it should keep them next to the request ID",
+			ctx:  requestid.WithRequestID(context.Background(), "req_123"),
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.With("worker", 1).InfoContext(ctx, "message consumed")
+			},
+			expected: map[string]any{
+				"level":      "INFO",
+				"msg":        "message consumed",
+				"worker":     float64(1),
+				RequestIDKey: "req_123",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var buf bytes.Buffer
+			logger := slog.New(NewContextHandler(slog.NewJSONHandler(&buf, nil)))
+
+			// Act
+			tt.log(tt.ctx, logger)
+
+			// Assert
+			assert.Equal(t, []map[string]any{tt.expected}, decodeRecords(t, &buf))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// Config configures a logger
+type Config struct {
+	Level           string   // Minimum level logged: debug, info, warn or error
+	Format          string   // Record format: text or json
+	RedactKeys      []string // Attribute keys whose values are redacted
+	DebugSampleRate int      // Keep 1 of every N debug records of the same message
+}
+
+// New creates a logger writing to w
+// Records are sampled, tagged with the request ID of their context and redacted, in that order
+func New(w io.Writer, config Config) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+		return nil, fmt.Errorf("logging: invalid level %q: %w", config.Level, err)
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch config.Format {
+	case "text", "":
+		handler = slog.NewTextHandler(w, options)
+	case "json":
+		handler = slog.NewJSONHandler(w, options)
+	default:
+		return nil, fmt.Errorf("logging: invalid format %q", config.Format)
+	}
+
+	handler = NewRedactHandler(handler, config.RedactKeys)
+	handler = NewContextHandler(handler)
+	handler = NewSamplingHandler(handler, config.DebugSampleRate)
+
+	return slog.New(handler), nil
+}
+
+// Open opens the output logs are written to: stdout, stderr or a file path, appended to
+// The returned function closes the output, it does nothing for stdout and stderr
+func Open(output string) (io.Writer, func() error, error) {
+	switch output {
+	case "stdout", "":
+		return os.Stdout, func() error { return nil }, nil
+	case "stderr":
+		return os.Stderr, func() error { return nil }, nil
+	}
+
+	file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("logging: failed to open %s: %w", output, err)
+	}
+	return file, file.Close, nil
+}
@@ -0,0 +1,168 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        Config
+		expectedError string
+	}{
+		{
+			name:   "when level and format are valid it should create logger",
+			config: Config{Level: "warn", Format: "json"},
+		},
+		{
+			name:   "when format is empty it should default to text",
+			config: Config{Level: "info"},
+		},
+		{
+			name:          "when level is unknown it should return error",
+			config:        Config{Level: "verbose", Format: "json"},
+			expectedError: `logging: invalid level "verbose": slog: level string "verbose": unknown name`,
+		},
+		{
+			name:          "when level is empty it should return error",
+			config:        Config{Format: "json"},
+			expectedError: `logging: invalid level "": slog: level string "": unknown name`,
+		},
+		{
+			name:          "when format is unknown it should return error",
+			config:        Config{Level: "info", Format: "xml"},
+			expectedError: `logging: invalid format "xml"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			logger, err := New(&bytes.Buffer{}, tt.config)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, logger)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, logger)
+			}
+		})
+	}
+}
+
+func TestNew_Pipeline(t *testing.T) {
+	t.Run("when records are logged it should filter by level, sample debug, add the request ID and redact", func(t *testing.T) {
+		// Arrange
+		var buf bytes.Buffer
+		logger, err := New(&buf, Config{Level: "debug", Format: "json", RedactKeys: []string{"token"}, DebugSampleRate: 2})
+		require.NoError(t, err)
+		ctx := requestid.WithRequestID(context.Background(), "req_123")
+
+		// Act
+		logger.DebugContext(ctx, "message consumed", "token", "tok_1")
+		logger.DebugContext(ctx, "message consumed", "token", "tok_2")
+		logger.ErrorContext(ctx, "payment failed", "token", "tok_3")
+
+		// Assert
+		assert.Equal(t, []map[string]any{
+			{"level": "DEBUG", "msg": "message consumed", "token": RedactedValue, RequestIDKey: "req_123"},
+			{"level": "ERROR", "msg": "payment failed", "token": RedactedValue, RequestIDKey: "req_123"},
+		}, decodeRecords(t, &buf))
+	})
+
+	t.Run("when a record is below the level it should drop it", func(t *testing.T) {
+		// Arrange
+		var buf bytes.Buffer
+		logger, err := New(&buf, Config{Level: "warn", Format: "text"})
+		require.NoError(t, err)
+
+		// Act
+		logger.Info("payment created")
+		logger.Warn("wallet slow")
+
+		// Assert
+		assert.NotContains(t, buf.String(), "payment created")
+		assert.Contains(t, buf.String(), "level=WARN msg=\"wallet slow\"")
+	})
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected *os.File
+	}{
+		{
+			name:     "when output is stdout it should write to stdout",
+			output:   "stdout",
+			expected: os.Stdout,
+		},
+		{
+			name:     "when output is empty it should write to stdout",
+			output:   "",
+			expected: os.Stdout,
+		},
+		{
+			name:     "when output is stderr it should write to stderr",
+			output:   "stderr",
+			expected: os.Stderr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			w, closeFn, err := Open(tt.output)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, w)
+			assert.NoError(t, closeFn())
+		})
+	}
+
+	t.Run("when output is a file path it should append to the file", func(t *testing.T) {
+		// Arrange
+		path := filepath.Join(t.TempDir(), "app.log")
+		require.NoError(t, os.WriteFile(path, []byte("first\n"), 0o644))
+
+		// Act
+		w, closeFn, err := Open(path)
+		require.NoError(t, err)
+		_, writeErr := w.Write([]byte("second\n"))
+		closeErr := closeFn()
+
+		// Assert
+		assert.NoError(t, writeErr)
+		assert.NoError(t, closeErr)
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, "first\nsecond\n", string(data))
+	})
+
+	t.Run("when the file cannot be opened it should return error", func(t *testing.T) {
+		// Arrange
+		path := filepath.Join(t.TempDir(), "missing", "app.log")
+
+		// Act
+		w, closeFn, err := Open(path)
+
+		// Assert
+		assert.Error(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), "logging: failed to open "+path+": "))
+		assert.Nil(t, w)
+		assert.Nil(t, closeFn)
+	})
+}
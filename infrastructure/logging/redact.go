@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// RedactedValue replaces the value of redacted attributes
+const RedactedValue = "[REDACTED]"
+
+// RedactHandler replaces the values of sensitive attributes before passing records to the next handler
+// Keys are matched case-insensitively at any group depth
+type RedactHandler struct {
+	next slog.Handler
+	keys map[string]bool
+}
+
+// NewRedactHandler creates a handler that redacts the attributes with the given keys
+func NewRedactHandler(next slog.Handler, keys []string) *RedactHandler {
+	redacted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		redacted[strings.ToLower(key)] = true
+	}
+	return &RedactHandler{next: next, keys: redacted}
+}
+
+// Enabled reports whether the next handler handles records at the level
+func (h *RedactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle redacts the record attributes and passes the record to the next handler
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) error {
+	if len(h.keys) == 0 || r.NumAttrs() == 0 {
+		return h.next.Handle(ctx, r)
+	}
+
+	redacted := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.redact(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+// WithAttrs returns a handler whose records include the attributes, redacted
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.redact(attr)
+	}
+	return &RedactHandler{next: h.next.WithAttrs(redacted), keys: h.keys}
+}
+
+// WithGroup returns a handler that qualifies the record attributes with the group
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return &RedactHandler{next: h.next.WithGroup(name), keys: h.keys}
+}
+
+// redact returns the attribute with its value replaced if its key is sensitive, redacting groups recursively
+func (h *RedactHandler) redact(attr slog.Attr) slog.Attr {
+	if h.keys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, RedactedValue)
+	}
+
+	value := attr.Value.Resolve()
+	if value.Kind() != slog.KindGroup {
+		return attr
+	}
+
+	group := value.Group()
+	redacted := make([]any, len(group))
+	for i, member := range group {
+		redacted[i] = h.redact(member)
+	}
+	return slog.Group(attr.Key, redacted...)
+}
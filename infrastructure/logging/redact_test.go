@@ -0,0 +1,173 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeRecords decodes the JSON records written to the buffer, one per line
+func decodeRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	records := []map[string]any{}
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		var record map[string]any
+		require.NoError(t, decoder.Decode(&record))
+		delete(record, slog.TimeKey)
+		records = append(records, record)
+	}
+	return records
+}
+
+// card logs as a group holding its token
+type card struct {
+	token string
+	brand string
+}
+
+// LogValue returns the card as a group
+func (c card) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("token", c.token), slog.String("brand", c.brand))
+}
+
+func TestRedactHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []string
+		log      func(logger *slog.Logger)
+		expected map[string]any
+	}{
+		{
+			name: "when a key is sensitive it should redact its value whatever its case",
+			keys: []string{"token", "User_ID"},
+			log: func(logger *slog.Logger) {
+				logger.Info("payment created", "Token", "tok_123", "USER_ID", "user_123", "user_id", "user_456", "amount", 100.5)
+			},
+			expected: map[string]any{
+				"level":   "INFO",
+				"msg":     "payment created",
+				"Token":   RedactedValue,
+				"USER_ID": RedactedValue,
+				"user_id": RedactedValue,
+				"amount":  100.5,
+			},
+		},
+		{
+			name: "when a sensitive key is inside nested groups it should redact it at any depth",
+			keys: []string{"token", "user_id"},
+			log: func(logger *slog.Logger) {
+				logger.Info("payment created", slog.Group("payment",
+					slog.String("user_id", "user_123"),
+					slog.Group("card", slog.String("token", "tok_123"), slog.String("brand", "visa")),
+				))
+			},
+			expected: map[string]any{
+				"level": "INFO",
+				"msg":   "payment created",
+				"payment": map[string]any{
+					"user_id": RedactedValue,
+					"card":    map[string]any{"token": RedactedValue, "brand": "visa"},
+				},
+			},
+		},
+		{
+			name: "when a group key is sensitive it should redact the whole group",
+			keys: []string{"card"},
+			log: func(logger *slog.Logger) {
+				logger.Info("payment created", slog.Group("card", slog.String("brand", "visa")))
+			},
+			expected: map[string]any{
+				"level": "INFO",
+				"msg":   "payment created",
+				"card":  RedactedValue,
+			},
+		},
+		{
+			name: "when a value resolves to a group it should redact its sensitive members",
+			keys: []string{"token"},
+			log: func(logger *slog.Logger) {
+				logger.Info("payment created", "card", card{token: "tok_123", brand: "visa"})
+			},
+			expected: map[string]any{
+				"level": "INFO",
+				"msg":   "payment created",
+				"card":  map[string]any{"token": RedactedValue, "brand": "visa"},
+			},
+		},
+		{
+			name: "when attributes are added with WithGroup it should redact the keys inside the group",
+			keys: []string{"token"},
+			log: func(logger *slog.Logger) {
+				logger.WithGroup("request").WithGroup("auth").Info("request authenticated", "token", "tok_123", "scheme", "bearer")
+			},
+			expected: map[string]any{
+				"level": "INFO",
+				"msg":   "request authenticated",
+				"request": map[string]any{
+					"auth": map[string]any{"token": RedactedValue, "scheme": "bearer"},
+				},
+			},
+		},
+		{
+			name: "when attributes are added with WithAttrs it should redact them",
+			keys: []string{"token", "user_id"},
+			log: func(logger *slog.Logger) {
+				logger.With("token", "tok_123", slog.Group("owner", slog.String("user_id", "user_123"))).
+					Info("payment created", "payment_id", "pay_123")
+			},
+			expected: map[string]any{
+				"level":      "INFO",
+				"msg":        "payment created",
+				"token":      RedactedValue,
+				"owner":      map[string]any{"user_id": RedactedValue},
+				"payment_id": "pay_123",
+			},
+		},
+		{
+			name: "when no key is configured it should pass the attributes unchanged",
+			log: func(logger *slog.Logger) {
+				logger.Info("payment created", "token", "tok_123")
+			},
+			expected: map[string]any{
+				"level": "INFO",
+				"msg":   "payment created",
+				"token": "tok_123",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var buf bytes.Buffer
+			logger := slog.New(NewRedactHandler(slog.NewJSONHandler(&buf, nil), tt.keys))
+
+			// Act
+			tt.log(logger)
+
+			// Assert
+			assert.Equal(t, []map[string]any{tt.expected}, decodeRecords(t, &buf))
+		})
+	}
+}
+
+func TestRedactHandler_Enabled(t *testing.T) {
+	t.Run("when the next handler skips a level it should skip it too", func(t *testing.T) {
+		// Arrange
+		handler := NewRedactHandler(slog.NewJSONHandler(&bytes.Buffer{}, &slog.HandlerOptions{Level: slog.LevelWarn}), []string{"token"})
+
+		// Act
+		infoEnabled := handler.Enabled(t.Context(), slog.LevelInfo)
+		warnEnabled := handler.Enabled(t.Context(), slog.LevelWarn)
+
+		// Assert
+		assert.False(t, infoEnabled)
+		assert.True(t, warnEnabled)
+	})
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// SamplingHandler keeps 1 of every N debug records with the same message
+// Records at info level and above are always kept, so sampling only thins out high-volume debug logs
+type SamplingHandler struct {
+	next     slog.Handler
+	rate     uint64
+	counters *sync.Map // Records seen per message, shared by the handlers derived from this one
+}
+
+// NewSamplingHandler creates a handler that samples debug records at the given rate, a rate below 2 keeps them all
+func NewSamplingHandler(next slog.Handler, rate int) *SamplingHandler {
+	if rate < 1 {
+		rate = 1
+	}
+	return &SamplingHandler{next: next, rate: uint64(rate), counters: &sync.Map{}}
+}
+
+// Enabled reports whether the next handler handles records at the level
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle passes the record to the next handler unless it is a debug record dropped by sampling
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.rate > 1 && r.Level < slog.LevelInfo {
+		counter, _ := h.counters.LoadOrStore(r.Message, new(atomic.Uint64))
+		if seen := counter.(*atomic.Uint64).Add(1); (seen-1)%h.rate != 0 {
+			return nil
+		}
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs returns a handler whose records include the attributes
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SamplingHandler{next: h.next.WithAttrs(attrs), rate: h.rate, counters: h.counters}
+}
+
+// WithGroup returns a handler that qualifies the record attributes with the group
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	return &SamplingHandler{next: h.next.WithGroup(name), rate: h.rate, counters: h.counters}
+}
@@ -0,0 +1,155 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// captureHandler records the messages of the records it handles, shared by the handlers derived from it
+type captureHandler struct {
+	mu       *sync.Mutex
+	messages *[]string
+}
+
+func newCaptureHandler() *captureHandler {
+	return &captureHandler{mu: &sync.Mutex{}, messages: &[]string{}}
+}
+
+func (h *captureHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+func (h *captureHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.messages = append(*h.messages, r.Level.String()+" "+r.Message)
+	return nil
+}
+
+func (h *captureHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *captureHandler) WithGroup(name string) slog.Handler {
+	return h
+}
+
+// count returns the records handled with the level and message
+func (h *captureHandler) count(level slog.Level, message string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	count := 0
+	for _, m := range *h.messages {
+		if m == level.String()+" "+message {
+			count++
+		}
+	}
+	return count
+}
+
+func TestSamplingHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     int
+		level    slog.Level
+		records  int
+		expected int
+	}{
+		{
+			name:     "when debug records are logged it should keep the first of every N",
+			rate:     3,
+			level:    slog.LevelDebug,
+			records:  7,
+			expected: 3,
+		},
+		{
+			name:     "when info records are logged it should keep them all",
+			rate:     3,
+			level:    slog.LevelInfo,
+			records:  7,
+			expected: 7,
+		},
+		{
+			name:     "when warn records are logged it should keep them all",
+			rate:     3,
+			level:    slog.LevelWarn,
+			records:  7,
+			expected: 7,
+		},
+		{
+			name:     "when error records are logged it should keep them all",
+			rate:     3,
+			level:    slog.LevelError,
+			records:  7,
+			expected: 7,
+		},
+		{
+			name:     "when the rate is 1 it should keep every debug record",
+			rate:     1,
+			level:    slog.LevelDebug,
+			records:  7,
+			expected: 7,
+		},
+		{
+			name:     "when the rate is not positive it should keep every debug record",
+			rate:     0,
+			level:    slog.LevelDebug,
+			records:  7,
+			expected: 7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			capture := newCaptureHandler()
+			logger := slog.New(NewSamplingHandler(capture, tt.rate))
+
+			// Act
+			for i := 0; i < tt.records; i++ {
+				logger.Log(context.Background(), tt.level, "message consumed")
+			}
+
+			// Assert
+			assert.Equal(t, tt.expected, capture.count(tt.level, "message consumed"))
+		})
+	}
+}
+
+func TestSamplingHandler_Counters(t *testing.T) {
+	t.Run("when debug records have different messages it should sample each message on its own", func(t *testing.T) {
+		// Arrange
+		capture := newCaptureHandler()
+		logger := slog.New(NewSamplingHandler(capture, 2))
+
+		// Act
+		for i := 0; i < 4; i++ {
+			logger.Debug("message consumed")
+			logger.Debug("message acked")
+		}
+
+		// Assert
+		assert.Equal(t, 2, capture.count(slog.LevelDebug, "message consumed"))
+		assert.Equal(t, 2, capture.count(slog.LevelDebug, "message acked"))
+	})
+
+	t.Run("when loggers are derived with attributes or groups it should share the counters", func(t *testing.T) {
+		// Arrange
+		capture := newCaptureHandler()
+		logger := slog.New(NewSamplingHandler(capture, 2))
+
+		// Act
+		logger.Debug("message consumed")
+		logger.With("worker", 1).Debug("message consumed")
+		logger.WithGroup("broker").Debug("message consumed")
+		logger.With("worker", 2).Debug("message consumed")
+
+		// Assert
+		assert.Equal(t, 2, capture.count(slog.LevelDebug, "message consumed"))
+	})
+}
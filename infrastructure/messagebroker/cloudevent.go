@@ -0,0 +1,261 @@
+package messagebroker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CloudEvents 1.0 constants
+const (
+	CloudEventsSpecVersion = "1.0"                          // Version of the CloudEvents specification
+	CloudEventsContentType = "application/cloudevents+json" // Content type of structured mode messages
+	JSONContentType        = "application/json"             // Content type of the event data and legacy messages
+
+	// CloudEventsHeaderPrefix prefixes the attributes of binary mode messages, as in the AMQP binding
+	CloudEventsHeaderPrefix = "cloudEvents_"
+
+	// legacyHeaderPrefix is the prefix of the first AMQP binding, still accepted on binary mode messages
+	legacyHeaderPrefix = "cloudEvents:"
+)
+
+// ErrNotCloudEvent is returned when a message is neither a structured nor a binary mode CloudEvent
+// Legacy messages carry the data itself as their body
+var ErrNotCloudEvent = errors.New("message is not a cloud event")
+
+// EventMode is how the events are written to the messages
+type EventMode string
+
+const (
+	EventModeLegacy     EventMode = "legacy"     // The body is the data, without event metadata
+	EventModeStructured EventMode = "structured" // The body is the whole event as application/cloudevents+json
+	EventModeBinary     EventMode = "binary"     // The body is the data, the attributes travel as cloudEvents_ headers
+)
+
+// Validate validates the event mode
+// It returns an error if the mode is unknown
+func (m EventMode) Validate() error {
+	switch m {
+	case EventModeLegacy, EventModeStructured, EventModeBinary:
+		return nil
+	}
+	return fmt.Errorf("invalid event mode %q", m)
+}
+
+// EventFormat configures how a publisher writes its events
+type EventFormat struct {
+	Mode   EventMode // How the events are written to the messages
+	Source string    // Source attribute of the events, identifies the publishing service
+	Codec  Codec     // How the event data is encoded, JSON if nil
+}
+
+// DataCodec returns the codec the event data is encoded with
+func (f EventFormat) DataCodec() Codec {
+	if f.Codec == nil {
+		return JSONCodec{}
+	}
+	return f.Codec
+}
+
+// CloudEvent is a CloudEvents 1.0 event
+type CloudEvent struct {
+	SpecVersion     string    `json:"specversion"`               // Version of the specification
+	ID              string    `json:"id"`                        // Unique ID of the event, consumers deduplicate with it
+	Source          string    `json:"source"`                    // Service that published the event
+	Type            string    `json:"type"`                      // Type of the event, reverse DNS prefixed
+	Subject         string    `json:"subject,omitempty"`         // Entity the event is about, e.g. the payment ID
+	Time            time.Time `json:"time"`                      // Time the event happened
+	DataContentType string    `json:"datacontenttype,omitempty"` // Content type of the data
+	DataSchema      string    `json:"dataschema,omitempty"`      // Schema the data adheres to
+	Data            []byte    `json:"-"`                         // Event data, encoded as DataContentType
+}
+
+// structuredEvent is the JSON form of a structured mode event
+// JSON data is embedded as is, any other content type travels base64 encoded
+type structuredEvent struct {
+	CloudEvent
+	Data       json.RawMessage `json:"data,omitempty"`
+	DataBase64 string          `json:"data_base64,omitempty"`
+}
+
+// NewCloudEvent creates an event with a new ID and the current time
+// The source is left empty for the publisher to fill in with its configured one
+func NewCloudEvent(eventType, subject, dataSchema, dataContentType string, data []byte) *CloudEvent {
+	return &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              uuid.New().String(),
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: dataContentType,
+		DataSchema:      dataSchema,
+		Data:            data,
+	}
+}
+
+// Validate validates the required attributes of the event
+// It returns an error naming the first missing attribute
+func (e *CloudEvent) Validate() error {
+	if e.SpecVersion != CloudEventsSpecVersion {
+		return fmt.Errorf("unsupported cloud event specversion %q", e.SpecVersion)
+	}
+	if e.ID == "" {
+		return errors.New("cloud event id is required")
+	}
+	if e.Source == "" {
+		return errors.New("cloud event source is required")
+	}
+	if e.Type == "" {
+		return errors.New("cloud event type is required")
+	}
+	return nil
+}
+
+// Encode writes the event in the given mode
+// It returns the body, the content type and the headers of the message, and an error if the event cannot be encoded
+func (e *CloudEvent) Encode(mode EventMode) ([]byte, string, map[string]interface{}, error) {
+	switch mode {
+	case EventModeLegacy:
+		return e.Data, e.contentType(), nil, nil
+	case EventModeStructured:
+		wire := structuredEvent{CloudEvent: *e}
+		if e.contentType() == JSONContentType {
+			wire.Data = e.Data
+		} else {
+			wire.DataBase64 = base64.StdEncoding.EncodeToString(e.Data)
+		}
+		body, err := json.Marshal(wire)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("cloud event: failed to marshal event: %w", err)
+		}
+		return body, CloudEventsContentType, nil, nil
+	case EventModeBinary:
+		headers := map[string]interface{}{
+			CloudEventsHeaderPrefix + "specversion": e.SpecVersion,
+			CloudEventsHeaderPrefix + "id":          e.ID,
+			CloudEventsHeaderPrefix + "source":      e.Source,
+			CloudEventsHeaderPrefix + "type":        e.Type,
+			CloudEventsHeaderPrefix + "time":        e.Time.Format(time.RFC3339Nano),
+		}
+		if e.Subject != "" {
+			headers[CloudEventsHeaderPrefix+"subject"] = e.Subject
+		}
+		if e.DataSchema != "" {
+			headers[CloudEventsHeaderPrefix+"dataschema"] = e.DataSchema
+		}
+		return e.Data, e.contentType(), headers, nil
+	}
+	return nil, "", nil, mode.Validate()
+}
+
+// contentType returns the content type of the data, JSON if it is not set
+func (e *CloudEvent) contentType() string {
+	if e.DataContentType == "" {
+		return JSONContentType
+	}
+	return e.DataContentType
+}
+
+// ParseCloudEvent reads the event of a structured or binary mode message
+// Structured messages whose content type was lost, e.g. replayed from the dead letter archive, are recognized by their specversion
+// It returns ErrNotCloudEvent for legacy messages and an error if the event is malformed
+func ParseCloudEvent(msg *Message) (*CloudEvent, error) {
+	if _, ok := header(msg.Headers, "specversion"); ok {
+		return parseBinary(msg)
+	}
+	if strings.HasPrefix(msg.ContentType, CloudEventsContentType) || hasSpecVersion(msg.Body) {
+		return parseStructured(msg.Body)
+	}
+	return nil, ErrNotCloudEvent
+}
+
+// parseStructured reads the event written as the whole message body
+func parseStructured(body []byte) (*CloudEvent, error) {
+	var wire structuredEvent
+	if err := json.Unmarshal(body, &wire); err != nil {
+		return nil, fmt.Errorf("cloud event: failed to parse structured event: %w", err)
+	}
+
+	event := wire.CloudEvent
+	event.Data = wire.Data
+	if wire.DataBase64 != "" {
+		data, err := base64.StdEncoding.DecodeString(wire.DataBase64)
+		if err != nil {
+			return nil, fmt.Errorf("cloud event: failed to decode data_base64: %w", err)
+		}
+		event.Data = data
+	}
+
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// parseBinary reads the event whose attributes travel as message headers
+func parseBinary(msg *Message) (*CloudEvent, error) {
+	specVersion, _ := header(msg.Headers, "specversion")
+	id, _ := header(msg.Headers, "id")
+	source, _ := header(msg.Headers, "source")
+	eventType, _ := header(msg.Headers, "type")
+	subject, _ := header(msg.Headers, "subject")
+	dataSchema, _ := header(msg.Headers, "dataschema")
+
+	event := &CloudEvent{
+		SpecVersion:     specVersion,
+		ID:              id,
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		DataContentType: msg.ContentType,
+		DataSchema:      dataSchema,
+		Data:            msg.Body,
+	}
+
+	if raw, ok := msg.Headers[CloudEventsHeaderPrefix+"time"]; ok {
+		event.Time = headerTime(raw)
+	} else if raw, ok := msg.Headers[legacyHeaderPrefix+"time"]; ok {
+		event.Time = headerTime(raw)
+	}
+
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// header returns a string attribute of a binary mode message, under the current or the legacy prefix
+func header(headers map[string]interface{}, attribute string) (string, bool) {
+	for _, prefix := range []string{CloudEventsHeaderPrefix, legacyHeaderPrefix} {
+		if value, ok := headers[prefix+attribute].(string); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// headerTime reads the time attribute, sent as an RFC 3339 string or an AMQP timestamp
+func headerTime(raw interface{}) time.Time {
+	switch v := raw.(type) {
+	case time.Time:
+		return v.UTC()
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+// hasSpecVersion tells whether a JSON body carries the specversion attribute of a structured event
+func hasSpecVersion(body []byte) bool {
+	var probe struct {
+		SpecVersion string `json:"specversion"`
+	}
+	return json.Unmarshal(body, &probe) == nil && probe.SpecVersion != ""
+}
@@ -0,0 +1,101 @@
+package messagebroker
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtobufContentType is the content type of protobuf encoded bodies and event data
+const ProtobufContentType = "application/protobuf"
+
+// Codec encodes and decodes message bodies in one content type
+type Codec interface {
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// ProtoMarshaler is implemented by the types written as protobuf through a generated message
+type ProtoMarshaler interface {
+	MarshalProto() ([]byte, error)
+}
+
+// ProtoUnmarshaler is implemented by the types read from protobuf through a generated message
+type ProtoUnmarshaler interface {
+	UnmarshalProto(data []byte) error
+}
+
+// JSONCodec encodes the bodies as JSON
+type JSONCodec struct{}
+
+// ContentType returns application/json
+func (JSONCodec) ContentType() string {
+	return JSONContentType
+}
+
+// Marshal encodes a value as JSON
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes a JSON body into a value
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// ProtobufCodec encodes the bodies as protobuf
+// Values are generated messages or types implementing ProtoMarshaler and ProtoUnmarshaler
+type ProtobufCodec struct{}
+
+// ContentType returns application/protobuf
+func (ProtobufCodec) ContentType() string {
+	return ProtobufContentType
+}
+
+// Marshal encodes a value as protobuf
+// It returns an error if the value has no protobuf form
+func (ProtobufCodec) Marshal(v any) ([]byte, error) {
+	switch m := v.(type) {
+	case proto.Message:
+		return proto.Marshal(m)
+	case ProtoMarshaler:
+		return m.MarshalProto()
+	}
+	return nil, fmt.Errorf("protobuf codec: cannot marshal %T", v)
+}
+
+// Unmarshal decodes a protobuf body into a value
+// It returns an error if the value has no protobuf form
+func (ProtobufCodec) Unmarshal(data []byte, v any) error {
+	switch m := v.(type) {
+	case proto.Message:
+		return proto.Unmarshal(data, m)
+	case ProtoUnmarshaler:
+		return m.UnmarshalProto(data)
+	}
+	return fmt.Errorf("protobuf codec: cannot unmarshal into %T", v)
+}
+
+// CodecFor returns the codec of a content type, bodies without a content type are JSON as before codecs existed
+// It returns an error if the content type is not supported
+func CodecFor(contentType string) (Codec, error) {
+	if contentType == "" {
+		return JSONCodec{}, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("codec: invalid content type %q: %w", contentType, err)
+	}
+
+	switch mediaType {
+	case JSONContentType:
+		return JSONCodec{}, nil
+	case ProtobufContentType, "application/x-protobuf":
+		return ProtobufCodec{}, nil
+	}
+	return nil, fmt.Errorf("codec: unsupported content type %q", contentType)
+}
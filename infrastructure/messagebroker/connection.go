@@ -8,14 +8,33 @@ import (
 	"github.com/streadway/amqp"
 )
 
-// Connection represents a TCP connection to RabbitMQ
+// amqpChannel is the subset of the AMQP channel publishers and consumers use
+// It is implemented by *amqp.Channel and by the channels of the in-memory broker
+type amqpChannel interface {
+	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Close() error
+}
+
+// backend is a broker that runs without RabbitMQ, in memory or on Postgres
+type backend interface {
+	channel() (amqpChannel, error)
+	Close() error
+}
+
+// Connection represents a TCP connection to RabbitMQ, or to a broker backend running without it
 type Connection struct {
-	conn *amqp.Connection
+	conn    *amqp.Connection
+	backend backend
 }
 
 // Validate validates the connection
 func (c *Connection) Validate() error {
-	if c.conn == nil {
+	if c.conn == nil && c.backend == nil {
 		return errors.New("connection: connection cannot be nil")
 	}
 	return nil
@@ -33,16 +52,39 @@ func Connect(url string) (*Connection, error) {
 	return &Connection{conn: conn}, nil
 }
 
-// Close closes the TCP connection
+// ConnectMemory returns a connection to an in-memory broker
+// Every channel opened from the connection shares the broker, so publishers and consumers of one process reach each other
+func ConnectMemory(broker *MemoryBroker) (*Connection, error) {
+	if broker == nil {
+		return nil, errors.New("connection: memory broker cannot be nil")
+	}
+
+	slog.Info("messagebroker: using in-memory broker")
+
+	return &Connection{backend: broker}, nil
+}
+
+// Close closes the TCP connection, or the broker backend
 func (c *Connection) Close() error {
 	if c.conn != nil {
 		return c.conn.Close()
 	}
+	if c.backend != nil {
+		return c.backend.Close()
+	}
 	return nil
 }
 
 // NewChannel creates a new channel from this connection
 func (c *Connection) NewChannel() (*Channel, error) {
+	if c.backend != nil {
+		ch, err := c.backend.channel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open channel: %w", err)
+		}
+		return &Channel{ch: ch}, nil
+	}
+
 	ch, err := c.conn.Channel()
 	if err != nil {
 		return nil, fmt.Errorf("failed to open channel: %w", err)
@@ -53,7 +95,7 @@ func (c *Connection) NewChannel() (*Channel, error) {
 
 // Channel represents a virtual connection multiplexed over a TCP connection
 type Channel struct {
-	ch *amqp.Channel
+	ch amqpChannel
 }
 
 // Close closes the channel
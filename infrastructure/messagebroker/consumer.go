@@ -1,30 +1,77 @@
 package messagebroker
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/streadway/amqp"
 )
 
+// Headers set on dead-lettered messages
+const (
+	AttemptsHeader           = "x-attempts"             // Failed deliveries so far
+	FailureReasonHeader      = "x-failure-reason"       // Error returned by the last delivery
+	OriginalQueueHeader      = "x-original-queue"       // Queue the message failed on
+	OriginalRoutingKeyHeader = "x-original-routing-key" // Routing key the message was published with
+	DeadLetteredAtHeader     = "x-dead-lettered-at"     // Time the message was dead-lettered (RFC 3339)
+	DeadLetterIDHeader       = "x-dead-letter-id"       // Unique ID of the dead letter, used to archive it once
+)
+
+// ErrDefer is wrapped by handlers to requeue a message without counting a failed attempt
+// It is meant for failures of a dependency that is known to be down, like an open circuit breaker
+var ErrDefer = errors.New("message deferred")
+
 // MessageHandler handles incoming messages
 type MessageHandler interface {
 	HandleMessage(body []byte) error
 }
 
+// Message is a delivered message with its routing metadata
+type Message struct {
+	ID          string // Message ID set by the publisher, kept across retries
+	ContentType string // Content type of the body, tells structured CloudEvents apart
+	Body        []byte
+	Headers     map[string]interface{}
+	Exchange    string
+	RoutingKey  string
+}
+
+// DeliveryHandler handles incoming messages that need their headers
+type DeliveryHandler interface {
+	HandleDelivery(msg *Message) error
+}
+
+// Deduplicator tells whether a handler already processed a message
+// Handlers mark their messages as processed in the same transaction as the change they cause
+type Deduplicator interface {
+	Processed(ctx context.Context, messageID string, handler string) (bool, error)
+}
+
 // ConsumerConfig configures a consumer
 type ConsumerConfig struct {
-	Exchange   string // Exchange name for topic-based routing
-	QueueName  string // Queue name to consume from
-	RoutingKey string // Routing key for binding (usually same as queue name)
-	Workers    int
+	Exchange             string // Exchange name for topic-based routing
+	QueueName            string // Queue name to consume from
+	RoutingKey           string // Routing key for binding (usually same as queue name)
+	Workers              int
+	MaxAttempts          int           // Failed deliveries before a message is dead-lettered, 0 requeues forever
+	DeadLetterRoutingKey string        // Routing key and queue name for dead letters, defaults to queue name + ".dlq"
+	DeferDelay           time.Duration // Time a worker pauses before requeuing a deferred message
+	Handler              string        // Name the handler marks its processed messages with
+	Deduplicator         Deduplicator  // Skips messages the handler already processed, nil handles every delivery
 }
 
 // Consumer consumes messages from RabbitMQ
 type Consumer struct {
-	channel *Channel
-	config  ConsumerConfig
+	channel  *Channel
+	config   ConsumerConfig
+	stop     chan struct{}  // Closed to stop the workers from taking new messages
+	stopOnce sync.Once      // Closes stop once
+	workers  sync.WaitGroup // Workers still running
 }
 
 // NewConsumer creates a new consumer
@@ -44,15 +91,43 @@ func NewConsumer(channel *Channel, config ConsumerConfig) (*Consumer, error) {
 	if config.Workers < 1 {
 		config.Workers = 1
 	}
+	if config.DeadLetterRoutingKey == "" {
+		config.DeadLetterRoutingKey = config.QueueName + ".dlq" // Default dead letter queue next to the main queue
+	}
+	if config.Deduplicator != nil && config.Handler == "" {
+		return nil, errors.New("consumer: handler name cannot be empty when deduplicating")
+	}
 
 	return &Consumer{
 		channel: channel,
 		config:  config,
+		stop:    make(chan struct{}),
 	}, nil
 }
 
 // Start starts consuming messages
 func (c *Consumer) Start(handler MessageHandler) error {
+	return c.start(func(msg amqp.Delivery) error {
+		return handler.HandleMessage(msg.Body)
+	})
+}
+
+// StartDeliveries starts consuming messages, passing their headers and routing metadata to the handler
+func (c *Consumer) StartDeliveries(handler DeliveryHandler) error {
+	return c.start(func(msg amqp.Delivery) error {
+		return handler.HandleDelivery(&Message{
+			ID:          msg.MessageId,
+			ContentType: msg.ContentType,
+			Body:        msg.Body,
+			Headers:     map[string]interface{}(msg.Headers),
+			Exchange:    msg.Exchange,
+			RoutingKey:  msg.RoutingKey,
+		})
+	})
+}
+
+// start declares the topology and starts the workers
+func (c *Consumer) start(handle func(msg amqp.Delivery) error) error {
 	// Declare topic exchange for flexible routing
 	err := c.channel.ch.ExchangeDeclare(
 		c.config.Exchange, // exchange name
@@ -92,6 +167,13 @@ func (c *Consumer) Start(handler MessageHandler) error {
 		return fmt.Errorf("failed to bind queue to exchange: %w", err)
 	}
 
+	// Declare dead letter queue so dead letters are kept until they are archived
+	if c.config.MaxAttempts > 0 {
+		if err := c.declareDeadLetterQueue(); err != nil {
+			return err
+		}
+	}
+
 	// Set prefetch count
 	if err := c.channel.ch.Qos(c.config.Workers*2, 0, false); err != nil {
 		return fmt.Errorf("failed to set QoS: %w", err)
@@ -113,19 +195,204 @@ func (c *Consumer) Start(handler MessageHandler) error {
 
 	// Start workers
 	for i := 0; i < c.config.Workers; i++ {
-		go c.worker(i, msgs, handler)
+		c.workers.Add(1)
+		go c.worker(i, msgs, handle)
 	}
 
 	return nil
 }
 
-func (c *Consumer) worker(id int, msgs <-chan amqp.Delivery, handler MessageHandler) {
-	for msg := range msgs {
-		if err := handler.HandleMessage(msg.Body); err != nil {
+// Stop stops the workers from taking new messages, waits for the messages being handled and closes the channel
+// Messages prefetched but not handled yet are requeued by the broker when the channel closes
+// It returns an error if the context is done before the workers finish, leaving the channel open
+func (c *Consumer) Stop(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		c.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("consumer: failed to wait for workers of queue %s: %w", c.config.QueueName, ctx.Err())
+	}
+
+	if err := c.channel.Close(); err != nil {
+		return fmt.Errorf("consumer: failed to close channel: %w", err)
+	}
+	return nil
+}
+
+// declareDeadLetterQueue declares the dead letter queue and binds it to the exchange
+func (c *Consumer) declareDeadLetterQueue() error {
+	_, err := c.channel.ch.QueueDeclare(
+		c.config.DeadLetterRoutingKey, // queue name
+		true,                          // durable
+		false,                         // auto-delete
+		false,                         // exclusive
+		false,                         // no-wait
+		nil,                           // args
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare dead letter queue: %w", err)
+	}
+
+	err = c.channel.ch.QueueBind(
+		c.config.DeadLetterRoutingKey, // queue name
+		c.config.DeadLetterRoutingKey, // routing key
+		c.config.Exchange,             // exchange
+		false,                         // no-wait
+		nil,                           // args
+	)
+	if err != nil {
+		return fmt.Errorf("failed to bind dead letter queue to exchange: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Consumer) worker(id int, msgs <-chan amqp.Delivery, handle func(msg amqp.Delivery) error) {
+	defer c.workers.Done()
+
+	for {
+		var msg amqp.Delivery
+		var ok bool
+
+		select {
+		case <-c.stop:
+			return
+		case msg, ok = <-msgs:
+			if !ok {
+				return
+			}
+		}
+
+		// A message received together with the stop signal is left for the broker to requeue
+		select {
+		case <-c.stop:
+			return
+		default:
+		}
+
+		if c.processed(id, msg) {
+			msg.Ack(false)
+			continue
+		}
+
+		err := handle(msg)
+		if errors.Is(err, ErrDefer) {
+			c.deferMessage(id, msg, err)
+		} else if err != nil {
 			slog.Error("Worker failed to handle message", "worker_id", id, "error", err)
-			msg.Nack(false, true) // requeue
+			c.fail(msg, err)
 		} else {
 			msg.Ack(false)
 		}
 	}
 }
+
+// processed reports whether the handler already processed a redelivered message
+// Messages without an ID are always handled, and so are messages whose lookup fails since handlers stay idempotent
+func (c *Consumer) processed(id int, msg amqp.Delivery) bool {
+	if c.config.Deduplicator == nil || msg.MessageId == "" {
+		return false
+	}
+
+	ctx := ContextFromHeaders(context.Background(), msg.Headers)
+	processed, err := c.config.Deduplicator.Processed(ctx, msg.MessageId, c.config.Handler)
+	if err != nil {
+		slog.WarnContext(ctx, "Worker failed to check processed message, handling it", "worker_id", id, "message_id", msg.MessageId, "error", err)
+		return false
+	}
+	if processed {
+		slog.InfoContext(ctx, "Worker skipped processed message", "worker_id", id, "queue", c.config.QueueName, "message_id", msg.MessageId, "handler", c.config.Handler)
+	}
+
+	return processed
+}
+
+// deferMessage pauses the worker for the defer delay and requeues the message as is
+// The pause keeps workers from spinning on messages that cannot be handled yet
+func (c *Consumer) deferMessage(id int, msg amqp.Delivery, cause error) {
+	slog.Warn("Worker deferred message", "worker_id", id, "queue", c.config.QueueName, "delay", c.config.DeferDelay, "reason", cause.Error())
+
+	// The pause is cut short on stop, the message is requeued either way
+	timer := time.NewTimer(c.config.DeferDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-c.stop:
+	}
+
+	msg.Nack(false, true) // requeue
+}
+
+// fail requeues a failed message, or dead-letters it once it reaches the maximum attempts
+// Without a maximum, the message is requeued as is
+func (c *Consumer) fail(msg amqp.Delivery, cause error) {
+	if c.config.MaxAttempts <= 0 {
+		msg.Nack(false, true) // requeue
+		return
+	}
+
+	attempts := attemptsOf(msg.Headers) + 1
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[AttemptsHeader] = int32(attempts)
+
+	exchange := ""                   // Default exchange delivers straight to the queue named by the routing key
+	routingKey := c.config.QueueName // Retry only on this queue, not on every queue bound to the original key
+	if attempts >= c.config.MaxAttempts {
+		exchange = c.config.Exchange
+		routingKey = c.config.DeadLetterRoutingKey
+		headers[FailureReasonHeader] = cause.Error()
+		headers[OriginalQueueHeader] = c.config.QueueName
+		headers[OriginalRoutingKeyHeader] = msg.RoutingKey
+		headers[DeadLetteredAtHeader] = time.Now().UTC().Format(time.RFC3339)
+		headers[DeadLetterIDHeader] = uuid.New().String()
+	}
+
+	err := c.channel.ch.Publish(
+		exchange,
+		routingKey,
+		false, // mandatory
+		false, // immediate
+		amqp.Publishing{
+			Headers:      headers,
+			MessageId:    msg.MessageId,
+			ContentType:  msg.ContentType,
+			DeliveryMode: amqp.Persistent,
+			Body:         msg.Body,
+		},
+	)
+	if err != nil {
+		slog.Error("Failed to republish message, requeuing", "queue", c.config.QueueName, "error", err)
+		msg.Nack(false, true) // requeue
+		return
+	}
+
+	if attempts >= c.config.MaxAttempts {
+		slog.Warn("Message dead-lettered", "queue", c.config.QueueName, "attempts", attempts, "reason", cause.Error())
+	}
+
+	msg.Ack(false)
+}
+
+// attemptsOf returns the failed attempts recorded in the message headers
+func attemptsOf(headers amqp.Table) int {
+	switch v := headers[AttemptsHeader].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
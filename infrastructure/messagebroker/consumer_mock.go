@@ -12,3 +12,9 @@ func (m *MockConsumer) Start(handler MessageHandler) error {
 	args := m.Called(handler)
 	return args.Error(0)
 }
+
+// StartDeliveries mocks the StartDeliveries method
+func (m *MockConsumer) StartDeliveries(handler DeliveryHandler) error {
+	args := m.Called(handler)
+	return args.Error(0)
+}
@@ -0,0 +1,530 @@
+package messagebroker
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+)
+
+// Queue arguments the in-memory broker honors, as RabbitMQ does
+const (
+	DeadLetterExchangeArg   = "x-dead-letter-exchange"    // Exchange rejected messages are republished to
+	DeadLetterRoutingKeyArg = "x-dead-letter-routing-key" // Routing key of rejected messages, defaults to the original one
+)
+
+// MemoryBroker is an in-process broker with the AMQP semantics publishers and consumers rely on
+// Topic, direct and fanout exchanges route to bound queues, the default exchange routes to the queue named by the routing key,
+// consumers get at most the prefetch count of unacked messages, nacked messages are requeued or dead-lettered
+// and the unacked messages of a closed channel are requeued
+// Messages are lost when the process exits, it is meant for tests and single binary local runs
+type MemoryBroker struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	exchanges map[string]*memoryExchange
+	queues    map[string]*memoryQueue
+	channels  map[*memoryChannel]struct{}
+	closed    bool
+}
+
+// memoryExchange is a declared exchange and its bindings
+type memoryExchange struct {
+	kind     string
+	bindings []memoryBinding
+}
+
+// memoryBinding binds a queue to an exchange with a routing key or pattern
+type memoryBinding struct {
+	queue string
+	key   string
+}
+
+// memoryQueue holds the messages ready to be delivered, in order
+type memoryQueue struct {
+	name      string
+	args      amqp.Table
+	messages  []*memoryMessage
+	enqueued  uint64
+	unacked   int
+	consumers int
+}
+
+// memoryMessage is a message routed to a queue
+type memoryMessage struct {
+	seq         uint64 // Position of the message in its queue, kept when it is requeued
+	exchange    string
+	routingKey  string
+	publishing  amqp.Publishing
+	redelivered bool
+}
+
+// NewMemoryBroker creates an empty in-memory broker
+func NewMemoryBroker() *MemoryBroker {
+	b := &MemoryBroker{
+		exchanges: map[string]*memoryExchange{},
+		queues:    map[string]*memoryQueue{},
+		channels:  map[*memoryChannel]struct{}{},
+	}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Close closes every channel, their consumers stop receiving messages
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for ch := range b.channels {
+		ch.closeLocked()
+	}
+	b.cond.Broadcast()
+	return nil
+}
+
+// Messages returns the messages of a queue, ready or delivered and not acked yet
+// It returns 0 if the queue was not declared
+func (b *MemoryBroker) Messages(queue string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q, ok := b.queues[queue]
+	if !ok {
+		return 0
+	}
+	return len(q.messages) + q.unacked
+}
+
+// channel opens a new channel on the broker
+func (b *MemoryBroker) channel() (amqpChannel, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, amqp.ErrClosed
+	}
+
+	ch := &memoryChannel{
+		broker:  b,
+		unacked: map[uint64]*memoryDelivery{},
+	}
+	b.channels[ch] = struct{}{}
+	return ch, nil
+}
+
+// route appends a message to every queue the exchange routes its routing key to
+// Messages no queue is bound for are dropped, as RabbitMQ does with unroutable messages
+func (b *MemoryBroker) route(exchange, routingKey string, msg amqp.Publishing) error {
+	if exchange == "" {
+		if q, ok := b.queues[routingKey]; ok {
+			b.enqueue(q, exchange, routingKey, msg)
+		}
+		return nil
+	}
+
+	ex, ok := b.exchanges[exchange]
+	if !ok {
+		return fmt.Errorf("memory broker: exchange %q not found", exchange)
+	}
+
+	routed := map[string]bool{}
+	for _, binding := range ex.bindings {
+		if routed[binding.queue] || !routes(ex.kind, binding.key, routingKey) {
+			continue
+		}
+		routed[binding.queue] = true
+		b.enqueue(b.queues[binding.queue], exchange, routingKey, msg)
+	}
+	return nil
+}
+
+// enqueue appends a copy of a message to a queue
+func (b *MemoryBroker) enqueue(q *memoryQueue, exchange, routingKey string, msg amqp.Publishing) {
+	msg.Headers = copyTable(msg.Headers)
+	msg.Body = append([]byte(nil), msg.Body...)
+	q.enqueued++
+	q.messages = append(q.messages, &memoryMessage{
+		seq:        q.enqueued,
+		exchange:   exchange,
+		routingKey: routingKey,
+		publishing: msg,
+	})
+}
+
+// requeue puts a message back at its original position in its queue, marked as redelivered
+// Messages requeued together keep their order whatever order they are settled in, as RabbitMQ does
+func (b *MemoryBroker) requeue(q *memoryQueue, msg *memoryMessage) {
+	msg.redelivered = true
+	i, _ := slices.BinarySearchFunc(q.messages, msg.seq, func(m *memoryMessage, seq uint64) int {
+		return cmp.Compare(m.seq, seq)
+	})
+	q.messages = slices.Insert(q.messages, i, msg)
+}
+
+// deadLetter republishes a rejected message to the dead letter exchange of its queue
+// Messages of queues without one are dropped, as RabbitMQ does
+func (b *MemoryBroker) deadLetter(q *memoryQueue, msg *memoryMessage) {
+	exchange, ok := q.args[DeadLetterExchangeArg].(string)
+	if !ok {
+		return
+	}
+
+	routingKey := msg.routingKey
+	if key, ok := q.args[DeadLetterRoutingKeyArg].(string); ok {
+		routingKey = key
+	}
+
+	_ = b.route(exchange, routingKey, msg.publishing)
+}
+
+// memoryChannel is a channel of the in-memory broker
+type memoryChannel struct {
+	broker    *MemoryBroker
+	prefetch  int
+	nextTag   uint64
+	unacked   map[uint64]*memoryDelivery
+	consumers []*memoryConsumer
+	closed    bool
+}
+
+// memoryDelivery is a message delivered to a consumer and not acked yet
+type memoryDelivery struct {
+	queue    *memoryQueue
+	message  *memoryMessage
+	consumer *memoryConsumer
+}
+
+// memoryConsumer receives the messages of a queue
+type memoryConsumer struct {
+	channel    *memoryChannel
+	queue      *memoryQueue
+	tag        string
+	autoAck    bool
+	prefetch   int
+	unacked    int
+	cancelled  bool
+	deliveries chan amqp.Delivery
+	done       chan struct{}
+}
+
+// ExchangeDeclare declares a topic, direct or fanout exchange
+// It returns an error if the exchange exists with another kind
+func (ch *memoryChannel) ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	b := ch.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ch.closed {
+		return amqp.ErrClosed
+	}
+
+	switch kind {
+	case amqp.ExchangeTopic, amqp.ExchangeDirect, amqp.ExchangeFanout:
+	default:
+		return fmt.Errorf("memory broker: unsupported exchange kind %q", kind)
+	}
+
+	if ex, ok := b.exchanges[name]; ok {
+		if ex.kind != kind {
+			return fmt.Errorf("memory broker: exchange %q already declared as %s", name, ex.kind)
+		}
+		return nil
+	}
+
+	b.exchanges[name] = &memoryExchange{kind: kind}
+	return nil
+}
+
+// QueueDeclare declares a queue, queues without a name get a generated one
+// Declaring an existing queue returns its state
+func (ch *memoryChannel) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	b := ch.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ch.closed {
+		return amqp.Queue{}, amqp.ErrClosed
+	}
+
+	if name == "" {
+		name = "amq.gen-" + uuid.New().String()
+	}
+
+	q, ok := b.queues[name]
+	if !ok {
+		q = &memoryQueue{name: name, args: copyTable(args)}
+		b.queues[name] = q
+	}
+
+	return amqp.Queue{Name: q.name, Messages: len(q.messages), Consumers: q.consumers}, nil
+}
+
+// QueueBind binds a queue to an exchange
+// It returns an error if the queue or the exchange were not declared
+func (ch *memoryChannel) QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error {
+	b := ch.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ch.closed {
+		return amqp.ErrClosed
+	}
+
+	if _, ok := b.queues[name]; !ok {
+		return fmt.Errorf("memory broker: queue %q not found", name)
+	}
+	ex, ok := b.exchanges[exchange]
+	if !ok {
+		return fmt.Errorf("memory broker: exchange %q not found", exchange)
+	}
+
+	binding := memoryBinding{queue: name, key: key}
+	for _, existing := range ex.bindings {
+		if existing == binding {
+			return nil
+		}
+	}
+	ex.bindings = append(ex.bindings, binding)
+	return nil
+}
+
+// Qos sets the prefetch count of the consumers started afterwards, 0 delivers without limit
+func (ch *memoryChannel) Qos(prefetchCount, prefetchSize int, global bool) error {
+	b := ch.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ch.closed {
+		return amqp.ErrClosed
+	}
+
+	ch.prefetch = prefetchCount
+	return nil
+}
+
+// Consume starts a consumer on a queue
+// The deliveries channel is closed when the channel or the broker is closed
+func (ch *memoryChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	b := ch.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ch.closed {
+		return nil, amqp.ErrClosed
+	}
+
+	q, ok := b.queues[queue]
+	if !ok {
+		return nil, fmt.Errorf("memory broker: queue %q not found", queue)
+	}
+
+	if consumer == "" {
+		consumer = "ctag-" + uuid.New().String()
+	}
+
+	c := &memoryConsumer{
+		channel:    ch,
+		queue:      q,
+		tag:        consumer,
+		autoAck:    autoAck,
+		prefetch:   ch.prefetch,
+		deliveries: make(chan amqp.Delivery),
+		done:       make(chan struct{}),
+	}
+	ch.consumers = append(ch.consumers, c)
+	q.consumers++
+
+	go c.dispatch()
+
+	return c.deliveries, nil
+}
+
+// Publish routes a message through an exchange
+// It returns an error if the exchange was not declared
+func (ch *memoryChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	b := ch.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ch.closed {
+		return amqp.ErrClosed
+	}
+
+	if err := b.route(exchange, key, msg); err != nil {
+		return err
+	}
+
+	b.cond.Broadcast()
+	return nil
+}
+
+// Close closes the channel, its unacked messages are requeued
+func (ch *memoryChannel) Close() error {
+	b := ch.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch.closeLocked()
+	delete(b.channels, ch)
+	b.cond.Broadcast()
+	return nil
+}
+
+// closeLocked cancels the consumers of the channel and requeues its unacked messages
+// The broker lock must be held
+func (ch *memoryChannel) closeLocked() {
+	if ch.closed {
+		return
+	}
+	ch.closed = true
+
+	for _, c := range ch.consumers {
+		c.cancelled = true
+		c.queue.consumers--
+		close(c.done)
+	}
+
+	for tag, d := range ch.unacked {
+		delete(ch.unacked, tag)
+		d.queue.unacked--
+		ch.broker.requeue(d.queue, d.message)
+	}
+}
+
+// Ack acknowledges a delivery, or every delivery up to it when multiple is set
+func (ch *memoryChannel) Ack(tag uint64, multiple bool) error {
+	return ch.settle(tag, multiple, func(d *memoryDelivery) {})
+}
+
+// Nack rejects a delivery, or every delivery up to it when multiple is set
+// Rejected messages are requeued, or dead-lettered when requeue is not set
+func (ch *memoryChannel) Nack(tag uint64, multiple, requeue bool) error {
+	return ch.settle(tag, multiple, func(d *memoryDelivery) {
+		if requeue {
+			ch.broker.requeue(d.queue, d.message)
+		} else {
+			ch.broker.deadLetter(d.queue, d.message)
+		}
+	})
+}
+
+// Reject rejects a single delivery
+func (ch *memoryChannel) Reject(tag uint64, requeue bool) error {
+	return ch.Nack(tag, false, requeue)
+}
+
+// settle removes deliveries from the unacked ones and hands them to fn
+// It returns an error if the delivery tag is unknown, e.g. already settled
+func (ch *memoryChannel) settle(tag uint64, multiple bool, fn func(d *memoryDelivery)) error {
+	b := ch.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ch.closed {
+		return amqp.ErrClosed
+	}
+
+	tags := []uint64{tag}
+	if multiple {
+		tags = tags[:0]
+		for t := range ch.unacked {
+			if t <= tag {
+				tags = append(tags, t)
+			}
+		}
+		slices.Sort(tags)
+	}
+
+	for _, t := range tags {
+		d, ok := ch.unacked[t]
+		if !ok {
+			return fmt.Errorf("memory broker: unknown delivery tag %d", t)
+		}
+		delete(ch.unacked, t)
+		d.queue.unacked--
+		d.consumer.unacked--
+		fn(d)
+	}
+
+	b.cond.Broadcast()
+	return nil
+}
+
+// dispatch delivers the messages of the queue to the consumer until it is cancelled
+// Without auto-ack, the consumer gets at most the prefetch count of unacked messages
+func (c *memoryConsumer) dispatch() {
+	defer close(c.deliveries)
+
+	b := c.channel.broker
+	for {
+		b.mu.Lock()
+		for !c.cancelled && (len(c.queue.messages) == 0 || c.full()) {
+			b.cond.Wait()
+		}
+		if c.cancelled {
+			b.mu.Unlock()
+			return
+		}
+
+		msg := c.queue.messages[0]
+		c.queue.messages = c.queue.messages[1:]
+
+		c.channel.nextTag++
+		tag := c.channel.nextTag
+		if !c.autoAck {
+			c.channel.unacked[tag] = &memoryDelivery{queue: c.queue, message: msg, consumer: c}
+			c.queue.unacked++
+			c.unacked++
+		}
+		delivery := c.delivery(tag, msg)
+		b.mu.Unlock()
+
+		select {
+		case c.deliveries <- delivery:
+		case <-c.done:
+			// Cancelled before the message was taken, closing the channel requeued it unless it was auto-acked
+			if c.autoAck {
+				b.mu.Lock()
+				b.requeue(c.queue, msg)
+				b.cond.Broadcast()
+				b.mu.Unlock()
+			}
+			return
+		}
+	}
+}
+
+// full tells whether the consumer reached its prefetch count
+func (c *memoryConsumer) full() bool {
+	return !c.autoAck && c.prefetch > 0 && c.unacked >= c.prefetch
+}
+
+// delivery builds the AMQP delivery of a message
+func (c *memoryConsumer) delivery(tag uint64, msg *memoryMessage) amqp.Delivery {
+	p := msg.publishing
+	return amqp.Delivery{
+		Acknowledger:    c.channel,
+		Headers:         copyTable(p.Headers),
+		ContentType:     p.ContentType,
+		ContentEncoding: p.ContentEncoding,
+		DeliveryMode:    p.DeliveryMode,
+		Priority:        p.Priority,
+		CorrelationId:   p.CorrelationId,
+		ReplyTo:         p.ReplyTo,
+		Expiration:      p.Expiration,
+		MessageId:       p.MessageId,
+		Timestamp:       p.Timestamp,
+		Type:            p.Type,
+		UserId:          p.UserId,
+		AppId:           p.AppId,
+		ConsumerTag:     c.tag,
+		DeliveryTag:     tag,
+		Redelivered:     msg.redelivered,
+		Exchange:        msg.exchange,
+		RoutingKey:      msg.routingKey,
+		Body:            p.Body,
+	}
+}
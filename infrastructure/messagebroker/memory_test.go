@@ -0,0 +1,506 @@
+package messagebroker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMemoryChannel opens a channel on the broker
+func newTestMemoryChannel(t *testing.T, b *MemoryBroker) *memoryChannel {
+	t.Helper()
+
+	ch, err := b.channel()
+	require.NoError(t, err)
+	return ch.(*memoryChannel)
+}
+
+// declarePayments declares the payments topic exchange and the payments queue bound to payment.*
+func declarePayments(t *testing.T, ch *memoryChannel, args amqp.Table) {
+	t.Helper()
+
+	require.NoError(t, ch.ExchangeDeclare("payments", amqp.ExchangeTopic, true, false, false, false, nil))
+	_, err := ch.QueueDeclare("payments", true, false, false, false, args)
+	require.NoError(t, err)
+	require.NoError(t, ch.QueueBind("payments", "payment.*", "payments", false, nil))
+}
+
+// publishPayments publishes a payment.created message for each message ID to the payments exchange
+func publishPayments(t *testing.T, ch *memoryChannel, messageIDs ...string) {
+	t.Helper()
+
+	for _, id := range messageIDs {
+		require.NoError(t, ch.Publish("payments", "payment.created", false, false, amqp.Publishing{MessageId: id, Body: []byte(id)}))
+	}
+}
+
+// assertNoDelivery asserts the consumer gets no delivery for a while, a closed consumer gets none
+func assertNoDelivery(t *testing.T, deliveries <-chan amqp.Delivery) {
+	t.Helper()
+
+	select {
+	case d, ok := <-deliveries:
+		if ok {
+			t.Fatalf("unexpected delivery %q", d.MessageId)
+		}
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// messageIDsOf returns the message IDs of the deliveries
+func messageIDsOf(deliveries []amqp.Delivery) []string {
+	ids := make([]string, 0, len(deliveries))
+	for _, d := range deliveries {
+		ids = append(ids, d.MessageId)
+	}
+	return ids
+}
+
+func TestMemoryChannel_Publish(t *testing.T) {
+	tests := []struct {
+		name             string
+		kind             string
+		bindings         map[string][]string
+		exchange         string
+		routingKey       string
+		expectedError    string
+		expectedMessages map[string]int
+	}{
+		{
+			name:             "when the exchange is topic it should route to the queues whose pattern matches",
+			kind:             amqp.ExchangeTopic,
+			bindings:         map[string][]string{"created": {"payment.created"}, "all": {"payment.#"}, "refunds": {"refund.*"}},
+			exchange:         "events",
+			routingKey:       "payment.created",
+			expectedMessages: map[string]int{"created": 1, "all": 1, "refunds": 0},
+		},
+		{
+			name:             "when a queue is bound with several matching keys it should get a single copy",
+			kind:             amqp.ExchangeTopic,
+			bindings:         map[string][]string{"all": {"payment.*", "#"}},
+			exchange:         "events",
+			routingKey:       "payment.created",
+			expectedMessages: map[string]int{"all": 1},
+		},
+		{
+			name:             "when the exchange is direct it should route to the queues bound with the routing key",
+			kind:             amqp.ExchangeDirect,
+			bindings:         map[string][]string{"created": {"payment.created"}, "wildcard": {"payment.*"}},
+			exchange:         "events",
+			routingKey:       "payment.created",
+			expectedMessages: map[string]int{"created": 1, "wildcard": 0},
+		},
+		{
+			name:             "when the exchange is fanout it should route to every bound queue",
+			kind:             amqp.ExchangeFanout,
+			bindings:         map[string][]string{"first": {""}, "second": {"refund.created"}},
+			exchange:         "events",
+			routingKey:       "payment.created",
+			expectedMessages: map[string]int{"first": 1, "second": 1},
+		},
+		{
+			name:             "when the exchange is the default one it should route to the queue named by the routing key",
+			kind:             amqp.ExchangeTopic,
+			bindings:         map[string][]string{"created": {"payment.created"}, "payment.created": nil},
+			exchange:         "",
+			routingKey:       "payment.created",
+			expectedMessages: map[string]int{"created": 0, "payment.created": 1},
+		},
+		{
+			name:             "when no queue is bound for the routing key it should drop the message",
+			kind:             amqp.ExchangeTopic,
+			bindings:         map[string][]string{"refunds": {"refund.*"}},
+			exchange:         "events",
+			routingKey:       "payment.created",
+			expectedMessages: map[string]int{"refunds": 0},
+		},
+		{
+			name:             "when the exchange was not declared it should return error",
+			kind:             amqp.ExchangeTopic,
+			bindings:         map[string][]string{"created": {"payment.created"}},
+			exchange:         "missing",
+			routingKey:       "payment.created",
+			expectedError:    `memory broker: exchange "missing" not found`,
+			expectedMessages: map[string]int{"created": 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			b := NewMemoryBroker()
+			ch := newTestMemoryChannel(t, b)
+			require.NoError(t, ch.ExchangeDeclare("events", tt.kind, true, false, false, false, nil))
+			for queue, keys := range tt.bindings {
+				_, err := ch.QueueDeclare(queue, true, false, false, false, nil)
+				require.NoError(t, err)
+				for _, key := range keys {
+					require.NoError(t, ch.QueueBind(queue, key, "events", false, nil))
+				}
+			}
+
+			// Act
+			err := ch.Publish(tt.exchange, tt.routingKey, false, false, amqp.Publishing{MessageId: "msg-1"})
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			for queue, expected := range tt.expectedMessages {
+				assert.Equal(t, expected, b.Messages(queue), queue)
+			}
+		})
+	}
+}
+
+func TestMemoryChannel_ExchangeDeclare(t *testing.T) {
+	tests := []struct {
+		name          string
+		declared      string
+		kind          string
+		expectedError string
+	}{
+		{name: "when the exchange exists with the same kind it should succeed", declared: amqp.ExchangeTopic, kind: amqp.ExchangeTopic},
+		{name: "when the exchange exists with another kind it should return error", declared: amqp.ExchangeTopic, kind: amqp.ExchangeDirect, expectedError: `memory broker: exchange "payments" already declared as topic`},
+		{name: "when the kind is not supported it should return error", kind: amqp.ExchangeHeaders, expectedError: `memory broker: unsupported exchange kind "headers"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ch := newTestMemoryChannel(t, NewMemoryBroker())
+			if tt.declared != "" {
+				require.NoError(t, ch.ExchangeDeclare("payments", tt.declared, true, false, false, false, nil))
+			}
+
+			// Act
+			err := ch.ExchangeDeclare("payments", tt.kind, true, false, false, false, nil)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMemoryConsumer_Prefetch(t *testing.T) {
+	t.Run("when the consumer reaches the prefetch count it should wait for a settlement to get more messages", func(t *testing.T) {
+		// Arrange
+		ch := newTestMemoryChannel(t, NewMemoryBroker())
+		declarePayments(t, ch, nil)
+		publishPayments(t, ch, "msg-1", "msg-2", "msg-3")
+		require.NoError(t, ch.Qos(2, 0, false))
+
+		// Act
+		deliveries, err := ch.Consume("payments", "", false, false, false, false, nil)
+		require.NoError(t, err)
+		first := receive(t, deliveries, 2)
+
+		// Assert
+		assert.Equal(t, []string{"msg-1", "msg-2"}, messageIDsOf(first))
+		assertNoDelivery(t, deliveries)
+
+		require.NoError(t, first[0].Ack(false))
+		assert.Equal(t, []string{"msg-3"}, messageIDsOf(receive(t, deliveries, 1)))
+	})
+
+	t.Run("when the prefetch count is zero it should deliver every message", func(t *testing.T) {
+		// Arrange
+		ch := newTestMemoryChannel(t, NewMemoryBroker())
+		declarePayments(t, ch, nil)
+		publishPayments(t, ch, "msg-1", "msg-2", "msg-3")
+		require.NoError(t, ch.Qos(0, 0, false))
+
+		// Act
+		deliveries, err := ch.Consume("payments", "", false, false, false, false, nil)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, []string{"msg-1", "msg-2", "msg-3"}, messageIDsOf(receive(t, deliveries, 3)))
+	})
+
+	t.Run("when the consumer auto-acks it should ignore the prefetch count", func(t *testing.T) {
+		// Arrange
+		b := NewMemoryBroker()
+		ch := newTestMemoryChannel(t, b)
+		declarePayments(t, ch, nil)
+		publishPayments(t, ch, "msg-1", "msg-2", "msg-3")
+		require.NoError(t, ch.Qos(1, 0, false))
+
+		// Act
+		deliveries, err := ch.Consume("payments", "", true, false, false, false, nil)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, []string{"msg-1", "msg-2", "msg-3"}, messageIDsOf(receive(t, deliveries, 3)))
+		assert.Equal(t, 0, b.Messages("payments"))
+	})
+
+	t.Run("when the prefetch count changes it should only apply to the consumers started afterwards", func(t *testing.T) {
+		// Arrange
+		ch := newTestMemoryChannel(t, NewMemoryBroker())
+		declarePayments(t, ch, nil)
+		publishPayments(t, ch, "msg-1", "msg-2")
+		require.NoError(t, ch.Qos(1, 0, false))
+		deliveries, err := ch.Consume("payments", "", false, false, false, false, nil)
+		require.NoError(t, err)
+
+		// Act
+		require.NoError(t, ch.Qos(0, 0, false))
+
+		// Assert
+		receive(t, deliveries, 1)
+		assertNoDelivery(t, deliveries)
+	})
+}
+
+func TestMemoryChannel_Settle(t *testing.T) {
+	tests := []struct {
+		name                string
+		settle              func(ch *memoryChannel) error
+		expectedError       string
+		expectedMessages    int
+		expectedRedelivered []string
+	}{
+		{
+			name:             "when a delivery is acked it should remove its message only",
+			settle:           func(ch *memoryChannel) error { return ch.Ack(2, false) },
+			expectedMessages: 2,
+		},
+		{
+			name:             "when deliveries are acked with multiple it should remove every message up to the tag",
+			settle:           func(ch *memoryChannel) error { return ch.Ack(2, true) },
+			expectedMessages: 1,
+		},
+		{
+			name:                "when a delivery is nacked with requeue it should deliver its message again as redelivered",
+			settle:              func(ch *memoryChannel) error { return ch.Nack(2, false, true) },
+			expectedMessages:    3,
+			expectedRedelivered: []string{"msg-2"},
+		},
+		{
+			name:                "when deliveries are nacked with multiple and requeue it should deliver their messages again in order",
+			settle:              func(ch *memoryChannel) error { return ch.Nack(3, true, true) },
+			expectedMessages:    3,
+			expectedRedelivered: []string{"msg-1", "msg-2", "msg-3"},
+		},
+		{
+			name:             "when deliveries are nacked with multiple without requeue it should drop their messages",
+			settle:           func(ch *memoryChannel) error { return ch.Nack(2, true, false) },
+			expectedMessages: 1,
+		},
+		{
+			name:                "when a delivery is rejected with requeue it should deliver its message again",
+			settle:              func(ch *memoryChannel) error { return ch.Reject(1, true) },
+			expectedMessages:    3,
+			expectedRedelivered: []string{"msg-1"},
+		},
+		{
+			name: "when the delivery was already settled it should return error",
+			settle: func(ch *memoryChannel) error {
+				if err := ch.Ack(1, false); err != nil {
+					return err
+				}
+				return ch.Ack(1, false)
+			},
+			expectedError:    "memory broker: unknown delivery tag 1",
+			expectedMessages: 2,
+		},
+		{
+			name: "when the channel was closed it should return closed error",
+			settle: func(ch *memoryChannel) error {
+				if err := ch.Close(); err != nil {
+					return err
+				}
+				return ch.Ack(1, false)
+			},
+			expectedError:    amqp.ErrClosed.Error(),
+			expectedMessages: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			b := NewMemoryBroker()
+			ch := newTestMemoryChannel(t, b)
+			declarePayments(t, ch, nil)
+			publishPayments(t, ch, "msg-1", "msg-2", "msg-3")
+
+			deliveries, err := ch.Consume("payments", "", false, false, false, false, nil)
+			require.NoError(t, err)
+			received := receive(t, deliveries, 3)
+			require.Equal(t, []uint64{1, 2, 3}, tagsOf(received))
+			for _, d := range received {
+				require.False(t, d.Redelivered)
+			}
+
+			// Act
+			err = tt.settle(ch)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedMessages, b.Messages("payments"))
+
+			if len(tt.expectedRedelivered) == 0 {
+				assertNoDelivery(t, deliveries)
+				return
+			}
+			redelivered := receive(t, deliveries, len(tt.expectedRedelivered))
+			assert.Equal(t, tt.expectedRedelivered, messageIDsOf(redelivered))
+			for _, d := range redelivered {
+				assert.True(t, d.Redelivered)
+			}
+		})
+	}
+}
+
+func TestMemoryChannel_Close(t *testing.T) {
+	t.Run("when the channel has unacked deliveries it should requeue their messages in order", func(t *testing.T) {
+		// Arrange
+		b := NewMemoryBroker()
+		ch := newTestMemoryChannel(t, b)
+		declarePayments(t, ch, nil)
+		publishPayments(t, ch, "msg-1", "msg-2", "msg-3")
+		require.NoError(t, ch.Qos(2, 0, false))
+		deliveries, err := ch.Consume("payments", "", false, false, false, false, nil)
+		require.NoError(t, err)
+		receive(t, deliveries, 2)
+
+		// Act
+		err = ch.Close()
+
+		// Assert
+		assert.NoError(t, err)
+		_, open := <-deliveries
+		assert.False(t, open, "deliveries are closed with the channel")
+		assert.Equal(t, 3, b.Messages("payments"))
+		assert.Equal(t, amqp.ErrClosed, ch.Ack(1, false))
+
+		other := newTestMemoryChannel(t, b)
+		otherDeliveries, err := other.Consume("payments", "", false, false, false, false, nil)
+		require.NoError(t, err)
+		redelivered := receive(t, otherDeliveries, 3)
+		assert.Equal(t, []string{"msg-1", "msg-2", "msg-3"}, messageIDsOf(redelivered))
+		assert.Equal(t, []bool{true, true, false}, []bool{redelivered[0].Redelivered, redelivered[1].Redelivered, redelivered[2].Redelivered})
+	})
+
+	t.Run("when the broker is closed it should close every channel and refuse new ones", func(t *testing.T) {
+		// Arrange
+		b := NewMemoryBroker()
+		ch := newTestMemoryChannel(t, b)
+		declarePayments(t, ch, nil)
+		deliveries, err := ch.Consume("payments", "", false, false, false, false, nil)
+		require.NoError(t, err)
+
+		// Act
+		err = b.Close()
+
+		// Assert
+		assert.NoError(t, err)
+		_, open := <-deliveries
+		assert.False(t, open, "deliveries are closed with the broker")
+		assert.Equal(t, amqp.ErrClosed, ch.Publish("payments", "payment.created", false, false, amqp.Publishing{}))
+		_, err = b.channel()
+		assert.Equal(t, amqp.ErrClosed, err)
+	})
+}
+
+func TestMemoryBroker_DeadLetter(t *testing.T) {
+	tests := []struct {
+		name               string
+		args               amqp.Table
+		bindingKey         string
+		expectedDeadLetter bool
+		expectedRoutingKey string
+	}{
+		{
+			name:               "when the queue has a dead letter exchange and routing key it should republish the message with that key",
+			args:               amqp.Table{DeadLetterExchangeArg: "payments.dlx", DeadLetterRoutingKeyArg: "payments.dead"},
+			bindingKey:         "payments.dead",
+			expectedDeadLetter: true,
+			expectedRoutingKey: "payments.dead",
+		},
+		{
+			name:               "when the queue has a dead letter exchange without routing key it should keep the original routing key",
+			args:               amqp.Table{DeadLetterExchangeArg: "payments.dlx"},
+			bindingKey:         "payment.*",
+			expectedDeadLetter: true,
+			expectedRoutingKey: "payment.created",
+		},
+		{
+			name:               "when the dead letter exchange routes the key to no queue it should drop the message",
+			args:               amqp.Table{DeadLetterExchangeArg: "payments.dlx", DeadLetterRoutingKeyArg: "payments.dead"},
+			bindingKey:         "payment.*",
+			expectedDeadLetter: false,
+		},
+		{
+			name:               "when the queue has no dead letter exchange it should drop the message",
+			args:               nil,
+			bindingKey:         "#",
+			expectedDeadLetter: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			b := NewMemoryBroker()
+			ch := newTestMemoryChannel(t, b)
+			require.NoError(t, ch.ExchangeDeclare("payments.dlx", amqp.ExchangeTopic, true, false, false, false, nil))
+			_, err := ch.QueueDeclare("payments.dlq", true, false, false, false, nil)
+			require.NoError(t, err)
+			require.NoError(t, ch.QueueBind("payments.dlq", tt.bindingKey, "payments.dlx", false, nil))
+
+			args := copyTable(tt.args)
+			declarePayments(t, ch, args)
+			// The queue keeps the arguments it was declared with
+			for key := range args {
+				args[key] = "changed"
+			}
+
+			require.NoError(t, ch.Publish("payments", "payment.created", false, false, amqp.Publishing{
+				MessageId: "msg-1",
+				Headers:   amqp.Table{"x-attempts": int32(3)},
+				Body:      []byte(`{"id":1}`),
+			}))
+			deliveries, err := ch.Consume("payments", "", false, false, false, false, nil)
+			require.NoError(t, err)
+			delivery := receive(t, deliveries, 1)[0]
+
+			// Act
+			err = delivery.Nack(false, false)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, 0, b.Messages("payments"))
+			if !tt.expectedDeadLetter {
+				assert.Equal(t, 0, b.Messages("payments.dlq"))
+				return
+			}
+
+			assert.Equal(t, 1, b.Messages("payments.dlq"))
+			dead, err := ch.Consume("payments.dlq", "", false, false, false, false, nil)
+			require.NoError(t, err)
+			deadLettered := receive(t, dead, 1)[0]
+			assert.Equal(t, "payments.dlx", deadLettered.Exchange)
+			assert.Equal(t, tt.expectedRoutingKey, deadLettered.RoutingKey)
+			assert.Equal(t, "msg-1", deadLettered.MessageId)
+			assert.Equal(t, amqp.Table{"x-attempts": int32(3)}, deadLettered.Headers)
+			assert.Equal(t, []byte(`{"id":1}`), deadLettered.Body)
+			assert.False(t, deadLettered.Redelivered)
+		})
+	}
+}
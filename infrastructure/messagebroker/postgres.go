@@ -0,0 +1,874 @@
+package messagebroker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/streadway/amqp"
+)
+
+// PostgresConfig configures the Postgres broker backend
+type PostgresConfig struct {
+	PollInterval      time.Duration // Time a consumer waits before polling an empty queue again
+	VisibilityTimeout time.Duration // Time a delivered message stays hidden without being acked, extended while it is handled
+	BatchSize         int           // Messages fetched per poll by consumers without a prefetch count
+	MaxDeliveries     int           // Deliveries of a message never acked before it is dead-lettered instead of delivered again
+}
+
+// Defaults of the Postgres broker backend
+const (
+	defaultPostgresPollInterval      = 500 * time.Millisecond
+	defaultPostgresVisibilityTimeout = 30 * time.Second
+	defaultPostgresBatchSize         = 10
+	defaultPostgresMaxDeliveries     = 20
+)
+
+// postgresBroker is a broker backend keeping exchanges, bindings, queues and messages in Postgres tables
+// Consumers claim messages with SELECT ... FOR UPDATE SKIP LOCKED and hide them for the visibility timeout,
+// so several replicas share a queue and the messages of a crashed consumer are delivered again once it expires
+type postgresBroker struct {
+	db     *sql.DB
+	config PostgresConfig
+
+	mu       sync.Mutex
+	channels map[*postgresChannel]struct{}
+	closed   bool
+}
+
+// ConnectPostgres returns a connection to a broker backend on the given database
+// The broker tables are created by the migrations, closing the connection leaves the database open
+func ConnectPostgres(db *sql.DB, config PostgresConfig) (*Connection, error) {
+	if db == nil {
+		return nil, errors.New("connection: database cannot be nil")
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultPostgresPollInterval
+	}
+	if config.VisibilityTimeout <= 0 {
+		config.VisibilityTimeout = defaultPostgresVisibilityTimeout
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultPostgresBatchSize
+	}
+	if config.MaxDeliveries <= 0 {
+		config.MaxDeliveries = defaultPostgresMaxDeliveries
+	}
+
+	slog.Info("messagebroker: using Postgres broker", "poll_interval", config.PollInterval, "visibility_timeout", config.VisibilityTimeout, "max_deliveries", config.MaxDeliveries)
+
+	return &Connection{backend: &postgresBroker{
+		db:       db,
+		config:   config,
+		channels: map[*postgresChannel]struct{}{},
+	}}, nil
+}
+
+// Close closes every channel, their unacked messages become visible again
+func (b *postgresBroker) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	channels := make([]*postgresChannel, 0, len(b.channels))
+	for ch := range b.channels {
+		channels = append(channels, ch)
+	}
+	b.mu.Unlock()
+
+	var errs []error
+	for _, ch := range channels {
+		errs = append(errs, ch.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// channel opens a new channel on the broker
+func (b *postgresBroker) channel() (amqpChannel, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, amqp.ErrClosed
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := &postgresChannel{
+		broker:  b,
+		ctx:     ctx,
+		cancel:  cancel,
+		unacked: map[uint64]*postgresDelivery{},
+	}
+	b.channels[ch] = struct{}{}
+	return ch, nil
+}
+
+// postgresChannel is a channel of the Postgres broker
+type postgresChannel struct {
+	broker *postgresBroker
+	ctx    context.Context // Cancelled when the channel is closed, stops the consumers
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	prefetch int
+	nextTag  uint64
+	unacked  map[uint64]*postgresDelivery
+	closed   bool
+	wg       sync.WaitGroup // Running consumer goroutines, waited for on close
+}
+
+// postgresDelivery is a message claimed by a consumer and not acked yet
+type postgresDelivery struct {
+	id       int64
+	consumer *postgresConsumer
+}
+
+// postgresConsumer polls a queue for visible messages
+type postgresConsumer struct {
+	channel    *postgresChannel
+	queue      string
+	tag        string
+	prefetch   int
+	unacked    int
+	deliveries chan amqp.Delivery
+	settled    chan struct{} // Signaled when a delivery is settled, the consumer may claim more
+}
+
+// postgresMessage is a message row claimed from a queue
+type postgresMessage struct {
+	id          int64
+	exchange    string
+	routingKey  string
+	messageID   string
+	contentType string
+	headers     amqp.Table
+	body        []byte
+	deliveries  int
+}
+
+// ExchangeDeclare declares a topic, direct or fanout exchange
+// It returns an error if the exchange exists with another kind
+func (ch *postgresChannel) ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	if ch.isClosed() {
+		return amqp.ErrClosed
+	}
+
+	switch kind {
+	case amqp.ExchangeTopic, amqp.ExchangeDirect, amqp.ExchangeFanout:
+	default:
+		return fmt.Errorf("postgres broker: unsupported exchange kind %q", kind)
+	}
+
+	query := `
+		INSERT INTO broker_exchanges (name, kind)
+		VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING kind
+	`
+
+	var declared string
+	if err := ch.broker.db.QueryRowContext(ch.ctx, query, name, kind).Scan(&declared); err != nil {
+		return fmt.Errorf("postgres broker: failed to declare exchange: %w", err)
+	}
+	if declared != kind {
+		return fmt.Errorf("postgres broker: exchange %q already declared as %s", name, declared)
+	}
+	return nil
+}
+
+// QueueDeclare declares a queue, queues without a name get a generated one
+// The dead letter exchange and routing key arguments are kept from the first declaration
+func (ch *postgresChannel) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	if ch.isClosed() {
+		return amqp.Queue{}, amqp.ErrClosed
+	}
+
+	if name == "" {
+		name = "amq.gen-" + uuid.New().String()
+	}
+
+	deadLetterExchange, _ := args[DeadLetterExchangeArg].(string)
+	deadLetterRoutingKey, _ := args[DeadLetterRoutingKeyArg].(string)
+
+	query := `
+		INSERT INTO broker_queues (name, dead_letter_exchange, dead_letter_routing_key)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''))
+		ON CONFLICT (name) DO NOTHING
+	`
+
+	if _, err := ch.broker.db.ExecContext(ch.ctx, query, name, deadLetterExchange, deadLetterRoutingKey); err != nil {
+		return amqp.Queue{}, fmt.Errorf("postgres broker: failed to declare queue: %w", err)
+	}
+
+	var messages int
+	if err := ch.broker.db.QueryRowContext(ch.ctx, `SELECT COUNT(*) FROM broker_messages WHERE queue = $1`, name).Scan(&messages); err != nil {
+		return amqp.Queue{}, fmt.Errorf("postgres broker: failed to count queue messages: %w", err)
+	}
+
+	return amqp.Queue{Name: name, Messages: messages}, nil
+}
+
+// QueueBind binds a queue to an exchange
+// It returns an error if the queue or the exchange were not declared
+func (ch *postgresChannel) QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error {
+	if ch.isClosed() {
+		return amqp.ErrClosed
+	}
+
+	query := `
+		INSERT INTO broker_bindings (exchange, queue, binding_key)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`
+
+	if _, err := ch.broker.db.ExecContext(ch.ctx, query, exchange, name, key); err != nil {
+		return fmt.Errorf("postgres broker: failed to bind queue %q to exchange %q: %w", name, exchange, err)
+	}
+	return nil
+}
+
+// Qos sets the prefetch count of the consumers started afterwards, 0 claims the configured batch size per poll
+func (ch *postgresChannel) Qos(prefetchCount, prefetchSize int, global bool) error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if ch.closed {
+		return amqp.ErrClosed
+	}
+
+	ch.prefetch = prefetchCount
+	return nil
+}
+
+// Consume starts a consumer polling a queue
+// The deliveries channel is closed when the channel or the broker is closed
+func (ch *postgresChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	if autoAck {
+		return nil, errors.New("postgres broker: auto-ack is not supported")
+	}
+
+	var exists bool
+	if err := ch.broker.db.QueryRowContext(ch.ctx, `SELECT EXISTS (SELECT 1 FROM broker_queues WHERE name = $1)`, queue).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("postgres broker: failed to find queue: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("postgres broker: queue %q not found", queue)
+	}
+
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if ch.closed {
+		return nil, amqp.ErrClosed
+	}
+
+	if consumer == "" {
+		consumer = "ctag-" + uuid.New().String()
+	}
+
+	c := &postgresConsumer{
+		channel:    ch,
+		queue:      queue,
+		tag:        consumer,
+		prefetch:   ch.prefetch,
+		deliveries: make(chan amqp.Delivery),
+		settled:    make(chan struct{}, 1),
+	}
+	ch.wg.Add(2)
+	go c.poll()
+	go c.extendVisibility()
+
+	return c.deliveries, nil
+}
+
+// Publish routes a message through an exchange, the message is stored in every queue it is routed to in one transaction
+// It returns an error if the exchange was not declared
+func (ch *postgresChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	if ch.isClosed() {
+		return amqp.ErrClosed
+	}
+
+	tx, err := ch.broker.db.BeginTx(ch.ctx, nil)
+	if err != nil {
+		return fmt.Errorf("postgres broker: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := route(ch.ctx, tx, exchange, key, msg.MessageId, msg.ContentType, msg.Headers, msg.Body); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("postgres broker: failed to commit message: %w", err)
+	}
+	return nil
+}
+
+// Close stops the consumers of the channel and makes its unacked messages visible again
+func (ch *postgresChannel) Close() error {
+	ch.mu.Lock()
+	if ch.closed {
+		ch.mu.Unlock()
+		return nil
+	}
+	ch.closed = true
+	ch.mu.Unlock()
+
+	// Stop the consumers before collecting the unacked messages, so no message is claimed afterwards
+	ch.cancel()
+	ch.wg.Wait()
+
+	ch.mu.Lock()
+	ids := make([]int64, 0, len(ch.unacked))
+	for tag, d := range ch.unacked {
+		ids = append(ids, d.id)
+		delete(ch.unacked, tag)
+	}
+	ch.mu.Unlock()
+	slices.Sort(ids)
+
+	ch.broker.mu.Lock()
+	delete(ch.broker.channels, ch)
+	ch.broker.mu.Unlock()
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ch.broker.config.VisibilityTimeout)
+	defer cancel()
+
+	query := `UPDATE broker_messages SET visible_at = NOW() WHERE id = ANY($1)`
+	if _, err := ch.broker.db.ExecContext(ctx, query, pq.Array(ids)); err != nil {
+		return fmt.Errorf("postgres broker: failed to requeue unacked messages: %w", err)
+	}
+	return nil
+}
+
+// Ack acknowledges a delivery, or every delivery up to it when multiple is set, deleting its message
+func (ch *postgresChannel) Ack(tag uint64, multiple bool) error {
+	return ch.settle(tag, multiple, func(ctx context.Context, tx *sql.Tx, id int64) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM broker_messages WHERE id = $1`, id)
+		return err
+	})
+}
+
+// Nack rejects a delivery, or every delivery up to it when multiple is set
+// Rejected messages are made visible again, or dead-lettered when requeue is not set
+func (ch *postgresChannel) Nack(tag uint64, multiple, requeue bool) error {
+	return ch.settle(tag, multiple, func(ctx context.Context, tx *sql.Tx, id int64) error {
+		if requeue {
+			_, err := tx.ExecContext(ctx, `UPDATE broker_messages SET visible_at = NOW() WHERE id = $1`, id)
+			return err
+		}
+		return deadLetter(ctx, tx, id)
+	})
+}
+
+// Reject rejects a single delivery
+func (ch *postgresChannel) Reject(tag uint64, requeue bool) error {
+	return ch.Nack(tag, false, requeue)
+}
+
+// settle settles deliveries in one transaction and removes them from the unacked ones
+// It returns an error if the delivery tag is unknown, e.g. already settled
+func (ch *postgresChannel) settle(tag uint64, multiple bool, fn func(ctx context.Context, tx *sql.Tx, id int64) error) error {
+	ch.mu.Lock()
+	if ch.closed {
+		ch.mu.Unlock()
+		return amqp.ErrClosed
+	}
+
+	tags := []uint64{tag}
+	if multiple {
+		tags = tags[:0]
+		for t := range ch.unacked {
+			if t <= tag {
+				tags = append(tags, t)
+			}
+		}
+		slices.Sort(tags)
+	}
+
+	deliveries := make([]*postgresDelivery, 0, len(tags))
+	for _, t := range tags {
+		d, ok := ch.unacked[t]
+		if !ok {
+			ch.mu.Unlock()
+			return fmt.Errorf("postgres broker: unknown delivery tag %d", t)
+		}
+		deliveries = append(deliveries, d)
+	}
+	ch.mu.Unlock()
+
+	// Settling outlives the channel context, a message handled during shutdown is still acked
+	ctx, cancel := context.WithTimeout(context.Background(), ch.broker.config.VisibilityTimeout)
+	defer cancel()
+
+	tx, err := ch.broker.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("postgres broker: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, d := range deliveries {
+		if err := fn(ctx, tx, d.id); err != nil {
+			return fmt.Errorf("postgres broker: failed to settle message %d: %w", d.id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("postgres broker: failed to commit settlement: %w", err)
+	}
+
+	ch.mu.Lock()
+	for _, t := range tags {
+		if d, ok := ch.unacked[t]; ok {
+			delete(ch.unacked, t)
+			d.consumer.unacked--
+			d.consumer.signal()
+		}
+	}
+	ch.mu.Unlock()
+
+	return nil
+}
+
+// isClosed tells whether the channel was closed
+func (ch *postgresChannel) isClosed() bool {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	return ch.closed
+}
+
+// poll claims the visible messages of the queue and delivers them until the channel is closed
+// The consumer claims at most the prefetch count of unacked messages, polling again right away while the queue has more
+func (c *postgresConsumer) poll() {
+	defer c.channel.wg.Done()
+	defer close(c.deliveries)
+
+	ctx := c.channel.ctx
+	config := c.channel.broker.config
+
+	for {
+		limit := c.capacity()
+		claimed := 0
+
+		if limit > 0 {
+			messages, err := c.claim(ctx, limit)
+			if err != nil && ctx.Err() == nil {
+				slog.Error("Postgres broker failed to claim messages", "queue", c.queue, "error", err)
+			}
+			claimed = len(messages)
+
+			for _, msg := range messages {
+				if !c.deliver(ctx, msg) {
+					return
+				}
+			}
+		}
+
+		// A full claim may have left messages behind, poll again without waiting
+		if limit > 0 && claimed == limit {
+			continue
+		}
+
+		// Only a full consumer is woken up by a settlement, the others wait for the poll interval
+		var settled <-chan struct{}
+		if limit <= 0 {
+			settled = c.settled
+		}
+
+		timer := time.NewTimer(config.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-settled:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// capacity returns how many messages the consumer may claim
+func (c *postgresConsumer) capacity() int {
+	c.channel.mu.Lock()
+	defer c.channel.mu.Unlock()
+
+	if c.prefetch <= 0 {
+		return c.channel.broker.config.BatchSize - c.unacked
+	}
+	return c.prefetch - c.unacked
+}
+
+// claim hides up to limit visible messages of the queue for the visibility timeout and returns them in order
+// SKIP LOCKED lets consumers of other replicas claim the next messages instead of waiting on these
+// Messages claimed past the max deliveries are dead-lettered in the same transaction instead of being returned,
+// so a message that crashes its consumer or is never acked does not go around forever
+func (c *postgresConsumer) claim(ctx context.Context, limit int) ([]*postgresMessage, error) {
+	config := c.channel.broker.config
+
+	tx, err := c.channel.broker.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE broker_messages
+		SET deliveries = deliveries + 1, visible_at = NOW() + make_interval(secs => $3)
+		WHERE id IN (
+			SELECT id FROM broker_messages
+			WHERE queue = $1 AND visible_at <= NOW()
+			ORDER BY id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, exchange, routing_key, message_id, content_type, headers, body, deliveries
+	`
+
+	rows, err := tx.QueryContext(ctx, query, c.queue, limit, config.VisibilityTimeout.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	claimed, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*postgresMessage, 0, len(claimed))
+	var deadLettered []*postgresMessage
+	for _, msg := range claimed {
+		if msg.deliveries <= config.MaxDeliveries {
+			messages = append(messages, msg)
+			continue
+		}
+		if err := deadLetter(ctx, tx, msg.id); err != nil {
+			return nil, fmt.Errorf("dead-letter message %d: %w", msg.id, err)
+		}
+		deadLettered = append(deadLettered, msg)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	for _, msg := range deadLettered {
+		slog.Warn("Postgres broker dead-lettered message after max deliveries", "queue", c.queue, "message_id", msg.messageID, "deliveries", msg.deliveries-1)
+	}
+
+	// RETURNING does not keep the order of the subquery
+	sort.Slice(messages, func(i, j int) bool { return messages[i].id < messages[j].id })
+	return messages, nil
+}
+
+// scanMessages reads the claimed message rows and closes them
+func scanMessages(rows *sql.Rows) ([]*postgresMessage, error) {
+	defer rows.Close()
+
+	var messages []*postgresMessage
+	for rows.Next() {
+		var msg postgresMessage
+		var headers []byte
+		if err := rows.Scan(&msg.id, &msg.exchange, &msg.routingKey, &msg.messageID, &msg.contentType, &headers, &msg.body, &msg.deliveries); err != nil {
+			return nil, err
+		}
+		var err error
+		if msg.headers, err = decodeHeaders(headers); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// deliver registers a claimed message as unacked and hands it to the consumer
+// It returns false if the channel was closed first, the message is made visible again by Close
+func (c *postgresConsumer) deliver(ctx context.Context, msg *postgresMessage) bool {
+	c.channel.mu.Lock()
+	c.channel.nextTag++
+	tag := c.channel.nextTag
+	c.channel.unacked[tag] = &postgresDelivery{id: msg.id, consumer: c}
+	c.unacked++
+	c.channel.mu.Unlock()
+
+	delivery := amqp.Delivery{
+		Acknowledger: c.channel,
+		Headers:      msg.headers,
+		ContentType:  msg.contentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    msg.messageID,
+		ConsumerTag:  c.tag,
+		DeliveryTag:  tag,
+		Redelivered:  msg.deliveries > 1,
+		Exchange:     msg.exchange,
+		RoutingKey:   msg.routingKey,
+		Body:         msg.body,
+	}
+
+	select {
+	case c.deliveries <- delivery:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// extendVisibility keeps the unacked messages of the consumer hidden while they are handled
+// Messages of a consumer that stopped extending them, e.g. a crashed replica, become visible once the timeout expires
+func (c *postgresConsumer) extendVisibility() {
+	defer c.channel.wg.Done()
+
+	ctx := c.channel.ctx
+	timeout := c.channel.broker.config.VisibilityTimeout
+	ticker := time.NewTicker(timeout / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ids := c.unackedIDs()
+		if len(ids) == 0 {
+			continue
+		}
+
+		query := `UPDATE broker_messages SET visible_at = NOW() + make_interval(secs => $2) WHERE id = ANY($1)`
+		if _, err := c.channel.broker.db.ExecContext(ctx, query, pq.Array(ids), timeout.Seconds()); err != nil && ctx.Err() == nil {
+			slog.Warn("Postgres broker failed to extend message visibility", "queue", c.queue, "messages", len(ids), "error", err)
+		}
+	}
+}
+
+// unackedIDs returns the message IDs of the unacked deliveries of the consumer
+func (c *postgresConsumer) unackedIDs() []int64 {
+	c.channel.mu.Lock()
+	defer c.channel.mu.Unlock()
+
+	var ids []int64
+	for _, d := range c.channel.unacked {
+		if d.consumer == c {
+			ids = append(ids, d.id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// signal wakes the consumer up after a settlement without blocking
+func (c *postgresConsumer) signal() {
+	select {
+	case c.settled <- struct{}{}:
+	default:
+	}
+}
+
+// route stores a message in every queue the exchange routes its routing key to
+// Messages no queue is bound for are dropped, as RabbitMQ does with unroutable messages
+func route(ctx context.Context, tx *sql.Tx, exchange, routingKey, messageID, contentType string, headers amqp.Table, body []byte) error {
+	encoded, err := encodeHeaders(headers)
+	if err != nil {
+		return err
+	}
+	// JSONB parameters are sent as text, lib/pq would send bytes as bytea
+	encodedHeaders := string(encoded)
+	if body == nil {
+		body = []byte{}
+	}
+
+	insert := `
+		INSERT INTO broker_messages (queue, exchange, routing_key, message_id, content_type, headers, body)
+		SELECT name, $2::text, $3::text, $4::text, $5::text, $6::jsonb, $7::bytea FROM broker_queues WHERE name = $1
+	`
+
+	// The default exchange delivers straight to the queue named by the routing key
+	if exchange == "" {
+		if _, err := tx.ExecContext(ctx, insert, routingKey, exchange, routingKey, messageID, contentType, encodedHeaders, body); err != nil {
+			return fmt.Errorf("postgres broker: failed to store message: %w", err)
+		}
+		return nil
+	}
+
+	var kind string
+	err = tx.QueryRowContext(ctx, `SELECT kind FROM broker_exchanges WHERE name = $1`, exchange).Scan(&kind)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("postgres broker: exchange %q not found", exchange)
+	}
+	if err != nil {
+		return fmt.Errorf("postgres broker: failed to find exchange: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT queue, binding_key FROM broker_bindings WHERE exchange = $1`, exchange)
+	if err != nil {
+		return fmt.Errorf("postgres broker: failed to list bindings: %w", err)
+	}
+
+	var queues []string
+	routed := map[string]bool{}
+	for rows.Next() {
+		var queue, bindingKey string
+		if err := rows.Scan(&queue, &bindingKey); err != nil {
+			rows.Close()
+			return fmt.Errorf("postgres broker: failed to scan binding: %w", err)
+		}
+		if !routed[queue] && routes(kind, bindingKey, routingKey) {
+			routed[queue] = true
+			queues = append(queues, queue)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("postgres broker: failed to list bindings: %w", err)
+	}
+	rows.Close()
+
+	for _, queue := range queues {
+		if _, err := tx.ExecContext(ctx, insert, queue, exchange, routingKey, messageID, contentType, encodedHeaders, body); err != nil {
+			return fmt.Errorf("postgres broker: failed to store message: %w", err)
+		}
+	}
+	return nil
+}
+
+// deadLetter moves a rejected message to the dead letter exchange of its queue
+// Messages of queues without one are deleted, as RabbitMQ does
+func deadLetter(ctx context.Context, tx *sql.Tx, id int64) error {
+	query := `
+		DELETE FROM broker_messages m
+		USING broker_queues q
+		WHERE m.id = $1 AND q.name = m.queue
+		RETURNING m.routing_key, m.message_id, m.content_type, m.headers, m.body,
+			COALESCE(q.dead_letter_exchange, ''), q.dead_letter_routing_key
+	`
+
+	var routingKey, messageID, contentType, exchange string
+	var deadLetterRoutingKey sql.NullString
+	var headers, body []byte
+	err := tx.QueryRowContext(ctx, query, id).Scan(&routingKey, &messageID, &contentType, &headers, &body, &exchange, &deadLetterRoutingKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil // Already settled once its visibility expired
+	}
+	if err != nil {
+		return err
+	}
+	if exchange == "" {
+		return nil
+	}
+
+	if deadLetterRoutingKey.Valid {
+		routingKey = deadLetterRoutingKey.String
+	}
+
+	decoded, err := decodeHeaders(headers)
+	if err != nil {
+		return err
+	}
+	return route(ctx, tx, exchange, routingKey, messageID, contentType, decoded, body)
+}
+
+// headerValue is a header stored with its AMQP type, so consumers read back the type it was published with
+type headerValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// encodeHeaders writes AMQP headers as JSON keeping their types
+// It returns an error if a header has a type the backend does not store
+func encodeHeaders(headers amqp.Table) ([]byte, error) {
+	encoded := make(map[string]headerValue, len(headers))
+	for key, value := range headers {
+		var kind string
+		switch v := value.(type) {
+		case string:
+			kind = "string"
+		case bool:
+			kind = "bool"
+		case int32:
+			kind = "int32"
+		case int64:
+			kind = "int64"
+		case int:
+			kind = "int64"
+			value = int64(v)
+		case float64:
+			kind = "float64"
+		case time.Time:
+			kind = "time"
+			value = v.UTC().Format(time.RFC3339Nano)
+		default:
+			return nil, fmt.Errorf("postgres broker: unsupported header type %T for %q", value, key)
+		}
+
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("postgres broker: failed to encode header %q: %w", key, err)
+		}
+		encoded[key] = headerValue{Type: kind, Value: raw}
+	}
+	return json.Marshal(encoded)
+}
+
+// decodeHeaders reads the AMQP headers written by encodeHeaders
+func decodeHeaders(data []byte) (amqp.Table, error) {
+	var encoded map[string]headerValue
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("postgres broker: failed to decode headers: %w", err)
+	}
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+
+	headers := make(amqp.Table, len(encoded))
+	for key, header := range encoded {
+		var err error
+		switch header.Type {
+		case "string":
+			var v string
+			err = json.Unmarshal(header.Value, &v)
+			headers[key] = v
+		case "bool":
+			var v bool
+			err = json.Unmarshal(header.Value, &v)
+			headers[key] = v
+		case "int32":
+			var v int32
+			err = json.Unmarshal(header.Value, &v)
+			headers[key] = v
+		case "int64":
+			var v int64
+			err = json.Unmarshal(header.Value, &v)
+			headers[key] = v
+		case "float64":
+			var v float64
+			err = json.Unmarshal(header.Value, &v)
+			headers[key] = v
+		case "time":
+			var v string
+			if err = json.Unmarshal(header.Value, &v); err == nil {
+				var t time.Time
+				t, err = time.Parse(time.RFC3339Nano, v)
+				headers[key] = t
+			}
+		default:
+			err = fmt.Errorf("unknown type %q", header.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("postgres broker: failed to decode header %q: %w", key, err)
+		}
+	}
+	return headers, nil
+}
@@ -0,0 +1,590 @@
+package messagebroker
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Queries of the Postgres broker, matched as regular expressions with their whitespace collapsed
+const (
+	existsQuery       = `SELECT EXISTS \(SELECT 1 FROM broker_queues WHERE name = \$1\)`
+	claimQuery        = `UPDATE broker_messages SET deliveries = deliveries \+ 1, visible_at = NOW\(\) \+ make_interval\(secs => \$3\) WHERE id IN \( SELECT id FROM broker_messages WHERE queue = \$1 AND visible_at <= NOW\(\) ORDER BY id LIMIT \$2 FOR UPDATE SKIP LOCKED \)`
+	extendQuery       = `UPDATE broker_messages SET visible_at = NOW\(\) \+ make_interval\(secs => \$2\) WHERE id = ANY\(\$1\)`
+	requeueQuery      = `UPDATE broker_messages SET visible_at = NOW\(\) WHERE id = ANY\(\$1\)`
+	nackQuery         = `UPDATE broker_messages SET visible_at = NOW\(\) WHERE id = \$1`
+	ackQuery          = `DELETE FROM broker_messages WHERE id = \$1`
+	deadLetterQuery   = `DELETE FROM broker_messages m USING broker_queues q WHERE m.id = \$1`
+	exchangeKindQuery = `SELECT kind FROM broker_exchanges WHERE name = \$1`
+	bindingsQuery     = `SELECT queue, binding_key FROM broker_bindings WHERE exchange = \$1`
+	insertQuery       = `INSERT INTO broker_messages`
+	declareQueueQuery = `INSERT INTO broker_queues`
+	countQuery        = `SELECT COUNT\(\*\) FROM broker_messages WHERE queue = \$1`
+)
+
+var (
+	claimColumns      = []string{"id", "exchange", "routing_key", "message_id", "content_type", "headers", "body", "deliveries"}
+	deadLetterColumns = []string{"routing_key", "message_id", "content_type", "headers", "body", "dead_letter_exchange", "dead_letter_routing_key"}
+)
+
+// claimedRow is a message row returned by a claim
+type claimedRow struct {
+	id         int64
+	deliveries int
+}
+
+// claimRows returns the rows of claimed messages of the payments queue
+func claimRows(rows ...claimedRow) *sqlmock.Rows {
+	result := sqlmock.NewRows(claimColumns)
+	for _, row := range rows {
+		result.AddRow(row.id, "payments", "payment.created", fmt.Sprintf("msg-%d", row.id), "application/json", []byte(`{"x-attempts":{"type":"int32","value":1}}`), []byte(`{}`), row.deliveries)
+	}
+	return result
+}
+
+// newTestPostgresChannel opens a channel of a Postgres broker on a mocked database
+// The poll interval is long enough for consumers to claim only once
+func newTestPostgresChannel(t *testing.T, config PostgresConfig) (*postgresChannel, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	if config.PollInterval == 0 {
+		config.PollInterval = time.Hour
+	}
+	if config.VisibilityTimeout == 0 {
+		config.VisibilityTimeout = time.Hour
+	}
+
+	conn, err := ConnectPostgres(db, config)
+	require.NoError(t, err)
+
+	ch, err := conn.backend.channel()
+	require.NoError(t, err)
+
+	return ch.(*postgresChannel), mock
+}
+
+// expectClaim expects a consumer of the payments queue to start and claim the rows with its first poll
+func expectClaim(mock sqlmock.Sqlmock, limit int, visibilityTimeout time.Duration, rows *sqlmock.Rows) {
+	mock.ExpectQuery(existsQuery).WithArgs("payments").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectBegin()
+	mock.ExpectQuery(claimQuery).WithArgs("payments", limit, visibilityTimeout.Seconds()).WillReturnRows(rows)
+	mock.ExpectCommit()
+}
+
+// consume starts a consumer of the payments queue and receives n deliveries
+func consume(t *testing.T, ch *postgresChannel, prefetch int, n int) []amqp.Delivery {
+	t.Helper()
+
+	require.NoError(t, ch.Qos(prefetch, 0, false))
+	deliveries, err := ch.Consume("payments", "", false, false, false, false, nil)
+	require.NoError(t, err)
+
+	return receive(t, deliveries, n)
+}
+
+// receive receives n deliveries, failing the test if they do not arrive in time
+func receive(t *testing.T, deliveries <-chan amqp.Delivery, n int) []amqp.Delivery {
+	t.Helper()
+
+	var received []amqp.Delivery
+	for len(received) < n {
+		select {
+		case d, ok := <-deliveries:
+			require.True(t, ok, "deliveries closed after %d of %d", len(received), n)
+			received = append(received, d)
+		case <-time.After(time.Second):
+			t.Fatalf("received %d of %d deliveries", len(received), n)
+		}
+	}
+	return received
+}
+
+// tagsOf returns the delivery tags of the deliveries
+func tagsOf(deliveries []amqp.Delivery) []uint64 {
+	tags := make([]uint64, 0, len(deliveries))
+	for _, d := range deliveries {
+		tags = append(tags, d.DeliveryTag)
+	}
+	return tags
+}
+
+func TestConnectPostgres(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         PostgresConfig
+		expectedConfig PostgresConfig
+	}{
+		{
+			name:   "when config is empty it should use the defaults",
+			config: PostgresConfig{},
+			expectedConfig: PostgresConfig{
+				PollInterval:      defaultPostgresPollInterval,
+				VisibilityTimeout: defaultPostgresVisibilityTimeout,
+				BatchSize:         defaultPostgresBatchSize,
+				MaxDeliveries:     defaultPostgresMaxDeliveries,
+			},
+		},
+		{
+			name:           "when config is set it should keep it",
+			config:         PostgresConfig{PollInterval: time.Second, VisibilityTimeout: time.Minute, BatchSize: 5, MaxDeliveries: 3},
+			expectedConfig: PostgresConfig{PollInterval: time.Second, VisibilityTimeout: time.Minute, BatchSize: 5, MaxDeliveries: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			db, _, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			// Act
+			conn, err := ConnectPostgres(db, tt.config)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedConfig, conn.backend.(*postgresBroker).config)
+		})
+	}
+
+	t.Run("when database is nil it should return error", func(t *testing.T) {
+		// Act
+		conn, err := ConnectPostgres(nil, PostgresConfig{})
+
+		// Assert
+		assert.EqualError(t, err, "connection: database cannot be nil")
+		assert.Nil(t, conn)
+	})
+}
+
+func TestPostgresConsumer_Claim(t *testing.T) {
+	tests := []struct {
+		name                string
+		prefetch            int
+		maxDeliveries       int
+		rows                []claimedRow
+		expectDeadLetter    func(mock sqlmock.Sqlmock)
+		expectedLimit       int
+		expectedTags        []uint64
+		expectedMessageIDs  []string
+		expectedRedelivered []bool
+		expectedRequeued    []int64
+	}{
+		{
+			name:                "when the queue has visible messages it should claim up to the prefetch and deliver them in id order",
+			prefetch:            5,
+			rows:                []claimedRow{{id: 12, deliveries: 1}, {id: 11, deliveries: 1}},
+			expectedLimit:       5,
+			expectedTags:        []uint64{1, 2},
+			expectedMessageIDs:  []string{"msg-11", "msg-12"},
+			expectedRedelivered: []bool{false, false},
+			expectedRequeued:    []int64{11, 12},
+		},
+		{
+			name:                "when the channel has no prefetch it should claim up to the batch size",
+			prefetch:            0,
+			rows:                []claimedRow{{id: 11, deliveries: 1}},
+			expectedLimit:       defaultPostgresBatchSize,
+			expectedTags:        []uint64{1},
+			expectedMessageIDs:  []string{"msg-11"},
+			expectedRedelivered: []bool{false},
+			expectedRequeued:    []int64{11},
+		},
+		{
+			name:                "when a message visibility expired before it was acked it should deliver it again as redelivered",
+			prefetch:            5,
+			rows:                []claimedRow{{id: 11, deliveries: 2}, {id: 12, deliveries: 1}},
+			expectedLimit:       5,
+			expectedTags:        []uint64{1, 2},
+			expectedMessageIDs:  []string{"msg-11", "msg-12"},
+			expectedRedelivered: []bool{true, false},
+			expectedRequeued:    []int64{11, 12},
+		},
+		{
+			name:          "when a message is claimed past the max deliveries it should dead-letter it in the claim and deliver the rest",
+			prefetch:      5,
+			maxDeliveries: 3,
+			rows:          []claimedRow{{id: 11, deliveries: 4}, {id: 12, deliveries: 3}},
+			expectDeadLetter: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(deadLetterQuery).WithArgs(int64(11)).WillReturnRows(sqlmock.NewRows(deadLetterColumns).
+					AddRow("payment.created", "msg", "application/json", []byte(`{}`), []byte(`{}`), "payments.dlx", nil))
+				mock.ExpectQuery(exchangeKindQuery).WithArgs("payments.dlx").WillReturnRows(sqlmock.NewRows([]string{"kind"}).AddRow("fanout"))
+				mock.ExpectQuery(bindingsQuery).WithArgs("payments.dlx").WillReturnRows(sqlmock.NewRows([]string{"queue", "binding_key"}).AddRow("payments.dlq", ""))
+				mock.ExpectExec(insertQuery).WithArgs("payments.dlq", "payments.dlx", "payment.created", "msg", "application/json", "{}", []byte(`{}`)).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			expectedLimit:       5,
+			expectedTags:        []uint64{1},
+			expectedMessageIDs:  []string{"msg-12"},
+			expectedRedelivered: []bool{true},
+			expectedRequeued:    []int64{12},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ch, mock := newTestPostgresChannel(t, PostgresConfig{MaxDeliveries: tt.maxDeliveries})
+
+			mock.ExpectQuery(existsQuery).WithArgs("payments").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectBegin()
+			mock.ExpectQuery(claimQuery).WithArgs("payments", tt.expectedLimit, time.Hour.Seconds()).WillReturnRows(claimRows(tt.rows...))
+			if tt.expectDeadLetter != nil {
+				tt.expectDeadLetter(mock)
+			}
+			mock.ExpectCommit()
+			mock.ExpectExec(requeueQuery).WithArgs(pq.Array(tt.expectedRequeued)).WillReturnResult(sqlmock.NewResult(0, int64(len(tt.expectedRequeued))))
+
+			// Act
+			deliveries := consume(t, ch, tt.prefetch, len(tt.expectedTags))
+			closeErr := ch.Close()
+
+			// Assert
+			assert.NoError(t, closeErr)
+			assert.Equal(t, tt.expectedTags, tagsOf(deliveries))
+			for i, d := range deliveries {
+				assert.Equal(t, tt.expectedMessageIDs[i], d.MessageId)
+				assert.Equal(t, tt.expectedRedelivered[i], d.Redelivered)
+				assert.Equal(t, amqp.Table{"x-attempts": int32(1)}, d.Headers)
+				assert.Equal(t, "payment.created", d.RoutingKey)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresConsumer_Claim_AcrossConsumers(t *testing.T) {
+	// Arrange
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := ConnectPostgres(db, PostgresConfig{PollInterval: time.Hour, VisibilityTimeout: time.Hour})
+	require.NoError(t, err)
+
+	// Each replica gets the rows its own SKIP LOCKED claim returned, whichever claims first
+	mock.MatchExpectationsInOrder(false)
+	expectClaim(mock, 3, time.Hour, claimRows(claimedRow{id: 1, deliveries: 1}, claimedRow{id: 2, deliveries: 1}))
+	expectClaim(mock, 3, time.Hour, claimRows(claimedRow{id: 3, deliveries: 1}))
+
+	first, err := conn.backend.channel()
+	require.NoError(t, err)
+	second, err := conn.backend.channel()
+	require.NoError(t, err)
+
+	// Act
+	require.NoError(t, first.Qos(3, 0, false))
+	require.NoError(t, second.Qos(3, 0, false))
+	firstDeliveries, err := first.Consume("payments", "first", false, false, false, false, nil)
+	require.NoError(t, err)
+	secondDeliveries, err := second.Consume("payments", "second", false, false, false, false, nil)
+	require.NoError(t, err)
+
+	var claimed [][]string
+	var total int
+	for total < 3 {
+		select {
+		case d := <-firstDeliveries:
+			claimed = append(claimed, []string{d.ConsumerTag, d.MessageId})
+			total++
+		case d := <-secondDeliveries:
+			claimed = append(claimed, []string{d.ConsumerTag, d.MessageId})
+			total++
+		case <-time.After(time.Second):
+			t.Fatalf("received %d of 3 deliveries", total)
+		}
+	}
+
+	// Assert
+	assert.NoError(t, mock.ExpectationsWereMet())
+	byConsumer := map[string]int{}
+	for _, c := range claimed {
+		byConsumer[c[0]]++
+	}
+	assert.Len(t, byConsumer, 2, "each consumer delivers its own claim")
+	assert.ElementsMatch(t, []int{1, 2}, []int{byConsumer["first"], byConsumer["second"]})
+
+	mock.ExpectExec(requeueQuery).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(requeueQuery).WillReturnResult(sqlmock.NewResult(0, 2))
+	assert.NoError(t, conn.Close())
+}
+
+func TestPostgresConsumer_ExtendVisibility(t *testing.T) {
+	// Arrange
+	visibilityTimeout := 30 * time.Millisecond
+	ch, mock := newTestPostgresChannel(t, PostgresConfig{VisibilityTimeout: visibilityTimeout})
+
+	expectClaim(mock, 5, visibilityTimeout, claimRows(claimedRow{id: 11, deliveries: 1}, claimedRow{id: 12, deliveries: 1}))
+	mock.ExpectExec(extendQuery).WithArgs(pq.Array([]int64{11, 12}), visibilityTimeout.Seconds()).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// Act
+	deliveries := consume(t, ch, 5, 2)
+
+	// Assert
+	// The unacked messages are kept hidden every third of the visibility timeout
+	assert.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, time.Millisecond)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(ackQuery).WithArgs(int64(11)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, deliveries[0].Ack(false))
+
+	// Only the message still unacked is extended afterwards
+	mock.ExpectExec(extendQuery).WithArgs(pq.Array([]int64{12}), visibilityTimeout.Seconds()).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, time.Millisecond)
+
+	mock.ExpectExec(requeueQuery).WithArgs(pq.Array([]int64{12})).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, ch.Close())
+}
+
+func TestPostgresChannel_Settle(t *testing.T) {
+	tests := []struct {
+		name             string
+		settle           func(ch *postgresChannel) error
+		expectSettlement func(mock sqlmock.Sqlmock)
+		expectedError    string
+		expectedRequeued []int64
+	}{
+		{
+			name:   "when a delivery is acked it should delete its message only",
+			settle: func(ch *postgresChannel) error { return ch.Ack(2, false) },
+			expectSettlement: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(ackQuery).WithArgs(int64(12)).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedRequeued: []int64{11, 13},
+		},
+		{
+			name:   "when deliveries are acked with multiple it should delete every message up to the tag in one transaction",
+			settle: func(ch *postgresChannel) error { return ch.Ack(2, true) },
+			expectSettlement: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(ackQuery).WithArgs(int64(11)).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(ackQuery).WithArgs(int64(12)).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedRequeued: []int64{13},
+		},
+		{
+			name:   "when deliveries are nacked with multiple and requeue it should make every message up to the tag visible again",
+			settle: func(ch *postgresChannel) error { return ch.Nack(2, true, true) },
+			expectSettlement: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(nackQuery).WithArgs(int64(11)).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(nackQuery).WithArgs(int64(12)).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedRequeued: []int64{13},
+		},
+		{
+			name:   "when a delivery is rejected without requeue on a queue without dead letter exchange it should delete its message",
+			settle: func(ch *postgresChannel) error { return ch.Reject(3, false) },
+			expectSettlement: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(deadLetterQuery).WithArgs(int64(13)).WillReturnRows(sqlmock.NewRows(deadLetterColumns).
+					AddRow("payment.created", "msg", "application/json", []byte(`{}`), []byte(`{}`), "", nil))
+				mock.ExpectCommit()
+			},
+			expectedRequeued: []int64{11, 12},
+		},
+		{
+			name:             "when the delivery tag is unknown it should return error without settling any message",
+			settle:           func(ch *postgresChannel) error { return ch.Ack(9, false) },
+			expectSettlement: func(mock sqlmock.Sqlmock) {},
+			expectedError:    "postgres broker: unknown delivery tag 9",
+			expectedRequeued: []int64{11, 12, 13},
+		},
+		{
+			name:   "when the settlement fails it should roll back and keep the deliveries unacked",
+			settle: func(ch *postgresChannel) error { return ch.Ack(2, true) },
+			expectSettlement: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(ackQuery).WithArgs(int64(11)).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(ackQuery).WithArgs(int64(12)).WillReturnError(errors.New("connection reset"))
+				mock.ExpectRollback()
+			},
+			expectedError:    "postgres broker: failed to settle message 12: connection reset",
+			expectedRequeued: []int64{11, 12, 13},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ch, mock := newTestPostgresChannel(t, PostgresConfig{})
+			expectClaim(mock, 5, time.Hour, claimRows(claimedRow{id: 11, deliveries: 1}, claimedRow{id: 12, deliveries: 1}, claimedRow{id: 13, deliveries: 1}))
+			tt.expectSettlement(mock)
+			mock.ExpectExec(requeueQuery).WithArgs(pq.Array(tt.expectedRequeued)).WillReturnResult(sqlmock.NewResult(0, int64(len(tt.expectedRequeued))))
+
+			consume(t, ch, 5, 3)
+
+			// Act
+			err := tt.settle(ch)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, ch.Close())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresChannel_Close(t *testing.T) {
+	tests := []struct {
+		name             string
+		rows             []claimedRow
+		acked            []uint64
+		expectedRequeued []int64
+	}{
+		{
+			name:             "when the channel has unacked deliveries it should make their messages visible again",
+			rows:             []claimedRow{{id: 11, deliveries: 1}, {id: 12, deliveries: 1}},
+			expectedRequeued: []int64{11, 12},
+		},
+		{
+			name:             "when every delivery was acked it should not requeue any message",
+			rows:             []claimedRow{{id: 11, deliveries: 1}},
+			acked:            []uint64{1},
+			expectedRequeued: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ch, mock := newTestPostgresChannel(t, PostgresConfig{})
+			expectClaim(mock, 5, time.Hour, claimRows(tt.rows...))
+			for range tt.acked {
+				mock.ExpectBegin()
+				mock.ExpectExec(ackQuery).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+			if tt.expectedRequeued != nil {
+				mock.ExpectExec(requeueQuery).WithArgs(pq.Array(tt.expectedRequeued)).WillReturnResult(sqlmock.NewResult(0, int64(len(tt.expectedRequeued))))
+			}
+
+			deliveries, err := func() (<-chan amqp.Delivery, error) {
+				require.NoError(t, ch.Qos(5, 0, false))
+				return ch.Consume("payments", "", false, false, false, false, nil)
+			}()
+			require.NoError(t, err)
+			receive(t, deliveries, len(tt.rows))
+			for _, tag := range tt.acked {
+				require.NoError(t, ch.Ack(tag, false))
+			}
+
+			// Act
+			err = ch.Close()
+
+			// Assert
+			assert.NoError(t, err)
+			_, open := <-deliveries
+			assert.False(t, open, "deliveries are closed with the channel")
+			assert.NoError(t, ch.Close(), "closing twice is a no-op")
+			assert.Equal(t, amqp.ErrClosed, ch.Ack(1, false))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresChannel_DeadLetter(t *testing.T) {
+	tests := []struct {
+		name                 string
+		deadLetterExchange   string
+		deadLetterRoutingKey any
+		bindings             [][]string
+		expectedQueues       []string
+		expectedRoutingKey   string
+		missingExchange      bool
+		expectedError        string
+	}{
+		{
+			name:                 "when the queue has a dead letter exchange and routing key it should route the message with that key",
+			deadLetterExchange:   "payments.dlx",
+			deadLetterRoutingKey: "payments.dead",
+			bindings:             [][]string{{"payments.dlq", "payments.dead"}, {"audit", "payments.*.other"}},
+			expectedQueues:       []string{"payments.dlq"},
+			expectedRoutingKey:   "payments.dead",
+		},
+		{
+			name:                 "when the queue has a dead letter exchange without routing key it should keep the original routing key",
+			deadLetterExchange:   "payments.dlx",
+			deadLetterRoutingKey: nil,
+			bindings:             [][]string{{"payments.dlq", "payment.*"}, {"audit", "#"}},
+			expectedQueues:       []string{"payments.dlq", "audit"},
+			expectedRoutingKey:   "payment.created",
+		},
+		{
+			name:                 "when the dead letter exchange was not declared it should return error and keep the message",
+			deadLetterExchange:   "payments.dlx",
+			deadLetterRoutingKey: nil,
+			missingExchange:      true,
+			expectedError:        `postgres broker: failed to settle message 11: postgres broker: exchange "payments.dlx" not found`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ch, mock := newTestPostgresChannel(t, PostgresConfig{})
+
+			mock.ExpectExec(declareQueueQuery).WithArgs("payments", tt.deadLetterExchange, "").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(countQuery).WithArgs("payments").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			expectClaim(mock, 5, time.Hour, claimRows(claimedRow{id: 11, deliveries: 1}))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(deadLetterQuery).WithArgs(int64(11)).WillReturnRows(sqlmock.NewRows(deadLetterColumns).
+				AddRow("payment.created", "msg", "application/json", []byte(`{"x-attempts":{"type":"int32","value":1}}`), []byte(`{}`), tt.deadLetterExchange, tt.deadLetterRoutingKey))
+			if tt.missingExchange {
+				mock.ExpectQuery(exchangeKindQuery).WithArgs(tt.deadLetterExchange).WillReturnRows(sqlmock.NewRows([]string{"kind"}))
+				mock.ExpectRollback()
+				mock.ExpectExec(requeueQuery).WithArgs(pq.Array([]int64{11})).WillReturnResult(sqlmock.NewResult(0, 1))
+			} else {
+				bindings := sqlmock.NewRows([]string{"queue", "binding_key"})
+				for _, b := range tt.bindings {
+					bindings.AddRow(b[0], b[1])
+				}
+				mock.ExpectQuery(exchangeKindQuery).WithArgs(tt.deadLetterExchange).WillReturnRows(sqlmock.NewRows([]string{"kind"}).AddRow(amqp.ExchangeTopic))
+				mock.ExpectQuery(bindingsQuery).WithArgs(tt.deadLetterExchange).WillReturnRows(bindings)
+				for _, queue := range tt.expectedQueues {
+					mock.ExpectExec(insertQuery).
+						WithArgs(queue, tt.deadLetterExchange, tt.expectedRoutingKey, "msg", "application/json", `{"x-attempts":{"type":"int32","value":1}}`, []byte(`{}`)).
+						WillReturnResult(sqlmock.NewResult(1, 1))
+				}
+				mock.ExpectCommit()
+			}
+
+			_, err := ch.QueueDeclare("payments", true, false, false, false, amqp.Table{DeadLetterExchangeArg: tt.deadLetterExchange})
+			require.NoError(t, err)
+			consume(t, ch, 5, 1)
+
+			// Act
+			err = ch.Nack(1, false, false)
+
+			// Assert
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, ch.Close())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -1,13 +1,21 @@
 package messagebroker
 
 import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/requestid"
 	"github.com/streadway/amqp"
 )
 
+// RequestIDHeader carries the ID of the request that published the message, consumers restore it to log with it
+const RequestIDHeader = "x-request-id"
+
 // PublisherConfig configures a publisher
 type PublisherConfig struct {
-	Exchange   string
-	RoutingKey string
+	Exchange    string
+	RoutingKey  string
+	EventFormat EventFormat // How PublishEvent writes the events, legacy if the mode is empty
 }
 
 // Publisher publishes messages to RabbitMQ
@@ -21,6 +29,12 @@ func NewPublisher(conn *Connection, config PublisherConfig) (*Publisher, error)
 	if err := conn.Validate(); err != nil {
 		return nil, err
 	}
+	if config.EventFormat.Mode == "" {
+		config.EventFormat.Mode = EventModeLegacy
+	}
+	if err := config.EventFormat.Mode.Validate(); err != nil {
+		return nil, err
+	}
 
 	channel, err := conn.NewChannel()
 	if err != nil {
@@ -35,15 +49,92 @@ func NewPublisher(conn *Connection, config PublisherConfig) (*Publisher, error)
 
 // Publish publishes a JSON message
 func (p *Publisher) Publish(body []byte) error {
+	return p.PublishWithHeaders(body, nil)
+}
+
+// PublishWithHeaders publishes a JSON message with the given AMQP headers
+func (p *Publisher) PublishWithHeaders(body []byte, headers map[string]interface{}) error {
+	return p.PublishWithRoutingKey(p.config.RoutingKey, body, headers)
+}
+
+// PublishWithRoutingKey publishes a JSON message with the given AMQP headers to the given routing key
+// instead of the configured one
+// Every message gets a new ID, consumers deduplicate redeliveries with it
+func (p *Publisher) PublishWithRoutingKey(routingKey string, body []byte, headers map[string]interface{}) error {
+	return p.publish(routingKey, uuid.New().String(), JSONContentType, body, headers)
+}
+
+// PublishMessage publishes a message as it was received, to its routing key and with its ID and content type
+// Messages without an ID get a new one, and messages without a content type are published as JSON
+func (p *Publisher) PublishMessage(msg *Message) error {
+	messageID := msg.ID
+	if messageID == "" {
+		messageID = uuid.New().String()
+	}
+	contentType := msg.ContentType
+	if contentType == "" {
+		contentType = JSONContentType
+	}
+	return p.publish(msg.RoutingKey, messageID, contentType, msg.Body, msg.Headers)
+}
+
+// PublishEvent publishes an event to the configured routing key, written in the configured mode
+// The event ID is the message ID, and events without a source get the configured one
+func (p *Publisher) PublishEvent(event *CloudEvent, headers map[string]interface{}) error {
+	if event.Source == "" {
+		event.Source = p.config.EventFormat.Source
+	}
+
+	body, contentType, eventHeaders, err := event.Encode(p.config.EventFormat.Mode)
+	if err != nil {
+		return err
+	}
+
+	if headers == nil && len(eventHeaders) > 0 {
+		headers = map[string]interface{}{}
+	}
+	for k, v := range eventHeaders {
+		headers[k] = v
+	}
+
+	return p.publish(p.config.RoutingKey, event.ID, contentType, body, headers)
+}
+
+// publish publishes a persistent message
+func (p *Publisher) publish(routingKey, messageID, contentType string, body []byte, headers map[string]interface{}) error {
 	return p.channel.ch.Publish(
 		p.config.Exchange,
-		p.config.RoutingKey,
+		routingKey,
 		false, // mandatory
 		false, // immediate
 		amqp.Publishing{
-			ContentType:  "application/json",
+			Headers:      amqp.Table(headers),
+			MessageId:    messageID,
+			ContentType:  contentType,
 			DeliveryMode: amqp.Persistent,
 			Body:         body,
 		},
 	)
 }
+
+// WithRequestID returns the headers with the request ID of the context added, creating them if they are nil
+func WithRequestID(ctx context.Context, headers map[string]interface{}) map[string]interface{} {
+	id := requestid.FromContext(ctx)
+	if id == "" {
+		return headers
+	}
+	if headers == nil {
+		headers = map[string]interface{}{}
+	}
+	headers[RequestIDHeader] = id
+	return headers
+}
+
+// ContextFromHeaders returns a copy of the context carrying the request ID of the message headers
+// Messages published without a request ID get a new one, so their processing can still be correlated
+func ContextFromHeaders(ctx context.Context, headers map[string]interface{}) context.Context {
+	if id, ok := headers[RequestIDHeader].(string); ok && requestid.Valid(id) {
+		return requestid.WithRequestID(ctx, id)
+	}
+	return requestid.WithRequestID(ctx, requestid.New())
+}
@@ -13,3 +13,26 @@ func (m *MockPublisher) Publish(body []byte) error {
 	return args.Error(0)
 }
 
+// PublishWithHeaders publishes a message with headers to the broker
+func (m *MockPublisher) PublishWithHeaders(body []byte, headers map[string]interface{}) error {
+	args := m.Called(body, headers)
+	return args.Error(0)
+}
+
+// PublishWithRoutingKey publishes a message with headers to the given routing key
+func (m *MockPublisher) PublishWithRoutingKey(routingKey string, body []byte, headers map[string]interface{}) error {
+	args := m.Called(routingKey, body, headers)
+	return args.Error(0)
+}
+
+// PublishMessage publishes a message as it was received
+func (m *MockPublisher) PublishMessage(msg *Message) error {
+	args := m.Called(msg)
+	return args.Error(0)
+}
+
+// PublishEvent publishes an event to the broker
+func (m *MockPublisher) PublishEvent(event *CloudEvent, headers map[string]interface{}) error {
+	args := m.Called(event, headers)
+	return args.Error(0)
+}
@@ -0,0 +1,105 @@
+package messagebroker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextFromHeaders(t *testing.T) {
+	tests := []struct {
+		name             string
+		headers          map[string]interface{}
+		shouldReuseID    bool
+		shouldGenerateID bool
+	}{
+		{
+			name:          "when headers carry a valid ID it should reuse it",
+			headers:       map[string]interface{}{RequestIDHeader: "req_123"},
+			shouldReuseID: true,
+		},
+		{
+			name:             "when headers are nil it should generate an ID",
+			headers:          nil,
+			shouldGenerateID: true,
+		},
+		{
+			name:             "when headers carry no ID it should generate one",
+			headers:          map[string]interface{}{"x-attempts": int32(1)},
+			shouldGenerateID: true,
+		},
+		{
+			name:             "when ID is not a string it should generate one",
+			headers:          map[string]interface{}{RequestIDHeader: int32(123)},
+			shouldGenerateID: true,
+		},
+		{
+			name:             "when ID has control characters it should generate one",
+			headers:          map[string]interface{}{RequestIDHeader: "req_123\nlevel=ERROR"},
+			shouldGenerateID: true,
+		},
+		{
+			name:             "when ID is too long it should generate one",
+			headers:          map[string]interface{}{RequestIDHeader: strings.Repeat("a", 129)},
+			shouldGenerateID: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			ctx := ContextFromHeaders(context.Background(), tt.headers)
+
+			// Assert
+			id := requestid.FromContext(ctx)
+			if tt.shouldReuseID {
+				assert.Equal(t, tt.headers[RequestIDHeader], id)
+			}
+			if tt.shouldGenerateID {
+				assert.True(t, requestid.Valid(id))
+				assert.NotEqual(t, tt.headers[RequestIDHeader], id)
+			}
+		})
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		headers  map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name:     "when context carries an ID it should add it to the headers",
+			ctx:      requestid.WithRequestID(context.Background(), "req_123"),
+			headers:  map[string]interface{}{"x-attempts": int32(1)},
+			expected: map[string]interface{}{"x-attempts": int32(1), RequestIDHeader: "req_123"},
+		},
+		{
+			name:     "when headers are nil it should create them",
+			ctx:      requestid.WithRequestID(context.Background(), "req_123"),
+			headers:  nil,
+			expected: map[string]interface{}{RequestIDHeader: "req_123"},
+		},
+		{
+			name:     "when context carries no ID it should return the headers unchanged",
+			ctx:      context.Background(),
+			headers:  nil,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			result := WithRequestID(tt.ctx, tt.headers)
+
+			// Assert
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
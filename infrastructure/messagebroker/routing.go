@@ -0,0 +1,54 @@
+package messagebroker
+
+import (
+	"strings"
+
+	"github.com/streadway/amqp"
+)
+
+// routes tells whether a binding key routes a routing key on an exchange of the given kind
+// It is shared by the broker backends that run without RabbitMQ
+func routes(kind, bindingKey, routingKey string) bool {
+	switch kind {
+	case amqp.ExchangeFanout:
+		return true
+	case amqp.ExchangeTopic:
+		return topicMatch(strings.Split(bindingKey, "."), strings.Split(routingKey, "."))
+	default:
+		return bindingKey == routingKey
+	}
+}
+
+// topicMatch matches the words of a routing key against a topic pattern
+// * matches exactly one word and # matches zero or more words
+func topicMatch(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(words); i++ {
+			if topicMatch(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(words) > 0 && topicMatch(pattern[1:], words[1:])
+	default:
+		return len(words) > 0 && pattern[0] == words[0] && topicMatch(pattern[1:], words[1:])
+	}
+}
+
+// copyTable returns a shallow copy of AMQP headers or arguments, nil stays nil
+func copyTable(table amqp.Table) amqp.Table {
+	if table == nil {
+		return nil
+	}
+	copied := make(amqp.Table, len(table))
+	for k, v := range table {
+		copied[k] = v
+	}
+	return copied
+}
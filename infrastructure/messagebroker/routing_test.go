@@ -0,0 +1,95 @@
+package messagebroker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopicMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    string
+		routingKey string
+		expected   bool
+	}{
+		{name: "when pattern and key are equal it should match", pattern: "payment.created", routingKey: "payment.created", expected: true},
+		{name: "when a word differs it should not match", pattern: "payment.created", routingKey: "payment.failed", expected: false},
+		{name: "when the key has more words than the pattern it should not match", pattern: "payment.created", routingKey: "payment.created.v2", expected: false},
+		{name: "when * replaces one word it should match", pattern: "payment.*", routingKey: "payment.created", expected: true},
+		{name: "when * has no word to replace it should not match", pattern: "payment.*", routingKey: "payment", expected: false},
+		{name: "when * has two words to replace it should not match", pattern: "payment.*", routingKey: "payment.created.v2", expected: false},
+		{name: "when * is in the middle it should match one word", pattern: "payment.*.v2", routingKey: "payment.created.v2", expected: true},
+		{name: "when # replaces no word it should match", pattern: "payment.#", routingKey: "payment", expected: true},
+		{name: "when # replaces one word it should match", pattern: "payment.#", routingKey: "payment.created", expected: true},
+		{name: "when # replaces several words it should match", pattern: "payment.#", routingKey: "payment.created.v2", expected: true},
+		{name: "when # is alone it should match any key", pattern: "#", routingKey: "payment.created.v2", expected: true},
+		{name: "when # is in the middle it should match zero or more words", pattern: "payment.#.v2", routingKey: "payment.v2", expected: true},
+		{name: "when # is followed by a word the key lacks it should not match", pattern: "payment.#.v2", routingKey: "payment.created", expected: false},
+		{name: "when the key is empty it should be a single empty word matched by *", pattern: "#.*", routingKey: "", expected: true},
+		{name: "when the key is empty it should match an empty pattern", pattern: "", routingKey: "", expected: true},
+		{name: "when the key is empty it should not match a word", pattern: "payment", routingKey: "", expected: false},
+		{name: "when the key has empty words it should match them as words", pattern: "payment.*.created", routingKey: "payment..created", expected: true},
+		{name: "when the key has an empty last word it should not match a shorter pattern", pattern: "payment", routingKey: "payment.", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			matched := topicMatch(strings.Split(tt.pattern, "."), strings.Split(tt.routingKey, "."))
+
+			// Assert
+			assert.Equal(t, tt.expected, matched)
+		})
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		kind       string
+		bindingKey string
+		routingKey string
+		expected   bool
+	}{
+		{name: "when the exchange is fanout it should route any key", kind: amqp.ExchangeFanout, bindingKey: "payment.created", routingKey: "refund.created", expected: true},
+		{name: "when the exchange is direct and the keys are equal it should route", kind: amqp.ExchangeDirect, bindingKey: "payment.created", routingKey: "payment.created", expected: true},
+		{name: "when the exchange is direct it should not treat * as a wildcard", kind: amqp.ExchangeDirect, bindingKey: "payment.*", routingKey: "payment.created", expected: false},
+		{name: "when the exchange is topic it should match the pattern", kind: amqp.ExchangeTopic, bindingKey: "payment.*", routingKey: "payment.created", expected: true},
+		{name: "when the exchange is topic and the pattern does not match it should not route", kind: amqp.ExchangeTopic, bindingKey: "refund.#", routingKey: "payment.created", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			routed := routes(tt.kind, tt.bindingKey, tt.routingKey)
+
+			// Assert
+			assert.Equal(t, tt.expected, routed)
+		})
+	}
+}
+
+func TestCopyTable(t *testing.T) {
+	t.Run("when the table is nil it should return nil", func(t *testing.T) {
+		// Act
+		copied := copyTable(nil)
+
+		// Assert
+		assert.Nil(t, copied)
+	})
+
+	t.Run("when the table is set it should return a copy", func(t *testing.T) {
+		// Arrange
+		table := amqp.Table{"x-attempts": int32(1)}
+
+		// Act
+		copied := copyTable(table)
+		copied["x-attempts"] = int32(2)
+
+		// Assert
+		assert.Equal(t, amqp.Table{"x-attempts": int32(1)}, table)
+	})
+}
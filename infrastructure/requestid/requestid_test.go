@@ -0,0 +1,127 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		expected bool
+	}{
+		{
+			name:     "when ID is a UUID it should be valid",
+			id:       "3f2b8c1e-5d4a-4b7e-9c2f-1a6d8e0b4c3a",
+			expected: true,
+		},
+		{
+			name:     "when ID uses printable ASCII punctuation it should be valid",
+			id:       "req_123:abc/DEF.~!",
+			expected: true,
+		},
+		{
+			name:     "when ID has a single character it should be valid",
+			id:       "a",
+			expected: true,
+		},
+		{
+			name:     "when ID has 128 characters it should be valid",
+			id:       strings.Repeat("a", 128),
+			expected: true,
+		},
+		{
+			name:     "when ID is empty it should be invalid",
+			id:       "",
+			expected: false,
+		},
+		{
+			name:     "when ID has 129 characters it should be invalid",
+			id:       strings.Repeat("a", 129),
+			expected: false,
+		},
+		{
+			name:     "when ID has a space it should be invalid",
+			id:       "req 123",
+			expected: false,
+		},
+		{
+			name:     "when ID has a newline it should be invalid so it cannot forge log lines",
+			id:       "req_123\nlevel=ERROR",
+			expected: false,
+		},
+		{
+			name:     "when ID has a tab it should be invalid",
+			id:       "req\t123",
+			expected: false,
+		},
+		{
+			name:     "when ID has a DEL character it should be invalid",
+			id:       "req\x7f123",
+			expected: false,
+		},
+		{
+			name:     "when ID has non ASCII characters it should be invalid",
+			id:       "req_ñ123",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			result := Valid(tt.id)
+
+			// Assert
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Run("when IDs are generated it should return distinct valid UUIDs", func(t *testing.T) {
+		// Act
+		first := New()
+		second := New()
+
+		// Assert
+		assert.True(t, Valid(first))
+		assert.NotEqual(t, first, second)
+		_, err := uuid.Parse(first)
+		assert.NoError(t, err)
+	})
+}
+
+func TestFromContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected string
+	}{
+		{
+			name:     "when context carries a request ID it should return it",
+			ctx:      WithRequestID(context.Background(), "req_123"),
+			expected: "req_123",
+		},
+		{
+			name:     "when context carries no request ID it should return an empty string",
+			ctx:      context.Background(),
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			result := FromContext(tt.ctx)
+
+			// Assert
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
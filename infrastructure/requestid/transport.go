@@ -0,0 +1,29 @@
+package requestid
+
+import (
+	"net/http"
+)
+
+// Transport forwards the request ID of the request context in the X-Request-ID header
+// Requests that already set the header are sent as they are
+type Transport struct {
+	Base http.RoundTripper // Transport sending the requests, http.DefaultTransport if nil
+}
+
+// RoundTrip sends the request with the request ID header
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	id := FromContext(req.Context())
+	if id == "" || req.Header.Get(Header) != "" {
+		return base.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request, the header is set on a copy
+	req = req.Clone(req.Context())
+	req.Header.Set(Header, id)
+	return base.RoundTrip(req)
+}
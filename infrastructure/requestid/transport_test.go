@@ -0,0 +1,136 @@
+package requestid
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingTransport records the request it is asked to send
+type recordingTransport struct {
+	req *http.Request
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.req = req
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func TestTransport_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name           string
+		contextID      string
+		headerID       string
+		expectedHeader string
+		shouldCopy     bool
+	}{
+		{
+			name:           "when context carries a request ID it should send it in the header of a copy",
+			contextID:      "req_123",
+			expectedHeader: "req_123",
+			shouldCopy:     true,
+		},
+		{
+			name:           "when request already sets the header it should send the request as it is",
+			contextID:      "req_123",
+			headerID:       "req_456",
+			expectedHeader: "req_456",
+		},
+		{
+			name:           "when context carries no request ID it should send the request as it is",
+			expectedHeader: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			base := &recordingTransport{}
+			transport := &Transport{Base: base}
+
+			ctx := context.Background()
+			if tt.contextID != "" {
+				ctx = WithRequestID(ctx, tt.contextID)
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://wallet.local/api/v1/reservations", nil)
+			require.NoError(t, err)
+			if tt.headerID != "" {
+				req.Header.Set(Header, tt.headerID)
+			}
+
+			// Act
+			resp, err := transport.RoundTrip(req)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			require.NotNil(t, base.req)
+			assert.Equal(t, tt.expectedHeader, base.req.Header.Get(Header))
+			assert.Equal(t, tt.headerID, req.Header.Get(Header))
+			if tt.shouldCopy {
+				assert.NotSame(t, req, base.req)
+				assert.Equal(t, req.URL.String(), base.req.URL.String())
+				assert.Equal(t, ctx, base.req.Context())
+			} else {
+				assert.Same(t, req, base.req)
+			}
+		})
+	}
+}
+
+func TestTransport_RoundTrip_BaseError(t *testing.T) {
+	t.Run("when base transport fails it should return its error", func(t *testing.T) {
+		// Arrange
+		transport := &Transport{Base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		})}
+		req, err := http.NewRequestWithContext(WithRequestID(context.Background(), "req_123"), http.MethodGet, "http://wallet.local", nil)
+		require.NoError(t, err)
+
+		// Act
+		resp, err := transport.RoundTrip(req)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Equal(t, "connection refused", err.Error())
+		assert.Nil(t, resp)
+	})
+}
+
+func TestTransport_RoundTrip_DefaultTransport(t *testing.T) {
+	t.Run("when base is nil it should send the request with http.DefaultTransport", func(t *testing.T) {
+		// Arrange
+		received := make(chan string, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received <- r.Header.Get(Header)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		client := &http.Client{Transport: &Transport{}}
+		req, err := http.NewRequestWithContext(WithRequestID(context.Background(), "req_123"), http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		// Act
+		resp, err := client.Do(req)
+
+		// Assert
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, "req_123", <-received)
+		assert.Empty(t, req.Header.Get(Header))
+	})
+}
+
+// roundTripperFunc adapts a function to an http.RoundTripper
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
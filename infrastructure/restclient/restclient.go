@@ -4,6 +4,8 @@ import (
 	"errors"
 	"net/http"
 	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/requestid"
 )
 
 // Config represents the configuration for the HTTP client
@@ -22,5 +24,8 @@ func NewRestClient(config *Config) (*http.Client, error) {
 
 	// TODO: Implement the logic to create a new HTTP client
 
-	return &http.Client{}, nil
+	// Outgoing requests carry the request ID of their context
+	return &http.Client{
+		Transport: &requestid.Transport{Base: http.DefaultTransport},
+	}, nil
 }
@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RunStatus represents the outcome of a job run
+type RunStatus string
+
+const (
+	RunStatusSucceeded RunStatus = "succeeded" // The job finished without error
+	RunStatusFailed    RunStatus = "failed"    // The job returned an error
+	RunStatusTimedOut  RunStatus = "timed_out" // The job exceeded its timeout
+)
+
+// Run represents a single execution of a job
+type Run struct {
+	ID         string
+	JobName    string
+	Holder     string
+	Status     RunStatus
+	Error      string
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// finish sets the run outcome from the job error and the run context error
+func (r *Run) finish(err error, ctxErr error) {
+	r.FinishedAt = time.Now()
+
+	switch {
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		r.Status = RunStatusTimedOut
+		r.Error = ctxErr.Error()
+	case err != nil:
+		r.Status = RunStatusFailed
+		r.Error = err.Error()
+	default:
+		r.Status = RunStatusSucceeded
+	}
+}
+
+// HistoryDB defines the database operations required by PostgresHistory
+type HistoryDB interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// PostgresHistory records job runs in the job_runs table
+type PostgresHistory struct {
+	db HistoryDB
+}
+
+// NewPostgresHistory creates a new PostgresHistory
+func NewPostgresHistory(db HistoryDB) (*PostgresHistory, error) {
+	if db == nil {
+		return nil, errors.New("job history: database cannot be nil")
+	}
+
+	return &PostgresHistory{db: db}, nil
+}
+
+// Record inserts the job run
+func (h *PostgresHistory) Record(ctx context.Context, run *Run) error {
+	if run.ID == "" {
+		run.ID = uuid.New().String()
+	}
+
+	query := `
+		INSERT INTO job_runs (id, job_name, holder, status, error, started_at, finished_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+	`
+
+	_, err := h.db.ExecContext(ctx, query,
+		run.ID,
+		run.JobName,
+		run.Holder,
+		run.Status,
+		run.Error,
+		run.StartedAt,
+		run.FinishedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("job history: record run: %w", err)
+	}
+
+	return nil
+}
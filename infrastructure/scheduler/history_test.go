@@ -0,0 +1,155 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRun_Finish(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		ctxErr         error
+		expectedStatus RunStatus
+		expectedError  string
+	}{
+		{
+			name:           "when the job returns no error it should be succeeded",
+			expectedStatus: RunStatusSucceeded,
+		},
+		{
+			name:           "when the job returns an error it should be failed with the error",
+			err:            errors.New("wallet unavailable"),
+			expectedStatus: RunStatusFailed,
+			expectedError:  "wallet unavailable",
+		},
+		{
+			name:           "when the run deadline was exceeded it should be timed out whatever the job returned",
+			err:            errors.New("query canceled"),
+			ctxErr:         context.DeadlineExceeded,
+			expectedStatus: RunStatusTimedOut,
+			expectedError:  "context deadline exceeded",
+		},
+		{
+			name:           "when the run was canceled by shutdown it should be failed with the job error",
+			err:            context.Canceled,
+			ctxErr:         context.Canceled,
+			expectedStatus: RunStatusFailed,
+			expectedError:  "context canceled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			run := &Run{StartedAt: time.Now()}
+
+			// Act
+			run.finish(tt.err, tt.ctxErr)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, run.Status)
+			assert.Equal(t, tt.expectedError, run.Error)
+			assert.False(t, run.FinishedAt.Before(run.StartedAt))
+		})
+	}
+}
+
+func TestNewPostgresHistory(t *testing.T) {
+	t.Run("when database is nil it should return error", func(t *testing.T) {
+		// Act
+		history, err := NewPostgresHistory(nil)
+
+		// Assert
+		assert.EqualError(t, err, "job history: database cannot be nil")
+		assert.Nil(t, history)
+	})
+}
+
+func TestPostgresHistory_Record(t *testing.T) {
+	startedAt := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	finishedAt := startedAt.Add(time.Second)
+
+	tests := []struct {
+		name          string
+		run           *Run
+		mockError     error
+		expectedError error
+	}{
+		{
+			name: "when the run has an ID it should insert it with that ID",
+			run: &Run{
+				ID:         "run_1",
+				JobName:    "wallet-reconciler",
+				Holder:     "replica-1",
+				Status:     RunStatusFailed,
+				Error:      "wallet unavailable",
+				StartedAt:  startedAt,
+				FinishedAt: finishedAt,
+			},
+		},
+		{
+			name: "when the run has no ID it should generate one",
+			run: &Run{
+				JobName:    "wallet-reconciler",
+				Holder:     "replica-1",
+				Status:     RunStatusSucceeded,
+				StartedAt:  startedAt,
+				FinishedAt: finishedAt,
+			},
+		},
+		{
+			name: "when the insert fails it should return error",
+			run: &Run{
+				ID:         "run_1",
+				JobName:    "wallet-reconciler",
+				Holder:     "replica-1",
+				Status:     RunStatusSucceeded,
+				StartedAt:  startedAt,
+				FinishedAt: finishedAt,
+			},
+			mockError:     errors.New("connection refused"),
+			expectedError: errors.New("job history: record run: connection refused"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockDB := new(database.MockDB)
+			mockDB.On("ExecContext", mock.Anything, mock.Anything, mock.MatchedBy(func(args []any) bool {
+				return len(args) == 7 &&
+					args[0] != "" &&
+					args[1] == tt.run.JobName &&
+					args[2] == tt.run.Holder &&
+					args[3] == tt.run.Status &&
+					args[4] == tt.run.Error &&
+					args[5] == tt.run.StartedAt &&
+					args[6] == tt.run.FinishedAt
+			})).Return(driver.RowsAffected(1), tt.mockError)
+
+			history, err := NewPostgresHistory(mockDB)
+			assert.NoError(t, err)
+
+			// Act
+			err = history.Record(context.Background(), tt.run)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NotEmpty(t, tt.run.ID)
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
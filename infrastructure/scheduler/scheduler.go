@@ -0,0 +1,190 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/requestid"
+)
+
+// Job is a task run periodically by the scheduler
+type Job struct {
+	Name     string                          // Unique job name, also used as the lock name
+	Interval time.Duration                   // Time between runs
+	Jitter   time.Duration                   // Maximum random delay added to each interval
+	Timeout  time.Duration                   // Maximum duration of a single run
+	Run      func(ctx context.Context) error // Task to run
+}
+
+// Validate validates the job
+func (j *Job) Validate() error {
+	if j.Name == "" {
+		return errors.New("job name cannot be empty")
+	}
+	if j.Interval <= 0 {
+		return fmt.Errorf("job %s: interval must be greater than 0", j.Name)
+	}
+	if j.Jitter < 0 {
+		return fmt.Errorf("job %s: jitter cannot be negative", j.Name)
+	}
+	if j.Timeout <= 0 {
+		return fmt.Errorf("job %s: timeout must be greater than 0", j.Name)
+	}
+	if j.Run == nil {
+		return fmt.Errorf("job %s: run function cannot be nil", j.Name)
+	}
+	return nil
+}
+
+// Leader reports whether this instance is allowed to run jobs
+type Leader interface {
+	IsLeader() bool
+}
+
+// Unlocker releases a held lock
+type Unlocker interface {
+	Unlock(ctx context.Context) error
+}
+
+// Locker guards a single job run across instances
+type Locker interface {
+	TryLock(ctx context.Context, name string) (Unlocker, error)
+}
+
+// HistoryRecorder records the outcome of job runs
+type HistoryRecorder interface {
+	Record(ctx context.Context, run *Run) error
+}
+
+// Scheduler runs jobs on their intervals, only while this instance is the leader
+type Scheduler struct {
+	leader  Leader
+	locker  Locker
+	history HistoryRecorder
+	holder  string
+	jobs    []Job
+	wg      sync.WaitGroup
+}
+
+// New creates a new Scheduler
+// Holder identifies this instance in the run history
+func New(leader Leader, locker Locker, history HistoryRecorder, holder string) (*Scheduler, error) {
+	if leader == nil {
+		return nil, errors.New("scheduler: leader cannot be nil")
+	}
+	if locker == nil {
+		return nil, errors.New("scheduler: locker cannot be nil")
+	}
+	if history == nil {
+		return nil, errors.New("scheduler: history recorder cannot be nil")
+	}
+
+	return &Scheduler{
+		leader:  leader,
+		locker:  locker,
+		history: history,
+		holder:  holder,
+	}, nil
+}
+
+// Register adds a job to the scheduler
+// Jobs must be registered before the scheduler starts
+func (s *Scheduler) Register(job Job) error {
+	if err := job.Validate(); err != nil {
+		return fmt.Errorf("scheduler: %w", err)
+	}
+
+	for _, registered := range s.jobs {
+		if registered.Name == job.Name {
+			return fmt.Errorf("scheduler: job %s already registered", job.Name)
+		}
+	}
+
+	s.jobs = append(s.jobs, job)
+	return nil
+}
+
+// Start starts a goroutine per registered job that runs until the context is done
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Wait waits for the job loops to finish after the context passed to Start is done
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// loop waits for the job interval plus jitter and runs the job, until the context is done
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	for {
+		timer := time.NewTimer(next(job))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !s.leader.IsLeader() {
+			continue
+		}
+
+		s.runOnce(ctx, job)
+	}
+}
+
+// runOnce runs the job under its lock and timeout and records the run
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	// Each run gets its own request ID, so the logs and messages of a run can be correlated
+	ctx = requestid.WithRequestID(ctx, requestid.New())
+
+	lock, err := s.locker.TryLock(ctx, job.Name)
+	if err != nil {
+		slog.DebugContext(ctx, "Job skipped, lock not acquired", "job", job.Name, "error", err)
+		return
+	}
+	defer func() {
+		if err := lock.Unlock(context.Background()); err != nil {
+			slog.WarnContext(ctx, "Failed to unlock job", "job", job.Name, "error", err)
+		}
+	}()
+
+	runCtx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+
+	run := &Run{
+		JobName:   job.Name,
+		Holder:    s.holder,
+		StartedAt: time.Now(),
+	}
+
+	err = job.Run(runCtx)
+	run.finish(err, runCtx.Err())
+
+	if run.Status != RunStatusSucceeded {
+		slog.ErrorContext(ctx, "Job run failed", "job", job.Name, "status", run.Status, "error", run.Error)
+	}
+
+	if err := s.history.Record(context.Background(), run); err != nil {
+		slog.WarnContext(ctx, "Failed to record job run", "job", job.Name, "error", err)
+	}
+}
+
+// next returns the delay until the next run of the job
+func next(job Job) time.Duration {
+	if job.Jitter <= 0 {
+		return job.Interval
+	}
+	return job.Interval + time.Duration(rand.Int63n(int64(job.Jitter)))
+}
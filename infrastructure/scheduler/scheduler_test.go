@@ -0,0 +1,434 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLeader is a Leader whose leadership is switched by the test
+type fakeLeader struct {
+	leader atomic.Bool
+}
+
+func (l *fakeLeader) IsLeader() bool {
+	return l.leader.Load()
+}
+
+// fakeLocker is a Locker that grants every lock unless it is set to fail
+type fakeLocker struct {
+	mu        sync.Mutex
+	err       error
+	unlockErr error
+	locked    []string
+	unlocked  []string
+}
+
+func (l *fakeLocker) TryLock(ctx context.Context, name string) (Unlocker, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.err != nil {
+		return nil, l.err
+	}
+	l.locked = append(l.locked, name)
+	return &fakeUnlocker{locker: l, name: name}, nil
+}
+
+// fakeUnlocker records the unlock in its locker
+type fakeUnlocker struct {
+	locker *fakeLocker
+	name   string
+}
+
+func (u *fakeUnlocker) Unlock(ctx context.Context) error {
+	u.locker.mu.Lock()
+	defer u.locker.mu.Unlock()
+
+	u.locker.unlocked = append(u.locker.unlocked, u.name)
+	return u.locker.unlockErr
+}
+
+// fakeHistory is a HistoryRecorder that hands the recorded runs to the test
+type fakeHistory struct {
+	err  error
+	runs chan *Run
+}
+
+func newFakeHistory() *fakeHistory {
+	return &fakeHistory{runs: make(chan *Run, 16)}
+}
+
+func (h *fakeHistory) Record(ctx context.Context, run *Run) error {
+	h.runs <- run
+	return h.err
+}
+
+// validJob returns a job that succeeds right away
+func validJob() Job {
+	return Job{
+		Name:     "wallet-reconciler",
+		Interval: time.Minute,
+		Jitter:   time.Second,
+		Timeout:  time.Second,
+		Run:      func(ctx context.Context) error { return nil },
+	}
+}
+
+func TestJob_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		modify        func(job *Job)
+		expectedError error
+	}{
+		{
+			name:   "when the job is valid it should return no error",
+			modify: func(job *Job) {},
+		},
+		{
+			name:   "when jitter is zero it should return no error",
+			modify: func(job *Job) { job.Jitter = 0 },
+		},
+		{
+			name:          "when name is empty it should return error",
+			modify:        func(job *Job) { job.Name = "" },
+			expectedError: errors.New("job name cannot be empty"),
+		},
+		{
+			name:          "when interval is not positive it should return error",
+			modify:        func(job *Job) { job.Interval = 0 },
+			expectedError: errors.New("job wallet-reconciler: interval must be greater than 0"),
+		},
+		{
+			name:          "when jitter is negative it should return error",
+			modify:        func(job *Job) { job.Jitter = -time.Second },
+			expectedError: errors.New("job wallet-reconciler: jitter cannot be negative"),
+		},
+		{
+			name:          "when timeout is not positive it should return error",
+			modify:        func(job *Job) { job.Timeout = 0 },
+			expectedError: errors.New("job wallet-reconciler: timeout must be greater than 0"),
+		},
+		{
+			name:          "when run function is nil it should return error",
+			modify:        func(job *Job) { job.Run = nil },
+			expectedError: errors.New("job wallet-reconciler: run function cannot be nil"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			job := validJob()
+			tt.modify(&job)
+
+			// Act
+			err := job.Validate()
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name          string
+		leader        Leader
+		locker        Locker
+		history       HistoryRecorder
+		expectedError error
+	}{
+		{
+			name:    "when all dependencies are valid it should create scheduler",
+			leader:  &fakeLeader{},
+			locker:  &fakeLocker{},
+			history: newFakeHistory(),
+		},
+		{
+			name:          "when leader is nil it should return error",
+			locker:        &fakeLocker{},
+			history:       newFakeHistory(),
+			expectedError: errors.New("scheduler: leader cannot be nil"),
+		},
+		{
+			name:          "when locker is nil it should return error",
+			leader:        &fakeLeader{},
+			history:       newFakeHistory(),
+			expectedError: errors.New("scheduler: locker cannot be nil"),
+		},
+		{
+			name:          "when history recorder is nil it should return error",
+			leader:        &fakeLeader{},
+			locker:        &fakeLocker{},
+			expectedError: errors.New("scheduler: history recorder cannot be nil"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			s, err := New(tt.leader, tt.locker, tt.history, "replica-1")
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, s)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, s)
+			}
+		})
+	}
+}
+
+func TestScheduler_Register(t *testing.T) {
+	t.Run("when the job is invalid it should return error", func(t *testing.T) {
+		// Arrange
+		s, err := New(&fakeLeader{}, &fakeLocker{}, newFakeHistory(), "replica-1")
+		require.NoError(t, err)
+		job := validJob()
+		job.Interval = 0
+
+		// Act
+		err = s.Register(job)
+
+		// Assert
+		assert.EqualError(t, err, "scheduler: job wallet-reconciler: interval must be greater than 0")
+	})
+
+	t.Run("when a job with the same name is registered it should return error", func(t *testing.T) {
+		// Arrange
+		s, err := New(&fakeLeader{}, &fakeLocker{}, newFakeHistory(), "replica-1")
+		require.NoError(t, err)
+		require.NoError(t, s.Register(validJob()))
+
+		// Act
+		err = s.Register(validJob())
+
+		// Assert
+		assert.EqualError(t, err, "scheduler: job wallet-reconciler already registered")
+	})
+}
+
+func TestNext(t *testing.T) {
+	t.Run("when the job has no jitter it should wait exactly the interval", func(t *testing.T) {
+		// Arrange
+		job := Job{Interval: time.Minute}
+
+		// Act
+		delay := next(job)
+
+		// Assert
+		assert.Equal(t, time.Minute, delay)
+	})
+
+	t.Run("when the job has jitter it should add a random delay below the jitter", func(t *testing.T) {
+		// Arrange
+		job := Job{Interval: time.Minute, Jitter: 10 * time.Millisecond}
+		seen := map[time.Duration]bool{}
+
+		// Act
+		for i := 0; i < 1000; i++ {
+			seen[next(job)] = true
+		}
+
+		// Assert
+		for delay := range seen {
+			assert.GreaterOrEqual(t, delay, time.Minute)
+			assert.Less(t, delay, time.Minute+10*time.Millisecond)
+		}
+		assert.Greater(t, len(seen), 1, "the delay varies between runs")
+	})
+}
+
+func TestScheduler_RunOnce(t *testing.T) {
+	tests := []struct {
+		name             string
+		run              func(ctx context.Context) error
+		lockErr          error
+		unlockErr        error
+		historyErr       error
+		expectedLocked   bool
+		expectedStatus   RunStatus
+		expectedRunError string
+	}{
+		{
+			name:           "when the job succeeds it should record a succeeded run and unlock",
+			run:            func(ctx context.Context) error { return nil },
+			expectedLocked: true,
+			expectedStatus: RunStatusSucceeded,
+		},
+		{
+			name:             "when the job fails it should record a failed run with the error",
+			run:              func(ctx context.Context) error { return errors.New("wallet unavailable") },
+			expectedLocked:   true,
+			expectedStatus:   RunStatusFailed,
+			expectedRunError: "wallet unavailable",
+		},
+		{
+			name: "when the job exceeds its timeout it should cancel it and record a timed out run",
+			run: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			expectedLocked:   true,
+			expectedStatus:   RunStatusTimedOut,
+			expectedRunError: "context deadline exceeded",
+		},
+		{
+			name:           "when unlocking fails it should still record the run",
+			run:            func(ctx context.Context) error { return nil },
+			unlockErr:      errors.New("connection reset"),
+			expectedLocked: true,
+			expectedStatus: RunStatusSucceeded,
+		},
+		{
+			name:           "when recording the run fails it should not fail the run",
+			run:            func(ctx context.Context) error { return nil },
+			historyErr:     errors.New("connection reset"),
+			expectedLocked: true,
+			expectedStatus: RunStatusSucceeded,
+		},
+		{
+			name:           "when another instance holds the job lock it should skip the run without recording it",
+			run:            func(ctx context.Context) error { return errors.New("must not run") },
+			lockErr:        errors.New("lock: not acquired"),
+			expectedLocked: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			locker := &fakeLocker{err: tt.lockErr, unlockErr: tt.unlockErr}
+			history := newFakeHistory()
+			history.err = tt.historyErr
+			s, err := New(&fakeLeader{}, locker, history, "replica-1")
+			require.NoError(t, err)
+
+			var requestID string
+			job := validJob()
+			job.Timeout = 20 * time.Millisecond
+			job.Run = func(ctx context.Context) error {
+				requestID = requestid.FromContext(ctx)
+				return tt.run(ctx)
+			}
+
+			// Act
+			s.runOnce(context.Background(), job)
+
+			// Assert
+			if !tt.expectedLocked {
+				assert.Empty(t, locker.locked)
+				assert.Empty(t, requestID)
+				assert.Len(t, history.runs, 0)
+				return
+			}
+
+			assert.Equal(t, []string{"wallet-reconciler"}, locker.locked)
+			assert.Equal(t, []string{"wallet-reconciler"}, locker.unlocked)
+			assert.NotEmpty(t, requestID, "each run gets its own request ID")
+			require.Len(t, history.runs, 1)
+			run := <-history.runs
+			assert.Equal(t, "wallet-reconciler", run.JobName)
+			assert.Equal(t, "replica-1", run.Holder)
+			assert.Equal(t, tt.expectedStatus, run.Status)
+			assert.Equal(t, tt.expectedRunError, run.Error)
+			assert.False(t, run.FinishedAt.Before(run.StartedAt))
+		})
+	}
+}
+
+func TestScheduler_Start(t *testing.T) {
+	t.Run("when this instance is not the leader it should not run jobs until it becomes leader", func(t *testing.T) {
+		// Arrange
+		leader := &fakeLeader{}
+		history := newFakeHistory()
+		s, err := New(leader, &fakeLocker{}, history, "replica-1")
+		require.NoError(t, err)
+
+		var runs atomic.Int32
+		require.NoError(t, s.Register(Job{
+			Name:     "wallet-reconciler",
+			Interval: 5 * time.Millisecond,
+			Timeout:  time.Second,
+			Run: func(ctx context.Context) error {
+				runs.Add(1)
+				return nil
+			},
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// Act
+		s.Start(ctx)
+
+		// Assert
+		time.Sleep(30 * time.Millisecond)
+		assert.Zero(t, runs.Load(), "followers skip every tick")
+
+		leader.leader.Store(true)
+		select {
+		case run := <-history.runs:
+			assert.Equal(t, RunStatusSucceeded, run.Status)
+		case <-time.After(time.Second):
+			t.Fatal("the leader did not run the job")
+		}
+
+		cancel()
+		s.Wait()
+	})
+
+	t.Run("when several jobs are registered it should run each on its own interval", func(t *testing.T) {
+		// Arrange
+		leader := &fakeLeader{}
+		leader.leader.Store(true)
+		history := newFakeHistory()
+		s, err := New(leader, &fakeLocker{}, history, "replica-1")
+		require.NoError(t, err)
+
+		for _, name := range []string{"confirm-retry", "wallet-reconciler"} {
+			require.NoError(t, s.Register(Job{
+				Name:     name,
+				Interval: 5 * time.Millisecond,
+				Timeout:  time.Second,
+				Run:      func(ctx context.Context) error { return nil },
+			}))
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// Act
+		s.Start(ctx)
+
+		// Assert
+		ran := map[string]bool{}
+		for len(ran) < 2 {
+			select {
+			case run := <-history.runs:
+				ran[run.JobName] = true
+			case <-time.After(time.Second):
+				t.Fatalf("jobs run: %v", ran)
+			}
+		}
+
+		cancel()
+		s.Wait()
+	})
+}
@@ -1,30 +1,73 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/nahuelsoma/event-driven-challenge-payments/cmd/app"
 	"github.com/nahuelsoma/event-driven-challenge-payments/config"
 	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/database"
+	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/logging"
 	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/messagebroker"
 	"github.com/nahuelsoma/event-driven-challenge-payments/infrastructure/restclient"
 )
 
 func main() {
-	// Configure slog to show DEBUG level logs
-	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelDebug,
-	})))
-
 	// Load configuration from environment variables
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("config: %v", err)
 	}
 
+	// The dlq subcommand writes its result to stdout, so its logs go to stderr
+	dlqCommand := len(os.Args) > 1 && os.Args[1] == "dlq"
+
+	// Any other subcommand selects the components to run, flags override the run configuration
+	var mode app.Mode
+	if !dlqCommand {
+		mode, err = app.ParseMode(os.Args[1:], &cfg.Run, os.Stderr)
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if err != nil {
+			log.Fatalf("main: %v", err)
+		}
+	}
+
+	logOutput := cfg.Logging.Output
+	if dlqCommand && logOutput == "stdout" {
+		logOutput = "stderr"
+	}
+
+	logWriter, closeLog, err := logging.Open(logOutput)
+	if err != nil {
+		log.Fatalf("main: %v", err)
+	}
+	defer closeLog()
+
+	redactKeys := cfg.Logging.RedactKeys
+	if cfg.Logging.RedactAmounts {
+		redactKeys = append(redactKeys, "amount")
+	}
+
+	logger, err := logging.New(logWriter, logging.Config{
+		Level:           cfg.Logging.Level,
+		Format:          cfg.Logging.Format,
+		RedactKeys:      redactKeys,
+		DebugSampleRate: cfg.Logging.DebugSampleRate,
+	})
+	if err != nil {
+		log.Fatalf("main: %v", err)
+	}
+	slog.SetDefault(logger)
+
 	// Create database connection
 	dbConn, err := database.NewPostgresConnection(cfg.Database.URL())
 	if err != nil {
@@ -43,20 +86,49 @@ func main() {
 		log.Fatalf("main: failed to create HTTP client: %v", err)
 	}
 
-	// Create RabbitMQ connection
-	messageBrokerConn, err := messagebroker.Connect(cfg.MessageBroker.URL())
+	// Create circuit breakers shared by the API, the consumer and the jobs
+	breakers, err := app.NewBreakers(cfg)
+	if err != nil {
+		log.Fatalf("main: failed to create circuit breakers: %v", err)
+	}
+
+	// Create message broker connection, RabbitMQ, an in-process broker for local runs or queue tables in the database
+	messageBrokerConn, err := connectMessageBroker(cfg.MessageBroker, dbConn)
 	if err != nil {
-		log.Fatalf("main: failed to create RabbitMQ connection: %v", err)
+		log.Fatalf("main: failed to create message broker connection: %v", err)
 	}
 	defer messageBrokerConn.Close()
 
-	// Start consumer first (runs in background goroutines)
-	if err := app.StartConsumer(dbConn, walletClient, messageBrokerConn, cfg.Exchange, cfg.QueueName); err != nil {
-		log.Fatalf("main: failed to start consumer: %v", err)
+	// Run the dead letter queue admin command instead of the service
+	if dlqCommand {
+		if err := app.RunDLQ(context.Background(), dbConn, messageBrokerConn, cfg, os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("dlq: %v", err)
+		}
+		return
 	}
 
-	// Start API server (blocks to keep the application running)
-	if err := app.StartAPI(dbConn, walletClient, messageBrokerConn, cfg.Exchange, cfg.QueueName); err != nil {
-		log.Fatalf("main: failed to start API: %v", err)
+	// Run the components of the mode until SIGINT or SIGTERM, then drain them
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := app.Run(ctx, mode, dbConn, walletClient, breakers, messageBrokerConn, cfg); err != nil {
+		log.Fatalf("main: %v", err)
+	}
+}
+
+// connectMessageBroker connects to the broker of the configured driver
+// The in-memory broker only reaches the consumers of this process, so it is meant for single binary local runs
+// The Postgres broker shares the connection pool of the database
+func connectMessageBroker(cfg config.MessageBrokerConfig, db *database.DB) (*messagebroker.Connection, error) {
+	switch cfg.Driver {
+	case config.BrokerDriverMemory:
+		return messagebroker.ConnectMemory(messagebroker.NewMemoryBroker())
+	case config.BrokerDriverPostgres:
+		return messagebroker.ConnectPostgres(db.Conn(), messagebroker.PostgresConfig{
+			PollInterval:      cfg.PollInterval,
+			VisibilityTimeout: cfg.VisibilityTimeout,
+			MaxDeliveries:     cfg.MaxDeliveries,
+		})
 	}
+	return messagebroker.Connect(cfg.URL())
 }
@@ -0,0 +1,6 @@
+-- Rollback: Drop Job Tables
+
+DROP INDEX IF EXISTS idx_job_runs_job_name_started_at;
+DROP TABLE IF EXISTS job_runs;
+
+DROP TABLE IF EXISTS leases;
@@ -0,0 +1,22 @@
+-- Migration: Create Job Tables (Leader Election + Run History)
+-- Scheduled jobs only run on the replica holding the scheduler lease
+
+-- LEASES (time-bound ownership, renewed by the holder)
+CREATE TABLE IF NOT EXISTS leases (
+    name            TEXT PRIMARY KEY,
+    holder          TEXT NOT NULL,
+    expires_at      TIMESTAMP NOT NULL
+);
+
+-- JOB RUNS (history of every scheduled job execution)
+CREATE TABLE IF NOT EXISTS job_runs (
+    id              TEXT PRIMARY KEY,
+    job_name        TEXT NOT NULL,
+    holder          TEXT NOT NULL,
+    status          TEXT NOT NULL,  -- succeeded, failed, timed_out
+    error           TEXT,
+    started_at      TIMESTAMP NOT NULL,
+    finished_at     TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_job_name_started_at ON job_runs(job_name, started_at DESC);
@@ -0,0 +1,7 @@
+-- Rollback: Drop Dead Letter Tables
+
+DROP INDEX IF EXISTS idx_dead_letter_audit_dead_letter_id;
+DROP TABLE IF EXISTS dead_letter_audit;
+
+DROP INDEX IF EXISTS idx_dead_letters_status_dead_lettered_at;
+DROP TABLE IF EXISTS dead_letters;
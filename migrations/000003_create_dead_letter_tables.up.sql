@@ -0,0 +1,30 @@
+-- Migration: Create Dead Letter Tables (DLQ Archive + Audit Trail)
+-- Dead-lettered messages are archived here so operators can inspect, replay or discard them
+
+-- DEAD LETTERS (one row per message that exhausted its attempts)
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id                  TEXT PRIMARY KEY,
+    queue               TEXT NOT NULL,
+    routing_key         TEXT NOT NULL,
+    body                TEXT NOT NULL,
+    headers             JSONB NOT NULL DEFAULT '{}',
+    failure_reason      TEXT NOT NULL,
+    attempts            INTEGER NOT NULL,
+    status              VARCHAR(20) NOT NULL DEFAULT 'pending',  -- pending, replayed, discarded
+    dead_lettered_at    TIMESTAMP NOT NULL,
+    updated_at          TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_dead_letters_status_dead_lettered_at ON dead_letters(status, dead_lettered_at);
+
+-- DEAD LETTER AUDIT (append-only trail of every action on a dead letter)
+CREATE TABLE IF NOT EXISTS dead_letter_audit (
+    id                  TEXT PRIMARY KEY,
+    dead_letter_id      TEXT NOT NULL REFERENCES dead_letters(id),
+    action              VARCHAR(20) NOT NULL,  -- archived, replayed, discarded
+    actor               TEXT NOT NULL,
+    reason              TEXT NOT NULL DEFAULT '',
+    created_at          TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_dead_letter_audit_dead_letter_id ON dead_letter_audit(dead_letter_id, created_at);
@@ -0,0 +1,6 @@
+-- Rollback: Drop Wallet Discrepancies Table
+
+DROP INDEX IF EXISTS idx_payments_updated_at_id;
+
+DROP INDEX IF EXISTS idx_wallet_discrepancies_last_seen_at;
+DROP TABLE IF EXISTS wallet_discrepancies;
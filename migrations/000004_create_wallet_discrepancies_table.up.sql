@@ -0,0 +1,18 @@
+-- Migration: Create Wallet Discrepancies Table (Wallet Reconciliation Report)
+-- Payments whose wallet operation disagrees in a way that cannot be repaired automatically are reported here
+
+-- WALLET DISCREPANCIES (one row per payment, refreshed on every run that still detects the mismatch)
+CREATE TABLE IF NOT EXISTS wallet_discrepancies (
+    payment_id          TEXT PRIMARY KEY REFERENCES payments(id),
+    user_id             TEXT NOT NULL,
+    amount              DECIMAL(15,2) NOT NULL,
+    payment_status      VARCHAR(20) NOT NULL,  -- pending, reserved, completed, failed
+    wallet_status       VARCHAR(20) NOT NULL,  -- reserved, confirmed, released, not_found
+    detected_at         TIMESTAMP NOT NULL,
+    last_seen_at        TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_discrepancies_last_seen_at ON wallet_discrepancies(last_seen_at);
+
+-- Candidate lookup pages through payments by their last update
+CREATE INDEX IF NOT EXISTS idx_payments_updated_at_id ON payments(updated_at, id);
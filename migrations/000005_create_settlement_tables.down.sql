@@ -0,0 +1,10 @@
+-- Rollback: Drop Settlement Tables
+
+DROP INDEX IF EXISTS idx_payments_gateway_ref;
+
+DROP INDEX IF EXISTS idx_settlement_items_gateway_ref;
+DROP INDEX IF EXISTS idx_settlement_items_run_id;
+DROP TABLE IF EXISTS settlement_items;
+
+DROP INDEX IF EXISTS idx_settlement_runs_imported_at;
+DROP TABLE IF EXISTS settlement_runs;
@@ -0,0 +1,37 @@
+-- Migration: Create Settlement Tables (Gateway Settlement Reconciliation)
+-- Every imported settlement file is a run, every row and every unsettled completed payment is an item of the run
+
+-- SETTLEMENT RUNS (one row per imported file, the checksum makes each file import only once)
+CREATE TABLE IF NOT EXISTS settlement_runs (
+    id                  TEXT PRIMARY KEY,
+    file_name           TEXT NOT NULL,
+    checksum            TEXT NOT NULL UNIQUE,
+    status              VARCHAR(20) NOT NULL,  -- completed, failed
+    error               TEXT NOT NULL DEFAULT '',
+    total_rows          INTEGER NOT NULL DEFAULT 0,
+    summary             JSONB NOT NULL DEFAULT '{}',  -- items per status
+    imported_at         TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_settlement_runs_imported_at ON settlement_runs(imported_at);
+
+-- SETTLEMENT ITEMS (match result of each row, plus completed payments missing from the reports)
+CREATE TABLE IF NOT EXISTS settlement_items (
+    seq                 BIGSERIAL,  -- keeps file order
+    id                  TEXT PRIMARY KEY,
+    run_id              TEXT NOT NULL REFERENCES settlement_runs(id),
+    gateway_ref         TEXT NOT NULL,
+    payment_id          TEXT NOT NULL DEFAULT '',  -- empty for unknown references
+    status              VARCHAR(20) NOT NULL,  -- matched, amount_mismatch, currency_mismatch, unexpected_status, duplicate, unknown_reference, missing
+    reported_amount     DECIMAL(15,2) NOT NULL DEFAULT 0,
+    reported_currency   TEXT NOT NULL DEFAULT '',
+    expected_amount     DECIMAL(15,2) NOT NULL DEFAULT 0,
+    expected_currency   TEXT NOT NULL DEFAULT '',
+    settled_at          TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_settlement_items_run_id ON settlement_items(run_id, seq);
+CREATE INDEX IF NOT EXISTS idx_settlement_items_gateway_ref ON settlement_items(gateway_ref);
+
+-- Settlement matching looks payments up by gateway reference
+CREATE INDEX IF NOT EXISTS idx_payments_gateway_ref ON payments(gateway_ref);
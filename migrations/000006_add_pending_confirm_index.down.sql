@@ -0,0 +1,3 @@
+-- Rollback: Drop Pending Confirm Index
+
+DROP INDEX IF EXISTS idx_payments_pending_confirm_updated_at;
@@ -0,0 +1,6 @@
+-- Migration: Add Pending Confirm Index (Confirm Retry)
+-- Payments charged by the gateway wait in pending_confirm until the wallet confirms the funds
+-- Status values: pending, reserved, pending_confirm, completed, failed
+
+-- Confirm retry lookup scans only the payments still waiting for the wallet, oldest first
+CREATE INDEX IF NOT EXISTS idx_payments_pending_confirm_updated_at ON payments(updated_at) WHERE status = 'pending_confirm';
@@ -0,0 +1,3 @@
+-- Rollback: Drop Idempotency Keys Table
+
+DROP TABLE IF EXISTS idempotency_keys;
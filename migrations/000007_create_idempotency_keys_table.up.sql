@@ -0,0 +1,12 @@
+-- Migration: Create Idempotency Keys Table (Request Fingerprinting)
+-- The first request made with each Idempotency-Key is stored with its response so retries replay it
+
+-- IDEMPOTENCY KEYS (one row per key, replaced once the key expires)
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key                 TEXT PRIMARY KEY,
+    request_hash        TEXT NOT NULL,     -- SHA-256 of user_id, amount and currency
+    response_code       INTEGER,           -- NULL while the first request is in progress
+    response_body       JSONB,
+    created_at          TIMESTAMP NOT NULL,
+    expires_at          TIMESTAMP NOT NULL
+);
@@ -0,0 +1,3 @@
+-- Rollback: Drop API Keys Table
+
+DROP TABLE IF EXISTS api_keys;
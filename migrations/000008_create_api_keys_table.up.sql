@@ -0,0 +1,13 @@
+-- Migration: Create API Keys Table (Authentication)
+-- Only the SHA-256 of each key is stored, the key itself is shown once to its owner
+
+-- API KEYS (one row per issued key, revoked keys are kept for auditing)
+CREATE TABLE IF NOT EXISTS api_keys (
+    id                  TEXT PRIMARY KEY,
+    name                TEXT NOT NULL,          -- Human readable label of the key
+    key_hash            TEXT NOT NULL UNIQUE,   -- SHA-256 hex digest of the key
+    subject             TEXT NOT NULL,          -- User ID the key acts as
+    scopes              TEXT[] NOT NULL DEFAULT '{}',
+    created_at          TIMESTAMP NOT NULL,
+    revoked_at          TIMESTAMP               -- NULL while the key is active
+);
@@ -0,0 +1,4 @@
+-- Rollback: Drop Rate Limit Buckets Table
+
+DROP INDEX IF EXISTS idx_rate_limit_buckets_full_at;
+DROP TABLE IF EXISTS rate_limit_buckets;
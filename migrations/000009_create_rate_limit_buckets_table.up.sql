@@ -0,0 +1,12 @@
+-- Migration: Create Rate Limit Buckets Table (Shared Token Buckets)
+-- Used by the postgres rate limit backend so every replica counts against the same buckets
+
+-- RATE LIMIT BUCKETS (one row per route and caller, deleted once full again)
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key                 TEXT PRIMARY KEY,           -- Route and caller identity, e.g. "POST /api/v1/payments|user:user-001"
+    tokens              DOUBLE PRECISION NOT NULL,  -- Tokens left at updated_at
+    updated_at          TIMESTAMP NOT NULL,
+    full_at             TIMESTAMP NOT NULL          -- When the bucket is full again and can be deleted
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_full_at ON rate_limit_buckets(full_at);
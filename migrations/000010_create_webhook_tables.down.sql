@@ -0,0 +1,12 @@
+-- Rollback: Drop Webhook Tables
+
+DROP TRIGGER IF EXISTS payment_events_enqueue_webhooks ON payment_events;
+DROP FUNCTION IF EXISTS enqueue_webhook_deliveries();
+
+DROP INDEX IF EXISTS idx_webhook_delivery_attempts_delivery_id;
+DROP TABLE IF EXISTS webhook_delivery_attempts;
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_due;
+DROP TABLE IF EXISTS webhook_deliveries;
+
+DROP TABLE IF EXISTS webhook_subscriptions;
@@ -0,0 +1,61 @@
+-- Migration: Create Webhook Tables (Outbound Payment Event Notifications)
+-- Every payment event is enqueued for the matching subscriptions by a trigger, so no writer has to know about webhooks
+
+-- WEBHOOK SUBSCRIPTIONS (one row per subscriber URL)
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id                  TEXT PRIMARY KEY,
+    url                 TEXT NOT NULL,
+    secret              TEXT NOT NULL,                  -- HMAC-SHA256 key of the signatures
+    event_types         TEXT[] NOT NULL DEFAULT '{}',   -- Event types delivered, empty delivers every event
+    active              BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at          TIMESTAMP NOT NULL,
+    updated_at          TIMESTAMP NOT NULL
+);
+
+-- WEBHOOK DELIVERIES (one row per event and subscription)
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id                  TEXT PRIMARY KEY,
+    subscription_id     TEXT NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id            TEXT NOT NULL REFERENCES payment_events(id),
+    status              VARCHAR(20) NOT NULL DEFAULT 'pending',  -- pending, succeeded, failed
+    attempts            INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at     TIMESTAMP NOT NULL,
+    last_error          TEXT NOT NULL DEFAULT '',
+    created_at          TIMESTAMP NOT NULL,
+    updated_at          TIMESTAMP NOT NULL,
+
+    UNIQUE(subscription_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+-- WEBHOOK DELIVERY ATTEMPTS (delivery log, one row per request sent)
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    id                  TEXT PRIMARY KEY,
+    delivery_id         TEXT NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    attempt             INTEGER NOT NULL,
+    status_code         INTEGER NOT NULL DEFAULT 0,     -- 0 if the request failed before a response
+    error               TEXT NOT NULL DEFAULT '',
+    duration_ms         BIGINT NOT NULL DEFAULT 0,
+    attempted_at        TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery_id ON webhook_delivery_attempts(delivery_id, attempt);
+
+-- Enqueues every new payment event for the active subscriptions it matches
+CREATE OR REPLACE FUNCTION enqueue_webhook_deliveries() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO webhook_deliveries (id, subscription_id, event_id, status, attempts, next_attempt_at, created_at, updated_at)
+    SELECT gen_random_uuid()::text, s.id, NEW.id, 'pending', 0, NOW(), NOW(), NOW()
+    FROM webhook_subscriptions s
+    WHERE s.active
+        AND (cardinality(s.event_types) = 0 OR NEW.event_type = ANY(s.event_types))
+    ON CONFLICT (subscription_id, event_id) DO NOTHING;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER payment_events_enqueue_webhooks
+    AFTER INSERT ON payment_events
+    FOR EACH ROW EXECUTE FUNCTION enqueue_webhook_deliveries();
@@ -0,0 +1,4 @@
+-- Rollback: Drop Payment Event Notify Trigger
+
+DROP TRIGGER IF EXISTS payment_events_notify ON payment_events;
+DROP FUNCTION IF EXISTS notify_payment_event();
@@ -0,0 +1,16 @@
+-- Migration: Create Payment Events Notify Trigger (Server-Sent Events Stream)
+-- Every payment event notifies the payment_events channel with its payment ID, so open streams wake up without polling
+-- Notifications are delivered on commit, so listeners only read events that are already visible
+
+-- Notifies the payment of every new payment event
+CREATE OR REPLACE FUNCTION notify_payment_event() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('payment_events', NEW.payment_id);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER payment_events_notify
+    AFTER INSERT ON payment_events
+    FOR EACH ROW EXECUTE FUNCTION notify_payment_event();
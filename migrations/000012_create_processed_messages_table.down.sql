@@ -0,0 +1,4 @@
+-- Rollback: Drop Processed Messages Table
+
+DROP INDEX IF EXISTS idx_processed_messages_processed_at;
+DROP TABLE IF EXISTS processed_messages;
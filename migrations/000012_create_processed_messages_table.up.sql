@@ -0,0 +1,13 @@
+-- Migration: Create Processed Messages Table (Consumer Deduplication)
+-- Each handler records the messages it processed in the same transaction as the change they caused,
+-- so consumers skip redelivered messages. Rows are pruned once redeliveries are no longer expected
+
+-- PROCESSED MESSAGES (one row per message and handler)
+CREATE TABLE IF NOT EXISTS processed_messages (
+    message_id          TEXT NOT NULL,              -- AMQP message ID set by the publisher
+    handler             TEXT NOT NULL,              -- Handler that processed the message, e.g. "processor"
+    processed_at        TIMESTAMP NOT NULL,
+    PRIMARY KEY (message_id, handler)
+);
+
+CREATE INDEX IF NOT EXISTS idx_processed_messages_processed_at ON processed_messages(processed_at);
@@ -0,0 +1,5 @@
+-- Rollback: Drop Event Outbox Table
+
+DROP TRIGGER IF EXISTS payment_events_enqueue_outbox ON payment_events;
+DROP FUNCTION IF EXISTS enqueue_event_publication();
+DROP TABLE IF EXISTS event_outbox;
@@ -0,0 +1,25 @@
+-- Migration: Create Event Outbox Table (Domain Events Published to the Exchange)
+-- Every payment event is enqueued by a trigger in the transaction that appended it, so no writer has to know
+-- about the exchange. The publisher job deletes the rows once their event is published
+
+-- EVENT OUTBOX (one row per event waiting to be published)
+CREATE TABLE IF NOT EXISTS event_outbox (
+    position            BIGSERIAL PRIMARY KEY,          -- Insert order, events are published in it
+    event_id            TEXT NOT NULL UNIQUE REFERENCES payment_events(id),
+    created_at          TIMESTAMP NOT NULL
+);
+
+-- Enqueues every new payment event for publishing
+CREATE OR REPLACE FUNCTION enqueue_event_publication() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO event_outbox (event_id, created_at)
+    VALUES (NEW.id, NOW())
+    ON CONFLICT (event_id) DO NOTHING;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER payment_events_enqueue_outbox
+    AFTER INSERT ON payment_events
+    FOR EACH ROW EXECUTE FUNCTION enqueue_event_publication();
@@ -0,0 +1,6 @@
+-- Rollback: Drop Broker Tables
+
+DROP TABLE IF EXISTS broker_messages;
+DROP TABLE IF EXISTS broker_bindings;
+DROP TABLE IF EXISTS broker_queues;
+DROP TABLE IF EXISTS broker_exchanges;
@@ -0,0 +1,43 @@
+-- Migration: Create Broker Tables (Postgres Message Broker Backend)
+-- Environments without RabbitMQ run the message broker on these tables with BROKER_DRIVER=postgres.
+-- Consumers claim visible messages with SELECT ... FOR UPDATE SKIP LOCKED and hide them for the visibility timeout,
+-- acked messages are deleted and the messages of a crashed consumer are delivered again once the timeout expires
+
+-- EXCHANGES (declared by the consumers, as in RabbitMQ)
+CREATE TABLE IF NOT EXISTS broker_exchanges (
+    name                    TEXT PRIMARY KEY,
+    kind                    TEXT NOT NULL                   -- topic, direct or fanout
+);
+
+-- QUEUES
+CREATE TABLE IF NOT EXISTS broker_queues (
+    name                    TEXT PRIMARY KEY,
+    dead_letter_exchange    TEXT,                           -- Exchange rejected messages are moved to, NULL deletes them
+    dead_letter_routing_key TEXT,                           -- Routing key of rejected messages, NULL keeps the original one
+    created_at              TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- BINDINGS (a queue receives the messages whose routing key matches one of its binding keys)
+CREATE TABLE IF NOT EXISTS broker_bindings (
+    exchange                TEXT NOT NULL REFERENCES broker_exchanges(name) ON DELETE CASCADE,
+    queue                   TEXT NOT NULL REFERENCES broker_queues(name) ON DELETE CASCADE,
+    binding_key             TEXT NOT NULL,                  -- Routing key, or topic pattern with * and #
+    PRIMARY KEY (exchange, queue, binding_key)
+);
+
+-- MESSAGES (one row per queue the message was routed to)
+CREATE TABLE IF NOT EXISTS broker_messages (
+    id                      BIGSERIAL PRIMARY KEY,          -- Delivery order within a queue
+    queue                   TEXT NOT NULL REFERENCES broker_queues(name) ON DELETE CASCADE,
+    exchange                TEXT NOT NULL,                  -- Exchange the message was published to, empty for the default one
+    routing_key             TEXT NOT NULL,
+    message_id              TEXT NOT NULL DEFAULT '',       -- Message ID set by the publisher
+    content_type            TEXT NOT NULL DEFAULT '',
+    headers                 JSONB NOT NULL DEFAULT '{}',    -- Headers with their AMQP types
+    body                    BYTEA NOT NULL,
+    deliveries              INT NOT NULL DEFAULT 0,         -- Times the message was claimed, above 1 it is a redelivery
+    visible_at              TIMESTAMP NOT NULL DEFAULT NOW(), -- Consumers only claim messages visible since then
+    created_at              TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_broker_messages_queue_visible ON broker_messages(queue, visible_at, id);
@@ -0,0 +1,3 @@
+-- Rollback: Remove Idempotency Key Lease
+
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS locked_until;
@@ -0,0 +1,11 @@
+-- Migration: Add Idempotency Key Lease
+-- An in progress key is held until locked_until, so a key left by a request that crashed can be reclaimed
+-- before it expires. Keys in progress during the migration get a short lease from the time they started
+
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
+
+UPDATE idempotency_keys
+SET locked_until = created_at + INTERVAL '1 minute'
+WHERE locked_until IS NULL;
+
+ALTER TABLE idempotency_keys ALTER COLUMN locked_until SET NOT NULL;
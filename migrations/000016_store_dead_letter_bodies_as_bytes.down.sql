@@ -0,0 +1,7 @@
+-- Rollback: Store Dead Letter Bodies As Bytes
+-- Fails if a dead letter body is not valid UTF-8, discard those dead letters first
+
+ALTER TABLE dead_letters DROP COLUMN IF EXISTS message_id;
+ALTER TABLE dead_letters DROP COLUMN IF EXISTS content_type;
+
+ALTER TABLE dead_letters ALTER COLUMN body TYPE TEXT USING convert_from(body, 'UTF8');
@@ -0,0 +1,9 @@
+-- Migration: Store Dead Letter Bodies As Bytes
+-- Bodies are archived as published, so protobuf payloads with NUL or non UTF-8 bytes fit, and replayed
+-- with their original content type and message ID. Dead letters archived before keep an empty content type
+-- and message ID and are replayed as JSON with a new ID, as they were until now
+
+ALTER TABLE dead_letters ALTER COLUMN body TYPE BYTEA USING convert_to(body, 'UTF8');
+
+ALTER TABLE dead_letters ADD COLUMN IF NOT EXISTS content_type TEXT NOT NULL DEFAULT '';
+ALTER TABLE dead_letters ADD COLUMN IF NOT EXISTS message_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+// Package paymentsv1 holds the protobuf types of the payment messages, generated from payment.proto
+package paymentsv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative payments/v1/payment.proto